@@ -204,6 +204,27 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 			rejectedTxs = append(rejectedTxs, &rejectedTx{i, err.Error()})
 			continue
 		}
+		if tx.Type() == types.Rip7560Type {
+			if !chainConfig.IsRIP7560(vmContext.BlockNumber, vmContext.Time) {
+				errMsg := "RIP-7560 tx used before the RIP-7560 fork"
+				log.Warn("rejected tx", "index", i, "hash", tx.Hash(), "error", errMsg)
+				rejectedTxs = append(rejectedTxs, &rejectedTx{i, errMsg})
+				continue
+			}
+			// Tracing is not supported for the RIP-7560 frames
+			rip7560Config := vmConfig
+			rip7560Config.Tracer = nil
+			receipt, err := pre.applyRip7560Transaction(tx, txIndex, pre.rip7560Header(vmContext), statedb, chainConfig, rip7560Config, gaspool, &gasUsed)
+			if err != nil {
+				log.Info("rejected tx", "index", i, "hash", tx.Hash(), "error", err)
+				rejectedTxs = append(rejectedTxs, &rejectedTx{i, err.Error()})
+				continue
+			}
+			includedTxs = append(includedTxs, tx)
+			receipts = append(receipts, receipt)
+			txIndex++
+			continue
+		}
 		if tx.Type() == types.BlobTxType && vmContext.BlobBaseFee == nil {
 			errMsg := "blob tx used but field env.ExcessBlobGas missing"
 			log.Warn("rejected tx", "index", i, "hash", tx.Hash(), "error", errMsg)
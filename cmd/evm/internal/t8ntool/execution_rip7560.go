@@ -0,0 +1,83 @@
+package t8ntool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+)
+
+// rip7560ChainContext provides the RIP-7560 transaction processing with the headers of
+// the block hashes supplied in the environment, so BLOCKHASH resolves as it does for
+// the other transaction types.
+type rip7560ChainContext struct {
+	env *stEnv
+}
+
+func (c *rip7560ChainContext) Engine() consensus.Engine { return nil }
+
+func (c *rip7560ChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if known, ok := c.env.BlockHashes[math.HexOrDecimal64(number)]; !ok || known != hash {
+		return nil
+	}
+	header := &types.Header{Number: new(big.Int).SetUint64(number)}
+	if number > 0 {
+		header.ParentHash = c.env.BlockHashes[math.HexOrDecimal64(number-1)]
+	}
+	return header
+}
+
+// rip7560Header assembles the header of the block being executed from the environment.
+func (pre *Prestate) rip7560Header(vmContext vm.BlockContext) *types.Header {
+	header := &types.Header{
+		Coinbase:         pre.Env.Coinbase,
+		Difficulty:       new(big.Int),
+		Number:           new(big.Int).SetUint64(pre.Env.Number),
+		GasLimit:         pre.Env.GasLimit,
+		Time:             pre.Env.Timestamp,
+		BaseFee:          vmContext.BaseFee,
+		ExcessBlobGas:    pre.Env.ExcessBlobGas,
+		ParentBeaconRoot: pre.Env.ParentBeaconBlockRoot,
+	}
+	if pre.Env.Difficulty != nil {
+		header.Difficulty.Set(pre.Env.Difficulty)
+	}
+	if vmContext.Random != nil {
+		header.MixDigest = *vmContext.Random
+	}
+	if pre.Env.Number > 0 {
+		header.ParentHash = pre.Env.BlockHashes[math.HexOrDecimal64(pre.Env.Number-1)]
+	}
+	return header
+}
+
+// applyRip7560Transaction executes the validation and execution phases of an RIP-7560
+// transaction at the given index of the block. Transactions failing validation leave
+// the state and the gas pool untouched.
+func (pre *Prestate) applyRip7560Transaction(tx *types.Transaction, txIndex int, header *types.Header, statedb *state.StateDB,
+	chainConfig *params.ChainConfig, vmConfig vm.Config, gaspool *core.GasPool, gasUsed *uint64) (*types.Receipt, error) {
+	var (
+		snapshot = statedb.Snapshot()
+		prevGas  = gaspool.Gas()
+		prevUsed = *gasUsed
+	)
+	// The transaction is placed at its index in the batch, so that the transaction
+	// context of the logs matches its position in the block
+	txs := make([]*types.Transaction, txIndex+1)
+	txs[txIndex] = tx
+
+	_, receipts, _, _, err := core.HandleRip7560Transactions(txs, txIndex, statedb, &pre.Env.Coinbase, header, gaspool,
+		chainConfig, &rip7560ChainContext{env: &pre.Env}, vmConfig, false, gasUsed)
+	if err != nil {
+		statedb.RevertToSnapshot(snapshot)
+		gaspool.SetGas(prevGas)
+		*gasUsed = prevUsed
+		return nil, err
+	}
+	return receipts[0], nil
+}
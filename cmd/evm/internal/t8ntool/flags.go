@@ -149,6 +149,10 @@ var (
 			strings.Join(vm.ActivateableEips(), ", ")),
 		Value: "GrayGlacier",
 	}
+	Rip7560ReferenceFlag = &cli.StringFlag{
+		Name:  "rip7560.reference",
+		Usage: "Path of the t8n-compatible binary of a reference RIP-7560 implementation to diff the fixtures against",
+	}
 	VerbosityFlag = &cli.IntFlag{
 		Name:  "verbosity",
 		Usage: "sets the verbosity level",
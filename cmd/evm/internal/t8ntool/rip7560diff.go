@@ -0,0 +1,240 @@
+package t8ntool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// Rip7560Reference executes the transactions of a fixture with an independent
+// implementation of RIP-7560, returning its execution result and post-state.
+type Rip7560Reference func(fixture *Rip7560Fixture) (*ExecutionResult, Alloc, error)
+
+// NewRip7560T8nReference returns a reference running the fixtures through the state
+// transition tool of another client, invoked as `<binary> t8n` with the geth t8n flags.
+func NewRip7560T8nReference(binary string) Rip7560Reference {
+	return func(fixture *Rip7560Fixture) (*ExecutionResult, Alloc, error) {
+		dir, err := os.MkdirTemp("", "rip7560-reference")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		// The withdrawals are required by the tool past Shanghai, but are omitted from
+		// the JSON encoding of the environment when empty
+		env, err := json.Marshal(fixture.Env)
+		if err != nil {
+			return nil, nil, err
+		}
+		var envFields map[string]json.RawMessage
+		if err := json.Unmarshal(env, &envFields); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := envFields["withdrawals"]; !ok {
+			envFields["withdrawals"] = json.RawMessage("[]")
+		}
+		// The transactions are passed RLP encoded, that being their canonical form
+		txs, err := rlp.EncodeToBytes(fixture.Txs)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, obj := range map[string]interface{}{
+			"alloc.json": fixture.Pre,
+			"env.json":   envFields,
+			"txs.rlp":    hexutil.Bytes(txs),
+		} {
+			if err := saveFile(dir, name, obj); err != nil {
+				return nil, nil, err
+			}
+		}
+		cmd := exec.Command(binary, "t8n",
+			"--"+InputAllocFlag.Name, filepath.Join(dir, "alloc.json"),
+			"--"+InputEnvFlag.Name, filepath.Join(dir, "env.json"),
+			"--"+InputTxsFlag.Name, filepath.Join(dir, "txs.rlp"),
+			"--"+ForknameFlag.Name, fixture.Fork,
+			"--"+ChainIDFlag.Name, fixture.Config.ChainID.String(),
+			"--"+RewardFlag.Name, "-1",
+			"--"+OutputBasedir.Name, filepath.Join(dir, "out"),
+			"--"+OutputResultFlag.Name, "result.json",
+			"--"+OutputAllocFlag.Name, "alloc.json",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, nil, fmt.Errorf("reference t8n failed: %v\n%s", err, out)
+		}
+		var (
+			result = new(ExecutionResult)
+			post   = make(Alloc)
+		)
+		if err := loadJSONFile(filepath.Join(dir, "out", "result.json"), result); err != nil {
+			return nil, nil, err
+		}
+		if err := loadJSONFile(filepath.Join(dir, "out", "alloc.json"), &post); err != nil {
+			return nil, nil, err
+		}
+		return result, post, nil
+	}
+}
+
+func loadJSONFile(path string, obj interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s: %v", path, err))
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshalling %s: %v", path, err))
+	}
+	return nil
+}
+
+// DiffRip7560Fixture runs the transactions of a fixture through the reference and
+// reports every difference in the inclusion, status, gas usage and logs of the
+// transactions and in the resulting state. No differences are reported if the
+// reference agrees with the fixture.
+func DiffRip7560Fixture(fixture *Rip7560Fixture, reference Rip7560Reference) ([]string, error) {
+	result, post, err := reference(fixture)
+	if err != nil {
+		return nil, err
+	}
+	return diffRip7560Results(fixture.Result, fixture.Post, result, post), nil
+}
+
+// diffRip7560Results compares the execution result and post-state of a fixture (want)
+// to the ones produced by the reference (have).
+func diffRip7560Results(want *ExecutionResult, wantPost Alloc, have *ExecutionResult, havePost Alloc) []string {
+	var diffs []string
+	report := func(format string, args ...interface{}) {
+		diffs = append(diffs, fmt.Sprintf(format, args...))
+	}
+	// Transactions rejected by one implementation are usually the root cause of all
+	// other differences, so report them first
+	rejected := func(result *ExecutionResult) map[int]string {
+		indices := make(map[int]string)
+		for _, tx := range result.Rejected {
+			indices[tx.Index] = tx.Err
+		}
+		return indices
+	}
+	wantRejected, haveRejected := rejected(want), rejected(have)
+	for index, err := range wantRejected {
+		if _, ok := haveRejected[index]; !ok {
+			report("tx %d: rejected with %q, accepted by reference", index, err)
+		}
+	}
+	for index, err := range haveRejected {
+		if _, ok := wantRejected[index]; !ok {
+			report("tx %d: accepted, rejected by reference with %q", index, err)
+		}
+	}
+	haveReceipts := make(map[common.Hash]*types.Receipt)
+	for _, receipt := range have.Receipts {
+		haveReceipts[receipt.TxHash] = receipt
+	}
+	for _, wantReceipt := range want.Receipts {
+		haveReceipt, ok := haveReceipts[wantReceipt.TxHash]
+		if !ok {
+			continue // reported as a rejection above
+		}
+		tx := wantReceipt.TxHash
+		if haveReceipt.Status != wantReceipt.Status {
+			report("tx %x: status %d, reference %d", tx, wantReceipt.Status, haveReceipt.Status)
+		}
+		if haveReceipt.GasUsed != wantReceipt.GasUsed {
+			report("tx %x: gas used %d, reference %d", tx, wantReceipt.GasUsed, haveReceipt.GasUsed)
+		}
+		if haveReceipt.CumulativeGasUsed != wantReceipt.CumulativeGasUsed {
+			report("tx %x: cumulative gas used %d, reference %d", tx, wantReceipt.CumulativeGasUsed, haveReceipt.CumulativeGasUsed)
+		}
+		if len(haveReceipt.Logs) != len(wantReceipt.Logs) {
+			report("tx %x: %d logs, reference %d", tx, len(wantReceipt.Logs), len(haveReceipt.Logs))
+			continue
+		}
+		for i, wantLog := range wantReceipt.Logs {
+			haveLog := haveReceipt.Logs[i]
+			if haveLog.Address != wantLog.Address || !equalTopics(haveLog.Topics, wantLog.Topics) || !bytes.Equal(haveLog.Data, wantLog.Data) {
+				report("tx %x: log %d differs from reference", tx, i)
+			}
+		}
+	}
+	if have.GasUsed != want.GasUsed {
+		report("block gas used %d, reference %d", want.GasUsed, have.GasUsed)
+	}
+	if have.ReceiptRoot != want.ReceiptRoot {
+		report("receipts root %x, reference %x", want.ReceiptRoot, have.ReceiptRoot)
+	}
+	if have.StateRoot != want.StateRoot {
+		report("state root %x, reference %x", want.StateRoot, have.StateRoot)
+	}
+	// Pinpoint the state changes the implementations disagree on
+	addrs := make(map[common.Address]struct{})
+	for addr := range wantPost {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range havePost {
+		addrs[addr] = struct{}{}
+	}
+	sorted := make([]common.Address, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	for _, addr := range sorted {
+		wantAcc, wantOk := wantPost[addr]
+		haveAcc, haveOk := havePost[addr]
+		switch {
+		case !haveOk:
+			report("account %x: missing in reference post-state", addr)
+			continue
+		case !wantOk:
+			report("account %x: only present in reference post-state", addr)
+			continue
+		}
+		if bigString(wantAcc.Balance) != bigString(haveAcc.Balance) {
+			report("account %x: balance %v, reference %v", addr, bigString(wantAcc.Balance), bigString(haveAcc.Balance))
+		}
+		if wantAcc.Nonce != haveAcc.Nonce {
+			report("account %x: nonce %d, reference %d", addr, wantAcc.Nonce, haveAcc.Nonce)
+		}
+		if !bytes.Equal(wantAcc.Code, haveAcc.Code) {
+			report("account %x: code differs from reference", addr)
+		}
+		for slot, value := range wantAcc.Storage {
+			if haveAcc.Storage[slot] != value {
+				report("account %x: slot %x is %x, reference %x", addr, slot, value, haveAcc.Storage[slot])
+			}
+		}
+		for slot, value := range haveAcc.Storage {
+			if _, ok := wantAcc.Storage[slot]; !ok {
+				report("account %x: slot %x is empty, reference %x", addr, slot, value)
+			}
+		}
+	}
+	return diffs
+}
+
+func equalTopics(a, b []common.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func bigString(x *big.Int) string {
+	if x == nil {
+		return "0"
+	}
+	return x.String()
+}
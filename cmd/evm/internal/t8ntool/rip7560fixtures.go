@@ -0,0 +1,129 @@
+package t8ntool
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/tests/rip7560"
+	"github.com/urfave/cli/v2"
+	"io"
+	"math/big"
+)
+
+// rip7560FixtureFork is the fork the RIP-7560 consensus fixtures are generated for.
+const rip7560FixtureFork = "CancunRIP7560"
+
+// Rip7560Fixture is a cross-client consensus test vector: the transactions of a block
+// applied to a pre-state along with the expected post-state and execution result,
+// including the receipts and the system logs injected by RIP-7560.
+type Rip7560Fixture struct {
+	Fork   string              `json:"fork"`
+	Config *params.ChainConfig `json:"config"`
+	Env    stEnv               `json:"env"`
+	Pre    types.GenesisAlloc  `json:"pre"`
+	Txs    types.Transactions  `json:"txs"`
+	TxsRlp hexutil.Bytes       `json:"txsRlp"`
+	Post   Alloc               `json:"post"`
+	Result *ExecutionResult    `json:"result"`
+}
+
+// GenerateRip7560Fixtures executes the RIP-7560 consensus scenarios and writes the
+// fixture of each of them to the output directory. If a reference implementation is
+// given, the scenarios are also run through it and any difference is reported.
+func GenerateRip7560Fixtures(ctx *cli.Context) error {
+	baseDir, err := createBasedir(ctx)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+	}
+	var (
+		reference Rip7560Reference
+		failed    int
+	)
+	if ctx.IsSet(Rip7560ReferenceFlag.Name) {
+		reference = NewRip7560T8nReference(ctx.String(Rip7560ReferenceFlag.Name))
+	}
+	scenarios := rip7560.Scenarios()
+	for _, scenario := range scenarios {
+		fixture, err := MakeRip7560Fixture(scenario)
+		if err != nil {
+			return err
+		}
+		if err := saveFile(baseDir, scenario.Name+".json", fixture); err != nil {
+			return err
+		}
+		if reference == nil {
+			continue
+		}
+		diffs, err := DiffRip7560Fixture(fixture, reference)
+		if err != nil {
+			return NewError(ErrorEVM, fmt.Errorf("scenario %s: %v", scenario.Name, err))
+		}
+		for _, diff := range diffs {
+			fmt.Fprintf(ctx.App.ErrWriter, "%s: %s\n", scenario.Name, diff)
+		}
+		if len(diffs) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return NewError(ErrorEVM, fmt.Errorf("%d of %d scenarios differ from the reference", failed, len(scenarios)))
+	}
+	return nil
+}
+
+// MakeRip7560Fixture executes the transactions of a scenario on top of its pre-state.
+func MakeRip7560Fixture(scenario *rip7560.Scenario) (*Rip7560Fixture, error) {
+	chainConfig, _, err := tests.GetChainConfig(rip7560FixtureFork)
+	if err != nil {
+		return nil, NewError(ErrorConfig, err)
+	}
+	var (
+		excessBlobGas uint64
+		beaconRoot    common.Hash
+		env           = stEnv{
+			Coinbase:              common.HexToAddress("0xc014ba5e"),
+			Random:                new(big.Int),
+			GasLimit:              30_000_000,
+			Number:                1,
+			Timestamp:             1000,
+			BlockHashes:           map[math.HexOrDecimal64]common.Hash{0: {0x01}},
+			Withdrawals:           []*types.Withdrawal{},
+			BaseFee:               big.NewInt(7),
+			ExcessBlobGas:         &excessBlobGas,
+			ParentBeaconBlockRoot: &beaconRoot,
+		}
+		txs = make(types.Transactions, len(scenario.Txs))
+	)
+	for i, aatx := range scenario.Txs {
+		inner := types.NewTx(aatx).Rip7560TransactionData()
+		inner.ChainID = chainConfig.ChainID
+		txs[i] = types.NewTx(inner)
+	}
+	// With the gas accounting assertions enabled, transactions violating them are
+	// rejected instead of making it into the fixtures
+	prestate := &Prestate{Env: env, Pre: scenario.Pre}
+	statedb, result, body, err := prestate.Apply(vm.Config{Rip7560GasInvariants: true}, chainConfig, newSliceTxIterator(txs), -1, func(int, common.Hash) (*tracers.Tracer, io.WriteCloser, error) {
+		return nil, nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", scenario.Name, err)
+	}
+	post := make(Alloc)
+	statedb.DumpToCollector(post, nil)
+	return &Rip7560Fixture{
+		Fork:   rip7560FixtureFork,
+		Config: chainConfig,
+		Env:    env,
+		Pre:    scenario.Pre,
+		Txs:    txs,
+		TxsRlp: body,
+		Post:   post,
+		Result: result,
+	}, nil
+}
@@ -194,6 +194,16 @@ var blockBuilderCommand = &cli.Command{
 	},
 }
 
+var rip7560FixturesCommand = &cli.Command{
+	Name:   "rip7560-fixtures",
+	Usage:  "Generates cross-client RIP-7560 consensus fixtures",
+	Action: t8ntool.GenerateRip7560Fixtures,
+	Flags: []cli.Flag{
+		t8ntool.OutputBasedir,
+		t8ntool.Rip7560ReferenceFlag,
+	},
+}
+
 // vmFlags contains flags related to running the EVM.
 var vmFlags = []cli.Flag{
 	CodeFlag,
@@ -235,6 +245,7 @@ func init() {
 		stateTransitionCommand,
 		transactionCommand,
 		blockBuilderCommand,
+		rip7560FixturesCommand,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		flags.MigrateGlobalFlags(ctx)
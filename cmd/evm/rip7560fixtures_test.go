@@ -0,0 +1,140 @@
+package main
+
+import (
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/t8ntool"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/tests/rip7560"
+	"os"
+	"testing"
+)
+
+func TestRip7560Fixtures(t *testing.T) {
+	// The transaction statuses expected in each scenario, -1 marking a rejected transaction
+	expected := map[string][]int{
+		"validation_ok":             {1},
+		"validation_ok_no_tip":      {1},
+		"validation_out_of_gas":     {-1},
+		"validation_no_balance":     {-1},
+		"validation_nonce_too_high": {-1},
+		"validation_account_revert": {-1},
+		"validation_no_callback":    {-1},
+		"validation_expired":        {-1},
+		"execution_revert":          {0},
+		"deployer_ok":               {1},
+		"deployer_revert":           {-1},
+		"paymaster_ok":              {1},
+		"paymaster_revert":          {-1},
+		"multiple_transactions":     {1, 0, 1},
+	}
+	scenarios := rip7560.Scenarios()
+	if len(scenarios) != len(expected) {
+		t.Fatalf("scenario count mismatch: have %d, want %d", len(scenarios), len(expected))
+	}
+	for _, scenario := range scenarios {
+		want, ok := expected[scenario.Name]
+		if !ok {
+			t.Errorf("unexpected scenario %s", scenario.Name)
+			continue
+		}
+		fixture, err := t8ntool.MakeRip7560Fixture(scenario)
+		if err != nil {
+			t.Fatalf("scenario %s: failed to generate fixture: %v", scenario.Name, err)
+		}
+		var (
+			receipts = fixture.Result.Receipts
+			rejected = fixture.Result.Rejected
+		)
+		for i, status := range want {
+			if status < 0 {
+				if len(rejected) == 0 || rejected[0].Index != i {
+					t.Errorf("scenario %s: transaction %d not rejected", scenario.Name, i)
+				}
+				continue
+			}
+			if len(receipts) == 0 {
+				t.Errorf("scenario %s: transaction %d not included", scenario.Name, i)
+				continue
+			}
+			if receipts[0].Type != types.Rip7560Type || receipts[0].Status != uint64(status) {
+				t.Errorf("scenario %s: transaction %d status mismatch: have %d, want %d", scenario.Name, i, receipts[0].Status, status)
+			}
+			if len(receipts[0].Logs) == 0 {
+				t.Errorf("scenario %s: transaction %d is missing the system logs", scenario.Name, i)
+			}
+			receipts = receipts[1:]
+		}
+		// Fixtures must be reproducible for other clients to compare against
+		again, err := t8ntool.MakeRip7560Fixture(scenario)
+		if err != nil {
+			t.Fatalf("scenario %s: failed to regenerate fixture: %v", scenario.Name, err)
+		}
+		if again.Result.StateRoot != fixture.Result.StateRoot || again.Result.ReceiptRoot != fixture.Result.ReceiptRoot {
+			t.Errorf("scenario %s: fixture not reproducible", scenario.Name)
+		}
+	}
+}
+
+// TestRip7560Differential diffs the RIP-7560 scenarios against a reference implementation.
+// The t8n binary of the reference is taken from the RIP7560_REFERENCE environment
+// variable, falling back to a fresh in-process execution of the scenarios.
+func TestRip7560Differential(t *testing.T) {
+	for _, scenario := range rip7560.Scenarios() {
+		fixture, err := t8ntool.MakeRip7560Fixture(scenario)
+		if err != nil {
+			t.Fatalf("scenario %s: failed to generate fixture: %v", scenario.Name, err)
+		}
+		reference := func(*t8ntool.Rip7560Fixture) (*t8ntool.ExecutionResult, t8ntool.Alloc, error) {
+			fixture, err := t8ntool.MakeRip7560Fixture(scenario)
+			if err != nil {
+				return nil, nil, err
+			}
+			return fixture.Result, fixture.Post, nil
+		}
+		if binary := os.Getenv("RIP7560_REFERENCE"); binary != "" {
+			reference = t8ntool.NewRip7560T8nReference(binary)
+		}
+		diffs, err := t8ntool.DiffRip7560Fixture(fixture, reference)
+		if err != nil {
+			t.Fatalf("scenario %s: failed to run reference: %v", scenario.Name, err)
+		}
+		for _, diff := range diffs {
+			t.Errorf("scenario %s: %s", scenario.Name, diff)
+		}
+	}
+}
+
+func TestRip7560DifferentialDetectsDrift(t *testing.T) {
+	var scenario *rip7560.Scenario
+	for _, s := range rip7560.Scenarios() {
+		if s.Name == "multiple_transactions" {
+			scenario = s
+		}
+	}
+	fixture, err := t8ntool.MakeRip7560Fixture(scenario)
+	if err != nil {
+		t.Fatalf("failed to generate fixture: %v", err)
+	}
+	// A reference charging a different amount of gas for the first transaction
+	reference := func(*t8ntool.Rip7560Fixture) (*t8ntool.ExecutionResult, t8ntool.Alloc, error) {
+		drifted, err := t8ntool.MakeRip7560Fixture(scenario)
+		if err != nil {
+			return nil, nil, err
+		}
+		drifted.Result.Receipts[0].GasUsed++
+		drifted.Result.StateRoot = common.Hash{}
+		for addr, account := range drifted.Post {
+			account.Nonce++
+			drifted.Post[addr] = account
+			break
+		}
+		return drifted.Result, drifted.Post, nil
+	}
+	diffs, err := t8ntool.DiffRip7560Fixture(fixture, reference)
+	if err != nil {
+		t.Fatalf("failed to run reference: %v", err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("drift not detected: have %d differences, want 3: %v", len(diffs), diffs)
+	}
+}
@@ -558,6 +558,12 @@ var (
 		Usage:    "0x prefixed public address for the pending block producer (not used for actual block production)",
 		Category: flags.MinerCategory,
 	}
+	MinerRip7560BundlesFlag = &cli.StringFlag{
+		Name:     "miner.rip7560bundles",
+		Usage:    "Inclusion mode of RIP-7560 bundles with invalid transactions (skip, atomic or partial)",
+		Value:    string(ethconfig.Defaults.Miner.Rip7560Bundles),
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -1717,6 +1723,12 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(RollupComputePendingBlock.Name) {
 		cfg.RollupComputePendingBlock = ctx.Bool(RollupComputePendingBlock.Name)
 	}
+	if ctx.IsSet(MinerRip7560BundlesFlag.Name) {
+		cfg.Rip7560Bundles = miner.Rip7560BundleMode(ctx.String(MinerRip7560BundlesFlag.Name))
+		if !cfg.Rip7560Bundles.IsValid() {
+			Fatalf("Invalid choice for --%s '%s', allowed 'skip', 'atomic' or 'partial'", MinerRip7560BundlesFlag.Name, cfg.Rip7560Bundles)
+		}
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
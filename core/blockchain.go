@@ -102,6 +102,8 @@ const (
 	receiptsCacheLimit = 32
 	txLookupCacheLimit = 1024
 
+	rip7560ValidationCacheLimit = 4096
+
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	//
 	// Changelog:
@@ -262,6 +264,7 @@ type BlockChain struct {
 
 	// note: added to assist debugging in case of a failed validation after bundler performed second validation
 	rip7560TransactionDebugInfos []*types.Rip7560TransactionDebugInfo
+	rip7560ValidationCache       *Rip7560ValidationCache // Validations run at pool admission, reused by block building
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -309,6 +312,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		engine:        engine,
 		vmConfig:      vmConfig,
 		logger:        vmConfig.Tracer,
+
+		rip7560ValidationCache: NewRip7560ValidationCache(rip7560ValidationCacheLimit),
 	}
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
@@ -1060,6 +1065,7 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
 	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	rawdb.WriteRip7712NonceKeysByBlock(batch, block)
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
 
 	// Flush the whole batch into the disk, exit the node if failed
@@ -2300,6 +2306,16 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 	// reads should be blocked until the mutation is complete.
 	bc.txLookupLock.Lock()
 
+	// Delete the RIP-7712 nonce keys of the old chain before indexing the new one,
+	// as a block of both may use the same nonce key of a sender at the same number.
+	nonceKeysBatch := bc.db.NewBatch()
+	for _, block := range oldChain {
+		rawdb.DeleteRip7712NonceKeysByBlock(nonceKeysBatch, block)
+	}
+	if err := nonceKeysBatch.Write(); err != nil {
+		log.Crit("Failed to delete RIP-7712 nonce keys", "err", err)
+	}
+
 	// Insert the new chain segment in incremental order, from the old
 	// to the new. The new chain head (newChain[0]) is not inserted here,
 	// as it will be handled separately outside of this function
@@ -2580,3 +2596,9 @@ func (bc *BlockChain) SetRip7560TransactionDebugInfo(infos []*types.Rip7560Trans
 	}
 	bc.rip7560TransactionDebugInfos = append(bc.rip7560TransactionDebugInfos, infos...)
 }
+
+// Rip7560ValidationCache returns the cache of the RIP-7560 validations run by the
+// transaction pool, which block building reuses.
+func (bc *BlockChain) Rip7560ValidationCache() *Rip7560ValidationCache {
+	return bc.rip7560ValidationCache
+}
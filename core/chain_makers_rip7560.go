@@ -0,0 +1,30 @@
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// AddRip7560Tx adds an RIP-7560 transaction to the generated block, running its
+// validation and execution phases as block processing does. If no coinbase has
+// been set, the block's coinbase is set to the zero address.
+//
+// AddRip7560Tx panics if the transaction fails validation.
+func (b *BlockGen) AddRip7560Tx(tx *types.Transaction) {
+	if b.gasPool == nil {
+		b.SetCoinbase(common.Address{})
+	}
+	// The transaction is placed at its index in the batch, so that the transaction
+	// context of the logs matches its position in the block
+	txs := make([]*types.Transaction, len(b.txs)+1)
+	txs[len(b.txs)] = tx
+
+	_, receipts, _, _, err := HandleRip7560Transactions(txs, len(b.txs), b.statedb, &b.header.Coinbase, b.header, b.gasPool,
+		b.cm.config, b.cm, vm.Config{}, false, &b.header.GasUsed)
+	if err != nil {
+		panic(err)
+	}
+	b.txs = append(b.txs, tx)
+	b.receipts = append(b.receipts, receipts[0])
+}
@@ -41,3 +41,29 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// Rip7560TxStatus is a stage in the lifecycle of an RIP-7560 transaction.
+type Rip7560TxStatus string
+
+const (
+	Rip7560TxAccepted    Rip7560TxStatus = "accepted"    // the transaction was accepted into the pool
+	Rip7560TxRevalidated Rip7560TxStatus = "revalidated" // the transaction was re-checked against a new head and remains pending
+	Rip7560TxSelected    Rip7560TxStatus = "selected"    // the transaction was selected for inclusion in a block being built
+	Rip7560TxIncluded    Rip7560TxStatus = "included"    // the transaction was included in a block
+	Rip7560TxDropped     Rip7560TxStatus = "dropped"     // the transaction was dropped from the pool or the block being built
+	Rip7560TxExpired     Rip7560TxStatus = "expired"     // the transaction was dropped as its bundle was not included by the last block it was valid for
+	Rip7560TxTimedOut    Rip7560TxStatus = "timedOut"    // the transaction was dropped from the block being built as its validation ran for too long
+	Rip7560TxReplaced    Rip7560TxStatus = "replaced"    // the transaction was replaced in the pool by one with the same nonce and higher fees
+)
+
+// Rip7560TxStatusEvent is posted when an RIP-7560 transaction moves to another stage of its lifecycle.
+type Rip7560TxStatusEvent struct {
+	TxHash  common.Hash     `json:"transactionHash"`
+	Status  Rip7560TxStatus `json:"status"`
+	Receipt *types.Receipt  `json:"receipt,omitempty"` // set when the transaction is included
+	Reason  string          `json:"reason,omitempty"`  // set when the transaction is dropped
+
+	BundlerId  string                             `json:"bundlerId,omitempty"`  // set when the transaction was pushed in a bundle
+	BundleHash common.Hash                        `json:"bundleHash,omitempty"` // set when the transaction was pushed in a bundle
+	DebugInfo  *types.Rip7560TransactionDebugInfo `json:"debugInfo,omitempty"`  // set when the transaction failed validation during block building
+}
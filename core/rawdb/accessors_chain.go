@@ -692,13 +692,31 @@ type receiptLogs struct {
 	Logs []*types.Log
 }
 
+// storedRip7560ReceiptRLP is the storage encoding of an RIP-7560 transaction receipt, the
+// metadata list in place of the deposit fields being skipped.
+type storedRip7560ReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*types.Log
+	Rip7560           rlp.RawValue
+}
+
 // DecodeRLP implements rlp.Decoder.
 func (r *receiptLogs) DecodeRLP(s *rlp.Stream) error {
+	blob, err := s.Raw()
+	if err != nil {
+		return err
+	}
 	var stored storedReceiptRLP
-	if err := s.Decode(&stored); err != nil {
+	if err := rlp.DecodeBytes(blob, &stored); err == nil {
+		r.Logs = stored.Logs
+		return nil
+	}
+	var rip7560 storedRip7560ReceiptRLP
+	if err := rlp.DecodeBytes(blob, &rip7560); err != nil {
 		return err
 	}
-	r.Logs = stored.Logs
+	r.Logs = rip7560.Logs
 	return nil
 }
 
@@ -824,6 +824,27 @@ func TestParseLegacyReceiptRLP(t *testing.T) {
 	require.Equal(t, receipt.FeeScalar, result.FeeScalar)
 }
 
+func TestReadRip7560ReceiptLogs(t *testing.T) {
+	db := NewMemoryDatabase()
+	receipt := &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs:              []*types.Log{{Address: common.BytesToAddress([]byte{0x11})}},
+		Rip7560Validity:   &types.Rip7560Validity{AccountValidUntil: 1},
+	}
+	hash := common.BytesToHash([]byte{0x07, 0x56})
+	WriteReceipts(db, hash, 0, types.Receipts{receipt})
+
+	logs := ReadLogs(db, hash, 0)
+	if len(logs) != 1 || len(logs[0]) != 1 || logs[0][0].Address != receipt.Logs[0].Address {
+		t.Fatalf("logs mismatch: have %v, want %v", logs, receipt.Logs)
+	}
+	raw := ReadRawReceipts(db, hash, 0)
+	if len(raw) != 1 || raw[0].Rip7560Validity == nil || *raw[0].Rip7560Validity != *receipt.Rip7560Validity {
+		t.Fatalf("receipt metadata not stored: %v", raw)
+	}
+}
+
 func TestDeriveLogFields(t *testing.T) {
 	// Create a few transactions to have receipts for
 	to2 := common.HexToAddress("0x2")
@@ -93,6 +93,132 @@ func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	}
 }
 
+// rip7712NonceKeyLength is the length in bytes of an RIP-7712 192-bit nonce key.
+const rip7712NonceKeyLength = 24
+
+// WriteRip7712NonceKeys records the RIP-7712 nonce keys used by the RIP-7560
+// transactions of a canonical block, indexed by the transaction sender.
+func WriteRip7712NonceKeys(db ethdb.KeyValueWriter, number uint64, txs []*types.Transaction) {
+	for _, tx := range txs {
+		if aatx := rip7712NonceTx(tx); aatx != nil {
+			if err := db.Put(rip7712NonceKey(*aatx.Sender, aatx.NonceKey, number), []byte{}); err != nil {
+				log.Crit("Failed to store RIP-7712 nonce key", "err", err)
+			}
+		}
+	}
+}
+
+// WriteRip7712NonceKeysByBlock records the RIP-7712 nonce keys used by every
+// RIP-7560 transaction in a block, indexed by the transaction sender.
+func WriteRip7712NonceKeysByBlock(db ethdb.KeyValueWriter, block *types.Block) {
+	WriteRip7712NonceKeys(db, block.NumberU64(), block.Transactions())
+}
+
+// DeleteRip7712NonceKeys removes the RIP-7712 nonce keys recorded for the
+// transactions of a block no longer canonical or indexed. The keys remain
+// recorded for the other blocks using them.
+func DeleteRip7712NonceKeys(db ethdb.KeyValueWriter, number uint64, txs []*types.Transaction) {
+	for _, tx := range txs {
+		if aatx := rip7712NonceTx(tx); aatx != nil {
+			if err := db.Delete(rip7712NonceKey(*aatx.Sender, aatx.NonceKey, number)); err != nil {
+				log.Crit("Failed to delete RIP-7712 nonce key", "err", err)
+			}
+		}
+	}
+}
+
+// DeleteRip7712NonceKeysByBlock removes the RIP-7712 nonce keys recorded for the
+// transactions of a block.
+func DeleteRip7712NonceKeysByBlock(db ethdb.KeyValueWriter, block *types.Block) {
+	DeleteRip7712NonceKeys(db, block.NumberU64(), block.Transactions())
+}
+
+// rip7712NonceTx returns the RIP-7560 payload of the transaction if it uses an
+// RIP-7712 nonce key, nil otherwise.
+func rip7712NonceTx(tx *types.Transaction) *types.Rip7560AccountAbstractionTx {
+	if tx.Type() != types.Rip7560Type {
+		return nil
+	}
+	if aatx := tx.Rip7560TransactionData(); aatx.IsRip7712Nonce() {
+		return aatx
+	}
+	return nil
+}
+
+// ReadRip7712NonceKeys retrieves all RIP-7712 nonce keys used by the given sender
+// in the indexed blocks of the canonical chain, in ascending order.
+func ReadRip7712NonceKeys(db ethdb.Iteratee, sender common.Address) []*big.Int {
+	prefix := rip7712NonceKeysPrefix(sender)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var (
+		keys []*big.Int
+		last []byte
+	)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+rip7712NonceKeyLength+8 {
+			continue
+		}
+		// The entries of a nonce key used in several blocks are adjacent
+		nonceKey := key[len(prefix) : len(prefix)+rip7712NonceKeyLength]
+		if bytes.Equal(nonceKey, last) {
+			continue
+		}
+		keys = append(keys, new(big.Int).SetBytes(nonceKey))
+		last = common.CopyBytes(nonceKey)
+	}
+	return keys
+}
+
+// ReadRip7560PaymasterStats retrieves the RIP-7560 sponsorship stats of a paymaster
+// within an index section, or nil if it sponsored no transactions in the section.
+func ReadRip7560PaymasterStats(db ethdb.KeyValueReader, section uint64, paymaster common.Address) *types.Rip7560PaymasterStats {
+	data, _ := db.Get(rip7560PaymasterStatsKey(section, paymaster))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(types.Rip7560PaymasterStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid RIP-7560 paymaster stats RLP", "section", section, "paymaster", paymaster, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteRip7560PaymasterStats stores the RIP-7560 sponsorship stats of a paymaster
+// within an index section.
+func WriteRip7560PaymasterStats(db ethdb.KeyValueWriter, section uint64, paymaster common.Address, stats *types.Rip7560PaymasterStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to RLP encode RIP-7560 paymaster stats", "err", err)
+	}
+	if err := db.Put(rip7560PaymasterStatsKey(section, paymaster), data); err != nil {
+		log.Crit("Failed to store RIP-7560 paymaster stats", "err", err)
+	}
+}
+
+// DeleteRip7560PaymasterStats removes the RIP-7560 sponsorship stats of all paymasters
+// within an index section.
+func DeleteRip7560PaymasterStats(db ethdb.KeyValueStore, section uint64) {
+	prefix := rip7560PaymasterSectionPrefix(section)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+common.AddressLength {
+			continue
+		}
+		if err := db.Delete(it.Key()); err != nil {
+			log.Crit("Failed to delete RIP-7560 paymaster stats", "err", err)
+		}
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to iterate RIP-7560 paymaster stats", "err", it.Error())
+	}
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -112,6 +112,50 @@ func TestLookupStorage(t *testing.T) {
 	}
 }
 
+// Tests that the RIP-7712 nonce keys used by senders can be stored and enumerated.
+func TestRip7712NonceKeys(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	alice, bob := common.Address{0xaa}, common.Address{0xbb}
+	newTx := func(sender common.Address, nonceKey int64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			Sender:   &sender,
+			NonceKey: big.NewInt(nonceKey),
+		})
+	}
+	legacy := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), nil)
+	txs := []*types.Transaction{newTx(alice, 7), legacy, newTx(alice, 0), newTx(bob, 1), newTx(alice, 2), newTx(alice, 7)}
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, &types.Body{Transactions: txs}, nil, newTestHasher())
+	WriteRip7712NonceKeysByBlock(db, block)
+
+	check := func(sender common.Address, want []int64) {
+		t.Helper()
+		keys := ReadRip7712NonceKeys(db, sender)
+		if len(keys) != len(want) {
+			t.Fatalf("nonce key count mismatch for %x: have %d, want %d", sender, len(keys), len(want))
+		}
+		for i, key := range keys {
+			if key.Int64() != want[i] {
+				t.Errorf("nonce key %d mismatch for %x: have %v, want %d", i, sender, key, want[i])
+			}
+		}
+	}
+	check(alice, []int64{2, 7})
+	check(bob, []int64{1})
+	check(common.Address{0xcc}, nil)
+
+	// The keys used by other blocks remain listed once a block is deleted
+	next := types.NewBlock(&types.Header{Number: big.NewInt(315)}, &types.Body{Transactions: []*types.Transaction{newTx(alice, 7)}}, nil, newTestHasher())
+	WriteRip7712NonceKeysByBlock(db, next)
+	DeleteRip7712NonceKeysByBlock(db, block)
+	check(alice, []int64{7})
+	check(bob, nil)
+
+	DeleteRip7712NonceKeysByBlock(db, next)
+	check(alice, nil)
+}
+
 func TestDeleteBloomBits(t *testing.T) {
 	// Prepare testing data
 	db := NewMemoryDatabase()
@@ -84,8 +84,9 @@ func InitDatabaseFromFreezer(db ethdb.Database) {
 }
 
 type blockTxHashes struct {
-	number uint64
-	hashes []common.Hash
+	number     uint64
+	hashes     []common.Hash
+	rip7712Txs []*types.Transaction // Transactions using RIP-7712 nonce keys
 }
 
 // iterateTransactions iterates over all transactions in the (canon) block
@@ -147,13 +148,20 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 				log.Warn("Failed to decode block body", "block", data.number, "error", err)
 				return
 			}
-			var hashes []common.Hash
+			var (
+				hashes     []common.Hash
+				rip7712Txs []*types.Transaction
+			)
 			for _, tx := range body.Transactions {
 				hashes = append(hashes, tx.Hash())
+				if rip7712NonceTx(tx) != nil {
+					rip7712Txs = append(rip7712Txs, tx)
+				}
 			}
 			result := &blockTxHashes{
-				hashes: hashes,
-				number: data.number,
+				hashes:     hashes,
+				number:     data.number,
+				rip7712Txs: rip7712Txs,
 			}
 			// Feed the block to the aggregator, or abort on interrupt
 			select {
@@ -214,6 +222,7 @@ func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan
 			delivery := queue.PopItem()
 			lastNum = delivery.number
 			WriteTxLookupEntries(batch, delivery.number, delivery.hashes)
+			WriteRip7712NonceKeys(batch, delivery.number, delivery.rip7712Txs)
 			blocks++
 			txs += len(delivery.hashes)
 			// If enough data was accumulated in memory or we're at the last block, dump to disk
@@ -307,6 +316,7 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 			delivery := queue.PopItem()
 			nextNum = delivery.number + 1
 			DeleteTxLookupEntries(batch, delivery.hashes)
+			DeleteRip7712NonceKeys(batch, delivery.number, delivery.rip7712Txs)
 			txs += len(delivery.hashes)
 			blocks++
 
@@ -102,6 +102,40 @@ func TestChainIterator(t *testing.T) {
 	}
 }
 
+// Tests that the RIP-7712 nonce keys are indexed and unindexed along with the
+// transaction lookups.
+func TestIndexRip7712NonceKeys(t *testing.T) {
+	chainDb := NewMemoryDatabase()
+	sender := common.Address{0xaa}
+
+	for i := int64(0); i < 4; i++ {
+		var txs []*types.Transaction
+		if i > 0 {
+			txs = append(txs, types.NewTx(&types.Rip7560AccountAbstractionTx{Sender: &sender, NonceKey: big.NewInt(i)}))
+		}
+		block := types.NewBlock(&types.Header{Number: big.NewInt(i)}, &types.Body{Transactions: txs}, nil, newTestHasher())
+		WriteBlock(chainDb, block)
+		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+	}
+	check := func(want ...int64) {
+		t.Helper()
+		keys := ReadRip7712NonceKeys(chainDb, sender)
+		if len(keys) != len(want) {
+			t.Fatalf("nonce key count mismatch: have %v, want %v", keys, want)
+		}
+		for i, key := range keys {
+			if key.Int64() != want[i] {
+				t.Fatalf("nonce key %d mismatch: have %v, want %d", i, key, want[i])
+			}
+		}
+	}
+	IndexTransactions(chainDb, 0, 4, nil, false)
+	check(1, 2, 3)
+
+	UnindexTransactions(chainDb, 0, 2, nil, false)
+	check(2, 3)
+}
+
 func TestIndexTransactions(t *testing.T) {
 	// Construct test chain db
 	chainDb := NewMemoryDatabase()
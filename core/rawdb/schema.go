@@ -20,8 +20,10 @@ package rawdb
 import (
 	"bytes"
 	"encoding/binary"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/metrics"
 )
@@ -141,6 +143,11 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	rip7712NonceKeyPrefix = []byte("rip7712-nonce-key-") // rip7712NonceKeyPrefix + sender + nonce key (24 bytes) + num (uint64 big endian) -> empty
+
+	rip7560PaymasterStatsPrefix = []byte("rip7560-paymaster-") // rip7560PaymasterStatsPrefix + section (uint64 big endian) + paymaster -> RLP(types.Rip7560PaymasterStats)
+	Rip7560PaymasterIndexPrefix = []byte("iP")
+
 	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
 	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
 	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
@@ -223,6 +230,27 @@ func storageSnapshotsKey(accountHash common.Hash) []byte {
 	return append(SnapshotStoragePrefix, accountHash.Bytes()...)
 }
 
+// rip7712NonceKeysPrefix = rip7712NonceKeyPrefix + sender
+func rip7712NonceKeysPrefix(sender common.Address) []byte {
+	return append(rip7712NonceKeyPrefix, sender.Bytes()...)
+}
+
+// rip7712NonceKey = rip7712NonceKeyPrefix + sender + nonce key (24 bytes) + num (uint64 big endian)
+func rip7712NonceKey(sender common.Address, nonceKey *big.Int, number uint64) []byte {
+	key := append(rip7712NonceKeysPrefix(sender), math.PaddedBigBytes(nonceKey, rip7712NonceKeyLength)...)
+	return append(key, encodeBlockNumber(number)...)
+}
+
+// rip7560PaymasterSectionPrefix = rip7560PaymasterStatsPrefix + section (uint64 big endian)
+func rip7560PaymasterSectionPrefix(section uint64) []byte {
+	return append(rip7560PaymasterStatsPrefix, encodeBlockNumber(section)...)
+}
+
+// rip7560PaymasterStatsKey = rip7560PaymasterStatsPrefix + section (uint64 big endian) + paymaster
+func rip7560PaymasterStatsKey(section uint64, paymaster common.Address) []byte {
+	return append(rip7560PaymasterSectionPrefix(section), paymaster.Bytes()...)
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
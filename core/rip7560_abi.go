@@ -6,11 +6,51 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"math/big"
 	"strings"
 )
 
-var Rip7560Abi, _ = abi.JSON(strings.NewReader(Rip7560AbiJson))
+// Rip7560EntryPointAbi is a revision of the EntryPoint ABI: the calls the EntryPoint makes to
+// the frames of RIP-7560 transactions, the callbacks they make to it and the events it emits.
+type Rip7560EntryPointAbi struct {
+	abi.ABI
+	Version uint64 // Passed to the validation frames, and bound into the signing domain
+}
+
+// ErrRip7560AbiVersion is returned if the chain config switches RIP-7560 transactions to a
+// version of the EntryPoint ABI the client does not support.
+var ErrRip7560AbiVersion = errors.New("unsupported RIP-7560 EntryPoint ABI version")
+
+// rip7560EntryPointAbis are the revisions of the EntryPoint ABI supported by the client,
+// keyed by their version. A spec revision adds its version here and to the supported
+// versions of params, and chains switch to it with the RIP7560AbiVersions rules of their
+// config.
+var rip7560EntryPointAbis = map[uint64]*Rip7560EntryPointAbi{
+	Rip7560AbiVersion: newRip7560EntryPointAbi(Rip7560AbiVersion, Rip7560AbiJson),
+}
+
+// Rip7560Abi is the EntryPoint ABI of the default version, used before any version switch.
+var Rip7560Abi = rip7560EntryPointAbis[Rip7560AbiVersion].ABI
+
+func newRip7560EntryPointAbi(version uint64, definition string) *Rip7560EntryPointAbi {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid RIP-7560 EntryPoint ABI version %d: %v", version, err))
+	}
+	return &Rip7560EntryPointAbi{ABI: parsed, Version: version}
+}
+
+// Rip7560EntryPointAbiAt returns the EntryPoint ABI of RIP-7560 transactions at the given
+// block, or an error if the chain config switches to a version the client does not support.
+func Rip7560EntryPointAbiAt(config *params.ChainConfig, num *big.Int) (*Rip7560EntryPointAbi, error) {
+	version := config.RIP7560AbiVersion(num)
+	entryPointAbi, ok := rip7560EntryPointAbis[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrRip7560AbiVersion, version)
+	}
+	return entryPointAbi, nil
+}
 
 type AcceptAccountData struct {
 	ValidAfter *big.Int
@@ -23,36 +63,35 @@ type AcceptPaymasterData struct {
 	Context    []byte
 }
 
-func abiEncodeValidateTransaction(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
+func (a *Rip7560EntryPointAbi) encodeValidateTransaction(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
 
 	txAbiEncoding, err := tx.AbiEncode()
 	if err != nil {
 		return nil, err
 	}
-	validateTransactionData, err := Rip7560Abi.Pack("validateTransaction", big.NewInt(Rip7560AbiVersion), signingHash, txAbiEncoding)
+	validateTransactionData, err := a.Pack("validateTransaction", new(big.Int).SetUint64(a.Version), signingHash, txAbiEncoding)
 	return validateTransactionData, err
 }
 
-func abiEncodeValidatePaymasterTransaction(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
+func (a *Rip7560EntryPointAbi) encodeValidatePaymasterTransaction(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
 	txAbiEncoding, err := tx.AbiEncode()
 	if err != nil {
 		return nil, err
 	}
-	data, err := Rip7560Abi.Pack("validatePaymasterTransaction", big.NewInt(Rip7560AbiVersion), signingHash, txAbiEncoding)
+	data, err := a.Pack("validatePaymasterTransaction", new(big.Int).SetUint64(a.Version), signingHash, txAbiEncoding)
 	return data, err
 }
 
-func abiEncodePostPaymasterTransaction(success bool, actualGasCost uint64, context []byte) []byte {
-	// TODO: pass actual gas cost parameter here!
-	postOpData, err := Rip7560Abi.Pack("postPaymasterTransaction", success, big.NewInt(int64(actualGasCost)), context)
+func (a *Rip7560EntryPointAbi) encodePostPaymasterTransaction(success bool, actualGasCost *big.Int, context []byte) []byte {
+	postOpData, err := a.Pack("postPaymasterTransaction", success, actualGasCost, context)
 	if err != nil {
 		panic("unable to encode postPaymasterTransaction")
 	}
 	return postOpData
 }
 
-func decodeMethodParamsToInterface(output interface{}, methodName string, input []byte) error {
-	m, err := Rip7560Abi.MethodById(input)
+func (a *Rip7560EntryPointAbi) decodeMethodParamsToInterface(output interface{}, methodName string, input []byte) error {
+	m, err := a.MethodById(input)
 	if err != nil {
 		return fmt.Errorf("unable to decode %s: %w", methodName, err)
 	}
@@ -70,11 +109,11 @@ func decodeMethodParamsToInterface(output interface{}, methodName string, input
 	return nil
 }
 
-func abiDecodeAcceptAccount(input []byte, allowSigFail bool) (*AcceptAccountData, error) {
+func (a *Rip7560EntryPointAbi) decodeAcceptAccount(input []byte, allowSigFail bool) (*AcceptAccountData, error) {
 	acceptAccountData := &AcceptAccountData{}
-	err := decodeMethodParamsToInterface(acceptAccountData, "acceptAccount", input)
+	err := a.decodeMethodParamsToInterface(acceptAccountData, "acceptAccount", input)
 	if err != nil && allowSigFail {
-		err = decodeMethodParamsToInterface(acceptAccountData, "sigFailAccount", input)
+		err = a.decodeMethodParamsToInterface(acceptAccountData, "sigFailAccount", input)
 	}
 	if err != nil {
 		return nil, err
@@ -82,11 +121,11 @@ func abiDecodeAcceptAccount(input []byte, allowSigFail bool) (*AcceptAccountData
 	return acceptAccountData, nil
 }
 
-func abiDecodeAcceptPaymaster(input []byte, allowSigFail bool) (*AcceptPaymasterData, error) {
+func (a *Rip7560EntryPointAbi) decodeAcceptPaymaster(input []byte, allowSigFail bool) (*AcceptPaymasterData, error) {
 	acceptPaymasterData := &AcceptPaymasterData{}
-	err := decodeMethodParamsToInterface(acceptPaymasterData, "acceptPaymaster", input)
+	err := a.decodeMethodParamsToInterface(acceptPaymasterData, "acceptPaymaster", input)
 	if err != nil && allowSigFail {
-		err = decodeMethodParamsToInterface(acceptPaymasterData, "sigFailPaymaster", input)
+		err = a.decodeMethodParamsToInterface(acceptPaymasterData, "sigFailPaymaster", input)
 	}
 	if err != nil {
 		return nil, err
@@ -97,11 +136,11 @@ func abiDecodeAcceptPaymaster(input []byte, allowSigFail bool) (*AcceptPaymaster
 	return acceptPaymasterData, err
 }
 
-func abiEncodeRIP7560TransactionEvent(
+func (a *Rip7560EntryPointAbi) encodeRIP7560TransactionEvent(
 	aatx *types.Rip7560AccountAbstractionTx,
 	executionStatus uint64,
 ) (topics []common.Hash, data []byte, error error) {
-	id := Rip7560Abi.Events["RIP7560TransactionEvent"].ID
+	id := a.Events["RIP7560TransactionEvent"].ID
 	paymaster := aatx.Paymaster
 	if paymaster == nil {
 		paymaster = &common.Address{}
@@ -110,11 +149,11 @@ func abiEncodeRIP7560TransactionEvent(
 	if deployer == nil {
 		deployer = &common.Address{}
 	}
-	inputs := Rip7560Abi.Events["RIP7560TransactionEvent"].Inputs
+	inputs := a.Events["RIP7560TransactionEvent"].Inputs
 	data, error = inputs.NonIndexed().Pack(
 		aatx.NonceKey,
-		big.NewInt(int64(aatx.Nonce)),
-		big.NewInt(int64(executionStatus)),
+		new(big.Int).SetUint64(aatx.Nonce),
+		new(big.Int).SetUint64(executionStatus),
 	)
 	if error != nil {
 		return nil, nil, error
@@ -125,10 +164,46 @@ func abiEncodeRIP7560TransactionEvent(
 	return topics, data, nil
 }
 
-func abiEncodeRIP7560AccountDeployedEvent(
+// Rip7560ExecutionStatus returns the execution status reported by the RIP7560TransactionEvent
+// among the logs of an RIP-7560 transaction, or false if the logs do not include the event.
+// The event is looked up in every supported version of the EntryPoint ABI.
+func Rip7560ExecutionStatus(logs []*types.Log) (uint64, bool) {
+	for _, log := range logs {
+		if log.Address != AA_ENTRY_POINT || len(log.Topics) == 0 {
+			continue
+		}
+		event, ok := rip7560TransactionEventOf(log.Topics[0])
+		if !ok {
+			continue
+		}
+		args := make(map[string]interface{})
+		if err := event.Inputs.NonIndexed().UnpackIntoMap(args, log.Data); err != nil {
+			return 0, false
+		}
+		status, ok := args["executionStatus"].(*big.Int)
+		if !ok || !status.IsUint64() {
+			return 0, false
+		}
+		return status.Uint64(), true
+	}
+	return 0, false
+}
+
+// rip7560TransactionEventOf returns the RIP7560TransactionEvent with the given ID among the
+// supported versions of the EntryPoint ABI.
+func rip7560TransactionEventOf(id common.Hash) (abi.Event, bool) {
+	for _, entryPointAbi := range rip7560EntryPointAbis {
+		if event, ok := entryPointAbi.Events["RIP7560TransactionEvent"]; ok && event.ID == id {
+			return event, true
+		}
+	}
+	return abi.Event{}, false
+}
+
+func (a *Rip7560EntryPointAbi) encodeRIP7560AccountDeployedEvent(
 	aatx *types.Rip7560AccountAbstractionTx,
 ) (topics []common.Hash, data []byte, error error) {
-	id := Rip7560Abi.Events["RIP7560AccountDeployed"].ID
+	id := a.Events["RIP7560AccountDeployed"].ID
 	paymaster := aatx.Paymaster
 	if paymaster == nil {
 		paymaster = &common.Address{}
@@ -147,15 +222,15 @@ func abiEncodeRIP7560AccountDeployedEvent(
 	return topics, make([]byte, 0), nil
 }
 
-func abiEncodeRIP7560TransactionRevertReasonEvent(
+func (a *Rip7560EntryPointAbi) encodeRIP7560TransactionRevertReasonEvent(
 	aatx *types.Rip7560AccountAbstractionTx,
 	revertData []byte,
 ) (topics []common.Hash, data []byte, error error) {
-	id := Rip7560Abi.Events["RIP7560TransactionRevertReason"].ID
-	inputs := Rip7560Abi.Events["RIP7560TransactionRevertReason"].Inputs
+	id := a.Events["RIP7560TransactionRevertReason"].ID
+	inputs := a.Events["RIP7560TransactionRevertReason"].Inputs
 	data, error = inputs.NonIndexed().Pack(
 		aatx.NonceKey,
-		big.NewInt(int64(aatx.Nonce)),
+		new(big.Int).SetUint64(aatx.Nonce),
 		revertData,
 	)
 	if error != nil {
@@ -166,19 +241,19 @@ func abiEncodeRIP7560TransactionRevertReasonEvent(
 	return topics, data, nil
 }
 
-func abiEncodeRIP7560TransactionPostOpRevertReasonEvent(
+func (a *Rip7560EntryPointAbi) encodeRIP7560TransactionPostOpRevertReasonEvent(
 	aatx *types.Rip7560AccountAbstractionTx,
 	revertData []byte,
 ) (topics []common.Hash, data []byte, error error) {
-	id := Rip7560Abi.Events["RIP7560TransactionPostOpRevertReason"].ID
+	id := a.Events["RIP7560TransactionPostOpRevertReason"].ID
 	paymaster := aatx.Paymaster
 	if paymaster == nil {
 		paymaster = &common.Address{}
 	}
-	inputs := Rip7560Abi.Events["RIP7560TransactionPostOpRevertReason"].Inputs
+	inputs := a.Events["RIP7560TransactionPostOpRevertReason"].Inputs
 	data, error = inputs.NonIndexed().Pack(
 		aatx.NonceKey,
-		big.NewInt(int64(aatx.Nonce)),
+		new(big.Int).SetUint64(aatx.Nonce),
 		revertData,
 	)
 	if error != nil {
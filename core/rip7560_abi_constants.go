@@ -1,6 +1,9 @@
 package core
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
 
 const PaymasterMaxContextSize = 65536
 const Rip7560AbiVersion = 0
@@ -8,8 +11,9 @@ const Rip7560AbiVersion = 0
 var AA_ENTRY_POINT = common.HexToAddress("0x0000000000000000000000000000000000007560")
 var AA_SENDER_CREATOR = common.HexToAddress("0x00000000000000000000000000000000ffff7560")
 
-// AA_GAS_PENALTY_PCT is always applied to unused execution and postOp gas limits
-const AA_GAS_PENALTY_PCT = 10
+// AA_GAS_PENALTY_PCT is applied to unused execution and postOp gas limits, unless the
+// chain configures other penalty rules
+const AA_GAS_PENALTY_PCT = params.RIP7560DefaultGasPenaltyPercent
 
 const Rip7560AbiJson = `
 [
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestPostOpActualGasCost(t *testing.T) {
+	vpr := &ValidationPhaseResult{EffectiveGasPrice: uint256.NewInt(7)}
+	header := &types.Header{Number: big.NewInt(10)}
+
+	tests := []struct {
+		name      string
+		forkBlock *big.Int
+		want      uint64
+	}{
+		{"not configured", nil, 1000},
+		{"before fork", big.NewInt(11), 1000},
+		{"at fork", big.NewInt(10), 7000},
+		{"after fork", big.NewInt(0), 7000},
+	}
+	for _, tt := range tests {
+		config := &params.ChainConfig{RIP7560ActualGasCostBlock: tt.forkBlock}
+		if have := postOpActualGasCost(config, header, vpr, 1000); have.Uint64() != tt.want {
+			t.Errorf("%s: actual gas cost mismatch: have %v, want %v", tt.name, have, tt.want)
+		}
+	}
+}
+
+func TestEncodePostPaymasterTransaction(t *testing.T) {
+	// a wei amount that does not fit into an int64 must be encoded without truncation
+	actualGasCost := new(big.Int).Lsh(big.NewInt(1), 100)
+	context := []byte{1, 2, 3}
+
+	data := rip7560EntryPointAbis[Rip7560AbiVersion].encodePostPaymasterTransaction(true, actualGasCost, context)
+
+	method, err := Rip7560Abi.MethodById(data)
+	if err != nil {
+		t.Fatalf("failed to find method: %v", err)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack: %v", err)
+	}
+	if success := args[0].(bool); !success {
+		t.Errorf("success mismatch: have %v, want %v", success, true)
+	}
+	if have := args[1].(*big.Int); have.Cmp(actualGasCost) != 0 {
+		t.Errorf("actualGasCost mismatch: have %v, want %v", have, actualGasCost)
+	}
+	if have := args[2].([]byte); string(have) != string(context) {
+		t.Errorf("context mismatch: have %x, want %x", have, context)
+	}
+}
+
+func TestEncodeRip7560EventNonces(t *testing.T) {
+	// nonces and statuses that do not fit into an int64 must be encoded without wrapping around
+	entryPointAbi := rip7560EntryPointAbis[Rip7560AbiVersion]
+	aatx := &types.Rip7560AccountAbstractionTx{
+		Sender:   &common.Address{0x01},
+		NonceKey: big.NewInt(7),
+		Nonce:    1<<63 + 1,
+	}
+	nonce := new(big.Int).SetUint64(aatx.Nonce)
+	check := func(name string, data []byte, err error) {
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", name, err)
+		}
+		args, err := entryPointAbi.Events[name].Inputs.NonIndexed().Unpack(data)
+		if err != nil {
+			t.Fatalf("%s: failed to unpack: %v", name, err)
+		}
+		if have := args[1].(*big.Int); have.Cmp(nonce) != 0 {
+			t.Errorf("%s: nonce mismatch: have %v, want %v", name, have, nonce)
+		}
+	}
+	topics, data, err := entryPointAbi.encodeRIP7560TransactionEvent(aatx, 1<<63)
+	check("RIP7560TransactionEvent", data, err)
+	status, ok := Rip7560ExecutionStatus([]*types.Log{{Address: AA_ENTRY_POINT, Topics: topics, Data: data}})
+	if !ok || status != 1<<63 {
+		t.Errorf("execution status mismatch: have %d (found %v), want %d", status, ok, uint64(1<<63))
+	}
+	_, data, err = entryPointAbi.encodeRIP7560TransactionRevertReasonEvent(aatx, []byte{1})
+	check("RIP7560TransactionRevertReason", data, err)
+	_, data, err = entryPointAbi.encodeRIP7560TransactionPostOpRevertReasonEvent(aatx, []byte{1})
+	check("RIP7560TransactionPostOpRevertReason", data, err)
+}
+
+func TestRip7560EntryPointAbiVersions(t *testing.T) {
+	// register a revision of the ABI for the test
+	rip7560EntryPointAbis[1] = newRip7560EntryPointAbi(1, Rip7560AbiJson)
+	defer delete(rip7560EntryPointAbis, 1)
+
+	// the account stores the version it is validated with
+	test := newRip7560ValidationCacheTest(t, vm.PUSH1, 4, vm.CALLDATALOAD, vm.PUSH0, vm.SSTORE)
+	validatedVersion := func(versions ...*params.RIP7560AbiVersionRule) (uint64, error) {
+		test.config.RIP7560AbiVersions = versions
+		statedb := test.state.Copy()
+		gp := new(GasPool).AddGas(test.header.GasLimit)
+		_, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{0xc0}, gp, statedb, test.header, types.NewTx(test.aatx), vm.Config{})
+		return statedb.GetState(*test.aatx.Sender, common.Hash{}).Big().Uint64(), err
+	}
+	if version, err := validatedVersion(); err != nil || version != 0 {
+		t.Errorf("default version: have %d (err %v), want 0", version, err)
+	}
+	if version, err := validatedVersion(&params.RIP7560AbiVersionRule{Block: big.NewInt(0), Version: 1}); err != nil || version != 1 {
+		t.Errorf("switched version: have %d (err %v), want 1", version, err)
+	}
+	// the switch activates after the block of the test header
+	if version, err := validatedVersion(&params.RIP7560AbiVersionRule{Block: big.NewInt(2), Version: 1}); err != nil || version != 0 {
+		t.Errorf("pending switch: have %d (err %v), want 0", version, err)
+	}
+
+	// a switch to an unsupported version rejects the transactions
+	unsupported := &params.RIP7560AbiVersionRule{Block: big.NewInt(0), Version: 2}
+	if _, err := validatedVersion(unsupported); err == nil || !strings.Contains(err.Error(), ErrRip7560AbiVersion.Error()) {
+		t.Errorf("unsupported version: have error %v, want %v", err, ErrRip7560AbiVersion)
+	}
+	if _, err := Rip7560EntryPointAbiAt(test.config, test.header.Number); !errors.Is(err, ErrRip7560AbiVersion) {
+		t.Errorf("unsupported version lookup: have error %v, want %v", err, ErrRip7560AbiVersion)
+	}
+}
+
+func TestRip7560SupportedAbiVersions(t *testing.T) {
+	for version := range rip7560EntryPointAbis {
+		if !params.RIP7560SupportedAbiVersions[version] {
+			t.Errorf("registered version %d not supported by the chain config", version)
+		}
+	}
+	for version := range params.RIP7560SupportedAbiVersions {
+		if rip7560EntryPointAbis[version] == nil {
+			t.Errorf("supported version %d not registered", version)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// applyRip7560Authorizations sets the delegation designators of the EIP-7702
+// authorizations carried by the transaction. Like in EIP-7702 transactions, invalid
+// authorizations are skipped rather than failing the transaction, and the gas charged
+// for the authorities that already exist is partially refunded.
+func applyRip7560Authorizations(config *params.ChainConfig, statedb *state.StateDB, aatx *types.Rip7560AccountAbstractionTx) {
+	for i := range aatx.AuthorizationList {
+		auth := &aatx.AuthorizationList[i]
+		authority, err := validateRip7560Authorization(config, statedb, auth)
+		if err != nil {
+			log.Trace("Skipping invalid RIP-7560 authorization", "sender", aatx.Sender, "index", i, "err", err)
+			continue
+		}
+		if statedb.Exist(authority) {
+			statedb.AddRefund(params.CallNewAccountGas - params.TxAuthTupleGas)
+		}
+		statedb.SetNonce(authority, auth.Nonce+1)
+		if auth.Address == (common.Address{}) {
+			// Delegation to zero address means clear.
+			statedb.SetCode(authority, nil)
+			continue
+		}
+		statedb.SetCode(authority, types.AddressToDelegation(auth.Address))
+	}
+}
+
+// validateRip7560Authorization returns the authority of the authorization if it may be
+// applied to the current state.
+func validateRip7560Authorization(config *params.ChainConfig, statedb *state.StateDB, auth *types.SetCodeAuthorization) (common.Address, error) {
+	if !auth.ChainID.IsZero() && auth.ChainID.CmpBig(config.ChainID) != 0 {
+		return common.Address{}, ErrAuthorizationWrongChainID
+	}
+	if auth.Nonce+1 < auth.Nonce {
+		return common.Address{}, ErrAuthorizationNonceOverflow
+	}
+	authority, err := auth.Authority()
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrAuthorizationInvalidSignature, err)
+	}
+	// The authority is accessed whether or not the authorization applies
+	statedb.AddAddressToAccessList(authority)
+	if code := statedb.GetCode(authority); len(code) != 0 {
+		if _, ok := types.ParseDelegation(code); !ok {
+			return common.Address{}, ErrAuthorizationDestinationHasCode
+		}
+	}
+	if have := statedb.GetNonce(authority); have != auth.Nonce {
+		return common.Address{}, ErrAuthorizationNonceMismatch
+	}
+	return authority, nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+)
+
+// Rip7560PaymasterIndexer implements a core.ChainIndexer, aggregating the RIP-7560
+// transactions sponsored by each paymaster within every index section, so paymaster
+// operators can query them without processing the logs of the whole chain.
+type Rip7560PaymasterIndexer struct {
+	config  *params.ChainConfig
+	db      ethdb.Database // database instance to read blocks from and write index data into
+	size    uint64         // section size to aggregate the stats over
+	section uint64         // section number being processed currently
+	stats   map[common.Address]*types.Rip7560PaymasterStats
+}
+
+// NewRip7560PaymasterIndexer returns a chain indexer that aggregates the RIP-7560
+// sponsorship stats of every paymaster for the canonical chain.
+func NewRip7560PaymasterIndexer(db ethdb.Database, config *params.ChainConfig, size, confirms uint64) *ChainIndexer {
+	backend := &Rip7560PaymasterIndexer{
+		config: config,
+		db:     db,
+		size:   size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.Rip7560PaymasterIndexPrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "rip7560paymasters")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new paymaster stats section.
+func (p *Rip7560PaymasterIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	p.section, p.stats = section, make(map[common.Address]*types.Rip7560PaymasterStats)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the sponsored transactions of
+// a new block into the section stats.
+func (p *Rip7560PaymasterIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+	body := rawdb.ReadBody(p.db, hash, number)
+	if body == nil {
+		return fmt.Errorf("missing body of block #%d [%x]", number, hash)
+	}
+	var receipts types.Receipts
+	for i, tx := range body.Transactions {
+		if tx.Type() != types.Rip7560Type {
+			continue
+		}
+		paymaster := tx.Rip7560TransactionData().Paymaster
+		if paymaster == nil || *paymaster == (common.Address{}) {
+			continue
+		}
+		if receipts == nil {
+			receipts = rawdb.ReadReceipts(p.db, hash, number, header.Time, p.config)
+			if len(receipts) != len(body.Transactions) {
+				return fmt.Errorf("missing receipts of block #%d [%x]", number, hash)
+			}
+		}
+		stats := p.stats[*paymaster]
+		if stats == nil {
+			stats = &types.Rip7560PaymasterStats{
+				FromBlock: p.section * p.size,
+				ToBlock:   (p.section+1)*p.size - 1,
+				GasPaid:   new(big.Int),
+			}
+			p.stats[*paymaster] = stats
+		}
+		receipt := receipts[i]
+		stats.Transactions++
+		stats.GasUsed += receipt.GasUsed
+		stats.GasPaid.Add(stats.GasPaid, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice))
+		if receipt.L1Fee != nil {
+			stats.GasPaid.Add(stats.GasPaid, receipt.L1Fee)
+		}
+		if hasRip7560PostOpRevertReason(receipt.Logs) {
+			stats.PostOpFailures++
+		}
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, replacing the stats previously stored
+// for the section, if any, with the ones just aggregated.
+func (p *Rip7560PaymasterIndexer) Commit() error {
+	rawdb.DeleteRip7560PaymasterStats(p.db, p.section)
+
+	batch := p.db.NewBatch()
+	for paymaster, stats := range p.stats {
+		rawdb.WriteRip7560PaymasterStats(batch, p.section, paymaster, stats)
+	}
+	return batch.Write()
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (p *Rip7560PaymasterIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// hasRip7560PostOpRevertReason reports whether the logs of a transaction contain the
+// event the EntryPoint emits when the paymaster postOp frame fails.
+func hasRip7560PostOpRevertReason(logs []*types.Log) bool {
+	id := Rip7560Abi.Events["RIP7560TransactionPostOpRevertReason"].ID
+	for _, log := range logs {
+		if log.Address == AA_ENTRY_POINT && len(log.Topics) > 0 && log.Topics[0] == id {
+			return true
+		}
+	}
+	return false
+}
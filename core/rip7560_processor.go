@@ -0,0 +1,82 @@
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Rip7560Processor applies RIP-7560 transactions on top of a given state and header.
+// It is the entry point for block builders and sequencers embedding the account
+// abstraction semantics of this package instead of going through the RPC API.
+//
+// The interface is kept stable across releases: its methods and their signatures are
+// never changed or removed. New functionality is exposed through new interfaces, which
+// the value returned by NewRip7560Processor may additionally implement.
+type Rip7560Processor interface {
+	// ValidateTransaction runs the validation phase of the transaction at the given
+	// index of the block. The gas for the whole transaction is bought from the sender
+	// or paymaster and taken from the gas pool. On failure, the caller is responsible
+	// for reverting the state to a snapshot taken beforehand.
+	ValidateTransaction(statedb *state.StateDB, header *types.Header, gp *GasPool, txIndex int, tx *types.Transaction) (*ValidationPhaseResult, error)
+
+	// ExecuteTransaction runs the execution phase of a validated transaction and
+	// returns its receipt. The unused gas is returned to the gas pool and the gas
+	// used is added to usedGas. Starting with the RIP7711 fork, the transactions of a
+	// bundle are all validated before any of them is executed.
+	ExecuteTransaction(statedb *state.StateDB, header *types.Header, gp *GasPool, vpr *ValidationPhaseResult, usedGas *uint64) (*types.Receipt, error)
+
+	// ProcessBundle applies the consecutive RIP-7560 transactions of the bundle, the
+	// first of which is at the given index of the block. Transactions failing their
+	// validation phase are left out and reported in the result.
+	ProcessBundle(statedb *state.StateDB, header *types.Header, gp *GasPool, txIndex int, txs []*types.Transaction, usedGas *uint64) (*Rip7560BundleResult, error)
+}
+
+// Rip7560BundleResult is the outcome of a bundle applied by a Rip7560Processor.
+type Rip7560BundleResult struct {
+	Included []*types.Transaction                 // transactions included in the block
+	Receipts types.Receipts                       // receipts of the included transactions
+	Failures []*types.Rip7560TransactionDebugInfo // transactions failing their validation phase
+	Logs     []*types.Log                         // logs of the included transactions
+}
+
+// rip7560Processor implements Rip7560Processor on top of the transaction phases of this package.
+type rip7560Processor struct {
+	config *params.ChainConfig
+	chain  ChainContext
+	cfg    vm.Config
+}
+
+// NewRip7560Processor creates a Rip7560Processor applying transactions with the rules
+// of the given chain configuration. The chain context is used for the BLOCKHASH opcode.
+func NewRip7560Processor(config *params.ChainConfig, chain ChainContext, cfg vm.Config) Rip7560Processor {
+	return &rip7560Processor{config: config, chain: chain, cfg: cfg}
+}
+
+func (p *rip7560Processor) ValidateTransaction(statedb *state.StateDB, header *types.Header, gp *GasPool, txIndex int, tx *types.Transaction) (*ValidationPhaseResult, error) {
+	statedb.SetTxContext(tx.Hash(), txIndex)
+	vpr, err := ApplyRip7560ValidationPhases(p.config, p.chain, &header.Coinbase, gp, statedb, header, tx, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	vpr.TxIndex = txIndex
+	return vpr, nil
+}
+
+func (p *rip7560Processor) ExecuteTransaction(statedb *state.StateDB, header *types.Header, gp *GasPool, vpr *ValidationPhaseResult, usedGas *uint64) (*types.Receipt, error) {
+	receipt, _, _, err := ApplyRip7560ExecutionPhase(p.config, vpr, p.chain, &header.Coinbase, gp, statedb, header, p.cfg, usedGas)
+	if err != nil {
+		return nil, err
+	}
+	statedb.Finalise(true)
+	return receipt, nil
+}
+
+func (p *rip7560Processor) ProcessBundle(statedb *state.StateDB, header *types.Header, gp *GasPool, txIndex int, txs []*types.Transaction, usedGas *uint64) (*Rip7560BundleResult, error) {
+	included, receipts, failures, logs, err := handleRip7560Transactions(txs, txIndex, statedb, &header.Coinbase, header, gp, p.config, p.chain, p.cfg, true, usedGas)
+	if err != nil {
+		return nil, err
+	}
+	return &Rip7560BundleResult{Included: included, Receipts: receipts, Failures: failures, Logs: logs}, nil
+}
@@ -0,0 +1,363 @@
+package core
+
+import (
+	"encoding/binary"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"math/big"
+	"slices"
+)
+
+// Rip7560ValidationCache keeps the outcome of the validation phases of RIP-7560
+// transactions run at pool admission, keyed by the transaction hash along with a hash
+// of the state they touched. Block building reuses a validation instead of simulating
+// it again as long as none of that state changed, replaying its state changes and logs.
+type Rip7560ValidationCache struct {
+	entries *lru.Cache[common.Hash, *rip7560CachedValidation]
+}
+
+// rip7560ValidationCacheChain is implemented by chains keeping a Rip7560ValidationCache.
+type rip7560ValidationCacheChain interface {
+	Rip7560ValidationCache() *Rip7560ValidationCache
+}
+
+// rip7560CachedValidation is the outcome of the validation phases of a transaction,
+// along with what it depends on besides the touched state.
+type rip7560CachedValidation struct {
+	vpr            *ValidationPhaseResult
+	coinbase       common.Address
+	forks          rip7560ValidationForks
+	priceSensitive bool // Whether the balance of the gas payer was observed after the precharge
+
+	accessList  types.AccessList // Addresses and slots warm at the end of the validation phase
+	touched     types.AccessList // Accounts and slots the validation phase depends on
+	touchedHash common.Hash      // Hash of the state of the touched accounts and slots before validation
+
+	accounts []rip7560AccountWrite // Touched accounts changed by the validation phase
+	slots    []rip7560SlotWrite    // Touched slots changed by the validation phase
+	logs     []*types.Log          // Logs emitted by the validation phase
+}
+
+type rip7560AccountWrite struct {
+	address common.Address
+	nonce   uint64
+	balance *uint256.Int
+	code    []byte // New code of the account, nil if it did not change
+}
+
+type rip7560SlotWrite struct {
+	address common.Address
+	slot    common.Hash
+	value   common.Hash
+}
+
+// rip7560ValidationForks are the forks the validation phase of a transaction depends on.
+type rip7560ValidationForks struct {
+	rules                                                       params.Rules // Without the chain id
+	warmExecution, twoPhase, rip7712, signingDomain, builderFee bool
+	abiVersion                                                  uint64
+}
+
+func rip7560ValidationForksAt(config *params.ChainConfig, header *types.Header) rip7560ValidationForks {
+	rules := config.Rules(header.Number, header.Difficulty.Sign() == 0, header.Time)
+	rules.ChainID = nil
+	return rip7560ValidationForks{
+		rules:         rules,
+		warmExecution: config.IsRIP7560WarmExecution(header.Number),
+		twoPhase:      config.IsRIP7711(header.Number, header.Time),
+		rip7712:       config.IsRIP7712(header.Number, header.Time),
+		signingDomain: config.IsRIP7560SigningDomain(header.Number),
+		builderFee:    config.IsRIP7560BuilderFee(header.Number),
+		abiVersion:    config.RIP7560AbiVersion(header.Number),
+	}
+}
+
+// NewRip7560ValidationCache creates a cache holding the validation phase results of up
+// to the given number of transactions.
+func NewRip7560ValidationCache(size int) *Rip7560ValidationCache {
+	return &Rip7560ValidationCache{
+		entries: lru.NewCache[common.Hash, *rip7560CachedValidation](size),
+	}
+}
+
+// Validate runs the validation phases of the transaction like ApplyRip7560ValidationPhases,
+// and caches their outcome for block building to reuse. The logs of the validation phases
+// are recorded under the hash of the transaction. Validations depending on the block
+// they run in, on transient storage or destroying accounts are not cached.
+func (c *Rip7560ValidationCache) Validate(
+	chainConfig *params.ChainConfig,
+	bc ChainContext,
+	coinbase *common.Address,
+	gp *GasPool,
+	statedb *state.StateDB,
+	header *types.Header,
+	tx *types.Transaction,
+	cfg vm.Config,
+) (*ValidationPhaseResult, error) {
+	forks := rip7560ValidationForksAt(chainConfig, header)
+	if forks.rules.IsEIP4762 {
+		// the witness of the replayed validation would be missing
+		return ApplyRip7560ValidationPhases(chainConfig, bc, coinbase, gp, statedb, header, tx, cfg)
+	}
+	var (
+		pre      = statedb.Copy()
+		recorder = newRip7560ValidationRecorder(tx.Rip7560TransactionData(), *coinbase)
+	)
+	cfg.Tracer = recorder.hooks(cfg.Tracer)
+	statedb.SetTxContext(tx.Hash(), statedb.TxIndex())
+	logsBefore := len(statedb.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{}))
+
+	vpr, err := ApplyRip7560ValidationPhases(chainConfig, bc, coinbase, gp, statedb, header, tx, cfg)
+	if err != nil || recorder.uncacheable {
+		return vpr, err
+	}
+	cached := *vpr
+	entry := &rip7560CachedValidation{
+		vpr:            &cached,
+		coinbase:       *coinbase,
+		forks:          forks,
+		priceSensitive: recorder.priceSensitive,
+		accessList:     statedb.AccessList(),
+	}
+	for _, tuple := range entry.accessList {
+		if tuple.Address == *coinbase && !recorder.coinbaseAccessed {
+			continue
+		}
+		entry.touched = append(entry.touched, tuple)
+	}
+	entry.touchedHash = rip7560TouchedStateHash(pre, entry.touched)
+	for _, tuple := range entry.touched {
+		addr := tuple.Address
+		if pre.Exist(addr) && !statedb.Exist(addr) {
+			return vpr, nil
+		}
+		var (
+			nonce, balance = statedb.GetNonce(addr), statedb.GetBalance(addr)
+			codeChanged    = pre.GetCodeHash(addr) != statedb.GetCodeHash(addr)
+		)
+		if codeChanged || nonce != pre.GetNonce(addr) || !balance.Eq(pre.GetBalance(addr)) {
+			write := rip7560AccountWrite{address: addr, nonce: nonce, balance: balance.Clone()}
+			if codeChanged {
+				// not nil even if an EIP-7702 authorization cleared the code
+				write.code = append([]byte{}, statedb.GetCode(addr)...)
+			}
+			entry.accounts = append(entry.accounts, write)
+		}
+		for _, slot := range tuple.StorageKeys {
+			if value := statedb.GetState(addr, slot); value != pre.GetState(addr, slot) {
+				entry.slots = append(entry.slots, rip7560SlotWrite{address: addr, slot: slot, value: value})
+			}
+		}
+	}
+	for _, log := range statedb.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{})[logsBefore:] {
+		entry.logs = append(entry.logs, &types.Log{
+			Address: log.Address,
+			Topics:  slices.Clone(log.Topics),
+			Data:    slices.Clone(log.Data),
+		})
+	}
+	c.entries.Add(tx.Hash(), entry)
+	return vpr, nil
+}
+
+// reuse replays the cached validation phases of the transaction on top of the given
+// state, taking their gas from the pool, if none of the state they touched changed since
+// they were cached. It returns nil, leaving the state and gas pool untouched, if the
+// validation phases have to run again instead.
+func (c *Rip7560ValidationCache) reuse(
+	chainConfig *params.ChainConfig,
+	coinbase *common.Address,
+	gp *GasPool,
+	statedb *state.StateDB,
+	header *types.Header,
+	tx *types.Transaction,
+	cfg vm.Config,
+) *ValidationPhaseResult {
+	entry, ok := c.entries.Get(tx.Hash())
+	if !ok || cfg.Tracer != nil || entry.coinbase != *coinbase {
+		return nil
+	}
+	if entry.forks != rip7560ValidationForksAt(chainConfig, header) {
+		return nil
+	}
+	if entry.touchedHash != rip7560TouchedStateHash(statedb, entry.touched) {
+		return nil
+	}
+	cached := entry.vpr
+	if validateValidityTimeRange(header.Time, cached.SenderValidAfter, cached.SenderValidUntil) != nil ||
+		validateValidityTimeRange(header.Time, cached.PmValidAfter, cached.PmValidUntil) != nil {
+		return nil
+	}
+
+	// The precharge depends on the block the transaction is included in, and is taken
+	// again from the payer unless the validation phase observed it.
+	aatx := tx.Rip7560TransactionData()
+	gasPrice := uint256.MustFromBig(aatx.EffectiveGasPrice(header.BaseFee))
+	rollupCost, err := CalculateRollupCost(chainConfig, header, tx, statedb)
+	if err != nil {
+		return nil
+	}
+	gasLimit, err := aatx.TotalGasLimit()
+	if err != nil || gasLimit > header.GasLimit {
+		return nil
+	}
+	preCharge, overflow := new(uint256.Int).MulOverflow(new(uint256.Int).SetUint64(gasLimit), gasPrice)
+	if _, overflow2 := preCharge.AddOverflow(preCharge, rollupCost); overflow || overflow2 {
+		return nil
+	}
+	if _, overflow = preCharge.AddOverflow(preCharge, cached.BuilderFee); overflow {
+		return nil
+	}
+	if !preCharge.Eq(cached.PreCharge) && entry.priceSensitive {
+		return nil
+	}
+	payer := *aatx.GasPayer()
+	if statedb.GetBalance(payer).Cmp(preCharge) < 0 {
+		return nil
+	}
+	if err := gp.SubGas(gasLimit + rollupCost.Uint64()); err != nil {
+		return nil
+	}
+
+	for _, write := range entry.accounts {
+		statedb.SetNonce(write.address, write.nonce)
+		statedb.SetBalance(write.address, write.balance, tracing.BalanceChangeUnspecified)
+		if write.code != nil {
+			statedb.SetCode(write.address, write.code)
+		}
+	}
+	for _, write := range entry.slots {
+		statedb.SetState(write.address, write.slot, write.value)
+	}
+	if !preCharge.Eq(cached.PreCharge) {
+		balance := new(uint256.Int).Add(statedb.GetBalance(payer), cached.PreCharge)
+		statedb.SetBalance(payer, balance.Sub(balance, preCharge), tracing.BalanceChangeUnspecified)
+	}
+	for _, log := range entry.logs {
+		statedb.AddLog(&types.Log{
+			Address:     log.Address,
+			Topics:      slices.Clone(log.Topics),
+			Data:        slices.Clone(log.Data),
+			BlockNumber: header.Number.Uint64(),
+		})
+	}
+	statedb.SetAccessList(entry.accessList)
+	statedb.ResetTransientStorage()
+	statedb.Finalise(true)
+
+	vpr := *cached
+	vpr.Tx, vpr.TxHash = tx, tx.Hash()
+	vpr.EffectiveGasPrice, vpr.PreCharge = gasPrice, preCharge
+	return &vpr
+}
+
+// rip7560TouchedStateHash hashes the existence, nonce, balance and code of the accounts
+// along with the values of the storage slots.
+func rip7560TouchedStateHash(statedb *state.StateDB, touched types.AccessList) common.Hash {
+	var enc []byte
+	for _, tuple := range touched {
+		addr := tuple.Address
+		enc = append(enc, addr.Bytes()...)
+		if !statedb.Exist(addr) {
+			enc = append(enc, 0)
+		} else {
+			balance := statedb.GetBalance(addr).Bytes32()
+			codeHash := statedb.GetCodeHash(addr)
+			enc = append(enc, 1)
+			enc = binary.BigEndian.AppendUint64(enc, statedb.GetNonce(addr))
+			enc = append(enc, balance[:]...)
+			enc = append(enc, codeHash.Bytes()...)
+		}
+		for _, slot := range tuple.StorageKeys {
+			value := statedb.GetState(addr, slot)
+			enc = append(enc, slot.Bytes()...)
+			enc = append(enc, value.Bytes()...)
+		}
+	}
+	return crypto.Keccak256Hash(enc)
+}
+
+// rip7560ValidationRecorder watches the validation phases of a transaction for what
+// they depend on besides the state they touch.
+type rip7560ValidationRecorder struct {
+	payer    common.Address
+	coinbase common.Address
+
+	uncacheable      bool // Whether the block, transient storage or a destroyed account were used
+	priceSensitive   bool // Whether the balance of the payer was read or spent
+	coinbaseAccessed bool // Whether the coinbase was accessed besides being warm
+}
+
+func newRip7560ValidationRecorder(aatx *types.Rip7560AccountAbstractionTx, coinbase common.Address) *rip7560ValidationRecorder {
+	r := &rip7560ValidationRecorder{
+		payer:    *aatx.GasPayer(),
+		coinbase: coinbase,
+	}
+	for _, entity := range []*common.Address{aatx.Sender, aatx.Paymaster, aatx.Deployer} {
+		if entity != nil && *entity == coinbase {
+			r.coinbaseAccessed = true
+		}
+	}
+	return r
+}
+
+// hooks returns the given tracer hooks extended with the recorder.
+func (r *rip7560ValidationRecorder) hooks(inner *tracing.Hooks) *tracing.Hooks {
+	hooks := new(tracing.Hooks)
+	if inner != nil {
+		*hooks = *inner
+	}
+	onOpcode, onEnter := hooks.OnOpcode, hooks.OnEnter
+	hooks.OnOpcode = func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+		r.onOpcode(vm.OpCode(op), scope)
+		if onOpcode != nil {
+			onOpcode(pc, op, gas, cost, scope, rData, depth, err)
+		}
+	}
+	hooks.OnEnter = func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+		if from == r.coinbase || to == r.coinbase {
+			r.coinbaseAccessed = true
+		}
+		if from == r.payer && value != nil && value.Sign() > 0 {
+			r.priceSensitive = true
+		}
+		if onEnter != nil {
+			onEnter(depth, typ, from, to, input, gas, value)
+		}
+	}
+	return hooks
+}
+
+func (r *rip7560ValidationRecorder) onOpcode(op vm.OpCode, scope tracing.OpContext) {
+	switch op {
+	case vm.BLOCKHASH, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.PREVRANDAO, vm.GASLIMIT,
+		vm.BASEFEE, vm.BLOBHASH, vm.BLOBBASEFEE, vm.GASPRICE, vm.TLOAD, vm.TSTORE, vm.SELFDESTRUCT:
+		r.uncacheable = true
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.EXTCODEHASH:
+		stack := scope.StackData()
+		if len(stack) == 0 {
+			return
+		}
+		addr := common.Address(stack[len(stack)-1].Bytes20())
+		if addr == r.coinbase {
+			r.coinbaseAccessed = true
+		}
+		if op == vm.BALANCE && addr == r.payer {
+			r.priceSensitive = true
+		}
+	case vm.SELFBALANCE:
+		if scope.Address() == r.coinbase {
+			r.coinbaseAccessed = true
+		}
+		if scope.Address() == r.payer {
+			r.priceSensitive = true
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// rip7560AcceptingAccount returns the code of an account running the given code, then
+// accepting the transaction by calling the EntryPoint with the 'acceptAccount' calldata
+// appended to the code.
+func rip7560AcceptingAccount(t *testing.T, prefix ...vm.OpCode) []byte {
+	acceptAccount, err := Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	size := byte(len(acceptAccount))
+	code := make([]byte, 0, len(prefix)+24+len(acceptAccount))
+	for _, op := range prefix {
+		code = append(code, byte(op))
+	}
+	offset := byte(len(code) + 24)
+	code = append(code,
+		byte(vm.PUSH1), size, byte(vm.PUSH1), offset, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), 0x75, 0x60, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP),
+	)
+	return append(code, acceptAccount...)
+}
+
+// newRip7560ValidationCacheTest returns a test whose transaction passes validation
+// after running the given code in the account validation frame.
+func newRip7560ValidationCacheTest(t *testing.T, prefix ...vm.OpCode) *rip7560ExecutionTest {
+	test := newRip7560ExecutionTest(t, rip7560AcceptingAccount(t, prefix...))
+	shanghai, cancun := uint64(0), uint64(0)
+	test.config.ShanghaiTime, test.config.CancunTime = &shanghai, &cancun
+	test.config.RIP7560Block = big.NewInt(0)
+	test.config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 50, EIP1559Denominator: 10}
+	test.state.SetBalance(*test.aatx.Sender, uint256.NewInt(params.Ether), 0)
+	test.aatx.GasFeeCap = big.NewInt(10)
+	test.state.Finalise(true)
+	return test
+}
+
+// validate runs the validation phases of the transaction on a copy of the test state,
+// reusing the cached ones if given a cache.
+func (tt *rip7560ExecutionTest) validate(t *testing.T, cache *Rip7560ValidationCache, header *types.Header) (*ValidationPhaseResult, *GasPool, common.Hash, []*types.Log) {
+	var (
+		tx       = types.NewTx(tt.aatx)
+		statedb  = tt.state.Copy()
+		gp       = new(GasPool).AddGas(header.GasLimit)
+		coinbase = common.Address{0xc0}
+		vpr      *ValidationPhaseResult
+		err      error
+	)
+	statedb.SetTxContext(tx.Hash(), 0)
+	if cache != nil {
+		if vpr = cache.reuse(tt.config, &coinbase, gp, statedb, header, tx, vm.Config{}); vpr == nil {
+			return nil, gp, statedb.IntermediateRoot(true), nil
+		}
+	} else if vpr, err = ApplyRip7560ValidationPhases(tt.config, nil, &coinbase, gp, statedb, header, tx, vm.Config{}); err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
+	return vpr, gp, statedb.IntermediateRoot(true), statedb.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{})
+}
+
+// cache validates the transaction on a copy of the test state at the given header,
+// caching the result.
+func (tt *rip7560ExecutionTest) cache(t *testing.T, header *types.Header) *Rip7560ValidationCache {
+	cache := NewRip7560ValidationCache(1)
+	coinbase := common.Address{0xc0}
+	gp := new(GasPool).AddGas(header.GasLimit)
+	if _, err := cache.Validate(tt.config, nil, &coinbase, gp, tt.state.Copy(), header, types.NewTx(tt.aatx), vm.Config{}); err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
+	return cache
+}
+
+func TestRip7560ValidationCacheReuse(t *testing.T) {
+	// the account writes a storage slot and emits a log
+	test := newRip7560ValidationCacheTest(t, vm.PUSH1, 1, vm.PUSH1, 1, vm.SSTORE, vm.PUSH0, vm.PUSH0, vm.LOG0)
+	cache := test.cache(t, test.header)
+
+	// the block pays a different gas price than the pool head
+	header := &types.Header{Number: big.NewInt(2), Time: 12, Difficulty: big.NewInt(0), BaseFee: big.NewInt(5), GasLimit: 30_000_000}
+	want, wantGas, wantRoot, wantLogs := test.validate(t, nil, header)
+	have, haveGas, haveRoot, haveLogs := test.validate(t, cache, header)
+	if have == nil {
+		t.Fatal("cached validation not reused")
+	}
+	have.Tx = want.Tx // built separately, with their own caches
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("validation result mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+	if haveGas.Gas() != wantGas.Gas() {
+		t.Errorf("gas pool mismatch: have %d, want %d", haveGas.Gas(), wantGas.Gas())
+	}
+	if haveRoot != wantRoot {
+		t.Errorf("state root mismatch: have %x, want %x", haveRoot, wantRoot)
+	}
+	if len(wantLogs) != 1 || !reflect.DeepEqual(haveLogs, wantLogs) {
+		t.Errorf("logs mismatch:\nhave %v\nwant %v", haveLogs, wantLogs)
+	}
+
+	// the slot the validation depends on changes before the block
+	test.state.SetState(*test.aatx.Sender, common.Hash{31: 1}, common.Hash{31: 2})
+	if vpr, gp, _, _ := test.validate(t, cache, header); vpr != nil || gp.Gas() != header.GasLimit {
+		t.Error("cached validation reused on changed state")
+	}
+}
+
+func TestRip7560ValidationCacheDependencies(t *testing.T) {
+	var (
+		samePrice  = &types.Header{Number: big.NewInt(2), Time: 12, Difficulty: big.NewInt(0), BaseFee: big.NewInt(0), GasLimit: 30_000_000}
+		otherPrice = &types.Header{Number: big.NewInt(2), Time: 12, Difficulty: big.NewInt(0), BaseFee: big.NewInt(5), GasLimit: 30_000_000}
+	)
+	tests := []struct {
+		name   string
+		code   []vm.OpCode
+		header *types.Header
+		reused bool
+	}{
+		{"independent", []vm.OpCode{vm.PUSH0, vm.POP}, otherPrice, true},
+		{"block", []vm.OpCode{vm.TIMESTAMP, vm.POP}, samePrice, false},
+		{"transient storage", []vm.OpCode{vm.PUSH0, vm.PUSH0, vm.TSTORE}, samePrice, false},
+		{"transient storage read", []vm.OpCode{vm.PUSH0, vm.TLOAD, vm.POP}, samePrice, false},
+		{"payer balance, same price", []vm.OpCode{vm.SELFBALANCE, vm.POP}, samePrice, true},
+		{"payer balance, other price", []vm.OpCode{vm.SELFBALANCE, vm.POP}, otherPrice, false},
+		{"coinbase", []vm.OpCode{vm.PUSH1, 0xc0, vm.PUSH1, 152, vm.SHL, vm.BALANCE, vm.POP}, samePrice, true},
+	}
+	for _, tt := range tests {
+		test := newRip7560ValidationCacheTest(t, tt.code...)
+		cache := test.cache(t, test.header)
+		vpr, _, root, _ := test.validate(t, cache, tt.header)
+		if reused := vpr != nil; reused != tt.reused {
+			t.Errorf("%s: reused %v, want %v", tt.name, reused, tt.reused)
+			continue
+		}
+		if _, _, want, _ := test.validate(t, nil, tt.header); tt.reused && root != want {
+			t.Errorf("%s: state root mismatch: have %x, want %x", tt.name, root, want)
+		}
+	}
+}
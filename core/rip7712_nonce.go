@@ -1,21 +1,70 @@
 package core
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 	"math/big"
-	"slices"
 )
 
-// TODO: accept address as configuration parameter
+// nonceManagerReadGas is the gas available to read a nonce from the NonceManager.
+const nonceManagerReadGas = 100_000
+
+// AA_NONCE_MANAGER is the default address of the RIP-7712 NonceManager.
 var AA_NONCE_MANAGER = common.HexToAddress("0x4200000000000000000000000000000000000024")
 
-func prepareNonceManagerMessage(tx *types.Rip7560AccountAbstractionTx) []byte {
+// Rip7712NonceManager returns the address of the RIP-7712 NonceManager of the chain.
+func Rip7712NonceManager(config *params.ChainConfig) common.Address {
+	if config.RIP7712NonceManager != nil {
+		return *config.RIP7712NonceManager
+	}
+	return AA_NONCE_MANAGER
+}
 
-	return slices.Concat(
-		tx.Sender.Bytes(),
-		math.PaddedBigBytes(tx.NonceKey, 24),
-		math.PaddedBigBytes(big.NewInt(int64(tx.Nonce)), 8),
+func prepareNonceManagerMessage(tx *types.Rip7560AccountAbstractionTx) []byte {
+	return append(
+		PrepareNonceManagerGetMessage(*tx.Sender, tx.NonceKey),
+		math.PaddedBigBytes(new(big.Int).SetUint64(tx.Nonce), 8)...,
 	)
 }
+
+// PrepareNonceManagerGetMessage returns the call data used to read the current nonce
+// of the given sender and RIP-7712 nonce key from the NonceManager.
+func PrepareNonceManagerGetMessage(sender common.Address, nonceKey *big.Int) []byte {
+	return append(sender.Bytes(), math.PaddedBigBytes(nonceKey, 24)...)
+}
+
+// ErrRip7712NonceResult is returned if the NonceManager response is not a 256-bit nonce.
+var ErrRip7712NonceResult = errors.New("malformed RIP-7712 NonceManager result")
+
+// ParseNonceManagerGetResult extracts the sequence number from the NonceManager response.
+// The NonceManager returns a 256-bit nonce with the nonce key in the upper 192 bits
+// and the sequence number in the lower 64 bits.
+func ParseNonceManagerGetResult(returnData []byte) (uint64, error) {
+	if len(returnData) != 32 {
+		return 0, fmt.Errorf("%w: %d bytes", ErrRip7712NonceResult, len(returnData))
+	}
+	return binary.BigEndian.Uint64(returnData[24:]), nil
+}
+
+// ReadRip7560Nonce returns the current nonce of the sender for the given nonce key: the
+// account nonce for the zero key, or the sequence number kept by the NonceManager for
+// RIP-7712 nonce keys.
+func ReadRip7560Nonce(config *params.ChainConfig, chain ChainContext, header *types.Header, statedb *state.StateDB, sender common.Address, nonceKey *big.Int) (uint64, error) {
+	if nonceKey == nil || nonceKey.Sign() == 0 {
+		return statedb.GetNonce(sender), nil
+	}
+	blockContext := NewEVMBlockContext(header, chain, &header.Coinbase, config, statedb)
+	evm := vm.NewEVM(blockContext, vm.TxContext{GasPrice: new(big.Int)}, statedb, config, vm.Config{NoBaseFee: true})
+	ret, _, err := evm.StaticCall(vm.AccountRef(AA_ENTRY_POINT), Rip7712NonceManager(config), PrepareNonceManagerGetMessage(sender, nonceKey), nonceManagerReadGas)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read RIP-7712 nonce for key %#x: %w", nonceKey, err)
+	}
+	return ParseNonceManagerGetResult(ret)
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+func TestPrepareNonceManagerMessage(t *testing.T) {
+	aatx := &types.Rip7560AccountAbstractionTx{
+		Sender:   &common.Address{0x01},
+		NonceKey: big.NewInt(7),
+		Nonce:    1<<63 + 1,
+	}
+	// sender, then the 192-bit nonce key and the 64-bit sequence number, without wrapping around
+	want := append(common.Address{0x01}.Bytes(), common.FromHex("0x0000000000000000000000000000000000000000000000078000000000000001")...)
+	if have := prepareNonceManagerMessage(aatx); !bytes.Equal(have, want) {
+		t.Errorf("message mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestParseNonceManagerGetResult(t *testing.T) {
+	// the nonce key in the upper 192 bits, the sequence number in the lower 64 bits
+	result := common.FromHex("0x0000000000000000000000000000000000000000000000070000000000000102")
+	if nonce, err := ParseNonceManagerGetResult(result); err != nil || nonce != 0x102 {
+		t.Errorf("nonce mismatch: have %d (err %v), want %d", nonce, err, 0x102)
+	}
+	for _, malformed := range [][]byte{nil, result[:8], append(result, 0)} {
+		if _, err := ParseNonceManagerGetResult(malformed); !errors.Is(err, ErrRip7712NonceResult) {
+			t.Errorf("%d bytes: have error %v, want %v", len(malformed), err, ErrRip7712NonceResult)
+		}
+	}
+}
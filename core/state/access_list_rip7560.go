@@ -0,0 +1,56 @@
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"slices"
+)
+
+// accessListEntries returns the addresses and slots of the access list in the form of
+// a transaction access list, sorted by address and slot.
+func (al *accessList) accessListEntries() types.AccessList {
+	addrs := make([]common.Address, 0, len(al.addresses))
+	for addr := range al.addresses {
+		addrs = append(addrs, addr)
+	}
+	slices.SortFunc(addrs, common.Address.Cmp)
+
+	list := make(types.AccessList, 0, len(addrs))
+	for _, addr := range addrs {
+		tuple := types.AccessTuple{Address: addr, StorageKeys: []common.Hash{}}
+		if idx := al.addresses[addr]; idx >= 0 {
+			for slot := range al.slots[idx] {
+				tuple.StorageKeys = append(tuple.StorageKeys, slot)
+			}
+			slices.SortFunc(tuple.StorageKeys, common.Hash.Cmp)
+		}
+		list = append(list, tuple)
+	}
+	return list
+}
+
+// AccessList returns the addresses and storage slots warm in the current transaction.
+// RIP-7560 transactions carry them from their validation phase into their execution
+// phase, which are separated by the validation phases of the other transactions.
+func (s *StateDB) AccessList() types.AccessList {
+	return s.accessList.accessListEntries()
+}
+
+// SetAccessList replaces the access list of the current transaction with the given one,
+// leaving the transient storage untouched. Like Prepare, the change is not journaled.
+func (s *StateDB) SetAccessList(list types.AccessList) {
+	al := newAccessList()
+	for _, tuple := range list {
+		al.AddAddress(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			al.AddSlot(tuple.Address, key)
+		}
+	}
+	s.accessList = al
+}
+
+// ResetTransientStorage clears the transient storage of the current transaction, leaving
+// the access list untouched. Like Prepare, the change is not journaled.
+func (s *StateDB) ResetTransientStorage() {
+	s.transientStorage = newTransientStorage()
+}
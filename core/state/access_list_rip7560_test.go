@@ -0,0 +1,43 @@
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"reflect"
+	"testing"
+)
+
+func TestAccessListRoundTrip(t *testing.T) {
+	state, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	list := types.AccessList{
+		{Address: common.Address{0x01}, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		{Address: common.Address{0x02}, StorageKeys: []common.Hash{}},
+	}
+	// the entries are returned sorted, regardless of the order they were added in
+	state.SetAccessList(types.AccessList{list[1], {Address: list[0].Address, StorageKeys: []common.Hash{{0x02}, {0x01}}}})
+	if have := state.AccessList(); !reflect.DeepEqual(have, list) {
+		t.Fatalf("access list mismatch: have %v, want %v", have, list)
+	}
+	state.transientStorage.Set(common.Address{0x01}, common.Hash{}, common.Hash{0x01})
+	state.SetAccessList(nil)
+	if len(state.AccessList()) != 0 {
+		t.Errorf("access list not replaced: %v", state.AccessList())
+	}
+	if state.GetTransientState(common.Address{0x01}, common.Hash{}) != (common.Hash{0x01}) {
+		t.Error("transient storage reset with the access list")
+	}
+}
+
+func TestResetTransientStorage(t *testing.T) {
+	state, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetAccessList(types.AccessList{{Address: common.Address{0x01}, StorageKeys: []common.Hash{}}})
+	state.transientStorage.Set(common.Address{0x01}, common.Hash{}, common.Hash{0x01})
+	state.ResetTransientStorage()
+	if state.GetTransientState(common.Address{0x01}, common.Hash{}) != (common.Hash{}) {
+		t.Error("transient storage not reset")
+	}
+	if !state.AddressInAccessList(common.Address{0x01}) {
+		t.Error("access list reset with the transient storage")
+	}
+}
@@ -80,11 +80,15 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
 	}
 	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
+	txs := block.Transactions()
+	for i, tx := range txs {
 		if tx.Type() == types.Rip7560Type {
-			// HandleRip7560Transactions accepts a transaction array and in the future bundle handling will need this
-			tmpTxs := [1]*types.Transaction{tx}
-			_, validatedTxsReceipts, _, validateTxsLogs, err := HandleRip7560Transactions(tmpTxs[:], 0, statedb, &context.Coinbase, header, gp, p.config, p.chain, cfg, false, usedGas)
+			// Consecutive RIP-7560 transactions are applied together at their index in the
+			// block, as their validation phases may all run before their execution phases
+			if i > 0 && txs[i-1].Type() == types.Rip7560Type {
+				continue
+			}
+			_, validatedTxsReceipts, _, validateTxsLogs, err := HandleRip7560Transactions(txs, i, statedb, &context.Coinbase, header, gp, p.config, p.chain, cfg, false, usedGas)
 			receipts = append(receipts, validatedTxsReceipts...)
 			allLogs = append(allLogs, validateTxsLogs...)
 			if err != nil {
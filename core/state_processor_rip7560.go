@@ -15,13 +15,27 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 	"math/big"
+	"time"
 )
 
+// EntryPointCall is the EntryPoint callback made by a validation frame, intercepted by the
+// EVM as the frame runs.
 type EntryPointCall struct {
-	OnEnterSuper tracing.EnterHook
-	Input        []byte
-	From         common.Address
-	err          error
+	Input []byte
+	From  common.Address
+	err   error
+
+	frames []*types.Rip7560FrameDebugInfo // outcome of the validation frames run so far
+}
+
+// recordFrame keeps the outcome of a validation frame, reported along with the
+// validation error if the transaction fails validation.
+func (epc *EntryPointCall) recordFrame(entity string, result *ExecutionResult) {
+	epc.frames = append(epc.frames, &types.Rip7560FrameDebugInfo{
+		Entity:     entity,
+		Reverted:   result.Failed(),
+		ReturnData: common.CopyBytes(result.ReturnData),
+	})
 }
 
 type ValidationPhaseResult struct {
@@ -30,10 +44,11 @@ type ValidationPhaseResult struct {
 	TxHash                common.Hash
 	PaymasterContext      []byte
 	PreCharge             *uint256.Int
+	BuilderFee            *uint256.Int // Part of the precharge paid to the block builder
 	EffectiveGasPrice     *uint256.Int
 	PreTransactionGasCost uint64
 	ValidationRefund      uint64
-	CallDataUsedGas       uint64
+	CallDataUsedGas       uint64 // Part of the pre-transaction gas cost charged for the calldata
 	NonceManagerUsedGas   uint64
 	DeploymentUsedGas     uint64
 	ValidationUsedGas     uint64
@@ -42,6 +57,7 @@ type ValidationPhaseResult struct {
 	SenderValidUntil      uint64
 	PmValidAfter          uint64
 	PmValidUntil          uint64
+	WarmAccessList        types.AccessList // Addresses and slots warm at the end of the validation phase
 }
 
 func (vpr *ValidationPhaseResult) ValidationPhaseUsedGas() (uint64, error) {
@@ -54,6 +70,8 @@ func (vpr *ValidationPhaseResult) ValidationPhaseUsedGas() (uint64, error) {
 	)
 }
 
+// Execution statuses of an RIP-7560 transaction, reported by the RIP7560TransactionEvent
+// and the receipt.
 const (
 	ExecutionStatusSuccess                   = uint64(0)
 	ExecutionStatusExecutionFailure          = uint64(1)
@@ -61,14 +79,37 @@ const (
 	ExecutionStatusExecutionAndPostOpFailure = uint64(3)
 )
 
+// ErrRip7560ValidationTimeout is returned if the validation phase of an RIP-7560 transaction
+// runs for longer than the configured wall-clock limit.
+var ErrRip7560ValidationTimeout = errors.New("RIP-7560 validation timed out")
+
+// ErrRip7560GasLimitExceeded is returned if an RIP-7560 transaction is accounted more gas
+// than its total gas limit. It can only be caused by a bug in the gas accounting, and the
+// state the transaction was applied to has to be discarded.
+var ErrRip7560GasLimitExceeded = errors.New("RIP-7560 transaction used more gas than its total limit")
+
+// ErrRip7560GasLimitAboveBlock is returned if the total gas limit of an RIP-7560 transaction
+// exceeds the gas limit of the block, so that it can never be included.
+var ErrRip7560GasLimitAboveBlock = errors.New("RIP-7560 transaction gas limit exceeds the block gas limit")
+
 // ValidationPhaseError is an API error that encompasses an EVM revert with JSON error
 // code and a binary data blob.
 type ValidationPhaseError struct {
 	error
+	cause  error  // error the validation phase failed with, if any
 	reason string // revert reason hex encoded
 
 	revertEntityName *string
 	frameReverted    bool
+	frames           []*types.Rip7560FrameDebugInfo // validation frames run before the failure, if any
+	timedOut         bool
+}
+
+func (v *ValidationPhaseError) Unwrap() error {
+	if v.timedOut {
+		return ErrRip7560ValidationTimeout
+	}
+	return v.cause
 }
 
 func (v *ValidationPhaseError) ErrorData() interface{} {
@@ -117,6 +158,7 @@ func newValidationPhaseError(
 	}
 	return &ValidationPhaseError{
 		error:  err,
+		cause:  innerErr,
 		reason: hexutil.Encode(revertReason),
 
 		frameReverted:    frameReverted,
@@ -124,9 +166,22 @@ func newValidationPhaseError(
 	}
 }
 
+// newRip7560TimeoutError returns the error of a validation phase that timed out, blaming
+// the entity of the frame running when the EVM was cancelled.
+func newRip7560TimeoutError(frames []*types.Rip7560FrameDebugInfo) *ValidationPhaseError {
+	var entity *string
+	if len(frames) > 0 {
+		entity = ptr(frames[len(frames)-1].Entity)
+	}
+	vpe := newValidationPhaseError(ErrRip7560ValidationTimeout, nil, entity, false)
+	vpe.timedOut = true
+	return vpe
+}
+
 // HandleRip7560Transactions apply state changes of all sequential RIP-7560 transactions.
 // During block building the 'skipInvalid' flag is set to False, and invalid transactions are silently ignored.
-// Returns an array of included transactions.
+// Returns an array of included transactions. A transaction failing its execution phase
+// during block building aborts the whole call, its debug info returned with the error.
 func HandleRip7560Transactions(
 	transactions []*types.Transaction,
 	index int,
@@ -145,10 +200,10 @@ func HandleRip7560Transactions(
 	allLogs := make([]*types.Log, 0)
 
 	iTransactions, iReceipts, validationFailureReceipts, iLogs, err := handleRip7560Transactions(
-		transactions, index, statedb, coinbase, header, gp, chainConfig, bc, cfg, skipInvalid, usedGas,
+		transactions[index:], index, statedb, coinbase, header, gp, chainConfig, bc, cfg, skipInvalid, usedGas,
 	)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, nil, validationFailureReceipts, nil, err
 	}
 	validatedTransactions = append(validatedTransactions, iTransactions...)
 	receipts = append(receipts, iReceipts...)
@@ -156,6 +211,46 @@ func HandleRip7560Transactions(
 	return validatedTransactions, receipts, validationFailureReceipts, allLogs, nil
 }
 
+// HandleRip7560Bundle applies all transactions of the bundle atomically, in their original
+// order, the first of them at the given index of the block. They are applied to a copy of
+// the state, returned along with their receipts only if all of them pass validation; the
+// gas pool and used gas are only consumed in that case. Otherwise, the given state is left
+// untouched and the validation failures are returned instead, along with the error of a
+// transaction failing its execution phase, such as ErrRip7560GasLimitExceeded.
+func HandleRip7560Bundle(
+	bundle *types.ExternallyReceivedBundle,
+	index int,
+	statedb *state.StateDB,
+	coinbase *common.Address,
+	header *types.Header,
+	gp *GasPool,
+	chainConfig *params.ChainConfig,
+	bc ChainContext,
+	cfg vm.Config,
+	usedGas *uint64,
+) (*state.StateDB, types.Receipts, []*types.Rip7560TransactionDebugInfo, error) {
+	var (
+		bundleState   = statedb.Copy()
+		bundleGasPool = *gp
+		bundleUsedGas = *usedGas
+	)
+	validatedTxs, receipts, validationFailureInfos, _, err := handleRip7560Transactions(
+		bundle.Transactions, index, bundleState, coinbase, header, &bundleGasPool, chainConfig, bc, cfg, true, &bundleUsedGas,
+	)
+	if err != nil {
+		return nil, nil, validationFailureInfos, err
+	}
+	if len(validatedTxs) != len(bundle.Transactions) {
+		return nil, nil, validationFailureInfos, nil
+	}
+	*gp, *usedGas = bundleGasPool, bundleUsedGas
+	return bundleState, receipts, nil, nil
+}
+
+// handleRip7560Transactions applies the leading RIP-7560 transactions of the list, the
+// first of which is at the given index of the block. Starting with the RIP7711 fork, the
+// validation phases of all of them run first, then their execution phases, so that no
+// validation depends on the execution of another transaction.
 func handleRip7560Transactions(
 	transactions []*types.Transaction,
 	index int,
@@ -170,19 +265,74 @@ func handleRip7560Transactions(
 	usedGas *uint64,
 ) ([]*types.Transaction, types.Receipts, []*types.Rip7560TransactionDebugInfo, []*types.Log, error) {
 	validationPhaseResults := make([]*ValidationPhaseResult, 0)
+	validationGasCharged := make([]uint64, 0) // gas taken from the pool by each validation phase
 	validatedTransactions := make([]*types.Transaction, 0)
 	validationFailureInfos := make([]*types.Rip7560TransactionDebugInfo, 0)
 	receipts := make([]*types.Receipt, 0)
 	allLogs := make([]*types.Log, 0)
-	for i, tx := range transactions[index:] {
+
+	// Block building reuses the validations run at pool admission where it can
+	var cache *Rip7560ValidationCache
+	if chain, ok := bc.(rip7560ValidationCacheChain); ok && skipInvalid {
+		cache = chain.Rip7560ValidationCache()
+	}
+	twoPhase := chainConfig.IsRIP7711(header.Number, header.Time)
+	execute := func(vpr *ValidationPhaseResult, gasCharged uint64) error {
+		gasPoolBefore := gp.Gas() + gasCharged
+		receipt, _, _, err := ApplyRip7560ExecutionPhase(chainConfig, vpr, bc, coinbase, gp, statedb, header, cfg, usedGas)
+		if err != nil {
+			// The state is left partially applied, so the builder drops the transaction
+			// along with the rest of the batch.
+			if skipInvalid && errors.Is(err, ErrRip7560GasLimitExceeded) {
+				validationFailureInfos = append(validationFailureInfos, &types.Rip7560TransactionDebugInfo{
+					TxHash:           vpr.TxHash,
+					RevertData:       err.Error(),
+					RevertEntityName: "n/a",
+				})
+			}
+			return err
+		}
+		if cfg.Rip7560GasInvariants {
+			if err := checkRip7560GasPool(vpr.TxHash, gasPoolBefore, gp.Gas(), receipt.GasUsed); err != nil {
+				return err
+			}
+		}
+		statedb.Finalise(true)
+
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+		return nil
+	}
+	for i, tx := range transactions {
 		if tx.Type() != types.Rip7560Type {
 			break
 		}
 
 		statedb.SetTxContext(tx.Hash(), index+i)
 		beforeValidationSnapshotId := statedb.Snapshot()
-		vpr, vpe := ApplyRip7560ValidationPhases(chainConfig, bc, coinbase, gp, statedb, header, tx, cfg)
+		gasPoolBefore := gp.Gas()
+		var (
+			vpr *ValidationPhaseResult
+			vpe error
+		)
+		if cache != nil {
+			vpr = cache.reuse(chainConfig, coinbase, gp, statedb, header, tx, cfg)
+		}
+		if vpr == nil {
+			vpr, vpe = ApplyRip7560ValidationPhases(chainConfig, bc, coinbase, gp, statedb, header, tx, cfg)
+		}
 		if vpe != nil {
+			// Not fitting in the rest of the block, the transaction is left for the next one,
+			// unless it can never fit in any block
+			if skipInvalid && errors.Is(vpe, ErrGasLimitReached) {
+				totalGasLimit, _ := tx.Rip7560TransactionData().TotalGasLimit()
+				if totalGasLimit <= header.GasLimit {
+					log.Debug("Skipping RIP-7560 transaction exceeding the remaining block gas", "tx", tx.Hash(), "error", vpe)
+					statedb.RevertToSnapshot(beforeValidationSnapshotId)
+					continue
+				}
+				vpe = newValidationPhaseError(fmt.Errorf("%w: tx %v gas limit %d, block gas limit %d", ErrRip7560GasLimitAboveBlock, tx.Hash(), totalGasLimit, header.GasLimit), nil, ptr("block gas limit"), false)
+			}
 			if skipInvalid {
 				log.Error("Validation failed during block building, should not happen, skipping transaction", "error", vpe)
 				debugInfo := &types.Rip7560TransactionDebugInfo{
@@ -196,6 +346,8 @@ func handleRip7560Transactions(
 				if errors.As(vpe, &vpeCast) {
 					debugInfo.RevertData = vpeCast.reason
 					debugInfo.FrameReverted = vpeCast.frameReverted
+					debugInfo.Frames = vpeCast.frames
+					debugInfo.TimedOut = vpeCast.timedOut
 					debugInfo.RevertEntityName = ""
 					if vpeCast.revertEntityName != nil {
 						debugInfo.RevertEntityName = *vpeCast.revertEntityName
@@ -206,29 +358,45 @@ func handleRip7560Transactions(
 			}
 			return nil, nil, nil, nil, vpe
 		}
-		validationPhaseResults = append(validationPhaseResults, vpr)
+		vpr.TxIndex = index + i
 		validatedTransactions = append(validatedTransactions, tx)
-
-		// This is the line separating the Validation and Execution phases
-		// It should be separated to implement the mempool-friendly AA RIP-7711
-		// for i, vpr := range validationPhaseResults
-
-		// TODO: this will miss all validation phase events - pass in 'vpr'
-		// statedb.SetTxContext(vpr.Tx.Hash(), i)
-
-		receipt, _, _, err := ApplyRip7560ExecutionPhase(chainConfig, vpr, bc, coinbase, gp, statedb, header, cfg, usedGas)
-
-		if err != nil {
-			return nil, nil, nil, nil, err
+		if twoPhase {
+			validationPhaseResults = append(validationPhaseResults, vpr)
+			validationGasCharged = append(validationGasCharged, gasPoolBefore-gp.Gas())
+			continue
 		}
-		statedb.Finalise(true)
-
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
+		if err := execute(vpr, gasPoolBefore-gp.Gas()); err != nil {
+			return nil, nil, validationFailureInfos, nil, err
+		}
+	}
+	for i, vpr := range validationPhaseResults {
+		if err := execute(vpr, validationGasCharged[i]); err != nil {
+			return nil, nil, validationFailureInfos, nil, err
+		}
+	}
+	if twoPhase {
+		reindexRip7560Logs(allLogs)
 	}
 	return validatedTransactions, receipts, validationFailureInfos, allLogs, nil
 }
 
+// reindexRip7560Logs numbers the logs of consecutive RIP-7560 transactions in the order
+// of their receipts, starting from the lowest index among them. Processed in two phases,
+// the logs of all validation phases are added before those of the execution phases.
+func reindexRip7560Logs(logs []*types.Log) {
+	if len(logs) == 0 {
+		return
+	}
+	next := logs[0].Index
+	for _, log := range logs {
+		next = min(next, log.Index)
+	}
+	for _, log := range logs {
+		log.Index = next
+		next++
+	}
+}
+
 func CalculateRollupCost(
 	chainConfig *params.ChainConfig,
 	header *types.Header,
@@ -245,26 +413,48 @@ func CalculateRollupCost(
 	return uint256.MustFromBig(L1CostFunc(tx.RollupCostData(), header.Time)), nil
 }
 
+// Rip7560BuilderFee returns the fee the gas payer of an RIP-7560 transaction pays to the
+// block builder on top of its gas cost. It is only charged from the RIP7560BuilderFee
+// fork on.
+func Rip7560BuilderFee(config *params.ChainConfig, header *types.Header, aatx *types.Rip7560AccountAbstractionTx) *uint256.Int {
+	fee := new(uint256.Int)
+	if config.IsRIP7560BuilderFee(header.Number) && aatx.BuilderFee != nil {
+		fee.SetFromBig(aatx.BuilderFee)
+	}
+	return fee
+}
+
 func BuyGasRip7560Transaction(
 	st *types.Rip7560AccountAbstractionTx,
 	state vm.StateDB,
 	gasPrice *uint256.Int,
 	gp *GasPool,
 	rollupCost *uint256.Int,
+	builderFee *uint256.Int,
 ) (uint64, *uint256.Int, error) {
 	gasLimit, err := st.TotalGasLimit()
 	if err != nil {
 		return 0, nil, err
 	}
 
-	//TODO: check gasLimit against block gasPool
-	preCharge := new(uint256.Int).SetUint64(gasLimit)
-	preCharge = preCharge.Mul(preCharge, gasPrice)
+	preCharge, overflow := new(uint256.Int).MulOverflow(new(uint256.Int).SetUint64(gasLimit), gasPrice)
+	if overflow {
+		return 0, nil, fmt.Errorf("%w: RIP-7560 gas limit %d at gas price %v", ErrInsufficientFunds, gasLimit, gasPrice)
+	}
 
 	// adjust rollup cost
 	gasLimit += rollupCost.Uint64()
-	preCharge = preCharge.Add(preCharge, rollupCost)
+	if _, overflow = preCharge.AddOverflow(preCharge, rollupCost); overflow {
+		return 0, nil, fmt.Errorf("%w: RIP-7560 gas limit %d at gas price %v", ErrInsufficientFunds, gasLimit, gasPrice)
+	}
+	if _, overflow = preCharge.AddOverflow(preCharge, builderFee); overflow {
+		return 0, nil, fmt.Errorf("%w: RIP-7560 gas limit %d at gas price %v, builder fee %v", ErrInsufficientFunds, gasLimit, gasPrice, builderFee)
+	}
 
+	// The transaction is checked to fit in the rest of the block before charging the payer
+	if have := gp.Gas(); have < gasLimit {
+		return 0, nil, newValidationPhaseError(fmt.Errorf("%w: have %d, want %d", ErrGasLimitReached, have, gasLimit), nil, ptr("block gas limit"), false)
+	}
 	chargeFrom := st.GasPayer()
 
 	if have, want := state.GetBalance(*chargeFrom), preCharge; have.Cmp(want) < 0 {
@@ -272,29 +462,32 @@ func BuyGasRip7560Transaction(
 	}
 
 	state.SubBalance(*chargeFrom, preCharge, 0)
-	if err := gp.SubGas(gasLimit); err != nil {
-		return 0, nil, newValidationPhaseError(err, nil, ptr("block gas limit"), false)
-	}
+	gp.SubGas(gasLimit)
 	return gasLimit, preCharge, nil
 }
 
-// refund the transaction payer (either account or paymaster) with the excess gas cost
-func refundPayer(vpr *ValidationPhaseResult, state vm.StateDB, gasUsed uint64) {
+// refund the transaction payer (either account or paymaster) with the excess gas cost,
+// keeping the builder fee
+func refundPayer(vpr *ValidationPhaseResult, state vm.StateDB, gasUsed uint64) *uint256.Int {
 	var chargeFrom = vpr.Tx.Rip7560TransactionData().GasPayer()
 
 	actualGasCost := new(uint256.Int).Mul(vpr.EffectiveGasPrice, new(uint256.Int).SetUint64(gasUsed))
 
 	refund := new(uint256.Int).Sub(vpr.PreCharge, actualGasCost)
+	if vpr.BuilderFee != nil {
+		refund.Sub(refund, vpr.BuilderFee)
+	}
 
 	state.AddBalance(*chargeFrom, refund, tracing.BalanceIncreaseGasReturn)
+	return refund
 }
 
 // CheckNonceRip7560 checks nonce of RIP-7560 transactions.
 // Transactions that don't rely on RIP-7712 two-dimensional nonces are checked statically.
 // Transactions using RIP-7712 two-dimensional nonces execute an extra validation frame on-chain.
-func CheckNonceRip7560(st *StateTransition, tx *types.Rip7560AccountAbstractionTx) (uint64, error) {
+func CheckNonceRip7560(st *StateTransition, epc *EntryPointCall, tx *types.Rip7560AccountAbstractionTx) (uint64, error) {
 	if tx.IsRip7712Nonce() {
-		return performNonceCheckFrameRip7712(st, tx)
+		return performNonceCheckFrameRip7712(st, epc, tx)
 	}
 	stNonce := st.state.GetNonce(*tx.Sender)
 	if msgNonce := tx.Nonce; stNonce < msgNonce {
@@ -310,12 +503,14 @@ func CheckNonceRip7560(st *StateTransition, tx *types.Rip7560AccountAbstractionT
 	return 0, nil
 }
 
-func performNonceCheckFrameRip7712(st *StateTransition, tx *types.Rip7560AccountAbstractionTx) (uint64, error) {
-	if !st.evm.ChainConfig().IsRIP7712(st.evm.Context.BlockNumber) {
+func performNonceCheckFrameRip7712(st *StateTransition, epc *EntryPointCall, tx *types.Rip7560AccountAbstractionTx) (uint64, error) {
+	if !st.evm.ChainConfig().IsRIP7712(st.evm.Context.BlockNumber, st.evm.Context.Time) {
 		return 0, wrapError(fmt.Errorf("RIP-7712 nonce is disabled"))
 	}
+	nonceManager := Rip7712NonceManager(st.evm.ChainConfig())
 	nonceManagerMessageData := prepareNonceManagerMessage(tx)
-	resultNonceManager := CallFrame(st, &AA_ENTRY_POINT, &AA_NONCE_MANAGER, nonceManagerMessageData, st.gasRemaining)
+	resultNonceManager := CallFrame(st, &AA_ENTRY_POINT, &nonceManager, nonceManagerMessageData, st.gasRemaining)
+	epc.recordFrame("nonce", resultNonceManager)
 	if resultNonceManager.Failed() {
 		return 0, newValidationPhaseError(
 			fmt.Errorf("RIP-7712 nonce validation failed: %w", resultNonceManager.Err),
@@ -329,6 +524,20 @@ func performNonceCheckFrameRip7712(st *StateTransition, tx *types.Rip7560Account
 
 // call a frame in the context of this state transition.
 func CallFrame(st *StateTransition, from *common.Address, to *common.Address, data []byte, gasLimit uint64) *ExecutionResult {
+	// the frame pays for the witness of its caller and callee before any code runs
+	if witnessGas := rip7560FrameWitnessGas(st.evm, *from, *to); witnessGas > gasLimit {
+		st.gasRemaining -= gasLimit
+		return &ExecutionResult{UsedGas: gasLimit, Err: vm.ErrOutOfGas}
+	} else if witnessGas > 0 {
+		st.gasRemaining -= witnessGas
+		result := callFrame(st, from, to, data, gasLimit-witnessGas)
+		result.UsedGas += witnessGas
+		return result
+	}
+	return callFrame(st, from, to, data, gasLimit)
+}
+
+func callFrame(st *StateTransition, from *common.Address, to *common.Address, data []byte, gasLimit uint64) *ExecutionResult {
 	sender := vm.AccountRef(*from)
 	retData, gasRemaining, err := st.evm.Call(sender, *to, data, gasLimit, uint256.NewInt(0))
 	usedGas := gasLimit - gasRemaining
@@ -343,6 +552,47 @@ func CallFrame(st *StateTransition, from *common.Address, to *common.Address, da
 
 func ptr(s string) *string { return &s }
 
+// warmRip7560Entities adds the paymaster and deployer of the transaction to the access
+// list. Like the sender, they are called in top-level frames, so they are accessed
+// before any of their code runs.
+func warmRip7560Entities(statedb *state.StateDB, aatx *types.Rip7560AccountAbstractionTx) {
+	if aatx.Paymaster != nil {
+		statedb.AddAddressToAccessList(*aatx.Paymaster)
+	}
+	if aatx.Deployer != nil {
+		statedb.AddAddressToAccessList(*aatx.Deployer)
+	}
+}
+
+// addRip7560AccessEvents starts the access events of the EVM, as Reset does for other
+// transactions, and adds the gas payer of the transaction to them, as AddTxOrigin does
+// for the sender of other transactions, so the gas purchase feeds the witness of
+// stateless clients free of charge.
+func addRip7560AccessEvents(evm *vm.EVM, aatx *types.Rip7560AccountAbstractionTx) {
+	if !evm.ChainConfig().IsEIP4762(evm.Context.BlockNumber, evm.Context.Time) {
+		return
+	}
+	if evm.AccessEvents == nil {
+		evm.AccessEvents = state.NewAccessEvents(evm.StateDB.PointCache())
+	}
+	// the sender's nonce is incremented, and its balance pays for gas without a paymaster
+	evm.AccessEvents.AddTxOrigin(*aatx.Sender)
+	if aatx.Paymaster != nil {
+		evm.AccessEvents.BalanceGas(*aatx.Paymaster, true)
+	}
+}
+
+// rip7560FrameWitnessGas returns the EIP-4762 witness gas of a top-level frame calling the
+// given account, charged like a CALL for the basic data of both the caller and the callee.
+// The EntryPoint, sender, paymaster, deployer and NonceManager are thus paid for by the
+// first frame accessing them, and are warm for the later ones.
+func rip7560FrameWitnessGas(evm *vm.EVM, from common.Address, to common.Address) uint64 {
+	if !evm.ChainConfig().IsEIP4762(evm.Context.BlockNumber, evm.Context.Time) {
+		return 0
+	}
+	return evm.AccessEvents.MessageCallGas(from) + evm.AccessEvents.MessageCallGas(to)
+}
+
 func ApplyRip7560ValidationPhases(
 	chainConfig *params.ChainConfig,
 	bc ChainContext,
@@ -353,14 +603,26 @@ func ApplyRip7560ValidationPhases(
 	tx *types.Transaction,
 	cfg vm.Config,
 	allowSigFailFlag ...bool,
-) (*ValidationPhaseResult, error) {
+) (vpr *ValidationPhaseResult, err error) {
+	epc := &EntryPointCall{}
+	defer func() {
+		// report the outcome of the frames that ran along with the validation error
+		var vpe *ValidationPhaseError
+		if errors.As(err, &vpe) {
+			vpe.frames = epc.frames
+		}
+	}()
 	var allowSigFail = false
 	if len(allowSigFailFlag) > 0 && allowSigFailFlag[0] {
 		allowSigFail = allowSigFailFlag[0]
 	}
 
 	aatx := tx.Rip7560TransactionData()
-	err := performStaticValidation(aatx, statedb)
+	err = performStaticValidation(chainConfig, header, aatx, statedb)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	entryPointAbi, err := Rip7560EntryPointAbiAt(chainConfig, header.Number)
 	if err != nil {
 		return nil, wrapError(err)
 	}
@@ -374,7 +636,8 @@ func ApplyRip7560ValidationPhases(
 		return nil, err
 	}
 
-	gasLimit, preCharge, err := BuyGasRip7560Transaction(aatx, statedb, effectiveGasPrice, gp, rollupCost)
+	builderFee := Rip7560BuilderFee(chainConfig, header, aatx)
+	gasLimit, preCharge, err := BuyGasRip7560Transaction(aatx, statedb, effectiveGasPrice, gp, rollupCost, builderFee)
 	if err != nil {
 		return nil, wrapError(err)
 	}
@@ -389,22 +652,23 @@ func ApplyRip7560ValidationPhases(
 	rules := evm.ChainConfig().Rules(evm.Context.BlockNumber, evm.Context.Random != nil, evm.Context.Time)
 
 	statedb.Prepare(rules, *sender, evm.Context.Coinbase, &AA_ENTRY_POINT, vm.ActivePrecompiles(rules), tx.AccessList())
-
-	epc := &EntryPointCall{}
-
-	if evm.Config.Tracer == nil {
-		evm.Config.Tracer = &tracing.Hooks{
-			OnEnter: epc.OnEnter,
-		}
-	} else {
-		// keep the original tracer's OnEnter hook
-		epc.OnEnterSuper = evm.Config.Tracer.OnEnter
-		newTracer := *evm.Config.Tracer
-		newTracer.OnEnter = epc.OnEnter
-		evm.Config.Tracer = &newTracer
+	warmRip7560Entities(statedb, aatx)
+	addRip7560AccessEvents(evm, aatx)
+
+	// A frame is cut short at its next jump once the EVM is cancelled, so the validation
+	// phase is failed as a whole rather than judged on the frames it ran.
+	if cfg.Rip7560ValidationTimeout > 0 {
+		timer := time.AfterFunc(cfg.Rip7560ValidationTimeout, evm.Cancel)
+		defer func() {
+			timer.Stop()
+			if evm.Cancelled() {
+				vpr, err = nil, newRip7560TimeoutError(epc.frames)
+			}
+		}()
 	}
 
-	if evm.Config.Tracer.OnTxStart != nil {
+	evm.CallInterceptor = epc.intercept
+	if evm.Config.Tracer != nil && evm.Config.Tracer.OnTxStart != nil {
 		evm.Config.Tracer.OnTxStart(evm.GetVMContext(), tx, common.Address{})
 	}
 
@@ -412,13 +676,17 @@ func ApplyRip7560ValidationPhases(
 	st.initialGas = gasLimit
 	st.gasRemaining = gasLimit
 
-	preTransactionGasCost, err := aatx.PreTransactionGasCost()
+	preTransactionGasCost, err := aatx.PreTransactionGasCost(chainConfig.IsPrague(header.Number, header.Time))
+	if err != nil {
+		return nil, err
+	}
+	callDataUsedGas, err := aatx.CallDataGasCost()
 	if err != nil {
 		return nil, err
 	}
 
 	/*** Nonce Manager Frame ***/
-	nonceManagerUsedGas, err := CheckNonceRip7560(st, aatx)
+	nonceManagerUsedGas, err := CheckNonceRip7560(st, epc, aatx)
 	if err != nil {
 		return nil, err
 	}
@@ -428,6 +696,7 @@ func ApplyRip7560ValidationPhases(
 	if aatx.Deployer != nil {
 		deployerGasLimit := aatx.ValidationGasLimit - preTransactionGasCost
 		resultDeployer := CallFrame(st, &AA_SENDER_CREATOR, aatx.Deployer, aatx.DeployerData, deployerGasLimit)
+		epc.recordFrame("deployer", resultDeployer)
 		if resultDeployer.Failed() {
 			return nil, newValidationPhaseError(
 				resultDeployer.Err,
@@ -450,15 +719,18 @@ func ApplyRip7560ValidationPhases(
 		}
 	}
 
+	/*** EIP-7702 Authorizations ***/
+	applyRip7560Authorizations(chainConfig, statedb, aatx)
+
 	/*** Account Validation Frame ***/
-	signer := types.MakeSigner(chainConfig, header.Number, header.Time)
-	signingHash := signer.Hash(tx)
-	accountValidationMsg, err := prepareAccountValidationMessage(aatx, signingHash)
+	signingHash := MakeRip7560Signer(chainConfig, header).Hash(tx)
+	accountValidationMsg, err := prepareAccountValidationMessage(entryPointAbi, aatx, signingHash)
 	if err != nil {
 		return nil, wrapError(err)
 	}
 	accountGasLimit := aatx.ValidationGasLimit - preTransactionGasCost - deploymentUsedGas
 	resultAccountValidation := CallFrame(st, &AA_ENTRY_POINT, aatx.Sender, accountValidationMsg, accountGasLimit)
+	epc.recordFrame("account", resultAccountValidation)
 	if resultAccountValidation.Failed() {
 		return nil, newValidationPhaseError(
 			resultAccountValidation.Err,
@@ -467,7 +739,7 @@ func ApplyRip7560ValidationPhases(
 			true,
 		)
 	}
-	aad, err := validateAccountEntryPointCall(epc, aatx.Sender, allowSigFail)
+	aad, err := validateAccountEntryPointCall(entryPointAbi, epc, aatx.Sender, allowSigFail)
 	if err != nil {
 		return nil, wrapError(err)
 	}
@@ -482,21 +754,23 @@ func ApplyRip7560ValidationPhases(
 		return nil, wrapError(err)
 	}
 
-	paymasterContext, pmValidationUsedGas, pmValidAfter, pmValidUntil, err := applyPaymasterValidationFrame(st, epc, tx, signingHash, header, allowSigFail)
+	paymasterContext, pmValidationUsedGas, pmValidAfter, pmValidUntil, err := applyPaymasterValidationFrame(st, entryPointAbi, epc, tx, signingHash, header, allowSigFail)
 	if err != nil {
 		return nil, err
 	}
 
 	gasRefund := st.state.GetRefund()
 
-	vpr := &ValidationPhaseResult{
+	vpr = &ValidationPhaseResult{
 		Tx:                    tx,
 		TxHash:                tx.Hash(),
 		PreCharge:             preCharge,
+		BuilderFee:            builderFee,
 		EffectiveGasPrice:     effectiveGasPrice,
 		PaymasterContext:      paymasterContext,
 		PreTransactionGasCost: preTransactionGasCost,
 		ValidationRefund:      gasRefund,
+		CallDataUsedGas:       callDataUsedGas,
 		DeploymentUsedGas:     deploymentUsedGas,
 		NonceManagerUsedGas:   nonceManagerUsedGas,
 		ValidationUsedGas:     resultAccountValidation.UsedGas,
@@ -506,15 +780,48 @@ func ApplyRip7560ValidationPhases(
 		PmValidAfter:          pmValidAfter,
 		PmValidUntil:          pmValidUntil,
 	}
+	if isRip7560WarmExecution(chainConfig, header) {
+		vpr.WarmAccessList = statedb.AccessList()
+	}
 	statedb.Finalise(true)
 
 	return vpr, nil
 }
 
 func performStaticValidation(
+	chainConfig *params.ChainConfig,
+	header *types.Header,
 	aatx *types.Rip7560AccountAbstractionTx,
 	statedb *state.StateDB,
 ) error {
+	if !chainConfig.IsRIP7560(header.Number, header.Time) {
+		return wrapError(fmt.Errorf("%w: RIP-7560 is not active at block %v", types.ErrTxTypeNotSupported, header.Number))
+	}
+	// malformed transactions can reach block building through pushed bundles, so the
+	// fields dereferenced below are checked before anything else
+	if aatx.Sender == nil {
+		return wrapError(errors.New("sender address is not set"))
+	}
+	if aatx.GasFeeCap == nil || aatx.GasTipCap == nil {
+		return wrapError(errors.New("maxFeePerGas and maxPriorityFeePerGas must be set"))
+	}
+	// the signer of RIP-7560 transactions does not bind them to the chain
+	if aatx.ChainID == nil || aatx.ChainID.Cmp(chainConfig.ChainID) != 0 {
+		return wrapError(fmt.Errorf("%w: address %v, have %v, want %v", types.ErrInvalidChainId, aatx.Sender.Hex(), aatx.ChainID, chainConfig.ChainID))
+	}
+	if l := aatx.GasFeeCap.BitLen(); l > 256 {
+		return wrapError(fmt.Errorf("%w: address %v, maxFeePerGas bit length: %d", ErrFeeCapVeryHigh, aatx.Sender.Hex(), l))
+	}
+	if l := aatx.GasTipCap.BitLen(); l > 256 {
+		return wrapError(fmt.Errorf("%w: address %v, maxPriorityFeePerGas bit length: %d", ErrTipVeryHigh, aatx.Sender.Hex(), l))
+	}
+	if aatx.BuilderFee != nil && (aatx.BuilderFee.Sign() < 0 || aatx.BuilderFee.BitLen() > 256) {
+		return wrapError(fmt.Errorf("invalid builder fee: address %v, builderFee %v", aatx.Sender.Hex(), aatx.BuilderFee))
+	}
+	if len(aatx.AuthorizationList) != 0 && !chainConfig.IsRIP7560EIP7702(header.Number) {
+		return wrapError(fmt.Errorf("EIP-7702 authorizations not supported: address %v", aatx.Sender.Hex()))
+	}
+
 	hasPaymaster := aatx.Paymaster != nil
 	hasPaymasterData := aatx.PaymasterData != nil && len(aatx.PaymasterData) != 0
 	hasPaymasterGasLimit := aatx.PaymasterValidationGasLimit != 0
@@ -580,7 +887,7 @@ func performStaticValidation(
 		}
 	}
 
-	preTransactionGasCost, _ := aatx.PreTransactionGasCost()
+	preTransactionGasCost, _ := aatx.PreTransactionGasCost(chainConfig.IsPrague(header.Number, header.Time))
 	if preTransactionGasCost > aatx.ValidationGasLimit {
 		return wrapError(
 			fmt.Errorf(
@@ -590,7 +897,9 @@ func performStaticValidation(
 		)
 	}
 
-	if !hasDeployer && !hasCodeSender {
+	// an account without code may get one from its EIP-7702 authorization, or fails to
+	// accept the transaction otherwise
+	if !hasDeployer && !hasCodeSender && len(aatx.AuthorizationList) == 0 {
 		return wrapError(
 			fmt.Errorf(
 				"account is not deployed and no deployer is specified, account:%s", aatx.Sender.String(),
@@ -601,11 +910,11 @@ func performStaticValidation(
 	return nil
 }
 
-func applyPaymasterValidationFrame(st *StateTransition, epc *EntryPointCall, tx *types.Transaction, signingHash common.Hash, header *types.Header, estimate bool) ([]byte, uint64, uint64, uint64, error) {
+func applyPaymasterValidationFrame(st *StateTransition, entryPointAbi *Rip7560EntryPointAbi, epc *EntryPointCall, tx *types.Transaction, signingHash common.Hash, header *types.Header, estimate bool) ([]byte, uint64, uint64, uint64, error) {
 	/*** Paymaster Validation Frame ***/
 	aatx := tx.Rip7560TransactionData()
 	var pmValidationUsedGas uint64
-	paymasterMsg, err := preparePaymasterValidationMessage(aatx, signingHash)
+	paymasterMsg, err := preparePaymasterValidationMessage(entryPointAbi, aatx, signingHash)
 	if err != nil {
 		return nil, 0, 0, 0, wrapError(err)
 	}
@@ -613,6 +922,7 @@ func applyPaymasterValidationFrame(st *StateTransition, epc *EntryPointCall, tx
 		return nil, 0, 0, 0, nil
 	}
 	resultPm := CallFrame(st, &AA_ENTRY_POINT, aatx.Paymaster, paymasterMsg, aatx.PaymasterValidationGasLimit)
+	epc.recordFrame("paymaster", resultPm)
 
 	if resultPm.Failed() {
 		return nil, 0, 0, 0, newValidationPhaseError(
@@ -623,7 +933,7 @@ func applyPaymasterValidationFrame(st *StateTransition, epc *EntryPointCall, tx
 		)
 	}
 	pmValidationUsedGas = resultPm.UsedGas
-	apd, err := validatePaymasterEntryPointCall(epc, aatx.Paymaster, estimate)
+	apd, err := validatePaymasterEntryPointCall(entryPointAbi, epc, aatx.Paymaster, estimate)
 	if err != nil {
 		return nil, 0, 0, 0, wrapError(err)
 	}
@@ -642,13 +952,89 @@ func applyPaymasterValidationFrame(st *StateTransition, epc *EntryPointCall, tx
 	return apd.Context, pmValidationUsedGas, apd.ValidAfter.Uint64(), apd.ValidUntil.Uint64(), nil
 }
 
-func applyPaymasterPostOpFrame(st *StateTransition, aatx *types.Rip7560AccountAbstractionTx, vpr *ValidationPhaseResult, success bool, gasUsed uint64) *ExecutionResult {
+// PaymasterValidationResult is the result of running the paymaster validation frame on its own.
+type PaymasterValidationResult struct {
+	UsedGas     uint64
+	ValidAfter  uint64
+	ValidUntil  uint64
+	ContextSize int
+}
+
+// ApplyRip7560PaymasterValidation runs only the paymaster validation frame of the transaction,
+// without charging for gas or running the nonce, deployment and account validation frames.
+// It lets wallets check whether a paymaster would sponsor a transaction before a full estimation.
+func ApplyRip7560PaymasterValidation(
+	chainConfig *params.ChainConfig,
+	bc ChainContext,
+	statedb *state.StateDB,
+	header *types.Header,
+	tx *types.Transaction,
+	cfg vm.Config,
+) (*PaymasterValidationResult, error) {
+	aatx := tx.Rip7560TransactionData()
+	if aatx.Paymaster == nil || aatx.Paymaster.Cmp(common.Address{}) == 0 {
+		return nil, errors.New("transaction does not specify a paymaster")
+	}
+	entryPointAbi, err := Rip7560EntryPointAbiAt(chainConfig, header.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	blockContext := NewEVMBlockContext(header, bc, &header.Coinbase, chainConfig, statedb)
+	txContext := vm.TxContext{
+		Origin:   *aatx.Sender,
+		GasPrice: aatx.EffectiveGasPrice(header.BaseFee),
+	}
+	evm := vm.NewEVM(blockContext, txContext, statedb, chainConfig, cfg)
+	rules := evm.ChainConfig().Rules(evm.Context.BlockNumber, evm.Context.Random != nil, evm.Context.Time)
+	statedb.Prepare(rules, *aatx.Sender, evm.Context.Coinbase, &AA_ENTRY_POINT, vm.ActivePrecompiles(rules), tx.AccessList())
+	warmRip7560Entities(statedb, aatx)
+	addRip7560AccessEvents(evm, aatx)
+
+	epc := &EntryPointCall{}
+	evm.CallInterceptor = epc.intercept
+	st := NewStateTransition(evm, nil, new(GasPool).AddGas(aatx.PaymasterValidationGasLimit))
+	st.initialGas = aatx.PaymasterValidationGasLimit
+	st.gasRemaining = aatx.PaymasterValidationGasLimit
+
+	signingHash := MakeRip7560Signer(chainConfig, header).Hash(tx)
+	paymasterContext, usedGas, validAfter, validUntil, err := applyPaymasterValidationFrame(st, entryPointAbi, epc, tx, signingHash, header, false)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymasterValidationResult{
+		UsedGas:     usedGas,
+		ValidAfter:  validAfter,
+		ValidUntil:  validUntil,
+		ContextSize: len(paymasterContext),
+	}, nil
+}
+
+func applyPaymasterPostOpFrame(st *StateTransition, entryPointAbi *Rip7560EntryPointAbi, aatx *types.Rip7560AccountAbstractionTx, vpr *ValidationPhaseResult, success bool, actualGasCost *uint256.Int) *ExecutionResult {
 	var paymasterPostOpResult *ExecutionResult
-	paymasterPostOpMsg := preparePostOpMessage(vpr, success, gasUsed)
+	paymasterPostOpMsg := preparePostOpMessage(entryPointAbi, vpr, success, actualGasCost)
 	paymasterPostOpResult = CallFrame(st, &AA_ENTRY_POINT, aatx.Paymaster, paymasterPostOpMsg, aatx.PostOpGas)
 	return paymasterPostOpResult
 }
 
+// isRip7560WarmExecution returns whether the execution phase of an RIP-7560 transaction
+// starts with the addresses and storage slots warmed by its own validation phase. It is
+// always the case once the validation phases of all transactions run first.
+func isRip7560WarmExecution(config *params.ChainConfig, header *types.Header) bool {
+	return config.IsRIP7560WarmExecution(header.Number) || config.IsRIP7711(header.Number, header.Time)
+}
+
+// postOpActualGasCost returns the 'actualGasCost' value passed to the paymaster 'postPaymasterTransaction' frame.
+// Before the RIP7560ActualGasCost fork the amount of gas used was passed instead of its cost in wei,
+// so the historical behaviour is preserved for older blocks.
+func postOpActualGasCost(config *params.ChainConfig, header *types.Header, vpr *ValidationPhaseResult, gasUsed uint64) *uint256.Int {
+	actualGasCost := new(uint256.Int).SetUint64(gasUsed)
+	if !config.IsRIP7560ActualGasCost(header.Number) {
+		return actualGasCost
+	}
+	return actualGasCost.Mul(actualGasCost, vpr.EffectiveGasPrice)
+}
+
 func capRefund(getRefund uint64, gasUsed uint64) uint64 {
 	refund := gasUsed / params.RefundQuotientEIP3529
 	if refund > getRefund {
@@ -669,6 +1055,15 @@ func ApplyRip7560ExecutionPhase(
 	usedGas *uint64,
 ) (*types.Receipt, *ExecutionResult, *ExecutionResult, error) {
 
+	entryPointAbi, err := Rip7560EntryPointAbiAt(config, header.Number)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// The validation phases of other transactions may have run since this one was
+	// validated, so its context is restored for the logs of the execution phase and the
+	// injected events.
+	statedb.SetTxContext(vpr.TxHash, vpr.TxIndex)
+
 	blockContext := NewEVMBlockContext(header, bc, author, config, statedb)
 	aatx := vpr.Tx.Rip7560TransactionData()
 	sender := aatx.Sender
@@ -678,13 +1073,23 @@ func ApplyRip7560ExecutionPhase(
 	}
 	txContext.Origin = *aatx.Sender
 	evm := vm.NewEVM(blockContext, txContext, statedb, config, cfg)
+	addRip7560AccessEvents(evm, aatx)
+	// The validation phases of the other transactions ran since this one was validated, so
+	// its own warm addresses and slots are restored as if both phases shared a context.
+	if isRip7560WarmExecution(config, header) {
+		statedb.SetAccessList(vpr.WarmAccessList)
+	}
+	// Processed in two phases, the transient storage left is the one of the last validated
+	// transaction, and is not carried into the execution phase.
+	if config.IsRIP7711(header.Number, header.Time) {
+		statedb.ResetTransientStorage()
+	}
 	st := NewStateTransition(evm, nil, gp)
 	st.initialGas = math.MaxUint64
 	st.gasRemaining = math.MaxUint64
 
-	accountExecutionMsg := prepareAccountExecutionMessage(vpr.Tx)
 	beforeExecSnapshotId := statedb.Snapshot()
-	executionResult := CallFrame(st, &AA_ENTRY_POINT, sender, accountExecutionMsg, aatx.Gas)
+	executionResult, executionGasPenalty := applyAccountExecutionFrame(st, config, header, vpr.Tx)
 	receiptStatus := types.ReceiptStatusSuccessful
 	executionStatus := ExecutionStatusSuccess
 	execRefund := capRefund(st.state.GetRefund(), executionResult.UsedGas)
@@ -692,19 +1097,23 @@ func ApplyRip7560ExecutionPhase(
 		receiptStatus = types.ReceiptStatusFailed
 		executionStatus = ExecutionStatusExecutionFailure
 	}
-	executionGasPenalty := (aatx.Gas - executionResult.UsedGas) * AA_GAS_PENALTY_PCT / 100
 
+	penaltyRule := config.RIP7560GasPenalty(header.Number)
 	validationPhaseUsedGas, _ := vpr.ValidationPhaseUsedGas()
+	accountGasPenalty, pmGasPenalty := rip7560ValidationGasPenalty(penaltyRule, aatx, vpr)
 	gasUsed := validationPhaseUsedGas +
+		accountGasPenalty +
+		pmGasPenalty +
 		executionResult.UsedGas +
 		executionGasPenalty
 
 	gasRefund := capRefund(execRefund+vpr.ValidationRefund, gasUsed)
 
-	var postOpGasUsed uint64
+	var postOpGasUsed, postOpGasPenalty uint64
 	var paymasterPostOpResult *ExecutionResult
 	if len(vpr.PaymasterContext) != 0 {
-		paymasterPostOpResult = applyPaymasterPostOpFrame(st, aatx, vpr, !executionResult.Failed(), gasUsed-gasRefund)
+		actualGasCost := postOpActualGasCost(config, header, vpr, gasUsed-gasRefund)
+		paymasterPostOpResult = applyPaymasterPostOpFrame(st, entryPointAbi, aatx, vpr, !executionResult.Failed(), actualGasCost)
 		postOpGasUsed = paymasterPostOpResult.UsedGas
 		gasRefund += capRefund(paymasterPostOpResult.RefundedGas, postOpGasUsed)
 		// PostOp failed, reverting execution changes
@@ -713,147 +1122,227 @@ func ApplyRip7560ExecutionPhase(
 			receiptStatus = types.ReceiptStatusFailed
 			if executionStatus == ExecutionStatusExecutionFailure {
 				executionStatus = ExecutionStatusExecutionAndPostOpFailure
+			} else {
+				executionStatus = ExecutionStatusPostOpFailure
 			}
-			executionStatus = ExecutionStatusPostOpFailure
 		}
-		postOpGasPenalty := (aatx.PostOpGas - postOpGasUsed) * AA_GAS_PENALTY_PCT / 100
+		if penaltyRule.PostOp {
+			postOpGasPenalty = penaltyRule.Penalty(aatx.PostOpGas - postOpGasUsed)
+		}
 		postOpGasUsed += postOpGasPenalty
 		gasUsed += postOpGasUsed
 	}
 	gasUsed -= gasRefund
-	refundPayer(vpr, statedb, gasUsed)
-	payCoinbase(st, aatx, gasUsed)
-
-	// Also return remaining gas to the block gas counter so it is
-	// available for the next transaction.
-	totalGasLimit, _ := aatx.TotalGasLimit()
-	if totalGasLimit < gasUsed {
-		panic("cannot spend more gas than the total limit")
-	}
-	gasRemaining := totalGasLimit - gasUsed
-	gp.AddGas(gasRemaining)
 
-	err := injectRIP7560TransactionEvent(aatx, executionStatus, header, statedb)
+	systemEvents, err := rip7560SystemEvents(entryPointAbi, aatx, executionStatus, executionResult, paymasterPostOpResult)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	if aatx.Deployer != nil {
-		err = injectRIP7560AccountDeployedEvent(aatx, header, statedb)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-	}
-	if executionResult.Failed() {
-		err = injectRIP7560TransactionRevertReasonEvent(aatx, executionResult.ReturnData, header, statedb)
-		if err != nil {
-			return nil, nil, nil, err
-		}
+	// System events are charged on top of the used gas, limited by the gas the transaction has left.
+	totalGasLimit, _ := aatx.TotalGasLimit()
+	var systemEventsGasUsed uint64
+	if eventsGas := systemEventsGasCost(config, header, systemEvents); totalGasLimit > gasUsed {
+		systemEventsGasUsed = min(eventsGas, totalGasLimit-gasUsed)
+		gasUsed += systemEventsGasUsed
 	}
-	if paymasterPostOpResult != nil && paymasterPostOpResult.Failed() {
-		err = injectRIP7560TransactionPostOpRevertReasonEvent(aatx, paymasterPostOpResult.ReturnData, header, statedb)
+
+	// Checked before any payment, as the refund of the payer would underflow
+	if totalGasLimit < gasUsed {
+		log.Error("RIP-7560 transaction used more gas than its total limit", "tx", vpr.TxHash, "gasUsed", gasUsed, "limit", totalGasLimit)
+		return nil, nil, nil, fmt.Errorf("%w: tx %v used %d gas, limit %d", ErrRip7560GasLimitExceeded, vpr.TxHash, gasUsed, totalGasLimit)
+	}
+	refund := refundPayer(vpr, statedb, gasUsed)
+	// With an explicit penalty destination, the penalty is paid in full to it and the
+	// fees are only charged on the rest of the used gas.
+	feeGasUsed, penalty := gasUsed, new(uint256.Int)
+	if penaltyRule := config.RIP7560Penalty(header.Number); penaltyRule != nil {
+		penaltyGas := min(accountGasPenalty+pmGasPenalty+executionGasPenalty+postOpGasPenalty, gasUsed)
+		feeGasUsed -= penaltyGas
+		penalty = payRip7560Penalty(st, penaltyRule, vpr.EffectiveGasPrice, penaltyGas)
+	}
+	coinbaseFee := payCoinbase(st, aatx, feeGasUsed)
+	builderFee := payRip7560BuilderFee(st, config.RIP7560BuilderFeeRecipient, vpr.BuilderFee)
+	if cfg.Rip7560GasInvariants {
+		err := checkRip7560Payments(vpr.TxHash, &rip7560Payments{
+			PreCharge:   vpr.PreCharge,
+			Refund:      refund,
+			Coinbase:    coinbaseFee,
+			BuilderFee:  builderFee,
+			BaseFeeBurn: rip7560BaseFeeBurn(header.BaseFee, vpr.EffectiveGasPrice, feeGasUsed),
+			Penalty:     penalty,
+		})
 		if err != nil {
 			return nil, nil, nil, err
 		}
 	}
 
+	// Also return remaining gas to the block gas counter so it is
+	// available for the next transaction.
+	gasRemaining := totalGasLimit - gasUsed
+	gp.AddGas(gasRemaining)
+
+	// As for other transactions, the hash of the header is only the one of the block once
+	// it is complete, which is the case when processing it.
+	blockHash := header.Hash()
+	injectEvents(systemEvents, header.Number.Uint64(), blockHash, statedb)
+
 	// TODO: naming convention hell!!! 'usedGas' is 'CumulativeGasUsed' in block processing
 	*usedGas += gasUsed
 
 	receipt := &types.Receipt{Type: vpr.Tx.Type(), TxHash: vpr.Tx.Hash(), GasUsed: gasUsed, CumulativeGasUsed: *usedGas}
+	// The builder fee is paid on top of the effective gas price on the used gas, and is
+	// reported separately.
+	receipt.EffectiveGasPrice = vpr.EffectiveGasPrice.ToBig()
+	if config.IsRIP7560BuilderFee(header.Number) {
+		receipt.Rip7560BuilderFee = builderFee.ToBig()
+	}
 
 	receipt.Status = receiptStatus
+	receipt.Rip7560ExecutionStatus = &executionStatus
+	if config.IsRIP7560ReceiptRoot(header.Number) {
+		receipt.Rip7560ReceiptVersion = new(uint64)
+		*receipt.Rip7560ReceiptVersion = types.Rip7560ReceiptRootVersion
+	}
+	if deployed := aatx.DeployedAddress(); deployed != nil {
+		receipt.ContractAddress = *deployed
+	}
+	receipt.Rip7560GasAttribution = &types.Rip7560GasAttribution{
+		Account:             hexutil.Uint64(vpr.PreTransactionGasCost + vpr.NonceManagerUsedGas + vpr.DeploymentUsedGas + vpr.ValidationUsedGas + accountGasPenalty),
+		PaymasterValidation: hexutil.Uint64(vpr.PmValidationUsedGas + pmGasPenalty),
+		Execution:           hexutil.Uint64(executionResult.UsedGas + executionGasPenalty),
+		PostOp:              hexutil.Uint64(postOpGasUsed),
+		SystemEvents:        hexutil.Uint64(systemEventsGasUsed),
+		Refund:              hexutil.Uint64(gasRefund),
+	}
+	receipt.Rip7560FrameGasUsed = &types.Rip7560FrameGasUsed{
+		Validation:          hexutil.Uint64(vpr.ValidationUsedGas),
+		PaymasterValidation: hexutil.Uint64(vpr.PmValidationUsedGas),
+		Deployment:          hexutil.Uint64(vpr.DeploymentUsedGas),
+		Execution:           hexutil.Uint64(executionResult.UsedGas),
+		CallData:            hexutil.Uint64(vpr.CallDataUsedGas),
+	}
+	if paymasterPostOpResult != nil {
+		receipt.Rip7560FrameGasUsed.PostOp = hexutil.Uint64(paymasterPostOpResult.UsedGas)
+	}
+	receipt.Rip7560Validity = &types.Rip7560Validity{
+		AccountValidAfter:   hexutil.Uint64(vpr.SenderValidAfter),
+		AccountValidUntil:   hexutil.Uint64(vpr.SenderValidUntil),
+		PaymasterValidAfter: hexutil.Uint64(vpr.PmValidAfter),
+		PaymasterValidUntil: hexutil.Uint64(vpr.PmValidUntil),
+	}
 
 	// Set the receipt logs and create the bloom filter.
 	blockNumber := header.Number
-	receipt.Logs = statedb.GetLogs(vpr.TxHash, blockNumber.Uint64(), common.Hash{})
+	receipt.Logs = statedb.GetLogs(vpr.TxHash, blockNumber.Uint64(), blockHash)
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	receipt.BlockHash = blockHash
+	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(vpr.TxIndex)
 	// other fields are filled in DeriveFields (all tx, block fields, and updating CumulativeGasUsed
 	return receipt, executionResult, paymasterPostOpResult, nil
 }
 
-func injectRIP7560TransactionEvent(
-	aatx *types.Rip7560AccountAbstractionTx,
-	executionStatus uint64,
-	header *types.Header,
-	statedb *state.StateDB,
-) error {
-	topics, data, err := abiEncodeRIP7560TransactionEvent(aatx, executionStatus)
-	if err != nil {
-		return err
+// applyAccountExecutionFrame calls the account with the 'executionData' of the transaction
+// and returns the result of the frame along with the penalty charged on its unused gas, if
+// the penalty rule of the block covers the execution frame.
+// Starting with the RIP7560EmptyExecution fork, a transaction with an empty 'executionData',
+// such as a counterfactual deployment, skips the frame: it succeeds without using any gas
+// and is not penalized for the unused execution gas limit.
+func applyAccountExecutionFrame(st *StateTransition, config *params.ChainConfig, header *types.Header, tx *types.Transaction) (*ExecutionResult, uint64) {
+	aatx := tx.Rip7560TransactionData()
+	if len(aatx.ExecutionData) == 0 && config.IsRIP7560EmptyExecution(header.Number) {
+		return &ExecutionResult{}, 0
 	}
-	err = injectEvent(topics, data, header.Number.Uint64(), statedb)
-	if err != nil {
-		return err
+	result := CallFrame(st, &AA_ENTRY_POINT, aatx.Sender, prepareAccountExecutionMessage(tx), aatx.Gas)
+	if rule := config.RIP7560GasPenalty(header.Number); rule.Execution {
+		return result, rule.Penalty(aatx.Gas - result.UsedGas)
 	}
-	return nil
+	return result, 0
 }
 
-func injectRIP7560AccountDeployedEvent(
-	aatx *types.Rip7560AccountAbstractionTx,
-	header *types.Header,
-	statedb *state.StateDB,
-) error {
-	topics, data, err := abiEncodeRIP7560AccountDeployedEvent(aatx)
-	if err != nil {
-		return err
+// rip7560ValidationGasPenalty returns the penalties charged on the unused account and
+// paymaster validation gas limits of a transaction, if the penalty rule covers the
+// validation frames. The account validation gas limit also covers the pre-transaction
+// gas cost and the deployer frame.
+func rip7560ValidationGasPenalty(rule *params.RIP7560GasPenaltyRule, aatx *types.Rip7560AccountAbstractionTx, vpr *ValidationPhaseResult) (uint64, uint64) {
+	if !rule.Validation {
+		return 0, 0
 	}
-	err = injectEvent(topics, data, header.Number.Uint64(), statedb)
-	if err != nil {
-		return err
+	var accountGasUnused, pmGasUnused uint64
+	if used := vpr.PreTransactionGasCost + vpr.DeploymentUsedGas + vpr.ValidationUsedGas; used < aatx.ValidationGasLimit {
+		accountGasUnused = aatx.ValidationGasLimit - used
 	}
-	return nil
+	if aatx.Paymaster != nil && vpr.PmValidationUsedGas < aatx.PaymasterValidationGasLimit {
+		pmGasUnused = aatx.PaymasterValidationGasLimit - vpr.PmValidationUsedGas
+	}
+	return rule.Penalty(accountGasUnused), rule.Penalty(pmGasUnused)
 }
 
-func injectRIP7560TransactionRevertReasonEvent(
+// rip7560SystemEvents returns the EntryPoint events to be injected at the end of the execution phase.
+func rip7560SystemEvents(
+	entryPointAbi *Rip7560EntryPointAbi,
 	aatx *types.Rip7560AccountAbstractionTx,
-	revertData []byte,
-	header *types.Header,
-	statedb *state.StateDB,
-) error {
-	topics, data, err := abiEncodeRIP7560TransactionRevertReasonEvent(aatx, revertData)
-	if err != nil {
-		return err
+	executionStatus uint64,
+	executionResult *ExecutionResult,
+	paymasterPostOpResult *ExecutionResult,
+) ([]*types.Log, error) {
+	events := make([]*types.Log, 0)
+	appendEvent := func(topics []common.Hash, data []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		events = append(events, &types.Log{Address: AA_ENTRY_POINT, Topics: topics, Data: data})
+		return nil
 	}
-	err = injectEvent(topics, data, header.Number.Uint64(), statedb)
-	if err != nil {
-		return err
+	if err := appendEvent(entryPointAbi.encodeRIP7560TransactionEvent(aatx, executionStatus)); err != nil {
+		return nil, err
 	}
-	return nil
+	if aatx.Deployer != nil {
+		if err := appendEvent(entryPointAbi.encodeRIP7560AccountDeployedEvent(aatx)); err != nil {
+			return nil, err
+		}
+	}
+	if executionResult.Failed() {
+		if err := appendEvent(entryPointAbi.encodeRIP7560TransactionRevertReasonEvent(aatx, executionResult.ReturnData)); err != nil {
+			return nil, err
+		}
+	}
+	if paymasterPostOpResult != nil && paymasterPostOpResult.Failed() {
+		if err := appendEvent(entryPointAbi.encodeRIP7560TransactionPostOpRevertReasonEvent(aatx, paymasterPostOpResult.ReturnData)); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
 }
 
-func injectRIP7560TransactionPostOpRevertReasonEvent(
-	aatx *types.Rip7560AccountAbstractionTx,
-	revertData []byte,
-	header *types.Header,
-	statedb *state.StateDB,
-) error {
-	topics, data, err := abiEncodeRIP7560TransactionPostOpRevertReasonEvent(aatx, revertData)
-	if err != nil {
-		return err
+// systemEventsGasCost returns the gas charged for injecting the EntryPoint events.
+// Starting with the RIP7560SystemEventGas fork the events are priced as the equivalent
+// LOG opcodes (without memory expansion), before it they are injected free of charge.
+func systemEventsGasCost(config *params.ChainConfig, header *types.Header, events []*types.Log) uint64 {
+	if !config.IsRIP7560SystemEventGas(header.Number) {
+		return 0
 	}
-	err = injectEvent(topics, data, header.Number.Uint64(), statedb)
-	if err != nil {
-		return err
+	var gas uint64
+	for _, event := range events {
+		gas += params.LogGas + params.LogTopicGas*uint64(len(event.Topics)) + params.LogDataGas*uint64(len(event.Data))
 	}
-	return nil
+	return gas
 }
 
-func injectEvent(topics []common.Hash, data []byte, blockNumber uint64, statedb *state.StateDB) error {
-	transactionLog := &types.Log{
-		Address: AA_ENTRY_POINT,
-		Topics:  topics,
-		Data:    data,
-		// This is a non-consensus field, but assigned here because
-		// core/state doesn't know the current block number.
-		BlockNumber: blockNumber,
+// injectEvents adds the EntryPoint events to the logs of the current transaction of the
+// state, the transaction hash and index being assigned by the state.
+func injectEvents(events []*types.Log, blockNumber uint64, blockHash common.Hash, statedb *state.StateDB) {
+	for _, event := range events {
+		// These are non-consensus fields, but assigned here because
+		// core/state doesn't know the current block.
+		event.BlockNumber = blockNumber
+		event.BlockHash = blockHash
+		statedb.AddLog(event)
 	}
-	statedb.AddLog(transactionLog)
-	return nil
 }
 
 // extracted from TransitionDb()
-func payCoinbase(st *StateTransition, msg *types.Rip7560AccountAbstractionTx, gasUsed uint64) {
+func payCoinbase(st *StateTransition, msg *types.Rip7560AccountAbstractionTx, gasUsed uint64) *uint256.Int {
 	rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.Random != nil, st.evm.Context.Time)
 
 	effectiveTip := msg.GasTipCap
@@ -875,18 +1364,82 @@ func payCoinbase(st *StateTransition, msg *types.Rip7560AccountAbstractionTx, ga
 		if rules.IsEIP4762 && fee.Sign() != 0 {
 			st.evm.AccessEvents.BalanceGas(st.evm.Context.Coinbase, true)
 		}
+		return fee
 	}
+	return new(uint256.Int)
+}
+
+// payRip7560Penalty pays the penalty charged on the unused gas of a transaction to the
+// configured beneficiary, returning the amount paid or burnt.
+func payRip7560Penalty(st *StateTransition, penaltyRule *params.RIP7560PenaltyRule, effectiveGasPrice *uint256.Int, penaltyGas uint64) *uint256.Int {
+	penalty := new(uint256.Int).SetUint64(penaltyGas)
+	penalty.Mul(penalty, effectiveGasPrice)
+	if penalty.IsZero() {
+		return penalty
+	}
+	var (
+		beneficiary common.Address
+		reason      tracing.BalanceChangeReason
+	)
+	switch penaltyRule.Beneficiary {
+	case params.RIP7560PenaltyCoinbase:
+		beneficiary, reason = st.evm.Context.Coinbase, tracing.BalanceIncreaseRip7560PenaltyCoinbase
+	case params.RIP7560PenaltyVault:
+		beneficiary, reason = *penaltyRule.Vault, tracing.BalanceIncreaseRip7560PenaltyVault
+	default:
+		// burnt, the penalty is not credited to anyone
+		return penalty
+	}
+	st.state.AddBalance(beneficiary, penalty, reason)
+	if st.evm.ChainConfig().IsEIP4762(st.evm.Context.BlockNumber, st.evm.Context.Time) {
+		st.evm.AccessEvents.BalanceGas(beneficiary, true)
+	}
+	return penalty
 }
 
-func prepareAccountValidationMessage(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
-	return abiEncodeValidateTransaction(tx, signingHash)
+// payRip7560BuilderFee pays the builder fee charged from the gas payer of a transaction to
+// the configured recipient, or to the coinbase without one, returning the amount paid.
+func payRip7560BuilderFee(st *StateTransition, recipient *common.Address, builderFee *uint256.Int) *uint256.Int {
+	if builderFee == nil || builderFee.IsZero() {
+		return new(uint256.Int)
+	}
+	beneficiary := st.evm.Context.Coinbase
+	if recipient != nil {
+		beneficiary = *recipient
+	}
+	st.state.AddBalance(beneficiary, builderFee, tracing.BalanceIncreaseRip7560BuilderFee)
+	if st.evm.ChainConfig().IsEIP4762(st.evm.Context.BlockNumber, st.evm.Context.Time) {
+		st.evm.AccessEvents.BalanceGas(beneficiary, true)
+	}
+	return builderFee
 }
 
-func preparePaymasterValidationMessage(tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
+// MakeRip7560Signer returns the signer computing the signing hash of RIP-7560 transactions
+// at the given block. Starting with the RIP7560SigningDomain fork, the EntryPoint and its
+// ABI version are bound into the hash, preventing replays across EntryPoint upgrades.
+func MakeRip7560Signer(config *params.ChainConfig, header *types.Header) types.Signer {
+	if config.IsRIP7560SigningDomain(header.Number) {
+		return types.NewRIP7560DomainSigner(config.ChainID, Rip7560SigningDomain(config.RIP7560AbiVersion(header.Number)))
+	}
+	return types.MakeSigner(config, header.Number, header.Time)
+}
+
+// Rip7560SigningDomain returns the domain bound into the signing hash of RIP-7560
+// transactions using the given EntryPoint ABI version once the RIP7560SigningDomain fork
+// is active.
+func Rip7560SigningDomain(abiVersion uint64) types.Rip7560SigningDomain {
+	return types.Rip7560SigningDomain{EntryPoint: AA_ENTRY_POINT, AbiVersion: abiVersion}
+}
+
+func prepareAccountValidationMessage(entryPointAbi *Rip7560EntryPointAbi, tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
+	return entryPointAbi.encodeValidateTransaction(tx, signingHash)
+}
+
+func preparePaymasterValidationMessage(entryPointAbi *Rip7560EntryPointAbi, tx *types.Rip7560AccountAbstractionTx, signingHash common.Hash) ([]byte, error) {
 	if tx.Paymaster == nil || tx.Paymaster.Cmp(common.Address{}) == 0 {
 		return nil, nil
 	}
-	return abiEncodeValidatePaymasterTransaction(tx, signingHash)
+	return entryPointAbi.encodeValidatePaymasterTransaction(tx, signingHash)
 }
 
 func prepareAccountExecutionMessage(baseTx *types.Transaction) []byte {
@@ -894,11 +1447,11 @@ func prepareAccountExecutionMessage(baseTx *types.Transaction) []byte {
 	return tx.ExecutionData
 }
 
-func preparePostOpMessage(vpr *ValidationPhaseResult, success bool, gasUsed uint64) []byte {
-	return abiEncodePostPaymasterTransaction(success, gasUsed, vpr.PaymasterContext)
+func preparePostOpMessage(entryPointAbi *Rip7560EntryPointAbi, vpr *ValidationPhaseResult, success bool, actualGasCost *uint256.Int) []byte {
+	return entryPointAbi.encodePostPaymasterTransaction(success, actualGasCost.ToBig(), vpr.PaymasterContext)
 }
 
-func validateAccountEntryPointCall(epc *EntryPointCall, sender *common.Address, allowSigFail bool) (*AcceptAccountData, error) {
+func validateAccountEntryPointCall(entryPointAbi *Rip7560EntryPointAbi, epc *EntryPointCall, sender *common.Address, allowSigFail bool) (*AcceptAccountData, error) {
 	if epc.err != nil {
 		return nil, epc.err
 	}
@@ -908,10 +1461,10 @@ func validateAccountEntryPointCall(epc *EntryPointCall, sender *common.Address,
 	if epc.From.Cmp(*sender) != 0 {
 		return nil, errors.New("invalid call to EntryPoint contract from a wrong account address")
 	}
-	return abiDecodeAcceptAccount(epc.Input, allowSigFail)
+	return entryPointAbi.decodeAcceptAccount(epc.Input, allowSigFail)
 }
 
-func validatePaymasterEntryPointCall(epc *EntryPointCall, paymaster *common.Address, allowSigFail bool) (*AcceptPaymasterData, error) {
+func validatePaymasterEntryPointCall(entryPointAbi *Rip7560EntryPointAbi, epc *EntryPointCall, paymaster *common.Address, allowSigFail bool) (*AcceptPaymasterData, error) {
 	if epc.err != nil {
 		return nil, epc.err
 	}
@@ -922,7 +1475,7 @@ func validatePaymasterEntryPointCall(epc *EntryPointCall, paymaster *common.Addr
 	if epc.From.Cmp(*paymaster) != 0 {
 		return nil, errors.New("invalid call to EntryPoint contract from a wrong paymaster address")
 	}
-	apd, err := abiDecodeAcceptPaymaster(epc.Input, allowSigFail)
+	apd, err := entryPointAbi.decodeAcceptPaymaster(epc.Input, allowSigFail)
 	if err != nil {
 		return nil, err
 	}
@@ -945,10 +1498,9 @@ func validateValidityTimeRange(time uint64, validAfter uint64, validUntil uint64
 	return nil
 }
 
-func (epc *EntryPointCall) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
-	if epc.OnEnterSuper != nil {
-		epc.OnEnterSuper(depth, typ, from, to, input, gas, value)
-	}
+// intercept records the input and the caller of the call to the EntryPoint, failing the
+// frame validation if the EntryPoint is called more than once.
+func (epc *EntryPointCall) intercept(typ vm.OpCode, from common.Address, to common.Address, input []byte) {
 	isRip7560EntryPoint := to.Cmp(AA_ENTRY_POINT) == 0
 	if !isRip7560EntryPoint {
 		return
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/uint256"
+	"math/big"
+)
+
+// ErrRip7560GasAccounting is returned when the gas accounting of an RIP-7560 transaction
+// violates one of its invariants while the gas accounting assertions are enabled.
+var ErrRip7560GasAccounting = errors.New("RIP-7560 gas accounting invariant violated")
+
+// rip7560Payments are the amounts charged from the gas payer of an RIP-7560 transaction
+// and how they were distributed after its execution.
+type rip7560Payments struct {
+	PreCharge   *uint256.Int // Charged from the gas payer before the validation phase
+	Refund      *uint256.Int // Returned to the gas payer after the execution phase
+	Coinbase    *uint256.Int // Priority fee paid to the coinbase
+	BuilderFee  *uint256.Int // Fee paid to the block builder on top of the gas cost
+	BaseFeeBurn *uint256.Int // Base fee of the used gas, paid to no one
+	Penalty     *uint256.Int // Unused gas penalty paid to its configured destination
+}
+
+// checkRip7560Payments verifies that the whole pre-charge of a transaction is accounted
+// for: precharge = refund + coinbase payment + builder fee + base fee burn + penalty.
+func checkRip7560Payments(txHash common.Hash, payments *rip7560Payments) error {
+	var (
+		spent    = new(uint256.Int)
+		overflow bool
+	)
+	for _, amount := range []*uint256.Int{payments.Refund, payments.Coinbase, payments.BuilderFee, payments.BaseFeeBurn, payments.Penalty} {
+		if spent, overflow = spent.AddOverflow(spent, amount); overflow {
+			break
+		}
+	}
+	if overflow || spent.Cmp(payments.PreCharge) != 0 {
+		log.Error("RIP-7560 pre-charge not accounted for", "tx", txHash, "precharge", payments.PreCharge,
+			"refund", payments.Refund, "coinbase", payments.Coinbase, "builderFee", payments.BuilderFee, "burn", payments.BaseFeeBurn, "penalty", payments.Penalty)
+		return fmt.Errorf("%w: tx %v pre-charged %v, distributed refund %v + coinbase %v + builder fee %v + burn %v + penalty %v",
+			ErrRip7560GasAccounting, txHash, payments.PreCharge, payments.Refund, payments.Coinbase, payments.BuilderFee, payments.BaseFeeBurn, payments.Penalty)
+	}
+	return nil
+}
+
+// checkRip7560GasPool verifies that the block gas pool shrank by the gas used by a
+// transaction, as reported by its receipt.
+func checkRip7560GasPool(txHash common.Hash, before, after, gasUsed uint64) error {
+	if before < after || before-after != gasUsed {
+		log.Error("RIP-7560 gas pool delta does not match the gas used", "tx", txHash, "before", before, "after", after, "gasUsed", gasUsed)
+		return fmt.Errorf("%w: tx %v took %d gas from the block gas pool (%d -> %d), but used %d",
+			ErrRip7560GasAccounting, txHash, int64(before-after), before, after, gasUsed)
+	}
+	return nil
+}
+
+// rip7560BaseFeeBurn returns the base fee of the gas used by a transaction, which is
+// not credited to anyone. Calls simulated without a base fee burn nothing.
+func rip7560BaseFeeBurn(baseFee *big.Int, effectiveGasPrice *uint256.Int, gasUsed uint64) *uint256.Int {
+	burn := new(uint256.Int)
+	if baseFee != nil {
+		burn.SetFromBig(baseFee)
+	}
+	if burn.Gt(effectiveGasPrice) {
+		burn.Set(effectiveGasPrice)
+	}
+	return burn.Mul(burn, new(uint256.Int).SetUint64(gasUsed))
+}
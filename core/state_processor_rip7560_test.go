@@ -0,0 +1,795 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// rip7560ExecutionTest runs the execution phase of an RIP-7560 transaction sent by an account
+// with the given code, as if its validation phase has consumed only the PreTransactionGasCost.
+type rip7560ExecutionTest struct {
+	config *params.ChainConfig
+	header *types.Header
+	state  *state.StateDB
+	aatx   *types.Rip7560AccountAbstractionTx
+	vmcfg  vm.Config
+}
+
+func newRip7560ExecutionTest(t *testing.T, senderCode []byte) *rip7560ExecutionTest {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	sender := common.HexToAddress("0x1111111111222222222233333333334444444444")
+	statedb.SetCode(sender, senderCode)
+
+	config := *params.TestChainConfig
+	return &rip7560ExecutionTest{
+		config: &config,
+		header: &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: big.NewInt(0),
+			BaseFee:    big.NewInt(0),
+			GasLimit:   30_000_000,
+		},
+		state: statedb,
+		aatx: &types.Rip7560AccountAbstractionTx{
+			ChainID:            config.ChainID,
+			Sender:             &sender,
+			NonceKey:           big.NewInt(0),
+			Gas:                100_000,
+			ValidationGasLimit: 100_000,
+			GasTipCap:          big.NewInt(1),
+			GasFeeCap:          big.NewInt(1),
+			BuilderFee:         big.NewInt(0),
+		},
+	}
+}
+
+func (tt *rip7560ExecutionTest) run(t *testing.T) *types.Receipt {
+	receipt, err := tt.apply(nil)
+	if err != nil {
+		t.Fatalf("failed to apply execution phase: %v", err)
+	}
+	return receipt
+}
+
+// apply runs the execution phase, letting the caller tamper with the result of the
+// validation phase beforehand.
+func (tt *rip7560ExecutionTest) apply(modify func(vpr *ValidationPhaseResult)) (*types.Receipt, error) {
+	tx := types.NewTx(tt.aatx)
+	totalGasLimit, _ := tt.aatx.TotalGasLimit()
+	preTransactionGasCost, _ := tt.aatx.PreTransactionGasCost(false)
+	vpr := &ValidationPhaseResult{
+		Tx:                    tx,
+		TxHash:                tx.Hash(),
+		PreCharge:             uint256.NewInt(totalGasLimit),
+		EffectiveGasPrice:     uint256.NewInt(1),
+		PreTransactionGasCost: preTransactionGasCost,
+	}
+	if modify != nil {
+		modify(vpr)
+	}
+	tt.state.SetTxContext(tx.Hash(), 0)
+	receipt, _, _, err := ApplyRip7560ExecutionPhase(tt.config, vpr, nil, &common.Address{}, new(GasPool).AddGas(totalGasLimit), tt.state, tt.header, tt.vmcfg, new(uint64))
+	return receipt, err
+}
+
+func TestRip7560SystemEventsGas(t *testing.T) {
+	// the RIP7560TransactionEvent has 3 topics and 3 words of data
+	transactionEventGas := params.LogGas + 3*params.LogTopicGas + 96*params.LogDataGas
+	// the RIP7560TransactionRevertReason event has 2 topics and 4 words of data for an empty revert reason
+	revertReasonEventGas := params.LogGas + 2*params.LogTopicGas + 128*params.LogDataGas
+
+	tests := []struct {
+		name      string
+		code      []byte
+		forkBlock *big.Int
+		events    int
+		eventsGas uint64
+	}{
+		{"free before fork", nil, big.NewInt(2), 1, 0},
+		{"free when not configured", nil, nil, 1, 0},
+		{"charged after fork", nil, big.NewInt(1), 1, transactionEventGas},
+		{"reverted free before fork", []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}, nil, 2, 0},
+		{"reverted charged after fork", []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}, big.NewInt(0), 2, transactionEventGas + revertReasonEventGas},
+	}
+	for _, tt := range tests {
+		base := newRip7560ExecutionTest(t, tt.code).run(t)
+
+		test := newRip7560ExecutionTest(t, tt.code)
+		test.config.RIP7560SystemEventGasBlock = tt.forkBlock
+		receipt := test.run(t)
+
+		if len(receipt.Logs) != tt.events {
+			t.Errorf("%s: event count mismatch: have %d, want %d", tt.name, len(receipt.Logs), tt.events)
+		}
+		if have := receipt.GasUsed - base.GasUsed; have != tt.eventsGas {
+			t.Errorf("%s: events gas mismatch: have %d, want %d", tt.name, have, tt.eventsGas)
+		}
+	}
+}
+
+func TestRip7560GasInvariants(t *testing.T) {
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	for _, code := range [][]byte{nil, revert} {
+		test := newRip7560ExecutionTest(t, code)
+		test.vmcfg.Rip7560GasInvariants = true
+		test.run(t)
+
+		// Gas charged above the fee caps is neither paid to the coinbase nor burnt
+		overpriced := func(vpr *ValidationPhaseResult) {
+			vpr.PreCharge.Mul(vpr.PreCharge, uint256.NewInt(2))
+			vpr.EffectiveGasPrice = uint256.NewInt(2)
+		}
+		test = newRip7560ExecutionTest(t, code)
+		test.vmcfg.Rip7560GasInvariants = true
+		if _, err := test.apply(overpriced); !errors.Is(err, ErrRip7560GasAccounting) {
+			t.Errorf("lost gas payment not detected: have %v, want %v", err, ErrRip7560GasAccounting)
+		}
+		// A pre-charge short of the gas cost makes the refund underflow
+		test = newRip7560ExecutionTest(t, code)
+		test.vmcfg.Rip7560GasInvariants = true
+		if _, err := test.apply(func(vpr *ValidationPhaseResult) { vpr.PreCharge = uint256.NewInt(1) }); !errors.Is(err, ErrRip7560GasAccounting) {
+			t.Errorf("refund underflow not detected: have %v, want %v", err, ErrRip7560GasAccounting)
+		}
+		// The checks are disabled by default
+		test = newRip7560ExecutionTest(t, code)
+		if _, err := test.apply(overpriced); err != nil {
+			t.Errorf("unexpected error with disabled checks: %v", err)
+		}
+	}
+	if err := checkRip7560GasPool(common.Hash{}, 100, 40, 60); err != nil {
+		t.Errorf("unexpected gas pool error: %v", err)
+	}
+	for _, after := range []uint64{50, 120} {
+		if err := checkRip7560GasPool(common.Hash{}, 100, after, 60); !errors.Is(err, ErrRip7560GasAccounting) {
+			t.Errorf("gas pool delta %d -> %d not detected: have %v, want %v", 100, after, err, ErrRip7560GasAccounting)
+		}
+	}
+}
+
+func TestRip7560GasLimitExceeded(t *testing.T) {
+	test := newRip7560ExecutionTest(t, nil)
+	sender := *test.aatx.Sender
+	test.state.SetBalance(sender, uint256.NewInt(1000), 0)
+
+	// A validation phase accounted above the total gas limit of the transaction
+	_, err := test.apply(func(vpr *ValidationPhaseResult) { vpr.ValidationUsedGas = 1_000_000 })
+	if !errors.Is(err, ErrRip7560GasLimitExceeded) {
+		t.Fatalf("gas limit overrun not detected: have %v, want %v", err, ErrRip7560GasLimitExceeded)
+	}
+	if have := test.state.GetBalance(sender); !have.Eq(uint256.NewInt(1000)) {
+		t.Errorf("payer refunded on overrun: have balance %v, want %v", have, 1000)
+	}
+}
+
+func TestRip7560BlockGasLimit(t *testing.T) {
+	test := newRip7560ValidationCacheTest(t)
+	var (
+		sender   = *test.aatx.Sender
+		balance  = test.state.GetBalance(sender).Clone()
+		tx       = types.NewTx(test.aatx)
+		coinbase = common.Address{}
+	)
+	// The payer is not charged for a transaction not fitting in the rest of the block
+	gp := new(GasPool).AddGas(1000)
+	if _, _, err := BuyGasRip7560Transaction(test.aatx, test.state, uint256.NewInt(1), gp, new(uint256.Int), new(uint256.Int)); !errors.Is(err, ErrGasLimitReached) {
+		t.Errorf("exhausted gas pool not detected: have %v, want %v", err, ErrGasLimitReached)
+	}
+	if have := test.state.GetBalance(sender); !have.Eq(balance) || gp.Gas() != 1000 {
+		t.Errorf("charged for a transaction not fitting in the block: balance %v, gas pool %d", have, gp.Gas())
+	}
+	// The block builder leaves it for the next block instead of dropping it
+	txs, _, infos, _, err := HandleRip7560Transactions([]*types.Transaction{tx}, 0, test.state, &coinbase, test.header, gp, test.config, nil, vm.Config{}, true, new(uint64))
+	if err != nil || len(txs) != 0 || len(infos) != 0 {
+		t.Errorf("transaction not fitting in the block: have %d included, %d dropped, error %v", len(txs), len(infos), err)
+	}
+	// A transaction above the block gas limit can never be included, so it is dropped
+	header := *test.header
+	header.GasLimit = 1000
+	txs, _, infos, _, err = HandleRip7560Transactions([]*types.Transaction{tx}, 0, test.state, &coinbase, &header, gp, test.config, nil, vm.Config{}, true, new(uint64))
+	if err != nil || len(txs) != 0 || len(infos) != 1 {
+		t.Errorf("transaction above the block gas limit: have %d included, %d dropped, error %v", len(txs), len(infos), err)
+	}
+}
+
+func TestRip7560ChainID(t *testing.T) {
+	for _, chainID := range []*big.Int{big.NewInt(0), big.NewInt(1337)} {
+		test := newRip7560ValidationCacheTest(t)
+		test.aatx.ChainID = chainID
+		gp := new(GasPool).AddGas(test.header.GasLimit)
+		if _, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{}, gp, test.state, test.header, types.NewTx(test.aatx), vm.Config{}); !errors.Is(err, types.ErrInvalidChainId) {
+			t.Errorf("chain id %v: error mismatch: have %v, want %v", chainID, err, types.ErrInvalidChainId)
+		}
+	}
+}
+
+func TestRip7560TimeActivation(t *testing.T) {
+	forkTime := uint64(12)
+	tests := []struct {
+		name       string
+		headerTime uint64
+		forkTime   *uint64
+		active     bool
+	}{
+		{"not scheduled", 12, nil, false},
+		{"before fork", 11, &forkTime, false},
+		{"at fork", 12, &forkTime, true},
+	}
+	for _, tt := range tests {
+		test := newRip7560ValidationCacheTest(t)
+		test.config.RIP7560Block, test.config.RIP7560Time = nil, tt.forkTime
+		test.header.Time = tt.headerTime
+		gp := new(GasPool).AddGas(test.header.GasLimit)
+		_, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{}, gp, test.state, test.header, types.NewTx(test.aatx), vm.Config{})
+		if active := !errors.Is(err, types.ErrTxTypeNotSupported); active != tt.active {
+			t.Errorf("%s: active %v, want %v (error %v)", tt.name, active, tt.active, err)
+		} else if active && err != nil {
+			t.Errorf("%s: validation failed: %v", tt.name, err)
+		}
+	}
+}
+
+func TestRip7560Authorization(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		authority = crypto.PubkeyToAddress(key.PublicKey)
+		delegate  = common.Address{0xde, 0x1e}
+	)
+	for _, enabled := range []bool{false, true} {
+		// the sender is an EOA delegating to the code of an accepting account
+		test := newRip7560ValidationCacheTest(t)
+		test.state.SetCode(delegate, test.state.GetCode(*test.aatx.Sender))
+		test.state.SetBalance(authority, uint256.NewInt(params.Ether), 0)
+		test.state.Finalise(true)
+		test.aatx.Sender = &authority
+		if enabled {
+			test.config.RIP7560EIP7702Block = big.NewInt(0)
+		}
+		// the nonce of the sender is incremented before its authorization is applied
+		auth, err := types.SignSetCode(key, types.SetCodeAuthorization{ChainID: *uint256.MustFromBig(test.config.ChainID), Address: delegate, Nonce: 1})
+		if err != nil {
+			t.Fatalf("failed to sign authorization: %v", err)
+		}
+		test.aatx.AuthorizationList = []types.SetCodeAuthorization{auth}
+
+		gp := new(GasPool).AddGas(test.header.GasLimit)
+		vpr, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{}, gp, test.state, test.header, types.NewTx(test.aatx), vm.Config{})
+		if !enabled {
+			if err == nil {
+				t.Error("authorization accepted before the fork")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("validation failed: %v", err)
+		}
+		if code := test.state.GetCode(authority); !bytes.Equal(code, types.AddressToDelegation(delegate)) {
+			t.Errorf("code mismatch: have %x, want delegation to %x", code, delegate)
+		}
+		if nonce := test.state.GetNonce(authority); nonce != 2 {
+			t.Errorf("nonce mismatch: have %d, want 2", nonce)
+		}
+		// the authority exists, so it is refunded the cost of creating it
+		if want := params.CallNewAccountGas - params.TxAuthTupleGas; vpr.ValidationRefund != want {
+			t.Errorf("refund mismatch: have %d, want %d", vpr.ValidationRefund, want)
+		}
+	}
+
+	// a signature is not an authorization, the account must still be deployed
+	test := newRip7560ValidationCacheTest(t)
+	test.config.RIP7560EIP7702Block = big.NewInt(0)
+	test.aatx.Sender = &authority
+	test.aatx.AuthorizationData = []byte{0x01}
+	gp := new(GasPool).AddGas(test.header.GasLimit)
+	_, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{}, gp, test.state, test.header, types.NewTx(test.aatx), vm.Config{})
+	if err == nil || !strings.Contains(err.Error(), "account is not deployed") {
+		t.Errorf("undeployed account error mismatch: have %v", err)
+	}
+}
+
+func TestRip7560SystemEventsGasCapped(t *testing.T) {
+	// a revert reason of 4096 bytes costs more gas to log than the transaction has left,
+	// so the charge for the events is capped at the total gas limit
+	test := newRip7560ExecutionTest(t, []byte{byte(vm.PUSH2), 0x10, 0x00, byte(vm.PUSH1), 0, byte(vm.REVERT)})
+	test.config.RIP7560SystemEventGasBlock = big.NewInt(0)
+	test.aatx.ValidationGasLimit, _ = test.aatx.PreTransactionGasCost(false)
+	test.aatx.Gas = 1000
+
+	receipt := test.run(t)
+	totalGasLimit, _ := test.aatx.TotalGasLimit()
+	if receipt.GasUsed != totalGasLimit {
+		t.Errorf("gas used mismatch: have %d, want %d", receipt.GasUsed, totalGasLimit)
+	}
+}
+
+func TestRip7560EmptyExecution(t *testing.T) {
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	penalty := uint64(100_000 * AA_GAS_PENALTY_PCT / 100)
+
+	tests := []struct {
+		name      string
+		code      []byte
+		data      []byte
+		forkBlock *big.Int
+		status    uint64
+		events    int
+		saved     uint64
+	}{
+		{"called before fork", nil, nil, big.NewInt(2), types.ReceiptStatusSuccessful, 1, 0},
+		{"called when not configured", revert, nil, nil, types.ReceiptStatusFailed, 2, 0},
+		{"skipped after fork", nil, nil, big.NewInt(1), types.ReceiptStatusSuccessful, 1, penalty},
+		{"reverting account skipped after fork", revert, nil, big.NewInt(0), types.ReceiptStatusSuccessful, 1, 0},
+		{"non-empty data called after fork", revert, []byte{1}, big.NewInt(0), types.ReceiptStatusFailed, 2, 0},
+	}
+	for _, tt := range tests {
+		base := newRip7560ExecutionTest(t, tt.code)
+		base.aatx.ExecutionData = tt.data
+		baseReceipt := base.run(t)
+
+		test := newRip7560ExecutionTest(t, tt.code)
+		test.aatx.ExecutionData = tt.data
+		test.config.RIP7560EmptyExecutionBlock = tt.forkBlock
+		receipt := test.run(t)
+
+		if receipt.Status != tt.status {
+			t.Errorf("%s: status mismatch: have %d, want %d", tt.name, receipt.Status, tt.status)
+		}
+		if len(receipt.Logs) != tt.events {
+			t.Errorf("%s: event count mismatch: have %d, want %d", tt.name, len(receipt.Logs), tt.events)
+		}
+		// the gas used by the reverting account is not compared, only the penalty of a no-op frame
+		if tt.code == nil {
+			if have := baseReceipt.GasUsed - receipt.GasUsed; have != tt.saved {
+				t.Errorf("%s: saved gas mismatch: have %d, want %d", tt.name, have, tt.saved)
+			}
+		}
+	}
+}
+
+func TestRip7560PenaltyBeneficiary(t *testing.T) {
+	var (
+		vault    = common.Address{0xfe, 0xe5}
+		coinbase = common.Address{}
+		penalty  = uint64(100_000 * AA_GAS_PENALTY_PCT / 100)
+	)
+	// a gas price of 3 with a base fee of 2 tips the coinbase 1 per gas
+	priced := func(vpr *ValidationPhaseResult) {
+		vpr.PreCharge.Mul(vpr.PreCharge, uint256.NewInt(3))
+		vpr.EffectiveGasPrice = uint256.NewInt(3)
+	}
+	tests := []struct {
+		rules    []*params.RIP7560PenaltyRule
+		coinbase func(gasUsed uint64) uint64
+		vault    uint64
+	}{
+		{nil, func(gasUsed uint64) uint64 { return gasUsed }, 0},
+		{[]*params.RIP7560PenaltyRule{{Block: big.NewInt(2), Beneficiary: params.RIP7560PenaltyBurn}}, func(gasUsed uint64) uint64 { return gasUsed }, 0}, // not yet active
+		{[]*params.RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: params.RIP7560PenaltyBurn}}, func(gasUsed uint64) uint64 { return gasUsed - penalty }, 0},
+		{[]*params.RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: params.RIP7560PenaltyCoinbase}}, func(gasUsed uint64) uint64 { return gasUsed - penalty + 3*penalty }, 0},
+		{[]*params.RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: params.RIP7560PenaltyVault, Vault: &vault}}, func(gasUsed uint64) uint64 { return gasUsed - penalty }, 3 * penalty},
+	}
+	for _, tt := range tests {
+		test := newRip7560ExecutionTest(t, nil)
+		test.config.RIP7560Penalties = tt.rules
+		test.header.BaseFee = big.NewInt(2)
+		test.aatx.GasFeeCap = big.NewInt(3)
+		test.vmcfg.Rip7560GasInvariants = true
+		receipt, err := test.apply(priced)
+		if err != nil {
+			t.Fatalf("penalty %v: failed to apply execution phase: %v", tt.rules, err)
+		}
+		// the penalty does not change the gas charged from the payer
+		totalGasLimit, _ := test.aatx.TotalGasLimit()
+		if have, want := test.state.GetBalance(*test.aatx.Sender).Uint64(), 3*(totalGasLimit-receipt.GasUsed); have != want {
+			t.Errorf("penalty %v: refund mismatch: have %d, want %d", tt.rules, have, want)
+		}
+		if have, want := test.state.GetBalance(coinbase).Uint64(), tt.coinbase(receipt.GasUsed); have != want {
+			t.Errorf("penalty %v: coinbase balance mismatch: have %d, want %d", tt.rules, have, want)
+		}
+		if have := test.state.GetBalance(vault).Uint64(); have != tt.vault {
+			t.Errorf("penalty %v: vault balance mismatch: have %d, want %d", tt.rules, have, tt.vault)
+		}
+	}
+}
+
+func TestRip7560GasPenaltyRules(t *testing.T) {
+	preTransactionGasCost, _ := newRip7560ExecutionTest(t, nil).aatx.PreTransactionGasCost(false)
+	tests := []struct {
+		name    string
+		rules   []*params.RIP7560GasPenaltyRule
+		penalty uint64
+	}{
+		{"default", nil, 100_000 * AA_GAS_PENALTY_PCT / 100},
+		{"not yet active", []*params.RIP7560GasPenaltyRule{{Block: big.NewInt(2), Percent: 50, Validation: true}}, 100_000 * AA_GAS_PENALTY_PCT / 100},
+		{"validation only", []*params.RIP7560GasPenaltyRule{{Block: big.NewInt(1), Percent: 20, Validation: true}}, (100_000 - preTransactionGasCost) * 20 / 100},
+		{"superseded", []*params.RIP7560GasPenaltyRule{{Block: big.NewInt(0), Percent: 50, Execution: true}, {Block: big.NewInt(1), Percent: 0, Execution: true}}, 0},
+	}
+	for _, tt := range tests {
+		test := newRip7560ExecutionTest(t, nil)
+		test.config.RIP7560GasPenalties = tt.rules
+		receipt := test.run(t)
+		// the account runs no code, so the transaction only uses its pre-transaction gas
+		if have := receipt.GasUsed - preTransactionGasCost; have != tt.penalty {
+			t.Errorf("%s: penalty mismatch: have %d, want %d", tt.name, have, tt.penalty)
+		}
+	}
+}
+
+func TestRip7560BuilderFee(t *testing.T) {
+	var (
+		recipient = common.Address{0xb0}
+		coinbase  = common.Address{0xc0}
+	)
+	tests := []struct {
+		name      string
+		forkBlock *big.Int
+		recipient *common.Address
+		fee       uint64 // paid to the recipient, or the coinbase without one
+	}{
+		{"before fork", big.NewInt(2), nil, 0},
+		{"coinbase", big.NewInt(1), nil, 1000},
+		{"recipient", big.NewInt(1), &recipient, 1000},
+	}
+	for _, tt := range tests {
+		test := newRip7560ValidationCacheTest(t)
+		test.config.RIP7560BuilderFeeBlock = tt.forkBlock
+		test.config.RIP7560BuilderFeeRecipient = tt.recipient
+		test.aatx.BuilderFee = big.NewInt(1000)
+		test.vmcfg.Rip7560GasInvariants = true
+		var (
+			tx      = types.NewTx(test.aatx)
+			sender  = *test.aatx.Sender
+			balance = test.state.GetBalance(sender).Uint64()
+			gp      = new(GasPool).AddGas(test.header.GasLimit)
+		)
+		vpr, err := ApplyRip7560ValidationPhases(test.config, nil, &coinbase, gp, test.state, test.header, tx, test.vmcfg)
+		if err != nil {
+			t.Fatalf("%s: validation failed: %v", tt.name, err)
+		}
+		receipt, _, _, err := ApplyRip7560ExecutionPhase(test.config, vpr, nil, &coinbase, gp, test.state, test.header, test.vmcfg, new(uint64))
+		if err != nil {
+			t.Fatalf("%s: failed to apply execution phase: %v", tt.name, err)
+		}
+		// the gas is paid at a price of 1, the builder fee on top of it
+		if have, want := balance-test.state.GetBalance(sender).Uint64(), receipt.GasUsed+tt.fee; have != want {
+			t.Errorf("%s: payer charge mismatch: have %d, want %d", tt.name, have, want)
+		}
+		wantCoinbase, wantRecipient := receipt.GasUsed+tt.fee, uint64(0)
+		if tt.recipient != nil {
+			wantCoinbase, wantRecipient = receipt.GasUsed, tt.fee
+		}
+		if have := test.state.GetBalance(coinbase).Uint64(); have != wantCoinbase {
+			t.Errorf("%s: coinbase balance mismatch: have %d, want %d", tt.name, have, wantCoinbase)
+		}
+		if have := test.state.GetBalance(recipient).Uint64(); have != wantRecipient {
+			t.Errorf("%s: recipient balance mismatch: have %d, want %d", tt.name, have, wantRecipient)
+		}
+		if charged := test.config.IsRIP7560BuilderFee(test.header.Number); charged != (receipt.Rip7560BuilderFee != nil) ||
+			charged && receipt.Rip7560BuilderFee.Uint64() != tt.fee {
+			t.Errorf("%s: receipt builder fee mismatch: have %v, want %d", tt.name, receipt.Rip7560BuilderFee, tt.fee)
+		}
+	}
+
+	// The payer has to afford the builder fee along with the gas
+	test := newRip7560ValidationCacheTest(t)
+	test.config.RIP7560BuilderFeeBlock = big.NewInt(0)
+	test.aatx.BuilderFee = big.NewInt(params.Ether)
+	gp := new(GasPool).AddGas(test.header.GasLimit)
+	if _, err := ApplyRip7560ValidationPhases(test.config, nil, &coinbase, gp, test.state, test.header, types.NewTx(test.aatx), vm.Config{}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("unaffordable builder fee not detected: have %v, want %v", err, ErrInsufficientFunds)
+	}
+}
+
+func TestRip7560ReceiptStatus(t *testing.T) {
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	for _, tt := range []struct {
+		code          []byte
+		paymasterCode []byte // nil if no postOp frame runs
+		status        uint64
+	}{
+		{nil, nil, ExecutionStatusSuccess},
+		{revert, nil, ExecutionStatusExecutionFailure},
+		{nil, []byte{byte(vm.STOP)}, ExecutionStatusSuccess},
+		{nil, revert, ExecutionStatusPostOpFailure},
+		{revert, revert, ExecutionStatusExecutionAndPostOpFailure},
+	} {
+		test := newRip7560ExecutionTest(t, tt.code)
+		if tt.paymasterCode != nil {
+			paymaster := common.HexToAddress("0x5555555555666666666677777777778888888888")
+			test.state.SetCode(paymaster, tt.paymasterCode)
+			test.aatx.Paymaster = &paymaster
+			test.aatx.PostOpGas = 10_000
+		}
+		receipt, err := test.apply(func(vpr *ValidationPhaseResult) {
+			vpr.SenderValidAfter, vpr.SenderValidUntil = 1, 2
+			vpr.PmValidAfter, vpr.PmValidUntil = 3, 4
+			if tt.paymasterCode != nil {
+				vpr.PaymasterContext = []byte{0x01}
+			}
+		})
+		if err != nil {
+			t.Fatalf("failed to apply execution phase: %v", err)
+		}
+		if status, ok := Rip7560ExecutionStatus(receipt.Logs); !ok || status != tt.status {
+			t.Errorf("execution status mismatch: have %d (%v), want %d", status, ok, tt.status)
+		}
+		if receipt.Rip7560ExecutionStatus == nil || *receipt.Rip7560ExecutionStatus != tt.status {
+			t.Errorf("receipt execution status mismatch: have %v, want %d", receipt.Rip7560ExecutionStatus, tt.status)
+		}
+		want := types.Rip7560Validity{AccountValidAfter: 1, AccountValidUntil: 2, PaymasterValidAfter: 3, PaymasterValidUntil: 4}
+		if receipt.Rip7560Validity == nil || *receipt.Rip7560Validity != want {
+			t.Errorf("validity mismatch: have %+v, want %+v", receipt.Rip7560Validity, want)
+		}
+	}
+}
+
+func TestRip7560ReceiptContractAddress(t *testing.T) {
+	deployer := common.Address{0x02}
+	for _, tt := range []struct {
+		deployer *common.Address
+		code     []byte
+		deployed bool
+	}{
+		{nil, []byte{byte(vm.STOP)}, false},
+		{&deployer, []byte{byte(vm.STOP)}, true},
+		{&deployer, nil, true}, // the sender code is not checked again after validation
+	} {
+		test := newRip7560ExecutionTest(t, tt.code)
+		test.aatx.Deployer = tt.deployer
+		receipt := test.run(t)
+		want := common.Address{}
+		if tt.deployed {
+			want = *test.aatx.Sender
+		}
+		if receipt.ContractAddress != want {
+			t.Errorf("deployer %v, code %x: contract address mismatch: have %v, want %v", tt.deployer, tt.code, receipt.ContractAddress, want)
+		}
+	}
+}
+
+func TestRip7560ReceiptEffectiveGasPrice(t *testing.T) {
+	test := newRip7560ExecutionTest(t, nil)
+	receipt, err := test.apply(func(vpr *ValidationPhaseResult) {
+		vpr.EffectiveGasPrice = uint256.NewInt(7)
+	})
+	if err != nil {
+		t.Fatalf("failed to apply execution phase: %v", err)
+	}
+	if receipt.EffectiveGasPrice == nil || receipt.EffectiveGasPrice.Uint64() != 7 {
+		t.Errorf("effective gas price mismatch: have %v, want 7", receipt.EffectiveGasPrice)
+	}
+}
+
+func TestRip7560GasAttribution(t *testing.T) {
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	for _, code := range [][]byte{nil, revert} {
+		test := newRip7560ExecutionTest(t, code)
+		test.config.RIP7560SystemEventGasBlock = big.NewInt(0)
+		receipt, err := test.apply(func(vpr *ValidationPhaseResult) {
+			vpr.NonceManagerUsedGas = 100
+			vpr.DeploymentUsedGas = 200
+			vpr.ValidationUsedGas = 300
+			vpr.PmValidationUsedGas = 400
+			vpr.CallDataUsedGas = 50
+		})
+		if err != nil {
+			t.Fatalf("failed to apply execution phase: %v", err)
+		}
+		attribution := receipt.Rip7560GasAttribution
+		if attribution == nil {
+			t.Fatal("missing gas attribution")
+		}
+		if have := attribution.GasUsed(); have != receipt.GasUsed {
+			t.Errorf("attributed gas mismatch: have %d, want %d", have, receipt.GasUsed)
+		}
+		preTransactionGasCost, _ := test.aatx.PreTransactionGasCost(false)
+		if have, want := uint64(attribution.Account), preTransactionGasCost+600; have != want {
+			t.Errorf("account gas mismatch: have %d, want %d", have, want)
+		}
+		if attribution.PaymasterValidation != 400 {
+			t.Errorf("paymaster validation gas mismatch: have %d, want 400", attribution.PaymasterValidation)
+		}
+		if attribution.SystemEvents == 0 || attribution.PostOp != 0 {
+			t.Errorf("unexpected system events gas %d and postOp gas %d", attribution.SystemEvents, attribution.PostOp)
+		}
+		// the unused gas penalty is drawn from the execution gas limit
+		if penalty := uint64(100_000 * AA_GAS_PENALTY_PCT / 100); code == nil && uint64(attribution.Execution) != penalty {
+			t.Errorf("execution gas mismatch: have %d, want %d", attribution.Execution, penalty)
+		}
+		// the frame gas leaves the penalties out
+		frames := receipt.Rip7560FrameGasUsed
+		if frames == nil {
+			t.Fatal("missing frame gas")
+		}
+		if frames.Validation != 300 || frames.PaymasterValidation != 400 || frames.Deployment != 200 || frames.PostOp != 0 || frames.CallData != 50 {
+			t.Errorf("frame gas mismatch: have %+v", frames)
+		}
+		if have, want := uint64(frames.Execution), uint64(attribution.Execution)-(100_000-uint64(frames.Execution))*AA_GAS_PENALTY_PCT/100; have != want {
+			t.Errorf("execution frame gas mismatch: have %d, want %d", have, want)
+		}
+	}
+}
+
+func TestRip7560CallDataUsedGas(t *testing.T) {
+	test := newRip7560ValidationCacheTest(t)
+	test.aatx.ExecutionData = []byte{0, 1, 2}
+	vpr, _, _, _ := test.validate(t, nil, test.header)
+
+	// the calldata gas is part of the pre-transaction gas cost
+	want := 2*params.TxDataNonZeroGasEIP2028 + params.TxDataZeroGas
+	if vpr.CallDataUsedGas != want {
+		t.Errorf("calldata gas mismatch: have %d, want %d", vpr.CallDataUsedGas, want)
+	}
+	if have := vpr.PreTransactionGasCost; have != params.Rip7560TxGas+want {
+		t.Errorf("pre-transaction gas mismatch: have %d, want %d", have, params.Rip7560TxGas+want)
+	}
+}
+
+func TestRip7560EntryPointInterception(t *testing.T) {
+	// an empty call to the EntryPoint before the acceptAccount callback
+	repeated := []vm.OpCode{vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH2, 0x75, 0x60, vm.GAS, vm.CALL, vm.POP}
+	tests := []struct {
+		name   string
+		prefix []vm.OpCode
+		traced bool
+		valid  bool
+	}{
+		{"untraced", nil, false, true},
+		{"traced", nil, true, true},
+		{"repeated untraced", repeated, false, false},
+		{"repeated traced", repeated, true, false},
+	}
+	for _, tt := range tests {
+		test := newRip7560ValidationCacheTest(t, tt.prefix...)
+		var (
+			cfg   vm.Config
+			calls int
+		)
+		if tt.traced {
+			cfg.Tracer = &tracing.Hooks{
+				OnEnter: func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+					if to == AA_ENTRY_POINT {
+						calls++
+					}
+				},
+			}
+		}
+		gp := new(GasPool).AddGas(test.header.GasLimit)
+		_, err := ApplyRip7560ValidationPhases(test.config, nil, &common.Address{0xc0}, gp, test.state.Copy(), test.header, types.NewTx(test.aatx), cfg)
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("%s: validity mismatch: have %v, want %v", tt.name, err, tt.valid)
+		}
+		// the tracer sees every call to the EntryPoint
+		if want := len(tt.prefix) / len(repeated); tt.traced && calls != want+1 {
+			t.Errorf("%s: traced EntryPoint calls mismatch: have %d, want %d", tt.name, calls, want+1)
+		}
+	}
+}
+
+func TestRip7560WarmExecution(t *testing.T) {
+	sload := []byte{byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP)}
+	run := func(forkBlock *big.Int, warmSlot bool) uint64 {
+		test := newRip7560ExecutionTest(t, sload)
+		test.config.RIP7560WarmExecutionBlock = forkBlock
+		warm := types.AccessList{{Address: *test.aatx.Sender, StorageKeys: []common.Hash{}}}
+		if warmSlot {
+			warm[0].StorageKeys = append(warm[0].StorageKeys, common.Hash{})
+		}
+		// the state is left with the access list of the last validated transaction
+		test.state.SetAccessList(types.AccessList{{Address: *test.aatx.Sender}})
+		receipt, err := test.apply(func(vpr *ValidationPhaseResult) { vpr.WarmAccessList = warm })
+		if err != nil {
+			t.Fatalf("failed to apply execution phase: %v", err)
+		}
+		return receipt.GasUsed
+	}
+	var (
+		cold  = run(big.NewInt(0), false)
+		warm  = run(big.NewInt(0), true)
+		saved = params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929
+	)
+	// the gas saved on the execution frame is partially charged back as unused gas penalty
+	if have, want := cold-warm, saved-saved*AA_GAS_PENALTY_PCT/100; have != want {
+		t.Errorf("saved gas mismatch: have %d, want %d", have, want)
+	}
+	if have := run(big.NewInt(2), true); have != cold {
+		t.Errorf("warm state carried over before the fork: have %d gas used, want %d", have, cold)
+	}
+}
+
+func TestApplyRip7560PaymasterValidation(t *testing.T) {
+	acceptPaymaster, err := Rip7560Abi.Pack("acceptPaymaster", big.NewInt(10), big.NewInt(20), []byte{1, 2, 3})
+	if err != nil {
+		t.Fatalf("failed to pack acceptPaymaster: %v", err)
+	}
+	// copy the 'acceptPaymaster' calldata appended to the code into memory and call the EntryPoint with it
+	size := byte(len(acceptPaymaster))
+	accepting := []byte{
+		byte(vm.PUSH1), size, byte(vm.PUSH1), 26, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), 0x75, 0x60, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP),
+		byte(vm.STOP), byte(vm.STOP),
+	}
+	accepting = append(accepting, acceptPaymaster...)
+	reverting := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+
+	tests := []struct {
+		name     string
+		code     []byte
+		header   *types.Header
+		accepted bool
+	}{
+		{"accepted", accepting, &types.Header{Number: big.NewInt(1), Time: 15, BaseFee: big.NewInt(0), Difficulty: big.NewInt(0)}, true},
+		{"expired", accepting, &types.Header{Number: big.NewInt(1), Time: 21, BaseFee: big.NewInt(0), Difficulty: big.NewInt(0)}, false},
+		{"reverted", reverting, &types.Header{Number: big.NewInt(1), Time: 15, BaseFee: big.NewInt(0), Difficulty: big.NewInt(0)}, false},
+	}
+	for _, tt := range tests {
+		test := newRip7560ExecutionTest(t, nil)
+		test.config.RIP7560Block = big.NewInt(0)
+		paymaster := common.HexToAddress("0x5555555555666666666677777777778888888888")
+		test.state.SetCode(paymaster, tt.code)
+		test.aatx.Paymaster = &paymaster
+		test.aatx.PaymasterValidationGasLimit = 100_000
+		test.aatx.PostOpGas = 10_000
+
+		result, err := ApplyRip7560PaymasterValidation(test.config, nil, test.state, tt.header, types.NewTx(test.aatx), vm.Config{})
+		if !tt.accepted {
+			var vpe *ValidationPhaseError
+			if !errors.As(err, &vpe) {
+				t.Errorf("%s: expected a validation phase error, have %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: paymaster validation failed: %v", tt.name, err)
+		}
+		if result.ValidAfter != 10 || result.ValidUntil != 20 || result.ContextSize != 3 || result.UsedGas == 0 {
+			t.Errorf("%s: unexpected result: %+v", tt.name, result)
+		}
+	}
+}
+
+func TestRip7560FrameWitnessGas(t *testing.T) {
+	test := newRip7560ExecutionTest(t, nil)
+	verkle := uint64(0)
+	test.config.VerkleTime = &verkle
+	// the frame target exists without code, so calling it costs nothing but its witness
+	target := common.HexToAddress("0x5555555555666666666677777777778888888888")
+	test.state.SetBalance(target, uint256.NewInt(1), 0)
+
+	coinbase := common.Address{0xc0}
+	evm := vm.NewEVM(NewEVMBlockContext(test.header, nil, &coinbase, test.config, test.state), vm.TxContext{}, test.state, test.config, vm.Config{})
+	addRip7560AccessEvents(evm, test.aatx)
+	st := NewStateTransition(evm, nil, new(GasPool).AddGas(test.header.GasLimit))
+	st.gasRemaining = 100_000
+
+	// the caller and callee basic data are cold for the first frame only
+	cold := 2 * (params.WitnessBranchReadCost + 2*params.WitnessChunkReadCost)
+	for i, want := range []uint64{cold, 0} {
+		if result := CallFrame(st, &AA_ENTRY_POINT, &target, nil, cold); result.Err != nil || result.UsedGas != want {
+			t.Errorf("frame %d: have %d gas used, error %v, want %d", i, result.UsedGas, result.Err, want)
+		}
+	}
+	// a frame short of the witness gas of its callee fails without running
+	other := common.HexToAddress("0x9999999999aaaaaaaaaabbbbbbbbbbcccccccccc")
+	if result := CallFrame(st, &AA_ENTRY_POINT, &other, nil, cold/2-1); result.Err != vm.ErrOutOfGas || result.UsedGas != cold/2-1 {
+		t.Errorf("frame short of witness gas: have %d gas used, error %v", result.UsedGas, result.Err)
+	}
+	if want := 100_000 - cold - (cold/2 - 1); st.gasRemaining != want {
+		t.Errorf("remaining gas mismatch: have %d, want %d", st.gasRemaining, want)
+	}
+}
@@ -247,6 +247,17 @@ const (
 
 	// OP-Geth specific
 	BalanceMint BalanceChangeReason = 200
+
+	// RIP-7560 specific
+	// BalanceIncreaseRip7560PenaltyCoinbase is the penalty charged on the unused gas
+	// of an RIP-7560 transaction, paid to the coinbase.
+	BalanceIncreaseRip7560PenaltyCoinbase BalanceChangeReason = 210
+	// BalanceIncreaseRip7560PenaltyVault is the penalty charged on the unused gas
+	// of an RIP-7560 transaction, paid to the configured fee vault.
+	BalanceIncreaseRip7560PenaltyVault BalanceChangeReason = 211
+	// BalanceIncreaseRip7560BuilderFee is the builder fee of an RIP-7560 transaction,
+	// paid to the coinbase or the configured builder fee recipient.
+	BalanceIncreaseRip7560BuilderFee BalanceChangeReason = 212
 )
 
 // GasChangeReason is used to indicate the reason for a gas change, useful
@@ -1660,6 +1660,21 @@ func (pool *BlobPool) SubmitRip7560Bundle(_ *types.ExternallyReceivedBundle) err
 	return nil
 }
 
+func (pool *BlobPool) CheckRip7560Bundle(_ *types.ExternallyReceivedBundle) error {
+	// nothing to do here
+	return nil
+}
+
+func (pool *BlobPool) ExtendRip7560Bundle(_ common.Hash, _ *big.Int) error {
+	// nothing to do here
+	return nil
+}
+
+func (pool *BlobPool) CancelRip7560Bundle(_ common.Hash) (*types.BundleReceipt, error) {
+	// nothing to do here
+	return nil, nil
+}
+
 func (pool *BlobPool) GetRip7560BundleStatus(_ common.Hash) (*types.BundleReceipt, error) {
 	// nothing to do here
 	return nil, nil
@@ -1669,3 +1684,25 @@ func (pool *BlobPool) PendingRip7560Bundle() (*types.ExternallyReceivedBundle, e
 	// nothing to do here
 	return nil, nil
 }
+
+func (pool *BlobPool) PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error) {
+	// nothing to do here
+	return nil, nil
+}
+
+func (pool *BlobPool) SubscribeRip7560TxStatus(_ chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	// nothing to do here
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+func (pool *BlobPool) ReportRip7560TxsDropped(_ []*types.Rip7560TransactionDebugInfo) {
+	// nothing to do here
+}
+
+func (pool *BlobPool) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	// nothing to do here
+	return nil
+}
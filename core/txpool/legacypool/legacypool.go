@@ -2027,6 +2027,21 @@ func (pool *LegacyPool) SubmitRip7560Bundle(_ *types.ExternallyReceivedBundle) e
 	return nil
 }
 
+func (pool *LegacyPool) CheckRip7560Bundle(_ *types.ExternallyReceivedBundle) error {
+	// nothing to do here
+	return nil
+}
+
+func (pool *LegacyPool) ExtendRip7560Bundle(_ common.Hash, _ *big.Int) error {
+	// nothing to do here
+	return nil
+}
+
+func (pool *LegacyPool) CancelRip7560Bundle(_ common.Hash) (*types.BundleReceipt, error) {
+	// nothing to do here
+	return nil, nil
+}
+
 func (pool *LegacyPool) GetRip7560BundleStatus(_ common.Hash) (*types.BundleReceipt, error) {
 	// nothing to do here
 	return nil, nil
@@ -2036,3 +2051,25 @@ func (pool *LegacyPool) PendingRip7560Bundle() (*types.ExternallyReceivedBundle,
 	// nothing to do here
 	return nil, nil
 }
+
+func (pool *LegacyPool) PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error) {
+	// nothing to do here
+	return nil, nil
+}
+
+func (pool *LegacyPool) SubscribeRip7560TxStatus(_ chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	// nothing to do here
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+func (pool *LegacyPool) ReportRip7560TxsDropped(_ []*types.Rip7560TransactionDebugInfo) {
+	// nothing to do here
+}
+
+func (pool *LegacyPool) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	// nothing to do here
+	return nil
+}
@@ -0,0 +1,118 @@
+package rip7560pool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+)
+
+// validationAccesses is the state the validation phases of a pooled transaction read or
+// wrote. The transaction only needs to be revalidated once any of it changes.
+type validationAccesses struct {
+	accounts map[common.Address]struct{}                 // Accounts whose code, balance or nonce were accessed
+	slots    map[common.Address]map[common.Hash]struct{} // Storage slots accessed, by contract
+}
+
+func newValidationAccesses() *validationAccesses {
+	return &validationAccesses{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (a *validationAccesses) addAccount(addr common.Address) {
+	a.accounts[addr] = struct{}{}
+}
+
+func (a *validationAccesses) addSlot(addr common.Address, slot common.Hash) {
+	if a.slots[addr] == nil {
+		a.slots[addr] = make(map[common.Hash]struct{})
+	}
+	a.slots[addr][slot] = struct{}{}
+}
+
+// headDiff reports the changes of accounts and storage slots between two consecutive
+// head states. Every account and slot is only looked up once in each state.
+type headDiff struct {
+	oldState *state.StateDB
+	newState *state.StateDB
+
+	accounts map[common.Address]bool
+	storages map[common.Address]bool
+	slots    map[common.Address]map[common.Hash]bool
+}
+
+func newHeadDiff(oldState, newState *state.StateDB) *headDiff {
+	return &headDiff{
+		oldState: oldState,
+		newState: newState,
+		accounts: make(map[common.Address]bool),
+		storages: make(map[common.Address]bool),
+		slots:    make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// accountChanged reports whether the code, balance or nonce of the account changed.
+func (d *headDiff) accountChanged(addr common.Address) bool {
+	changed, ok := d.accounts[addr]
+	if !ok {
+		changed = d.oldState.GetNonce(addr) != d.newState.GetNonce(addr) ||
+			d.oldState.GetBalance(addr).Cmp(d.newState.GetBalance(addr)) != 0 ||
+			d.oldState.GetCodeHash(addr) != d.newState.GetCodeHash(addr)
+		d.accounts[addr] = changed
+	}
+	return changed
+}
+
+// storageChanged reports whether any storage slot of the account changed.
+func (d *headDiff) storageChanged(addr common.Address) bool {
+	changed, ok := d.storages[addr]
+	if !ok {
+		changed = d.oldState.GetStorageRoot(addr) != d.newState.GetStorageRoot(addr)
+		d.storages[addr] = changed
+	}
+	return changed
+}
+
+// slotChanged reports whether the value of the storage slot changed. The slots of the
+// accounts whose storage root did not change are not looked up.
+func (d *headDiff) slotChanged(addr common.Address, slot common.Hash) bool {
+	if !d.storageChanged(addr) {
+		return false
+	}
+	if d.slots[addr] == nil {
+		d.slots[addr] = make(map[common.Hash]bool)
+	}
+	changed, ok := d.slots[addr][slot]
+	if !ok {
+		changed = d.oldState.GetState(addr, slot) != d.newState.GetState(addr, slot)
+		d.slots[addr][slot] = changed
+	}
+	return changed
+}
+
+// invalidates reports whether the changes may invalidate a transaction relying on the
+// given accounts, whose validation phases made the given accesses. Without recorded
+// accesses, any storage change of the accounts invalidates the transaction.
+func (d *headDiff) invalidates(accounts []common.Address, accesses *validationAccesses) bool {
+	for _, addr := range accounts {
+		if d.accountChanged(addr) || (accesses == nil && d.storageChanged(addr)) {
+			return true
+		}
+	}
+	if accesses == nil {
+		return false
+	}
+	for addr := range accesses.accounts {
+		if d.accountChanged(addr) {
+			return true
+		}
+	}
+	for addr, slots := range accesses.slots {
+		for slot := range slots {
+			if d.slotChanged(addr, slot) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+package rip7560pool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// ErrErc7562Violation is returned if the validation phases of a transaction break the
+// ERC-7562 validation rules. The violations are listed by the Erc7562Error wrapping it.
+var ErrErc7562Violation = errors.New("ERC-7562 validation rules violated")
+
+// maxAssociatedSlotOffset is the furthest a storage slot associated with the sender can
+// be from the hash of a preimage starting with the sender address [STO-021].
+const maxAssociatedSlotOffset = 128
+
+// maxAssociatedPreimageSize is the size of the largest KECCAK256 preimage considered
+// for the association of storage slots with the sender.
+const maxAssociatedPreimageSize = 512
+
+// erc7562BannedOpcodes are the opcodes an unstaked entity may not use during validation.
+var erc7562BannedOpcodes = map[vm.OpCode]string{
+	vm.GASPRICE:     "OP-011",
+	vm.GASLIMIT:     "OP-011",
+	vm.DIFFICULTY:   "OP-011",
+	vm.TIMESTAMP:    "OP-011",
+	vm.BASEFEE:      "OP-011",
+	vm.BLOCKHASH:    "OP-011",
+	vm.NUMBER:       "OP-011",
+	vm.ORIGIN:       "OP-011",
+	vm.COINBASE:     "OP-011",
+	vm.BLOBHASH:     "OP-011",
+	vm.BLOBBASEFEE:  "OP-011",
+	vm.INVALID:      "OP-011",
+	vm.SELFDESTRUCT: "OP-011",
+	vm.CREATE:       "OP-031",
+	vm.BALANCE:      "OP-080",
+	vm.SELFBALANCE:  "OP-080",
+}
+
+// Erc7562Violation is a breach of an ERC-7562 validation rule by one of the entities of
+// a transaction.
+type Erc7562Violation struct {
+	Rule    string         `json:"rule"`    // Id of the rule, e.g. OP-011
+	Entity  string         `json:"entity"`  // Entity whose validation frame broke the rule
+	Address common.Address `json:"address"` // Contract whose code broke the rule
+	Reason  string         `json:"reason"`
+}
+
+func (v Erc7562Violation) String() string {
+	return fmt.Sprintf("[%s] %s: %s in %v", v.Rule, v.Entity, v.Reason, v.Address)
+}
+
+// Erc7562Error lists the ERC-7562 rules broken by the validation phases of a transaction.
+type Erc7562Error struct {
+	Violations []Erc7562Violation
+}
+
+func (e *Erc7562Error) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		violations[i] = v.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrErc7562Violation, strings.Join(violations, "; "))
+}
+
+func (e *Erc7562Error) Unwrap() error {
+	return ErrErc7562Violation
+}
+
+// erc7562Tracer enforces the ERC-7562 rules for unstaked entities on the validation
+// phases of an RIP-7560 transaction. The frames of system contracts, like the nonce
+// manager, are not subject to the rules.
+type erc7562Tracer struct {
+	aatx       *types.Rip7560AccountAbstractionTx
+	env        *tracing.VMContext
+	violations []Erc7562Violation
+
+	entity     string    // Entity of the current validation frame, empty for system frames
+	creates    int       // Number of CREATE2 opcodes run by the current frame
+	lastOp     vm.OpCode // Last opcode run by the current frame
+	lastOpAddr common.Address
+	extCode    *common.Address // Address of the EXTCODESIZE pending its ISZERO check [OP-051]
+
+	associated []*uint256.Int // Hashes of the preimages starting with the sender address
+
+	accesses *validationAccesses // State accessed by all frames, system ones included
+}
+
+func newErc7562Tracer(aatx *types.Rip7560AccountAbstractionTx) *erc7562Tracer {
+	return &erc7562Tracer{aatx: aatx, accesses: newValidationAccesses()}
+}
+
+func (t *erc7562Tracer) hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
+	}
+}
+
+// err returns the violations of the rules reported so far as an Erc7562Error.
+func (t *erc7562Tracer) err() error {
+	if len(t.violations) == 0 {
+		return nil
+	}
+	return &Erc7562Error{Violations: t.violations}
+}
+
+func (t *erc7562Tracer) report(rule string, addr common.Address, format string, args ...interface{}) {
+	v := Erc7562Violation{Rule: rule, Entity: t.entity, Address: addr, Reason: fmt.Sprintf(format, args...)}
+	if !slices.Contains(t.violations, v) {
+		t.violations = append(t.violations, v)
+	}
+}
+
+func (t *erc7562Tracer) OnTxStart(env *tracing.VMContext, _ *types.Transaction, _ common.Address) {
+	t.env = env
+}
+
+func (t *erc7562Tracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, _ []byte, _ uint64, value *big.Int) {
+	t.accesses.addAccount(to)
+	if depth == 0 {
+		t.entity = ""
+		switch {
+		case t.aatx.Deployer != nil && to == *t.aatx.Deployer && from == core.AA_SENDER_CREATOR:
+			t.entity = "deployer"
+		case to == *t.aatx.Sender:
+			t.entity = "account"
+		case t.aatx.Paymaster != nil && to == *t.aatx.Paymaster:
+			t.entity = "paymaster"
+		}
+		t.creates, t.lastOp, t.extCode = 0, vm.STOP, nil
+		return
+	}
+	if t.entity == "" {
+		return
+	}
+	// [OP-061] value may only be transferred to the entry point
+	if vm.OpCode(typ) == vm.CALL && value != nil && value.Sign() > 0 && to != core.AA_ENTRY_POINT {
+		t.report("OP-061", from, "call with value to %v", to)
+	}
+}
+
+func (t *erc7562Tracer) OnExit(depth int, _ []byte, _ uint64, err error, _ bool) {
+	if t.entity == "" {
+		return
+	}
+	if depth == 0 {
+		t.checkPending(vm.STOP)
+	}
+	// [OP-020] running out of gas in any call is forbidden
+	if errors.Is(err, vm.ErrOutOfGas) {
+		t.report("OP-020", t.lastOpAddr, "out of gas")
+	}
+}
+
+// checkPending checks the rules depending on the opcode following the previous one.
+func (t *erc7562Tracer) checkPending(op vm.OpCode) {
+	// [OP-012] GAS is only allowed right before a call
+	if t.lastOp == vm.GAS && !isCall(op) {
+		t.report("OP-012", t.lastOpAddr, "GAS not followed by a call")
+	}
+	// [OP-051] EXTCODESIZE of an address without code is only allowed right before ISZERO
+	if t.extCode != nil && op != vm.ISZERO {
+		t.report("OP-041", t.lastOpAddr, "access to %v without code", *t.extCode)
+	}
+	t.extCode = nil
+}
+
+func (t *erc7562Tracer) OnOpcode(_ uint64, opcode byte, _, _ uint64, scope tracing.OpContext, _ []byte, _ int, _ error) {
+	var (
+		op    = vm.OpCode(opcode)
+		addr  = scope.Address()
+		stack = scope.StackData()
+	)
+	t.recordAccess(op, addr, stack)
+	if t.entity == "" {
+		return
+	}
+	t.checkPending(op)
+	t.lastOp, t.lastOpAddr = op, addr
+
+	if rule, ok := erc7562BannedOpcodes[op]; ok {
+		t.report(rule, addr, "banned opcode %v", op)
+	}
+	switch {
+	case op == vm.CREATE2:
+		// [OP-031] CREATE2 is only allowed once, for the deployer to deploy the sender
+		if t.creates++; t.entity != "deployer" || t.creates > 1 {
+			t.report("OP-031", addr, "CREATE2 outside of the sender deployment")
+		}
+	case isCall(op) && len(stack) > 1:
+		t.checkCodeAccess(op, addr, common.Address(stack[len(stack)-2].Bytes20()))
+	case isExtCode(op) && len(stack) > 0:
+		t.checkCodeAccess(op, addr, common.Address(stack[len(stack)-1].Bytes20()))
+	case op == vm.SLOAD || op == vm.SSTORE || op == vm.TLOAD || op == vm.TSTORE:
+		if len(stack) > 0 {
+			t.checkStorageAccess(addr, &stack[len(stack)-1])
+		}
+	case op == vm.KECCAK256 && len(stack) > 1:
+		t.recordPreimage(scope.MemoryData(), &stack[len(stack)-1], &stack[len(stack)-2])
+	}
+}
+
+// recordAccess records the account or storage slot of the state accessed by the opcode.
+func (t *erc7562Tracer) recordAccess(op vm.OpCode, addr common.Address, stack []uint256.Int) {
+	if len(stack) == 0 {
+		return
+	}
+	switch {
+	case op == vm.SLOAD || op == vm.SSTORE:
+		t.accesses.addSlot(addr, common.Hash(stack[len(stack)-1].Bytes32()))
+	case op == vm.BALANCE || isExtCode(op):
+		t.accesses.addAccount(common.Address(stack[len(stack)-1].Bytes20()))
+	}
+}
+
+// checkCodeAccess checks the access of the code of target by a call or EXTCODE* opcode.
+func (t *erc7562Tracer) checkCodeAccess(op vm.OpCode, addr common.Address, target common.Address) {
+	// [OP-041] the entry point has no code, but is called back by the entities, and
+	// the sender may be accessed before it is deployed
+	if target == core.AA_ENTRY_POINT || target == *t.aatx.Sender {
+		return
+	}
+	// [OP-062] only the stateless precompiles are allowed
+	if slices.Contains(vm.PrecompiledAddressesCancun, target) {
+		if target.Big().Cmp(big.NewInt(10)) >= 0 {
+			t.report("OP-062", addr, "access to disallowed precompile %v", target)
+		}
+		return
+	}
+	if t.env == nil || len(t.env.StateDB.GetCode(target)) > 0 {
+		return
+	}
+	if op == vm.EXTCODESIZE {
+		t.extCode = &target
+		return
+	}
+	t.report("OP-041", addr, "access to %v without code", target)
+}
+
+// checkStorageAccess checks the access of the given storage slot of the contract.
+func (t *erc7562Tracer) checkStorageAccess(addr common.Address, slot *uint256.Int) {
+	// [STO-010] the sender's storage is always allowed, and [OP-070] transient storage
+	// follows the same rules as storage
+	if addr == *t.aatx.Sender {
+		return
+	}
+	// [STO-021] storage associated with the sender is allowed in any contract
+	if slot.Cmp(new(uint256.Int).SetBytes(t.aatx.Sender.Bytes())) == 0 {
+		return
+	}
+	for _, base := range t.associated {
+		if slot.Cmp(base) >= 0 && new(uint256.Int).Sub(slot, base).CmpUint64(maxAssociatedSlotOffset) <= 0 {
+			return
+		}
+	}
+	t.report("STO-021", addr, "access to unassociated storage slot %#x", slot.Bytes32())
+}
+
+// recordPreimage records the hash of a KECCAK256 preimage starting with the sender
+// address, like the keys of solidity mappings, as the base of associated slots. The
+// memory is not expanded for the opcode yet, so the preimage may extend beyond it.
+func (t *erc7562Tracer) recordPreimage(memory []byte, offset, size *uint256.Int) {
+	if !size.IsUint64() || size.Uint64() < 32 || size.Uint64() > maxAssociatedPreimageSize || !offset.IsUint64() || offset.Uint64() >= uint64(len(memory)) {
+		return
+	}
+	preimage := make([]byte, size.Uint64())
+	copy(preimage, memory[offset.Uint64():])
+	if common.BytesToAddress(preimage[:32]) != *t.aatx.Sender || !isZero(preimage[:12]) {
+		return
+	}
+	t.associated = append(t.associated, new(uint256.Int).SetBytes(crypto.Keccak256(preimage)))
+}
+
+func isCall(op vm.OpCode) bool {
+	return op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL
+}
+
+func isExtCode(op vm.OpCode) bool {
+	return op == vm.EXTCODESIZE || op == vm.EXTCODECOPY || op == vm.EXTCODEHASH
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package rip7560pool
+
+import (
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// eventQueue holds the events raised while the pool lock is held until it is released.
+// Sending on a feed blocks until every subscriber received the event, so sending under
+// the lock would let a slow subscriber, like an RPC client, stall head resets, bundle
+// submissions and block building.
+type eventQueue struct {
+	status []core.Rip7560TxStatusEvent
+	txs    []*types.Transaction // Transactions made executable, announced in a single event
+}
+
+func (q *eventQueue) sendStatus(ev core.Rip7560TxStatusEvent) {
+	q.status = append(q.status, ev)
+}
+
+func (q *eventQueue) sendTxsStatus(txs []*types.Transaction, status core.Rip7560TxStatus, reason string) {
+	for _, tx := range txs {
+		q.sendStatus(core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: status, Reason: reason})
+	}
+}
+
+func (q *eventQueue) announce(txs []*types.Transaction) {
+	q.txs = append(q.txs, txs...)
+}
+
+// flush sends the queued events on the feeds.
+func (q *eventQueue) flush(statusFeed, txFeed *event.Feed) {
+	for _, ev := range q.status {
+		statusFeed.Send(ev)
+	}
+	if len(q.txs) > 0 {
+		txFeed.Send(core.NewTxsEvent{Txs: q.txs})
+	}
+}
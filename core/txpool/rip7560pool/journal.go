@@ -0,0 +1,114 @@
+package rip7560pool
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// errNoActiveJournal is returned if a bundle is attempted to be inserted into the
+// journal, but no such file is currently open.
+var errNoActiveJournal = errors.New("no active journal")
+
+// bundleJournal is a rotating log of the pushed bundles not yet included in a block,
+// allowing them to survive node restarts. Bundles are stored whole and in submission
+// order, so that their atomicity and ordering are retained.
+type bundleJournal struct {
+	path   string         // Filesystem path to store the bundles at
+	writer io.WriteCloser // Output stream to write new bundles into
+}
+
+// newBundleJournal creates a new bundle journal backed by the given file.
+func newBundleJournal(path string) *bundleJournal {
+	return &bundleJournal{
+		path: path,
+	}
+}
+
+// load parses a bundle journal dump from disk, returning the bundles in the order
+// they were journaled.
+func (journal *bundleJournal) load() ([]*types.ExternallyReceivedBundle, error) {
+	input, err := os.Open(journal.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Skip the parsing if the journal file doesn't exist at all
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var (
+		stream  = rlp.NewStream(input, 0)
+		bundles []*types.ExternallyReceivedBundle
+	)
+	for {
+		bundle := new(types.ExternallyReceivedBundle)
+		if err = stream.Decode(bundle); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return bundles, err
+		}
+		bundles = append(bundles, bundle)
+	}
+}
+
+// insert adds the specified bundle to the disk journal.
+func (journal *bundleJournal) insert(bundle *types.ExternallyReceivedBundle) error {
+	if journal.writer == nil {
+		return errNoActiveJournal
+	}
+	return rlp.Encode(journal.writer, bundle)
+}
+
+// rotate regenerates the bundle journal based on the pending bundles of the pool.
+func (journal *bundleJournal) rotate(bundles []*types.ExternallyReceivedBundle) error {
+	// Close the current journal (if any is open)
+	if journal.writer != nil {
+		if err := journal.writer.Close(); err != nil {
+			return err
+		}
+		journal.writer = nil
+	}
+	// Generate a new journal with the pending bundles
+	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, bundle := range bundles {
+		if err = rlp.Encode(replacement, bundle); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	replacement.Close()
+
+	// Replace the live journal with the newly generated one
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+	sink, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	journal.writer = sink
+
+	log.Debug("Regenerated RIP-7560 bundle journal", "bundles", len(bundles))
+	return nil
+}
+
+// close flushes the bundle journal contents to disk and closes the file.
+func (journal *bundleJournal) close() error {
+	var err error
+
+	if journal.writer != nil {
+		err = journal.writer.Close()
+		journal.writer = nil
+	}
+	return err
+}
@@ -0,0 +1,795 @@
+package rip7560pool
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// nativePriceBump is the minimum increase, in percent, of both the fee cap and the tip
+// of a transaction replacing a pending one.
+const nativePriceBump = 10
+
+// nativeBundlerId is the bundler id of the bundles the native pool proposes to the miner.
+const nativeBundlerId = "native"
+
+// nativeLane is an independent sequence of nonces of a sender: the account nonce for
+// the zero nonce key, or the sequence of an RIP-7712 nonce key. The transactions of a
+// lane are pending, and proposed to the miner, once all preceding nonces are pending
+// or included. They are queued otherwise.
+type nativeLane struct {
+	sender   common.Address
+	nonceKey common.Hash
+}
+
+func laneOf(aatx *types.Rip7560AccountAbstractionTx) nativeLane {
+	return nativeLane{sender: *aatx.Sender, nonceKey: common.BigToHash(aatx.NonceKey)}
+}
+
+// nativeTxSlot identifies the nonce slot a transaction of the native pool takes. No two
+// transactions can use the same slot, a transaction for a taken slot replaces the pooled
+// one if it bumps its fees by nativePriceBump percent.
+type nativeTxSlot struct {
+	nativeLane
+	nonce uint64
+}
+
+func slotOf(aatx *types.Rip7560AccountAbstractionTx) nativeTxSlot {
+	return nativeTxSlot{nativeLane: laneOf(aatx), nonce: aatx.Nonce}
+}
+
+// Rip7560NativePool is the transaction pool of the RIP-7560 AA transactions submitted
+// individually, either by users over RPC or by peers. Unlike the bundler pool, it runs
+// the validation phases of the transactions itself on admission, enforcing the ERC-7562
+// rules on them, and proposes all of its pending transactions to the miner as a single
+// bundle. The transactions of a sender are tracked in independent nonce lanes, so that
+// the lanes of its RIP-7712 nonce keys are promoted independently of each other.
+type Rip7560NativePool struct {
+	config      NativeConfig
+	chain       BlockChain
+	coinbase    common.Address
+	txFeed      event.Feed
+	statusFeed  event.Feed
+	currentHead atomic.Pointer[types.Header] // Current head of the blockchain
+
+	pending    []*types.Transaction                // Pending transactions in arrival order, by ascending nonce within a lane
+	queued     map[nativeLane][]*types.Transaction // Transactions waiting for the preceding nonces of their lane, by ascending nonce
+	all        map[common.Hash]*types.Transaction  // Pending and queued transactions
+	slots      map[nativeTxSlot]common.Hash        // Transaction taking each nonce slot
+	accesses   map[common.Hash]*validationAccesses // State accessed by the last validation of each transaction
+	reputation *reputation                         // Reputation of the paymasters and deployers
+	baseFee    *big.Int                            // Base fee of the next block, nil before London
+	gasTip     *big.Int                            // Minimum tip of the pending transactions
+
+	mu     sync.Mutex
+	events eventQueue // Events raised under the lock, sent once it is released
+
+	validate func(head *types.Header, tx *types.Transaction, prefix []*types.Transaction) (*validationAccesses, error) // Runs the validation phases
+}
+
+// NewNative creates a new pool for individually submitted RIP-7560 transactions.
+func NewNative(config NativeConfig, chain BlockChain, coinbase common.Address) *Rip7560NativePool {
+	pool := &Rip7560NativePool{
+		config:   config.sanitize(),
+		chain:    chain,
+		coinbase: coinbase,
+	}
+	pool.validate = pool.validateTx
+	return pool
+}
+
+// Filter accepts the individual RIP-7560 transactions.
+func (pool *Rip7560NativePool) Filter(tx *types.Transaction) bool {
+	return tx.Type() == types.Rip7560Type
+}
+
+func (pool *Rip7560NativePool) Init(_ uint64, head *types.Header, _ txpool.AddressReserver) error {
+	pool.queued = make(map[nativeLane][]*types.Transaction)
+	pool.all = make(map[common.Hash]*types.Transaction)
+	pool.slots = make(map[nativeTxSlot]common.Hash)
+	pool.accesses = make(map[common.Hash]*validationAccesses)
+	pool.reputation = newReputation(head.Number.Uint64())
+	pool.baseFee = pool.pendingBaseFee(head)
+	pool.gasTip = new(big.Int)
+	pool.currentHead.Store(head)
+	return nil
+}
+
+func (pool *Rip7560NativePool) Close() error {
+	return nil
+}
+
+// unlock releases the pool lock, then sends the events raised while it was held.
+func (pool *Rip7560NativePool) unlock() {
+	events := pool.events
+	pool.events = eventQueue{}
+	pool.mu.Unlock()
+	events.flush(&pool.statusFeed, &pool.txFeed)
+}
+
+// Reset removes the transactions included in the new head, crediting the reputation of
+// their entities, queues the pending transactions priced out by the new base fee,
+// revalidates the ones relying on the accounts the new head touched and promotes the
+// queued transactions whose preceding nonces got included or whose fees are covered.
+func (pool *Rip7560NativePool) Reset(oldHead, newHead *types.Header) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+		receipts := pool.chain.GetReceiptsByHash(block.Hash())
+		for i, tx := range block.Transactions() {
+			if pool.remove(tx.Hash()) == nil {
+				continue
+			}
+			for _, entity := range reputationEntities(tx.Rip7560TransactionData()) {
+				pool.reputation.markIncluded(entity)
+			}
+			ev := core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: core.Rip7560TxIncluded}
+			if i < len(receipts) {
+				ev.Receipt = receipts[i]
+			}
+			pool.events.sendStatus(ev)
+		}
+	}
+	pool.reputation.decay(newHead.Number.Uint64())
+	pool.currentHead.Store(newHead)
+	pool.baseFee = pool.pendingBaseFee(newHead)
+	pool.demoteUnderpriced()
+	pool.revalidate(oldHead, newHead)
+	pool.promoteAll(newHead)
+}
+
+// pendingBaseFee returns the base fee of the block following the head, or nil if the
+// block is before London.
+func (pool *Rip7560NativePool) pendingBaseFee(head *types.Header) *big.Int {
+	config := pool.chain.Config()
+	if !config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		return nil
+	}
+	return eip1559.CalcBaseFee(config, head, head.Time+1)
+}
+
+// affordable reports whether the fees of the transaction cover the base fee of the next
+// block and the minimum tip, for it to be pending.
+func (pool *Rip7560NativePool) affordable(aatx *types.Rip7560AccountAbstractionTx) bool {
+	if aatx.GasTipCap.Cmp(pool.gasTip) < 0 {
+		return false
+	}
+	return pool.baseFee == nil || aatx.GasFeeCap.Cmp(new(big.Int).Add(pool.baseFee, pool.gasTip)) >= 0
+}
+
+// demoteUnderpriced queues the pending transactions whose fees are no longer affordable,
+// along with the following ones of their lanes.
+func (pool *Rip7560NativePool) demoteUnderpriced() {
+	var demoted int
+	for _, tx := range slices.Clone(pool.pending) {
+		if aatx := tx.Rip7560TransactionData(); slices.Contains(pool.pending, tx) && !pool.affordable(aatx) {
+			demoted += pool.demote(laneOf(aatx), aatx.Nonce)
+		}
+	}
+	if demoted > 0 {
+		log.Debug("Demoted underpriced RIP-7560 transactions", "basefee", pool.baseFee, "tip", pool.gasTip, "count", demoted)
+	}
+}
+
+// promoteAll promotes the queued transactions of every lane that became executable,
+// announcing them.
+func (pool *Rip7560NativePool) promoteAll(head *types.Header) {
+	var promoted []*types.Transaction
+	for lane := range pool.queued {
+		base, err := pool.laneNonce(head, lane)
+		if err != nil {
+			log.Debug("Failed to read RIP-7560 lane nonce", "sender", lane.sender, "nonceKey", lane.nonceKey, "err", err)
+			continue
+		}
+		promoted = append(promoted, pool.promote(head, lane, base)...)
+	}
+	if len(promoted) > 0 {
+		pool.events.announce(promoted)
+	}
+}
+
+// revalidate reruns the validation phases of the pending transactions that may have
+// been invalidated by the new head, dropping the ones that no longer validate so they
+// are not handed to the miner. Only the transactions whose validation accessed state
+// changed by the new head are revalidated, or all of them if the changes are unknown,
+// on reorgs or if a head state is missing.
+func (pool *Rip7560NativePool) revalidate(oldHead, newHead *types.Header) {
+	var (
+		diff    = pool.headDiff(oldHead, newHead)
+		dropped int
+	)
+	for _, tx := range slices.Clone(pool.pending) {
+		// Transactions demoted along with a dropped one are revalidated on promotion
+		if pool.all[tx.Hash()] == nil || !slices.Contains(pool.pending, tx) {
+			continue
+		}
+		aatx := tx.Rip7560TransactionData()
+		if diff != nil && !diff.invalidates(revalidationAccounts(pool.chain.Config(), aatx), pool.accesses[tx.Hash()]) {
+			continue
+		}
+		accesses, err := pool.validate(newHead, tx, pool.lanePending(laneOf(aatx), aatx.Nonce))
+		if err != nil {
+			pool.drop(tx.Hash())
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("revalidation failed: %v", err)})
+			dropped++
+			continue
+		}
+		pool.accesses[tx.Hash()] = accesses
+	}
+	if dropped > 0 {
+		log.Debug("Dropped invalidated RIP-7560 transactions", "number", newHead.Number, "count", dropped)
+	}
+}
+
+// headDiff returns the changes from the old head to its child, the new head, or nil if
+// the changes are unknown.
+func (pool *Rip7560NativePool) headDiff(oldHead, newHead *types.Header) *headDiff {
+	if oldHead == nil || newHead.ParentHash != oldHead.Hash() {
+		return nil
+	}
+	oldState, err := pool.chain.StateAt(oldHead.Root)
+	if err != nil || oldState == nil {
+		return nil
+	}
+	newState, err := pool.chain.StateAt(newHead.Root)
+	if err != nil || newState == nil {
+		return nil
+	}
+	return newHeadDiff(oldState, newState)
+}
+
+// revalidationAccounts returns the accounts whose changes may invalidate the transaction:
+// its sender, paymaster and deployer, and the nonce manager keeping its RIP-7712 nonce.
+// Their storage is only watched for transactions without recorded accesses.
+func revalidationAccounts(config *params.ChainConfig, aatx *types.Rip7560AccountAbstractionTx) []common.Address {
+	accounts := append([]common.Address{*aatx.Sender}, reputationEntities(aatx)...)
+	if aatx.IsRip7712Nonce() {
+		accounts = append(accounts, core.Rip7712NonceManager(config))
+	}
+	return accounts
+}
+
+// SetGasTip updates the minimum tip of the pending transactions, moving the ones that
+// are no longer or are now affordable between the pending and queued transactions.
+func (pool *Rip7560NativePool) SetGasTip(tip *big.Int) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	pool.gasTip = new(big.Int).Set(tip)
+	pool.demoteUnderpriced()
+	pool.promoteAll(pool.currentHead.Load())
+	log.Info("Native RIP-7560 pool tip threshold updated", "tip", tip)
+}
+
+func (pool *Rip7560NativePool) Has(hash common.Hash) bool {
+	return pool.Get(hash) != nil
+}
+
+func (pool *Rip7560NativePool) Get(hash common.Hash) *types.Transaction {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.all[hash]
+}
+
+// Add validates the transactions against the current head and adds the valid ones to
+// the pending transactions, or queues the ones ahead of the nonces of their lane.
+func (pool *Rip7560NativePool) Add(txs []*types.Transaction, _ bool, _ bool) []error {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	var (
+		errs     = make([]error, len(txs))
+		added    = make([]*types.Transaction, 0, len(txs))
+		promoted []*types.Transaction
+		head     = pool.currentHead.Load()
+	)
+	for i, tx := range txs {
+		var executable []*types.Transaction
+		if executable, errs[i] = pool.add(head, tx); errs[i] != nil {
+			log.Trace("Rejected RIP-7560 transaction", "hash", tx.Hash(), "err", errs[i])
+			continue
+		}
+		added = append(added, tx)
+		promoted = append(promoted, executable...)
+	}
+	if len(added) > 0 {
+		pool.events.sendTxsStatus(added, core.Rip7560TxAccepted, "")
+	}
+	if len(promoted) > 0 {
+		pool.events.announce(promoted)
+	}
+	return errs
+}
+
+// add admits the transaction into the pool, returning the transactions it made pending:
+// itself and the queued ones of its lane it unblocked. A transaction ahead of the nonces
+// of its lane, or whose fees are not affordable, is queued without validation.
+func (pool *Rip7560NativePool) add(head *types.Header, tx *types.Transaction) ([]*types.Transaction, error) {
+	if tx.Type() != types.Rip7560Type {
+		return nil, fmt.Errorf("%w: type %d", types.ErrTxTypeNotSupported, tx.Type())
+	}
+	if !pool.chain.Config().IsRIP7560(head.Number, head.Time) {
+		return nil, fmt.Errorf("%w: type %d rejected, pool not yet in RIP-7560", types.ErrTxTypeNotSupported, tx.Type())
+	}
+	if pool.all[tx.Hash()] != nil {
+		return nil, txpool.ErrAlreadyKnown
+	}
+	aatx := tx.Rip7560TransactionData()
+	if err := aatx.SanityCheck(); err != nil {
+		return nil, err
+	}
+	if chainID := pool.chain.Config().ChainID; aatx.ChainID.Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("%w: have %v, want %v", types.ErrInvalidChainId, aatx.ChainID, chainID)
+	}
+	if gas, _ := aatx.TotalGasLimit(); gas > head.GasLimit {
+		return nil, fmt.Errorf("%w: tx gas limit %d, block gas limit %d", txpool.ErrGasLimit, gas, head.GasLimit)
+	}
+	slot := slotOf(aatx)
+	replaced := pool.all[pool.slots[slot]]
+	if replaced != nil && !bumpsFees(replaced, tx) {
+		return nil, fmt.Errorf("%w: %v needs a %d%% fee bump", txpool.ErrReplaceUnderpriced, replaced.Hash(), nativePriceBump)
+	}
+	victims, err := pool.evictions(tx, replaced)
+	if err != nil {
+		return nil, err
+	}
+	entities := reputationEntities(aatx)
+	if err := pool.checkReputation(entities); err != nil {
+		return nil, err
+	}
+	base, err := pool.laneNonce(head, slot.nativeLane)
+	if err != nil {
+		return nil, err
+	}
+	if aatx.Nonce < base {
+		return nil, fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooLow, aatx.Sender, aatx.Nonce, base)
+	}
+	prefix := pool.lanePending(slot.nativeLane, aatx.Nonce)
+	if aatx.Nonce > base+uint64(len(prefix)) || !pool.affordable(aatx) {
+		if replaced != nil {
+			pool.remove(replaced.Hash())
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: replaced.Hash(), Status: core.Rip7560TxReplaced, Reason: fmt.Sprintf("replaced by %v", tx.Hash())})
+		}
+		pool.evict(victims, tx)
+		pool.enqueue(tx)
+		return nil, nil
+	}
+	accesses, err := pool.validate(head, tx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	pool.evict(victims, tx)
+	if replaced != nil {
+		// The replacement keeps the arrival order of the replaced transaction
+		pool.pending[slices.Index(pool.pending, replaced)] = tx
+		delete(pool.all, replaced.Hash())
+		delete(pool.accesses, replaced.Hash())
+		pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: replaced.Hash(), Status: core.Rip7560TxReplaced, Reason: fmt.Sprintf("replaced by %v", tx.Hash())})
+	} else {
+		pool.pending = append(pool.pending, tx)
+	}
+	pool.all[tx.Hash()] = tx
+	pool.accesses[tx.Hash()] = accesses
+	pool.slots[slot] = tx.Hash()
+	for _, entity := range entities {
+		pool.reputation.markSeen(entity)
+	}
+	return append([]*types.Transaction{tx}, pool.promote(head, slot.nativeLane, base)...), nil
+}
+
+// laneNonce returns the next nonce of the lane in the state of the given head.
+func (pool *Rip7560NativePool) laneNonce(head *types.Header, lane nativeLane) (uint64, error) {
+	statedb, err := pool.chain.StateAt(head.Root)
+	if err != nil {
+		return 0, err
+	}
+	return core.ReadRip7560Nonce(pool.chain.Config(), pool.chain, head, statedb, lane.sender, lane.nonceKey.Big())
+}
+
+// lanePending returns the pending transactions of the lane preceding the given nonce.
+func (pool *Rip7560NativePool) lanePending(lane nativeLane, nonce uint64) []*types.Transaction {
+	var txs []*types.Transaction
+	for _, tx := range pool.pending {
+		if aatx := tx.Rip7560TransactionData(); laneOf(aatx) == lane && aatx.Nonce < nonce {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// enqueue queues the transaction until the preceding nonces of its lane are pending.
+func (pool *Rip7560NativePool) enqueue(tx *types.Transaction) {
+	aatx := tx.Rip7560TransactionData()
+	lane := laneOf(aatx)
+	queue := pool.queued[lane]
+	i, _ := slices.BinarySearchFunc(queue, aatx.Nonce, func(queued *types.Transaction, nonce uint64) int {
+		return cmp.Compare(queued.Rip7560TransactionData().Nonce, nonce)
+	})
+	pool.queued[lane] = slices.Insert(queue, i, tx)
+	pool.all[tx.Hash()] = tx
+	pool.slots[slotOf(aatx)] = tx.Hash()
+}
+
+// promote moves the queued transactions of the lane continuing its pending ones to the
+// pending transactions, as long as they are affordable and validate. The queued transactions preceding the
+// nonce of the lane in the head state are stale and dropped, and so is the first one
+// failing validation, keeping the ones after it queued.
+func (pool *Rip7560NativePool) promote(head *types.Header, lane nativeLane, base uint64) []*types.Transaction {
+	var promoted []*types.Transaction
+	for len(pool.queued[lane]) > 0 {
+		var (
+			tx     = pool.queued[lane][0]
+			aatx   = tx.Rip7560TransactionData()
+			prefix = pool.lanePending(lane, aatx.Nonce)
+		)
+		if aatx.Nonce < base {
+			pool.remove(tx.Hash())
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("%v: tx: %d state: %d", core.ErrNonceTooLow, aatx.Nonce, base)})
+			continue
+		}
+		if aatx.Nonce > base+uint64(len(prefix)) || !pool.affordable(aatx) {
+			break
+		}
+		accesses, err := pool.validate(head, tx, prefix)
+		if err != nil {
+			pool.remove(tx.Hash())
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("validation failed on promotion: %v", err)})
+			break
+		}
+		pool.queued[lane] = pool.queued[lane][1:]
+		pool.pending = append(pool.pending, tx)
+		pool.accesses[tx.Hash()] = accesses
+		for _, entity := range reputationEntities(aatx) {
+			pool.reputation.markSeen(entity)
+		}
+		promoted = append(promoted, tx)
+	}
+	if len(pool.queued[lane]) == 0 {
+		delete(pool.queued, lane)
+	}
+	return promoted
+}
+
+// bumpsFees reports whether the transaction raises both the fee cap and the tip of the
+// pending one by at least nativePriceBump percent.
+func bumpsFees(pending, tx *types.Transaction) bool {
+	threshold := func(fee *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(fee, big.NewInt(100+nativePriceBump))
+		return bumped.Div(bumped, big.NewInt(100))
+	}
+	// Both fees must also strictly increase, for the bump to apply to Wei-level fees
+	return pending.GasFeeCapCmp(tx) < 0 && pending.GasTipCapCmp(tx) < 0 &&
+		tx.GasFeeCapIntCmp(threshold(pending.GasFeeCap())) >= 0 && tx.GasTipCapIntCmp(threshold(pending.GasTipCap())) >= 0
+}
+
+// checkReputation returns an error if any of the entities is banned, or throttled with
+// all of its pool slots taken.
+func (pool *Rip7560NativePool) checkReputation(entities []common.Address) error {
+	for _, entity := range entities {
+		switch pool.reputation.status(entity) {
+		case reputationBanned:
+			return fmt.Errorf("%w: %v", ErrEntityBanned, entity)
+		case reputationThrottled:
+			if pending := len(pool.pendingWith(entity)); pending >= throttledEntityPoolTxs {
+				return fmt.Errorf("%w: %v has %d pending transactions", ErrEntityThrottled, entity, pending)
+			}
+		}
+	}
+	return nil
+}
+
+// pendingWith returns the pending transactions relying on the given paymaster or deployer.
+func (pool *Rip7560NativePool) pendingWith(entity common.Address) []*types.Transaction {
+	var txs []*types.Transaction
+	for _, tx := range pool.pending {
+		if slices.Contains(reputationEntities(tx.Rip7560TransactionData()), entity) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// validateTx runs the validation phases of an RIP-7560 transaction on top of the
+// given head state, enforcing the ERC-7562 rules on them, and returns the state they
+// accessed. The validation phases of the pending transactions preceding it in its lane
+// are run first, for its nonce to be current. Breaking the rules takes precedence over
+// failing validation, so the violations are reported either way.
+func (pool *Rip7560NativePool) validateTx(head *types.Header, tx *types.Transaction, prefix []*types.Transaction) (*validationAccesses, error) {
+	statedb, err := pool.chain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	for _, prev := range prefix {
+		gp := new(core.GasPool).AddGas(head.GasLimit)
+		if _, err := core.ApplyRip7560ValidationPhases(pool.chain.Config(), pool.chain, &pool.coinbase, gp, statedb, head, prev, vm.Config{}); err != nil {
+			return nil, fmt.Errorf("preceding transaction %v failed validation: %w", prev.Hash(), err)
+		}
+	}
+	var (
+		gp     = new(core.GasPool).AddGas(head.GasLimit)
+		tracer = newErc7562Tracer(tx.Rip7560TransactionData())
+	)
+	if cache := pool.validationCache(); cache != nil {
+		_, err = cache.Validate(pool.chain.Config(), pool.chain, &pool.coinbase, gp, statedb, head, tx, vm.Config{Tracer: tracer.hooks()})
+	} else {
+		_, err = core.ApplyRip7560ValidationPhases(pool.chain.Config(), pool.chain, &pool.coinbase, gp, statedb, head, tx, vm.Config{Tracer: tracer.hooks()})
+	}
+	if rulesErr := tracer.err(); rulesErr != nil {
+		return nil, rulesErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tracer.accesses, nil
+}
+
+// validationCache returns the cache of validations shared with block building, if the
+// chain keeps one.
+func (pool *Rip7560NativePool) validationCache() *core.Rip7560ValidationCache {
+	if chain, ok := pool.chain.(interface {
+		Rip7560ValidationCache() *core.Rip7560ValidationCache
+	}); ok {
+		return chain.Rip7560ValidationCache()
+	}
+	return nil
+}
+
+// remove deletes the transaction with the given hash from the pool, returning it if it
+// was pooled. The transactions following it in its lane are kept as they are.
+func (pool *Rip7560NativePool) remove(hash common.Hash) *types.Transaction {
+	tx := pool.all[hash]
+	if tx == nil {
+		return nil
+	}
+	aatx := tx.Rip7560TransactionData()
+	delete(pool.all, hash)
+	delete(pool.accesses, hash)
+	delete(pool.slots, slotOf(aatx))
+	if i := slices.Index(pool.pending, tx); i >= 0 {
+		pool.pending = slices.Delete(pool.pending, i, i+1)
+		return tx
+	}
+	lane := laneOf(aatx)
+	if i := slices.Index(pool.queued[lane], tx); i >= 0 {
+		pool.queued[lane] = slices.Delete(pool.queued[lane], i, i+1)
+	}
+	if len(pool.queued[lane]) == 0 {
+		delete(pool.queued, lane)
+	}
+	return tx
+}
+
+// drop deletes the transaction with the given hash from the pool, returning it if it
+// was pooled. The pending transactions following it in its lane can no longer be
+// executed, and are queued until its nonce is taken again.
+func (pool *Rip7560NativePool) drop(hash common.Hash) *types.Transaction {
+	tx := pool.remove(hash)
+	if tx == nil {
+		return nil
+	}
+	aatx := tx.Rip7560TransactionData()
+	pool.demote(laneOf(aatx), aatx.Nonce+1)
+	return tx
+}
+
+// demote queues the pending transactions of the lane from the given nonce on, returning
+// their number.
+func (pool *Rip7560NativePool) demote(lane nativeLane, nonce uint64) int {
+	var demoted int
+	for i := 0; i < len(pool.pending); i++ {
+		if aatx := pool.pending[i].Rip7560TransactionData(); laneOf(aatx) == lane && aatx.Nonce >= nonce {
+			tx := pool.pending[i]
+			pool.pending = slices.Delete(pool.pending, i, i+1)
+			delete(pool.accesses, tx.Hash())
+			pool.enqueue(tx)
+			demoted++
+			i--
+		}
+	}
+	return demoted
+}
+
+// Pending returns nothing, as the RIP-7560 transactions are proposed to the miner as a
+// bundle by PendingRip7560Bundle.
+func (pool *Rip7560NativePool) Pending(_ txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction {
+	return nil
+}
+
+func (pool *Rip7560NativePool) SubscribeTransactions(ch chan<- core.NewTxsEvent, _ bool) event.Subscription {
+	return pool.txFeed.Subscribe(ch)
+}
+
+// Nonce is only used from 'GetPoolNonce' which is not relevant for AA transactions.
+func (pool *Rip7560NativePool) Nonce(_ common.Address) uint64 {
+	return 0
+}
+
+// Stats returns the number of pending and queued transactions.
+func (pool *Rip7560NativePool) Stats() (int, int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return len(pool.pending), len(pool.all) - len(pool.pending)
+}
+
+func (pool *Rip7560NativePool) Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pending := make(map[common.Address][]*types.Transaction)
+	for _, tx := range pool.pending {
+		sender := *tx.Rip7560TransactionData().Sender
+		pending[sender] = append(pending[sender], tx)
+	}
+	queued := make(map[common.Address][]*types.Transaction)
+	for lane, txs := range pool.queued {
+		queued[lane.sender] = append(queued[lane.sender], txs...)
+	}
+	return pending, queued
+}
+
+func (pool *Rip7560NativePool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var pending []*types.Transaction
+	for _, tx := range pool.pending {
+		if *tx.Rip7560TransactionData().Sender == addr {
+			pending = append(pending, tx)
+		}
+	}
+	queued := []*types.Transaction{}
+	for lane, txs := range pool.queued {
+		if lane.sender == addr {
+			queued = append(queued, txs...)
+		}
+	}
+	return pending, queued
+}
+
+// Locals are not necessary for AA Pool
+func (pool *Rip7560NativePool) Locals() []common.Address {
+	return []common.Address{}
+}
+
+func (pool *Rip7560NativePool) Status(hash common.Hash) txpool.TxStatus {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all[hash]
+	switch {
+	case tx == nil:
+		return txpool.TxStatusUnknown
+	case slices.Contains(pool.pending, tx):
+		return txpool.TxStatusPending
+	default:
+		return txpool.TxStatusQueued
+	}
+}
+
+// SubmitRip7560Bundle is not relevant for the native AA sub pool, bundles are accepted
+// by the bundler pool.
+func (pool *Rip7560NativePool) SubmitRip7560Bundle(_ *types.ExternallyReceivedBundle) error {
+	return nil
+}
+
+func (pool *Rip7560NativePool) CheckRip7560Bundle(_ *types.ExternallyReceivedBundle) error {
+	return nil
+}
+
+func (pool *Rip7560NativePool) ExtendRip7560Bundle(_ common.Hash, _ *big.Int) error {
+	return nil
+}
+
+func (pool *Rip7560NativePool) CancelRip7560Bundle(_ common.Hash) (*types.BundleReceipt, error) {
+	return nil, nil
+}
+
+func (pool *Rip7560NativePool) GetRip7560BundleStatus(_ common.Hash) (*types.BundleReceipt, error) {
+	return nil, nil
+}
+
+// PendingRip7560Bundle proposes the pending transactions to the miner in arrival order,
+// as many as fit in the gas limit of the next block. A lane is cut at its first
+// transaction not fitting, as the following ones could not be executed without it.
+func (pool *Rip7560NativePool) PendingRip7560Bundle() (*types.ExternallyReceivedBundle, error) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	head := pool.currentHead.Load()
+	var (
+		txs     []*types.Transaction
+		gas     uint64
+		skipped = make(map[nativeLane]bool)
+	)
+	for _, tx := range pool.pending {
+		aatx := tx.Rip7560TransactionData()
+		if skipped[laneOf(aatx)] {
+			continue
+		}
+		txGas, err := aatx.TotalGasLimit()
+		if err != nil || gas+txGas > head.GasLimit {
+			skipped[laneOf(aatx)] = true
+			continue
+		}
+		gas += txGas
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	pool.events.sendTxsStatus(txs, core.Rip7560TxSelected, "")
+	return &types.ExternallyReceivedBundle{
+		BundlerId:     nativeBundlerId,
+		BundleHash:    ethapi.CalculateBundleHash(txs),
+		ValidForBlock: new(big.Int).Add(head.Number, common.Big1),
+		Transactions:  txs,
+	}, nil
+}
+
+// PendingRip7560Bundles proposes the single bundle of the pending transactions of the
+// pool to the miner.
+func (pool *Rip7560NativePool) PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error) {
+	bundle, err := pool.PendingRip7560Bundle()
+	if err != nil || bundle == nil {
+		return nil, err
+	}
+	return []*types.ExternallyReceivedBundle{bundle}, nil
+}
+
+// SubscribeRip7560TxStatus subscribes to lifecycle events of the RIP-7560 transactions in the pool.
+func (pool *Rip7560NativePool) SubscribeRip7560TxStatus(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	return pool.statusFeed.Subscribe(ch)
+}
+
+// ReportRip7560TxsDropped removes the transactions of the pool that failed validation
+// while building a block, queueing the ones following them in their lanes. The paymaster
+// or deployer failing it is banned, along with the other pending transactions relying
+// on it.
+func (pool *Rip7560NativePool) ReportRip7560TxsDropped(infos []*types.Rip7560TransactionDebugInfo) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	for _, info := range infos {
+		tx := pool.drop(info.TxHash)
+		if tx == nil {
+			continue
+		}
+		status := core.Rip7560TxDropped
+		if info.TimedOut {
+			status = core.Rip7560TxTimedOut
+		}
+		pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: info.TxHash, Status: status, Reason: fmt.Sprintf("validation failed during block building: %s", info.RevertData), DebugInfo: info})
+
+		entity := txEntity(tx.Rip7560TransactionData(), info.RevertEntityName)
+		if entity == nil || !slices.Contains(reputationEntities(tx.Rip7560TransactionData()), *entity) {
+			continue
+		}
+		pool.reputation.markCrashed(*entity)
+		log.Warn("Banned RIP-7560 entity after failing validation during block building", "entity", info.RevertEntityName, "address", *entity)
+		for _, pending := range pool.pendingWith(*entity) {
+			pool.drop(pending.Hash())
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: pending.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("%v: %s %v", ErrEntityBanned, info.RevertEntityName, *entity)})
+		}
+	}
+}
+
+// Rip7560InclusionStats are only tracked by the bundler pool.
+func (pool *Rip7560NativePool) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	return nil
+}
@@ -0,0 +1,156 @@
+package rip7560pool
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"slices"
+)
+
+// NativeConfig are the limits of the native RIP-7560 pool. Once a limit is reached, a
+// new transaction evicts the lowest paying ones counting against it, if it pays more.
+type NativeConfig struct {
+	MaxTxs           uint64 // Maximum number of pooled transactions, pending and queued
+	MaxSenderTxs     uint64 // Maximum number of pooled transactions of a sender, across its nonce lanes
+	MaxPaymasterTxs  uint64 // Maximum number of pooled transactions sponsored by a paymaster
+	MaxValidationGas uint64 // Maximum aggregate validation gas limit of the pooled transactions
+}
+
+// DefaultNativeConfig contains the default limits of the native pool.
+var DefaultNativeConfig = NativeConfig{
+	MaxTxs:           4096,
+	MaxSenderTxs:     64,
+	MaxPaymasterTxs:  1024,
+	MaxValidationGas: 1_000_000_000,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *NativeConfig) sanitize() NativeConfig {
+	conf := *config
+	if conf.MaxTxs == 0 {
+		log.Warn("Sanitizing invalid RIP-7560 pool max transactions", "provided", conf.MaxTxs, "updated", DefaultNativeConfig.MaxTxs)
+		conf.MaxTxs = DefaultNativeConfig.MaxTxs
+	}
+	if conf.MaxSenderTxs == 0 {
+		log.Warn("Sanitizing invalid RIP-7560 pool max sender transactions", "provided", conf.MaxSenderTxs, "updated", DefaultNativeConfig.MaxSenderTxs)
+		conf.MaxSenderTxs = DefaultNativeConfig.MaxSenderTxs
+	}
+	if conf.MaxPaymasterTxs == 0 {
+		log.Warn("Sanitizing invalid RIP-7560 pool max paymaster transactions", "provided", conf.MaxPaymasterTxs, "updated", DefaultNativeConfig.MaxPaymasterTxs)
+		conf.MaxPaymasterTxs = DefaultNativeConfig.MaxPaymasterTxs
+	}
+	if conf.MaxValidationGas == 0 {
+		log.Warn("Sanitizing invalid RIP-7560 pool max validation gas", "provided", conf.MaxValidationGas, "updated", DefaultNativeConfig.MaxValidationGas)
+		conf.MaxValidationGas = DefaultNativeConfig.MaxValidationGas
+	}
+	return conf
+}
+
+// nativeLimit is a cap on the transactions of the pool matching a filter, weighted
+// either by count or by gas.
+type nativeLimit struct {
+	name   string
+	max    uint64
+	match  func(aatx *types.Rip7560AccountAbstractionTx) bool
+	weight func(aatx *types.Rip7560AccountAbstractionTx) uint64
+}
+
+func countWeight(*types.Rip7560AccountAbstractionTx) uint64 { return 1 }
+
+func validationGas(aatx *types.Rip7560AccountAbstractionTx) uint64 {
+	return aatx.ValidationGasLimit + aatx.PaymasterValidationGasLimit
+}
+
+// limits returns the limits the transaction counts against.
+func (pool *Rip7560NativePool) limits(aatx *types.Rip7560AccountAbstractionTx) []nativeLimit {
+	matchAll := func(*types.Rip7560AccountAbstractionTx) bool { return true }
+	limits := []nativeLimit{
+		{name: "pool", max: pool.config.MaxTxs, match: matchAll, weight: countWeight},
+		{name: "validation gas", max: pool.config.MaxValidationGas, match: matchAll, weight: validationGas},
+		{name: "sender", max: pool.config.MaxSenderTxs, weight: countWeight, match: func(other *types.Rip7560AccountAbstractionTx) bool {
+			return *other.Sender == *aatx.Sender
+		}},
+	}
+	if aatx.Paymaster != nil {
+		limits = append(limits, nativeLimit{name: "paymaster", max: pool.config.MaxPaymasterTxs, weight: countWeight, match: func(other *types.Rip7560AccountAbstractionTx) bool {
+			return other.Paymaster != nil && *other.Paymaster == *aatx.Paymaster
+		}})
+	}
+	return limits
+}
+
+// evictions returns the transactions to evict for the new one to fit in the limits of
+// the pool, replacing the given one if not nil. The lowest paying transactions are
+// evicted first, as long as they pay less than the new one; the transactions of its own
+// nonce lane are never evicted.
+func (pool *Rip7560NativePool) evictions(tx, replaced *types.Transaction) ([]*types.Transaction, error) {
+	var (
+		aatx    = tx.Rip7560TransactionData()
+		lane    = laneOf(aatx)
+		evicted = make(map[common.Hash]bool)
+		victims []*types.Transaction
+	)
+	for _, limit := range pool.limits(aatx) {
+		var (
+			used       uint64
+			candidates []*types.Transaction
+		)
+		for hash, pooled := range pool.all {
+			if pooled == replaced || evicted[hash] || !limit.match(pooled.Rip7560TransactionData()) {
+				continue
+			}
+			used += limit.weight(pooled.Rip7560TransactionData())
+			if laneOf(pooled.Rip7560TransactionData()) != lane {
+				candidates = append(candidates, pooled)
+			}
+		}
+		need := limit.weight(aatx)
+		if used+need <= limit.max {
+			continue
+		}
+		slices.SortFunc(candidates, func(a, b *types.Transaction) int {
+			if c := a.EffectiveGasTipCmp(b, pool.baseFee); c != 0 {
+				return c
+			}
+			// Evict the latest nonces first, to keep the lanes executable
+			return -cmpNonce(a, b)
+		})
+		for _, candidate := range candidates {
+			if used+need <= limit.max || candidate.EffectiveGasTipCmp(tx, pool.baseFee) >= 0 {
+				break
+			}
+			used -= limit.weight(candidate.Rip7560TransactionData())
+			evicted[candidate.Hash()] = true
+			victims = append(victims, candidate)
+		}
+		if used+need > limit.max {
+			return nil, fmt.Errorf("%w: %s limit of %d reached", txpool.ErrUnderpriced, limit.name, limit.max)
+		}
+	}
+	return victims, nil
+}
+
+// evict drops the transactions to make room for the new one, queueing the following
+// ones of their lanes.
+func (pool *Rip7560NativePool) evict(victims []*types.Transaction, tx *types.Transaction) {
+	for _, victim := range victims {
+		if pool.drop(victim.Hash()) != nil {
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: victim.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("evicted by better paying %v", tx.Hash())})
+		}
+	}
+}
+
+func cmpNonce(a, b *types.Transaction) int {
+	na, nb := a.Rip7560TransactionData().Nonce, b.Rip7560TransactionData().Nonce
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package rip7560pool
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"math/big"
+)
+
+// maxReorgDepth is the deepest reorg whose abandoned RIP-7560 transactions are re-injected.
+const maxReorgDepth = 64
+
+// reorgedBlocks collects the RIP-7560 transactions of the blocks abandoned when moving
+// from oldHead to newHead that were not included in the new chain, along with the
+// hashes of the abandoned blocks. It returns nil if the heads are not a reorg or if
+// the reorg is too deep or cannot be traced.
+func (pool *Rip7560BundlerPool) reorgedBlocks(oldHead, newHead *types.Header) (types.Transactions, map[common.Hash]struct{}) {
+	if oldHead == nil || oldHead.Hash() == newHead.ParentHash || oldHead.Hash() == newHead.Hash() {
+		return nil, nil
+	}
+	oldNum, newNum := oldHead.Number.Uint64(), newHead.Number.Uint64()
+	if max(oldNum, newNum)-min(oldNum, newNum) > maxReorgDepth {
+		log.Debug("Skipping deep RIP-7560 transaction reorg", "old", oldNum, "new", newNum)
+		return nil, nil
+	}
+	var (
+		rem = pool.chain.GetBlock(oldHead.Hash(), oldNum)
+		add = pool.chain.GetBlock(newHead.Hash(), newNum)
+
+		discarded, included types.Transactions
+		abandoned           = make(map[common.Hash]struct{})
+	)
+	if rem == nil || add == nil {
+		log.Debug("Skipping RIP-7560 transaction reorg with missing head", "old", oldHead.Hash(), "new", newHead.Hash())
+		return nil, nil
+	}
+	dropRem := func() bool {
+		discarded = append(discarded, rem.Transactions()...)
+		abandoned[rem.Hash()] = struct{}{}
+		if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
+			log.Error("Unrooted old chain seen by RIP-7560 pool", "block", oldHead.Number, "hash", oldHead.Hash())
+			return false
+		}
+		return true
+	}
+	keepAdd := func() bool {
+		included = append(included, add.Transactions()...)
+		if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
+			log.Error("Unrooted new chain seen by RIP-7560 pool", "block", newHead.Number, "hash", newHead.Hash())
+			return false
+		}
+		return true
+	}
+	for rem.NumberU64() > add.NumberU64() {
+		if !dropRem() {
+			return nil, nil
+		}
+	}
+	for add.NumberU64() > rem.NumberU64() {
+		if !keepAdd() {
+			return nil, nil
+		}
+	}
+	for rem.Hash() != add.Hash() {
+		if !dropRem() || !keepAdd() {
+			return nil, nil
+		}
+	}
+	lost := make(types.Transactions, 0, len(discarded))
+	for _, tx := range types.TxDifference(discarded, included) {
+		if tx.Type() == types.Rip7560Type {
+			lost = append(lost, tx)
+		}
+	}
+	return lost, abandoned
+}
+
+// reinjectReorged puts the RIP-7560 transactions lost in a reorg back into the pool
+// as bundles valid for the block following newHead. The bundles included in the
+// abandoned blocks are no longer reported as included, and their transactions are
+// re-injected under the same bundle hash. Transactions that are no longer valid
+// against the new head are dropped.
+func (pool *Rip7560BundlerPool) reinjectReorged(lost types.Transactions, abandoned map[common.Hash]struct{}, newHead *types.Header) {
+	if len(lost) == 0 && len(abandoned) == 0 {
+		return
+	}
+	// Forget the bundles included in the abandoned blocks, remembering which
+	// bundle each lost transaction belonged to
+	bundleOf := make(map[common.Hash]common.Hash)
+	for hash, receipt := range pool.includedBundles {
+		if _, ok := abandoned[receipt.BlockHash]; !ok {
+			continue
+		}
+		delete(pool.includedBundles, hash)
+		for _, txReceipt := range receipt.TransactionReceipts {
+			bundleOf[txReceipt.TxHash] = hash
+		}
+	}
+	if len(lost) == 0 {
+		return
+	}
+	statedb, err := pool.chain.StateAt(newHead.Root)
+	if err != nil {
+		log.Error("Failed to retrieve state to re-validate reorged RIP-7560 transactions", "err", err)
+		pool.events.sendTxsStatus(lost, core.Rip7560TxDropped, "failed to re-validate after chain reorganization")
+		return
+	}
+	var (
+		bundles   = make(map[common.Hash]*types.ExternallyReceivedBundle)
+		order     []common.Hash
+		unbundled types.Transactions
+		nextBlock = new(big.Int).Add(newHead.Number, common.Big1)
+	)
+	for _, tx := range lost {
+		if err := pool.validate(statedb, newHead, tx); err != nil {
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: tx.Hash(), Status: core.Rip7560TxDropped, Reason: fmt.Sprintf("no longer valid after chain reorganization: %v", err)})
+			continue
+		}
+		hash, ok := bundleOf[tx.Hash()]
+		if !ok {
+			unbundled = append(unbundled, tx)
+			continue
+		}
+		if bundles[hash] == nil {
+			bundles[hash] = &types.ExternallyReceivedBundle{BundleHash: hash, ValidForBlock: nextBlock}
+			order = append(order, hash)
+		}
+		bundles[hash].Transactions = append(bundles[hash].Transactions, tx)
+	}
+	if len(unbundled) > 0 {
+		hash := ethapi.CalculateBundleHash(unbundled)
+		bundles[hash] = &types.ExternallyReceivedBundle{BundleHash: hash, ValidForBlock: nextBlock, Transactions: unbundled}
+		order = append(order, hash)
+	}
+	for _, hash := range order {
+		bundle := bundles[hash]
+		log.Debug("Re-injecting reorged RIP-7560 bundle", "hash", hash, "txs", len(bundle.Transactions), "validForBlock", nextBlock)
+		pool.pendingBundles = append(pool.pendingBundles, bundle)
+		pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxRevalidated, "")
+		pool.events.announce(bundle.Transactions)
+	}
+}
+
+// validateTx runs the validation phases of an RIP-7560 transaction on top of the
+// given head state.
+func (pool *Rip7560BundlerPool) validateTx(statedb *state.StateDB, head *types.Header, tx *types.Transaction) error {
+	gp := new(core.GasPool).AddGas(head.GasLimit)
+	_, err := core.ApplyRip7560ValidationPhases(pool.chain.Config(), pool.chain, &pool.coinbase, gp, statedb, head, tx, vm.Config{})
+	return err
+}
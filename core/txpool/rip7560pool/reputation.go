@@ -0,0 +1,191 @@
+package rip7560pool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// validationTimeoutBanBlocks is the number of blocks an entity is banned from the pool
+// for, after its validation frame ran past the time limit of the block builder.
+const validationTimeoutBanBlocks = 64
+
+// ErrEntityBanned is returned if a bundle contains a transaction relying on an account,
+// paymaster or deployer that is banned from the pool.
+var ErrEntityBanned = errors.New("entity is banned")
+
+// ErrEntityThrottled is returned if a transaction relies on a paymaster or deployer that
+// is throttled and already has as many transactions pending as it is allowed to.
+var ErrEntityThrottled = errors.New("entity is throttled")
+
+const (
+	// minInclusionRateDenominator is the ratio of the transactions seen by the pool using
+	// an entity over the ones included in the chain the entity is expected to stay above.
+	minInclusionRateDenominator = 10
+
+	// throttlingSlack and banSlack are the number of expected inclusions an entity can
+	// miss before being throttled, and banned.
+	throttlingSlack = 10
+	banSlack        = 50
+
+	// throttledEntityPoolTxs is the number of pending transactions a throttled entity
+	// can have in the pool.
+	throttledEntityPoolTxs = 4
+
+	// reputationDecayBlocks is the number of blocks after which the counters of all the
+	// entities decay by reputationDecayRate/24, letting entities recover over time.
+	reputationDecayBlocks = 300
+	reputationDecayRate   = 23
+
+	// crashedEntitySeen is the number of seen transactions an entity is charged with for
+	// making a pooled transaction fail during block building, banning it.
+	crashedEntitySeen = 10000
+)
+
+// reputationStatus is the standing of an entity in the pool.
+type reputationStatus int
+
+const (
+	reputationOk reputationStatus = iota
+	reputationThrottled
+	reputationBanned
+)
+
+// entityReputation counts the transactions relying on an entity that were accepted by
+// the pool, and the ones of them that were included in the chain.
+type entityReputation struct {
+	seen     uint64
+	included uint64
+}
+
+// reputation tracks how reliably the paymasters and deployers of the pooled transactions
+// get them included, mirroring the ERC-7562 reputation of ERC-4337 bundlers. Entities
+// whose transactions keep getting invalidated are throttled, and eventually banned.
+type reputation struct {
+	entries   map[common.Address]*entityReputation
+	lastDecay uint64
+}
+
+func newReputation(head uint64) *reputation {
+	return &reputation{entries: make(map[common.Address]*entityReputation), lastDecay: head}
+}
+
+func (r *reputation) entry(entity common.Address) *entityReputation {
+	e := r.entries[entity]
+	if e == nil {
+		e = new(entityReputation)
+		r.entries[entity] = e
+	}
+	return e
+}
+
+// markSeen records a transaction relying on the entity being accepted by the pool.
+func (r *reputation) markSeen(entity common.Address) {
+	r.entry(entity).seen++
+}
+
+// markIncluded records a transaction relying on the entity being included in the chain.
+func (r *reputation) markIncluded(entity common.Address) {
+	r.entry(entity).included++
+}
+
+// markCrashed bans the entity for making a pooled transaction fail during block building.
+func (r *reputation) markCrashed(entity common.Address) {
+	e := r.entry(entity)
+	e.seen, e.included = crashedEntitySeen, 0
+}
+
+// status returns the standing of the entity.
+func (r *reputation) status(entity common.Address) reputationStatus {
+	e := r.entries[entity]
+	if e == nil {
+		return reputationOk
+	}
+	maxSeen := e.seen / minInclusionRateDenominator
+	switch {
+	case maxSeen <= e.included+throttlingSlack:
+		return reputationOk
+	case maxSeen <= e.included+banSlack:
+		return reputationThrottled
+	default:
+		return reputationBanned
+	}
+}
+
+// decay lowers the counters of all the entities for every reputationDecayBlocks blocks
+// passed until the given head, forgetting the entities with nothing left.
+func (r *reputation) decay(head uint64) {
+	for ; r.lastDecay+reputationDecayBlocks <= head; r.lastDecay += reputationDecayBlocks {
+		for entity, e := range r.entries {
+			e.seen = e.seen * reputationDecayRate / 24
+			e.included = e.included * reputationDecayRate / 24
+			if e.seen == 0 && e.included == 0 {
+				delete(r.entries, entity)
+			}
+		}
+	}
+}
+
+// reputationEntities returns the paymaster and deployer of the transaction, the entities
+// whose reputation is tracked.
+func reputationEntities(aatx *types.Rip7560AccountAbstractionTx) []common.Address {
+	var entities []common.Address
+	if aatx.Paymaster != nil {
+		entities = append(entities, *aatx.Paymaster)
+	}
+	if aatx.Deployer != nil {
+		entities = append(entities, *aatx.Deployer)
+	}
+	return entities
+}
+
+// bannedEntities maps the entities banned from the pool to the last block they are
+// banned in.
+type bannedEntities map[common.Address]uint64
+
+// ban bans the entity up to and including the given block, extending any running ban.
+func (b bannedEntities) ban(entity common.Address, until uint64) {
+	if b[entity] < until {
+		b[entity] = until
+	}
+}
+
+// prune lifts the bans that are over once the given block is the head.
+func (b bannedEntities) prune(head uint64) {
+	for entity, until := range b {
+		if until <= head {
+			delete(b, entity)
+		}
+	}
+}
+
+// check returns an error if any entity the transaction relies on is banned.
+func (b bannedEntities) check(tx *types.Transaction) error {
+	aatx := tx.Rip7560TransactionData()
+	if aatx == nil {
+		return nil
+	}
+	for _, name := range []string{"account", "paymaster", "deployer"} {
+		if entity := txEntity(aatx, name); entity != nil {
+			if until, banned := b[*entity]; banned {
+				return fmt.Errorf("%w: %s %v of transaction %x until block %d", ErrEntityBanned, name, *entity, tx.Hash(), until)
+			}
+		}
+	}
+	return nil
+}
+
+// txEntity returns the address of the entity with the given name as reported in the
+// validation debug info, or nil if the transaction does not use such an entity.
+func txEntity(aatx *types.Rip7560AccountAbstractionTx, name string) *common.Address {
+	switch name {
+	case "account":
+		return aatx.Sender
+	case "paymaster":
+		return aatx.Paymaster
+	case "deployer":
+		return aatx.Deployer
+	}
+	return nil
+}
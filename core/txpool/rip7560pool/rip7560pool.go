@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -16,62 +17,200 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"math/big"
 	"net/http"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// maxBundleValidityWindow is the furthest ahead of the current head the validity of a
+// pending bundle can be extended to.
+const maxBundleValidityWindow = 64
+
+// ErrBlockGasReserved is returned if a bundle does not fit in the gas of its target
+// block left unreserved by the bundles already accepted for it.
+var ErrBlockGasReserved = errors.New("insufficient unreserved block gas")
+
+// ErrInvalidBundleGas is returned if the gas limits of the transactions of a bundle
+// cannot be summed up.
+var ErrInvalidBundleGas = errors.New("invalid bundle gas")
+
+// ErrBundlerQuota is returned if a bundle does not fit in the pending bundles or gas
+// the pool allows for its bundler.
+var ErrBundlerQuota = errors.New("bundler quota exceeded")
+
+// ErrInvalidBundleWindow is returned if the inclusion window of a bundle is empty, over
+// already, or starts or ends too far ahead of the current head.
+var ErrInvalidBundleWindow = errors.New("invalid bundle inclusion window")
+
+// BlockChain defines the minimal set of methods needed to back an RIP-7560 pool with
+// a chain. On top of what the legacy pool needs, the chain context is required to
+// re-validate transactions against a new head.
+type BlockChain interface {
+	legacypool.BlockChain
+	core.ChainContext
+}
+
 type Config struct {
 	MaxBundleSize *uint64
 	MaxBundleGas  *uint64
 	PullUrls      []string
+	Journal       string // Journal of pushed bundles to survive node restarts
+
+	MaxBundlerBundles uint64 // Maximum number of pending bundles of a single bundler (0 = unlimited)
+	MaxBundlerGas     uint64 // Maximum aggregate gas limit of the pending bundles of a single bundler (0 = unlimited)
 }
 
 // Rip7560BundlerPool is the transaction pool dedicated to RIP-7560 AA transactions.
 // This implementation relies on an external bundler process to perform most of the hard work.
 type Rip7560BundlerPool struct {
 	config      Config
-	chain       legacypool.BlockChain
+	chain       BlockChain
 	txFeed      event.Feed
+	statusFeed  event.Feed
 	currentHead atomic.Pointer[types.Header] // Current head of the blockchain
 
 	pendingBundles  []*types.ExternallyReceivedBundle
 	includedBundles map[common.Hash]*types.BundleReceipt
+	droppedBundles  map[common.Hash]*types.BundleReceipt // Bundles expired or cancelled before their inclusion
+	selectedBundles map[common.Hash]struct{}             // Bundles selected for the block being built on the current head
+	inclusionStats  *inclusionStats
+	journal         *bundleJournal    // Journal of pending bundles to back up to disk
+	reservedGas     map[uint64]uint64 // Aggregate gas limit of the pending bundles targeting each block
+	banned          bannedEntities    // Entities whose transactions are not accepted for a while
 
-	mu sync.Mutex
+	mu     sync.Mutex
+	events eventQueue // Events raised under the lock, sent once it is released
 
 	coinbase common.Address
+
+	validate func(statedb *state.StateDB, head *types.Header, tx *types.Transaction) error // Re-validates reorged transactions
 }
 
 func (pool *Rip7560BundlerPool) Init(_ uint64, head *types.Header, _ txpool.AddressReserver) error {
 	pool.pendingBundles = make([]*types.ExternallyReceivedBundle, 0)
 	pool.includedBundles = make(map[common.Hash]*types.BundleReceipt)
+	pool.droppedBundles = make(map[common.Hash]*types.BundleReceipt)
+	pool.selectedBundles = make(map[common.Hash]struct{})
+	pool.inclusionStats = newInclusionStats()
+	pool.banned = make(bannedEntities)
 	pool.currentHead.Store(head)
+
+	// Restore the pushed bundles that are still valid from the journal
+	if pool.config.Journal != "" {
+		pool.journal = newBundleJournal(pool.config.Journal)
+
+		bundles, err := pool.journal.load()
+		if err != nil {
+			log.Warn("Failed to load RIP-7560 bundle journal", "err", err)
+		}
+		nextBlock := new(big.Int).Add(head.Number, common.Big1)
+		for _, bundle := range bundles {
+			if bundle.LastValidBlock().Cmp(nextBlock) < 0 {
+				pool.expire(bundle)
+				continue
+			}
+			pool.pendingBundles = append(pool.pendingBundles, bundle)
+			pool.inclusionStats.markSeen(bundle, time.Now())
+		}
+		log.Info("Loaded RIP-7560 bundle journal", "bundles", len(bundles), "expired", len(bundles)-len(pool.pendingBundles))
+
+		if err := pool.journal.rotate(pool.pendingBundles); err != nil {
+			log.Warn("Failed to rotate RIP-7560 bundle journal", "err", err)
+		}
+	}
+	pool.resetReservations(head)
 	return nil
 }
 
 func (pool *Rip7560BundlerPool) Close() error {
+	if pool.journal != nil {
+		return pool.journal.close()
+	}
 	return nil
 }
 
+// unlock releases the pool lock, then sends the events raised while it was held.
+func (pool *Rip7560BundlerPool) unlock() {
+	events := pool.events
+	pool.events = eventQueue{}
+	pool.mu.Unlock()
+	events.flush(&pool.statusFeed, &pool.txFeed)
+}
+
 func (pool *Rip7560BundlerPool) Reset(oldHead, newHead *types.Header) {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
+
+	lost, abandoned := pool.reorgedBlocks(oldHead, newHead)
+	pool.banned.prune(newHead.Number.Uint64())
 
+	now := time.Now()
 	newIncludedBundles := pool.gatherIncludedBundlesStats(newHead)
 	for _, included := range newIncludedBundles {
 		pool.includedBundles[included.BundleHash] = included
+		for _, receipt := range included.TransactionReceipts {
+			pool.inclusionStats.markIncluded(receipt.TxHash, now)
+			pool.events.sendStatus(core.Rip7560TxStatusEvent{TxHash: receipt.TxHash, Status: core.Rip7560TxIncluded, Receipt: receipt})
+		}
 	}
 
 	pendingBundles := make([]*types.ExternallyReceivedBundle, 0, len(pool.pendingBundles))
 	for _, bundle := range pool.pendingBundles {
 		nextBlock := big.NewInt(0).Add(newHead.Number, big.NewInt(1))
-		if bundle.ValidForBlock.Cmp(nextBlock) == 0 {
+		if included, ok := newIncludedBundles[bundle.BundleHash]; ok {
+			if len(included.DroppedTransactions) > 0 {
+				dropped := make(types.Transactions, 0, len(included.DroppedTransactions))
+				for _, tx := range bundle.Transactions {
+					if slices.Contains(included.DroppedTransactions, tx.Hash()) {
+						dropped = append(dropped, tx)
+					}
+				}
+				pool.inclusionStats.forget(dropped)
+				pool.events.sendTxsStatus(dropped, core.Rip7560TxDropped, fmt.Sprintf("left out of the block including bundle %v partially", bundle.BundleHash))
+			}
+			continue
+		}
+		if err := pool.checkBanned(bundle); err != nil {
+			pool.inclusionStats.forget(bundle.Transactions)
+			pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxDropped, err.Error())
+			continue
+		}
+		// Bundles waiting for the start of their inclusion window remain pending
+		if bundle.LastValidBlock().Cmp(nextBlock) >= 0 {
 			pendingBundles = append(pendingBundles, bundle)
+			pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxRevalidated, "")
+		} else {
+			pool.inclusionStats.forget(bundle.Transactions)
+			pool.expire(bundle)
+			pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxExpired, fmt.Sprintf("bundle was only valid until block %v", bundle.LastValidBlock()))
 		}
 	}
 	pool.pendingBundles = pendingBundles
+	pool.selectedBundles = make(map[common.Hash]struct{})
+	pool.reinjectReorged(lost, abandoned, newHead)
 	pool.currentHead.Store(newHead)
+	pool.resetReservations(newHead)
+
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.pendingBundles); err != nil {
+			log.Warn("Failed to rotate RIP-7560 bundle journal", "err", err)
+		}
+	}
+}
+
+// expire records the bundle as not included by the last block it was valid for.
+func (pool *Rip7560BundlerPool) expire(bundle *types.ExternallyReceivedBundle) {
+	pool.droppedBundles[bundle.BundleHash] = newDroppedBundleReceipt(bundle, types.BundleStatusExpired)
+}
+
+func newDroppedBundleReceipt(bundle *types.ExternallyReceivedBundle, status uint64) *types.BundleReceipt {
+	return &types.BundleReceipt{
+		BundleHash:  bundle.BundleHash,
+		Count:       uint64(len(bundle.Transactions)),
+		Status:      status,
+		BlockNumber: bundle.LastValidBlock().Uint64(),
+	}
 }
 
 // For simplicity, this function assumes 'Reset' called for each new block sequentially.
@@ -89,36 +228,32 @@ func (pool *Rip7560BundlerPool) gatherIncludedBundlesStats(newHead *types.Header
 	block := add.Transactions()
 
 	receipts := pool.chain.GetReceiptsByHash(add.Hash())
-	// match transactions in block to bundle ?
-
+	inBlock := make(map[common.Hash]struct{}, len(block))
+	for _, tx := range block {
+		inBlock[tx.Hash()] = struct{}{}
+	}
 	includedBundles := make(map[common.Hash]*types.BundleReceipt)
 
-	// 'pendingBundles' length is expected to be single digits, probably a single bundle in most cases
+	// 'pendingBundles' length is expected to be single digits, probably a single bundle in most cases.
+	// A bundle is included partially if the block builder left some of its transactions out,
+	// such as the suffix following the first invalid one in partial inclusion mode.
 	for _, bundle := range pool.pendingBundles {
-		if len(block) < len(bundle.Transactions) {
-			// this bundle does not even fit this block
+		var transactions, dropped types.Transactions
+		for _, tx := range bundle.Transactions {
+			if _, ok := inBlock[tx.Hash()]; ok {
+				transactions = append(transactions, tx)
+			} else {
+				dropped = append(dropped, tx)
+			}
+		}
+		if len(transactions) == 0 {
 			continue
 		}
-		for i := 0; i < len(block); i++ {
-			transactions := make(types.Transactions, 0)
-			for j := 0; j < len(bundle.Transactions); j++ {
-				blockTx := block[i]
-				bundleTx := bundle.Transactions[j]
-				if bundleTx.Hash().Cmp(blockTx.Hash()) == 0 {
-					// tx hash has matched
-					transactions = append(transactions, blockTx)
-					if j == len(bundle.Transactions)-1 {
-						// FOUND BUNDLE IN BLOCK
-						receipt := createBundleReceipt(add, bundle.BundleHash, transactions, receipts)
-						includedBundles[bundle.BundleHash] = receipt
-					} else {
-						// let's see if next tx in bundle matches
-						i++
-					}
-				}
-			}
+		receipt := createBundleReceipt(add, bundle.BundleHash, transactions, receipts)
+		for _, tx := range dropped {
+			receipt.DroppedTransactions = append(receipt.DroppedTransactions, tx.Hash())
 		}
-
+		includedBundles[bundle.BundleHash] = receipt
 	}
 	return includedBundles
 }
@@ -150,7 +285,7 @@ OuterLoop:
 	return &types.BundleReceipt{
 		BundleHash:          BundleHash,
 		Count:               uint64(len(transactions)),
-		Status:              0,
+		Status:              types.BundleStatusIncluded,
 		BlockNumber:         block.NumberU64(),
 		BlockHash:           block.Hash(),
 		TransactionReceipts: receipts,
@@ -164,11 +299,7 @@ OuterLoop:
 func (pool *Rip7560BundlerPool) SetGasTip(_ *big.Int) {}
 
 func (pool *Rip7560BundlerPool) Has(hash common.Hash) bool {
-	pool.mu.Lock()
-	defer pool.mu.Unlock()
-
-	tx := pool.Get(hash)
-	return tx != nil
+	return pool.Get(hash) != nil
 }
 
 func (pool *Rip7560BundlerPool) Get(hash common.Hash) *types.Transaction {
@@ -195,13 +326,124 @@ func (pool *Rip7560BundlerPool) Pending(_ txpool.PendingFilter) map[common.Addre
 
 func (pool *Rip7560BundlerPool) PendingRip7560Bundle() (*types.ExternallyReceivedBundle, error) {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
 	bundle := pool.selectExternalBundle()
+	if bundle == nil {
+		var err error
+		if bundle, err = pool.fetchBundleFromBundler(); err != nil {
+			return nil, err
+		}
+	}
 	if bundle != nil {
-		return bundle, nil
+		pool.selectedBundles[bundle.BundleHash] = struct{}{}
+		pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxSelected, "")
+	}
+	return bundle, nil
+}
+
+// PendingRip7560Bundles proposes all pushed bundles valid for the next block to the
+// miner, in submission order, or a bundle fetched from the bundlers if there are none.
+// The miner merges the ones not conflicting with each other, the highest paying first.
+func (pool *Rip7560BundlerPool) PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	var (
+		nextBlock = new(big.Int).Add(pool.currentHead.Load().Number, common.Big1)
+		bundles   []*types.ExternallyReceivedBundle
+	)
+	for _, bundle := range pool.pendingBundles {
+		if bundle.IsValidFor(nextBlock) {
+			bundles = append(bundles, bundle)
+		}
+	}
+	if len(bundles) == 0 {
+		bundle, err := pool.fetchBundleFromBundler()
+		if err != nil || bundle == nil {
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	for _, bundle := range bundles {
+		pool.selectedBundles[bundle.BundleHash] = struct{}{}
+		pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxSelected, "")
+	}
+	return bundles, nil
+}
+
+// SubscribeRip7560TxStatus subscribes to lifecycle events of the RIP-7560 transactions in the pool.
+func (pool *Rip7560BundlerPool) SubscribeRip7560TxStatus(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	return pool.statusFeed.Subscribe(ch)
+}
+
+// ReportRip7560TxsDropped notifies the subscribers about RIP-7560 transactions that failed
+// validation and were dropped from the block being built.
+// The debug info is attached so that the bundler that pushed the transaction learns why it was dropped.
+func (pool *Rip7560BundlerPool) ReportRip7560TxsDropped(infos []*types.Rip7560TransactionDebugInfo) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	for _, info := range infos {
+		reason := fmt.Sprintf("validation failed during block building: %s", info.RevertData)
+		if info.RevertEntityName != "" {
+			reason = fmt.Sprintf("validation failed during block building in %s: %s", info.RevertEntityName, info.RevertData)
+		}
+		ev := core.Rip7560TxStatusEvent{TxHash: info.TxHash, Status: core.Rip7560TxDropped, Reason: reason, DebugInfo: info}
+		if info.TimedOut {
+			ev.Status = core.Rip7560TxTimedOut
+			ev.Reason = fmt.Sprintf("validation timed out during block building in %s", info.RevertEntityName)
+		}
+		if bundle := pool.findPendingBundle(info.TxHash); bundle != nil {
+			ev.BundlerId = bundle.BundlerId
+			ev.BundleHash = bundle.BundleHash
+			if info.TimedOut {
+				pool.banTimedOutEntity(bundle, info)
+			}
+		}
+		pool.events.sendStatus(ev)
+	}
+}
+
+// banTimedOutEntity bans the entity whose validation frame timed out, so that it cannot
+// burn the time of the block builder again through other bundles. The bundles relying on
+// it are dropped once the next block is built.
+func (pool *Rip7560BundlerPool) banTimedOutEntity(bundle *types.ExternallyReceivedBundle, info *types.Rip7560TransactionDebugInfo) {
+	for _, tx := range bundle.Transactions {
+		if tx.Hash() != info.TxHash {
+			continue
+		}
+		entity := txEntity(tx.Rip7560TransactionData(), info.RevertEntityName)
+		if entity == nil {
+			return
+		}
+		until := pool.currentHead.Load().Number.Uint64() + validationTimeoutBanBlocks
+		pool.banned.ban(*entity, until)
+		log.Warn("Banned RIP-7560 entity after validation timeout", "entity", info.RevertEntityName, "address", *entity, "until", until)
+		return
+	}
+}
+
+// checkBanned returns an error if any transaction of the bundle relies on a banned entity.
+func (pool *Rip7560BundlerPool) checkBanned(bundle *types.ExternallyReceivedBundle) error {
+	for _, tx := range bundle.Transactions {
+		if err := pool.banned.check(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findPendingBundle returns the pending bundle containing the transaction with the given hash.
+func (pool *Rip7560BundlerPool) findPendingBundle(hash common.Hash) *types.ExternallyReceivedBundle {
+	for _, bundle := range pool.pendingBundles {
+		for _, tx := range bundle.Transactions {
+			if tx.Hash() == hash {
+				return bundle
+			}
+		}
 	}
-	return pool.fetchBundleFromBundler()
+	return nil
 }
 
 // SubscribeTransactions is not needed for the External Bundler AA sub pool and 'ch' will never be sent anything.
@@ -239,12 +481,14 @@ func (pool *Rip7560BundlerPool) Status(_ common.Hash) txpool.TxStatus {
 }
 
 // New creates a new RIP-7560 Account Abstraction Bundler transaction pool.
-func New(config Config, chain legacypool.BlockChain, coinbase common.Address) *Rip7560BundlerPool {
-	return &Rip7560BundlerPool{
+func New(config Config, chain BlockChain, coinbase common.Address) *Rip7560BundlerPool {
+	pool := &Rip7560BundlerPool{
 		config:   config,
 		chain:    chain,
 		coinbase: coinbase,
 	}
+	pool.validate = pool.validateTx
+	return pool
 }
 
 // Filter rejects all individual transactions for External Bundler AA sub pool.
@@ -254,23 +498,275 @@ func (pool *Rip7560BundlerPool) Filter(_ *types.Transaction) bool {
 
 func (pool *Rip7560BundlerPool) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
-	currentBlock := pool.currentHead.Load().Number
-	nextBlock := big.NewInt(0).Add(currentBlock, big.NewInt(1))
+	head := pool.currentHead.Load()
+	nextBlock := big.NewInt(0).Add(head.Number, big.NewInt(1))
 	log.Error("RIP-7560 bundle submitted", "validForBlock", bundle.ValidForBlock.String(), "nextBlock", nextBlock.String())
+
+	target, gas, err := pool.checkBundle(bundle, head)
+	if err != nil {
+		return err
+	}
+	pool.reservedGas[target] += gas
+
 	pool.pendingBundles = append(pool.pendingBundles, bundle)
-	if nextBlock.Cmp(bundle.ValidForBlock) == 0 {
-		pool.txFeed.Send(core.NewTxsEvent{Txs: bundle.Transactions})
+	delete(pool.droppedBundles, bundle.BundleHash)
+	pool.inclusionStats.markSeen(bundle, time.Now())
+	if pool.journal != nil {
+		if err := pool.journal.insert(bundle); err != nil {
+			log.Warn("Failed to journal RIP-7560 bundle", "hash", bundle.BundleHash, "err", err)
+		}
+	}
+	pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxAccepted, "")
+	if bundle.IsValidFor(nextBlock) {
+		pool.events.announce(bundle.Transactions)
+	}
+	return nil
+}
+
+// checkBundleWindow returns an error if the bundle cannot be included in any block built
+// on top of the given head, or only in blocks further ahead than the pool keeps bundles.
+// Bundles without a ValidUntilBlock keep their single target block, past or not.
+func checkBundleWindow(bundle *types.ExternallyReceivedBundle, head *types.Header) error {
+	if bundle.ValidForBlock == nil {
+		return fmt.Errorf("%w: bundle %x has no first valid block", ErrInvalidBundleWindow, bundle.BundleHash)
+	}
+	var (
+		nextBlock = new(big.Int).Add(head.Number, common.Big1)
+		maxBlock  = new(big.Int).Add(head.Number, big.NewInt(maxBundleValidityWindow))
+	)
+	if bundle.ValidForBlock.Cmp(maxBlock) > 0 {
+		return fmt.Errorf("%w: bundle %x starts at block %v, past block %v", ErrInvalidBundleWindow, bundle.BundleHash, bundle.ValidForBlock, maxBlock)
+	}
+	if bundle.ValidUntilBlock == nil {
+		return nil
+	}
+	switch {
+	case bundle.ValidUntilBlock.Cmp(bundle.ValidForBlock) < 0:
+		return fmt.Errorf("%w: bundle %x ends at block %v before starting at block %v", ErrInvalidBundleWindow, bundle.BundleHash, bundle.ValidUntilBlock, bundle.ValidForBlock)
+	case bundle.ValidUntilBlock.Cmp(nextBlock) < 0:
+		return fmt.Errorf("%w: bundle %x expired at block %v", ErrInvalidBundleWindow, bundle.BundleHash, bundle.ValidUntilBlock)
+	case bundle.ValidUntilBlock.Cmp(maxBlock) > 0:
+		return fmt.Errorf("%w: bundle %x ends at block %v, past block %v", ErrInvalidBundleWindow, bundle.BundleHash, bundle.ValidUntilBlock, maxBlock)
+	}
+	return nil
+}
+
+// CheckRip7560Bundle runs the admission checks of the pool on the bundle without
+// submitting it, returning the error SubmitRip7560Bundle would fail with.
+func (pool *Rip7560BundlerPool) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	_, _, err := pool.checkBundle(bundle, pool.currentHead.Load())
+	return err
+}
+
+// checkBundle verifies that the bundle may be accepted on top of the given head, and
+// returns the block it reserves gas in along with the amount of gas to reserve.
+func (pool *Rip7560BundlerPool) checkBundle(bundle *types.ExternallyReceivedBundle, head *types.Header) (uint64, uint64, error) {
+	if err := checkBundleWindow(bundle, head); err != nil {
+		return 0, 0, err
+	}
+	if err := pool.checkBanned(bundle); err != nil {
+		return 0, 0, err
+	}
+	// Reserve the gas of the bundle in its target block, so that bundles that cannot
+	// all be included are not accepted for the same block
+	gas, err := bundleGas(bundle)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidBundleGas, err)
+	}
+	if !pool.chain.Config().IsRIP7560(head.Number, head.Time) {
+		return 0, 0, fmt.Errorf("%w: type %d rejected, pool not yet in RIP-7560", types.ErrTxTypeNotSupported, types.Rip7560Type)
+	}
+	if err := checkBundleChainID(bundle, pool.chain.Config().ChainID); err != nil {
+		return 0, 0, err
+	}
+	if err := pool.checkBundlerQuota(bundle, gas); err != nil {
+		return 0, 0, err
+	}
+	target := bundleTargetBlock(bundle, head)
+	if reserved := pool.reservedGas[target]; reserved+gas > head.GasLimit {
+		return 0, 0, fmt.Errorf("%w: bundle %x needs %d gas, block %d has %d reserved of %d",
+			ErrBlockGasReserved, bundle.BundleHash, gas, target, reserved, head.GasLimit)
+	}
+	return target, gas, nil
+}
+
+// checkBundlerQuota returns an error if accepting the bundle would take its bundler over
+// the number of pending bundles or the pending gas allowed for a single bundler, so that
+// one bundler cannot crowd out the others. Bundles without a BundlerId share a quota.
+func (pool *Rip7560BundlerPool) checkBundlerQuota(bundle *types.ExternallyReceivedBundle, gas uint64) error {
+	if pool.config.MaxBundlerBundles == 0 && pool.config.MaxBundlerGas == 0 {
+		return nil
+	}
+	var (
+		bundles    uint64
+		pendingGas uint64
+	)
+	for _, pending := range pool.pendingBundles {
+		if pending.BundlerId != bundle.BundlerId {
+			continue
+		}
+		bundles++
+		// bundles are only accepted with a valid gas, but may have been journaled before
+		if pendingBundleGas, err := bundleGas(pending); err == nil {
+			pendingGas += pendingBundleGas
+		}
+	}
+	if max := pool.config.MaxBundlerBundles; max != 0 && bundles+1 > max {
+		return fmt.Errorf("%w: bundler %q has %d pending bundles, allowed %d", ErrBundlerQuota, bundle.BundlerId, bundles, max)
+	}
+	if max := pool.config.MaxBundlerGas; max != 0 && pendingGas+gas > max {
+		return fmt.Errorf("%w: bundle %x needs %d gas, bundler %q has %d pending of %d", ErrBundlerQuota, bundle.BundleHash, gas, bundle.BundlerId, pendingGas, max)
+	}
+	return nil
+}
+
+// bundleGas returns the aggregate gas limit of the transactions of the bundle.
+func bundleGas(bundle *types.ExternallyReceivedBundle) (uint64, error) {
+	var gas uint64
+	for _, tx := range bundle.Transactions {
+		aatx := tx.Rip7560TransactionData()
+		if aatx == nil {
+			return 0, fmt.Errorf("transaction %x is not an RIP-7560 transaction", tx.Hash())
+		}
+		txGas, err := aatx.TotalGasLimit()
+		if err != nil {
+			return 0, fmt.Errorf("transaction %x: %w", tx.Hash(), err)
+		}
+		if gas, err = types.SumGas(gas, txGas); err != nil {
+			return 0, fmt.Errorf("transaction %x: %w", tx.Hash(), err)
+		}
+	}
+	return gas, nil
+}
+
+// checkBundleChainID returns an error if a transaction of the bundle is bound to another
+// chain. Unlike other transaction types, the chain id of RIP-7560 transactions is not
+// enforced by their signer.
+func checkBundleChainID(bundle *types.ExternallyReceivedBundle, chainID *big.Int) error {
+	for _, tx := range bundle.Transactions {
+		if aatx := tx.Rip7560TransactionData(); aatx.ChainID == nil || aatx.ChainID.Cmp(chainID) != 0 {
+			return fmt.Errorf("%w: transaction %x has %v, want %v", types.ErrInvalidChainId, tx.Hash(), aatx.ChainID, chainID)
+		}
+	}
+	return nil
+}
+
+// bundleTargetBlock returns the block the bundle reserves gas in: the first block it
+// is valid for that is still to be built on top of the given head.
+func bundleTargetBlock(bundle *types.ExternallyReceivedBundle, head *types.Header) uint64 {
+	nextBlock := head.Number.Uint64() + 1
+	if bundle.ValidForBlock.Sign() < 0 || !bundle.ValidForBlock.IsUint64() || bundle.ValidForBlock.Uint64() < nextBlock {
+		return nextBlock
+	}
+	return bundle.ValidForBlock.Uint64()
+}
+
+// resetReservations recomputes the gas reserved in every block by the pending bundles.
+// Bundles left pending by the previous block move their reservation to the next one.
+func (pool *Rip7560BundlerPool) resetReservations(head *types.Header) {
+	pool.reservedGas = make(map[uint64]uint64)
+	for _, bundle := range pool.pendingBundles {
+		// bundles are only accepted with a valid gas, but may have been journaled before
+		if gas, err := bundleGas(bundle); err == nil {
+			pool.reservedGas[bundleTargetBlock(bundle, head)] += gas
+		}
+	}
+}
+
+// ExtendRip7560Bundle extends the validity window of a pending bundle up to and including
+// the given block. The bundle keeps its position in the queue, but its transactions are
+// re-validated against the current head first.
+func (pool *Rip7560BundlerPool) ExtendRip7560Bundle(hash common.Hash, validUntil *big.Int) error {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	var bundle *types.ExternallyReceivedBundle
+	for _, pending := range pool.pendingBundles {
+		if pending.BundleHash == hash {
+			bundle = pending
+			break
+		}
 	}
+	if bundle == nil {
+		return fmt.Errorf("unknown pending bundle %x", hash)
+	}
+	if validUntil == nil || validUntil.Cmp(bundle.LastValidBlock()) <= 0 {
+		return fmt.Errorf("bundle already valid until block %v", bundle.LastValidBlock())
+	}
+	head := pool.currentHead.Load()
+	if max := new(big.Int).Add(head.Number, big.NewInt(maxBundleValidityWindow)); validUntil.Cmp(max) > 0 {
+		return fmt.Errorf("bundle validity cannot be extended past block %v", max)
+	}
+	statedb, err := pool.chain.StateAt(head.Root)
+	if err != nil {
+		return err
+	}
+	for _, tx := range bundle.Transactions {
+		if err := pool.validate(statedb, head, tx); err != nil {
+			return fmt.Errorf("transaction %x no longer valid: %w", tx.Hash(), err)
+		}
+	}
+	bundle.ValidUntilBlock = new(big.Int).Set(validUntil)
+	log.Debug("Extended RIP-7560 bundle validity", "hash", hash, "validUntil", validUntil)
+
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.pendingBundles); err != nil {
+			log.Warn("Failed to rotate RIP-7560 bundle journal", "err", err)
+		}
+	}
+	pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxRevalidated, "")
 	return nil
 }
 
+// CancelRip7560Bundle removes a pending bundle from the pool and returns its cancelled
+// status. If the bundle was already consumed by the builder, it cannot be cancelled and
+// its status is returned instead: included in a block, or pending if selected for the
+// block being built. Unknown bundles have no status.
+func (pool *Rip7560BundlerPool) CancelRip7560Bundle(hash common.Hash) (*types.BundleReceipt, error) {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	if receipt, ok := pool.includedBundles[hash]; ok {
+		return receipt, nil
+	}
+	index := slices.IndexFunc(pool.pendingBundles, func(bundle *types.ExternallyReceivedBundle) bool {
+		return bundle.BundleHash == hash
+	})
+	if index < 0 {
+		return pool.droppedBundles[hash], nil
+	}
+	bundle := pool.pendingBundles[index]
+	if _, selected := pool.selectedBundles[hash]; selected {
+		return &types.BundleReceipt{BundleHash: hash, Count: uint64(len(bundle.Transactions)), Status: types.BundleStatusPending}, nil
+	}
+	pool.pendingBundles = slices.Delete(pool.pendingBundles, index, index+1)
+	pool.droppedBundles[hash] = newDroppedBundleReceipt(bundle, types.BundleStatusCancelled)
+	pool.inclusionStats.forget(bundle.Transactions)
+	pool.resetReservations(pool.currentHead.Load())
+
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.pendingBundles); err != nil {
+			log.Warn("Failed to rotate RIP-7560 bundle journal", "err", err)
+		}
+	}
+	log.Debug("Cancelled RIP-7560 bundle", "hash", hash)
+	pool.events.sendTxsStatus(bundle.Transactions, core.Rip7560TxDropped, "bundle cancelled by its bundler")
+	return pool.droppedBundles[hash], nil
+}
+
 func (pool *Rip7560BundlerPool) GetRip7560BundleStatus(hash common.Hash) (*types.BundleReceipt, error) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
-	return pool.includedBundles[hash], nil
+	if receipt, ok := pool.includedBundles[hash]; ok {
+		return receipt, nil
+	}
+	return pool.droppedBundles[hash], nil
 }
 
 type GetRip7560BundleArgs struct {
@@ -331,10 +827,14 @@ func (pool *Rip7560BundlerPool) fetchBundleFromBundler() (*types.ExternallyRecei
 	}, nil
 }
 
-// return first bundle
+// selectExternalBundle returns the first pending bundle whose inclusion window contains
+// the block being built.
 func (pool *Rip7560BundlerPool) selectExternalBundle() *types.ExternallyReceivedBundle {
-	if len(pool.pendingBundles) == 0 {
-		return nil
+	nextBlock := new(big.Int).Add(pool.currentHead.Load().Number, common.Big1)
+	for _, bundle := range pool.pendingBundles {
+		if bundle.IsValidFor(nextBlock) {
+			return bundle
+		}
 	}
-	return pool.pendingBundles[0]
+	return nil
 }
@@ -0,0 +1,1436 @@
+package rip7560pool
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+	"math"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testGasLimit is the gas limit of the blocks of the test chain.
+const testGasLimit = 1_000_000
+
+type testBlockChain struct {
+	config   *params.ChainConfig
+	blocks   map[common.Hash]*types.Block
+	receipts map[common.Hash]types.Receipts
+	states   state.Database // Database of the head states
+}
+
+func newTestBlockChain() *testBlockChain {
+	return &testBlockChain{
+		config:   testChainConfig,
+		blocks:   make(map[common.Hash]*types.Block),
+		receipts: make(map[common.Hash]types.Receipts),
+		states:   state.NewDatabase(rawdb.NewMemoryDatabase()),
+	}
+}
+
+// testChainConfig is the test chain config with RIP-7560 active from genesis.
+var testChainConfig = func() *params.ChainConfig {
+	config := *params.TestChainConfig
+	config.RIP7560Block = big.NewInt(0)
+	return &config
+}()
+
+// testNonceManagerCode is a RIP-7712 NonceManager returning a zero sequence number
+// for every nonce key.
+var testNonceManagerCode = []byte{byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN)}
+
+func (bc *testBlockChain) Config() *params.ChainConfig { return bc.config }
+
+func (bc *testBlockChain) CurrentBlock() *types.Header { return nil }
+
+func (bc *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.blocks[hash]
+}
+
+func (bc *testBlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, bc.states, nil)
+}
+
+func (bc *testBlockChain) Engine() consensus.Engine { return nil }
+
+func (bc *testBlockChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if block := bc.blocks[hash]; block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+func (bc *testBlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	return bc.receipts[hash]
+}
+
+// addBlock inserts a child block of parent with the given transactions into the test
+// chain and returns its header. A nil parent inserts a genesis block.
+func (bc *testBlockChain) addBlock(parent *types.Header, txs []*types.Transaction) *types.Header {
+	header := &types.Header{Difficulty: common.Big0, Number: big.NewInt(0), GasLimit: testGasLimit, BaseFee: big.NewInt(1)}
+	if parent != nil {
+		header.ParentHash = parent.Hash()
+		header.Number = new(big.Int).Add(parent.Number, common.Big1)
+	}
+	block := types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+	receipts := make(types.Receipts, len(txs))
+	for i, tx := range txs {
+		receipts[i] = &types.Receipt{TxHash: tx.Hash(), GasUsed: 21000, EffectiveGasPrice: big.NewInt(2)}
+	}
+	bc.blocks[block.Hash()] = block
+	bc.receipts[block.Hash()] = receipts
+	return block.Header()
+}
+
+func newTestBundle(validForBlock int64, nonces ...uint64) *types.ExternallyReceivedBundle {
+	sender := common.Address{0x01}
+	txs := make([]*types.Transaction, len(nonces))
+	for i, nonce := range nonces {
+		txs[i] = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &sender, Nonce: nonce})
+	}
+	return &types.ExternallyReceivedBundle{
+		BundlerId:     "bundler",
+		BundleHash:    common.Hash{byte(validForBlock), byte(len(nonces))},
+		ValidForBlock: big.NewInt(validForBlock),
+		Transactions:  txs,
+	}
+}
+
+func expectStatus(t *testing.T, events chan core.Rip7560TxStatusEvent, txs []*types.Transaction, status core.Rip7560TxStatus) []core.Rip7560TxStatusEvent {
+	t.Helper()
+	received := make([]core.Rip7560TxStatusEvent, 0, len(txs))
+	for _, tx := range txs {
+		select {
+		case ev := <-events:
+			if ev.TxHash != tx.Hash() || ev.Status != status {
+				t.Fatalf("unexpected event: have %v %s, want %v %s", ev.TxHash, ev.Status, tx.Hash(), status)
+			}
+			received = append(received, ev)
+		default:
+			t.Fatalf("missing %s event for %v", status, tx.Hash())
+		}
+	}
+	return received
+}
+
+func TestTxStatusEvents(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	included := newTestBundle(1, 0, 1)
+	expired := newTestBundle(1, 2)
+	retained := newTestBundle(2, 3)
+	for _, bundle := range []*types.ExternallyReceivedBundle{included, expired, retained} {
+		if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+		expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+	}
+
+	selected, err := pool.PendingRip7560Bundle()
+	if err != nil {
+		t.Fatalf("failed to get pending bundle: %v", err)
+	}
+	if selected != included {
+		t.Fatalf("unexpected bundle selected: have %v, want %v", selected.BundleHash, included.BundleHash)
+	}
+	expectStatus(t, events, included.Transactions, core.Rip7560TxSelected)
+
+	info := &types.Rip7560TransactionDebugInfo{TxHash: expired.Transactions[0].Hash(), RevertEntityName: "account", RevertData: "0x"}
+	pool.ReportRip7560TxsDropped([]*types.Rip7560TransactionDebugInfo{info})
+	ev := expectStatus(t, events, expired.Transactions, core.Rip7560TxDropped)[0]
+	if ev.Reason == "" {
+		t.Errorf("missing drop reason")
+	}
+	if ev.DebugInfo != info {
+		t.Errorf("debug info mismatch: have %v, want %v", ev.DebugInfo, info)
+	}
+	if ev.BundlerId != expired.BundlerId || ev.BundleHash != expired.BundleHash {
+		t.Errorf("bundle mismatch: have %s %v, want %s %v", ev.BundlerId, ev.BundleHash, expired.BundlerId, expired.BundleHash)
+	}
+
+	head := chain.addBlock(genesis, included.Transactions)
+	pool.Reset(genesis, head)
+	for _, ev := range expectStatus(t, events, included.Transactions, core.Rip7560TxIncluded) {
+		if ev.Receipt == nil || ev.Receipt.TxHash != ev.TxHash {
+			t.Errorf("missing receipt for included transaction %v", ev.TxHash)
+		}
+	}
+	expectStatus(t, events, expired.Transactions, core.Rip7560TxExpired)
+	expectStatus(t, events, retained.Transactions, core.Rip7560TxRevalidated)
+
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event: %v %s", ev.TxHash, ev.Status)
+	default:
+	}
+}
+
+// TestTxStatusEventsUnlocked checks that a subscriber not reading its status events
+// does not stall the pool, as the events are sent after releasing the pool lock.
+func TestTxStatusEventsUnlocked(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	bundle := newTestBundle(1, 0)
+	submitted := make(chan error, 1)
+	go func() { submitted <- pool.SubmitRip7560Bundle(bundle) }()
+
+	// The pool remains usable while the submission waits for the subscriber
+	added := make(chan struct{})
+	go func() {
+		for !pool.Has(bundle.Transactions[0].Hash()) {
+			time.Sleep(time.Millisecond)
+		}
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pool locked while sending status events")
+	}
+	select {
+	case err := <-submitted:
+		t.Fatalf("submission returned before the subscriber received its events: %v", err)
+	default:
+	}
+	if ev := <-events; ev.TxHash != bundle.Transactions[0].Hash() || ev.Status != core.Rip7560TxAccepted {
+		t.Fatalf("unexpected event: have %v %s, want %v %s", ev.TxHash, ev.Status, bundle.Transactions[0].Hash(), core.Rip7560TxAccepted)
+	}
+	if err := <-submitted; err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+}
+
+func TestReorgReinjection(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	var (
+		bundle  = newTestBundle(1, 0, 1)
+		foreign = newTestBundle(1, 5).Transactions[0] // included without being pushed to us
+		invalid = newTestBundle(1, 6).Transactions[0] // no longer valid after the reorg
+	)
+	pool.validate = func(_ *state.StateDB, _ *types.Header, tx *types.Transaction) error {
+		if tx.Hash() == invalid.Hash() {
+			return errors.New("nonce too low")
+		}
+		return nil
+	}
+	if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+
+	// Include the bundle along with other RIP-7560 transactions
+	abandoned := chain.addBlock(genesis, append(types.Transactions{foreign, invalid}, bundle.Transactions...))
+	pool.Reset(genesis, abandoned)
+	expectStatus(t, events, bundle.Transactions, core.Rip7560TxIncluded)
+	if receipt, _ := pool.GetRip7560BundleStatus(bundle.BundleHash); receipt == nil || receipt.BlockHash != abandoned.Hash() {
+		t.Fatalf("bundle not reported as included")
+	}
+
+	// Reorg to a longer chain including only one transaction of the bundle again
+	sibling := chain.addBlock(genesis, bundle.Transactions[1:])
+	head := chain.addBlock(sibling, nil)
+	pool.Reset(abandoned, head)
+
+	expectStatus(t, events, []*types.Transaction{invalid}, core.Rip7560TxDropped)
+	expectStatus(t, events, bundle.Transactions[:1], core.Rip7560TxRevalidated)
+	expectStatus(t, events, []*types.Transaction{foreign}, core.Rip7560TxRevalidated)
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event: %v %s", ev.TxHash, ev.Status)
+	default:
+	}
+	if receipt, _ := pool.GetRip7560BundleStatus(bundle.BundleHash); receipt != nil {
+		t.Errorf("bundle still reported as included in abandoned block")
+	}
+	if !pool.Has(bundle.Transactions[0].Hash()) || !pool.Has(foreign.Hash()) {
+		t.Errorf("reorged transactions not re-injected")
+	}
+	if pool.Has(bundle.Transactions[1].Hash()) || pool.Has(invalid.Hash()) {
+		t.Errorf("included or invalid transactions re-injected")
+	}
+	reinjected, err := pool.PendingRip7560Bundle()
+	if err != nil {
+		t.Fatalf("failed to get pending bundle: %v", err)
+	}
+	if reinjected.BundleHash != bundle.BundleHash || reinjected.ValidForBlock.Uint64() != 3 || len(reinjected.Transactions) != 1 {
+		t.Errorf("unexpected re-injected bundle: hash %v, valid for %v, %d txs", reinjected.BundleHash, reinjected.ValidForBlock, len(reinjected.Transactions))
+	}
+}
+
+func TestInclusionStats(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	var (
+		paymaster = common.Address{0x02}
+		sponsored = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x01}, Paymaster: &paymaster})
+		included  = &types.ExternallyReceivedBundle{
+			BundlerId:     "bundler",
+			BundleHash:    common.Hash{0x01},
+			ValidForBlock: big.NewInt(1),
+			Transactions:  append(types.Transactions{sponsored}, newTestBundle(1, 1).Transactions...),
+		}
+		expired = newTestBundle(1, 2)
+	)
+	expired.BundlerId = "other"
+	for _, bundle := range []*types.ExternallyReceivedBundle{included, expired} {
+		if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+	}
+	head := chain.addBlock(genesis, included.Transactions)
+	pool.Reset(genesis, head)
+
+	stats := pool.Rip7560InclusionStats()
+	if len(stats.Bundlers) != 1 || stats.Bundlers["bundler"] == nil || stats.Bundlers["bundler"].Count != 2 {
+		t.Fatalf("unexpected bundler stats: %v", stats.Bundlers)
+	}
+	if len(stats.Paymasters) != 2 || stats.Paymasters[paymaster].Count != 1 || stats.Paymasters[common.Address{}].Count != 1 {
+		t.Fatalf("unexpected paymaster stats: %v", stats.Paymasters)
+	}
+	if latency := stats.Bundlers["bundler"]; latency.Min > latency.Max || latency.Total < latency.Max {
+		t.Errorf("inconsistent latency: %+v", latency)
+	}
+	if len(pool.inclusionStats.seen) != 0 {
+		t.Errorf("transactions still tracked after inclusion or expiry: %d", len(pool.inclusionStats.seen))
+	}
+}
+
+func TestBundleJournal(t *testing.T) {
+	var (
+		chain   = newTestBlockChain()
+		genesis = chain.addBlock(nil, nil)
+		config  = Config{Journal: filepath.Join(t.TempDir(), "bundles.rlp")}
+		bundles = []*types.ExternallyReceivedBundle{newTestBundle(1, 0, 1), newTestBundle(2, 2), newTestBundle(2, 3, 4, 5)}
+	)
+	// restart closes the pool and loads the journal into a fresh one at the given head
+	restart := func(pool *Rip7560BundlerPool, head *types.Header) *Rip7560BundlerPool {
+		if pool != nil {
+			if err := pool.Close(); err != nil {
+				t.Fatalf("failed to close pool: %v", err)
+			}
+		}
+		pool = New(config, chain, common.Address{})
+		if err := pool.Init(0, head, nil); err != nil {
+			t.Fatalf("failed to init pool: %v", err)
+		}
+		return pool
+	}
+	check := func(pool *Rip7560BundlerPool, want []*types.ExternallyReceivedBundle) {
+		t.Helper()
+		if len(pool.pendingBundles) != len(want) {
+			t.Fatalf("pending bundle count mismatch: have %d, want %d", len(pool.pendingBundles), len(want))
+		}
+		for i, bundle := range pool.pendingBundles {
+			if bundle.BundleHash != want[i].BundleHash || bundle.BundlerId != want[i].BundlerId || bundle.ValidForBlock.Cmp(want[i].ValidForBlock) != 0 {
+				t.Errorf("bundle %d mismatch: have %v, want %v", i, bundle.BundleHash, want[i].BundleHash)
+			}
+			if len(bundle.Transactions) != len(want[i].Transactions) {
+				t.Fatalf("bundle %d transaction count mismatch: have %d, want %d", i, len(bundle.Transactions), len(want[i].Transactions))
+			}
+			for j, tx := range bundle.Transactions {
+				if tx.Hash() != want[i].Transactions[j].Hash() {
+					t.Errorf("bundle %d transaction %d mismatch: have %v, want %v", i, j, tx.Hash(), want[i].Transactions[j].Hash())
+				}
+			}
+		}
+	}
+	pool := restart(nil, genesis)
+	for _, bundle := range bundles {
+		if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+	}
+	// All the bundles survive a restart in order
+	pool = restart(pool, genesis)
+	check(pool, bundles)
+
+	// Included bundles are removed from the journal
+	head := chain.addBlock(genesis, bundles[0].Transactions)
+	pool.Reset(genesis, head)
+	pool = restart(pool, head)
+	check(pool, bundles[1:])
+
+	// Bundles expiring while the node is down are not restored
+	pool = restart(pool, chain.addBlock(head, nil))
+	check(pool, nil)
+	pool.Close()
+}
+
+func TestExtendBundleValidity(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	var (
+		extended = newTestBundle(1, 0)
+		expiring = newTestBundle(1, 1, 2)
+		invalid  = newTestBundle(1, 3, 4, 5)
+	)
+	pool.validate = func(_ *state.StateDB, _ *types.Header, tx *types.Transaction) error {
+		if tx.Hash() == invalid.Transactions[1].Hash() {
+			return errors.New("nonce too low")
+		}
+		return nil
+	}
+	for _, bundle := range []*types.ExternallyReceivedBundle{extended, expiring, invalid} {
+		if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+		expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+	}
+
+	if err := pool.ExtendRip7560Bundle(common.Hash{0xff}, big.NewInt(3)); err == nil {
+		t.Error("expected unknown bundle extension to fail")
+	}
+	if err := pool.ExtendRip7560Bundle(extended.BundleHash, big.NewInt(1)); err == nil {
+		t.Error("expected non-extending validity to fail")
+	}
+	if err := pool.ExtendRip7560Bundle(extended.BundleHash, big.NewInt(maxBundleValidityWindow+1)); err == nil {
+		t.Error("expected extension past the validity window to fail")
+	}
+	if err := pool.ExtendRip7560Bundle(invalid.BundleHash, big.NewInt(3)); err == nil {
+		t.Error("expected extension of invalid bundle to fail")
+	}
+	if err := pool.ExtendRip7560Bundle(extended.BundleHash, big.NewInt(3)); err != nil {
+		t.Fatalf("failed to extend bundle: %v", err)
+	}
+	expectStatus(t, events, extended.Transactions, core.Rip7560TxRevalidated)
+
+	// The extended bundle survives the blocks of its window, keeping its queue position
+	head := genesis
+	for number := 1; number <= 2; number++ {
+		parent := head
+		head = chain.addBlock(parent, nil)
+		pool.Reset(parent, head)
+		expectStatus(t, events, extended.Transactions, core.Rip7560TxRevalidated)
+		if number == 1 {
+			expectStatus(t, events, expiring.Transactions, core.Rip7560TxExpired)
+			expectStatus(t, events, invalid.Transactions, core.Rip7560TxExpired)
+		}
+
+		selected, err := pool.PendingRip7560Bundle()
+		if err != nil {
+			t.Fatalf("failed to get pending bundle: %v", err)
+		}
+		if selected != extended {
+			t.Fatalf("extended bundle not pending after block %d", number)
+		}
+		expectStatus(t, events, extended.Transactions, core.Rip7560TxSelected)
+	}
+	parent := head
+	head = chain.addBlock(parent, nil)
+	pool.Reset(parent, head)
+	expectStatus(t, events, extended.Transactions, core.Rip7560TxExpired)
+	if pool.Has(extended.Transactions[0].Hash()) {
+		t.Error("bundle retained past its validity window")
+	}
+}
+
+func TestBundleInclusionWindow(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	newWindowBundle := func(minBlock, maxBlock int64, nonce uint64) *types.ExternallyReceivedBundle {
+		bundle := newTestBundle(minBlock, nonce)
+		bundle.ValidUntilBlock = big.NewInt(maxBlock)
+		return bundle
+	}
+	for _, bundle := range []*types.ExternallyReceivedBundle{
+		newWindowBundle(3, 2, 1),                                                 // empty window
+		newWindowBundle(0, 0, 2),                                                 // window over already
+		newWindowBundle(1, maxBundleValidityWindow+1, 3),                         // ends too far ahead
+		newWindowBundle(maxBundleValidityWindow+1, maxBundleValidityWindow+1, 4), // starts too far ahead
+	} {
+		if err := pool.SubmitRip7560Bundle(bundle); !errors.Is(err, ErrInvalidBundleWindow) {
+			t.Errorf("window [%v, %v]: submission error mismatch: have %v, want %v", bundle.ValidForBlock, bundle.ValidUntilBlock, err, ErrInvalidBundleWindow)
+		}
+	}
+	windowed := newWindowBundle(2, 3, 0)
+	if err := pool.SubmitRip7560Bundle(windowed); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	expectStatus(t, events, windowed.Transactions, core.Rip7560TxAccepted)
+
+	// The bundle is only selected for the blocks of its window, and expires afterwards
+	head := genesis
+	for number := 1; number <= 3; number++ {
+		selected, err := pool.PendingRip7560Bundle()
+		if err != nil {
+			t.Fatalf("failed to get pending bundle: %v", err)
+		}
+		if inWindow := number >= 2; (selected == windowed) != inWindow {
+			t.Fatalf("block %d: bundle selection mismatch: have %v, want %v", number, selected != nil, inWindow)
+		}
+		if selected != nil {
+			expectStatus(t, events, windowed.Transactions, core.Rip7560TxSelected)
+		}
+		parent := head
+		head = chain.addBlock(parent, nil)
+		pool.Reset(parent, head)
+		if number < 3 {
+			expectStatus(t, events, windowed.Transactions, core.Rip7560TxRevalidated)
+		}
+	}
+	if ev := expectStatus(t, events, windowed.Transactions, core.Rip7560TxExpired)[0]; ev.Reason == "" {
+		t.Error("missing expiry reason")
+	}
+	if pool.Has(windowed.Transactions[0].Hash()) {
+		t.Error("bundle retained past its inclusion window")
+	}
+	receipt, err := pool.GetRip7560BundleStatus(windowed.BundleHash)
+	if err != nil {
+		t.Fatalf("failed to get bundle status: %v", err)
+	}
+	if receipt == nil || receipt.Status != types.BundleStatusExpired || receipt.BlockNumber != 3 {
+		t.Errorf("expired bundle status mismatch: have %+v, want status %d at block 3", receipt, types.BundleStatusExpired)
+	}
+}
+
+func TestBundleGasReservation(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	// newGasBundle creates a bundle with a single transaction of the given execution gas,
+	// needing 15000 more gas for its intrinsic cost
+	newGasBundle := func(validForBlock int64, id byte, gas uint64) *types.ExternallyReceivedBundle {
+		bundle := newTestBundle(validForBlock, uint64(id))
+		bundle.BundleHash[2] = id
+		bundle.Transactions[0] = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x01}, Nonce: uint64(id), Gas: gas})
+		return bundle
+	}
+	submit := func(bundle *types.ExternallyReceivedBundle, want error) {
+		t.Helper()
+		if err := pool.SubmitRip7560Bundle(bundle); !errors.Is(err, want) {
+			t.Fatalf("bundle %x: submission error mismatch: have %v, want %v", bundle.BundleHash, err, want)
+		}
+		if want == nil {
+			expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+		} else if pool.Has(bundle.Transactions[0].Hash()) {
+			t.Fatalf("bundle %x: rejected bundle is pending", bundle.BundleHash)
+		}
+	}
+	var (
+		first      = newGasBundle(1, 1, 600_000)
+		exclusive  = newGasBundle(1, 2, 400_000)
+		next       = newGasBundle(2, 3, 400_000)
+		overflow   = newGasBundle(1, 4, math.MaxUint64)
+		nextTooBig = newGasBundle(2, 5, 600_000)
+		nextFits   = newGasBundle(2, 6, 500_000)
+	)
+	submit(first, nil)
+	submit(exclusive, ErrBlockGasReserved)
+	submit(next, nil)
+	submit(overflow, ErrInvalidBundleGas)
+
+	// The reservation of the first bundle is released once it leaves the pool
+	head := chain.addBlock(genesis, nil)
+	pool.Reset(genesis, head)
+	expectStatus(t, events, first.Transactions, core.Rip7560TxExpired)
+	expectStatus(t, events, next.Transactions, core.Rip7560TxRevalidated)
+
+	submit(nextTooBig, ErrBlockGasReserved)
+	submit(nextFits, nil)
+}
+
+func TestBundlerQuota(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{MaxBundlerBundles: 2, MaxBundlerGas: 1_000_000}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	// newBundlerBundle creates a bundle of the bundler with a single transaction of the
+	// given execution gas, needing 15000 more gas for its intrinsic cost. Every bundle
+	// targets its own block, not to run into the gas reserved in the blocks.
+	newBundlerBundle := func(bundler string, id byte, gas uint64) *types.ExternallyReceivedBundle {
+		bundle := newTestBundle(int64(id), uint64(id))
+		bundle.BundlerId = bundler
+		bundle.Transactions[0] = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x01}, Nonce: uint64(id), Gas: gas})
+		return bundle
+	}
+	submit := func(bundle *types.ExternallyReceivedBundle, want error) {
+		t.Helper()
+		if err := pool.SubmitRip7560Bundle(bundle); !errors.Is(err, want) {
+			t.Fatalf("bundle %x: submission error mismatch: have %v, want %v", bundle.BundleHash, err, want)
+		}
+		if want == nil {
+			expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+		}
+	}
+	var (
+		first    = newBundlerBundle("greedy", 1, 400_000)
+		second   = newBundlerBundle("greedy", 2, 400_000)
+		third    = newBundlerBundle("greedy", 3, 100)
+		other    = newBundlerBundle("other", 4, 900_000)
+		otherGas = newBundlerBundle("other", 5, 100_000)
+	)
+	submit(first, nil)
+	submit(second, nil)
+	submit(third, ErrBundlerQuota)
+	submit(other, nil)
+	submit(otherGas, ErrBundlerQuota)
+
+	// The quota of the bundler is released once its bundles leave the pool
+	if _, err := pool.CancelRip7560Bundle(first.BundleHash); err != nil {
+		t.Fatalf("failed to cancel bundle: %v", err)
+	}
+	expectStatus(t, events, first.Transactions, core.Rip7560TxDropped)
+	submit(third, nil)
+}
+
+func TestCancelBundle(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	var (
+		selected  = newTestBundle(1, 0)
+		cancelled = newTestBundle(2, 1, 2)
+	)
+	for _, bundle := range []*types.ExternallyReceivedBundle{selected, cancelled} {
+		if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+		expectStatus(t, events, bundle.Transactions, core.Rip7560TxAccepted)
+	}
+	if receipt, err := pool.CancelRip7560Bundle(common.Hash{0xff}); receipt != nil || err != nil {
+		t.Errorf("unknown bundle cancelled: have %+v %v", receipt, err)
+	}
+
+	// The bundle selected for the block being built is consumed by the builder already
+	if bundle, _ := pool.PendingRip7560Bundle(); bundle != selected {
+		t.Fatalf("bundle not selected")
+	}
+	expectStatus(t, events, selected.Transactions, core.Rip7560TxSelected)
+	if receipt, err := pool.CancelRip7560Bundle(selected.BundleHash); err != nil || receipt.Status != types.BundleStatusPending {
+		t.Errorf("selected bundle status mismatch: have %+v %v, want %d", receipt, err, types.BundleStatusPending)
+	}
+
+	// The bundle still waiting for inclusion is removed from the pool
+	receipt, err := pool.CancelRip7560Bundle(cancelled.BundleHash)
+	if err != nil || receipt.Status != types.BundleStatusCancelled {
+		t.Fatalf("cancelled bundle status mismatch: have %+v %v, want %d", receipt, err, types.BundleStatusCancelled)
+	}
+	expectStatus(t, events, cancelled.Transactions, core.Rip7560TxDropped)
+	if pool.Has(cancelled.Transactions[0].Hash()) {
+		t.Error("cancelled bundle still pooled")
+	}
+	if status, _ := pool.GetRip7560BundleStatus(cancelled.BundleHash); status != receipt {
+		t.Errorf("cancelled bundle status mismatch: have %+v, want %+v", status, receipt)
+	}
+
+	// The included bundle can no longer be cancelled
+	head := chain.addBlock(genesis, selected.Transactions)
+	pool.Reset(genesis, head)
+	expectStatus(t, events, selected.Transactions, core.Rip7560TxIncluded)
+	if receipt, err := pool.CancelRip7560Bundle(selected.BundleHash); err != nil || receipt.Status != types.BundleStatusIncluded || receipt.BlockHash != head.Hash() {
+		t.Errorf("included bundle status mismatch: have %+v %v", receipt, err)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event: %v %s", ev.TxHash, ev.Status)
+	default:
+	}
+}
+
+func TestCheckBundle(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	bundle := newTestBundle(1, 1)
+	bundle.Transactions[0] = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x01}, Nonce: 1, Gas: 600_000})
+
+	// Checking a bundle neither enqueues it nor reserves its gas
+	for i := 0; i < 2; i++ {
+		if err := pool.CheckRip7560Bundle(bundle); err != nil {
+			t.Fatalf("check %d: unexpected error: %v", i, err)
+		}
+	}
+	if pool.Has(bundle.Transactions[0].Hash()) {
+		t.Fatal("checked bundle is pending")
+	}
+	if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+		t.Fatalf("failed to submit checked bundle: %v", err)
+	}
+	if err := pool.CheckRip7560Bundle(bundle); !errors.Is(err, ErrBlockGasReserved) {
+		t.Errorf("check error mismatch: have %v, want %v", err, ErrBlockGasReserved)
+	}
+	late := newTestBundle(0, 2)
+	late.ValidUntilBlock = big.NewInt(0)
+	if err := pool.CheckRip7560Bundle(late); !errors.Is(err, ErrInvalidBundleWindow) {
+		t.Errorf("check error mismatch: have %v, want %v", err, ErrInvalidBundleWindow)
+	}
+	foreign := newTestBundle(2, 2)
+	foreign.Transactions[0] = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: big.NewInt(1337), Sender: &common.Address{0x02}})
+	if err := pool.CheckRip7560Bundle(foreign); !errors.Is(err, types.ErrInvalidChainId) {
+		t.Errorf("check error mismatch: have %v, want %v", err, types.ErrInvalidChainId)
+	}
+}
+
+func TestValidationTimeoutBan(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := New(Config{}, chain, common.Address{})
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	// all the test bundles are sent by the same account
+	timedOut := newTestBundle(2, 0)
+	if err := pool.SubmitRip7560Bundle(timedOut); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	expectStatus(t, events, timedOut.Transactions, core.Rip7560TxAccepted)
+
+	pool.ReportRip7560TxsDropped([]*types.Rip7560TransactionDebugInfo{{
+		TxHash:           timedOut.Transactions[0].Hash(),
+		RevertEntityName: "account",
+		TimedOut:         true,
+	}})
+	if ev := expectStatus(t, events, timedOut.Transactions, core.Rip7560TxTimedOut)[0]; ev.BundleHash != timedOut.BundleHash {
+		t.Errorf("bundle hash mismatch: have %x, want %x", ev.BundleHash, timedOut.BundleHash)
+	}
+	if err := pool.SubmitRip7560Bundle(newTestBundle(2, 1)); !errors.Is(err, ErrEntityBanned) {
+		t.Errorf("bundle of banned entity accepted: have %v, want %v", err, ErrEntityBanned)
+	}
+
+	// the pending bundle relying on the banned entity is dropped with the next block
+	head := chain.addBlock(genesis, nil)
+	pool.Reset(genesis, head)
+	expectStatus(t, events, timedOut.Transactions, core.Rip7560TxDropped)
+	if pool.Has(timedOut.Transactions[0].Hash()) {
+		t.Error("bundle of banned entity retained")
+	}
+
+	// the ban is lifted after its last block
+	sender := common.Address{0x01}
+	pool.banned.prune(validationTimeoutBanBlocks - 1)
+	if _, banned := pool.banned[sender]; !banned {
+		t.Error("ban lifted early")
+	}
+	pool.banned.prune(validationTimeoutBanBlocks)
+	if _, banned := pool.banned[sender]; banned {
+		t.Error("ban not lifted")
+	}
+}
+
+func TestNativePoolActivation(t *testing.T) {
+	chain := newTestBlockChain()
+	chain.config = params.TestChainConfig
+	genesis := chain.addBlock(nil, nil)
+
+	// neither the native pool nor the bundler pool accept transactions before the fork
+	native := NewNative(DefaultNativeConfig, chain, common.Address{})
+	if err := native.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init native pool: %v", err)
+	}
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x01}})
+	if err := native.Add([]*types.Transaction{tx}, false, false)[0]; !errors.Is(err, types.ErrTxTypeNotSupported) {
+		t.Errorf("transaction error mismatch: have %v, want %v", err, types.ErrTxTypeNotSupported)
+	}
+	bundler := New(Config{}, chain, common.Address{})
+	if err := bundler.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init bundler pool: %v", err)
+	}
+	if err := bundler.SubmitRip7560Bundle(newTestBundle(1, 0)); !errors.Is(err, types.ErrTxTypeNotSupported) {
+		t.Errorf("bundle error mismatch: have %v, want %v", err, types.ErrTxTypeNotSupported)
+	}
+}
+
+func TestNativePool(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	var (
+		rejected    = common.Address{0xff}
+		errReverted = errors.New("validation reverted")
+	)
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		if *tx.Rip7560TransactionData().Sender == rejected {
+			return nil, errReverted
+		}
+		return nil, nil
+	}
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	newTx := func(sender common.Address, nonce uint64, gas uint64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            params.TestChainConfig.ChainID,
+			Sender:             &sender,
+			NonceKey:           big.NewInt(0),
+			Nonce:              nonce,
+			Gas:                gas,
+			ValidationGasLimit: 100_000,
+			GasTipCap:          big.NewInt(1),
+			GasFeeCap:          big.NewInt(2),
+		})
+	}
+	var (
+		first     = newTx(common.Address{0x01}, 0, 100_000)
+		second    = newTx(common.Address{0x01}, 1, 100_000)
+		invalid   = newTx(rejected, 0, 100_000)
+		conflict  = newTx(common.Address{0x01}, 0, 200_000)
+		oversized = newTx(common.Address{0x03}, 0, testGasLimit)
+		malformed = types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &common.Address{0x02}, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(1)})
+		legacy    = types.NewTx(&types.LegacyTx{})
+	)
+	if pool.Filter(legacy) || !pool.Filter(first) {
+		t.Fatal("native pool does not filter individual RIP-7560 transactions")
+	}
+	errs := pool.Add([]*types.Transaction{first, invalid, conflict, oversized, malformed, second, first}, false, false)
+	for i, want := range []error{nil, errReverted, txpool.ErrReplaceUnderpriced, txpool.ErrGasLimit, types.ErrInvalidRip7560Tx, nil, txpool.ErrAlreadyKnown} {
+		if !errors.Is(errs[i], want) {
+			t.Errorf("tx %d: error mismatch: have %v, want %v", i, errs[i], want)
+		}
+	}
+	expectStatus(t, events, []*types.Transaction{first, second}, core.Rip7560TxAccepted)
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 2/0", pending, queued)
+	}
+
+	// The pending transactions are proposed to the miner in arrival order
+	bundle, err := pool.PendingRip7560Bundle()
+	if err != nil {
+		t.Fatalf("failed to get pending bundle: %v", err)
+	}
+	if bundle == nil || len(bundle.Transactions) != 2 || bundle.Transactions[0] != first || bundle.Transactions[1] != second {
+		t.Fatalf("pending bundle mismatch: have %v", bundle)
+	}
+	if bundle.BundlerId != nativeBundlerId || bundle.ValidForBlock.Uint64() != 1 {
+		t.Errorf("pending bundle mismatch: have bundler %s for block %v", bundle.BundlerId, bundle.ValidForBlock)
+	}
+	expectStatus(t, events, bundle.Transactions, core.Rip7560TxSelected)
+
+	// Transactions dropped by the miner or included in the chain leave the pool
+	pool.ReportRip7560TxsDropped([]*types.Rip7560TransactionDebugInfo{{TxHash: second.Hash(), RevertData: "nonce too low"}})
+	expectStatus(t, events, []*types.Transaction{second}, core.Rip7560TxDropped)
+
+	head := chain.addBlock(genesis, []*types.Transaction{first})
+	pool.Reset(genesis, head)
+	received := expectStatus(t, events, []*types.Transaction{first}, core.Rip7560TxIncluded)
+	if received[0].Receipt == nil {
+		t.Error("included event without receipt")
+	}
+	if pool.Has(first.Hash()) || pool.Has(second.Hash()) {
+		t.Fatal("removed transactions still pending")
+	}
+	if bundle, _ := pool.PendingRip7560Bundle(); bundle != nil {
+		t.Fatalf("unexpected pending bundle: %v", bundle)
+	}
+	// The nonce slot of an included transaction can be taken again
+	if err := pool.Add([]*types.Transaction{conflict}, false, false)[0]; err != nil {
+		t.Errorf("failed to add transaction to released slot: %v", err)
+	}
+}
+
+func TestReputation(t *testing.T) {
+	var (
+		entity = common.Address{0xee}
+		r      = newReputation(0)
+	)
+	for i := 0; i < (throttlingSlack+1)*minInclusionRateDenominator; i++ {
+		r.markSeen(entity)
+	}
+	if have := r.status(entity); have != reputationThrottled {
+		t.Fatalf("status mismatch: have %d, want throttled", have)
+	}
+	r.markIncluded(entity)
+	if have := r.status(entity); have != reputationOk {
+		t.Fatalf("status mismatch: have %d, want ok", have)
+	}
+	r.markCrashed(entity)
+	if have := r.status(entity); have != reputationBanned {
+		t.Fatalf("status mismatch: have %d, want banned", have)
+	}
+	// Entities recover over time, and are forgotten once their counters run out
+	r.decay(reputationDecayBlocks - 1)
+	if have := r.entries[entity].seen; have != crashedEntitySeen {
+		t.Fatalf("counters decayed early: have %d seen", have)
+	}
+	r.decay(200 * reputationDecayBlocks)
+	if have := r.status(entity); have != reputationOk {
+		t.Fatalf("status mismatch: have %d, want ok", have)
+	}
+	r.decay(1000 * reputationDecayBlocks)
+	if len(r.entries) != 0 {
+		t.Fatalf("decayed entities not forgotten: %d left", len(r.entries))
+	}
+}
+
+func TestNativePoolReputation(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		return nil, nil
+	}
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	paymaster := common.Address{0xaa}
+	newTx := func(sender byte) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Sender:    &common.Address{sender},
+			NonceKey:  big.NewInt(0),
+			Gas:       10_000,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+			Paymaster: &paymaster,
+		})
+	}
+	// A throttled paymaster only gets a few transactions into the pool
+	pool.reputation.entry(paymaster).seen = (throttlingSlack + 1) * minInclusionRateDenominator
+	for i := 0; i < throttledEntityPoolTxs; i++ {
+		if err := pool.Add([]*types.Transaction{newTx(byte(i + 1))}, false, false)[0]; err != nil {
+			t.Fatalf("tx %d: failed to add transaction of throttled paymaster: %v", i, err)
+		}
+	}
+	throttled := newTx(0xf0)
+	if err := pool.Add([]*types.Transaction{throttled}, false, false)[0]; !errors.Is(err, ErrEntityThrottled) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrEntityThrottled)
+	}
+	// Getting transactions included restores the reputation of the paymaster
+	included := pool.Get(newTx(1).Hash())
+	head := chain.addBlock(genesis, []*types.Transaction{included})
+	pool.Reset(genesis, head)
+	if err := pool.Add([]*types.Transaction{throttled}, false, false)[0]; err != nil {
+		t.Fatalf("failed to add transaction after inclusion: %v", err)
+	}
+	// Failing validation during block building bans the paymaster and drops its transactions
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	pool.ReportRip7560TxsDropped([]*types.Rip7560TransactionDebugInfo{{TxHash: throttled.Hash(), RevertEntityName: "paymaster"}})
+	expectStatus(t, events, []*types.Transaction{throttled, newTx(2), newTx(3), newTx(4)}, core.Rip7560TxDropped)
+	if pending, _ := pool.Stats(); pending != 0 {
+		t.Fatalf("transactions of banned paymaster still pending: %d", pending)
+	}
+	if err := pool.Add([]*types.Transaction{newTx(0xf1)}, false, false)[0]; !errors.Is(err, ErrEntityBanned) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrEntityBanned)
+	}
+}
+
+func TestNativePoolRevalidation(t *testing.T) {
+	var (
+		chain     = newTestBlockChain()
+		touched   = common.Address{0x01}
+		untouched = common.Address{0x02}
+	)
+	statedb, _ := state.New(types.EmptyRootHash, chain.states, nil)
+	statedb.SetBalance(touched, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	statedb.SetBalance(untouched, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	statedb.SetCode(core.AA_NONCE_MANAGER, testNonceManagerCode)
+	oldRoot, _ := statedb.Commit(0, true)
+	statedb, _ = state.New(oldRoot, chain.states, nil)
+	statedb.SetBalance(touched, uint256.NewInt(2), tracing.BalanceChangeUnspecified)
+	newRoot, _ := statedb.Commit(1, true)
+
+	oldHead := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(0), GasLimit: testGasLimit, Root: oldRoot}
+	newHead := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(1), GasLimit: testGasLimit, Root: newRoot, ParentHash: oldHead.Hash()}
+
+	var invalid error
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		return nil, invalid
+	}
+	if err := pool.Init(0, oldHead, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	newTx := func(sender common.Address, nonceKey int64, paymaster *common.Address) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Sender:    &sender,
+			NonceKey:  big.NewInt(nonceKey),
+			Gas:       10_000,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+			Paymaster: paymaster,
+		})
+	}
+	var (
+		touchedSender    = newTx(touched, 0, nil)
+		untouchedSender  = newTx(untouched, 0, nil)
+		touchedPaymaster = newTx(untouched, 1, &touched)
+		untouchedManager = newTx(untouched, 2, nil)
+	)
+	for i, err := range pool.Add([]*types.Transaction{touchedSender, untouchedSender, touchedPaymaster, untouchedManager}, false, false) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	// Only the transactions relying on the touched accounts are revalidated
+	invalid = errors.New("invalid")
+	pool.Reset(oldHead, newHead)
+	expectStatus(t, events, []*types.Transaction{touchedSender, touchedPaymaster}, core.Rip7560TxDropped)
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatch: have %d, want 2", pending)
+	}
+	// All transactions are revalidated if the changes are unknown
+	pool.Reset(nil, newHead)
+	expectStatus(t, events, []*types.Transaction{untouchedSender, untouchedManager}, core.Rip7560TxDropped)
+	if pending, _ := pool.Stats(); pending != 0 {
+		t.Fatalf("pending transactions mismatch: have %d, want 0", pending)
+	}
+}
+
+func TestNativePoolSlotInvalidation(t *testing.T) {
+	var (
+		chain    = newTestBlockChain()
+		contract = common.Address{0xcc}
+		watched  = common.Hash{0x01}
+		other    = common.Hash{0x02}
+	)
+	statedb, _ := state.New(types.EmptyRootHash, chain.states, nil)
+	statedb.SetNonce(contract, 1)
+	statedb.SetState(contract, watched, common.Hash{0x01})
+	statedb.SetState(contract, other, common.Hash{0x01})
+	root, _ := statedb.Commit(0, true)
+
+	// commit applies the storage changes on top of the parent head and returns the child head
+	commit := func(parent *types.Header, slot common.Hash) *types.Header {
+		statedb, _ := state.New(parent.Root, chain.states, nil)
+		statedb.SetState(contract, slot, common.Hash{byte(parent.Number.Uint64() + 2)})
+		root, _ := statedb.Commit(parent.Number.Uint64()+1, true)
+		return &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: new(big.Int).Add(parent.Number, common.Big1), GasLimit: testGasLimit, Root: root, ParentHash: parent.Hash()}
+	}
+	var (
+		validations int
+		invalid     error
+	)
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		validations++
+		accesses := newValidationAccesses()
+		accesses.addSlot(contract, watched)
+		return accesses, invalid
+	}
+	head := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(0), GasLimit: testGasLimit, Root: root}
+	if err := pool.Init(0, head, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Sender:    &common.Address{0x01},
+		NonceKey:  big.NewInt(0),
+		Gas:       10_000,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+	})
+	if err := pool.Add([]*types.Transaction{tx}, false, false)[0]; err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Changing storage the validation did not access keeps the transaction untouched
+	invalid = errors.New("invalid")
+	next := commit(head, other)
+	pool.Reset(head, next)
+	if validations != 1 || !pool.Has(tx.Hash()) {
+		t.Fatalf("transaction revalidated after unrelated change: %d validations", validations)
+	}
+	// Changing an accessed slot gets the transaction revalidated and dropped
+	pool.Reset(next, commit(next, watched))
+	if validations != 2 || pool.Has(tx.Hash()) {
+		t.Fatalf("transaction not invalidated after accessed slot change: %d validations", validations)
+	}
+}
+
+func TestNativePoolReplacement(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		return nil, nil
+	}
+	genesis := chain.addBlock(nil, nil)
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	newTx := func(sender byte, tip, feeCap int64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Sender:    &common.Address{sender},
+			NonceKey:  big.NewInt(0),
+			Gas:       10_000,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(feeCap),
+		})
+	}
+	var (
+		original = newTx(1, 100, 200)
+		other    = newTx(2, 100, 200)
+	)
+	for i, err := range pool.Add([]*types.Transaction{original, other}, false, false) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	defer sub.Unsubscribe()
+
+	// Replacements must bump both the fee cap and the tip by the price bump
+	for i, tx := range []*types.Transaction{newTx(1, 100, 300), newTx(1, 200, 200), newTx(1, 109, 220), newTx(1, 110, 219)} {
+		if err := pool.Add([]*types.Transaction{tx}, false, false)[0]; !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+			t.Errorf("tx %d: error mismatch: have %v, want %v", i, err, txpool.ErrReplaceUnderpriced)
+		}
+	}
+	replacement := newTx(1, 110, 220)
+	if err := pool.Add([]*types.Transaction{replacement}, false, false)[0]; err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if ev := expectStatus(t, events, []*types.Transaction{original}, core.Rip7560TxReplaced)[0]; !strings.Contains(ev.Reason, replacement.Hash().Hex()) {
+		t.Errorf("replaced event reason mismatch: have %q", ev.Reason)
+	}
+	expectStatus(t, events, []*types.Transaction{replacement}, core.Rip7560TxAccepted)
+	if pool.Has(original.Hash()) {
+		t.Fatal("replaced transaction still pending")
+	}
+	// The replacement takes the place of the replaced transaction in the arrival order
+	bundle, _ := pool.PendingRip7560Bundle()
+	if bundle == nil || len(bundle.Transactions) != 2 || bundle.Transactions[0] != replacement || bundle.Transactions[1] != other {
+		t.Fatalf("pending bundle mismatch: have %v", bundle)
+	}
+}
+
+func TestNativePoolNonceLanes(t *testing.T) {
+	var (
+		chain  = newTestBlockChain()
+		sender = common.Address{0x01}
+		other  = common.Address{0x02}
+	)
+	statedb, _ := state.New(types.EmptyRootHash, chain.states, nil)
+	statedb.SetNonce(sender, 2)
+	statedb.SetCode(core.AA_NONCE_MANAGER, testNonceManagerCode)
+	root, _ := statedb.Commit(0, true)
+	genesis := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(0), GasLimit: testGasLimit, Root: root}
+
+	prefixes := make(map[common.Hash]int)
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, prefix []*types.Transaction) (*validationAccesses, error) {
+		prefixes[tx.Hash()] = len(prefix)
+		return nil, nil
+	}
+	if err := pool.Init(0, genesis, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	newTx := func(sender common.Address, nonceKey int64, nonce uint64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Sender:    &sender,
+			NonceKey:  big.NewInt(nonceKey),
+			Nonce:     nonce,
+			Gas:       10_000,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+		})
+	}
+	txs := make(chan core.NewTxsEvent, 16)
+	sub := pool.SubscribeTransactions(txs, false)
+	defer sub.Unsubscribe()
+
+	// Transactions ahead of the nonce of their lane are queued, those before it rejected
+	var (
+		stale   = newTx(sender, 0, 1)
+		ahead   = newTx(sender, 0, 3)
+		current = newTx(sender, 0, 2)
+		keyed   = newTx(sender, 1, 0)
+	)
+	errs := pool.Add([]*types.Transaction{stale, ahead, keyed}, false, false)
+	for i, want := range []error{core.ErrNonceTooLow, nil, nil} {
+		if !errors.Is(errs[i], want) {
+			t.Fatalf("tx %d: error mismatch: have %v, want %v", i, errs[i], want)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 1/1", pending, queued)
+	}
+	if status := pool.Status(ahead.Hash()); status != txpool.TxStatusQueued {
+		t.Fatalf("queued transaction status mismatch: have %v", status)
+	}
+	if ev := <-txs; len(ev.Txs) != 1 || ev.Txs[0] != keyed {
+		t.Fatalf("announced transactions mismatch: have %v, want the keyed one", ev.Txs)
+	}
+	// Filling the nonce gap promotes the queued transaction, validated after its predecessor
+	if err := pool.Add([]*types.Transaction{current}, false, false)[0]; err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 3/0", pending, queued)
+	}
+	if prefixes[ahead.Hash()] != 1 || prefixes[current.Hash()] != 0 {
+		t.Errorf("validation prefix mismatch: have %d and %d", prefixes[current.Hash()], prefixes[ahead.Hash()])
+	}
+	if ev := <-txs; len(ev.Txs) != 2 || ev.Txs[0] != current || ev.Txs[1] != ahead {
+		t.Fatalf("announced transactions mismatch: have %v, want the promoted ones", ev.Txs)
+	}
+	bundle, _ := pool.PendingRip7560Bundle()
+	if bundle == nil || len(bundle.Transactions) != 3 || bundle.Transactions[0] != keyed || bundle.Transactions[1] != current || bundle.Transactions[2] != ahead {
+		t.Fatalf("pending bundle mismatch: have %v", bundle)
+	}
+	// Dropping a transaction queues the following ones of its lane only
+	pool.ReportRip7560TxsDropped([]*types.Rip7560TransactionDebugInfo{{TxHash: current.Hash()}})
+	if pool.Status(ahead.Hash()) != txpool.TxStatusQueued || pool.Status(keyed.Hash()) != txpool.TxStatusPending {
+		t.Fatalf("lane statuses mismatch: have %v and %v", pool.Status(ahead.Hash()), pool.Status(keyed.Hash()))
+	}
+	// Queued transactions are promoted once their preceding nonces get included
+	queued := newTx(other, 0, 1)
+	if err := pool.Add([]*types.Transaction{queued}, false, false)[0]; err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	statedb, _ = state.New(root, chain.states, nil)
+	statedb.SetNonce(other, 1)
+	next := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(1), GasLimit: testGasLimit, ParentHash: genesis.Hash()}
+	next.Root, _ = statedb.Commit(1, true)
+	pool.Reset(genesis, next)
+	if pool.Status(queued.Hash()) != txpool.TxStatusPending || pool.Status(ahead.Hash()) != txpool.TxStatusQueued {
+		t.Fatalf("lane statuses mismatch: have %v and %v", pool.Status(queued.Hash()), pool.Status(ahead.Hash()))
+	}
+	if ev := <-txs; len(ev.Txs) != 1 || ev.Txs[0] != queued {
+		t.Fatalf("announced transactions mismatch: have %v, want the promoted one", ev.Txs)
+	}
+}
+
+func TestNativePoolBaseFee(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := NewNative(DefaultNativeConfig, chain, common.Address{})
+	pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+		return nil, nil
+	}
+	// The base fee stays at 100 in the next block, and drops to 88 after the block following it
+	head := &types.Header{Difficulty: common.Big0, Number: big.NewInt(0), GasLimit: testGasLimit, GasUsed: testGasLimit / 2, BaseFee: big.NewInt(100)}
+	if err := pool.Init(0, head, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	newTx := func(sender byte, nonce uint64, tip, feeCap int64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Sender:    &common.Address{sender},
+			NonceKey:  big.NewInt(0),
+			Nonce:     nonce,
+			Gas:       10_000,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(feeCap),
+		})
+	}
+	var (
+		cheap     = newTx(1, 0, 1, 99)
+		following = newTx(1, 1, 50, 200)
+		rich      = newTx(2, 0, 50, 200)
+	)
+	for i, err := range pool.Add([]*types.Transaction{cheap, following, rich}, false, false) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	expectStatuses := func(want map[*types.Transaction]txpool.TxStatus) {
+		t.Helper()
+		for tx, status := range want {
+			if have := pool.Status(tx.Hash()); have != status {
+				t.Errorf("tx %v: status mismatch: have %v, want %v", tx.Hash(), have, status)
+			}
+		}
+	}
+	// Transactions not covering the base fee are queued, along with the following ones of their lane
+	expectStatuses(map[*types.Transaction]txpool.TxStatus{cheap: txpool.TxStatusQueued, following: txpool.TxStatusQueued, rich: txpool.TxStatusPending})
+
+	// A dropping base fee promotes them
+	next := &types.Header{Difficulty: common.Big0, Number: big.NewInt(1), GasLimit: testGasLimit, BaseFee: big.NewInt(100), ParentHash: head.Hash()}
+	pool.Reset(head, next)
+	expectStatuses(map[*types.Transaction]txpool.TxStatus{cheap: txpool.TxStatusPending, following: txpool.TxStatusPending, rich: txpool.TxStatusPending})
+
+	// A raised minimum tip demotes the transactions below it, and a lowered one promotes them back
+	pool.SetGasTip(big.NewInt(20))
+	expectStatuses(map[*types.Transaction]txpool.TxStatus{cheap: txpool.TxStatusQueued, following: txpool.TxStatusQueued, rich: txpool.TxStatusPending})
+	if bundle, _ := pool.PendingRip7560Bundle(); bundle == nil || len(bundle.Transactions) != 1 || bundle.Transactions[0] != rich {
+		t.Fatalf("pending bundle mismatch: have %v", bundle)
+	}
+	pool.SetGasTip(common.Big0)
+	expectStatuses(map[*types.Transaction]txpool.TxStatus{cheap: txpool.TxStatusPending, following: txpool.TxStatusPending, rich: txpool.TxStatusPending})
+}
+
+func TestNativePoolLimits(t *testing.T) {
+	var (
+		paymaster = common.Address{0xaa}
+		unlimited = NativeConfig{MaxTxs: 100, MaxSenderTxs: 100, MaxPaymasterTxs: 100, MaxValidationGas: 100_000_000}
+	)
+	newTx := func(sender byte, nonceKey int64, nonce uint64, tip int64, paymaster *common.Address) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            params.TestChainConfig.ChainID,
+			Sender:             &common.Address{sender},
+			NonceKey:           big.NewInt(nonceKey),
+			Nonce:              nonce,
+			Gas:                10_000,
+			ValidationGasLimit: 100_000,
+			GasTipCap:          big.NewInt(tip),
+			GasFeeCap:          big.NewInt(100),
+			Paymaster:          paymaster,
+		})
+	}
+	tests := []struct {
+		name    string
+		limit   func(config *NativeConfig)
+		pooled  []*types.Transaction
+		cheap   *types.Transaction // transaction rejected for not paying more than the pooled ones
+		better  *types.Transaction // transaction evicting the lowest paying pooled one
+		evicted *types.Transaction
+	}{
+		{
+			name:    "sender",
+			limit:   func(config *NativeConfig) { config.MaxSenderTxs = 2 },
+			pooled:  []*types.Transaction{newTx(1, 0, 0, 1, nil), newTx(1, 1, 0, 2, nil), newTx(2, 0, 0, 1, nil)},
+			cheap:   newTx(1, 2, 0, 1, nil),
+			better:  newTx(1, 2, 0, 3, nil),
+			evicted: newTx(1, 0, 0, 1, nil),
+		},
+		{
+			name:    "paymaster",
+			limit:   func(config *NativeConfig) { config.MaxPaymasterTxs = 1 },
+			pooled:  []*types.Transaction{newTx(1, 0, 0, 1, &paymaster), newTx(2, 0, 0, 1, nil)},
+			cheap:   newTx(3, 0, 0, 1, &paymaster),
+			better:  newTx(3, 0, 0, 2, &paymaster),
+			evicted: newTx(1, 0, 0, 1, &paymaster),
+		},
+		{
+			name:    "validation gas",
+			limit:   func(config *NativeConfig) { config.MaxValidationGas = 250_000 },
+			pooled:  []*types.Transaction{newTx(1, 0, 0, 2, nil), newTx(2, 0, 0, 1, nil)},
+			cheap:   newTx(3, 0, 0, 1, nil),
+			better:  newTx(3, 0, 0, 3, nil),
+			evicted: newTx(2, 0, 0, 1, nil),
+		},
+		{
+			// The lane of the new transaction is kept, and other lanes lose their latest nonces first
+			name:    "pool",
+			limit:   func(config *NativeConfig) { config.MaxTxs = 2 },
+			pooled:  []*types.Transaction{newTx(1, 0, 0, 1, nil), newTx(1, 0, 1, 1, nil)},
+			cheap:   newTx(1, 0, 2, 10, nil),
+			better:  newTx(2, 0, 0, 10, nil),
+			evicted: newTx(1, 0, 1, 1, nil),
+		},
+	}
+	for _, tt := range tests {
+		config := unlimited
+		tt.limit(&config)
+
+		chain := newTestBlockChain()
+		statedb, _ := state.New(types.EmptyRootHash, chain.states, nil)
+		statedb.SetCode(core.AA_NONCE_MANAGER, testNonceManagerCode)
+		root, _ := statedb.Commit(0, true)
+		genesis := &types.Header{Difficulty: common.Big0, BaseFee: big.NewInt(1), Number: big.NewInt(0), GasLimit: testGasLimit, Root: root}
+
+		pool := NewNative(config, chain, common.Address{})
+		pool.validate = func(head *types.Header, tx *types.Transaction, _ []*types.Transaction) (*validationAccesses, error) {
+			return nil, nil
+		}
+		if err := pool.Init(0, genesis, nil); err != nil {
+			t.Fatalf("%s: failed to init pool: %v", tt.name, err)
+		}
+		for i, err := range pool.Add(tt.pooled, false, false) {
+			if err != nil {
+				t.Fatalf("%s: tx %d: failed to add transaction: %v", tt.name, i, err)
+			}
+		}
+		events := make(chan core.Rip7560TxStatusEvent, 16)
+		sub := pool.SubscribeRip7560TxStatus(events)
+
+		if err := pool.Add([]*types.Transaction{tt.cheap}, false, false)[0]; !errors.Is(err, txpool.ErrUnderpriced) || !strings.Contains(err.Error(), tt.name) {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, txpool.ErrUnderpriced)
+		}
+		if err := pool.Add([]*types.Transaction{tt.better}, false, false)[0]; err != nil {
+			t.Fatalf("%s: failed to add better paying transaction: %v", tt.name, err)
+		}
+		expectStatus(t, events, []*types.Transaction{tt.evicted}, core.Rip7560TxDropped)
+		expectStatus(t, events, []*types.Transaction{tt.better}, core.Rip7560TxAccepted)
+		if pool.Has(tt.evicted.Hash()) {
+			t.Errorf("%s: evicted transaction still pooled", tt.name)
+		}
+		sub.Unsubscribe()
+	}
+}
@@ -0,0 +1,105 @@
+package rip7560pool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"time"
+)
+
+// inclusionLatencyTimer measures the time from RIP-7560 transactions being first seen
+// by the pool to their inclusion. The breakdown by bundler and paymaster is only kept
+// in memory, as the bundler IDs are chosen by the bundlers.
+var inclusionLatencyTimer = metrics.NewRegisteredTimer("txpool/rip7560/inclusion/latency", nil)
+
+// seenTx is an RIP-7560 transaction pending inclusion, tracked for latency statistics.
+type seenTx struct {
+	time      time.Time
+	bundlerId string
+	paymaster common.Address
+}
+
+// inclusionStats tracks the inclusion latency of RIP-7560 transactions. It is not
+// thread safe and relies on the pool lock.
+type inclusionStats struct {
+	seen       map[common.Hash]*seenTx
+	bundlers   map[string]*types.Rip7560InclusionLatency
+	paymasters map[common.Address]*types.Rip7560InclusionLatency
+}
+
+func newInclusionStats() *inclusionStats {
+	return &inclusionStats{
+		seen:       make(map[common.Hash]*seenTx),
+		bundlers:   make(map[string]*types.Rip7560InclusionLatency),
+		paymasters: make(map[common.Address]*types.Rip7560InclusionLatency),
+	}
+}
+
+// markSeen records the time the transactions of a bundle were first seen.
+func (s *inclusionStats) markSeen(bundle *types.ExternallyReceivedBundle, now time.Time) {
+	for _, tx := range bundle.Transactions {
+		if _, ok := s.seen[tx.Hash()]; ok {
+			continue
+		}
+		var paymaster common.Address
+		if tx.Type() == types.Rip7560Type && tx.Rip7560TransactionData().Paymaster != nil {
+			paymaster = *tx.Rip7560TransactionData().Paymaster
+		}
+		s.seen[tx.Hash()] = &seenTx{time: now, bundlerId: bundle.BundlerId, paymaster: paymaster}
+	}
+}
+
+// markIncluded accounts the inclusion latency of a transaction, if it was seen before.
+func (s *inclusionStats) markIncluded(hash common.Hash, now time.Time) {
+	seen, ok := s.seen[hash]
+	if !ok {
+		return
+	}
+	delete(s.seen, hash)
+
+	latency := now.Sub(seen.time)
+	inclusionLatencyTimer.Update(latency)
+
+	if s.bundlers[seen.bundlerId] == nil {
+		s.bundlers[seen.bundlerId] = new(types.Rip7560InclusionLatency)
+	}
+	s.bundlers[seen.bundlerId].Add(latency)
+
+	if s.paymasters[seen.paymaster] == nil {
+		s.paymasters[seen.paymaster] = new(types.Rip7560InclusionLatency)
+	}
+	s.paymasters[seen.paymaster].Add(latency)
+}
+
+// forget stops tracking transactions dropped from the pool.
+func (s *inclusionStats) forget(txs []*types.Transaction) {
+	for _, tx := range txs {
+		delete(s.seen, tx.Hash())
+	}
+}
+
+// snapshot returns a copy of the aggregated statistics.
+func (s *inclusionStats) snapshot() *types.Rip7560InclusionStats {
+	stats := &types.Rip7560InclusionStats{
+		Bundlers:   make(map[string]*types.Rip7560InclusionLatency, len(s.bundlers)),
+		Paymasters: make(map[common.Address]*types.Rip7560InclusionLatency, len(s.paymasters)),
+	}
+	for id, latency := range s.bundlers {
+		cpy := *latency
+		stats.Bundlers[id] = &cpy
+	}
+	for paymaster, latency := range s.paymasters {
+		cpy := *latency
+		stats.Paymasters[paymaster] = &cpy
+	}
+	return stats
+}
+
+// Rip7560InclusionStats returns the inclusion latency statistics of the transactions
+// submitted to the pool.
+func (pool *Rip7560BundlerPool) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.inclusionStats.snapshot()
+}
@@ -166,6 +166,13 @@ type SubPool interface {
 	// RIP-7560 specific subpool functions, other subpools should ignore these
 
 	SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error
+	CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error
+	ExtendRip7560Bundle(hash common.Hash, validUntil *big.Int) error
+	CancelRip7560Bundle(hash common.Hash) (*types.BundleReceipt, error)
 	GetRip7560BundleStatus(hash common.Hash) (*types.BundleReceipt, error)
 	PendingRip7560Bundle() (*types.ExternallyReceivedBundle, error)
+	PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error)
+	SubscribeRip7560TxStatus(ch chan<- core.Rip7560TxStatusEvent) event.Subscription
+	ReportRip7560TxsDropped(infos []*types.Rip7560TransactionDebugInfo)
+	Rip7560InclusionStats() *types.Rip7560InclusionStats
 }
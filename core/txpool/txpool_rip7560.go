@@ -1,8 +1,12 @@
 package txpool
 
 import (
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"math/big"
 )
 
 // SubmitRip7560Bundle inserts the entire bundle of Type 4 transactions into the relevant pool.
@@ -17,6 +21,38 @@ func (p *TxPool) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) err
 	return nil
 }
 
+// CheckRip7560Bundle runs the admission checks of the pools on the bundle without submitting it.
+func (p *TxPool) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	for _, subpool := range p.subpools {
+		if err := subpool.CheckRip7560Bundle(bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtendRip7560Bundle extends the validity window of a pending bundle of Type 4 transactions.
+func (p *TxPool) ExtendRip7560Bundle(hash common.Hash, validUntil *big.Int) error {
+	for _, subpool := range p.subpools {
+		if err := subpool.ExtendRip7560Bundle(hash, validUntil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelRip7560Bundle removes a pending bundle of Type 4 transactions from the pool,
+// returning the status of the bundle afterwards.
+func (p *TxPool) CancelRip7560Bundle(hash common.Hash) (*types.BundleReceipt, error) {
+	for _, subpool := range p.subpools {
+		receipt, err := subpool.CancelRip7560Bundle(hash)
+		if err != nil || receipt != nil {
+			return receipt, err
+		}
+	}
+	return nil, fmt.Errorf("unknown bundle %x", hash)
+}
+
 func (p *TxPool) GetRip7560BundleStatus(hash common.Hash) (*types.BundleReceipt, error) {
 	// todo: we cannot 'filter-out' the AA pool so just passing to all pools - only AA pool has code in SubmitBundle
 	for _, subpool := range p.subpools {
@@ -44,3 +80,47 @@ func (p *TxPool) PendingRip7560Bundle() (*types.ExternallyReceivedBundle, error)
 	}
 	return nil, nil
 }
+
+// PendingRip7560Bundles returns the bundles of Type 4 transactions proposed by all pools
+// for the next block, in the order of the pools.
+func (p *TxPool) PendingRip7560Bundles() ([]*types.ExternallyReceivedBundle, error) {
+	var bundles []*types.ExternallyReceivedBundle
+	for _, subpool := range p.subpools {
+		pending, err := subpool.PendingRip7560Bundles()
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, pending...)
+	}
+	return bundles, nil
+}
+
+// SubscribeRip7560TxStatus subscribes to lifecycle events of RIP-7560 transactions.
+func (p *TxPool) SubscribeRip7560TxStatus(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	subs := make([]event.Subscription, len(p.subpools))
+	for i, subpool := range p.subpools {
+		subs[i] = subpool.SubscribeRip7560TxStatus(ch)
+	}
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
+// ReportRip7560TxsDropped notifies the pools that the given RIP-7560 transactions were dropped
+// from the block being built.
+func (p *TxPool) ReportRip7560TxsDropped(infos []*types.Rip7560TransactionDebugInfo) {
+	if len(infos) == 0 {
+		return
+	}
+	for _, subpool := range p.subpools {
+		subpool.ReportRip7560TxsDropped(infos)
+	}
+}
+
+// Rip7560InclusionStats returns the inclusion latency statistics of RIP-7560 transactions.
+func (p *TxPool) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	for _, subpool := range p.subpools {
+		if stats := subpool.Rip7560InclusionStats(); stats != nil {
+			return stats
+		}
+	}
+	return nil
+}
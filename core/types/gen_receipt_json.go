@@ -16,30 +16,36 @@ var _ = (*receiptMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (r Receipt) MarshalJSON() ([]byte, error) {
 	type Receipt struct {
-		Type                  hexutil.Uint64  `json:"type,omitempty"`
-		PostState             hexutil.Bytes   `json:"root"`
-		Status                hexutil.Uint64  `json:"status"`
-		CumulativeGasUsed     hexutil.Uint64  `json:"cumulativeGasUsed" gencodec:"required"`
-		Bloom                 Bloom           `json:"logsBloom"         gencodec:"required"`
-		Logs                  []*Log          `json:"logs"              gencodec:"required"`
-		TxHash                common.Hash     `json:"transactionHash" gencodec:"required"`
-		ContractAddress       common.Address  `json:"contractAddress"`
-		GasUsed               hexutil.Uint64  `json:"gasUsed" gencodec:"required"`
-		EffectiveGasPrice     *hexutil.Big    `json:"effectiveGasPrice"`
-		BlobGasUsed           hexutil.Uint64  `json:"blobGasUsed,omitempty"`
-		BlobGasPrice          *hexutil.Big    `json:"blobGasPrice,omitempty"`
-		DepositNonce          *hexutil.Uint64 `json:"depositNonce,omitempty"`
-		DepositReceiptVersion *hexutil.Uint64 `json:"depositReceiptVersion,omitempty"`
-		BlockHash             common.Hash     `json:"blockHash,omitempty"`
-		BlockNumber           *hexutil.Big    `json:"blockNumber,omitempty"`
-		TransactionIndex      hexutil.Uint    `json:"transactionIndex"`
-		L1GasPrice            *hexutil.Big    `json:"l1GasPrice,omitempty"`
-		L1BlobBaseFee         *hexutil.Big    `json:"l1BlobBaseFee,omitempty"`
-		L1GasUsed             *hexutil.Big    `json:"l1GasUsed,omitempty"`
-		L1Fee                 *hexutil.Big    `json:"l1Fee,omitempty"`
-		FeeScalar             *big.Float      `json:"l1FeeScalar,omitempty"`
-		L1BaseFeeScalar       *hexutil.Uint64 `json:"l1BaseFeeScalar,omitempty"`
-		L1BlobBaseFeeScalar   *hexutil.Uint64 `json:"l1BlobBaseFeeScalar,omitempty"`
+		Type                   hexutil.Uint64         `json:"type,omitempty"`
+		PostState              hexutil.Bytes          `json:"root"`
+		Status                 hexutil.Uint64         `json:"status"`
+		CumulativeGasUsed      hexutil.Uint64         `json:"cumulativeGasUsed" gencodec:"required"`
+		Bloom                  Bloom                  `json:"logsBloom"         gencodec:"required"`
+		Logs                   []*Log                 `json:"logs"              gencodec:"required"`
+		TxHash                 common.Hash            `json:"transactionHash" gencodec:"required"`
+		ContractAddress        common.Address         `json:"contractAddress"`
+		GasUsed                hexutil.Uint64         `json:"gasUsed" gencodec:"required"`
+		EffectiveGasPrice      *hexutil.Big           `json:"effectiveGasPrice"`
+		BlobGasUsed            hexutil.Uint64         `json:"blobGasUsed,omitempty"`
+		BlobGasPrice           *hexutil.Big           `json:"blobGasPrice,omitempty"`
+		DepositNonce           *hexutil.Uint64        `json:"depositNonce,omitempty"`
+		DepositReceiptVersion  *hexutil.Uint64        `json:"depositReceiptVersion,omitempty"`
+		BlockHash              common.Hash            `json:"blockHash,omitempty"`
+		BlockNumber            *hexutil.Big           `json:"blockNumber,omitempty"`
+		TransactionIndex       hexutil.Uint           `json:"transactionIndex"`
+		L1GasPrice             *hexutil.Big           `json:"l1GasPrice,omitempty"`
+		L1BlobBaseFee          *hexutil.Big           `json:"l1BlobBaseFee,omitempty"`
+		L1GasUsed              *hexutil.Big           `json:"l1GasUsed,omitempty"`
+		L1Fee                  *hexutil.Big           `json:"l1Fee,omitempty"`
+		FeeScalar              *big.Float             `json:"l1FeeScalar,omitempty"`
+		L1BaseFeeScalar        *hexutil.Uint64        `json:"l1BaseFeeScalar,omitempty"`
+		L1BlobBaseFeeScalar    *hexutil.Uint64        `json:"l1BlobBaseFeeScalar,omitempty"`
+		Rip7560GasAttribution  *Rip7560GasAttribution `json:"gasAttribution,omitempty"`
+		Rip7560Validity        *Rip7560Validity       `json:"validity,omitempty"`
+		Rip7560FrameGasUsed    *Rip7560FrameGasUsed   `json:"frameGasUsed,omitempty"`
+		Rip7560ExecutionStatus *hexutil.Uint64        `json:"executionStatus,omitempty"`
+		Rip7560BuilderFee      *hexutil.Big           `json:"builderFee,omitempty"`
+		Rip7560ReceiptVersion  *hexutil.Uint64        `json:"rip7560ReceiptVersion,omitempty"`
 	}
 	var enc Receipt
 	enc.Type = hexutil.Uint64(r.Type)
@@ -66,36 +72,48 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 	enc.FeeScalar = r.FeeScalar
 	enc.L1BaseFeeScalar = (*hexutil.Uint64)(r.L1BaseFeeScalar)
 	enc.L1BlobBaseFeeScalar = (*hexutil.Uint64)(r.L1BlobBaseFeeScalar)
+	enc.Rip7560GasAttribution = r.Rip7560GasAttribution
+	enc.Rip7560Validity = r.Rip7560Validity
+	enc.Rip7560FrameGasUsed = r.Rip7560FrameGasUsed
+	enc.Rip7560ExecutionStatus = (*hexutil.Uint64)(r.Rip7560ExecutionStatus)
+	enc.Rip7560BuilderFee = (*hexutil.Big)(r.Rip7560BuilderFee)
+	enc.Rip7560ReceiptVersion = (*hexutil.Uint64)(r.Rip7560ReceiptVersion)
 	return json.Marshal(&enc)
 }
 
 // UnmarshalJSON unmarshals from JSON.
 func (r *Receipt) UnmarshalJSON(input []byte) error {
 	type Receipt struct {
-		Type                  *hexutil.Uint64 `json:"type,omitempty"`
-		PostState             *hexutil.Bytes  `json:"root"`
-		Status                *hexutil.Uint64 `json:"status"`
-		CumulativeGasUsed     *hexutil.Uint64 `json:"cumulativeGasUsed" gencodec:"required"`
-		Bloom                 *Bloom          `json:"logsBloom"         gencodec:"required"`
-		Logs                  []*Log          `json:"logs"              gencodec:"required"`
-		TxHash                *common.Hash    `json:"transactionHash" gencodec:"required"`
-		ContractAddress       *common.Address `json:"contractAddress"`
-		GasUsed               *hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
-		EffectiveGasPrice     *hexutil.Big    `json:"effectiveGasPrice"`
-		BlobGasUsed           *hexutil.Uint64 `json:"blobGasUsed,omitempty"`
-		BlobGasPrice          *hexutil.Big    `json:"blobGasPrice,omitempty"`
-		DepositNonce          *hexutil.Uint64 `json:"depositNonce,omitempty"`
-		DepositReceiptVersion *hexutil.Uint64 `json:"depositReceiptVersion,omitempty"`
-		BlockHash             *common.Hash    `json:"blockHash,omitempty"`
-		BlockNumber           *hexutil.Big    `json:"blockNumber,omitempty"`
-		TransactionIndex      *hexutil.Uint   `json:"transactionIndex"`
-		L1GasPrice            *hexutil.Big    `json:"l1GasPrice,omitempty"`
-		L1BlobBaseFee         *hexutil.Big    `json:"l1BlobBaseFee,omitempty"`
-		L1GasUsed             *hexutil.Big    `json:"l1GasUsed,omitempty"`
-		L1Fee                 *hexutil.Big    `json:"l1Fee,omitempty"`
-		FeeScalar             *big.Float      `json:"l1FeeScalar,omitempty"`
-		L1BaseFeeScalar       *hexutil.Uint64 `json:"l1BaseFeeScalar,omitempty"`
-		L1BlobBaseFeeScalar   *hexutil.Uint64 `json:"l1BlobBaseFeeScalar,omitempty"`
+		Type                   *hexutil.Uint64        `json:"type,omitempty"`
+		PostState              *hexutil.Bytes         `json:"root"`
+		Status                 *hexutil.Uint64        `json:"status"`
+		CumulativeGasUsed      *hexutil.Uint64        `json:"cumulativeGasUsed" gencodec:"required"`
+		Bloom                  *Bloom                 `json:"logsBloom"         gencodec:"required"`
+		Logs                   []*Log                 `json:"logs"              gencodec:"required"`
+		TxHash                 *common.Hash           `json:"transactionHash" gencodec:"required"`
+		ContractAddress        *common.Address        `json:"contractAddress"`
+		GasUsed                *hexutil.Uint64        `json:"gasUsed" gencodec:"required"`
+		EffectiveGasPrice      *hexutil.Big           `json:"effectiveGasPrice"`
+		BlobGasUsed            *hexutil.Uint64        `json:"blobGasUsed,omitempty"`
+		BlobGasPrice           *hexutil.Big           `json:"blobGasPrice,omitempty"`
+		DepositNonce           *hexutil.Uint64        `json:"depositNonce,omitempty"`
+		DepositReceiptVersion  *hexutil.Uint64        `json:"depositReceiptVersion,omitempty"`
+		BlockHash              *common.Hash           `json:"blockHash,omitempty"`
+		BlockNumber            *hexutil.Big           `json:"blockNumber,omitempty"`
+		TransactionIndex       *hexutil.Uint          `json:"transactionIndex"`
+		L1GasPrice             *hexutil.Big           `json:"l1GasPrice,omitempty"`
+		L1BlobBaseFee          *hexutil.Big           `json:"l1BlobBaseFee,omitempty"`
+		L1GasUsed              *hexutil.Big           `json:"l1GasUsed,omitempty"`
+		L1Fee                  *hexutil.Big           `json:"l1Fee,omitempty"`
+		FeeScalar              *big.Float             `json:"l1FeeScalar,omitempty"`
+		L1BaseFeeScalar        *hexutil.Uint64        `json:"l1BaseFeeScalar,omitempty"`
+		L1BlobBaseFeeScalar    *hexutil.Uint64        `json:"l1BlobBaseFeeScalar,omitempty"`
+		Rip7560GasAttribution  *Rip7560GasAttribution `json:"gasAttribution,omitempty"`
+		Rip7560Validity        *Rip7560Validity       `json:"validity,omitempty"`
+		Rip7560FrameGasUsed    *Rip7560FrameGasUsed   `json:"frameGasUsed,omitempty"`
+		Rip7560ExecutionStatus *hexutil.Uint64        `json:"executionStatus,omitempty"`
+		Rip7560BuilderFee      *hexutil.Big           `json:"builderFee,omitempty"`
+		Rip7560ReceiptVersion  *hexutil.Uint64        `json:"rip7560ReceiptVersion,omitempty"`
 	}
 	var dec Receipt
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -178,5 +196,23 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 	if dec.L1BlobBaseFeeScalar != nil {
 		r.L1BlobBaseFeeScalar = (*uint64)(dec.L1BlobBaseFeeScalar)
 	}
+	if dec.Rip7560GasAttribution != nil {
+		r.Rip7560GasAttribution = dec.Rip7560GasAttribution
+	}
+	if dec.Rip7560Validity != nil {
+		r.Rip7560Validity = dec.Rip7560Validity
+	}
+	if dec.Rip7560FrameGasUsed != nil {
+		r.Rip7560FrameGasUsed = dec.Rip7560FrameGasUsed
+	}
+	if dec.Rip7560ExecutionStatus != nil {
+		r.Rip7560ExecutionStatus = (*uint64)(dec.Rip7560ExecutionStatus)
+	}
+	if dec.Rip7560BuilderFee != nil {
+		r.Rip7560BuilderFee = (*big.Int)(dec.Rip7560BuilderFee)
+	}
+	if dec.Rip7560ReceiptVersion != nil {
+		r.Rip7560ReceiptVersion = (*uint64)(dec.Rip7560ReceiptVersion)
+	}
 	return nil
 }
@@ -49,6 +49,9 @@ const (
 
 	// The version number for post-canyon deposit receipts.
 	CanyonDepositReceiptVersion = uint64(1)
+
+	// The version number of the RIP-7560 receipts committed to the receipt root.
+	Rip7560ReceiptRootVersion = uint64(1)
 )
 
 // Receipt represents the results of a transaction.
@@ -92,6 +95,20 @@ type Receipt struct {
 	FeeScalar           *big.Float `json:"l1FeeScalar,omitempty"`         // Present from pre-bedrock to Ecotone. Nil after Ecotone
 	L1BaseFeeScalar     *uint64    `json:"l1BaseFeeScalar,omitempty"`     // Always nil prior to the Ecotone hardfork
 	L1BlobBaseFeeScalar *uint64    `json:"l1BlobBaseFeeScalar,omitempty"` // Always nil prior to the Ecotone hardfork
+
+	// RIP-7560: split of the used gas by the entity gas limit it was drawn from
+	Rip7560GasAttribution *Rip7560GasAttribution `json:"gasAttribution,omitempty"`
+	// RIP-7560: validity windows returned by the validation frames
+	Rip7560Validity *Rip7560Validity `json:"validity,omitempty"`
+	// RIP-7560: gas used by each frame of the transaction
+	Rip7560FrameGasUsed *Rip7560FrameGasUsed `json:"frameGasUsed,omitempty"`
+	// RIP-7560: execution status reported by the RIP7560TransactionEvent
+	Rip7560ExecutionStatus *uint64 `json:"executionStatus,omitempty"`
+	// RIP-7560: fee paid to the block builder on top of the gas cost, once charged
+	Rip7560BuilderFee *big.Int `json:"builderFee,omitempty"`
+	// RIP-7560: set once the receipt is committed to the receipt root rather than only its
+	// type. The state transition process ensures this is only set after the RIP7560ReceiptRoot fork.
+	Rip7560ReceiptVersion *uint64 `json:"rip7560ReceiptVersion,omitempty"`
 }
 
 type receiptMarshaling struct {
@@ -116,6 +133,11 @@ type receiptMarshaling struct {
 	L1BlobBaseFeeScalar   *hexutil.Uint64
 	DepositNonce          *hexutil.Uint64
 	DepositReceiptVersion *hexutil.Uint64
+
+	// RIP-7560
+	Rip7560ExecutionStatus *hexutil.Uint64
+	Rip7560BuilderFee      *hexutil.Big
+	Rip7560ReceiptVersion  *hexutil.Uint64
 }
 
 // receiptRLP is the consensus encoding of a receipt.
@@ -126,6 +148,16 @@ type receiptRLP struct {
 	Logs              []*Log
 }
 
+// rip7560ConsensusReceiptRLP is the consensus encoding of an RIP-7560 receipt. Receipts
+// before the RIP7560ReceiptRoot fork have no version, and encode like receiptRLP.
+type rip7560ConsensusReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             Bloom
+	Logs              []*Log
+	Version           *uint64 `rlp:"optional"`
+}
+
 type depositReceiptRLP struct {
 	PostStateOrStatus []byte
 	CumulativeGasUsed uint64
@@ -154,6 +186,27 @@ type storedReceiptRLP struct {
 	DepositReceiptVersion *uint64 `rlp:"optional"`
 }
 
+// rip7560StoredReceiptRLP is the storage encoding of an RIP-7560 transaction receipt. Its
+// non-consensus metadata is appended as a list in place of the deposit fields, which RIP-7560
+// receipts do not have. Receipts stored before the metadata was introduced decode as
+// storedReceiptRLP, without metadata.
+type rip7560StoredReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*Log
+	Rip7560           rip7560ReceiptRLP
+}
+
+// rip7560ReceiptRLP is the storage encoding of the RIP-7560 receipt metadata. New fields
+// have to be appended as optional for the receipts stored before them to still decode.
+type rip7560ReceiptRLP struct {
+	GasAttribution  *Rip7560GasAttribution `rlp:"nil"`
+	Validity        *Rip7560Validity       `rlp:"nil"`
+	FrameGasUsed    *Rip7560FrameGasUsed   `rlp:"nil"`
+	ExecutionStatus *uint64                `rlp:"optional"`
+	ReceiptVersion  *uint64                `rlp:"optional"`
+}
+
 // LegacyOptimismStoredReceiptRLP is the pre bedrock storage encoding of a
 // receipt. It will only exist in the database if it was migrated using the
 // migration tool. Nodes that sync using snap-sync will not have any of these
@@ -259,6 +312,9 @@ func (r *Receipt) encodeTyped(data *receiptRLP, w *bytes.Buffer) error {
 	case DepositTxType:
 		withNonce := &depositReceiptRLP{data.PostStateOrStatus, data.CumulativeGasUsed, data.Bloom, data.Logs, r.DepositNonce, r.DepositReceiptVersion}
 		return rlp.Encode(w, withNonce)
+	case Rip7560Type:
+		withVersion := &rip7560ConsensusReceiptRLP{data.PostStateOrStatus, data.CumulativeGasUsed, data.Bloom, data.Logs, r.Rip7560ReceiptVersion}
+		return rlp.Encode(w, withVersion)
 	default:
 		return rlp.Encode(w, data)
 	}
@@ -347,6 +403,15 @@ func (r *Receipt) decodeTyped(b []byte) error {
 		r.DepositNonce = data.DepositNonce
 		r.DepositReceiptVersion = data.DepositReceiptVersion
 		return r.setFromRLP(receiptRLP{data.PostStateOrStatus, data.CumulativeGasUsed, data.Bloom, data.Logs})
+	case Rip7560Type:
+		var data rip7560ConsensusReceiptRLP
+		err := rlp.DecodeBytes(b[1:], &data)
+		if err != nil {
+			return err
+		}
+		r.Type = b[0]
+		r.Rip7560ReceiptVersion = data.Version
+		return r.setFromRLP(receiptRLP{data.PostStateOrStatus, data.CumulativeGasUsed, data.Bloom, data.Logs})
 	default:
 		return ErrTxTypeNotSupported
 	}
@@ -415,6 +480,17 @@ func (r *ReceiptForStorage) EncodeRLP(_w io.Writer) error {
 		if r.DepositReceiptVersion != nil {
 			w.WriteUint64(*r.DepositReceiptVersion)
 		}
+	} else if r.Rip7560GasAttribution != nil || r.Rip7560Validity != nil || r.Rip7560FrameGasUsed != nil || r.Rip7560ExecutionStatus != nil || r.Rip7560ReceiptVersion != nil {
+		err := rlp.Encode(w, &rip7560ReceiptRLP{
+			GasAttribution:  r.Rip7560GasAttribution,
+			Validity:        r.Rip7560Validity,
+			FrameGasUsed:    r.Rip7560FrameGasUsed,
+			ExecutionStatus: r.Rip7560ExecutionStatus,
+			ReceiptVersion:  r.Rip7560ReceiptVersion,
+		})
+		if err != nil {
+			return err
+		}
 	}
 	w.ListEnd(outerList)
 	return w.Flush()
@@ -428,13 +504,36 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	if err != nil {
 		return err
 	}
-	// First try to decode the latest receipt database format, try the pre-bedrock Optimism legacy format otherwise.
+	// First try to decode the latest receipt database format, then the RIP-7560 one, and
+	// try the pre-bedrock Optimism legacy format otherwise.
 	if err := decodeStoredReceiptRLP(r, blob); err == nil {
 		return nil
 	}
+	if err := decodeRip7560StoredReceiptRLP(r, blob); err == nil {
+		return nil
+	}
 	return decodeLegacyOptimismReceiptRLP(r, blob)
 }
 
+func decodeRip7560StoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
+	var stored rip7560StoredReceiptRLP
+	if err := rlp.DecodeBytes(blob, &stored); err != nil {
+		return err
+	}
+	if err := (*Receipt)(r).setStatus(stored.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.CumulativeGasUsed = stored.CumulativeGasUsed
+	r.Logs = stored.Logs
+	r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
+	r.Rip7560GasAttribution = stored.Rip7560.GasAttribution
+	r.Rip7560Validity = stored.Rip7560.Validity
+	r.Rip7560FrameGasUsed = stored.Rip7560.FrameGasUsed
+	r.Rip7560ExecutionStatus = stored.Rip7560.ExecutionStatus
+	r.Rip7560ReceiptVersion = stored.Rip7560.ReceiptVersion
+	return nil
+}
+
 func decodeLegacyOptimismReceiptRLP(r *ReceiptForStorage, blob []byte) error {
 	var stored LegacyOptimismStoredReceiptRLP
 	if err := rlp.DecodeBytes(blob, &stored); err != nil {
@@ -492,7 +591,8 @@ func (rs Receipts) Len() int { return len(rs) }
 // EncodeIndex encodes the i'th receipt to w. For DepositTxType receipts with non-nil DepositNonce
 // but nil DepositReceiptVersion, the output will differ than calling r.MarshalBinary(); this
 // behavior difference should not be changed to preserve backwards compatibility of receipt-root
-// hash computation.
+// hash computation. The same holds for RIP-7560 receipts with a nil Rip7560ReceiptVersion,
+// which only contribute their type.
 func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	r := rs[i]
 	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
@@ -504,6 +604,11 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	switch r.Type {
 	case AccessListTxType, DynamicFeeTxType, BlobTxType:
 		rlp.Encode(w, data)
+	case Rip7560Type:
+		if r.Rip7560ReceiptVersion != nil {
+			// post-RIP7560ReceiptRoot receipt hash computation update
+			rlp.Encode(w, &rip7560ConsensusReceiptRLP{data.PostStateOrStatus, data.CumulativeGasUsed, r.Bloom, r.Logs, r.Rip7560ReceiptVersion})
+		}
 	case DepositTxType:
 		if r.DepositReceiptVersion != nil {
 			// post-canyon receipt hash computation update
@@ -546,8 +651,19 @@ func (rs Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, nu
 		rs[i].TransactionIndex = uint(i)
 
 		// The contract address can be derived from the transaction itself
-		// AA transactions always have "sender" as the account address, regardless if it is created by this TX
-		if txs[i].To() == nil && txs[i].Type() != Rip7560Type {
+		// AA transactions have "sender" as the contract address only if it is deployed by their deployer frame
+		if txs[i].Type() == Rip7560Type {
+			rs[i].ContractAddress = common.Address{}
+			if deployed := txs[i].Rip7560TransactionData().DeployedAddress(); deployed != nil {
+				rs[i].ContractAddress = *deployed
+			}
+			if aatx := txs[i].Rip7560TransactionData(); config.IsRIP7560BuilderFee(rs[i].BlockNumber) {
+				rs[i].Rip7560BuilderFee = new(big.Int)
+				if aatx.BuilderFee != nil {
+					rs[i].Rip7560BuilderFee.Set(aatx.BuilderFee)
+				}
+			}
+		} else if txs[i].To() == nil {
 			// Deriving the signer is expensive, only do if it's actually needed
 			from, _ := Sender(signer, txs[i])
 			nonce := txs[i].Nonce()
@@ -1030,6 +1030,116 @@ func TestRoundTripReceipt(t *testing.T) {
 	}
 }
 
+// TestRip7560ReceiptEncodeIndex checks that the receipt root commits to the logs of
+// RIP-7560 receipts after the RIP7560ReceiptRoot fork, including the events injected on
+// behalf of the EntryPoint, and only to their type before.
+func TestRip7560ReceiptEncodeIndex(t *testing.T) {
+	receipt := &Receipt{
+		Type:              Rip7560Type,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs:              []*Log{{Address: common.BytesToAddress([]byte{0x75, 0x60}), Topics: []common.Hash{common.HexToHash("dead")}, Data: []byte{0x01}}},
+	}
+	buf := new(bytes.Buffer)
+	Receipts{receipt}.EncodeIndex(0, buf)
+	require.Equal(t, []byte{Rip7560Type}, buf.Bytes())
+
+	// the consensus encoding of pre-fork receipts is unchanged
+	regularBytes, err := receipt.MarshalBinary()
+	require.NoError(t, err)
+	legacyBytes, err := rlp.EncodeToBytes(&receiptRLP{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{Rip7560Type}, legacyBytes...), regularBytes)
+
+	version := Rip7560ReceiptRootVersion
+	receipt.Rip7560ReceiptVersion = &version
+	buf.Reset()
+	Receipts{receipt}.EncodeIndex(0, buf)
+	regularBytes, err = receipt.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, regularBytes, buf.Bytes())
+
+	decoded := new(Receipt)
+	require.NoError(t, decoded.UnmarshalBinary(regularBytes))
+	require.Equal(t, receipt.Type, decoded.Type)
+	require.Equal(t, receipt.Logs, decoded.Logs)
+	require.Equal(t, receipt.Rip7560ReceiptVersion, decoded.Rip7560ReceiptVersion)
+
+	// an event differing only in its data keeps the bloom, so only the receipt root tells it apart
+	divergent := *receipt
+	divergent.Logs = []*Log{{Address: receipt.Logs[0].Address, Topics: receipt.Logs[0].Topics, Data: []byte{0x02}}}
+	buf.Reset()
+	Receipts{&divergent}.EncodeIndex(0, buf)
+	require.NotEqual(t, regularBytes, buf.Bytes())
+}
+
+func TestRoundTripRip7560ReceiptForStorage(t *testing.T) {
+	executionStatus, receiptVersion := uint64(2), Rip7560ReceiptRootVersion
+	logs := []*Log{{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{common.HexToHash("dead")}, Data: []byte{0x01}}}
+	tests := []struct {
+		name string
+		rcpt *Receipt
+	}{
+		{name: "NoMetadata", rcpt: &Receipt{Status: ReceiptStatusSuccessful, CumulativeGasUsed: 1, Logs: logs}},
+		{name: "Metadata", rcpt: &Receipt{
+			Status:                 ReceiptStatusFailed,
+			CumulativeGasUsed:      2,
+			Logs:                   logs,
+			Rip7560GasAttribution:  &Rip7560GasAttribution{Account: 1, PaymasterValidation: 2, Execution: 3, PostOp: 4, SystemEvents: 5, Refund: 6},
+			Rip7560Validity:        &Rip7560Validity{AccountValidAfter: 1, AccountValidUntil: 2, PaymasterValidAfter: 3, PaymasterValidUntil: 4},
+			Rip7560FrameGasUsed:    &Rip7560FrameGasUsed{Validation: 1, PaymasterValidation: 2, Deployment: 3, Execution: 4, PostOp: 5, CallData: 6},
+			Rip7560ExecutionStatus: &executionStatus,
+			Rip7560ReceiptVersion:  &receiptVersion,
+		}},
+		{name: "PartialMetadata", rcpt: &Receipt{
+			Status:            ReceiptStatusSuccessful,
+			CumulativeGasUsed: 3,
+			Logs:              logs,
+			Rip7560Validity:   &Rip7560Validity{},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := rlp.EncodeToBytes((*ReceiptForStorage)(test.rcpt))
+			require.NoError(t, err)
+
+			d := &ReceiptForStorage{}
+			err = rlp.DecodeBytes(data, d)
+			require.NoError(t, err)
+			require.Equal(t, test.rcpt.Status, d.Status)
+			require.Equal(t, test.rcpt.CumulativeGasUsed, d.CumulativeGasUsed)
+			require.Equal(t, test.rcpt.Logs, d.Logs)
+			require.Nil(t, d.DepositNonce)
+			require.Equal(t, test.rcpt.Rip7560GasAttribution, d.Rip7560GasAttribution)
+			require.Equal(t, test.rcpt.Rip7560Validity, d.Rip7560Validity)
+			require.Equal(t, test.rcpt.Rip7560FrameGasUsed, d.Rip7560FrameGasUsed)
+			require.Equal(t, test.rcpt.Rip7560ExecutionStatus, d.Rip7560ExecutionStatus)
+			require.Equal(t, test.rcpt.Rip7560ReceiptVersion, d.Rip7560ReceiptVersion)
+		})
+	}
+}
+
+// TestRip7560DeriveContractAddress checks that the derived contract address of AA
+// transactions follows the rule of the state processor: the sender, if deployed.
+func TestRip7560DeriveContractAddress(t *testing.T) {
+	sender, deployer := common.Address{0x01}, common.Address{0x02}
+	newTx := func(deployer *common.Address) *Transaction {
+		return NewTx(&Rip7560AccountAbstractionTx{
+			ChainID:   big.NewInt(1),
+			Sender:    &sender,
+			Deployer:  deployer,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		})
+	}
+	txs := Transactions{newTx(nil), newTx(&deployer)}
+	receipts := Receipts{{Type: Rip7560Type, Logs: []*Log{}}, {Type: Rip7560Type, Logs: []*Log{}}}
+	err := receipts.DeriveFields(params.TestChainConfig, common.Hash{1}, 1, 1, big.NewInt(1), nil, txs)
+	require.NoError(t, err)
+	require.Equal(t, common.Address{}, receipts[0].ContractAddress)
+	require.Equal(t, sender, receipts[1].ContractAddress)
+}
+
 func TestRoundTripReceiptForStorage(t *testing.T) {
 	tests := []struct {
 		name string
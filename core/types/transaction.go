@@ -364,7 +364,11 @@ func (tx *Transaction) IsSystemTx() bool {
 }
 
 // Cost returns (gas * gasPrice) + (blobGas * blobGasPrice) + value.
+// For RIP-7560 transactions, it returns (total gas limit * gasFeeCap) + builderFee.
 func (tx *Transaction) Cost() *big.Int {
+	if aatx, ok := tx.inner.(*Rip7560AccountAbstractionTx); ok {
+		return aatx.Cost()
+	}
 	total := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
 	if tx.Type() == BlobTxType {
 		total.Add(total, new(big.Int).Mul(tx.BlobGasFeeCap(), new(big.Int).SetUint64(tx.BlobGas())))
@@ -596,9 +600,17 @@ func (tx *Transaction) Size() uint64 {
 
 	// Cache miss, encode and cache.
 	// Note we rely on the assumption that all tx.inner values are RLP-encoded!
-	c := writeCounter(0)
-	rlp.Encode(&c, &tx.inner)
-	size := uint64(c)
+	var size uint64
+	if aatx, ok := tx.inner.(*Rip7560AccountAbstractionTx); ok {
+		// The encoding of RIP-7560 transactions omits the zero paymaster and deployer addresses.
+		var buf bytes.Buffer
+		aatx.encode(&buf)
+		size = uint64(buf.Len())
+	} else {
+		c := writeCounter(0)
+		rlp.Encode(&c, &tx.inner)
+		size = uint64(c)
+	}
 
 	// For blob transactions, add the size of the blob content and the outer list of the
 	// tx + sidecar encoding.
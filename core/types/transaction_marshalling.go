@@ -79,6 +79,9 @@ type txJSON struct {
 
 	// RIP 7712 additional transaction field
 	NonceKey *hexutil.Big `json:"nonceKey,omitempty"`
+
+	// EIP-7702 authorizations of RIP 7560 transactions
+	AuthorizationList []SetCodeAuthorization `json:"authorizationList,omitempty"`
 }
 
 // yParityValue returns the YParity value from JSON. For backwards-compatibility reasons,
@@ -199,6 +202,7 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 		enc.AccessList = &itx.AccessList
 		enc.Sender = itx.Sender
 		enc.AuthorizationData = (*hexutil.Bytes)(&itx.AuthorizationData)
+		enc.AuthorizationList = itx.AuthorizationList
 		enc.ExecutionData = (*hexutil.Bytes)(&itx.ExecutionData)
 		enc.Paymaster = itx.Paymaster
 		enc.PaymasterData = (*hexutil.Bytes)(&itx.PaymasterData)
@@ -545,12 +549,15 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 			itx.AccessList = *dec.AccessList
 		}
 		if dec.Sender == nil {
-			return errors.New("sender value must not be nil")
+			return errors.New("missing required field 'sender' in transaction")
 		}
 		itx.Sender = dec.Sender
 		if dec.AuthorizationData != nil {
 			itx.AuthorizationData = *dec.AuthorizationData
 		}
+		if len(dec.AuthorizationList) != 0 {
+			itx.AuthorizationList = dec.AuthorizationList
+		}
 		if dec.Paymaster != nil {
 			itx.Paymaster = dec.Paymaster
 		}
@@ -563,7 +570,8 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		if dec.DeployerData != nil {
 			itx.DeployerData = *dec.DeployerData
 		}
-		if dec.NonceKey == nil {
+		itx.NonceKey = new(big.Int)
+		if dec.NonceKey != nil {
 			itx.NonceKey = (*big.Int)(dec.NonceKey)
 		}
 		if dec.BuilderFee == nil {
@@ -571,7 +579,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		}
 		itx.BuilderFee = (*big.Int)(dec.BuilderFee)
 		if dec.ValidationGas == nil {
-			return errors.New("missing required field 'validationGas' for txdata")
+			return errors.New("missing required field 'verificationGasLimit' for txdata")
 		}
 		itx.ValidationGasLimit = uint64(*dec.ValidationGas)
 		if dec.PaymasterGas != nil {
@@ -584,8 +592,8 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 			return errors.New("missing required field 'nonce' in transaction")
 		}
 		itx.Nonce = uint64(*dec.Nonce)
-		if dec.Value == nil {
-			return errors.New("missing required field 'value' in transaction")
+		if dec.Value != nil && dec.Value.ToInt().Sign() != 0 {
+			return errors.New("RIP-7560 transaction cannot have a 'value'")
 		}
 	default:
 		return ErrTxTypeNotSupported
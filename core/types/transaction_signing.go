@@ -40,10 +40,11 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int, blockTime uint64) Signer {
 	var signer Signer
 	switch {
-	case config.IsRIP7560(blockNumber):
+	case config.IsRIP7560(blockNumber, blockTime) && config.IsCancun(blockNumber, blockTime) && !config.IsOptimism():
+		signer = newCancunRIP7560Signer(config.ChainID)
+	case config.IsRIP7560(blockNumber, blockTime):
 		signer = NewRIP7560Signer(config.ChainID)
 	case config.IsCancun(blockNumber, blockTime) && !config.IsOptimism():
-	case config.IsCancun(blockNumber, blockTime):
 		signer = NewCancunSigner(config.ChainID)
 	case config.IsLondon(blockNumber):
 		signer = NewLondonSigner(config.ChainID)
@@ -68,6 +69,12 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int, blockTime uint
 // have the current block number available, use MakeSigner instead.
 func LatestSigner(config *params.ChainConfig) Signer {
 	if config.ChainID != nil {
+		if config.RIP7560Block != nil || config.RIP7560Time != nil {
+			if config.CancunTime != nil && !config.IsOptimism() {
+				return newCancunRIP7560Signer(config.ChainID)
+			}
+			return NewRIP7560Signer(config.ChainID)
+		}
 		if config.CancunTime != nil && !config.IsOptimism() {
 			return NewCancunSigner(config.ChainID)
 		}
@@ -5,47 +5,107 @@ import (
 	"math/big"
 )
 
-type rip7560Signer struct{ londonSigner }
+// Rip7560SigningDomain is the EntryPoint bound into the signing hash of RIP-7560
+// transactions, so that a signature cannot be replayed against another EntryPoint
+// or ABI version.
+type Rip7560SigningDomain struct {
+	EntryPoint common.Address
+	AbiVersion uint64
+}
+
+// rip7560Signer computes the signing hash of RIP-7560 transactions and delegates
+// the other transaction types to the signer of the active fork.
+type rip7560Signer struct {
+	Signer                       // signer of the other transaction types
+	domain *Rip7560SigningDomain // nil if the signing hash is not bound to an EntryPoint
+}
 
+// NewRIP7560Signer returns a signer that accepts
+// - RIP-7560 account abstraction transactions, and
+// - the transaction types accepted by the London signer.
 func NewRIP7560Signer(chainId *big.Int) Signer {
-	return rip7560Signer{londonSigner{eip2930Signer{NewEIP155Signer(chainId)}}}
+	return rip7560Signer{Signer: NewLondonSigner(chainId)}
+}
+
+// NewRIP7560DomainSigner returns a signer binding the given EntryPoint domain into
+// the signing hash of RIP-7560 transactions, along with the chain ID.
+func NewRIP7560DomainSigner(chainId *big.Int, domain Rip7560SigningDomain) Signer {
+	return rip7560Signer{Signer: NewLondonSigner(chainId), domain: &domain}
+}
+
+// newCancunRIP7560Signer returns an RIP-7560 signer which also accepts EIP-4844 blob
+// transactions.
+func newCancunRIP7560Signer(chainId *big.Int) Signer {
+	return rip7560Signer{Signer: NewCancunSigner(chainId)}
+}
+
+func (s rip7560Signer) Equal(s2 Signer) bool {
+	x, ok := s2.(rip7560Signer)
+	if !ok || !x.Signer.Equal(s.Signer) || (x.domain == nil) != (s.domain == nil) {
+		return false
+	}
+	return x.domain == nil || *x.domain == *s.domain
 }
 
+// Sender returns the sender account of an RIP-7560 transaction. Its authorization is
+// verified by the validation frame rather than by an ECDSA signature.
 func (s rip7560Signer) Sender(tx *Transaction) (common.Address, error) {
 	if tx.Type() != Rip7560Type {
-		return s.londonSigner.Sender(tx)
+		return s.Signer.Sender(tx)
+	}
+	sender := tx.Rip7560TransactionData().Sender
+	if sender == nil {
+		return common.Address{}, ErrInvalidSig
 	}
-	return [20]byte{}, nil
+	return *sender, nil
+}
+
+// SignatureValues returns an error for RIP-7560 transactions, which do not carry an
+// ECDSA signature.
+func (s rip7560Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != Rip7560Type {
+		return s.Signer.SignatureValues(tx, sig)
+	}
+	return nil, nil, nil, ErrTxTypeNotSupported
 }
 
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s rip7560Signer) Hash(tx *Transaction) common.Hash {
 	if tx.Type() != Rip7560Type {
-		return s.londonSigner.Hash(tx)
+		return s.Signer.Hash(tx)
 	}
 	aatx := tx.Rip7560TransactionData()
-	return prefixedRlpHash(
-		tx.Type(),
-		[]interface{}{
-			s.chainId,
-			aatx.Nonce,
-			aatx.NonceKey,
-			aatx.Sender,
-			aatx.Deployer,
-			aatx.DeployerData,
-			aatx.Paymaster,
-			aatx.PaymasterData,
-			aatx.ExecutionData,
-			aatx.BuilderFee,
-			tx.GasTipCap(),
-			tx.GasFeeCap(),
-			aatx.ValidationGasLimit,
-			aatx.PaymasterValidationGasLimit,
-			aatx.PostOpGas,
-			tx.Gas(),
-			tx.AccessList(),
-
-			// no AuthorizationData here - this is hashing "for signing"
-		})
+	var fields []interface{}
+	if s.domain != nil {
+		fields = []interface{}{s.ChainID(), s.domain.EntryPoint, s.domain.AbiVersion}
+	} else {
+		fields = []interface{}{s.ChainID()}
+	}
+	fields = append(fields,
+		aatx.Nonce,
+		aatx.NonceKey,
+		aatx.Sender,
+		aatx.Deployer,
+		aatx.DeployerData,
+		aatx.Paymaster,
+		aatx.PaymasterData,
+		aatx.ExecutionData,
+		aatx.BuilderFee,
+		tx.GasTipCap(),
+		tx.GasFeeCap(),
+		aatx.ValidationGasLimit,
+		aatx.PaymasterValidationGasLimit,
+		aatx.PostOpGas,
+		tx.Gas(),
+		tx.AccessList(),
+
+		// no AuthorizationData here - this is hashing "for signing"
+	)
+	// the authorization list is only hashed if present, leaving the signing hash of the
+	// transactions without authorizations unchanged
+	if len(aatx.AuthorizationList) != 0 {
+		fields = append(fields, aatx.AuthorizationList)
+	}
+	return prefixedRlpHash(tx.Type(), fields)
 }
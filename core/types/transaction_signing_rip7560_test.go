@@ -0,0 +1,147 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"testing"
+)
+
+func TestRip7560SigningDomain(t *testing.T) {
+	sender := common.Address{0x01}
+	tx := NewTx(&Rip7560AccountAbstractionTx{
+		ChainID:   big.NewInt(1),
+		Sender:    &sender,
+		NonceKey:  big.NewInt(0),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+	})
+	var (
+		domain   = Rip7560SigningDomain{EntryPoint: common.Address{0x75, 0x60}}
+		upgraded = Rip7560SigningDomain{EntryPoint: common.Address{0x75, 0x60}, AbiVersion: 1}
+		moved    = Rip7560SigningDomain{EntryPoint: common.Address{0x75, 0x61}}
+	)
+	signers := []Signer{
+		NewRIP7560Signer(big.NewInt(1)),
+		NewRIP7560DomainSigner(big.NewInt(1), domain),
+		NewRIP7560DomainSigner(big.NewInt(1), upgraded),
+		NewRIP7560DomainSigner(big.NewInt(1), moved),
+		NewRIP7560DomainSigner(big.NewInt(2), domain),
+	}
+	// every domain parameter changes the signing hash, so signatures cannot be replayed
+	hashes := make(map[common.Hash]int)
+	for i, signer := range signers {
+		hash := signer.Hash(tx)
+		if j, ok := hashes[hash]; ok {
+			t.Errorf("signers %d and %d have the same signing hash %x", j, i, hash)
+		}
+		hashes[hash] = i
+
+		for j, other := range signers {
+			if have := signer.Equal(other); have != (i == j) {
+				t.Errorf("signers %d and %d equality mismatch: have %v, want %v", i, j, have, i == j)
+			}
+		}
+	}
+	if !NewRIP7560DomainSigner(big.NewInt(1), domain).Equal(signers[1]) {
+		t.Error("signers of the same domain are not equal")
+	}
+	// other transaction types are not affected by the domain
+	legacy := NewTx(&DynamicFeeTx{ChainID: big.NewInt(1), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)})
+	if signers[0].Hash(legacy) != signers[1].Hash(legacy) {
+		t.Error("domain changes the signing hash of a non RIP-7560 transaction")
+	}
+}
+
+func newRip7560SigningTestTx() *Rip7560AccountAbstractionTx {
+	var (
+		sender    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		paymaster = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		deployer  = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	)
+	return &Rip7560AccountAbstractionTx{
+		ChainID:                     big.NewInt(1),
+		Nonce:                       1,
+		GasTipCap:                   big.NewInt(2),
+		GasFeeCap:                   big.NewInt(3),
+		Gas:                         100000,
+		AccessList:                  AccessList{{Address: sender, StorageKeys: []common.Hash{{0x01}}}},
+		Sender:                      &sender,
+		AuthorizationData:           []byte{0xaa},
+		ExecutionData:               []byte{0xbb},
+		Paymaster:                   &paymaster,
+		PaymasterData:               []byte{0xcc},
+		Deployer:                    &deployer,
+		DeployerData:                []byte{0xdd},
+		BuilderFee:                  big.NewInt(4),
+		ValidationGasLimit:          200000,
+		PaymasterValidationGasLimit: 300000,
+		PostOpGas:                   400000,
+		NonceKey:                    big.NewInt(5),
+	}
+}
+
+func TestRip7560SignerHash(t *testing.T) {
+	var (
+		signer = NewRIP7560Signer(big.NewInt(1))
+		domain = NewRIP7560DomainSigner(big.NewInt(1), Rip7560SigningDomain{EntryPoint: common.Address{0x75, 0x60}, AbiVersion: 1})
+		tx     = newRip7560SigningTestTx()
+	)
+	for _, tt := range []struct {
+		signer Signer
+		want   common.Hash
+	}{
+		{signer, common.HexToHash("0x2f609fa32bf597d37d12dd2f40864a7d0fa08a58af3503bec9a86b6e55f87bf2")},
+		{domain, common.HexToHash("0x744e5b9a19d8487ccdc904c10793710433ccd26fa106096059326bf7593a95e2")},
+	} {
+		if have := tt.signer.Hash(NewTx(tx)); have != tt.want {
+			t.Errorf("signing hash mismatch: have %x, want %x", have, tt.want)
+		}
+	}
+	// The authorization data is not covered by the signing hash, every other field is
+	unsigned := tx.copy().(*Rip7560AccountAbstractionTx)
+	unsigned.AuthorizationData = []byte{0x01, 0x02}
+	if signer.Hash(NewTx(tx)) != signer.Hash(NewTx(unsigned)) {
+		t.Error("authorization data changes the signing hash")
+	}
+	modified := tx.copy().(*Rip7560AccountAbstractionTx)
+	modified.ExecutionData = []byte{0xbc}
+	if signer.Hash(NewTx(tx)) == signer.Hash(NewTx(modified)) {
+		t.Error("execution data does not change the signing hash")
+	}
+}
+
+func TestRip7560Signer(t *testing.T) {
+	var (
+		tx     = NewTx(newRip7560SigningTestTx())
+		config = &params.ChainConfig{ChainID: big.NewInt(1), LondonBlock: big.NewInt(0), RIP7560Block: big.NewInt(0)}
+		cancun = &params.ChainConfig{ChainID: big.NewInt(1), LondonBlock: big.NewInt(0), RIP7560Block: big.NewInt(0), CancunTime: new(uint64)}
+	)
+	for _, signer := range []Signer{
+		MakeSigner(config, big.NewInt(0), 0),
+		MakeSigner(cancun, big.NewInt(0), 0),
+		LatestSigner(config),
+		LatestSigner(cancun),
+	} {
+		if signer.Hash(tx) != NewRIP7560Signer(big.NewInt(1)).Hash(tx) {
+			t.Errorf("signer %T does not compute the RIP-7560 signing hash", signer)
+		}
+		if sender, err := Sender(signer, tx); err != nil || sender != *tx.Rip7560TransactionData().Sender {
+			t.Errorf("sender mismatch: have %v (%v), want %v", sender, err, tx.Rip7560TransactionData().Sender)
+		}
+		if _, _, _, err := signer.SignatureValues(tx, make([]byte, 65)); err != ErrTxTypeNotSupported {
+			t.Errorf("signature values error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+		}
+	}
+	// Blob transactions are accepted from the Cancun fork on
+	blobtx := NewTx(&BlobTx{})
+	if _, err := MakeSigner(config, big.NewInt(0), 0).Sender(blobtx); err != ErrTxTypeNotSupported {
+		t.Errorf("blob transaction accepted before Cancun: %v", err)
+	}
+	if _, err := MakeSigner(cancun, big.NewInt(0), 0).Sender(blobtx); err == ErrTxTypeNotSupported {
+		t.Error("blob transaction rejected after Cancun")
+	}
+	if MakeSigner(config, big.NewInt(0), 0).Equal(MakeSigner(cancun, big.NewInt(0), 0)) {
+		t.Error("RIP-7560 signers with different base signers are equal")
+	}
+}
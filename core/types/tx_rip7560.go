@@ -18,12 +18,16 @@ package types
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"math"
 	"math/big"
+	"time"
 )
 
 // Rip7560AccountAbstractionTx represents an RIP-7560 transaction.
@@ -51,6 +55,9 @@ type Rip7560AccountAbstractionTx struct {
 
 	// RIP-7712 two-dimensional nonce (optional), 192 bits
 	NonceKey *big.Int
+
+	// EIP-7702 authorizations applied before the account validation frame (optional)
+	AuthorizationList []SetCodeAuthorization `rlp:"optional"`
 }
 
 func (tx *Rip7560AccountAbstractionTx) isSystemTx() bool { return false }
@@ -82,6 +89,10 @@ func (tx *Rip7560AccountAbstractionTx) copy() TxData {
 		PostOpGas:                   tx.PostOpGas,
 	}
 	copy(cpy.AccessList, tx.AccessList)
+	if tx.AuthorizationList != nil {
+		cpy.AuthorizationList = make([]SetCodeAuthorization, len(tx.AuthorizationList))
+		copy(cpy.AuthorizationList, tx.AuthorizationList)
+	}
 	if tx.ChainID != nil {
 		cpy.ChainID.Set(tx.ChainID)
 	}
@@ -120,6 +131,16 @@ func (tx *Rip7560AccountAbstractionTx) GasPayer() *common.Address {
 	return tx.Sender
 }
 
+// DeployedAddress returns the address of the account created by the deployer frame of the
+// transaction, or nil if it has none. The validation phase of transactions with a deployer
+// fails unless the deployer frame creates code at the sender address.
+func (tx *Rip7560AccountAbstractionTx) DeployedAddress() *common.Address {
+	if tx.Deployer == nil {
+		return nil
+	}
+	return tx.Sender
+}
+
 func SumGas(vals ...uint64) (uint64, error) {
 	var sum uint64
 	for _, val := range vals {
@@ -142,17 +163,43 @@ func callDataCost(data []byte) uint64 {
 	return nz*params.TxDataNonZeroGasEIP2028 + z*params.TxDataZeroGas
 }
 
-func (tx *Rip7560AccountAbstractionTx) PreTransactionGasCost() (uint64, error) {
-	calldataGasCost, err := tx.callDataGasCost()
+// PreTransactionGasCost returns the gas charged for the transaction before its frames
+// run. After Prague, it is at least the EIP-7623 floor of the cost of its calldata.
+func (tx *Rip7560AccountAbstractionTx) PreTransactionGasCost(isPrague bool) (uint64, error) {
+	calldataGasCost, err := tx.CallDataGasCost()
 	if err != nil {
 		return 0, err
 	}
 	accessListGasCost := tx.accessListGasCost()
 	eip7702CodeInsertionsGasCost := tx.eip7702CodeInsertionsGasCost()
-	return params.Rip7560TxGas + calldataGasCost + accessListGasCost + eip7702CodeInsertionsGasCost, nil
+	gas := params.Rip7560TxGas + calldataGasCost + accessListGasCost + eip7702CodeInsertionsGasCost
+	if isPrague {
+		floorDataGas, err := tx.floorDataGas()
+		if err != nil {
+			return 0, err
+		}
+		gas = max(gas, floorDataGas)
+	}
+	return gas, nil
 }
 
-func (tx *Rip7560AccountAbstractionTx) callDataGasCost() (uint64, error) {
+// floorDataGas returns the EIP-7623 floor of the gas charged for the calldata of the
+// transaction: its authorization, deployer, execution and paymaster data.
+func (tx *Rip7560AccountAbstractionTx) floorDataGas() (uint64, error) {
+	var tokens uint64
+	for _, data := range [][]byte{tx.AuthorizationData, tx.DeployerData, tx.ExecutionData, tx.PaymasterData} {
+		z := uint64(bytes.Count(data, []byte{0}))
+		tokens += z + (uint64(len(data))-z)*params.TxTokenPerNonZeroByte
+	}
+	if tokens > (math.MaxUint64-params.Rip7560TxGas)/params.TxCostFloorPerToken {
+		return 0, fmt.Errorf("invalid gas values")
+	}
+	return params.Rip7560TxGas + tokens*params.TxCostFloorPerToken, nil
+}
+
+// CallDataGasCost returns the part of the pre-transaction gas cost charged for the calldata
+// of the transaction, before the EIP-7623 floor.
+func (tx *Rip7560AccountAbstractionTx) CallDataGasCost() (uint64, error) {
 	return SumGas(
 		callDataCost(tx.AuthorizationData),
 		callDataCost(tx.DeployerData),
@@ -171,11 +218,6 @@ func (tx *Rip7560AccountAbstractionTx) accessListGasCost() uint64 {
 	return gas
 }
 
-// note: this function must be implemented if EIP-7702 transactions are enabled
-func (tx *Rip7560AccountAbstractionTx) eip7702CodeInsertionsGasCost() uint64 {
-	return 0
-}
-
 func (tx *Rip7560AccountAbstractionTx) TotalGasLimit() (uint64, error) {
 	return SumGas(
 		params.Rip7560TxGas,
@@ -183,6 +225,60 @@ func (tx *Rip7560AccountAbstractionTx) TotalGasLimit() (uint64, error) {
 	)
 }
 
+// Cost returns the maximum amount the gas payer can be charged for the transaction: its
+// total gas limit at the fee cap, plus the builder fee.
+func (tx *Rip7560AccountAbstractionTx) Cost() *big.Int {
+	gas := new(big.Int).SetUint64(params.Rip7560TxGas)
+	for _, limit := range []uint64{tx.Gas, tx.ValidationGasLimit, tx.PaymasterValidationGasLimit, tx.PostOpGas} {
+		gas.Add(gas, new(big.Int).SetUint64(limit))
+	}
+	total := gas.Mul(gas, tx.GasFeeCap)
+	if tx.BuilderFee != nil {
+		total.Add(total, tx.BuilderFee)
+	}
+	return total
+}
+
+// ErrInvalidRip7560Tx is returned by SanityCheck if a transaction is malformed.
+var ErrInvalidRip7560Tx = errors.New("invalid RIP-7560 transaction")
+
+// SanityCheck verifies the fields of the transaction that can be checked without any
+// state, rejecting transactions that cannot possibly be valid before they are executed.
+func (tx *Rip7560AccountAbstractionTx) SanityCheck() error {
+	switch {
+	case tx.ChainID == nil:
+		return fmt.Errorf("%w: chain ID not set", ErrInvalidRip7560Tx)
+	case tx.Sender == nil:
+		return fmt.Errorf("%w: sender not set", ErrInvalidRip7560Tx)
+	case tx.NonceKey == nil:
+		return fmt.Errorf("%w: nonce key not set", ErrInvalidRip7560Tx)
+	case tx.GasFeeCap == nil || tx.GasTipCap == nil:
+		return fmt.Errorf("%w: maxFeePerGas and maxPriorityFeePerGas must be set", ErrInvalidRip7560Tx)
+	}
+	if tx.NonceKey.Sign() < 0 || tx.NonceKey.BitLen() > 192 {
+		return fmt.Errorf("%w: nonce key %v not a 192 bit unsigned integer", ErrInvalidRip7560Tx, tx.NonceKey)
+	}
+	if tx.GasFeeCap.Sign() < 0 || tx.GasFeeCap.BitLen() > 256 {
+		return fmt.Errorf("%w: maxFeePerGas %v out of range", ErrInvalidRip7560Tx, tx.GasFeeCap)
+	}
+	if tx.GasTipCap.Sign() < 0 || tx.GasTipCap.BitLen() > 256 {
+		return fmt.Errorf("%w: maxPriorityFeePerGas %v out of range", ErrInvalidRip7560Tx, tx.GasTipCap)
+	}
+	if tx.GasTipCap.Cmp(tx.GasFeeCap) > 0 {
+		return fmt.Errorf("%w: maxPriorityFeePerGas %v higher than maxFeePerGas %v", ErrInvalidRip7560Tx, tx.GasTipCap, tx.GasFeeCap)
+	}
+	if _, err := tx.TotalGasLimit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRip7560Tx, err)
+	}
+	if tx.Deployer == nil && len(tx.DeployerData) != 0 {
+		return fmt.Errorf("%w: deployer data set without a deployer", ErrInvalidRip7560Tx)
+	}
+	if tx.Paymaster == nil && (len(tx.PaymasterData) != 0 || tx.PaymasterValidationGasLimit != 0 || tx.PostOpGas != 0) {
+		return fmt.Errorf("%w: paymaster fields set without a paymaster", ErrInvalidRip7560Tx)
+	}
+	return nil
+}
+
 // IsRip7712Nonce returns true if the transaction uses an RIP-7712 two-dimensional nonce
 func (tx *Rip7560AccountAbstractionTx) IsRip7712Nonce() bool {
 	return tx.NonceKey != nil && tx.NonceKey.Cmp(big.NewInt(0)) == 1
@@ -226,7 +322,59 @@ func (tx *Rip7560AccountAbstractionTx) encode(b *bytes.Buffer) error {
 
 // decode the payload-bearing bytes of the encoded RIP-7560 transaction payload
 func (tx *Rip7560AccountAbstractionTx) decode(input []byte) error {
-	return rlp.DecodeBytes(input, tx)
+	var dec Rip7560AccountAbstractionTx
+	if err := rlp.DecodeBytes(input, &dec); err != nil {
+		return err
+	}
+	if err := dec.checkDecoded(); err != nil {
+		return err
+	}
+	*tx = dec
+	return nil
+}
+
+// checkDecoded rejects the decoded transactions whose fields cannot be represented, or
+// which are not encoded the way encode would encode them, as their hash would change when
+// they are re-encoded.
+func (tx *Rip7560AccountAbstractionTx) checkDecoded() error {
+	if tx.Paymaster != nil && *tx.Paymaster == (common.Address{}) {
+		return fmt.Errorf("%w: zero paymaster address not encoded as empty", ErrInvalidRip7560Tx)
+	}
+	if tx.Deployer != nil && *tx.Deployer == (common.Address{}) {
+		return fmt.Errorf("%w: zero deployer address not encoded as empty", ErrInvalidRip7560Tx)
+	}
+	if tx.AuthorizationList != nil && len(tx.AuthorizationList) == 0 {
+		return fmt.Errorf("%w: empty authorization list not omitted", ErrInvalidRip7560Tx)
+	}
+	for _, field := range []struct {
+		name  string
+		value *big.Int
+		bits  int
+	}{
+		{"chain ID", tx.ChainID, 256},
+		{"maxPriorityFeePerGas", tx.GasTipCap, 256},
+		{"maxFeePerGas", tx.GasFeeCap, 256},
+		{"builder fee", tx.BuilderFee, 256},
+		{"nonce key", tx.NonceKey, 192},
+	} {
+		if field.value.BitLen() > field.bits {
+			return fmt.Errorf("%w: %s %v exceeds %d bits", ErrInvalidRip7560Tx, field.name, field.value, field.bits)
+		}
+	}
+	for _, field := range []struct {
+		name  string
+		value uint64
+	}{
+		{"callGasLimit", tx.Gas},
+		{"verificationGasLimit", tx.ValidationGasLimit},
+		{"paymasterVerificationGasLimit", tx.PaymasterValidationGasLimit},
+		{"paymasterPostOpGasLimit", tx.PostOpGas},
+	} {
+		if field.value > 1<<62 {
+			return fmt.Errorf("%w: %s %d out of range", ErrInvalidRip7560Tx, field.name, field.value)
+		}
+	}
+	return nil
 }
 
 // Rip7560Transaction an equivalent of a solidity struct only used to encode the 'transaction' parameter
@@ -247,6 +395,18 @@ type Rip7560Transaction struct {
 	DeployerData                []byte
 	ExecutionData               []byte
 	AuthorizationData           []byte
+	AuthorizationList           []Rip7560Authorization
+}
+
+// Rip7560Authorization an equivalent of a solidity struct only used to encode the EIP-7702
+// authorizations of the 'transaction' parameter
+type Rip7560Authorization struct {
+	ChainId *big.Int
+	Address common.Address
+	Nonce   *big.Int
+	YParity uint8
+	R       *big.Int
+	S       *big.Int
 }
 
 func (tx *Rip7560AccountAbstractionTx) AbiEncode() ([]byte, error) {
@@ -267,6 +427,14 @@ func (tx *Rip7560AccountAbstractionTx) AbiEncode() ([]byte, error) {
 		{Name: "deployerData", Type: "bytes"},
 		{Name: "executionData", Type: "bytes"},
 		{Name: "authorizationData", Type: "bytes"},
+		{Name: "authorizationList", Type: "tuple[]", Components: []abi.ArgumentMarshaling{
+			{Name: "chainId", Type: "uint256"},
+			{Name: "address", Type: "address"},
+			{Name: "nonce", Type: "uint256"},
+			{Name: "yParity", Type: "uint8"},
+			{Name: "r", Type: "uint256"},
+			{Name: "s", Type: "uint256"},
+		}},
 	})
 
 	args := abi.Arguments{
@@ -282,28 +450,89 @@ func (tx *Rip7560AccountAbstractionTx) AbiEncode() ([]byte, error) {
 		deployer = &common.Address{}
 	}
 
+	if tx.Sender == nil {
+		return nil, errors.New("sender not set")
+	}
 	record := &Rip7560Transaction{
 		Sender:                      *tx.Sender,
-		NonceKey:                    tx.NonceKey,
-		Nonce:                       big.NewInt(int64(tx.Nonce)),
-		ValidationGasLimit:          big.NewInt(int64(tx.ValidationGasLimit)),
-		PaymasterValidationGasLimit: big.NewInt(int64(tx.PaymasterValidationGasLimit)),
-		PostOpGasLimit:              big.NewInt(int64(tx.PostOpGas)),
-		CallGasLimit:                big.NewInt(int64(tx.Gas)),
-		MaxFeePerGas:                tx.GasFeeCap,
-		MaxPriorityFeePerGas:        tx.GasTipCap,
-		BuilderFee:                  tx.BuilderFee,
+		NonceKey:                    abiUint256(tx.NonceKey),
+		Nonce:                       new(big.Int).SetUint64(tx.Nonce),
+		ValidationGasLimit:          new(big.Int).SetUint64(tx.ValidationGasLimit),
+		PaymasterValidationGasLimit: new(big.Int).SetUint64(tx.PaymasterValidationGasLimit),
+		PostOpGasLimit:              new(big.Int).SetUint64(tx.PostOpGas),
+		CallGasLimit:                new(big.Int).SetUint64(tx.Gas),
+		MaxFeePerGas:                abiUint256(tx.GasFeeCap),
+		MaxPriorityFeePerGas:        abiUint256(tx.GasTipCap),
+		BuilderFee:                  abiUint256(tx.BuilderFee),
 		Paymaster:                   *paymaster,
 		PaymasterData:               tx.PaymasterData,
 		Deployer:                    *deployer,
 		DeployerData:                tx.DeployerData,
 		ExecutionData:               tx.ExecutionData,
 		AuthorizationData:           tx.AuthorizationData,
+		AuthorizationList:           make([]Rip7560Authorization, len(tx.AuthorizationList)),
+	}
+	for i, auth := range tx.AuthorizationList {
+		record.AuthorizationList[i] = Rip7560Authorization{
+			ChainId: auth.ChainID.ToBig(),
+			Address: auth.Address,
+			Nonce:   new(big.Int).SetUint64(auth.Nonce),
+			YParity: auth.V,
+			R:       auth.R.ToBig(),
+			S:       auth.S.ToBig(),
+		}
 	}
 	packed, err := args.Pack(&record)
 	return packed, err
 }
 
+// abiUint256 returns the value of an optional uint256 field, zero if it is not set.
+func abiUint256(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
+// Rip7560GasAttribution splits the gas used by an RIP-7560 transaction by the gas limit
+// of the entity it was drawn from, so that a paymaster can tell apart the gas it
+// sponsored. The used gas of the receipt is the sum of all parts minus the refund.
+type Rip7560GasAttribution struct {
+	Account             hexutil.Uint64 `json:"account"`             // validationGasLimit: intrinsic gas, nonce manager, deployment and account validation
+	PaymasterValidation hexutil.Uint64 `json:"paymasterValidation"` // paymasterVerificationGasLimit
+	Execution           hexutil.Uint64 `json:"execution"`           // callGasLimit, including the unused gas penalty
+	PostOp              hexutil.Uint64 `json:"postOp"`              // paymasterPostOpGasLimit, including the unused gas penalty
+	SystemEvents        hexutil.Uint64 `json:"systemEvents"`        // EntryPoint events, charged from the gas left of the total limit
+	Refund              hexutil.Uint64 `json:"refund"`              // Refunded gas, subtracted from the sum of the parts
+}
+
+// GasUsed returns the gas used by the transaction according to the attribution.
+func (a *Rip7560GasAttribution) GasUsed() uint64 {
+	return uint64(a.Account+a.PaymasterValidation+a.Execution+a.PostOp+a.SystemEvents) - uint64(a.Refund)
+}
+
+// Rip7560FrameGasUsed is the gas used by each frame of an RIP-7560 transaction, without the
+// penalties charged on the unused execution and postOp gas, along with the gas charged for
+// its calldata as part of the pre-transaction gas.
+type Rip7560FrameGasUsed struct {
+	Validation          hexutil.Uint64 `json:"validationGasUsed"`
+	PaymasterValidation hexutil.Uint64 `json:"paymasterValidationGasUsed"`
+	Deployment          hexutil.Uint64 `json:"deploymentGasUsed"`
+	Execution           hexutil.Uint64 `json:"executionGasUsed"`
+	PostOp              hexutil.Uint64 `json:"postOpGasUsed"`
+	CallData            hexutil.Uint64 `json:"callDataGasUsed" rlp:"optional"`
+}
+
+// Rip7560Validity holds the ranges of timestamps the account and the paymaster of an
+// RIP-7560 transaction accepted it in, as returned by their validation frames. Zero means
+// unbounded, and the paymaster range is unbounded without a paymaster.
+type Rip7560Validity struct {
+	AccountValidAfter   hexutil.Uint64 `json:"accountValidAfter"`
+	AccountValidUntil   hexutil.Uint64 `json:"accountValidUntil"`
+	PaymasterValidAfter hexutil.Uint64 `json:"paymasterValidAfter"`
+	PaymasterValidUntil hexutil.Uint64 `json:"paymasterValidUntil"`
+}
+
 // ExternallyReceivedBundle represents a bundle of Type 4 transactions received from a trusted 3rd party.
 // The validator includes the bundle in the original order atomically or drops it completely.
 type ExternallyReceivedBundle struct {
@@ -311,24 +540,104 @@ type ExternallyReceivedBundle struct {
 	BundleHash    common.Hash
 	ValidForBlock *big.Int
 	Transactions  []*Transaction
+
+	// ValidUntilBlock is the last block the bundle remains valid for if the bundler
+	// submitted or extended it with an inclusion window reaching past ValidForBlock.
+	ValidUntilBlock *big.Int `rlp:"optional"`
 }
 
-// BundleReceipt represents a receipt for an ExternallyReceivedBundle successfully included in a block.
+// LastValidBlock returns the last block the bundle may be included in.
+func (b *ExternallyReceivedBundle) LastValidBlock() *big.Int {
+	if b.ValidUntilBlock != nil {
+		return b.ValidUntilBlock
+	}
+	return b.ValidForBlock
+}
+
+// IsValidFor reports whether the given block is within the inclusion window of the bundle.
+func (b *ExternallyReceivedBundle) IsValidFor(block *big.Int) bool {
+	return b.ValidForBlock.Cmp(block) <= 0 && b.LastValidBlock().Cmp(block) >= 0
+}
+
+// Statuses of an ExternallyReceivedBundle reported by a BundleReceipt.
+const (
+	BundleStatusIncluded  uint64 = iota // the bundle was included in a block
+	BundleStatusPending                 // the bundle is waiting for inclusion
+	BundleStatusInvalid                 // the bundle was dropped as invalid
+	BundleStatusUnknown                 // the bundle is not known
+	BundleStatusExpired                 // the bundle was not included by the last block it was valid for
+	BundleStatusCancelled               // the bundle was cancelled by its bundler before inclusion
+)
+
+// BundleReceipt represents a receipt for an ExternallyReceivedBundle successfully included in a block,
+// or for one that expired or was cancelled, in which case BlockNumber is the last block it was valid for.
+// A bundle only partially included lists the transactions left out of the block in DroppedTransactions.
 type BundleReceipt struct {
 	BundleHash          common.Hash
 	Count               uint64
-	Status              uint64 // 0=included / 1=pending / 2=invalid / 3=unknown
+	Status              uint64 // 0=included / 1=pending / 2=invalid / 3=unknown / 4=expired / 5=cancelled
 	BlockNumber         uint64
 	BlockHash           common.Hash
 	TransactionReceipts []*Receipt
 	GasUsed             uint64
 	GasPaidPriority     *big.Int
 	BlockTimestamp      uint64
+	DroppedTransactions []common.Hash `json:",omitempty"`
+}
+
+// Rip7560InclusionLatency aggregates the time RIP-7560 transactions spent between being
+// first seen by the pool and being included in a block.
+type Rip7560InclusionLatency struct {
+	Count uint64
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+}
+
+// Add accounts the inclusion latency of a single transaction.
+func (l *Rip7560InclusionLatency) Add(latency time.Duration) {
+	if l.Count == 0 || latency < l.Min {
+		l.Min = latency
+	}
+	if latency > l.Max {
+		l.Max = latency
+	}
+	l.Count++
+	l.Total += latency
+}
+
+// Rip7560InclusionStats holds the inclusion latencies of RIP-7560 transactions grouped by
+// the bundler that submitted them and by their paymaster. Transactions paying for their
+// own gas are grouped under the zero address.
+type Rip7560InclusionStats struct {
+	Bundlers   map[string]*Rip7560InclusionLatency
+	Paymasters map[common.Address]*Rip7560InclusionLatency
+}
+
+// Rip7560PaymasterStats aggregates the RIP-7560 transactions a paymaster sponsored
+// within a range of blocks.
+type Rip7560PaymasterStats struct {
+	FromBlock      uint64
+	ToBlock        uint64
+	Transactions   uint64   // number of sponsored transactions
+	GasUsed        uint64   // gas used by the sponsored transactions
+	GasPaid        *big.Int // gas cost charged to the paymaster, including the L1 data fee, in wei
+	PostOpFailures uint64   // number of sponsored transactions whose postOp frame failed
 }
 
 type Rip7560TransactionDebugInfo struct {
-	TxHash           common.Hash
-	RevertEntityName string
-	FrameReverted    bool // true if reverted, false if did not call EntryPoint callback
-	RevertData       string
+	TxHash           common.Hash              `json:"transactionHash"`
+	RevertEntityName string                   `json:"revertEntityName"`
+	FrameReverted    bool                     `json:"frameReverted"` // true if reverted, false if did not call EntryPoint callback
+	RevertData       string                   `json:"revertData"`
+	Frames           []*Rip7560FrameDebugInfo `json:"frames"`             // validation frames that ran, in order
+	TimedOut         bool                     `json:"timedOut,omitempty"` // true if the validation ran past the wall-clock limit of the builder
+}
+
+// Rip7560FrameDebugInfo is the outcome of a validation frame run by a transaction that
+// failed validation, letting bundlers decode the custom errors returned by any entity.
+type Rip7560FrameDebugInfo struct {
+	Entity     string        `json:"entity"` // one of "nonce", "deployer", "account" or "paymaster"
+	Reverted   bool          `json:"reverted"`
+	ReturnData hexutil.Bytes `json:"returnData"`
 }
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// DelegationPrefix is used by code to denote the account is delegating to
+// another account.
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// ParseDelegation tries to parse the address from a delegation slice.
+func ParseDelegation(b []byte) (common.Address, bool) {
+	if len(b) != 23 || !bytes.HasPrefix(b, DelegationPrefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(b[len(DelegationPrefix):]), true
+}
+
+// AddressToDelegation adds the delegation prefix to the specified address.
+func AddressToDelegation(addr common.Address) []byte {
+	return append(common.CopyBytes(DelegationPrefix), addr.Bytes()...)
+}
+
+//go:generate go run github.com/fjl/gencodec -type SetCodeAuthorization -field-override authorizationMarshaling -out gen_authorization.go
+
+// SetCodeAuthorization is an EIP-7702 authorization, signed by an account to delegate
+// its code to the code of another account.
+type SetCodeAuthorization struct {
+	ChainID uint256.Int    `json:"chainId" gencodec:"required"`
+	Address common.Address `json:"address" gencodec:"required"`
+	Nonce   uint64         `json:"nonce" gencodec:"required"`
+	V       uint8          `json:"yParity" gencodec:"required"`
+	R       uint256.Int    `json:"r" gencodec:"required"`
+	S       uint256.Int    `json:"s" gencodec:"required"`
+}
+
+// field type overrides for gencodec
+type authorizationMarshaling struct {
+	ChainID hexutil.U256
+	Nonce   hexutil.Uint64
+	V       hexutil.Uint64
+	R       hexutil.U256
+	S       hexutil.U256
+}
+
+// SignSetCode creates a signed SetCode authorization.
+func SignSetCode(prv *ecdsa.PrivateKey, auth SetCodeAuthorization) (SetCodeAuthorization, error) {
+	sighash := auth.SigHash()
+	sig, err := crypto.Sign(sighash[:], prv)
+	if err != nil {
+		return SetCodeAuthorization{}, err
+	}
+	auth.R.SetBytes(sig[:32])
+	auth.S.SetBytes(sig[32:64])
+	auth.V = sig[64]
+	return auth, nil
+}
+
+// SigHash returns the hash of the authorization signed by the authority.
+func (a *SetCodeAuthorization) SigHash() common.Hash {
+	return prefixedRlpHash(0x05, []any{
+		a.ChainID,
+		a.Address,
+		a.Nonce,
+	})
+}
+
+// Authority recovers the account signing the authorization.
+func (a *SetCodeAuthorization) Authority() (common.Address, error) {
+	sighash := a.SigHash()
+	if !crypto.ValidateSignatureValues(a.V, a.R.ToBig(), a.S.ToBig(), true) {
+		return common.Address{}, ErrInvalidSig
+	}
+	// encode the signature in uncompressed format
+	var sig [crypto.SignatureLength]byte
+	a.R.WriteToSlice(sig[:32])
+	a.S.WriteToSlice(sig[32:64])
+	sig[64] = a.V
+	// recover the public key from the signature
+	pub, err := crypto.Ecrecover(sighash[:], sig[:])
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(pub) == 0 || pub[0] != 4 {
+		return common.Address{}, errors.New("invalid public key")
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
+	return addr, nil
+}
+
+// eip7702CodeInsertionsGasCost returns the gas charged for the EIP-7702 authorizations
+// of the transaction: the cost of creating each authority account, partially refunded
+// for the ones that exist when the authorization is applied.
+func (tx *Rip7560AccountAbstractionTx) eip7702CodeInsertionsGasCost() uint64 {
+	return uint64(len(tx.AuthorizationList)) * params.CallNewAccountGas
+}
@@ -0,0 +1,458 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestRip7560SanityCheck(t *testing.T) {
+	sender := common.Address{0x01}
+	tests := []struct {
+		name   string
+		modify func(tx *Rip7560AccountAbstractionTx)
+		valid  bool
+	}{
+		{"valid", func(tx *Rip7560AccountAbstractionTx) {}, true},
+		{"no chain ID", func(tx *Rip7560AccountAbstractionTx) { tx.ChainID = nil }, false},
+		{"no sender", func(tx *Rip7560AccountAbstractionTx) { tx.Sender = nil }, false},
+		{"no nonce key", func(tx *Rip7560AccountAbstractionTx) { tx.NonceKey = nil }, false},
+		{"nonce key too long", func(tx *Rip7560AccountAbstractionTx) { tx.NonceKey = new(big.Int).Lsh(common.Big1, 192) }, false},
+		{"no fee cap", func(tx *Rip7560AccountAbstractionTx) { tx.GasFeeCap = nil }, false},
+		{"tip above fee cap", func(tx *Rip7560AccountAbstractionTx) { tx.GasTipCap = big.NewInt(3) }, false},
+		{"gas overflow", func(tx *Rip7560AccountAbstractionTx) { tx.Gas = math.MaxUint64 }, false},
+		{"deployer data only", func(tx *Rip7560AccountAbstractionTx) { tx.DeployerData = []byte{1} }, false},
+		{"postOp gas only", func(tx *Rip7560AccountAbstractionTx) { tx.PostOpGas = 1 }, false},
+	}
+	for _, tt := range tests {
+		tx := &Rip7560AccountAbstractionTx{
+			ChainID:   big.NewInt(1),
+			Sender:    &sender,
+			NonceKey:  big.NewInt(0),
+			Gas:       100_000,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+		}
+		tt.modify(tx)
+		err := tx.SanityCheck()
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidRip7560Tx) {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, ErrInvalidRip7560Tx)
+		}
+	}
+}
+
+func TestRip7560DeriveFields(t *testing.T) {
+	var (
+		sender   = common.Address{0x01}
+		deployer = common.Address{0x02}
+	)
+	txs := []*Transaction{
+		NewTx(&Rip7560AccountAbstractionTx{Sender: &sender, Deployer: &deployer}),
+		NewTx(&Rip7560AccountAbstractionTx{Sender: &sender, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10)}),
+	}
+	receipts := Receipts{{CumulativeGasUsed: 1}, {CumulativeGasUsed: 2}}
+	if err := receipts.DeriveFields(params.TestChainConfig, common.Hash{0x01}, 1, 0, big.NewInt(1), nil, txs); err != nil {
+		t.Fatalf("failed to derive fields: %v", err)
+	}
+	if receipts[0].ContractAddress != sender {
+		t.Errorf("deployment contract address mismatch: have %v, want %v", receipts[0].ContractAddress, sender)
+	}
+	if receipts[1].ContractAddress != (common.Address{}) {
+		t.Errorf("unexpected contract address %v", receipts[1].ContractAddress)
+	}
+	// The effective gas price is the base fee plus the tip, capped by the fee cap
+	if price := receipts[1].EffectiveGasPrice; price == nil || price.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("effective gas price mismatch: have %v, want 3", price)
+	}
+}
+
+func TestRip7560TransactionJSON(t *testing.T) {
+	var (
+		sender    = common.Address{0x01}
+		paymaster = common.Address{0x02}
+		deployer  = common.Address{0x03}
+	)
+	tx := NewTx(&Rip7560AccountAbstractionTx{
+		ChainID:                     big.NewInt(1),
+		Nonce:                       3,
+		NonceKey:                    big.NewInt(5),
+		Gas:                         100_000,
+		GasTipCap:                   big.NewInt(1),
+		GasFeeCap:                   big.NewInt(2),
+		AccessList:                  AccessList{{Address: sender, StorageKeys: []common.Hash{{0x01}}}},
+		ExecutionData:               []byte{0x01},
+		Sender:                      &sender,
+		AuthorizationData:           []byte{0x02},
+		Paymaster:                   &paymaster,
+		PaymasterData:               []byte{0x03},
+		Deployer:                    &deployer,
+		DeployerData:                []byte{0x04},
+		BuilderFee:                  big.NewInt(6),
+		ValidationGasLimit:          200_000,
+		PaymasterValidationGasLimit: 300_000,
+		PostOpGas:                   400_000,
+	})
+	blob, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		t.Fatalf("failed to decode transaction fields: %v", err)
+	}
+	for _, field := range []string{
+		"sender", "nonceKey", "authorizationData", "executionData", "paymaster", "paymasterData", "deployer", "deployerData",
+		"builderFee", "verificationGasLimit", "paymasterVerificationGasLimit", "paymasterPostOpGasLimit", "accessList",
+	} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("missing field %q", field)
+		}
+	}
+	dec := new(Transaction)
+	if err := json.Unmarshal(blob, dec); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+	if key := dec.Rip7560TransactionData().NonceKey; key.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("nonce key mismatch: have %v, want 5", key)
+	}
+
+	fields["value"] = "0x1"
+	if blob, err = json.Marshal(fields); err != nil {
+		t.Fatalf("failed to encode transaction fields: %v", err)
+	}
+	if err := json.Unmarshal(blob, new(Transaction)); err == nil {
+		t.Errorf("transaction with value decoded")
+	}
+}
+
+func TestRip7560DecodeStrict(t *testing.T) {
+	var (
+		sender = common.Address{0x01}
+		zero   = common.Address{}
+	)
+	tests := []struct {
+		name    string
+		modify  func(tx *Rip7560AccountAbstractionTx)
+		trailer []byte
+		valid   bool
+	}{
+		{"valid", func(tx *Rip7560AccountAbstractionTx) {}, nil, true},
+		{"trailing bytes", func(tx *Rip7560AccountAbstractionTx) {}, []byte{0x80}, false},
+		{"zero paymaster", func(tx *Rip7560AccountAbstractionTx) { tx.Paymaster = &zero }, nil, false},
+		{"zero deployer", func(tx *Rip7560AccountAbstractionTx) { tx.Deployer = &zero }, nil, false},
+		{"nonce key too long", func(tx *Rip7560AccountAbstractionTx) { tx.NonceKey = new(big.Int).Lsh(common.Big1, 192) }, nil, false},
+		{"fee cap too long", func(tx *Rip7560AccountAbstractionTx) { tx.GasFeeCap = new(big.Int).Lsh(common.Big1, 256) }, nil, false},
+		{"gas out of range", func(tx *Rip7560AccountAbstractionTx) { tx.ValidationGasLimit = math.MaxUint64 }, nil, false},
+	}
+	for _, tt := range tests {
+		tx := &Rip7560AccountAbstractionTx{
+			ChainID:    big.NewInt(1),
+			Sender:     &sender,
+			NonceKey:   big.NewInt(0),
+			Gas:        100_000,
+			GasTipCap:  big.NewInt(1),
+			GasFeeCap:  big.NewInt(2),
+			BuilderFee: big.NewInt(0),
+		}
+		tt.modify(tx)
+		// The payload is encoded directly, as encode would normalize the addresses
+		payload, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", tt.name, err)
+		}
+		payload = append(append([]byte{Rip7560Type}, payload...), tt.trailer...)
+
+		err = new(Transaction).UnmarshalBinary(payload)
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: invalid payload decoded", tt.name)
+		}
+	}
+	// A required address cannot be encoded as empty
+	payload, err := rlp.EncodeToBytes([]interface{}{
+		big.NewInt(1), uint64(0), big.NewInt(1), big.NewInt(2), uint64(0), AccessList{},
+		[]byte{}, // sender
+		[]byte{}, []byte{}, []byte{}, []byte{}, []byte{}, []byte{}, big.NewInt(0), uint64(0), uint64(0), uint64(0), big.NewInt(0),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if err := new(Transaction).UnmarshalBinary(append([]byte{Rip7560Type}, payload...)); err == nil {
+		t.Error("transaction without sender decoded")
+	}
+}
+
+func TestRip7560AbiEncode(t *testing.T) {
+	var (
+		sender    = common.Address{0x01}
+		paymaster = common.Address{0x02}
+	)
+	tx := &Rip7560AccountAbstractionTx{
+		Sender:                      &sender,
+		NonceKey:                    new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 192), common.Big1),
+		Nonce:                       math.MaxUint64,
+		ValidationGasLimit:          1<<63 + 1,
+		PaymasterValidationGasLimit: 1<<63 + 2,
+		PostOpGas:                   1<<63 + 3,
+		Gas:                         1<<63 + 4,
+		GasFeeCap:                   new(big.Int).Lsh(common.Big1, 255),
+		GasTipCap:                   big.NewInt(5),
+		Paymaster:                   &paymaster,
+		PaymasterData:               []byte{0xaa},
+		ExecutionData:               []byte{0xbb, 0xcc},
+		AuthorizationData:           []byte{0xdd},
+	}
+	encoded, err := tx.AbiEncode()
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	// The struct is dynamic, its head words follow the offset of the tuple in the Solidity layout
+	word := func(i int) *big.Int {
+		return new(big.Int).SetBytes(encoded[32+32*i : 64+32*i])
+	}
+	for i, want := range []*big.Int{
+		new(big.Int).SetBytes(sender.Bytes()),
+		tx.NonceKey,
+		new(big.Int).SetUint64(tx.Nonce),
+		new(big.Int).SetUint64(tx.ValidationGasLimit),
+		new(big.Int).SetUint64(tx.PaymasterValidationGasLimit),
+		new(big.Int).SetUint64(tx.PostOpGas),
+		new(big.Int).SetUint64(tx.Gas),
+		tx.GasFeeCap,
+		tx.GasTipCap,
+		new(big.Int), // builder fee not set
+		new(big.Int).SetBytes(paymaster.Bytes()),
+	} {
+		if have := word(i); have.Cmp(want) != 0 {
+			t.Errorf("word %d mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	// Decoding the encoding with the Solidity struct type returns the transaction fields
+	structType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "sender", Type: "address"},
+		{Name: "nonceKey", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "validationGasLimit", Type: "uint256"},
+		{Name: "paymasterValidationGasLimit", Type: "uint256"},
+		{Name: "postOpGasLimit", Type: "uint256"},
+		{Name: "callGasLimit", Type: "uint256"},
+		{Name: "maxFeePerGas", Type: "uint256"},
+		{Name: "maxPriorityFeePerGas", Type: "uint256"},
+		{Name: "builderFee", Type: "uint256"},
+		{Name: "paymaster", Type: "address"},
+		{Name: "paymasterData", Type: "bytes"},
+		{Name: "deployer", Type: "address"},
+		{Name: "deployerData", Type: "bytes"},
+		{Name: "executionData", Type: "bytes"},
+		{Name: "authorizationData", Type: "bytes"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create struct type: %v", err)
+	}
+	args := abi.Arguments{{Type: structType}}
+	unpacked, err := args.Unpack(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	decoded := *abi.ConvertType(unpacked[0], new(Rip7560Transaction)).(*Rip7560Transaction)
+	for i, field := range []struct{ have, want *big.Int }{
+		{decoded.NonceKey, tx.NonceKey},
+		{decoded.Nonce, new(big.Int).SetUint64(tx.Nonce)},
+		{decoded.ValidationGasLimit, new(big.Int).SetUint64(tx.ValidationGasLimit)},
+		{decoded.PaymasterValidationGasLimit, new(big.Int).SetUint64(tx.PaymasterValidationGasLimit)},
+		{decoded.PostOpGasLimit, new(big.Int).SetUint64(tx.PostOpGas)},
+		{decoded.CallGasLimit, new(big.Int).SetUint64(tx.Gas)},
+		{decoded.MaxFeePerGas, tx.GasFeeCap},
+		{decoded.MaxPriorityFeePerGas, tx.GasTipCap},
+		{decoded.BuilderFee, new(big.Int)},
+	} {
+		if field.have.Cmp(field.want) != 0 {
+			t.Errorf("decoded integer %d mismatch: have %v, want %v", i, field.have, field.want)
+		}
+	}
+	if decoded.Sender != sender || decoded.Paymaster != paymaster || decoded.Deployer != (common.Address{}) {
+		t.Errorf("decoded addresses mismatch: have %v %v %v", decoded.Sender, decoded.Paymaster, decoded.Deployer)
+	}
+	for i, field := range []struct{ have, want []byte }{
+		{decoded.PaymasterData, tx.PaymasterData},
+		{decoded.DeployerData, nil},
+		{decoded.ExecutionData, tx.ExecutionData},
+		{decoded.AuthorizationData, tx.AuthorizationData},
+	} {
+		if !bytes.Equal(field.have, field.want) {
+			t.Errorf("decoded bytes %d mismatch: have %x, want %x", i, field.have, field.want)
+		}
+	}
+}
+
+func TestRip7560CostAndSize(t *testing.T) {
+	var (
+		sender = common.Address{0x01}
+		zero   = common.Address{}
+	)
+	// The zero paymaster address is not part of the encoding
+	tx := NewTx(&Rip7560AccountAbstractionTx{
+		ChainID:                     big.NewInt(1),
+		Sender:                      &sender,
+		NonceKey:                    big.NewInt(0),
+		Gas:                         100,
+		ValidationGasLimit:          200,
+		PaymasterValidationGasLimit: 300,
+		PostOpGas:                   400,
+		GasTipCap:                   big.NewInt(1),
+		GasFeeCap:                   big.NewInt(2),
+		BuilderFee:                  big.NewInt(3),
+		Paymaster:                   &zero,
+		ExecutionData:               []byte{0x01, 0x02},
+	})
+	want := new(big.Int).SetUint64((params.Rip7560TxGas+1000)*2 + 3)
+	if have := tx.Cost(); have.Cmp(want) != 0 {
+		t.Errorf("cost mismatch: have %v, want %v", have, want)
+	}
+	encoded, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if have := tx.Size(); have != uint64(len(encoded)) {
+		t.Errorf("size mismatch: have %d, want %d", have, len(encoded))
+	}
+}
+
+func TestRip7560Authorizations(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	auths := make([]SetCodeAuthorization, 2)
+	for i := range auths {
+		auth, err := SignSetCode(key, SetCodeAuthorization{ChainID: *uint256.NewInt(1), Address: common.Address{byte(i + 1)}, Nonce: uint64(i)})
+		if err != nil {
+			t.Fatalf("failed to sign authorization: %v", err)
+		}
+		auths[i] = auth
+	}
+	for i, auth := range auths {
+		if authority, err := auth.Authority(); err != nil || authority != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("authorization %d: authority mismatch: have %x (%v), want %x", i, authority, err, crypto.PubkeyToAddress(key.PublicKey))
+		}
+	}
+	sender := common.Address{0x01}
+	withoutAuths := &Rip7560AccountAbstractionTx{
+		ChainID:           big.NewInt(1),
+		Sender:            &sender,
+		NonceKey:          new(big.Int),
+		GasTipCap:         big.NewInt(1),
+		GasFeeCap:         big.NewInt(2),
+		BuilderFee:        new(big.Int),
+		AuthorizationData: []byte{0xaa}, // the signature of the account, not an authorization
+	}
+	withAuths := withoutAuths.copy().(*Rip7560AccountAbstractionTx)
+	withAuths.AuthorizationList = auths
+
+	// the list is omitted from the encoding of the transactions without authorizations
+	encoded, _ := rlp.EncodeToBytes(withoutAuths)
+	legacy, _ := rlp.EncodeToBytes([]interface{}{
+		withoutAuths.ChainID, withoutAuths.Nonce, withoutAuths.GasTipCap, withoutAuths.GasFeeCap, withoutAuths.Gas, withoutAuths.AccessList,
+		withoutAuths.Sender, withoutAuths.AuthorizationData, withoutAuths.ExecutionData, withoutAuths.Paymaster, withoutAuths.PaymasterData,
+		withoutAuths.Deployer, withoutAuths.DeployerData, withoutAuths.BuilderFee, withoutAuths.ValidationGasLimit,
+		withoutAuths.PaymasterValidationGasLimit, withoutAuths.PostOpGas, withoutAuths.NonceKey,
+	})
+	if !bytes.Equal(encoded, legacy) {
+		t.Errorf("encoding without authorizations changed:\nhave %x\nwant %x", encoded, legacy)
+	}
+	blob, err := NewTx(withAuths).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	dec := new(Transaction)
+	if err := dec.UnmarshalBinary(blob); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if have := dec.Rip7560TransactionData().AuthorizationList; len(have) != 2 || have[1].Address != auths[1].Address || have[1].R != auths[1].R {
+		t.Errorf("authorization list mismatch: have %v, want %v", have, auths)
+	}
+	if data := dec.Rip7560TransactionData().AuthorizationData; !bytes.Equal(data, withoutAuths.AuthorizationData) {
+		t.Errorf("authorization data mismatch: have %x, want %x", data, withoutAuths.AuthorizationData)
+	}
+
+	// the signing hash covers the list, but not the authorization data
+	signer := NewRIP7560Signer(big.NewInt(1))
+	if signer.Hash(NewTx(withAuths)) == signer.Hash(NewTx(withoutAuths)) {
+		t.Error("signing hash does not cover the authorization list")
+	}
+	withAuths.AuthorizationData = []byte{0xbb}
+	if have, want := signer.Hash(NewTx(withAuths)), signer.Hash(dec); have != want {
+		t.Errorf("signing hash covers the authorization data: have %x, want %x", have, want)
+	}
+
+	// the list is carried in the JSON and ABI encodings
+	if blob, err = json.Marshal(NewTx(withAuths)); err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	if err := json.Unmarshal(blob, dec); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if dec.Hash() != NewTx(withAuths).Hash() {
+		t.Errorf("JSON round trip hash mismatch: have %x, want %x", dec.Hash(), NewTx(withAuths).Hash())
+	}
+	withAbi, _ := withAuths.AbiEncode()
+	withoutAbi, _ := withoutAuths.AbiEncode()
+	if len(withAbi) != len(withoutAbi)+2*6*32 {
+		t.Errorf("ABI encoding length mismatch: have %d, want %d", len(withAbi), len(withoutAbi)+2*6*32)
+	}
+
+	// every authorization is charged as if it created its authority account
+	have, _ := withAuths.PreTransactionGasCost(false)
+	want, _ := withoutAuths.PreTransactionGasCost(false)
+	if want += 2 * params.CallNewAccountGas; have != want {
+		t.Errorf("pre-transaction gas mismatch: have %d, want %d", have, want)
+	}
+	if address, ok := ParseDelegation(AddressToDelegation(common.Address{0x42})); !ok || address != (common.Address{0x42}) {
+		t.Errorf("delegation mismatch: have %x %v", address, ok)
+	}
+}
+
+func TestRip7560FloorDataGas(t *testing.T) {
+	tests := []struct {
+		name              string
+		tx                *Rip7560AccountAbstractionTx
+		prePrague, prague uint64
+	}{
+		{"no data", &Rip7560AccountAbstractionTx{}, params.Rip7560TxGas, params.Rip7560TxGas},
+		{
+			// the floor is below the cost of the access list
+			"access list",
+			&Rip7560AccountAbstractionTx{ExecutionData: []byte{1}, AccessList: AccessList{{Address: common.Address{1}}}},
+			params.Rip7560TxGas + 16 + params.TxAccessListAddressGas,
+			params.Rip7560TxGas + 16 + params.TxAccessListAddressGas,
+		},
+		{
+			"calldata",
+			&Rip7560AccountAbstractionTx{ExecutionData: bytes.Repeat([]byte{1}, 1000), PaymasterData: make([]byte, 100)},
+			params.Rip7560TxGas + 1000*16 + 100*4,
+			params.Rip7560TxGas + (1000*4+100)*params.TxCostFloorPerToken,
+		},
+	}
+	for _, tt := range tests {
+		if have, err := tt.tx.PreTransactionGasCost(false); err != nil || have != tt.prePrague {
+			t.Errorf("%s: gas mismatch before Prague: have %d (%v), want %d", tt.name, have, err, tt.prePrague)
+		}
+		if have, err := tt.tx.PreTransactionGasCost(true); err != nil || have != tt.prague {
+			t.Errorf("%s: gas mismatch after Prague: have %d (%v), want %d", tt.name, have, err, tt.prague)
+		}
+	}
+}
@@ -533,3 +533,12 @@ func enable4762(jt *JumpTable) {
 		}
 	}
 }
+
+// enable7702 applies the EIP-7702 changes to support delegation designators: calls to a
+// delegating account also pay for accessing the code it delegates to.
+func enable7702(jt *JumpTable) {
+	jt[CALL].dynamicGas = gasCallEIP7702
+	jt[CALLCODE].dynamicGas = gasCallCodeEIP7702
+	jt[STATICCALL].dynamicGas = gasStaticCallEIP7702
+	jt[DELEGATECALL].dynamicGas = gasDelegateCallEIP7702
+}
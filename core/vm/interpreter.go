@@ -18,6 +18,7 @@ package vm
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -39,6 +40,9 @@ type Config struct {
 	EnablePreimageRecording bool  // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int // Additional EIPS that are to be enabled
 	EnableWitnessCollection bool  // true if witness collection is enabled
+	Rip7560GasInvariants    bool  // Enables the gas accounting assertions of RIP-7560 transactions
+
+	Rip7560ValidationTimeout time.Duration // Wall-clock limit of the validation phase of RIP-7560 transactions (0 = unlimited)
 
 	PrecompileOverrides PrecompileOverrides // Precompiles can be swapped / changed / wrapped as needed
 }
@@ -135,6 +139,12 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 	default:
 		table = &frontierInstructionSet
 	}
+	// Calls follow the delegation designators set by the EIP-7702 authorizations of
+	// RIP-7560 transactions, charged on top of the EIP-2929 access costs
+	if evm.chainRules.IsRIP7560EIP7702 && evm.chainRules.IsEIP2929 {
+		table = copyJumpTable(table)
+		enable7702(table)
+	}
 	var extraEips []int
 	if len(evm.Config.ExtraEips) > 0 {
 		// Deep-copy jumptable to prevent modification of opcodes in other tables
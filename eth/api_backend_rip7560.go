@@ -3,19 +3,137 @@ package eth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
 )
 
 func (b *EthAPIBackend) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
 	if !b.rip7560AcceptPush {
 		return errors.New("illegal call to eth_sendRip7560TransactionsBundle: Config.Eth.Rip7560AcceptPush is not set")
 	}
-	return b.eth.txPool.SubmitRip7560Bundle(bundle)
+	if err := b.eth.txPool.SubmitRip7560Bundle(bundle); err != nil {
+		return err
+	}
+	if b.eth.rip7560ForwardRPC == nil {
+		return nil
+	}
+	// Relay the accepted bundle to the upstream block producer
+	data, err := rlp.EncodeToBytes(bundle)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), rip7560ForwardTimeout)
+	defer cancel()
+	var hash common.Hash
+	if err := b.eth.rip7560ForwardRPC.CallContext(ctx, &hash, "eth_sendRawRip7560Bundle", hexutil.Encode(data)); err != nil {
+		log.Warn("Failed to forward RIP-7560 bundle", "hash", bundle.BundleHash, "err", err)
+		return fmt.Errorf("failed to forward bundle to block producer: %w", err)
+	}
+	return nil
+}
+
+// CheckRip7560Bundle runs the admission checks of the local pool on the bundle without
+// submitting or relaying it.
+func (b *EthAPIBackend) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	if !b.rip7560AcceptPush {
+		return errors.New("illegal call to eth_sendRip7560TransactionsBundle: Config.Eth.Rip7560AcceptPush is not set")
+	}
+	return b.eth.txPool.CheckRip7560Bundle(bundle)
+}
+
+func (b *EthAPIBackend) ExtendRip7560Bundle(ctx context.Context, hash common.Hash, validUntil *big.Int) error {
+	if !b.rip7560AcceptPush {
+		return errors.New("illegal call to eth_extendRip7560BundleValidity: Config.Eth.Rip7560AcceptPush is not set")
+	}
+	if err := b.eth.txPool.ExtendRip7560Bundle(hash, validUntil); err != nil {
+		return err
+	}
+	if b.eth.rip7560ForwardRPC == nil {
+		return nil
+	}
+	// Extend the bundle relayed to the upstream block producer as well
+	ctx, cancel := context.WithTimeout(ctx, rip7560ForwardTimeout)
+	defer cancel()
+	if err := b.eth.rip7560ForwardRPC.CallContext(ctx, nil, "eth_extendRip7560BundleValidity", hash, (*hexutil.Big)(validUntil)); err != nil {
+		return fmt.Errorf("failed to forward bundle extension to block producer: %w", err)
+	}
+	return nil
+}
+
+func (b *EthAPIBackend) CancelRip7560Bundle(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	if !b.rip7560AcceptPush {
+		return nil, errors.New("illegal call to rip7560_cancelBundle: Config.Eth.Rip7560AcceptPush is not set")
+	}
+	receipt, err := b.eth.txPool.CancelRip7560Bundle(hash)
+	if b.eth.rip7560ForwardRPC == nil {
+		return receipt, err
+	}
+	// The bundle relayed to the upstream block producer is the one its builder consumes
+	ctx, cancel := context.WithTimeout(ctx, rip7560ForwardTimeout)
+	defer cancel()
+	if err := b.eth.rip7560ForwardRPC.CallContext(ctx, &receipt, "eth_cancelRip7560Bundle", hash); err != nil {
+		return nil, fmt.Errorf("failed to forward bundle cancellation to block producer: %w", err)
+	}
+	return receipt, nil
 }
 
 func (b *EthAPIBackend) GetRip7560BundleStatus(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
-	return b.eth.txPool.GetRip7560BundleStatus(hash)
+	receipt, err := b.eth.txPool.GetRip7560BundleStatus(hash)
+	if err != nil || receipt != nil || b.eth.rip7560ForwardRPC == nil {
+		return receipt, err
+	}
+	// The bundle may only be known to the upstream block producer
+	ctx, cancel := context.WithTimeout(ctx, rip7560ForwardTimeout)
+	defer cancel()
+	if err := b.eth.rip7560ForwardRPC.CallContext(ctx, &receipt, "eth_getRip7560BundleStatus", hash); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+func (b *EthAPIBackend) SubscribeRip7560TxStatusEvent(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	return b.eth.txPool.SubscribeRip7560TxStatus(ch)
+}
+
+func (b *EthAPIBackend) Rip7560FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, [][]*big.Int, error) {
+	return b.gpo.Rip7560FeeHistory(ctx, blockCount, lastBlock, percentiles)
+}
+
+func (b *EthAPIBackend) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	return b.eth.txPool.Rip7560InclusionStats()
+}
+
+// Rip7560PaymasterStats returns the sponsorship stats of the paymaster within every
+// indexed section overlapping the given block range, skipping the sections in which
+// it sponsored no transactions.
+func (b *EthAPIBackend) Rip7560PaymasterStats(ctx context.Context, paymaster common.Address, fromBlock, toBlock uint64) ([]*types.Rip7560PaymasterStats, error) {
+	indexer := b.eth.rip7560PaymasterIndexer
+	if indexer == nil {
+		return nil, errors.New("RIP-7560 paymaster indexing is disabled: Config.Eth.Rip7560PaymasterIndexBlocks is not set")
+	}
+	var (
+		size           = b.eth.config.Rip7560PaymasterIndexBlocks
+		sections, _, _ = indexer.Sections()
+		result         []*types.Rip7560PaymasterStats
+	)
+	for section := fromBlock / size; section <= toBlock/size && section < sections; section++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if stats := rawdb.ReadRip7560PaymasterStats(b.eth.chainDb, section, paymaster); stats != nil {
+			result = append(result, stats)
+		}
+	}
+	return result, nil
 }
 
 // GetRip7560TransactionDebugInfo debug method for RIP-7560
@@ -29,6 +147,7 @@ func (b *EthAPIBackend) GetRip7560TransactionDebugInfo(hash common.Hash) (map[st
 		"revertEntityName": info.RevertEntityName,
 		"revertData":       info.RevertData,
 		"frameReverted":    info.FrameReverted,
+		"frames":           info.Frames,
 	}, nil
 }
 
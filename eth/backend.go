@@ -79,6 +79,7 @@ type Ethereum struct {
 
 	seqRPCService        *rpc.Client
 	historicalRPCService *rpc.Client
+	rip7560ForwardRPC    *rpc.Client
 
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
@@ -91,6 +92,8 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	rip7560PaymasterIndexer *core.ChainIndexer // RIP-7560 paymaster stats indexer, nil if disabled
+
 	APIBackend *EthAPIBackend
 
 	miner    *miner.Miner
@@ -115,6 +118,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if !config.Miner.Rip7560Bundles.IsValid() {
+		return nil, fmt.Errorf("invalid RIP-7560 bundle inclusion mode %q", config.Miner.Rip7560Bundles)
+	}
 	if config.Miner.GasPrice == nil || config.Miner.GasPrice.Sign() <= 0 {
 		log.Warn("Sanitizing invalid miner gas price", "provided", config.Miner.GasPrice, "updated", ethconfig.Defaults.Miner.GasPrice)
 		config.Miner.GasPrice = new(big.Int).Set(ethconfig.Defaults.Miner.GasPrice)
@@ -193,6 +199,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EnableWitnessCollection: config.EnableWitnessCollection,
+			Rip7560GasInvariants:    config.Rip7560GasInvariants,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -260,6 +267,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	log.Info("Initialising Ethereum protocol", "network", config.NetworkId, "dbversion", dbVer)
 
 	eth.bloomIndexer.Start(eth.blockchain)
+	if config.Rip7560PaymasterIndexBlocks > 0 {
+		eth.rip7560PaymasterIndexer = core.NewRip7560PaymasterIndexer(chainDb, eth.blockchain.Config(), config.Rip7560PaymasterIndexBlocks, params.BloomConfirms)
+		eth.rip7560PaymasterIndexer.Start(eth.blockchain)
+	}
 
 	if config.BlobPool.Datadir != "" {
 		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
@@ -270,14 +281,23 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
 
+	if config.Rip7560Journal != "" {
+		config.Rip7560Journal = stack.ResolvePath(config.Rip7560Journal)
+	}
 	rip7560PoolConfig := rip7560pool.Config{
 		MaxBundleGas:  config.Rip7560MaxBundleGas,
 		MaxBundleSize: config.Rip7560MaxBundleSize,
 		PullUrls:      config.Rip7560PullUrls,
+		Journal:       config.Rip7560Journal,
+
+		MaxBundlerBundles: config.Rip7560MaxBundlerBundles,
+		MaxBundlerGas:     config.Rip7560MaxBundlerGas,
 	}
 	rip7560 := rip7560pool.New(rip7560PoolConfig, eth.blockchain, config.Miner.Etherbase)
+	rip7560Native := rip7560pool.NewNative(config.Rip7560Pool, eth.blockchain, config.Miner.Etherbase)
 
-	txPools := []txpool.SubPool{legacyPool, rip7560}
+	// The bundler pool goes first, pushed bundles take precedence over the native ones
+	txPools := []txpool.SubPool{legacyPool, rip7560, rip7560Native}
 	if !eth.BlockChain().Config().IsOptimism() {
 		blobPool := blobpool.New(config.BlobPool, eth.blockchain)
 		txPools = append(txPools, blobPool)
@@ -300,6 +320,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
 		NoTxGossip:     config.RollupDisableTxPoolGossip,
+
+		Rip7560TxGossip:          config.Rip7560TxGossip,
+		Rip7560PeerMaxInvalidTxs: config.Rip7560PeerMaxInvalidTxs,
 	}); err != nil {
 		return nil, err
 	}
@@ -344,6 +367,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		eth.historicalRPCService = client
 	}
 
+	if config.Rip7560ForwardUrl != "" {
+		client, err := dialRip7560Forwarder(config.Rip7560ForwardUrl, config.Rip7560ForwardJwtSecret)
+		if err != nil {
+			return nil, err
+		}
+		eth.rip7560ForwardRPC = client
+	}
+
 	// Start the RPC service
 	eth.netRPCService = ethapi.NewNetAPI(eth.p2pServer, networkID)
 
@@ -430,6 +461,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	if config := s.blockchain.Config(); (config.RIP7560Block != nil || config.RIP7560Time != nil) && s.config.Rip7560TxGossip {
+		protos = append(protos, eth.MakeRip7560Protocols()...)
+	}
 	return protos
 }
 
@@ -468,6 +502,9 @@ func (s *Ethereum) Stop() error {
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
+	if s.rip7560PaymasterIndexer != nil {
+		s.rip7560PaymasterIndexer.Close()
+	}
 	s.txPool.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
@@ -477,6 +514,9 @@ func (s *Ethereum) Stop() error {
 	if s.historicalRPCService != nil {
 		s.historicalRPCService.Close()
 	}
+	if s.rip7560ForwardRPC != nil {
+		s.rip7560ForwardRPC.Close()
+	}
 
 	// Clean shutdown marker as the last thing before closing db
 	s.shutdownTracker.Stop()
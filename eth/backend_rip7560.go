@@ -0,0 +1,36 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"os"
+	"strings"
+	"time"
+)
+
+// rip7560ForwardTimeout bounds the calls relaying RIP-7560 bundles and status
+// queries to the upstream block producer.
+const rip7560ForwardTimeout = 5 * time.Second
+
+// dialRip7560Forwarder connects to the authenticated RPC endpoint of the block
+// producer that accepted RIP-7560 bundles are relayed to.
+func dialRip7560Forwarder(url string, secretFile string) (*rpc.Client, error) {
+	if secretFile == "" {
+		return nil, errors.New("RIP-7560 bundle forwarding requires a JWT secret")
+	}
+	data, err := os.ReadFile(secretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RIP-7560 forwarding JWT secret: %w", err)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid RIP-7560 forwarding JWT secret length %d", len(secret))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), rip7560ForwardTimeout)
+	defer cancel()
+	return rpc.DialOptions(ctx, url, rpc.WithHTTPAuth(node.NewJWTAuth([32]byte(secret))))
+}
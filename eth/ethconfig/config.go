@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -64,10 +65,12 @@ var Defaults = Config{
 	Miner:              miner.DefaultConfig,
 	TxPool:             legacypool.DefaultConfig,
 	BlobPool:           blobpool.DefaultConfig,
+	Rip7560Pool:        rip7560pool.DefaultNativeConfig,
 	RPCGasCap:          50000000,
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
+	Rip7560Journal:     "rip7560bundles.rlp",
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -133,8 +136,9 @@ type Config struct {
 	Miner miner.Config
 
 	// Transaction pool options
-	TxPool   legacypool.Config
-	BlobPool blobpool.Config
+	TxPool      legacypool.Config
+	BlobPool    blobpool.Config
+	Rip7560Pool rip7560pool.NativeConfig // Limits of the pool of individually submitted RIP-7560 transactions
 
 	// Gas Price Oracle options
 	GPO gasprice.Config
@@ -196,11 +200,44 @@ type Config struct {
 	// Rip7560MaxBundleSize is the maximum number of transactions an RIP-7560 bundle can contain
 	Rip7560MaxBundleSize *uint64 `toml:",omitempty"`
 
+	// Rip7560MaxBundlerBundles is the maximum number of pending RIP-7560 bundles of a single bundler (0 = unlimited)
+	Rip7560MaxBundlerBundles uint64 `toml:",omitempty"`
+
+	// Rip7560MaxBundlerGas is the maximum aggregate gas limit of the pending RIP-7560 bundles of a single bundler (0 = unlimited)
+	Rip7560MaxBundlerGas uint64 `toml:",omitempty"`
+
 	// Rip7560PullUrls provides a list of bundlers the node will ask for new bundles for each block
 	Rip7560PullUrls []string
 
 	// Rip7560AcceptPush when set to "true" the node will accept incoming 'eth_sendRip7560TransactionsBundle'
 	Rip7560AcceptPush bool `toml:",omitempty"`
+
+	// Rip7560Journal is the file pending pushed RIP-7560 bundles are journaled to (empty = disabled)
+	Rip7560Journal string `toml:",omitempty"`
+
+	// Rip7560TxGossip enables the announcement and retrieval of RIP-7560 transactions
+	// to and from the peers advertising the `rip7560` capability
+	Rip7560TxGossip bool `toml:",omitempty"`
+
+	// Rip7560PeerMaxInvalidTxs is the number of invalid RIP-7560 transactions a peer may
+	// deliver in excess of the valid ones before it gets disconnected (0 = default)
+	Rip7560PeerMaxInvalidTxs int `toml:",omitempty"`
+
+	// Rip7560ForwardUrl is the authenticated RPC endpoint of the block producer that
+	// accepted RIP-7560 bundles are relayed to (empty = disabled)
+	Rip7560ForwardUrl string `toml:",omitempty"`
+
+	// Rip7560ForwardJwtSecret is the file holding the JWT secret used to authenticate
+	// with the block producer at Rip7560ForwardUrl
+	Rip7560ForwardJwtSecret string `toml:",omitempty"`
+
+	// Rip7560GasInvariants enables the debug assertions on the gas accounting of
+	// RIP-7560 transactions, failing the ones violating them
+	Rip7560GasInvariants bool `toml:",omitempty"`
+
+	// Rip7560PaymasterIndexBlocks is the number of blocks the RIP-7560 paymaster
+	// sponsorship stats are aggregated over (0 = indexing disabled)
+	Rip7560PaymasterIndexBlocks uint64 `toml:",omitempty"`
 }
 
 // CreateConsensusEngine creates a consensus engine for the given chain config.
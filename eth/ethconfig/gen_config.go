@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
@@ -48,6 +49,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Miner                                   miner.Config
 		TxPool                                  legacypool.Config
 		BlobPool                                blobpool.Config
+		Rip7560Pool                             rip7560pool.NativeConfig
 		GPO                                     gasprice.Config
 		EnablePreimageRecording                 bool
 		EnableWitnessCollection                 bool `toml:"-"`
@@ -74,8 +76,17 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		RollupHaltOnIncompatibleProtocolVersion string
 		Rip7560MaxBundleGas                     *uint64 `toml:",omitempty"`
 		Rip7560MaxBundleSize                    *uint64 `toml:",omitempty"`
+		Rip7560MaxBundlerBundles                uint64  `toml:",omitempty"`
+		Rip7560MaxBundlerGas                    uint64  `toml:",omitempty"`
 		Rip7560PullUrls                         []string
-		Rip7560AcceptPush                       bool `toml:",omitempty"`
+		Rip7560AcceptPush                       bool   `toml:",omitempty"`
+		Rip7560Journal                          string `toml:",omitempty"`
+		Rip7560TxGossip                         bool   `toml:",omitempty"`
+		Rip7560PeerMaxInvalidTxs                int    `toml:",omitempty"`
+		Rip7560ForwardUrl                       string `toml:",omitempty"`
+		Rip7560ForwardJwtSecret                 string `toml:",omitempty"`
+		Rip7560GasInvariants                    bool   `toml:",omitempty"`
+		Rip7560PaymasterIndexBlocks             uint64 `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -109,6 +120,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
+	enc.Rip7560Pool = c.Rip7560Pool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.EnableWitnessCollection = c.EnableWitnessCollection
@@ -135,8 +147,17 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RollupHaltOnIncompatibleProtocolVersion = c.RollupHaltOnIncompatibleProtocolVersion
 	enc.Rip7560MaxBundleGas = c.Rip7560MaxBundleGas
 	enc.Rip7560MaxBundleSize = c.Rip7560MaxBundleSize
+	enc.Rip7560MaxBundlerBundles = c.Rip7560MaxBundlerBundles
+	enc.Rip7560MaxBundlerGas = c.Rip7560MaxBundlerGas
 	enc.Rip7560PullUrls = c.Rip7560PullUrls
 	enc.Rip7560AcceptPush = c.Rip7560AcceptPush
+	enc.Rip7560Journal = c.Rip7560Journal
+	enc.Rip7560TxGossip = c.Rip7560TxGossip
+	enc.Rip7560PeerMaxInvalidTxs = c.Rip7560PeerMaxInvalidTxs
+	enc.Rip7560ForwardUrl = c.Rip7560ForwardUrl
+	enc.Rip7560ForwardJwtSecret = c.Rip7560ForwardJwtSecret
+	enc.Rip7560GasInvariants = c.Rip7560GasInvariants
+	enc.Rip7560PaymasterIndexBlocks = c.Rip7560PaymasterIndexBlocks
 	return &enc, nil
 }
 
@@ -174,6 +195,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Miner                                   *miner.Config
 		TxPool                                  *legacypool.Config
 		BlobPool                                *blobpool.Config
+		Rip7560Pool                             *rip7560pool.NativeConfig
 		GPO                                     *gasprice.Config
 		EnablePreimageRecording                 *bool
 		EnableWitnessCollection                 *bool `toml:"-"`
@@ -200,8 +222,17 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		RollupHaltOnIncompatibleProtocolVersion *string
 		Rip7560MaxBundleGas                     *uint64 `toml:",omitempty"`
 		Rip7560MaxBundleSize                    *uint64 `toml:",omitempty"`
+		Rip7560MaxBundlerBundles                *uint64 `toml:",omitempty"`
+		Rip7560MaxBundlerGas                    *uint64 `toml:",omitempty"`
 		Rip7560PullUrls                         []string
-		Rip7560AcceptPush                       *bool `toml:",omitempty"`
+		Rip7560AcceptPush                       *bool   `toml:",omitempty"`
+		Rip7560Journal                          *string `toml:",omitempty"`
+		Rip7560TxGossip                         *bool   `toml:",omitempty"`
+		Rip7560PeerMaxInvalidTxs                *int    `toml:",omitempty"`
+		Rip7560ForwardUrl                       *string `toml:",omitempty"`
+		Rip7560ForwardJwtSecret                 *string `toml:",omitempty"`
+		Rip7560GasInvariants                    *bool   `toml:",omitempty"`
+		Rip7560PaymasterIndexBlocks             *uint64 `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -300,6 +331,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.BlobPool != nil {
 		c.BlobPool = *dec.BlobPool
 	}
+	if dec.Rip7560Pool != nil {
+		c.Rip7560Pool = *dec.Rip7560Pool
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
@@ -378,11 +412,38 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Rip7560MaxBundleSize != nil {
 		c.Rip7560MaxBundleSize = dec.Rip7560MaxBundleSize
 	}
+	if dec.Rip7560MaxBundlerBundles != nil {
+		c.Rip7560MaxBundlerBundles = *dec.Rip7560MaxBundlerBundles
+	}
+	if dec.Rip7560MaxBundlerGas != nil {
+		c.Rip7560MaxBundlerGas = *dec.Rip7560MaxBundlerGas
+	}
 	if dec.Rip7560PullUrls != nil {
 		c.Rip7560PullUrls = dec.Rip7560PullUrls
 	}
 	if dec.Rip7560AcceptPush != nil {
 		c.Rip7560AcceptPush = *dec.Rip7560AcceptPush
 	}
+	if dec.Rip7560Journal != nil {
+		c.Rip7560Journal = *dec.Rip7560Journal
+	}
+	if dec.Rip7560TxGossip != nil {
+		c.Rip7560TxGossip = *dec.Rip7560TxGossip
+	}
+	if dec.Rip7560PeerMaxInvalidTxs != nil {
+		c.Rip7560PeerMaxInvalidTxs = *dec.Rip7560PeerMaxInvalidTxs
+	}
+	if dec.Rip7560ForwardUrl != nil {
+		c.Rip7560ForwardUrl = *dec.Rip7560ForwardUrl
+	}
+	if dec.Rip7560ForwardJwtSecret != nil {
+		c.Rip7560ForwardJwtSecret = *dec.Rip7560ForwardJwtSecret
+	}
+	if dec.Rip7560GasInvariants != nil {
+		c.Rip7560GasInvariants = *dec.Rip7560GasInvariants
+	}
+	if dec.Rip7560PaymasterIndexBlocks != nil {
+		c.Rip7560PaymasterIndexBlocks = *dec.Rip7560PaymasterIndexBlocks
+	}
 	return nil
 }
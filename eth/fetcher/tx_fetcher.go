@@ -195,6 +195,9 @@ type TxFetcher struct {
 	fetchTxs func(string, []common.Hash) error  // Retrieves a set of txs from a remote peer
 	dropPeer func(string)                       // Drops a peer in case of announcement violation
 
+	rip7560Scores  *rip7560PeerScores // Invalid RIP-7560 transactions delivered per peer
+	rip7560Limiter *rip7560Limiter    // Per-peer RIP-7560 validation budgets
+
 	step  chan struct{} // Notification channel when the fetcher loop iterates
 	clock mclock.Clock  // Time wrapper to simulate in tests
 	rand  *mrand.Rand   // Randomizer to use in tests instead of map range loops (soft-random)
@@ -231,6 +234,9 @@ func NewTxFetcherForTests(
 		dropPeer:    dropPeer,
 		clock:       clock,
 		rand:        rand,
+
+		rip7560Scores:  newRip7560PeerScores(defaultRip7560MaxInvalidTxs),
+		rip7560Limiter: newRip7560Limiter(),
 	}
 }
 
@@ -313,11 +319,20 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 	// Keep track of all the propagated transactions
 	inMeter.Mark(int64(len(txs)))
 
+	// Throttle the validation of RIP-7560 transactions, queueing the ones exceeding
+	// the budget of the peer to be added to the pool later on
+	txs, queued, busy := f.rip7560Limiter.admit(peer, txs, f.clock.Now())
+	if busy && !f.rip7560Limiter.acquire(f.quit) {
+		return errTerminated
+	}
+
 	// Push all the transactions into the pool, tracking underpriced ones to avoid
 	// re-requesting them and dropping the peer in case of malicious transfers.
 	var (
 		added = make([]common.Hash, 0, len(txs))
 		metas = make([]txMetadata, 0, len(txs))
+
+		dropped bool // Whether the peer has been dropped for delivering invalid RIP-7560 transactions
 	)
 	// proceed in batches
 	for i := 0; i < len(txs); i += 128 {
@@ -352,6 +367,16 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			default:
 				otherreject++
 			}
+			// Penalize peers consistently delivering invalid RIP-7560 transactions,
+			// as their validation requires an expensive EVM execution
+			if batch[j].Type() == types.Rip7560Type && !dropped {
+				if f.rip7560Scores.record(peer, err) {
+					log.Debug("Peer delivering invalid RIP-7560 transactions", "peer", peer)
+					txRip7560InvalidPeerDropMeter.Mark(1)
+					f.dropPeer(peer)
+					dropped = true
+				}
+			}
 			added = append(added, batch[j].Hash())
 			metas = append(metas, txMetadata{
 				kind: batch[j].Type(),
@@ -368,6 +393,17 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			log.Debug("Peer delivering stale transactions", "peer", peer, "rejected", otherreject)
 		}
 	}
+	if busy {
+		f.rip7560Limiter.done(peer)
+	}
+	// Queued transactions are in our possession, don't retrieve them again
+	for _, tx := range queued {
+		added = append(added, tx.Hash())
+		metas = append(metas, txMetadata{
+			kind: tx.Type(),
+			size: uint32(tx.Size()),
+		})
+	}
 	select {
 	case f.cleanup <- &txDelivery{origin: peer, hashes: added, metas: metas, direct: direct}:
 		return nil
@@ -391,6 +427,7 @@ func (f *TxFetcher) Drop(peer string) error {
 // hash notifications and block fetches until termination requested.
 func (f *TxFetcher) Start() {
 	go f.loop()
+	go f.rip7560Loop()
 }
 
 // Stop terminates the announcement based synchroniser, canceling all pending
@@ -430,6 +467,10 @@ func (f *TxFetcher) loop() {
 				ann.hashes = ann.hashes[:want-maxTxAnnounces]
 				ann.metas = ann.metas[:want-maxTxAnnounces]
 			}
+			f.capRip7560Announces(ann)
+			if len(ann.hashes) == 0 {
+				break
+			}
 			// All is well, schedule the remainder of the transactions
 			idleWait := len(f.waittime) == 0
 			_, oldPeer := f.announces[ann.origin]
@@ -713,6 +754,8 @@ func (f *TxFetcher) loop() {
 
 		case drop := <-f.drop:
 			// A peer was dropped, remove all traces of it
+			f.rip7560Scores.forget(drop.peer)
+			f.rip7560Limiter.forget(drop.peer)
 			if _, ok := f.waitslots[drop.peer]; ok {
 				for hash := range f.waitslots[drop.peer] {
 					delete(f.waitlist[hash], drop.peer)
@@ -870,14 +913,22 @@ func (f *TxFetcher) scheduleFetches(timer *mclock.Timer, timeout chan struct{},
 			return // continue in the for-each
 		}
 		var (
-			hashes = make([]common.Hash, 0, maxTxRetrievals)
-			bytes  uint64
+			hashes   = make([]common.Hash, 0, maxTxRetrievals)
+			bytes    uint64
+			rip7560s int
 		)
 		f.forEachAnnounce(f.announces[peer], func(hash common.Hash, meta *txMetadata) bool {
 			// If the transaction is already fetching, skip to the next one
 			if _, ok := f.fetching[hash]; ok {
 				return true
 			}
+			// If the RIP-7560 budget of the request is exhausted, skip to the next one
+			if meta.isRip7560() {
+				if rip7560s >= maxRip7560TxRetrievals {
+					return true
+				}
+				rip7560s++
+			}
 			// Mark the hash as fetching and stash away possible alternates
 			f.fetching[hash] = peer
 
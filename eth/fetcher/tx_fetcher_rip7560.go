@@ -0,0 +1,342 @@
+package fetcher
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRip7560TxAnnounces is the maximum number of unique RIP-7560 transactions
+	// a peer can announce in a short time. It is much lower than maxTxAnnounces as
+	// admitting an RIP-7560 transaction requires executing its validation frames.
+	maxRip7560TxAnnounces = 256
+
+	// maxRip7560TxRetrievals is the maximum number of RIP-7560 transactions that
+	// can be fetched in one request.
+	maxRip7560TxRetrievals = 16
+)
+
+var txAnnounceRip7560DOSMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/announces/rip7560/dos", nil)
+
+// isRip7560 reports whether the announced metadata describes an RIP-7560 transaction.
+func (meta *txMetadata) isRip7560() bool {
+	return meta != nil && meta.kind == types.Rip7560Type
+}
+
+// capRip7560Announces drops the RIP-7560 transactions of an announcement that
+// exceed the budget of pending RIP-7560 announcements of the originating peer.
+func (f *TxFetcher) capRip7560Announces(ann *txAnnounce) {
+	var used int
+	for _, meta := range f.waitslots[ann.origin] {
+		if meta.isRip7560() {
+			used++
+		}
+	}
+	for _, meta := range f.announces[ann.origin] {
+		if meta.isRip7560() {
+			used++
+		}
+	}
+	var (
+		hashes  = ann.hashes[:0]
+		metas   = ann.metas[:0]
+		dropped int64
+	)
+	for i, hash := range ann.hashes {
+		if ann.metas[i].isRip7560() {
+			if used >= maxRip7560TxAnnounces {
+				dropped++
+				continue
+			}
+			used++
+		}
+		hashes = append(hashes, hash)
+		metas = append(metas, ann.metas[i])
+	}
+	ann.hashes, ann.metas = hashes, metas
+	txAnnounceRip7560DOSMeter.Mark(dropped)
+}
+
+// defaultRip7560MaxInvalidTxs is the default number of invalid RIP-7560 transactions
+// a peer may deliver in excess of the valid ones before it gets disconnected.
+const defaultRip7560MaxInvalidTxs = 16
+
+var txRip7560InvalidPeerDropMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/rip7560/invalid/drop", nil)
+
+// rip7560PeerScores tracks the RIP-7560 transactions failing the pool's static or
+// validation-phase checks per peer. Every invalid transaction raises the score of
+// the delivering peer and every valid one lowers it, so that only peers which
+// consistently feed invalid transactions reach the limit.
+type rip7560PeerScores struct {
+	limit  int
+	scores map[string]int
+	lock   sync.Mutex
+}
+
+func newRip7560PeerScores(limit int) *rip7560PeerScores {
+	return &rip7560PeerScores{limit: limit, scores: make(map[string]int)}
+}
+
+// record accounts the outcome of adding a delivered RIP-7560 transaction to the
+// pool to the peer and reports whether the peer has reached the limit of invalid
+// transactions. Rejections unrelated to the validity of the transaction are ignored.
+func (s *rip7560PeerScores) record(peer string, err error) bool {
+	if err != nil && !isInvalidRip7560Tx(err) {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err == nil {
+		if s.scores[peer] > 0 {
+			s.scores[peer]--
+		}
+		return false
+	}
+	s.scores[peer]++
+	return s.scores[peer] >= s.limit
+}
+
+// forget removes all traces of a disconnected peer.
+func (s *rip7560PeerScores) forget(peer string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.scores, peer)
+}
+
+// SetRip7560MaxInvalidTxs sets the number of invalid RIP-7560 transactions a peer
+// may deliver in excess of the valid ones before it gets disconnected.
+func (f *TxFetcher) SetRip7560MaxInvalidTxs(limit int) {
+	f.rip7560Scores = newRip7560PeerScores(limit)
+}
+
+// isInvalidRip7560Tx reports whether the pool rejected an RIP-7560 transaction
+// because it failed its checks, rather than being already known, underpriced or
+// not supported locally.
+func isInvalidRip7560Tx(err error) bool {
+	return err != nil &&
+		!errors.Is(err, txpool.ErrAlreadyKnown) &&
+		!errors.Is(err, txpool.ErrUnderpriced) &&
+		!errors.Is(err, txpool.ErrReplaceUnderpriced) &&
+		!errors.Is(err, core.ErrTxTypeNotSupported)
+}
+
+const (
+	// rip7560TxRate is the number of RIP-7560 transactions per second a peer may
+	// have validated, once it exhausted its rip7560TxBurst allowance.
+	rip7560TxRate = 32
+
+	// rip7560TxBurst is the number of RIP-7560 transactions a peer may have
+	// validated in a short burst.
+	rip7560TxBurst = 64
+
+	// maxRip7560TxQueued is the maximum number of RIP-7560 transactions exceeding
+	// the budget of a peer that are queued for later validation.
+	maxRip7560TxQueued = 256
+
+	// maxRip7560Validations is the maximum number of concurrent RIP-7560 batches
+	// being validated across all peers.
+	maxRip7560Validations = 4
+
+	// rip7560QueueInterval is the interval at which the queued RIP-7560 transactions
+	// are validated as the budgets of their peers allow.
+	rip7560QueueInterval = 100 * time.Millisecond
+)
+
+var (
+	txRip7560QueuedMeter      = metrics.NewRegisteredMeter("eth/fetcher/transaction/rip7560/queued", nil)
+	txRip7560QueueDropMeter   = metrics.NewRegisteredMeter("eth/fetcher/transaction/rip7560/queue/drop", nil)
+	txRip7560ValidationsMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/rip7560/validations", nil)
+	txRip7560ValidationsGauge = metrics.NewRegisteredGauge("eth/fetcher/transaction/rip7560/validations/active", nil)
+)
+
+// rip7560PeerBudget is the RIP-7560 validation budget of a single peer.
+type rip7560PeerBudget struct {
+	tokens float64              // Number of transactions the peer may have validated right now
+	last   mclock.AbsTime       // Last time the tokens were refilled
+	busy   bool                 // Whether transactions of the peer are being validated
+	queue  []*types.Transaction // Transactions exceeding the budget, waiting for validation
+}
+
+// refill tops up the tokens of the peer for the time elapsed since the last refill.
+func (b *rip7560PeerBudget) refill(now mclock.AbsTime) {
+	b.tokens = math.Min(rip7560TxBurst, b.tokens+rip7560TxRate*time.Duration(now-b.last).Seconds())
+	b.last = now
+}
+
+// rip7560Limiter throttles the validation of the RIP-7560 transactions delivered
+// by peers. Validating such transactions requires executing EVM frames, so each
+// peer gets a rate budget and may only have a single batch validated at a time,
+// while the number of batches validated concurrently across peers is capped.
+type rip7560Limiter struct {
+	peers map[string]*rip7560PeerBudget
+	slots chan struct{} // Validation slots shared by all peers
+	lock  sync.Mutex
+}
+
+func newRip7560Limiter() *rip7560Limiter {
+	return &rip7560Limiter{
+		peers: make(map[string]*rip7560PeerBudget),
+		slots: make(chan struct{}, maxRip7560Validations),
+	}
+}
+
+// admit splits the delivered transactions into the ones that can be validated
+// right away and the RIP-7560 ones queued for later as they exceed the budget of
+// the peer. RIP-7560 transactions overflowing the queue are dropped. The peer is
+// marked busy if any RIP-7560 transaction is admitted, which must be followed up
+// by a call to done.
+func (l *rip7560Limiter) admit(peer string, txs []*types.Transaction, now mclock.AbsTime) (admitted []*types.Transaction, queued []*types.Transaction, busy bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	budget := l.peers[peer]
+	if budget == nil {
+		budget = &rip7560PeerBudget{tokens: rip7560TxBurst, last: now}
+		l.peers[peer] = budget
+	}
+	budget.refill(now)
+
+	var (
+		validating = budget.busy
+		dropped    int64
+	)
+	admitted = make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if tx.Type() != types.Rip7560Type {
+			admitted = append(admitted, tx)
+			continue
+		}
+		// Keep the delivery order by not admitting anything while transactions
+		// of the peer are still queued
+		switch {
+		case !validating && len(budget.queue) == 0 && budget.tokens >= 1:
+			budget.tokens--
+			budget.busy, busy = true, true
+			admitted = append(admitted, tx)
+
+		case len(budget.queue) < maxRip7560TxQueued:
+			budget.queue = append(budget.queue, tx)
+			queued = append(queued, tx)
+
+		default:
+			dropped++
+		}
+	}
+	txRip7560QueuedMeter.Mark(int64(len(queued)))
+	txRip7560QueueDropMeter.Mark(dropped)
+	return admitted, queued, busy
+}
+
+// next pops a batch of queued transactions of a peer that is not busy and has
+// budget for them, marking the peer busy. It returns nil if there is none.
+func (l *rip7560Limiter) next(now mclock.AbsTime) (string, []*types.Transaction) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for peer, budget := range l.peers {
+		if budget.busy || len(budget.queue) == 0 {
+			continue
+		}
+		budget.refill(now)
+
+		n := min(int(budget.tokens), len(budget.queue), maxRip7560TxRetrievals)
+		if n == 0 {
+			continue
+		}
+		txs := budget.queue[:n:n]
+		budget.queue = budget.queue[n:]
+		budget.tokens -= float64(n)
+		budget.busy = true
+		return peer, txs
+	}
+	return "", nil
+}
+
+// acquire waits for a validation slot to become available, returning false if
+// the fetcher is terminated in the meantime.
+func (l *rip7560Limiter) acquire(quit chan struct{}) bool {
+	select {
+	case l.slots <- struct{}{}:
+		txRip7560ValidationsMeter.Mark(1)
+		txRip7560ValidationsGauge.Inc(1)
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// done releases the validation slot acquired for a busy peer.
+func (l *rip7560Limiter) done(peer string) {
+	<-l.slots
+	txRip7560ValidationsGauge.Dec(1)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if budget := l.peers[peer]; budget != nil {
+		budget.busy = false
+	}
+}
+
+// forget removes all traces of a disconnected peer, dropping its queued transactions.
+func (l *rip7560Limiter) forget(peer string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if budget := l.peers[peer]; budget != nil {
+		txRip7560QueueDropMeter.Mark(int64(len(budget.queue)))
+		delete(l.peers, peer)
+	}
+}
+
+// rip7560Loop periodically validates the queued RIP-7560 transactions as the
+// budgets of their peers allow.
+func (f *TxFetcher) rip7560Loop() {
+	for {
+		timer := f.clock.NewTimer(rip7560QueueInterval)
+		select {
+		case <-timer.C():
+		case <-f.quit:
+			timer.Stop()
+			return
+		}
+		for {
+			peer, txs := f.rip7560Limiter.next(f.clock.Now())
+			if txs == nil {
+				break
+			}
+			if !f.rip7560Limiter.acquire(f.quit) {
+				return
+			}
+			f.addQueuedRip7560Txs(peer, txs)
+			f.rip7560Limiter.done(peer)
+		}
+	}
+}
+
+// addQueuedRip7560Txs adds previously queued RIP-7560 transactions of a peer to
+// the pool. They were already reported as delivered when queued, so only the
+// underpriced tracking and the scoring of the peer remain to be done.
+func (f *TxFetcher) addQueuedRip7560Txs(peer string, txs []*types.Transaction) {
+	for i, err := range f.addTxs(txs) {
+		if errors.Is(err, txpool.ErrUnderpriced) || errors.Is(err, txpool.ErrReplaceUnderpriced) {
+			f.underpriced.Add(txs[i].Hash(), txs[i].Time())
+		}
+		if f.rip7560Scores.record(peer, err) {
+			log.Debug("Peer delivering invalid RIP-7560 transactions", "peer", peer)
+			txRip7560InvalidPeerDropMeter.Mark(1)
+			f.dropPeer(peer)
+			return
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package fetcher
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Tests that a peer can only have a limited number of RIP-7560 transaction
+// announcements pending, while other transaction types are unaffected.
+func TestTransactionFetcherRip7560DoSProtection(t *testing.T) {
+	var (
+		hashes []common.Hash
+		kinds  []byte
+		sizes  []uint32
+	)
+	for i := 0; i < maxRip7560TxAnnounces+2; i++ {
+		hashes = append(hashes, common.Hash{0x01, byte(i / 256), byte(i % 256)})
+		kinds = append(kinds, types.Rip7560Type)
+		sizes = append(sizes, 100)
+	}
+	legacy := common.Hash{0x02}
+	announces := func(hashes []common.Hash) []announce {
+		anns := make([]announce, len(hashes))
+		for i, hash := range hashes {
+			anns[i] = announce{hash, typeptr(types.Rip7560Type), sizeptr(100)}
+		}
+		return anns
+	}
+	legacyAnnounce := announce{legacy, typeptr(types.LegacyTxType), sizeptr(100)}
+
+	testTransactionFetcherParallel(t, txFetcherTest{
+		init: func() *TxFetcher {
+			return NewTxFetcher(
+				func(common.Hash) bool { return false },
+				nil,
+				func(string, []common.Hash) error { return nil },
+				nil,
+			)
+		},
+		steps: []interface{}{
+			// Announce all but one of the allowed transactions
+			doTxNotify{peer: "A", hashes: hashes[:maxRip7560TxAnnounces-1], types: kinds[:maxRip7560TxAnnounces-1], sizes: sizes[:maxRip7560TxAnnounces-1]},
+			isWaitingWithMeta(map[string][]announce{
+				"A": announces(hashes[:maxRip7560TxAnnounces-1]),
+			}),
+			// Announce more than allowed along with a legacy transaction
+			doTxNotify{
+				peer:   "A",
+				hashes: append([]common.Hash{legacy}, hashes[maxRip7560TxAnnounces-1:]...),
+				types:  append([]byte{types.LegacyTxType}, kinds[maxRip7560TxAnnounces-1:]...),
+				sizes:  append([]uint32{100}, sizes[maxRip7560TxAnnounces-1:]...),
+			},
+			isWaitingWithMeta(map[string][]announce{
+				"A": append([]announce{legacyAnnounce}, announces(hashes[:maxRip7560TxAnnounces])...),
+			}),
+			// Another peer has its own budget
+			doTxNotify{peer: "B", hashes: hashes[maxRip7560TxAnnounces:], types: kinds[maxRip7560TxAnnounces:], sizes: sizes[maxRip7560TxAnnounces:]},
+			isWaitingWithMeta(map[string][]announce{
+				"A": append([]announce{legacyAnnounce}, announces(hashes[:maxRip7560TxAnnounces])...),
+				"B": announces(hashes[maxRip7560TxAnnounces:]),
+			}),
+		},
+	})
+}
+
+// Tests that peers consistently delivering RIP-7560 transactions failing validation
+// get dropped, while invalid transactions of other types or rejections unrelated
+// to validity do not count against them.
+func TestTransactionFetcherRip7560InvalidPeerDrop(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		aaTxs  []*types.Transaction
+	)
+	for i := 0; i < 5; i++ {
+		aaTxs = append(aaTxs, types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       100000,
+			Sender:    &sender,
+		}))
+	}
+	var (
+		errInvalid = errors.New("validation failed")
+		results    = map[common.Hash]error{
+			aaTxs[0].Hash():   errInvalid,
+			aaTxs[1].Hash():   nil,
+			aaTxs[2].Hash():   errInvalid,
+			aaTxs[3].Hash():   txpool.ErrAlreadyKnown,
+			aaTxs[4].Hash():   errInvalid,
+			testTxs[0].Hash(): errInvalid,
+			testTxs[1].Hash(): errInvalid,
+		}
+		drop = make(chan string, 1)
+	)
+	notDropped := doFunc(func() {
+		select {
+		case peer := <-drop:
+			t.Errorf("peer %s dropped prematurely", peer)
+		default:
+		}
+	})
+	testTransactionFetcherParallel(t, txFetcherTest{
+		init: func() *TxFetcher {
+			f := NewTxFetcher(
+				func(common.Hash) bool { return false },
+				func(txs []*types.Transaction) []error {
+					errs := make([]error, len(txs))
+					for i, tx := range txs {
+						errs[i] = results[tx.Hash()]
+					}
+					return errs
+				},
+				func(string, []common.Hash) error { return nil },
+				func(peer string) { drop <- peer },
+			)
+			f.SetRip7560MaxInvalidTxs(2)
+			return f
+		},
+		steps: []interface{}{
+			// Invalid legacy transactions are not scored
+			doTxEnqueue{peer: "A", txs: []*types.Transaction{testTxs[0], testTxs[1]}},
+			notDropped,
+			// A valid transaction offsets an invalid one
+			doTxEnqueue{peer: "A", txs: []*types.Transaction{aaTxs[0], aaTxs[1]}},
+			notDropped,
+			// Known transactions are not scored
+			doTxEnqueue{peer: "A", txs: []*types.Transaction{aaTxs[2], aaTxs[3]}},
+			notDropped,
+			// Another peer is scored separately
+			doTxEnqueue{peer: "B", txs: []*types.Transaction{aaTxs[4]}},
+			notDropped,
+			// Reaching the limit drops the peer
+			doTxEnqueue{peer: "A", txs: []*types.Transaction{aaTxs[4]}},
+			doFunc(func() {
+				if peer := <-drop; peer != "A" {
+					t.Errorf("dropped peer mismatch: have %s, want A", peer)
+				}
+			}),
+		},
+	})
+}
+
+// Tests that RIP-7560 transactions exceeding the validation budget of a peer are
+// queued and added to the pool later on, while the overflow is dropped.
+func TestTransactionFetcherRip7560RateLimit(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		txs    []*types.Transaction
+	)
+	for i := 0; i < rip7560TxBurst+maxRip7560TxQueued+1; i++ {
+		txs = append(txs, types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       100000,
+			Sender:    &sender,
+		}))
+	}
+	var (
+		clock   = new(mclock.Simulated)
+		added   = make(chan []*types.Transaction, 64)
+		fetcher = NewTxFetcherForTests(
+			func(common.Hash) bool { return false },
+			func(txs []*types.Transaction) []error {
+				added <- txs
+				return make([]error, len(txs))
+			},
+			func(string, []common.Hash) error { return nil },
+			nil, clock, nil,
+		)
+	)
+	fetcher.Start()
+	defer fetcher.Stop()
+
+	// Deliver the burst along with the queued and overflowing transactions
+	if err := fetcher.Enqueue("A", append([]*types.Transaction{testTxs[0]}, txs...), false); err != nil {
+		t.Fatalf("failed to enqueue transactions: %v", err)
+	}
+	if have := <-added; len(have) != rip7560TxBurst+1 {
+		t.Fatalf("admitted transaction count mismatch: have %d, want %d", len(have), rip7560TxBurst+1)
+	}
+	// Wait for the queued transactions to be added in order as the budget refills
+	next := rip7560TxBurst
+	for tries := 0; next < rip7560TxBurst+maxRip7560TxQueued && tries < 1000; tries++ {
+		clock.Run(rip7560QueueInterval)
+		select {
+		case have := <-added:
+			if len(have) > maxRip7560TxRetrievals {
+				t.Fatalf("queued batch too large: have %d, want at most %d", len(have), maxRip7560TxRetrievals)
+			}
+			for _, tx := range have {
+				if tx.Hash() != txs[next].Hash() {
+					t.Fatalf("queued transaction %d out of order", next)
+				}
+				next++
+			}
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if next != rip7560TxBurst+maxRip7560TxQueued {
+		t.Fatalf("queued transactions not added: have %d, want %d", next-rip7560TxBurst, maxRip7560TxQueued)
+	}
+	// The overflowing transaction must have been dropped
+	clock.Run(time.Second)
+	select {
+	case have := <-added:
+		t.Fatalf("overflowing transactions added: %d", len(have))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,100 @@
+package filters
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Rip7560AccountDeployedCriteria selects the RIP7560AccountDeployed events injected by the EntryPoint.
+// Empty lists match any deployer or paymaster.
+type Rip7560AccountDeployedCriteria struct {
+	Deployers  []common.Address `json:"deployers"`
+	Paymasters []common.Address `json:"paymasters"`
+}
+
+// Rip7560AccountDeployed is a decoded RIP7560AccountDeployed event.
+type Rip7560AccountDeployed struct {
+	Sender      common.Address `json:"sender"`
+	Paymaster   common.Address `json:"paymaster"`
+	Deployer    common.Address `json:"deployer"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+// rip7560AccountDeployedQuery converts the criteria into a log filter query.
+func rip7560AccountDeployedQuery(crit Rip7560AccountDeployedCriteria) ethereum.FilterQuery {
+	toTopics := func(addresses []common.Address) []common.Hash {
+		topics := make([]common.Hash, len(addresses))
+		for i, address := range addresses {
+			topics[i] = common.BytesToHash(address.Bytes())
+		}
+		return topics
+	}
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{core.AA_ENTRY_POINT},
+		Topics: [][]common.Hash{
+			{core.Rip7560Abi.Events["RIP7560AccountDeployed"].ID},
+			nil,
+			toTopics(crit.Paymasters),
+			toTopics(crit.Deployers),
+		},
+	}
+}
+
+// decodeRip7560AccountDeployed decodes a log matched by rip7560AccountDeployedQuery.
+func decodeRip7560AccountDeployed(log *types.Log) *Rip7560AccountDeployed {
+	return &Rip7560AccountDeployed{
+		Sender:      common.BytesToAddress(log.Topics[1].Bytes()),
+		Paymaster:   common.BytesToAddress(log.Topics[2].Bytes()),
+		Deployer:    common.BytesToAddress(log.Topics[3].Bytes()),
+		TxHash:      log.TxHash,
+		BlockHash:   log.BlockHash,
+		BlockNumber: hexutil.Uint64(log.BlockNumber),
+		LogIndex:    hexutil.Uint(log.Index),
+		Removed:     log.Removed,
+	}
+}
+
+// Rip7560AccountDeployed creates a subscription that fires for each account deployed by an RIP-7560
+// transaction through one of the given deployers and sponsored by one of the given paymasters.
+// Deployments that are removed because of a chain reorganization are sent again with removed set.
+func (api *FilterAPI) Rip7560AccountDeployed(ctx context.Context, crit Rip7560AccountDeployedCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+	)
+
+	logsSub, err := api.events.SubscribeLogs(rip7560AccountDeployedQuery(crit), matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer logsSub.Unsubscribe()
+		for {
+			select {
+			case logs := <-matchedLogs:
+				for _, log := range logs {
+					notifier.Notify(rpcSub.ID, decodeRip7560AccountDeployed(log))
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
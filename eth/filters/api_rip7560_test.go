@@ -0,0 +1,65 @@
+package filters
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"testing"
+	"time"
+)
+
+func TestRip7560AccountDeployedFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		backend, sys = newTestFilterSystem(t, rawdb.NewMemoryDatabase(), Config{})
+		api          = NewFilterAPI(sys)
+
+		sender    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		paymaster = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		deployer  = common.HexToAddress("0x3333333333333333333333333333333333333333")
+		other     = common.HexToAddress("0x9999999999999999999999999999999999999999")
+		eventID   = core.Rip7560Abi.Events["RIP7560AccountDeployed"].ID
+	)
+	deployedLog := func(address common.Address, deployer common.Address) *types.Log {
+		return &types.Log{
+			Address:     address,
+			Topics:      []common.Hash{eventID, common.BytesToHash(sender.Bytes()), common.BytesToHash(paymaster.Bytes()), common.BytesToHash(deployer.Bytes())},
+			TxHash:      common.Hash{0x01},
+			BlockNumber: 5,
+			Index:       2,
+		}
+	}
+	logs := []*types.Log{
+		deployedLog(core.AA_ENTRY_POINT, deployer),
+		deployedLog(core.AA_ENTRY_POINT, other),
+		deployedLog(other, deployer),
+	}
+
+	matched := make(chan []*types.Log, 1)
+	sub, err := api.events.SubscribeLogs(rip7560AccountDeployedQuery(Rip7560AccountDeployedCriteria{Deployers: []common.Address{deployer}}), matched)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if nsend := backend.logsFeed.Send(logs); nsend == 0 {
+		t.Fatal("Logs event not delivered")
+	}
+	select {
+	case have := <-matched:
+		if len(have) != 1 || have[0] != logs[0] {
+			t.Fatalf("unexpected matched logs: %v", have)
+		}
+		deployed := decodeRip7560AccountDeployed(have[0])
+		if deployed.Sender != sender || deployed.Paymaster != paymaster || deployed.Deployer != deployer {
+			t.Errorf("unexpected decoded event: %+v", deployed)
+		}
+		if deployed.TxHash != logs[0].TxHash || deployed.BlockNumber != 5 || deployed.LogIndex != 2 {
+			t.Errorf("unexpected event position: %+v", deployed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("deployment event not matched")
+	}
+}
@@ -118,6 +118,10 @@ func EstimateRip7560Validation(ctx context.Context, tx *types.Transaction, opts
 	vpUsedGas, _ := vpr.ValidationPhaseUsedGas()
 	lo = vpUsedGas - 1
 
+	// The result and state of the validation at the 'hi' gas limit are the ones the
+	// execution gas is estimated on, so they are only replaced by successful runs
+	hiVpr, hiState := vpr, statedb
+
 	// There's a fairly high chance for the transaction to execute successfully
 	// with gasLimit set to the first execution's usedGas + gasRefund. Explicitly
 	// check that gas amount and use as a limit for the binary search.
@@ -133,7 +137,7 @@ func EstimateRip7560Validation(ctx context.Context, tx *types.Transaction, opts
 		if vpr == nil {
 			lo = optimisticGasLimit
 		} else {
-			hi = optimisticGasLimit
+			hi, hiVpr, hiState = optimisticGasLimit, vpr, statedb
 		}
 	}
 	// Binary search for the smallest gas limit that allows the tx to execute successfully.
@@ -164,12 +168,12 @@ func EstimateRip7560Validation(ctx context.Context, tx *types.Transaction, opts
 		if vpr == nil {
 			lo = mid
 		} else {
-			hi = mid
+			hi, hiVpr, hiState = mid, vpr, statedb
 		}
 	}
 
-	opts.ValidationPhaseResult = vpr
-	opts.State = statedb
+	opts.ValidationPhaseResult = hiVpr
+	opts.State = hiState
 	return hi, nil
 }
 
@@ -210,7 +214,9 @@ func executeRip7560Execution(ctx context.Context, tx *types.Transaction, opts *O
 		}
 		return true, nil, nil, err // Bail out
 	}
-	return false, exr, ppr, nil
+	// The postOp frame is given its own gas limit, but it runs after the execution
+	// frame, so both have to succeed for the execution gas limit to be sufficient
+	return exr.Failed() || (ppr != nil && ppr.Failed()), exr, ppr, nil
 }
 
 func EstimateRip7560Execution(ctx context.Context, opts *Options, gasCap uint64) (uint64, []byte, error) {
@@ -266,12 +272,11 @@ func EstimateRip7560Execution(ctx context.Context, opts *Options, gasCap uint64)
 		return 0, nil, err
 	}
 	if failed {
-		if exr != nil && ppr != nil {
-			if !errors.Is(exr.Err, vm.ErrOutOfGas) {
-				return 0, exr.Revert(), exr.Err
-			} else if !errors.Is(ppr.Err, vm.ErrOutOfGas) {
-				return 0, ppr.Revert(), ppr.Err
-			}
+		if exr != nil && exr.Failed() && !errors.Is(exr.Err, vm.ErrOutOfGas) {
+			return 0, exr.Revert(), exr.Err
+		}
+		if ppr != nil && ppr.Failed() && !errors.Is(ppr.Err, vm.ErrOutOfGas) {
+			return 0, ppr.Revert(), ppr.Err
 		}
 		return 0, nil, fmt.Errorf("gas required exceeds allowance (%d)", hi)
 	}
@@ -280,21 +285,14 @@ func EstimateRip7560Execution(ctx context.Context, opts *Options, gasCap uint64)
 	// is those that explicitly check gas remaining in order to execute within a
 	// given limit, but we probably don't want to return the lowest possible gas
 	// limit for these cases anyway.
-	if ppr == nil {
-		lo = exr.UsedGas - 1
-	} else {
-		lo = exr.UsedGas + ppr.UsedGas - 1
-	}
+	// Only the execution frame is limited by the estimated gas, the postOp frame
+	// has its own limit.
+	lo = exr.UsedGas - 1
 
 	// There's a fairly high chance for the transaction to execute successfully
 	// with gasLimit set to the first execution's usedGas + gasRefund. Explicitly
 	// check that gas amount and use as a limit for the binary search.
-	var optimisticGasLimit uint64
-	if ppr == nil {
-		optimisticGasLimit = (exr.UsedGas + exr.RefundedGas + params.CallStipend) * 64 / 63
-	} else {
-		optimisticGasLimit = (exr.UsedGas + exr.RefundedGas + ppr.UsedGas + ppr.RefundedGas + params.CallStipend) * 64 / 63
-	}
+	optimisticGasLimit := (exr.UsedGas + exr.RefundedGas + params.CallStipend) * 64 / 63
 	if optimisticGasLimit < hi {
 		failed, _, _, err = executeRip7560Execution(ctx, tx, opts, optimisticGasLimit)
 		if err != nil {
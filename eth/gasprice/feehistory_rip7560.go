@@ -0,0 +1,144 @@
+package gasprice
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"slices"
+)
+
+// rip7560TxFees holds the fees paid by a single RIP-7560 transaction.
+type rip7560TxFees struct {
+	gasUsed    uint64
+	builderFee *big.Int
+	reward     *big.Int
+}
+
+// processRip7560Block returns the requested percentiles of the builder fees and the effective
+// priority fees per gas paid by the RIP-7560 transactions included in the given block, weighted
+// by gas used. All zero rows are returned if the block contains no RIP-7560 transactions.
+func processRip7560Block(block *types.Block, receipts types.Receipts, percentiles []float64) (builderFee []*big.Int, reward []*big.Int) {
+	var (
+		txs        []rip7560TxFees
+		sumGasUsed uint64
+	)
+	for i, tx := range block.Transactions() {
+		if tx.Type() != types.Rip7560Type {
+			continue
+		}
+		fees := rip7560TxFees{gasUsed: receipts[i].GasUsed, builderFee: new(big.Int)}
+		if aatx := tx.Rip7560TransactionData(); aatx.BuilderFee != nil {
+			fees.builderFee.Set(aatx.BuilderFee)
+		}
+		fees.reward, _ = tx.EffectiveGasTip(block.BaseFee())
+		txs = append(txs, fees)
+		sumGasUsed += fees.gasUsed
+	}
+	builderFee = gasWeightedPercentiles(txs, sumGasUsed, percentiles, func(fees rip7560TxFees) *big.Int { return fees.builderFee })
+	reward = gasWeightedPercentiles(txs, sumGasUsed, percentiles, func(fees rip7560TxFees) *big.Int { return fees.reward })
+	return builderFee, reward
+}
+
+// gasWeightedPercentiles sorts the transactions by the given fee and returns its requested percentiles.
+func gasWeightedPercentiles(txs []rip7560TxFees, totalGasUsed uint64, percentiles []float64, fee func(rip7560TxFees) *big.Int) []*big.Int {
+	result := make([]*big.Int, len(percentiles))
+	if len(txs) == 0 {
+		for i := range result {
+			result[i] = new(big.Int)
+		}
+		return result
+	}
+	sorted := slices.Clone(txs)
+	slices.SortStableFunc(sorted, func(a, b rip7560TxFees) int {
+		return fee(a).Cmp(fee(b))
+	})
+
+	var txIndex int
+	sumGasUsed := sorted[0].gasUsed
+
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(totalGasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+		result[i] = fee(sorted[txIndex])
+	}
+	return result
+}
+
+// Rip7560FeeHistory returns the fee history of the RIP-7560 transactions included in the specified
+// range of blocks, resolved the same way as in FeeHistory. Two arrays are returned based on the
+// processed blocks:
+//   - builderFee: the requested percentiles of builder fees of RIP-7560 transactions in each block
+//   - reward: the requested percentiles of effective priority fees per gas of RIP-7560 transactions
+//     in each block
+//
+// Both are sorted in ascending order and weighted by gas used of the RIP-7560 transactions only.
+func (oracle *Oracle) Rip7560FeeHistory(ctx context.Context, blocks uint64, unresolvedLastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, [][]*big.Int, error) {
+	if blocks < 1 {
+		return common.Big0, nil, nil, nil
+	}
+	if len(percentiles) == 0 {
+		return common.Big0, nil, nil, fmt.Errorf("%w: no percentiles requested", errInvalidPercentile)
+	}
+	if len(percentiles) > maxQueryLimit {
+		return common.Big0, nil, nil, fmt.Errorf("%w: over the query limit %d", errInvalidPercentile, maxQueryLimit)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return common.Big0, nil, nil, fmt.Errorf("%w: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p <= percentiles[i-1] {
+			return common.Big0, nil, nil, fmt.Errorf("%w: #%d:%f >= #%d:%f", errInvalidPercentile, i-1, percentiles[i-1], i, p)
+		}
+	}
+	if blocks > oracle.maxBlockHistory {
+		log.Warn("Sanitizing RIP-7560 fee history length", "requested", blocks, "truncated", oracle.maxBlockHistory)
+		blocks = oracle.maxBlockHistory
+	}
+	pendingBlock, pendingReceipts, lastBlock, blocks, err := oracle.resolveBlockRange(ctx, unresolvedLastBlock, blocks)
+	if err != nil || blocks == 0 {
+		return common.Big0, nil, nil, err
+	}
+	oldestBlock := lastBlock + 1 - blocks
+
+	var (
+		builderFee = make([][]*big.Int, 0, blocks)
+		reward     = make([][]*big.Int, 0, blocks)
+	)
+	for number := oldestBlock; number <= lastBlock; number++ {
+		var (
+			block    *types.Block
+			receipts types.Receipts
+		)
+		if pendingBlock != nil && number >= pendingBlock.NumberU64() {
+			block, receipts = pendingBlock, pendingReceipts
+		} else {
+			if block, err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(number)); err != nil {
+				return common.Big0, nil, nil, err
+			}
+			if block == nil {
+				// getting no block and no error means we are requesting into the future (might happen because of a reorg)
+				break
+			}
+			if receipts, err = oracle.backend.GetReceipts(ctx, block.Hash()); err != nil {
+				return common.Big0, nil, nil, err
+			}
+		}
+		if len(receipts) != len(block.Transactions()) {
+			return common.Big0, nil, nil, fmt.Errorf("receipts missing for block %d", number)
+		}
+		blockBuilderFee, blockReward := processRip7560Block(block, receipts, percentiles)
+		builderFee = append(builderFee, blockBuilderFee)
+		reward = append(reward, blockReward)
+	}
+	if len(reward) == 0 {
+		return common.Big0, nil, nil, nil
+	}
+	return new(big.Int).SetUint64(oldestBlock), builderFee, reward, nil
+}
@@ -0,0 +1,50 @@
+package gasprice
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"math/big"
+	"testing"
+)
+
+func TestProcessRip7560Block(t *testing.T) {
+	sender := common.Address{0x01}
+	newAATx := func(builderFee, tip int64) *types.Transaction {
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			Sender:     &sender,
+			GasTipCap:  big.NewInt(tip),
+			GasFeeCap:  big.NewInt(100),
+			BuilderFee: big.NewInt(builderFee),
+		})
+	}
+	txs := []*types.Transaction{
+		newAATx(30, 1),
+		types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(50)}),
+		newAATx(10, 3),
+		newAATx(20, 2),
+	}
+	receipts := types.Receipts{{GasUsed: 100}, {GasUsed: 1000}, {GasUsed: 100}, {GasUsed: 200}}
+	header := &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(10)}
+	block := types.NewBlock(header, &types.Body{Transactions: txs}, receipts, trie.NewStackTrie(nil))
+
+	builderFee, reward := processRip7560Block(block, receipts, []float64{0, 50, 100})
+
+	// the legacy transaction is ignored, and the percentiles are weighted by gas used
+	for i, want := range []int64{10, 20, 30} {
+		if builderFee[i].Int64() != want {
+			t.Errorf("builder fee percentile %d mismatch: have %v, want %v", i, builderFee[i], want)
+		}
+	}
+	for i, want := range []int64{1, 2, 3} {
+		if reward[i].Int64() != want {
+			t.Errorf("reward percentile %d mismatch: have %v, want %v", i, reward[i], want)
+		}
+	}
+
+	empty := types.NewBlock(header, &types.Body{Transactions: txs[1:2]}, receipts[1:2], trie.NewStackTrie(nil))
+	builderFee, reward = processRip7560Block(empty, receipts[1:2], []float64{50})
+	if builderFee[0].Sign() != 0 || reward[0].Sign() != 0 {
+		t.Errorf("expected zero percentiles for a block without RIP-7560 transactions, have %v %v", builderFee[0], reward[0])
+	}
+}
@@ -94,6 +94,9 @@ type handlerConfig struct {
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
 	NoTxGossip     bool                   // Disable P2P transaction gossip
+
+	Rip7560TxGossip          bool // Enable P2P gossip of RIP-7560 transactions
+	Rip7560PeerMaxInvalidTxs int  // Invalid RIP-7560 transactions tolerated per peer (0 = default)
 }
 
 type handler struct {
@@ -109,7 +112,8 @@ type handler struct {
 	chain    *core.BlockChain
 	maxPeers int
 
-	noTxGossip bool
+	noTxGossip    bool
+	rip7560Gossip bool // Whether RIP-7560 transactions are exchanged with capable peers
 
 	downloader *downloader.Downloader
 	txFetcher  *fetcher.TxFetcher
@@ -144,6 +148,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		database:       config.Database,
 		txpool:         config.TxPool,
 		noTxGossip:     config.NoTxGossip,
+		rip7560Gossip:  config.Rip7560TxGossip,
 		chain:          config.Chain,
 		peers:          newPeerSet(),
 		requiredBlocks: config.RequiredBlocks,
@@ -204,6 +209,9 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return h.txpool.Add(txs, false, false)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+	if config.Rip7560PeerMaxInvalidTxs > 0 {
+		h.txFetcher.SetRip7560MaxInvalidTxs(config.Rip7560PeerMaxInvalidTxs)
+	}
 	return h, nil
 }
 
@@ -467,13 +475,14 @@ func (h *handler) Stop() {
 }
 
 // BroadcastTransactions will propagate a batch of transactions
-// - To a square root of all peers for non-blob transactions
+// - To a square root of all peers for non-blob and non-RIP-7560 transactions
 // - And, separately, as announcements to all peers which are not known to
 // already have the given transaction.
 func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	var (
-		blobTxs  int // Number of blob transactions to announce only
-		largeTxs int // Number of large transactions to announce only
+		blobTxs    int // Number of blob transactions to announce only
+		rip7560Txs int // Number of RIP-7560 transactions to announce only
+		largeTxs   int // Number of large transactions to announce only
 
 		directCount int // Number of transactions sent directly to peers (duplicates included)
 		annCount    int // Number of transactions announced across all peers (duplicates included)
@@ -498,6 +507,8 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		switch {
 		case tx.Type() == types.BlobTxType:
 			blobTxs++
+		case tx.Type() == types.Rip7560Type:
+			rip7560Txs++
 		case tx.Size() > txMaxBroadcastSize:
 			largeTxs++
 		default:
@@ -511,6 +522,10 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		// enode ID together with the transaction sender and broadcast if
 		// `sha(self, peer, sender) mod peers < sqrt(peers)`.
 		for _, peer := range h.peers.peersWithoutTransaction(tx.Hash()) {
+			// Skip peers that did not advertise RIP-7560 support in the handshake
+			if tx.Type() == types.Rip7560Type && !h.supportsRip7560(peer.Peer) {
+				continue
+			}
 			var broadcast bool
 			if maybeDirect {
 				hasher.Reset()
@@ -540,10 +555,17 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		annCount += len(hashes)
 		peer.AsyncSendPooledTransactionHashes(hashes)
 	}
-	log.Debug("Distributed transactions", "plaintxs", len(txs)-blobTxs-largeTxs, "blobtxs", blobTxs, "largetxs", largeTxs,
+	log.Debug("Distributed transactions", "plaintxs", len(txs)-blobTxs-rip7560Txs-largeTxs, "blobtxs", blobTxs, "rip7560txs", rip7560Txs, "largetxs", largeTxs,
 		"bcastpeers", len(txset), "bcastcount", directCount, "annpeers", len(annos), "anncount", annCount)
 }
 
+// supportsRip7560 reports whether RIP-7560 transactions may be exchanged with the
+// peer, which requires both gossip to be enabled locally and the peer to advertise
+// the `rip7560` capability.
+func (h *handler) supportsRip7560(peer *eth.Peer) bool {
+	return h.rip7560Gossip && peer.SupportsRip7560()
+}
+
 // txBroadcastLoop announces new transactions to connected peers.
 func (h *handler) txBroadcastLoop() {
 	defer h.wg.Done()
@@ -75,6 +75,13 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	// Consume any broadcasts and announces, forwarding the rest to the downloader
 	switch packet := packet.(type) {
 	case *eth.NewPooledTransactionHashesPacket:
+		if !(*handler)(h).supportsRip7560(peer) {
+			for _, kind := range packet.Types {
+				if kind == types.Rip7560Type {
+					return errors.New("disallowed RIP-7560 transaction announcement")
+				}
+			}
+		}
 		return h.txFetcher.Notify(peer.ID(), packet.Types, packet.Sizes, packet.Hashes)
 
 	case *eth.TransactionsPacket:
@@ -82,10 +89,20 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 			if tx.Type() == types.BlobTxType {
 				return errors.New("disallowed broadcast blob transaction")
 			}
+			if tx.Type() == types.Rip7560Type {
+				return errors.New("disallowed broadcast RIP-7560 transaction")
+			}
 		}
 		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
 
 	case *eth.PooledTransactionsResponse:
+		if !(*handler)(h).supportsRip7560(peer) {
+			for _, tx := range *packet {
+				if tx.Type() == types.Rip7560Type {
+					return errors.New("disallowed RIP-7560 transaction delivery")
+				}
+			}
+		}
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
 	default:
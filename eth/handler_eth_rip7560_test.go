@@ -0,0 +1,65 @@
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"math/big"
+	"testing"
+)
+
+// Tests that RIP-7560 transactions are only accepted from peers that advertised
+// the `rip7560` capability in the handshake, and only if gossip is enabled locally.
+func TestRip7560GossipCapability(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandler()
+	defer handler.close()
+
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       100000,
+		Sender:    &sender,
+	})
+	caps := []p2p.Cap{{Name: eth.Rip7560ProtocolName, Version: eth.Rip7560ProtocolVersions[0]}}
+
+	for i, tt := range []struct {
+		gossip    bool
+		caps      []p2p.Cap
+		supported bool
+	}{
+		{gossip: true, caps: nil, supported: false},
+		{gossip: true, caps: caps, supported: true},
+		{gossip: false, caps: caps, supported: false},
+	} {
+		handler.handler.rip7560Gossip = tt.gossip
+
+		p2pPeer, _ := p2p.MsgPipe()
+		peer := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{byte(i + 1)}, "", tt.caps, p2pPeer), p2pPeer, handler.txpool)
+		defer peer.Close()
+
+		if have := handler.handler.supportsRip7560(peer); have != tt.supported {
+			t.Fatalf("test %d: capability mismatch: have %v, want %v", i, have, tt.supported)
+		}
+		if info := (&ethPeer{Peer: peer}).info(); info.Rip7560 != (tt.caps != nil) {
+			t.Errorf("test %d: peer info capability mismatch: have %v, want %v", i, info.Rip7560, tt.caps != nil)
+		}
+		announce := &eth.NewPooledTransactionHashesPacket{
+			Types:  []byte{tx.Type()},
+			Sizes:  []uint32{uint32(tx.Size())},
+			Hashes: []common.Hash{tx.Hash()},
+		}
+		if err := (*ethHandler)(handler.handler).Handle(peer, announce); (err == nil) != tt.supported {
+			t.Errorf("test %d: announcement acceptance mismatch: err %v, want supported %v", i, err, tt.supported)
+		}
+		delivery := &eth.PooledTransactionsResponse{tx}
+		if err := (*ethHandler)(handler.handler).Handle(peer, delivery); (err == nil) != tt.supported {
+			t.Errorf("test %d: delivery acceptance mismatch: err %v, want supported %v", i, err, tt.supported)
+		}
+	}
+}
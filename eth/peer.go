@@ -25,6 +25,7 @@ import (
 // about a connected peer.
 type ethPeerInfo struct {
 	Version uint `json:"version"` // Ethereum protocol version negotiated
+	Rip7560 bool `json:"rip7560"` // Whether the peer supports RIP-7560 transaction gossip
 }
 
 // ethPeer is a wrapper around eth.Peer to maintain a few extra metadata.
@@ -37,6 +38,7 @@ type ethPeer struct {
 func (p *ethPeer) info() *ethPeerInfo {
 	return &ethPeerInfo{
 		Version: p.Version(),
+		Rip7560: p.SupportsRip7560(),
 	}
 }
 
@@ -116,7 +116,8 @@ func (p *Peer) announceTransactions() {
 				size         common.StorageSize
 			)
 			for count = 0; count < len(queue) && size < maxTxPacketSize; count++ {
-				if tx := p.txpool.Get(queue[count]); tx != nil {
+				// RIP-7560 transactions are only announced to peers understanding them
+				if tx := p.txpool.Get(queue[count]); tx != nil && (tx.Type() != types.Rip7560Type || p.SupportsRip7560()) {
 					pending = append(pending, queue[count])
 					pendingTypes = append(pendingTypes, tx.Type())
 					pendingSizes = append(pendingSizes, uint32(tx.Size()))
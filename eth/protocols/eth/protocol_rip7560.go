@@ -0,0 +1,43 @@
+package eth
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// Rip7560ProtocolName is the devp2p capability advertised by nodes that understand
+// the gossip of RIP-7560 transactions over the `eth` protocol.
+const Rip7560ProtocolName = "rip7560"
+
+// Rip7560ProtocolVersions are the supported versions of the `rip7560` capability.
+var Rip7560ProtocolVersions = []uint{1}
+
+// MakeRip7560Protocols constructs the P2P protocol definitions for `rip7560`. The
+// protocol has no messages of its own, it only flags in the devp2p handshake that
+// type-4 transactions may be announced to and requested from the peer.
+func MakeRip7560Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(Rip7560ProtocolVersions))
+	for _, version := range Rip7560ProtocolVersions {
+		protocols = append(protocols, p2p.Protocol{
+			Name:    Rip7560ProtocolName,
+			Version: version,
+			Length:  0,
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				// Block until the peer disconnects, no message is expected
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+				return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
+			},
+		})
+	}
+	return protocols
+}
+
+// SupportsRip7560 reports whether the peer advertised the `rip7560` capability,
+// thus RIP-7560 transactions may be exchanged with it.
+func (p *Peer) SupportsRip7560() bool {
+	return p.RunningCap(Rip7560ProtocolName, Rip7560ProtocolVersions)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/holiman/uint256"
 	"math/big"
@@ -40,6 +41,15 @@ type access struct {
 	TransientWrites map[string]uint64 `json:"transientWrites"`
 }
 
+// create2Item is an address derivation of a CREATE2 opcode, allowing the bundler to
+// verify the deployed address without re-executing the frame
+type create2Item struct {
+	From         common.Address `json:"from"`
+	Salt         common.Hash    `json:"salt"`
+	InitCodeHash common.Hash    `json:"initCodeHash"`
+	Address      common.Address `json:"address"`
+}
+
 // note - this means an individual 'frame' in 7560 (validate, execute, postOp)
 type entryPointCall struct {
 	//TopLevelMethodSig     hexutil.Bytes                       `json:"topLevelMethodSig"`
@@ -49,6 +59,8 @@ type entryPointCall struct {
 	ExtCodeAccessInfo     map[common.Address]string           `json:"extCodeAccessInfo"`
 	ContractSize          map[common.Address]*contractSizeVal `json:"contractSize"`
 	OOG                   bool                                `json:"oog"`
+	Keccak                []hexutil.Bytes                     `json:"keccak"`
+	Create2               []*create2Item                      `json:"create2"`
 }
 
 /******* *******/
@@ -182,6 +194,8 @@ func (b *rip7560ValidationTracer) createNewTopLevelFrame(addr common.Address) {
 		ExtCodeAccessInfo:     map[common.Address]string{},
 		ContractSize:          map[common.Address]*contractSizeVal{},
 		OOG:                   false,
+		Keccak:                make([]hexutil.Bytes, 0),
+		Create2:               make([]*create2Item, 0),
 	}
 	b.CallsFromEntryPoint = append(b.CallsFromEntryPoint, b.CurrentLevel)
 	b.lastOp = ""
@@ -197,7 +211,8 @@ func (b *rip7560ValidationTracer) OnOpcode(pc uint64, op byte, gas, cost uint64,
 	stackSize := len(scope.StackData())
 	stackTop3 := partialStack{}
 	for i := 0; i < 3 && i < stackSize; i++ {
-		stackTop3 = append(stackTop3, StackBack(scope.StackData(), i))
+		// copy the items, as the stack is modified by the following opcodes
+		stackTop3 = append(stackTop3, new(uint256.Int).Set(StackBack(scope.StackData(), i)))
 	}
 	b.lastThreeOpCodes = append(b.lastThreeOpCodes, &lastThreeOpCodesItem{
 		Opcode:    opcode,
@@ -319,7 +334,25 @@ func (b *rip7560ValidationTracer) OnOpcode(pc uint64, op byte, gas, cost uint64,
 			keccak := make([]byte, len.Uint64())
 			copy(keccak, memory[ofs.Uint64():ofs.Uint64()+len.Uint64()])
 			b.Keccak = append(b.Keccak, keccak)
+			// [STO-021] associated storage is claimed against the keccak preimages of the frame
+			b.CurrentLevel.Keccak = append(b.CurrentLevel.Keccak, keccak)
 		}
+	} else if opcode == "CREATE2" {
+		ofs := StackBack(scope.StackData(), 1)
+		len := StackBack(scope.StackData(), 2)
+		salt := common.Hash(StackBack(scope.StackData(), 3).Bytes32())
+		initCode := []byte{}
+		if len.Uint64() > 0 {
+			// memory is not expanded for an empty init code, so the offset may be out of bounds
+			initCode = scope.MemoryData()[ofs.Uint64() : ofs.Uint64()+len.Uint64()]
+		}
+		initCodeHash := crypto.Keccak256Hash(initCode)
+		b.CurrentLevel.Create2 = append(b.CurrentLevel.Create2, &create2Item{
+			From:         scope.Address(),
+			Salt:         salt,
+			InitCodeHash: initCodeHash,
+			Address:      crypto.CreateAddress2(scope.Address(), salt, initCodeHash.Bytes()),
+		})
 	} else if strings.HasPrefix(opcode, "LOG") {
 		count, _ := strconv.Atoi(opcode[3:])
 		ofs := StackBack(scope.StackData(), 0)
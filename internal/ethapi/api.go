@@ -1497,20 +1497,9 @@ type RPCTransaction struct {
 
 	// Introduced by RIP-7712
 	NonceKey *hexutil.Big `json:"nonceKey,omitempty"`
-}
 
-func toBytes(data []byte) *hexutil.Bytes {
-	if len(data) == 0 {
-		return nil
-	}
-	return (*hexutil.Bytes)(&data)
-}
-
-func conditional_uint64(v uint64, addr *common.Address) *hexutil.Uint64 {
-	if addr == nil {
-		return nil
-	}
-	return (*hexutil.Uint64)(&v)
+	// EIP-7702 authorizations of RIP-7560 transactions
+	AuthorizationList []types.SetCodeAuthorization `json:"authorizationList,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1590,26 +1579,34 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		}
 
 	case types.Rip7560Type:
+		// RIP-7560 transactions carry no ECDSA signature, the sender is their origin and
+		// all their fields are returned, even when empty, except the absent paymaster and
+		// deployer.
 		rip7560Tx := tx.Rip7560TransactionData()
+		al := tx.AccessList()
 
 		result.S = nil
 		result.R = nil
 		result.V = nil
 		result.To = nil
+		if rip7560Tx.Sender != nil {
+			result.From = *rip7560Tx.Sender
+		}
+		result.Accesses = &al
 		result.NonceKey = (*hexutil.Big)(rip7560Tx.NonceKey)
 		result.Input = make(hexutil.Bytes, 0)
 		result.Sender = rip7560Tx.Sender
-		result.AuthorizationData = toBytes(rip7560Tx.AuthorizationData)
-		result.ExecutionData = toBytes(rip7560Tx.ExecutionData)
-		result.Gas = hexutil.Uint64(tx.Gas())
+		result.AuthorizationData = (*hexutil.Bytes)(&rip7560Tx.AuthorizationData)
+		result.AuthorizationList = rip7560Tx.AuthorizationList
+		result.ExecutionData = (*hexutil.Bytes)(&rip7560Tx.ExecutionData)
 		result.Paymaster = rip7560Tx.Paymaster
-		result.PaymasterData = toBytes(rip7560Tx.PaymasterData)
+		result.PaymasterData = (*hexutil.Bytes)(&rip7560Tx.PaymasterData)
 		result.Deployer = rip7560Tx.Deployer
-		result.DeployerData = toBytes(rip7560Tx.DeployerData)
+		result.DeployerData = (*hexutil.Bytes)(&rip7560Tx.DeployerData)
 		result.BuilderFee = (*hexutil.Big)(rip7560Tx.BuilderFee)
 		result.ValidationGas = (*hexutil.Uint64)(&rip7560Tx.ValidationGasLimit)
-		result.PaymasterValidationGasLimit = conditional_uint64(rip7560Tx.PaymasterValidationGasLimit, rip7560Tx.Paymaster)
-		result.PostOpGas = conditional_uint64(rip7560Tx.PostOpGas, rip7560Tx.Paymaster)
+		result.PaymasterValidationGasLimit = (*hexutil.Uint64)(&rip7560Tx.PaymasterValidationGasLimit)
+		result.PostOpGas = (*hexutil.Uint64)(&rip7560Tx.PostOpGas)
 
 		//shared fields with DynamicFeeTxType
 		result.ChainID = (*hexutil.Big)(tx.ChainId())
@@ -2034,6 +2031,36 @@ func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber u
 		fields["blobGasPrice"] = (*hexutil.Big)(receipt.BlobGasPrice)
 	}
 
+	if tx.Type() == types.Rip7560Type {
+		aatx := tx.Rip7560TransactionData()
+		fields["from"] = aatx.Sender
+		fields["sender"] = aatx.Sender
+		fields["paymaster"] = aatx.Paymaster
+		fields["deployer"] = aatx.Deployer
+		// Receipts stored before the execution status was recorded carry it in the logs only.
+		if receipt.Rip7560ExecutionStatus != nil {
+			fields["executionStatus"] = hexutil.Uint64(*receipt.Rip7560ExecutionStatus)
+		} else if status, ok := core.Rip7560ExecutionStatus(receipt.Logs); ok {
+			fields["executionStatus"] = hexutil.Uint64(status)
+		}
+		if receipt.Rip7560BuilderFee != nil {
+			fields["builderFee"] = (*hexutil.Big)(receipt.Rip7560BuilderFee)
+		}
+	}
+	if receipt.Rip7560GasAttribution != nil {
+		fields["gasAttribution"] = receipt.Rip7560GasAttribution
+	}
+	if receipt.Rip7560Validity != nil {
+		fields["validity"] = receipt.Rip7560Validity
+	}
+	if frames := receipt.Rip7560FrameGasUsed; frames != nil {
+		fields["validationGasUsed"] = frames.Validation
+		fields["paymasterValidationGasUsed"] = frames.PaymasterValidation
+		fields["deploymentGasUsed"] = frames.Deployment
+		fields["executionGasUsed"] = frames.Execution
+		fields["postOpGasUsed"] = frames.PostOp
+	}
+
 	// If the ContractAddress is 20 0x0 bytes, assume it is not a contract creation
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
@@ -2069,6 +2096,11 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
+	if tx.Type() == types.Rip7560Type {
+		aatx := tx.Rip7560TransactionData()
+		log.Info("Submitted RIP-7560 transaction", "hash", tx.Hash().Hex(), "sender", aatx.Sender, "nonceKey", aatx.NonceKey, "nonce", aatx.Nonce)
+		return tx.Hash(), nil
+	}
 	head := b.CurrentBlock()
 	signer := types.MakeSigner(b.ChainConfig(), head.Number, head.Time)
 	from, err := types.Sender(signer, tx)
@@ -785,6 +785,39 @@ func (b testBackend) HistoricalRPCService() *rpc.Client {
 func (b testBackend) Genesis() *types.Block {
 	panic("implement me")
 }
+func (b testBackend) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	panic("implement me")
+}
+func (b testBackend) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	panic("implement me")
+}
+func (b testBackend) ExtendRip7560Bundle(ctx context.Context, hash common.Hash, validUntil *big.Int) error {
+	panic("implement me")
+}
+func (b testBackend) CancelRip7560Bundle(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	panic("implement me")
+}
+func (b testBackend) GetRip7560BundleStatus(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	panic("implement me")
+}
+func (b testBackend) SubscribeRip7560TxStatusEvent(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	panic("implement me")
+}
+func (b testBackend) Rip7560FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, [][]*big.Int, error) {
+	panic("implement me")
+}
+func (b testBackend) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	panic("implement me")
+}
+func (b testBackend) Rip7560PaymasterStats(ctx context.Context, paymaster common.Address, fromBlock, toBlock uint64) ([]*types.Rip7560PaymasterStats, error) {
+	panic("implement me")
+}
+func (b testBackend) GetRip7560TransactionDebugInfo(hash common.Hash) (map[string]interface{}, error) {
+	panic("implement me")
+}
+func (b testBackend) SetRip7560TransactionDebugInfo(infos []*types.Rip7560TransactionDebugInfo) {
+	panic("implement me")
+}
 
 func TestEstimateGas(t *testing.T) {
 	t.Parallel()
@@ -103,7 +103,14 @@ type Backend interface {
 	// RIP-7560 specific functions
 
 	SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error
+	CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error
+	ExtendRip7560Bundle(ctx context.Context, hash common.Hash, validUntil *big.Int) error
+	CancelRip7560Bundle(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error)
 	GetRip7560BundleStatus(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error)
+	SubscribeRip7560TxStatusEvent(ch chan<- core.Rip7560TxStatusEvent) event.Subscription
+	Rip7560FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, [][]*big.Int, error)
+	Rip7560InclusionStats() *types.Rip7560InclusionStats
+	Rip7560PaymasterStats(ctx context.Context, paymaster common.Address, fromBlock, toBlock uint64) ([]*types.Rip7560PaymasterStats, error)
 
 	// RIP-7560 debug
 
@@ -135,6 +142,13 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		}, {
 			Namespace: "personal",
 			Service:   NewPersonalAccountAPI(apiBackend, nonceLock),
+		}, {
+			Namespace: "rip7560",
+			Service:   NewRip7560API(apiBackend),
+		}, {
+			Namespace:     "eth",
+			Service:       NewRip7560ForwardAPI(apiBackend),
+			Authenticated: true,
 		},
 	}
 }
@@ -4,15 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/gasestimator"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/holiman/uint256"
@@ -21,38 +26,832 @@ import (
 	"time"
 )
 
+// Rip7560API provides the RIP-7560 specific APIs of the rip7560 namespace.
+type Rip7560API struct {
+	b Backend
+}
+
+// NewRip7560API creates a new RIP-7560 API instance.
+func NewRip7560API(b Backend) *Rip7560API {
+	return &Rip7560API{b}
+}
+
+// Rip7560ForwardAPI receives the RIP-7560 bundles relayed by sentry nodes to the
+// block producer. It is only served on the authenticated RPC endpoint.
+type Rip7560ForwardAPI struct {
+	b Backend
+}
+
+// NewRip7560ForwardAPI creates a new RIP-7560 bundle forwarding API instance.
+func NewRip7560ForwardAPI(b Backend) *Rip7560ForwardAPI {
+	return &Rip7560ForwardAPI{b}
+}
+
+// SendRawRip7560Bundle submits an RLP-encoded bundle relayed by a sentry node and
+// returns its hash.
+func (api *Rip7560ForwardAPI) SendRawRip7560Bundle(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	bundle := new(types.ExternallyReceivedBundle)
+	if err := rlp.DecodeBytes(input, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	if len(bundle.Transactions) == 0 {
+		return common.Hash{}, errors.New("submitted bundle has zero length")
+	}
+	if bundle.BundleHash != CalculateBundleHash(bundle.Transactions) {
+		return common.Hash{}, errors.New("bundle hash mismatch")
+	}
+	if err := SubmitRip7560Bundle(ctx, api.b, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.BundleHash, nil
+}
+
+// CancelRip7560Bundle cancels a bundle relayed by a sentry node, returning the status
+// of the bundle afterwards.
+func (api *Rip7560ForwardAPI) CancelRip7560Bundle(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	return api.b.CancelRip7560Bundle(ctx, hash)
+}
+
+// Rip7560BundleCancellation is the outcome of the cancellation of a pending bundle.
+type Rip7560BundleCancellation struct {
+	Cancelled bool                 `json:"cancelled"`
+	Status    *types.BundleReceipt `json:"status"` // Status of the bundle after the cancellation attempt
+}
+
+// CancelBundle removes a previously submitted bundle from the pending queue before its
+// inclusion. The cancellation fails if the bundle was already consumed by the builder,
+// either included in a block or selected for the block being built.
+func (api *Rip7560API) CancelBundle(ctx context.Context, hash common.Hash) (*Rip7560BundleCancellation, error) {
+	receipt, err := api.b.CancelRip7560Bundle(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("unknown bundle %x", hash)
+	}
+	return &Rip7560BundleCancellation{
+		Cancelled: receipt.Status == types.BundleStatusCancelled,
+		Status:    receipt,
+	}, nil
+}
+
+// Rip7560EntryPoint is an entrypoint recognized by the consensus rules along with its ABI version.
+type Rip7560EntryPoint struct {
+	Address       common.Address        `json:"address"`
+	AbiVersion    hexutil.Uint64        `json:"abiVersion"`
+	SigningDomain *Rip7560SigningDomain `json:"signingDomain,omitempty"`
+}
+
+// Rip7560SigningDomain is the domain wallets have to bind into the signing hash of the
+// RIP-7560 transactions they sign, in the order it appears in the hash preimage.
+type Rip7560SigningDomain struct {
+	ChainID    *hexutil.Big   `json:"chainId"`
+	EntryPoint common.Address `json:"entryPoint"`
+	AbiVersion hexutil.Uint64 `json:"abiVersion"`
+}
+
+// GetSupportedEntryPoints returns the entrypoints recognized by the consensus rules at the current
+// head, mirroring the ERC-4337 eth_supportedEntryPoints discovery call. The list is empty as long
+// as RIP-7560 is not activated. Once the EntryPoint is bound into the signing hash, the domain the
+// wallets have to sign over is reported as well.
+func (api *Rip7560API) GetSupportedEntryPoints() []*Rip7560EntryPoint {
+	entryPoints := make([]*Rip7560EntryPoint, 0)
+	config, head := api.b.ChainConfig(), api.b.CurrentHeader()
+	if !config.IsRIP7560(head.Number, head.Time) {
+		return entryPoints
+	}
+	abiVersion := config.RIP7560AbiVersion(head.Number)
+	entryPoint := &Rip7560EntryPoint{Address: core.AA_ENTRY_POINT, AbiVersion: hexutil.Uint64(abiVersion)}
+	if config.IsRIP7560SigningDomain(head.Number) {
+		domain := core.Rip7560SigningDomain(abiVersion)
+		entryPoint.SigningDomain = &Rip7560SigningDomain{
+			ChainID:    (*hexutil.Big)(config.ChainID),
+			EntryPoint: domain.EntryPoint,
+			AbiVersion: hexutil.Uint64(domain.AbiVersion),
+		}
+	}
+	return append(entryPoints, entryPoint)
+}
+
+// Rip7560ValidityWindow is the range of timestamps an RIP-7560 transaction is valid in,
+// as returned by the validation frame of the account or the paymaster. Zero means unbounded.
+type Rip7560ValidityWindow struct {
+	ValidAfter hexutil.Uint64 `json:"validAfter"`
+	ValidUntil hexutil.Uint64 `json:"validUntil"`
+}
+
+// Rip7560ValidationGasUsed is the gas used by each part of the validation phase of an
+// RIP-7560 transaction.
+type Rip7560ValidationGasUsed struct {
+	PreTransaction      hexutil.Uint64 `json:"preTransaction"` // Intrinsic gas of the transaction
+	CallData            hexutil.Uint64 `json:"callData"`       // Part of the intrinsic gas charged for the calldata
+	NonceManager        hexutil.Uint64 `json:"nonceManager"`
+	Deployment          hexutil.Uint64 `json:"deployment"`
+	AccountValidation   hexutil.Uint64 `json:"accountValidation"`
+	PaymasterValidation hexutil.Uint64 `json:"paymasterValidation"`
+}
+
+// Rip7560Validation is the outcome of the validation phase of an RIP-7560 transaction.
+type Rip7560Validation struct {
+	Account          Rip7560ValidityWindow    `json:"account"`
+	Paymaster        *Rip7560ValidityWindow   `json:"paymaster,omitempty"` // Only set for sponsored transactions
+	PaymasterContext hexutil.Bytes            `json:"paymasterContext"`
+	GasUsed          Rip7560ValidationGasUsed `json:"gasUsed"`
+}
+
+// ValidateTransaction runs only the validation phase of the given transaction on top of the
+// given block state, as bundlers do before building a bundle, and returns the validity windows
+// of the account and the paymaster, the paymaster context and the gas used by each frame.
+// Nothing is submitted and all state changes are discarded.
+func (api *Rip7560API) ValidateTransaction(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*Rip7560Validation, error) {
+	tx, state, header, err := rip7560CallState(ctx, api.b, args, blockNrOrHash, overrides)
+	if err != nil {
+		return nil, err
+	}
+	vmConfig := vm.Config{NoBaseFee: true, Rip7560ValidationTimeout: api.b.RPCEVMTimeout()}
+	gp := new(core.GasPool).AddGas(math.MaxUint64)
+	state.SetTxContext(tx.Hash(), 0)
+	vpr, err := core.ApplyRip7560ValidationPhases(api.b.ChainConfig(), NewChainContext(ctx, api.b), &header.Coinbase, gp, state, header, tx, vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	validation := &Rip7560Validation{
+		Account:          Rip7560ValidityWindow{ValidAfter: hexutil.Uint64(vpr.SenderValidAfter), ValidUntil: hexutil.Uint64(vpr.SenderValidUntil)},
+		PaymasterContext: vpr.PaymasterContext,
+		GasUsed: Rip7560ValidationGasUsed{
+			PreTransaction:      hexutil.Uint64(vpr.PreTransactionGasCost),
+			CallData:            hexutil.Uint64(vpr.CallDataUsedGas),
+			NonceManager:        hexutil.Uint64(vpr.NonceManagerUsedGas),
+			Deployment:          hexutil.Uint64(vpr.DeploymentUsedGas),
+			AccountValidation:   hexutil.Uint64(vpr.ValidationUsedGas),
+			PaymasterValidation: hexutil.Uint64(vpr.PmValidationUsedGas),
+		},
+	}
+	if paymaster := tx.Rip7560TransactionData().Paymaster; paymaster != nil && *paymaster != (common.Address{}) {
+		validation.Paymaster = &Rip7560ValidityWindow{ValidAfter: hexutil.Uint64(vpr.PmValidAfter), ValidUntil: hexutil.Uint64(vpr.PmValidUntil)}
+	}
+	return validation, nil
+}
+
+// Rip7560PaymasterSponsorship reports whether a paymaster would sponsor an RIP-7560 transaction.
+type Rip7560PaymasterSponsorship struct {
+	Accepted    bool           `json:"accepted"`
+	Reason      string         `json:"reason,omitempty"`
+	RevertData  interface{}    `json:"revertData,omitempty"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	ValidAfter  hexutil.Uint64 `json:"validAfter"`
+	ValidUntil  hexutil.Uint64 `json:"validUntil"`
+	ContextSize hexutil.Uint64 `json:"contextSize"`
+}
+
+// CheckPaymasterSponsorship runs only the paymaster validation frame of the given transaction on top
+// of the given block and reports whether the paymaster accepts it, along with the validity window
+// and the size of the context it returned. The account validation frame is not run, so a sponsored
+// transaction may still fail its full validation.
+func (api *Rip7560API) CheckPaymasterSponsorship(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*Rip7560PaymasterSponsorship, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	tx := args.ToTransaction()
+	if tx.Type() != types.Rip7560Type {
+		return nil, errors.New("not an RIP-7560 transaction")
+	}
+	if gasCap := api.b.RPCGasCap(); gasCap != 0 && tx.Rip7560TransactionData().PaymasterValidationGasLimit > gasCap {
+		return nil, fmt.Errorf("paymaster validation gas limit exceeds the RPC gas cap %d", gasCap)
+	}
+	result, err := core.ApplyRip7560PaymasterValidation(api.b.ChainConfig(), NewChainContext(ctx, api.b), state, header, tx, vm.Config{NoBaseFee: true})
+	if err != nil {
+		var vpe *core.ValidationPhaseError
+		if !errors.As(err, &vpe) {
+			return nil, err
+		}
+		return &Rip7560PaymasterSponsorship{Reason: vpe.Error(), RevertData: vpe.ErrorData()}, nil
+	}
+	return &Rip7560PaymasterSponsorship{
+		Accepted:    true,
+		GasUsed:     hexutil.Uint64(result.UsedGas),
+		ValidAfter:  hexutil.Uint64(result.ValidAfter),
+		ValidUntil:  hexutil.Uint64(result.ValidUntil),
+		ContextSize: hexutil.Uint64(result.ContextSize),
+	}, nil
+}
+
+// Rip7560InclusionLatency is the time a group of RIP-7560 transactions spent between
+// being first seen by the pool and being included in a block, in milliseconds.
+type Rip7560InclusionLatency struct {
+	Count   hexutil.Uint64 `json:"count"`
+	Average hexutil.Uint64 `json:"averageMs"`
+	Min     hexutil.Uint64 `json:"minMs"`
+	Max     hexutil.Uint64 `json:"maxMs"`
+}
+
+// Rip7560InclusionStats holds the inclusion latencies grouped by bundler ID and by paymaster.
+// Transactions paying for their own gas are grouped under the zero address.
+type Rip7560InclusionStats struct {
+	Bundlers   map[string]*Rip7560InclusionLatency         `json:"bundlers"`
+	Paymasters map[common.Address]*Rip7560InclusionLatency `json:"paymasters"`
+}
+
+func newRip7560InclusionLatency(latency *types.Rip7560InclusionLatency) *Rip7560InclusionLatency {
+	result := &Rip7560InclusionLatency{
+		Count: hexutil.Uint64(latency.Count),
+		Min:   hexutil.Uint64(latency.Min.Milliseconds()),
+		Max:   hexutil.Uint64(latency.Max.Milliseconds()),
+	}
+	if latency.Count > 0 {
+		result.Average = hexutil.Uint64((latency.Total / time.Duration(latency.Count)).Milliseconds())
+	}
+	return result
+}
+
+// GetInclusionStats returns the time from first seen to inclusion of the RIP-7560 transactions
+// submitted to this node, aggregated by bundler ID and by paymaster.
+func (api *Rip7560API) GetInclusionStats() *Rip7560InclusionStats {
+	result := &Rip7560InclusionStats{
+		Bundlers:   make(map[string]*Rip7560InclusionLatency),
+		Paymasters: make(map[common.Address]*Rip7560InclusionLatency),
+	}
+	stats := api.b.Rip7560InclusionStats()
+	if stats == nil {
+		return result
+	}
+	for id, latency := range stats.Bundlers {
+		result.Bundlers[id] = newRip7560InclusionLatency(latency)
+	}
+	for paymaster, latency := range stats.Paymasters {
+		result.Paymasters[paymaster] = newRip7560InclusionLatency(latency)
+	}
+	return result
+}
+
+// Rip7560PaymasterStats is the sponsorship activity of a paymaster within a range of blocks.
+type Rip7560PaymasterStats struct {
+	FromBlock         hexutil.Uint64 `json:"fromBlock"`
+	ToBlock           hexutil.Uint64 `json:"toBlock"`
+	Transactions      hexutil.Uint64 `json:"transactions"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	GasPaid           *hexutil.Big   `json:"gasPaid"`
+	PostOpFailures    hexutil.Uint64 `json:"postOpFailures"`
+	PostOpFailureRate float64        `json:"postOpFailureRate"`
+}
+
+// GetPaymasterStats returns the number of transactions sponsored by the paymaster, the gas
+// they used and paid for and the rate of their failed postOp frames, for every indexed range
+// of blocks overlapping [fromBlock, toBlock]. Ranges without sponsored transactions are omitted.
+func (api *Rip7560API) GetPaymasterStats(ctx context.Context, paymaster common.Address, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber) ([]*Rip7560PaymasterStats, error) {
+	resolve := func(number rpc.BlockNumber) (uint64, error) {
+		if number >= 0 {
+			return uint64(number), nil
+		}
+		header, err := api.b.HeaderByNumber(ctx, number)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, fmt.Errorf("block %v not found", number)
+		}
+		return header.Number.Uint64(), nil
+	}
+	from, err := resolve(fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolve(toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: fromBlock %d is after toBlock %d", from, to)
+	}
+	stats, err := api.b.Rip7560PaymasterStats(ctx, paymaster, from, to)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Rip7560PaymasterStats, len(stats))
+	for i, s := range stats {
+		result[i] = &Rip7560PaymasterStats{
+			FromBlock:      hexutil.Uint64(s.FromBlock),
+			ToBlock:        hexutil.Uint64(s.ToBlock),
+			Transactions:   hexutil.Uint64(s.Transactions),
+			GasUsed:        hexutil.Uint64(s.GasUsed),
+			GasPaid:        (*hexutil.Big)(s.GasPaid),
+			PostOpFailures: hexutil.Uint64(s.PostOpFailures),
+		}
+		if s.Transactions > 0 {
+			result[i].PostOpFailureRate = float64(s.PostOpFailures) / float64(s.Transactions)
+		}
+	}
+	return result, nil
+}
+
 type Rip7560UsedGas struct {
 	ValidationGas hexutil.Uint64 `json:"verificationGasLimit"`
 	ExecutionGas  hexutil.Uint64 `json:"callGasLimit"`
+	CallDataGas   hexutil.Uint64 `json:"callDataGas"` // Part of the verification gas charged for the calldata
 }
 
-func (s *TransactionAPI) SendRip7560TransactionsBundle(ctx context.Context, args []TransactionArgs, creationBlock *big.Int, bundlerId string) (common.Hash, error) {
+// SendRip7560TransactionsBundle submits a bundle to be included in the block following
+// creationBlock or, if validUntilBlock is given, in any block of the inclusion window
+// between them. The bundle expires once the window is over. With dryRun set, the bundle
+// is only run through the admission steps and their Rip7560SubmissionDiagnostics are
+// returned instead of the bundle hash. Otherwise, malformed transactions and, with
+// simulate set, transactions failing their validation phases on top of the latest block
+// reject the bundle with a Rip7560BundleError.
+func (s *TransactionAPI) SendRip7560TransactionsBundle(ctx context.Context, args []TransactionArgs, creationBlock *big.Int, bundlerId string, validUntilBlock *big.Int, dryRun *bool, simulate *bool) (interface{}, error) {
 	if len(args) == 0 {
 		return common.Hash{}, errors.New("submitted bundle has zero length")
 	}
-	txs := make([]*types.Transaction, len(args))
-	for i := 0; i < len(args); i++ {
-		txs[i] = args[i].ToTransaction()
+	txs, err := toRip7560Transactions(args)
+	if err != nil {
+		return common.Hash{}, err
 	}
 	bundle := &types.ExternallyReceivedBundle{
-		BundlerId:     bundlerId,
-		ValidForBlock: creationBlock,
-		Transactions:  txs,
+		BundlerId:       bundlerId,
+		ValidForBlock:   creationBlock,
+		Transactions:    txs,
+		ValidUntilBlock: validUntilBlock,
 	}
 	bundleHash := CalculateBundleHash(txs)
 	bundle.BundleHash = bundleHash
-	err := SubmitRip7560Bundle(ctx, s.b, bundle)
-	if err != nil {
+	if dryRun != nil && *dryRun {
+		return dryRunRip7560Bundle(ctx, s.b, bundle)
+	}
+	if err := checkRip7560BundleTransactions(ctx, s.b, txs, simulate != nil && *simulate); err != nil {
+		return common.Hash{}, err
+	}
+	if err := SubmitRip7560Bundle(ctx, s.b, bundle); err != nil {
 		return common.Hash{}, err
 	}
 	return bundleHash, nil
 }
 
+// Rip7560TransactionError is the error of a single transaction of a submitted bundle.
+type Rip7560TransactionError struct {
+	Index           hexutil.Uint64                     `json:"index"`
+	Message         string                             `json:"message"`
+	ValidationError *types.Rip7560TransactionDebugInfo `json:"validationError,omitempty"`
+}
+
+// Rip7560BundleError is returned if transactions of a submitted bundle are invalid. Its
+// JSON error data lists the errors of the invalid transactions by their index.
+type Rip7560BundleError struct {
+	Errors []*Rip7560TransactionError
+}
+
+func (e *Rip7560BundleError) Error() string {
+	first := e.Errors[0]
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("invalid bundle: transaction %d: %s", first.Index, first.Message)
+	}
+	return fmt.Sprintf("invalid bundle: transaction %d: %s (and %d more)", first.Index, first.Message, len(e.Errors)-1)
+}
+
+// ErrorCode returns the JSON error code of invalid parameters.
+func (e *Rip7560BundleError) ErrorCode() int {
+	return -32602
+}
+
+// ErrorData returns the errors of the invalid transactions.
+func (e *Rip7560BundleError) ErrorData() interface{} {
+	return e.Errors
+}
+
+// toRip7560Transactions converts the transaction arguments of a bundle, rejecting the
+// ones missing the fields every RIP-7560 transaction needs.
+func toRip7560Transactions(args []TransactionArgs) ([]*types.Transaction, error) {
+	var (
+		txs  = make([]*types.Transaction, len(args))
+		errs []*Rip7560TransactionError
+	)
+	for i := range args {
+		if missing := args[i].missingRip7560Field(); missing != "" {
+			errs = append(errs, &Rip7560TransactionError{Index: hexutil.Uint64(i), Message: fmt.Sprintf("%v: %s not set", types.ErrInvalidRip7560Tx, missing)})
+			continue
+		}
+		txs[i] = args[i].ToTransaction()
+	}
+	if len(errs) != 0 {
+		return nil, &Rip7560BundleError{Errors: errs}
+	}
+	return txs, nil
+}
+
+// missingRip7560Field returns the first field every RIP-7560 transaction needs that is
+// missing from the arguments, or an empty string if none is.
+func (args *TransactionArgs) missingRip7560Field() string {
+	switch {
+	case args.Sender == nil:
+		return "sender"
+	case args.Nonce == nil:
+		return "nonce"
+	case args.ExecutionData == nil:
+		return "executionData"
+	case args.AuthorizationData == nil:
+		return "authorizationData"
+	}
+	return ""
+}
+
+// checkRip7560BundleTransactions runs the stateless checks on the transactions of a
+// submitted bundle and, with simulate set, their validation phases on top of the
+// latest block, in order.
+func checkRip7560BundleTransactions(ctx context.Context, b Backend, txs []*types.Transaction, simulate bool) error {
+	var errs []*Rip7560TransactionError
+	for i, tx := range txs {
+		if err := checkRip7560Transaction(b.ChainConfig(), tx); err != nil {
+			errs = append(errs, &Rip7560TransactionError{Index: hexutil.Uint64(i), Message: err.Error()})
+		}
+	}
+	if len(errs) == 0 && simulate {
+		state, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if state == nil || err != nil {
+			return err
+		}
+		vmConfig := vm.Config{Rip7560ValidationTimeout: b.RPCEVMTimeout()}
+		simulation, err := simulateRip7560Bundle(ctx, b, txs, state, header, vmConfig)
+		if err != nil {
+			return err
+		}
+		for i, result := range simulation.Transactions {
+			if result.Error != "" {
+				errs = append(errs, &Rip7560TransactionError{Index: hexutil.Uint64(i), Message: result.Error})
+				continue
+			}
+			if result.ValidationError == nil {
+				continue
+			}
+			info := result.ValidationError
+			message := fmt.Sprintf("validation failed: %s", info.RevertData)
+			if info.RevertEntityName != "" {
+				message = fmt.Sprintf("validation failed in %s: %s", info.RevertEntityName, info.RevertData)
+			}
+			errs = append(errs, &Rip7560TransactionError{Index: hexutil.Uint64(i), Message: message, ValidationError: info})
+		}
+	}
+	if len(errs) != 0 {
+		return &Rip7560BundleError{Errors: errs}
+	}
+	return nil
+}
+
+// rawRip7560TxValidityBlocks is the number of blocks a transaction submitted on its own
+// remains pending for before it expires.
+const rawRip7560TxValidityBlocks = 8
+
+// SendRawRip7560Transaction submits an RLP-encoded, signed RIP-7560 transaction on its own
+// and returns its hash. The transaction is checked against the latest state and wrapped
+// into a single transaction bundle valid for the next rawRip7560TxValidityBlocks blocks.
+// With dryRun set, the Rip7560SubmissionDiagnostics of that bundle are returned instead.
+func (s *TransactionAPI) SendRawRip7560Transaction(ctx context.Context, input hexutil.Bytes, dryRun *bool) (interface{}, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	header := s.b.CurrentHeader()
+	txs := []*types.Transaction{tx}
+	nextBlock := new(big.Int).Add(header.Number, common.Big1)
+	bundle := &types.ExternallyReceivedBundle{
+		BundlerId:       "raw",
+		BundleHash:      CalculateBundleHash(txs),
+		ValidForBlock:   nextBlock,
+		Transactions:    txs,
+		ValidUntilBlock: new(big.Int).Add(nextBlock, big.NewInt(rawRip7560TxValidityBlocks-1)),
+	}
+	if dryRun != nil && *dryRun {
+		return dryRunRip7560Bundle(ctx, s.b, bundle)
+	}
+	if err := checkRip7560Transaction(s.b.ChainConfig(), tx); err != nil {
+		return common.Hash{}, err
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if statedb == nil || err != nil {
+		return common.Hash{}, err
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	vmConfig := vm.Config{Rip7560ValidationTimeout: s.b.RPCEVMTimeout()}
+	if _, err := core.ApplyRip7560ValidationPhases(s.b.ChainConfig(), NewChainContext(ctx, s.b), &header.Coinbase, gp, statedb, header, tx, vmConfig); err != nil {
+		return common.Hash{}, err
+	}
+	if err := SubmitRip7560Bundle(ctx, s.b, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// checkRip7560Transaction runs the stateless checks of a transaction submitted on its own.
+func checkRip7560Transaction(config *params.ChainConfig, tx *types.Transaction) error {
+	if tx.Type() != types.Rip7560Type {
+		return fmt.Errorf("%w: type %d", types.ErrTxTypeNotSupported, tx.Type())
+	}
+	aatx := tx.Rip7560TransactionData()
+	if err := aatx.SanityCheck(); err != nil {
+		return err
+	}
+	if aatx.ChainID.Cmp(config.ChainID) != 0 {
+		return fmt.Errorf("%w: have %v, want %v", types.ErrInvalidChainId, aatx.ChainID, config.ChainID)
+	}
+	return nil
+}
+
+// Rip7560SubmissionDiagnostics is the outcome of a dry run submission. Every admission
+// step is run on the bundle, but it is never enqueued: the stateless checks of its
+// transactions, the policy checks of the pool and the simulation of the bundle on top
+// of the latest block. Bundles with malformed transactions are not simulated.
+type Rip7560SubmissionDiagnostics struct {
+	BundleHash common.Hash              `json:"bundleHash"`
+	Accepted   bool                     `json:"accepted"`
+	Errors     []string                 `json:"errors,omitempty"`
+	Simulation *Rip7560BundleSimulation `json:"simulation,omitempty"`
+}
+
+// dryRunRip7560Bundle runs all admission steps on the bundle without submitting it.
+func dryRunRip7560Bundle(ctx context.Context, b Backend, bundle *types.ExternallyReceivedBundle) (*Rip7560SubmissionDiagnostics, error) {
+	diagnostics := &Rip7560SubmissionDiagnostics{BundleHash: bundle.BundleHash}
+	for i, tx := range bundle.Transactions {
+		if err := checkRip7560Transaction(b.ChainConfig(), tx); err != nil {
+			diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("transaction %d: %v", i, err))
+		}
+	}
+	malformed := len(diagnostics.Errors) != 0
+	if err := b.CheckRip7560Bundle(bundle); err != nil {
+		diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("pool: %v", err))
+	}
+	if malformed {
+		return diagnostics, nil
+	}
+	state, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	vmConfig := vm.Config{Rip7560ValidationTimeout: b.RPCEVMTimeout()}
+	if diagnostics.Simulation, err = simulateRip7560Bundle(ctx, b, bundle.Transactions, state, header, vmConfig); err != nil {
+		return nil, err
+	}
+	diagnostics.Accepted = len(diagnostics.Errors) == 0
+	for _, tx := range diagnostics.Simulation.Transactions {
+		if tx.ValidationError != nil || tx.Error != "" {
+			diagnostics.Accepted = false
+		}
+	}
+	return diagnostics, nil
+}
+
+// ExtendRip7560BundleValidity extends the validity window of a pending bundle up to and
+// including the given block, instead of cancelling and resubmitting it.
+func (s *TransactionAPI) ExtendRip7560BundleValidity(ctx context.Context, hash common.Hash, validUntilBlock *hexutil.Big) error {
+	if validUntilBlock == nil {
+		return errors.New("missing validUntilBlock")
+	}
+	return s.b.ExtendRip7560Bundle(ctx, hash, validUntilBlock.ToInt())
+}
+
 func (s *TransactionAPI) GetRip7560BundleStatus(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
 	bundleStats, err := s.b.GetRip7560BundleStatus(ctx, hash)
 	return bundleStats, err
 }
 
+// Rip7560SimulatedTransaction is the outcome of a single transaction of a simulated bundle.
+// Transactions that fail validation carry the validation error instead of a receipt, and
+// transactions that were not executed carry the error explaining why.
+type Rip7560SimulatedTransaction struct {
+	TxHash          common.Hash                        `json:"transactionHash"`
+	Status          *hexutil.Uint64                    `json:"status,omitempty"`
+	GasUsed         *hexutil.Uint64                    `json:"gasUsed,omitempty"`
+	Logs            []*types.Log                       `json:"logs,omitempty"`
+	GasAttribution  *types.Rip7560GasAttribution       `json:"gasAttribution,omitempty"`
+	ValidationError *types.Rip7560TransactionDebugInfo `json:"validationError,omitempty"`
+	Error           string                             `json:"error,omitempty"`
+}
+
+// Rip7560BundleSimulation is the outcome of a simulated bundle.
+type Rip7560BundleSimulation struct {
+	BlockNumber  hexutil.Uint64                 `json:"blockNumber"`
+	GasUsed      hexutil.Uint64                 `json:"gasUsed"`
+	Transactions []*Rip7560SimulatedTransaction `json:"transactions"`
+}
+
+// SimulateRip7560Bundle runs the validation and execution phases of all transactions of the bundle
+// in a pending block on top of the given block, the same way the block builder would, and returns
+// the outcome of each transaction. The bundle is never submitted to the pool and all state changes are discarded.
+func (s *TransactionAPI) SimulateRip7560Bundle(ctx context.Context, args []TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*Rip7560BundleSimulation, error) {
+	if len(args) == 0 {
+		return nil, errors.New("submitted bundle has zero length")
+	}
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i].ChainID == nil {
+			args[i].ChainID = (*hexutil.Big)(s.b.ChainConfig().ChainID)
+		}
+		txs[i] = args[i].ToTransaction()
+		if txs[i].Type() != types.Rip7560Type {
+			return nil, fmt.Errorf("transaction %d is not an RIP-7560 transaction", i)
+		}
+	}
+	return simulateRip7560Bundle(ctx, s.b, txs, state, header, vm.Config{NoBaseFee: true})
+}
+
+// simulateRip7560Bundle runs the validation and execution phases of the transactions on
+// top of the given state, in a pending block built on the given parent, and returns the
+// outcome of each transaction. The gas available to the bundle is capped by the RPC gas cap.
+func simulateRip7560Bundle(ctx context.Context, b Backend, txs []*types.Transaction, state *state.StateDB, parent *types.Header, vmConfig vm.Config) (*Rip7560BundleSimulation, error) {
+	header := rip7560PendingHeader(b.ChainConfig(), parent)
+	gasLimit := header.GasLimit
+	if gasCap := b.RPCGasCap(); gasCap != 0 && gasCap < gasLimit {
+		gasLimit = gasCap
+	}
+	var (
+		gp      = new(core.GasPool).AddGas(gasLimit)
+		usedGas uint64
+	)
+	_, receipts, validationFailures, _, err := core.HandleRip7560Transactions(
+		txs, 0, state, &header.Coinbase, header, gp, b.ChainConfig(), NewChainContext(ctx, b), vmConfig, true, &usedGas,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[common.Hash]*Rip7560SimulatedTransaction, len(txs))
+	for _, receipt := range receipts {
+		status, gasUsed := hexutil.Uint64(receipt.Status), hexutil.Uint64(receipt.GasUsed)
+		results[receipt.TxHash] = &Rip7560SimulatedTransaction{TxHash: receipt.TxHash, Status: &status, GasUsed: &gasUsed, Logs: receipt.Logs, GasAttribution: receipt.Rip7560GasAttribution}
+	}
+	for _, info := range validationFailures {
+		results[info.TxHash] = &Rip7560SimulatedTransaction{TxHash: info.TxHash, ValidationError: info}
+	}
+	simulation := &Rip7560BundleSimulation{
+		BlockNumber:  hexutil.Uint64(header.Number.Uint64()),
+		GasUsed:      hexutil.Uint64(usedGas),
+		Transactions: make([]*Rip7560SimulatedTransaction, len(txs)),
+	}
+	for i, tx := range txs {
+		result := results[tx.Hash()]
+		if result == nil {
+			// Skipped by the builder, the transaction is left for a later block
+			result = &Rip7560SimulatedTransaction{TxHash: tx.Hash(), Error: errRip7560NotExecuted.Error()}
+		}
+		simulation.Transactions[i] = result
+	}
+	return simulation, nil
+}
+
+// errRip7560NotExecuted is reported for the simulated transactions that did not fit in
+// the gas left for the bundle.
+var errRip7560NotExecuted = errors.New("not executed: exceeds the remaining gas of the bundle")
+
+// rip7560PendingHeader returns the header of the pending block built on top of the given
+// parent, that bundles are simulated in.
+func rip7560PendingHeader(config *params.ChainConfig, parent *types.Header) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Coinbase:   parent.Coinbase,
+		Difficulty: parent.Difficulty,
+		MixDigest:  parent.MixDigest,
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent, header.Time)
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		var excessBlobGas uint64
+		if parent.ExcessBlobGas != nil && parent.BlobGasUsed != nil {
+			excessBlobGas = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		} else {
+			excessBlobGas = eip4844.CalcExcessBlobGas(0, 0)
+		}
+		header.BlobGasUsed = new(uint64)
+		header.ExcessBlobGas = &excessBlobGas
+	}
+	return header
+}
+
+type rip7560FeeHistoryResult struct {
+	OldestBlock *hexutil.Big     `json:"oldestBlock"`
+	BuilderFee  [][]*hexutil.Big `json:"builderFee"`
+	Reward      [][]*hexutil.Big `json:"reward"`
+}
+
+// Rip7560FeeHistory returns the builder fee and priority fee percentiles of the RIP-7560
+// transactions included in the given range of blocks, so that bundlers can estimate fees.
+func (api *EthereumAPI) Rip7560FeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, percentiles []float64) (*rip7560FeeHistoryResult, error) {
+	oldest, builderFee, reward, err := api.b.Rip7560FeeHistory(ctx, uint64(blockCount), lastBlock, percentiles)
+	if err != nil {
+		return nil, err
+	}
+	toHexutil := func(values [][]*big.Int) [][]*hexutil.Big {
+		result := make([][]*hexutil.Big, len(values))
+		for i, w := range values {
+			result[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				result[i][j] = (*hexutil.Big)(v)
+			}
+		}
+		return result
+	}
+	return &rip7560FeeHistoryResult{
+		OldestBlock: (*hexutil.Big)(oldest),
+		BuilderFee:  toHexutil(builderFee),
+		Reward:      toHexutil(reward),
+	}, nil
+}
+
+// Rip7560TransactionStatus creates a subscription that is notified each time the RIP-7560 transaction
+// with the given hash moves to another stage of its lifecycle: accepted to the pool, revalidated,
+// selected for a block, included (with its receipt) or dropped (with the reason).
+func (s *TransactionAPI) Rip7560TransactionStatus(ctx context.Context, hash common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.Rip7560TxStatusEvent, 16)
+		sub := s.b.SubscribeRip7560TxStatusEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				if ev.TxHash == hash {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Rip7560BundlerDroppedTransactions creates a subscription that is notified each time a transaction
+// of one of the given bundles is dropped during block building, together with the debug info
+// explaining why its validation failed. The bundles are identified by the hashes returned on their
+// submission, as the bundler ids are chosen by the bundlers and would let anyone read the drop
+// reasons of the bundles of others.
+func (s *TransactionAPI) Rip7560BundlerDroppedTransactions(ctx context.Context, bundleHashes []common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if len(bundleHashes) == 0 {
+		return &rpc.Subscription{}, errors.New("no bundle hashes to watch")
+	}
+	watched := make(map[common.Hash]struct{}, len(bundleHashes))
+	for _, hash := range bundleHashes {
+		watched[hash] = struct{}{}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.Rip7560TxStatusEvent, 16)
+		sub := s.b.SubscribeRip7560TxStatusEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				dropped := ev.Status == core.Rip7560TxDropped || ev.Status == core.Rip7560TxTimedOut
+				if _, ok := watched[ev.BundleHash]; ok && dropped && ev.DebugInfo != nil {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 func (s *TransactionAPI) GetRip7560TransactionDebugInfo(hash common.Hash) (map[string]interface{}, error) {
 	return s.b.GetRip7560TransactionDebugInfo(hash)
 }
@@ -69,7 +868,7 @@ func (s *TransactionAPI) CallRip7560Validation(ctx context.Context, args Transac
 	//	return nil, err
 	//}
 
-	//if s.b.ChainConfig().IsRIP7560(header.Number) {
+	//if s.b.ChainConfig().IsRIP7560(header.Number, header.Time) {
 	//	return nil, fmt.Errorf("cannot call RIP-7560 validation on pre-rip7560 block %v", header.Number)
 	//}
 
@@ -132,7 +931,7 @@ func doCallRip7560Validation(ctx context.Context, b Backend, args TransactionArg
 	if err != nil {
 		return nil, err
 	}
-	_, _, err = core.BuyGasRip7560Transaction(aatx, state, gasPriceUint256, gp, rollupCost)
+	_, _, err = core.BuyGasRip7560Transaction(aatx, state, gasPriceUint256, gp, rollupCost, core.Rip7560BuilderFee(chainConfig, header, aatx))
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +993,7 @@ func DoEstimateRip7560TransactionGas(ctx context.Context, b Backend, args Transa
 	if err != nil {
 		return nil, err
 	}
-	_, _, err = core.BuyGasRip7560Transaction(aatx, state, gasPriceUint256, gp, rollupCost)
+	_, _, err = core.BuyGasRip7560Transaction(aatx, state, gasPriceUint256, gp, rollupCost, core.Rip7560BuilderFee(chainConfig, header, aatx))
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +1018,7 @@ func DoEstimateRip7560TransactionGas(ctx context.Context, b Backend, args Transa
 	return &Rip7560UsedGas{
 		ValidationGas: hexutil.Uint64(vg),
 		ExecutionGas:  hexutil.Uint64(eg),
+		CallDataGas:   hexutil.Uint64(opts.ValidationPhaseResult.CallDataUsedGas),
 	}, nil
 }
 
@@ -233,13 +1033,306 @@ func (s *BlockChainAPI) EstimateRip7560TransactionGas(ctx context.Context, args
 		return nil, err
 	}
 
-	if !s.b.ChainConfig().IsRIP7560(header.Number) {
+	if !s.b.ChainConfig().IsRIP7560(header.Number, header.Time) {
 		return nil, fmt.Errorf("cannot estimate gas for RIP-7560 tx on pre-bedrock block %v", header.Number)
 	}
 
 	return DoEstimateRip7560TransactionGas(ctx, s.b, args, bNrOrHash, overrides, s.b.RPCGasCap())
 }
 
+// Rip7560CallResult is the outcome of a simulated RIP-7560 transaction.
+type Rip7560CallResult struct {
+	Status           hexutil.Uint64               `json:"status"`
+	ReturnData       hexutil.Bytes                `json:"returnData"`                 // Returned or reverted by the execution frame
+	ExecutionError   string                       `json:"executionError,omitempty"`   // Error of the execution frame, if it failed
+	PostOpReturnData hexutil.Bytes                `json:"postOpReturnData,omitempty"` // Returned or reverted by the paymaster postOp frame
+	PostOpError      string                       `json:"postOpError,omitempty"`      // Error of the paymaster postOp frame, if it failed
+	GasUsed          hexutil.Uint64               `json:"gasUsed"`
+	GasAttribution   *types.Rip7560GasAttribution `json:"gasAttribution"`
+	Logs             []*types.Log                 `json:"logs"`
+}
+
+// CallRip7560 runs the validation and execution phases of the given transaction on top of
+// the given block state, and returns the data returned by its execution frame, the gas
+// used by each of its frames and the logs it emitted. Nothing is submitted and all state
+// changes are discarded. A transaction failing validation returns the validation error.
+func (s *BlockChainAPI) CallRip7560(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*Rip7560CallResult, error) {
+	tx, state, header, err := rip7560CallState(ctx, s.b, args, blockNrOrHash, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return doCallRip7560(ctx, s.b, tx, state, header, vm.Config{NoBaseFee: true, Rip7560ValidationTimeout: s.b.RPCEVMTimeout()})
+}
+
+// rip7560CallState returns the transaction of the call arguments, filled with the call
+// defaults, along with the state and header of the given block to simulate it on, the
+// latest one by default. The state overrides are applied to the returned state.
+func rip7560CallState(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*types.Transaction, *state.StateDB, *types.Header, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, nil, nil, err
+	}
+	if !b.ChainConfig().IsRIP7560(header.Number, header.Time) {
+		return nil, nil, nil, fmt.Errorf("cannot call RIP-7560 tx on pre-RIP-7560 block %v", header.Number)
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := args.Call7560Defaults(b.RPCGasCap(), header.BaseFee, b.ChainConfig().ChainID); err != nil {
+		return nil, nil, nil, err
+	}
+	return args.ToTransaction(), state, header, nil
+}
+
+func doCallRip7560(ctx context.Context, b Backend, tx *types.Transaction, state *state.StateDB, header *types.Header, vmConfig vm.Config) (*Rip7560CallResult, error) {
+	var (
+		chainConfig = b.ChainConfig()
+		bc          = NewChainContext(ctx, b)
+		gp          = new(core.GasPool).AddGas(math.MaxUint64)
+		usedGas     uint64
+	)
+	state.SetTxContext(tx.Hash(), 0)
+	vpr, err := core.ApplyRip7560ValidationPhases(chainConfig, bc, &header.Coinbase, gp, state, header, tx, vmConfig)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	receipt, executionResult, postOpResult, err := core.ApplyRip7560ExecutionPhase(chainConfig, vpr, bc, &header.Coinbase, gp, state, header, vmConfig, &usedGas)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	result := &Rip7560CallResult{
+		Status:         hexutil.Uint64(receipt.Status),
+		ReturnData:     executionResult.Revert(),
+		GasUsed:        hexutil.Uint64(receipt.GasUsed),
+		GasAttribution: receipt.Rip7560GasAttribution,
+		Logs:           receipt.Logs,
+	}
+	if result.Logs == nil {
+		result.Logs = []*types.Log{}
+	}
+	if executionResult.Err != nil {
+		result.ExecutionError = executionResult.Err.Error()
+	} else {
+		result.ReturnData = executionResult.Return()
+	}
+	if postOpResult != nil {
+		if postOpResult.Err != nil {
+			result.PostOpReturnData, result.PostOpError = postOpResult.Revert(), postOpResult.Err.Error()
+		} else {
+			result.PostOpReturnData = postOpResult.Return()
+		}
+	}
+	return result, nil
+}
+
+// GetNonce returns the next nonce the sender is expected to use with the given nonce key
+// at the given block, the latest one by default: the account nonce for the zero key, or
+// the sequence number kept by the RIP-7712 NonceManager for any other key.
+func (api *Rip7560API) GetNonce(ctx context.Context, sender common.Address, nonceKey *hexutil.Big, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	return rip7560Nonce(ctx, api.b, sender, nonceKey.ToInt(), bNrOrHash)
+}
+
+// rip7560Nonce returns the next nonce of the sender for the nonce key at the given block.
+func rip7560Nonce(ctx context.Context, b Backend, sender common.Address, nonceKey *big.Int, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return 0, err
+	}
+	nonce, err := core.ReadRip7560Nonce(b.ChainConfig(), NewChainContext(ctx, b), header, state, sender, nonceKey)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(nonce), state.Error()
+}
+
+// GetSigningHash returns the hash the validation frames pass to the account and the paymaster
+// of the given transaction, for them to check its signature, if included in the block following
+// the given one, the latest by default. The transaction is hashed as given, without defaults.
+func (api *Rip7560API) GetSigningHash(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (common.Hash, error) {
+	if missing := args.missingRip7560Field(); missing != "" {
+		return common.Hash{}, fmt.Errorf("%w: %s not set", types.ErrInvalidRip7560Tx, missing)
+	}
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	header, err := headerByNumberOrHash(ctx, api.b, bNrOrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	next := types.CopyHeader(header)
+	next.Number.Add(next.Number, common.Big1)
+	return core.MakeRip7560Signer(api.b.ChainConfig(), next).Hash(args.ToTransaction()), nil
+}
+
+// Rip7712NonceKey is an RIP-7712 nonce key used by a sender along with its current sequence number.
+type Rip7712NonceKey struct {
+	Key   *hexutil.Big   `json:"key"`
+	Nonce hexutil.Uint64 `json:"nonce"`
+}
+
+// GetRip7712NonceKeys returns all RIP-7712 nonce keys the sender has used in the canonical chain
+// together with their sequence numbers at the given block, read from the NonceManager.
+func (s *BlockChainAPI) GetRip7712NonceKeys(ctx context.Context, sender common.Address, blockNrOrHash *rpc.BlockNumberOrHash) ([]*Rip7712NonceKey, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	keys := rawdb.ReadRip7712NonceKeys(s.b.ChainDb(), sender)
+	result := make([]*Rip7712NonceKey, 0, len(keys))
+	nonceManager := core.Rip7712NonceManager(s.b.ChainConfig())
+	for _, key := range keys {
+		data := hexutil.Bytes(core.PrepareNonceManagerGetMessage(sender, key))
+		args := TransactionArgs{To: &nonceManager, Data: &data}
+		res, err := doCall(ctx, s.b, args, state, header, nil, nil, s.b.RPCEVMTimeout(), s.b.RPCGasCap())
+		if err != nil {
+			return nil, err
+		}
+		if res.Failed() {
+			return nil, fmt.Errorf("failed to read RIP-7712 nonce for key %#x: %w", key, res.Err)
+		}
+		nonce, err := core.ParseNonceManagerGetResult(res.Return())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RIP-7712 nonce for key %#x: %w", key, err)
+		}
+		result = append(result, &Rip7712NonceKey{
+			Key:   (*hexutil.Big)(key),
+			Nonce: hexutil.Uint64(nonce),
+		})
+	}
+	return result, nil
+}
+
+// Rip7560SystemEvent is a decoded event injected by the EntryPoint for an RIP-7560 transaction.
+type Rip7560SystemEvent struct {
+	Name     string                 `json:"name"`
+	LogIndex hexutil.Uint           `json:"logIndex"`
+	Args     map[string]interface{} `json:"args"`
+}
+
+// Rip7560TransactionSummary describes an RIP-7560 transaction included in a block.
+type Rip7560TransactionSummary struct {
+	TransactionHash             common.Hash           `json:"transactionHash"`
+	TransactionIndex            hexutil.Uint64        `json:"transactionIndex"`
+	Sender                      *common.Address       `json:"sender"`
+	Paymaster                   *common.Address       `json:"paymaster"`
+	Deployer                    *common.Address       `json:"deployer"`
+	NonceKey                    *hexutil.Big          `json:"nonceKey"`
+	Nonce                       hexutil.Uint64        `json:"nonce"`
+	ValidationGasLimit          hexutil.Uint64        `json:"verificationGasLimit"`
+	PaymasterValidationGasLimit hexutil.Uint64        `json:"paymasterVerificationGasLimit"`
+	CallGasLimit                hexutil.Uint64        `json:"callGasLimit"`
+	PostOpGasLimit              hexutil.Uint64        `json:"paymasterPostOpGasLimit"`
+	GasUsed                     hexutil.Uint64        `json:"gasUsed"`
+	Status                      hexutil.Uint64        `json:"status"`
+	ExecutionStatus             *hexutil.Uint64       `json:"executionStatus"`
+	Events                      []*Rip7560SystemEvent `json:"events"`
+}
+
+// GetRip7560BlockSummary returns all RIP-7560 transactions included in the given block together
+// with their gas limits and usage, execution status and the decoded events injected by the EntryPoint.
+func (s *BlockChainAPI) GetRip7560BlockSummary(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*Rip7560TransactionSummary, error) {
+	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(txs) != len(receipts) {
+		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
+	}
+	result := make([]*Rip7560TransactionSummary, 0)
+	for i, tx := range txs {
+		if tx.Type() != types.Rip7560Type {
+			continue
+		}
+		summary, err := newRip7560TransactionSummary(tx, receipts[i], i)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+func newRip7560TransactionSummary(tx *types.Transaction, receipt *types.Receipt, index int) (*Rip7560TransactionSummary, error) {
+	aatx := tx.Rip7560TransactionData()
+	summary := &Rip7560TransactionSummary{
+		TransactionHash:             tx.Hash(),
+		TransactionIndex:            hexutil.Uint64(index),
+		Sender:                      aatx.Sender,
+		Paymaster:                   aatx.Paymaster,
+		Deployer:                    aatx.Deployer,
+		NonceKey:                    (*hexutil.Big)(aatx.NonceKey),
+		Nonce:                       hexutil.Uint64(aatx.Nonce),
+		ValidationGasLimit:          hexutil.Uint64(aatx.ValidationGasLimit),
+		PaymasterValidationGasLimit: hexutil.Uint64(aatx.PaymasterValidationGasLimit),
+		CallGasLimit:                hexutil.Uint64(aatx.Gas),
+		PostOpGasLimit:              hexutil.Uint64(aatx.PostOpGas),
+		GasUsed:                     hexutil.Uint64(receipt.GasUsed),
+		Status:                      hexutil.Uint64(receipt.Status),
+		Events:                      make([]*Rip7560SystemEvent, 0),
+	}
+	for _, log := range receipt.Logs {
+		event, err := decodeRip7560SystemEvent(log)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+		if status, ok := event.Args["executionStatus"].(*big.Int); ok && event.Name == "RIP7560TransactionEvent" {
+			executionStatus := hexutil.Uint64(status.Uint64())
+			summary.ExecutionStatus = &executionStatus
+		}
+		summary.Events = append(summary.Events, event)
+	}
+	return summary, nil
+}
+
+// decodeRip7560SystemEvent decodes a log emitted by the EntryPoint, or returns nil for any other log.
+func decodeRip7560SystemEvent(log *types.Log) (*Rip7560SystemEvent, error) {
+	if log.Address != core.AA_ENTRY_POINT || len(log.Topics) == 0 {
+		return nil, nil
+	}
+	event, err := core.Rip7560Abi.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, nil
+	}
+	args := make(map[string]interface{})
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(args, log.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", event.Name, err)
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event topics: %w", event.Name, err)
+	}
+	return &Rip7560SystemEvent{Name: event.Name, LogIndex: hexutil.Uint(log.Index), Args: args}, nil
+}
+
 // CalculateBundleHash
 // TODO: If this code is indeed necessary, keep it in utils; better - remove altogether.
 func CalculateBundleHash(txs []*types.Transaction) common.Hash {
@@ -0,0 +1,959 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRip7560TransactionSummary(t *testing.T) {
+	sender := common.Address{0x01}
+	paymaster := common.Address{0x02}
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{
+		Sender:             &sender,
+		Paymaster:          &paymaster,
+		NonceKey:           big.NewInt(3),
+		Nonce:              4,
+		Gas:                1000,
+		ValidationGasLimit: 2000,
+	})
+
+	event := core.Rip7560Abi.Events["RIP7560TransactionEvent"]
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(3), big.NewInt(4), big.NewInt(int64(core.ExecutionStatusExecutionFailure)))
+	if err != nil {
+		t.Fatalf("failed to pack event: %v", err)
+	}
+	receipt := &types.Receipt{
+		Status:  types.ReceiptStatusSuccessful,
+		GasUsed: 500,
+		Logs: []*types.Log{
+			{Address: common.Address{0x03}, Topics: []common.Hash{event.ID}},
+			{
+				Address: core.AA_ENTRY_POINT,
+				Topics:  []common.Hash{event.ID, common.BytesToHash(sender.Bytes()), common.BytesToHash(paymaster.Bytes())},
+				Data:    data,
+				Index:   1,
+			},
+		},
+	}
+
+	summary, err := newRip7560TransactionSummary(tx, receipt, 2)
+	if err != nil {
+		t.Fatalf("failed to create summary: %v", err)
+	}
+	if summary.TransactionIndex != 2 || summary.GasUsed != 500 || summary.CallGasLimit != 1000 || summary.ValidationGasLimit != 2000 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.ExecutionStatus == nil || uint64(*summary.ExecutionStatus) != core.ExecutionStatusExecutionFailure {
+		t.Errorf("execution status mismatch: have %v, want %d", summary.ExecutionStatus, core.ExecutionStatusExecutionFailure)
+	}
+	// only the log emitted by the EntryPoint is decoded
+	if len(summary.Events) != 1 {
+		t.Fatalf("event count mismatch: have %d, want 1", len(summary.Events))
+	}
+	decoded := summary.Events[0]
+	if decoded.Name != "RIP7560TransactionEvent" || decoded.LogIndex != 1 {
+		t.Errorf("unexpected event: %s at %d", decoded.Name, decoded.LogIndex)
+	}
+	if have := decoded.Args["sender"]; have != sender {
+		t.Errorf("sender mismatch: have %v, want %v", have, sender)
+	}
+	if have := decoded.Args["paymaster"]; have != paymaster {
+		t.Errorf("paymaster mismatch: have %v, want %v", have, paymaster)
+	}
+}
+
+func TestRip7560GetSupportedEntryPoints(t *testing.T) {
+	b := newBackendMock()
+	api := NewRip7560API(b)
+	if have := api.GetSupportedEntryPoints(); len(have) != 0 {
+		t.Errorf("expected no entrypoints before activation, have %d", len(have))
+	}
+
+	b.config.RIP7560Block = big.NewInt(0)
+	have := api.GetSupportedEntryPoints()
+	if len(have) != 1 || have[0].Address != core.AA_ENTRY_POINT || have[0].AbiVersion != core.Rip7560AbiVersion {
+		t.Errorf("unexpected entrypoints: %v", have)
+	}
+	if have[0].SigningDomain != nil {
+		t.Errorf("unexpected signing domain before activation: %v", have[0].SigningDomain)
+	}
+
+	b.config.RIP7560SigningDomainBlock = big.NewInt(0)
+	domain := api.GetSupportedEntryPoints()[0].SigningDomain
+	if domain == nil {
+		t.Fatal("missing signing domain after activation")
+	}
+	if domain.ChainID.ToInt().Cmp(b.config.ChainID) != 0 || domain.EntryPoint != core.AA_ENTRY_POINT || domain.AbiVersion != core.Rip7560AbiVersion {
+		t.Errorf("unexpected signing domain: %+v", domain)
+	}
+}
+
+// entryPointCallbackCode returns the code of a contract that calls the EntryPoint with the given calldata.
+func entryPointCallbackCode(calldata []byte) []byte {
+	size := byte(len(calldata))
+	code := []byte{
+		byte(vm.PUSH1), size, byte(vm.PUSH1), 24, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), 0x75, 0x60, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP),
+	}
+	return append(code, calldata...)
+}
+
+func TestSimulateRip7560Bundle(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	var (
+		config   = *params.TestChainConfig
+		valid    = common.Address{0x01}
+		invalid  = common.Address{0x02}
+		reverted = []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config:   &config,
+		GasLimit: 1_000_000,
+		Alloc: types.GenesisAlloc{
+			valid:   {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptAccount)},
+			invalid: {Balance: big.NewInt(params.Ether), Code: reverted},
+		},
+	}
+	api := NewTransactionAPI(newTestBackend(t, 0, genesis, ethash.NewFaker(), nil), nil)
+
+	newArgs := func(sender common.Address, nonce hexutil.Uint64, executionGas hexutil.Uint64) TransactionArgs {
+		gas := hexutil.Uint64(100_000)
+		return TransactionArgs{
+			Sender:               &sender,
+			Nonce:                &nonce,
+			Gas:                  &executionGas,
+			ValidationGas:        &gas,
+			MaxFeePerGas:         (*hexutil.Big)(big.NewInt(params.GWei * 2)),
+			MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(1)),
+			BuilderFee:           new(hexutil.Big),
+			ExecutionData:        new(hexutil.Bytes),
+			AuthorizationData:    new(hexutil.Bytes),
+		}
+	}
+	// The last transaction fits in the block, but not in the gas left by the others
+	bundle := []TransactionArgs{newArgs(invalid, 0, 100_000), newArgs(valid, 0, 100_000), newArgs(valid, 1, 750_000)}
+	simulation, err := api.SimulateRip7560Bundle(context.Background(), bundle, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if simulation.BlockNumber != 1 {
+		t.Errorf("bundle not simulated in the pending block: have block %d, want 1", simulation.BlockNumber)
+	}
+	if len(simulation.Transactions) != 3 {
+		t.Fatalf("transaction count mismatch: have %d, want 3", len(simulation.Transactions))
+	}
+	if skipped := simulation.Transactions[2]; skipped == nil || skipped.Error == "" || skipped.Status != nil {
+		t.Errorf("expected the last transaction to be reported as not executed, have %+v", skipped)
+	}
+	if failed := simulation.Transactions[0]; failed.ValidationError == nil || failed.Status != nil {
+		t.Errorf("expected validation failure for the first transaction, have %+v", failed)
+	}
+	included := simulation.Transactions[1]
+	if included.ValidationError != nil || included.Status == nil || uint64(*included.Status) != types.ReceiptStatusSuccessful {
+		t.Fatalf("expected the second transaction to be included, have %+v", included)
+	}
+	if uint64(*included.GasUsed) != uint64(simulation.GasUsed) || len(included.Logs) == 0 {
+		t.Errorf("unexpected receipt: gas used %d of %d, %d logs", *included.GasUsed, simulation.GasUsed, len(included.Logs))
+	}
+	if included.GasAttribution == nil || included.GasAttribution.GasUsed() != uint64(*included.GasUsed) {
+		t.Errorf("gas attribution does not add up to the gas used: %+v", included.GasAttribution)
+	}
+}
+
+func TestCallRip7560(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	// The account accepts the transaction through the EntryPoint callback, then returns 42
+	size := byte(len(acceptAccount))
+	code := append([]byte{
+		byte(vm.PUSH1), size, byte(vm.PUSH1), 33, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), 0x75, 0x60, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 42, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}, acceptAccount...)
+
+	var (
+		config  = *params.TestChainConfig
+		valid   = common.Address{0x01}
+		invalid = common.Address{0x02}
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			valid:   {Balance: big.NewInt(params.Ether), Code: code},
+			invalid: {Balance: big.NewInt(params.Ether), Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+		},
+	}
+	api := NewBlockChainAPI(newTestBackend(t, 0, genesis, ethash.NewFaker(), nil))
+
+	newArgs := func(sender common.Address) TransactionArgs {
+		gas := hexutil.Uint64(100_000)
+		return TransactionArgs{
+			Sender:            &sender,
+			Nonce:             new(hexutil.Uint64),
+			Gas:               &gas,
+			ValidationGas:     &gas,
+			ExecutionData:     new(hexutil.Bytes),
+			AuthorizationData: new(hexutil.Bytes),
+		}
+	}
+	result, err := api.CallRip7560(context.Background(), newArgs(valid), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to call transaction: %v", err)
+	}
+	if uint64(result.Status) != types.ReceiptStatusSuccessful || result.ExecutionError != "" {
+		t.Fatalf("unexpected execution failure: %+v", result)
+	}
+	if want := common.BigToHash(big.NewInt(42)).Bytes(); !reflect.DeepEqual([]byte(result.ReturnData), want) {
+		t.Errorf("return data mismatch: have %x, want %x", result.ReturnData, want)
+	}
+	if result.GasAttribution == nil || result.GasAttribution.GasUsed() != uint64(result.GasUsed) || result.GasAttribution.Execution == 0 {
+		t.Errorf("gas attribution does not add up to the gas used: %+v", result.GasAttribution)
+	}
+	if len(result.Logs) == 0 {
+		t.Errorf("missing logs of the transaction")
+	}
+	if _, err := api.CallRip7560(context.Background(), newArgs(invalid), nil, nil); err == nil {
+		t.Errorf("expected validation failure")
+	}
+}
+
+func TestRip7560ValidateTransaction(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(1), big.NewInt(1000))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	acceptPaymaster, err := core.Rip7560Abi.Pack("acceptPaymaster", big.NewInt(0), big.NewInt(2000), []byte{0xca, 0xfe})
+	if err != nil {
+		t.Fatalf("failed to pack acceptPaymaster: %v", err)
+	}
+	var (
+		config    = *params.TestChainConfig
+		sender    = common.Address{0x01}
+		paymaster = common.Address{0x02}
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config:    &config,
+		Timestamp: 10,
+		Alloc: types.GenesisAlloc{
+			sender:    {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptAccount)},
+			paymaster: {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptPaymaster)},
+		},
+	}
+	api := NewRip7560API(newTestBackend(t, 0, genesis, ethash.NewFaker(), nil))
+
+	gas := hexutil.Uint64(100_000)
+	args := TransactionArgs{
+		Sender:            &sender,
+		Nonce:             new(hexutil.Uint64),
+		Gas:               &gas,
+		ValidationGas:     &gas,
+		ExecutionData:     new(hexutil.Bytes),
+		AuthorizationData: new(hexutil.Bytes),
+	}
+	validation, err := api.ValidateTransaction(context.Background(), args, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to validate transaction: %v", err)
+	}
+	if want := (Rip7560ValidityWindow{ValidAfter: 1, ValidUntil: 1000}); validation.Account != want {
+		t.Errorf("account validity mismatch: have %+v, want %+v", validation.Account, want)
+	}
+	if validation.Paymaster != nil || len(validation.PaymasterContext) != 0 {
+		t.Errorf("unexpected paymaster validation of a self-paid transaction: %+v", validation)
+	}
+	if validation.GasUsed.PreTransaction == 0 || validation.GasUsed.CallData != 0 || validation.GasUsed.AccountValidation == 0 || validation.GasUsed.PaymasterValidation != 0 {
+		t.Errorf("unexpected gas used: %+v", validation.GasUsed)
+	}
+
+	args.Paymaster = &paymaster
+	args.PaymasterGas = &gas
+	args.PostOpGas = &gas
+	args.PaymasterData = &hexutil.Bytes{1}
+	validation, err = api.ValidateTransaction(context.Background(), args, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to validate sponsored transaction: %v", err)
+	}
+	if want := (Rip7560ValidityWindow{ValidAfter: 0, ValidUntil: 2000}); validation.Paymaster == nil || *validation.Paymaster != want {
+		t.Errorf("paymaster validity mismatch: have %+v, want %+v", validation.Paymaster, want)
+	}
+	if !reflect.DeepEqual([]byte(validation.PaymasterContext), []byte{0xca, 0xfe}) {
+		t.Errorf("paymaster context mismatch: have %x, want cafe", validation.PaymasterContext)
+	}
+	if validation.GasUsed.PaymasterValidation == 0 || validation.GasUsed.CallData != hexutil.Uint64(params.TxDataNonZeroGasEIP2028) {
+		t.Errorf("unexpected sponsored gas used: %+v", validation.GasUsed)
+	}
+}
+
+func TestRip7560GetNonce(t *testing.T) {
+	var (
+		config = *params.TestChainConfig
+		sender = common.Address{0x01}
+	)
+	config.RIP7560Block = big.NewInt(0)
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether), Nonce: 3},
+			// The NonceManager returns sequence number 7 with the key in the upper bits, for any key
+			core.AA_NONCE_MANAGER: {Code: []byte{
+				byte(vm.PUSH1), 7, byte(vm.PUSH1), 1, byte(vm.PUSH1), 192, byte(vm.SHL), byte(vm.OR), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+				byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+			}},
+		},
+	}
+	api := NewRip7560API(newTestBackend(t, 0, genesis, ethash.NewFaker(), nil))
+
+	for _, tt := range []struct {
+		key   *hexutil.Big
+		nonce hexutil.Uint64
+	}{
+		{key: nil, nonce: 3},
+		{key: new(hexutil.Big), nonce: 3},
+		{key: (*hexutil.Big)(big.NewInt(1)), nonce: 7},
+	} {
+		nonce, err := api.GetNonce(context.Background(), sender, tt.key, nil)
+		if err != nil {
+			t.Fatalf("key %v: failed to get nonce: %v", tt.key, err)
+		}
+		if nonce != tt.nonce {
+			t.Errorf("key %v: nonce mismatch: have %d, want %d", tt.key, nonce, tt.nonce)
+		}
+	}
+}
+
+func TestRip7560RPCTransaction(t *testing.T) {
+	config := *params.TestChainConfig
+	config.RIP7560Block = big.NewInt(0)
+	sender := common.Address{0x01}
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:            config.ChainID,
+		Nonce:              3,
+		Gas:                100_000,
+		GasTipCap:          big.NewInt(1),
+		GasFeeCap:          big.NewInt(2),
+		Sender:             &sender,
+		ValidationGasLimit: 200_000,
+	})
+	blob, err := json.Marshal(newRPCTransaction(tx, common.Hash{0x01}, 1, 0, 0, big.NewInt(1), &config, nil))
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		t.Fatalf("failed to decode transaction fields: %v", err)
+	}
+	if fields["from"] != strings.ToLower(sender.Hex()) {
+		t.Errorf("from mismatch: have %v, want %v", fields["from"], sender)
+	}
+	// Empty fields are returned as well, only the absent paymaster and deployer are left out
+	for _, field := range []string{
+		"sender", "nonceKey", "authorizationData", "executionData", "paymasterData", "deployerData", "builderFee",
+		"verificationGasLimit", "paymasterVerificationGasLimit", "paymasterPostOpGasLimit", "accessList",
+	} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("missing field %q", field)
+		}
+	}
+	dec := new(types.Transaction)
+	if err := json.Unmarshal(blob, dec); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+}
+
+func TestRip7560SetDefaults(t *testing.T) {
+	var (
+		config    = *params.TestChainConfig
+		sender    = common.Address{0x01}
+		paymaster = common.Address{0x02}
+		fee       = (*hexutil.Big)(big.NewInt(params.GWei))
+		gas       = hexutil.Uint64(100_000)
+	)
+	config.RIP7560Block = big.NewInt(0)
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether), Nonce: 3}},
+	}
+	b := newTestBackend(t, 0, genesis, ethash.NewFaker(), nil)
+
+	args := TransactionArgs{Sender: &sender, MaxFeePerGas: fee, MaxPriorityFeePerGas: fee}
+	if err := args.setDefaults(context.Background(), b, true); err != nil {
+		t.Fatalf("failed to set defaults: %v", err)
+	}
+	if args.Nonce == nil || *args.Nonce != 3 {
+		t.Errorf("nonce mismatch: have %v, want 3", args.Nonce)
+	}
+	if args.ChainID.ToInt().Cmp(config.ChainID) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %v", args.ChainID, config.ChainID)
+	}
+	if args.Gas == nil || args.ValidationGas == nil || *args.PaymasterGas != 0 || *args.PostOpGas != 0 {
+		t.Errorf("gas limits not defaulted: gas %v, validation %v, paymaster %v, postOp %v", args.Gas, args.ValidationGas, args.PaymasterGas, args.PostOpGas)
+	}
+	aatx := args.ToTransaction().Rip7560TransactionData()
+	if aatx.Paymaster != nil || aatx.Deployer != nil || aatx.NonceKey.Sign() != 0 || aatx.BuilderFee.Sign() != 0 {
+		t.Errorf("optional fields not defaulted: paymaster %v, deployer %v, nonce key %v, builder fee %v", aatx.Paymaster, aatx.Deployer, aatx.NonceKey, aatx.BuilderFee)
+	}
+
+	for _, tt := range []struct {
+		name string
+		args TransactionArgs
+	}{
+		{"to", TransactionArgs{Sender: &sender, To: &paymaster}},
+		{"value", TransactionArgs{Sender: &sender, Value: fee}},
+		{"input", TransactionArgs{Sender: &sender, Input: &hexutil.Bytes{0x01}}},
+		{"gas price", TransactionArgs{Sender: &sender, GasPrice: fee}},
+		{"paymaster data without paymaster", TransactionArgs{Sender: &sender, PaymasterData: &hexutil.Bytes{0x01}}},
+		{"paymaster gas without paymaster", TransactionArgs{Sender: &sender, PaymasterGas: &gas}},
+		{"deployer data without deployer", TransactionArgs{Sender: &sender, DeployerData: &hexutil.Bytes{0x01}}},
+		{"paymaster without gas limits", TransactionArgs{Sender: &sender, Paymaster: &paymaster, MaxFeePerGas: fee, MaxPriorityFeePerGas: fee}},
+		{"fields without sender", TransactionArgs{NonceKey: new(hexutil.Big)}},
+	} {
+		if err := tt.args.setDefaults(context.Background(), b, true); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestRip7560GetSigningHash(t *testing.T) {
+	config := *params.TestChainConfig
+	config.RIP7560Block = big.NewInt(0)
+	config.RIP7560SigningDomainBlock = big.NewInt(1)
+	api := NewRip7560API(newTestBackend(t, 0, &core.Genesis{Config: &config, Alloc: types.GenesisAlloc{}}, ethash.NewFaker(), nil))
+
+	sender := common.Address{0x01}
+	args := TransactionArgs{
+		ChainID:           (*hexutil.Big)(config.ChainID),
+		Sender:            &sender,
+		Nonce:             new(hexutil.Uint64),
+		ExecutionData:     &hexutil.Bytes{0x01},
+		AuthorizationData: new(hexutil.Bytes),
+	}
+	// The signing domain is active from the block following the latest one
+	hash, err := api.GetSigningHash(context.Background(), args, nil)
+	if err != nil {
+		t.Fatalf("failed to get signing hash: %v", err)
+	}
+	if want := types.NewRIP7560DomainSigner(config.ChainID, core.Rip7560SigningDomain(core.Rip7560AbiVersion)).Hash(args.ToTransaction()); hash != want {
+		t.Errorf("signing hash mismatch: have %x, want %x", hash, want)
+	}
+	if undomained := types.NewRIP7560Signer(config.ChainID).Hash(args.ToTransaction()); hash == undomained {
+		t.Errorf("signing hash not bound to the signing domain")
+	}
+	args.AuthorizationData = nil
+	if _, err := api.GetSigningHash(context.Background(), args, nil); !errors.Is(err, types.ErrInvalidRip7560Tx) {
+		t.Errorf("error mismatch: have %v, want %v", err, types.ErrInvalidRip7560Tx)
+	}
+}
+
+func TestRip7560ReceiptContractAddress(t *testing.T) {
+	sender := common.Address{0x01}
+	deployer := common.Address{0x02}
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+
+	// The deployed sender is reported as the contract address only
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(1), ContractAddress: sender}
+	deployment := types.NewTx(&types.Rip7560AccountAbstractionTx{Sender: &sender, Deployer: &deployer})
+	fields := marshalReceipt(receipt, common.Hash{}, 1, types.NewRIP7560Signer(config.ChainID), deployment, 0, config)
+	if have, ok := fields["contractAddress"].(common.Address); !ok || have != sender {
+		t.Errorf("contract address mismatch: have %v, want %v", fields["contractAddress"], sender)
+	}
+	if have, ok := fields["deployedAccount"]; ok {
+		t.Errorf("deployed account reported besides the contract address: %v", have)
+	}
+
+	receipt = &types.Receipt{Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(1)}
+	existing := types.NewTx(&types.Rip7560AccountAbstractionTx{Sender: &sender})
+	fields = marshalReceipt(receipt, common.Hash{}, 1, types.NewRIP7560Signer(config.ChainID), existing, 0, config)
+	if have := fields["contractAddress"]; have != nil {
+		t.Errorf("unexpected contract address: %v", have)
+	}
+}
+
+func TestRip7560ReceiptFields(t *testing.T) {
+	var (
+		sender    = common.Address{0x01}
+		paymaster = common.Address{0x02}
+		config    = &params.ChainConfig{ChainID: big.NewInt(1)}
+		event     = core.Rip7560Abi.Events["RIP7560TransactionEvent"]
+	)
+	data, err := event.Inputs.NonIndexed().Pack(new(big.Int), new(big.Int), new(big.Int).SetUint64(core.ExecutionStatusPostOpFailure))
+	if err != nil {
+		t.Fatalf("failed to pack event: %v", err)
+	}
+	receipt := &types.Receipt{
+		Status:            types.ReceiptStatusFailed,
+		EffectiveGasPrice: big.NewInt(1),
+		Logs:              []*types.Log{{Address: core.AA_ENTRY_POINT, Topics: []common.Hash{event.ID, {}, {}}, Data: data}},
+		Rip7560Validity:   &types.Rip7560Validity{AccountValidUntil: 10},
+		Rip7560FrameGasUsed: &types.Rip7560FrameGasUsed{
+			Validation:          1,
+			PaymasterValidation: 2,
+			Deployment:          3,
+			Execution:           4,
+			PostOp:              5,
+		},
+	}
+	tx := types.NewTx(&types.Rip7560AccountAbstractionTx{Sender: &sender, Paymaster: &paymaster})
+	fields := marshalReceipt(receipt, common.Hash{}, 1, types.NewRIP7560Signer(config.ChainID), tx, 0, config)
+
+	for _, field := range []string{"from", "sender"} {
+		if have, ok := fields[field].(*common.Address); !ok || *have != sender {
+			t.Errorf("%s mismatch: have %v, want %v", field, fields[field], sender)
+		}
+	}
+	if have, ok := fields["paymaster"].(*common.Address); !ok || *have != paymaster {
+		t.Errorf("paymaster mismatch: have %v, want %v", fields["paymaster"], paymaster)
+	}
+	if have, ok := fields["deployer"].(*common.Address); !ok || have != nil {
+		t.Errorf("deployer mismatch: have %v, want nil", fields["deployer"])
+	}
+	if have := fields["executionStatus"]; have != hexutil.Uint64(core.ExecutionStatusPostOpFailure) {
+		t.Errorf("execution status mismatch: have %v, want %d", have, core.ExecutionStatusPostOpFailure)
+	}
+	if have := fields["validity"]; have != receipt.Rip7560Validity {
+		t.Errorf("validity mismatch: have %v, want %v", have, receipt.Rip7560Validity)
+	}
+	for i, field := range []string{"validationGasUsed", "paymasterValidationGasUsed", "deploymentGasUsed", "executionGasUsed", "postOpGasUsed"} {
+		if have := fields[field]; have != hexutil.Uint64(i+1) {
+			t.Errorf("%s mismatch: have %v, want %d", field, have, i+1)
+		}
+	}
+	// The execution status recorded in the receipt takes precedence over the logs
+	status := core.ExecutionStatusExecutionAndPostOpFailure
+	receipt.Rip7560ExecutionStatus = &status
+	fields = marshalReceipt(receipt, common.Hash{}, 1, types.NewRIP7560Signer(config.ChainID), tx, 0, config)
+	if have := fields["executionStatus"]; have != hexutil.Uint64(status) {
+		t.Errorf("execution status mismatch: have %v, want %d", have, status)
+	}
+}
+
+func TestRip7560GetInclusionStats(t *testing.T) {
+	b := newBackendMock()
+	api := NewRip7560API(b)
+	if have := api.GetInclusionStats(); len(have.Bundlers) != 0 || len(have.Paymasters) != 0 {
+		t.Errorf("expected empty stats without a pool, have %v", have)
+	}
+
+	var latency types.Rip7560InclusionLatency
+	latency.Add(2 * time.Second)
+	latency.Add(4 * time.Second)
+	paymaster := common.Address{0x01}
+	b.inclusionStats = &types.Rip7560InclusionStats{
+		Bundlers:   map[string]*types.Rip7560InclusionLatency{"bundler": &latency},
+		Paymasters: map[common.Address]*types.Rip7560InclusionLatency{paymaster: &latency},
+	}
+	want := &Rip7560InclusionLatency{Count: 2, Average: 3000, Min: 2000, Max: 4000}
+	have := api.GetInclusionStats()
+	if got := have.Bundlers["bundler"]; got == nil || *got != *want {
+		t.Errorf("bundler latency mismatch: have %+v, want %+v", got, want)
+	}
+	if got := have.Paymasters[paymaster]; got == nil || *got != *want {
+		t.Errorf("paymaster latency mismatch: have %+v, want %+v", got, want)
+	}
+}
+
+func TestRip7560GetPaymasterStats(t *testing.T) {
+	b := newBackendMock()
+	api := NewRip7560API(b)
+
+	paymaster := common.Address{0x01}
+	b.paymasterStats = map[common.Address][]*types.Rip7560PaymasterStats{
+		paymaster: {
+			{FromBlock: 0, ToBlock: 99, Transactions: 4, GasUsed: 400_000, GasPaid: big.NewInt(4000), PostOpFailures: 1},
+			{FromBlock: 200, ToBlock: 299, Transactions: 1, GasUsed: 100_000, GasPaid: big.NewInt(1000)},
+		},
+	}
+	have, err := api.GetPaymasterStats(context.Background(), paymaster, 50, 150)
+	if err != nil {
+		t.Fatalf("failed to get paymaster stats: %v", err)
+	}
+	want := &Rip7560PaymasterStats{
+		FromBlock:         0,
+		ToBlock:           99,
+		Transactions:      4,
+		GasUsed:           400_000,
+		GasPaid:           (*hexutil.Big)(big.NewInt(4000)),
+		PostOpFailures:    1,
+		PostOpFailureRate: 0.25,
+	}
+	if len(have) != 1 || !reflect.DeepEqual(have[0], want) {
+		t.Errorf("paymaster stats mismatch: have %+v, want [%+v]", have, want)
+	}
+	if have, err := api.GetPaymasterStats(context.Background(), common.Address{0x02}, 0, 299); err != nil || len(have) != 0 {
+		t.Errorf("expected no stats for an unknown paymaster, have %v, err %v", have, err)
+	}
+	if _, err := api.GetPaymasterStats(context.Background(), paymaster, 150, 50); err == nil {
+		t.Error("expected an error for an inverted block range")
+	}
+}
+
+func TestRip7560SendRawBundle(t *testing.T) {
+	b := newBackendMock()
+	api := NewRip7560ForwardAPI(b)
+
+	sender := common.Address{0x01}
+	txs := []*types.Transaction{types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:            big.NewInt(42),
+		Sender:             &sender,
+		NonceKey:           big.NewInt(0),
+		Nonce:              1,
+		Gas:                1000,
+		ValidationGasLimit: 2000,
+		GasTipCap:          big.NewInt(1),
+		GasFeeCap:          big.NewInt(1),
+	})}
+	bundle := &types.ExternallyReceivedBundle{
+		BundlerId:     "sentry",
+		BundleHash:    CalculateBundleHash(txs),
+		ValidForBlock: big.NewInt(1),
+		Transactions:  txs,
+	}
+	data, err := rlp.EncodeToBytes(bundle)
+	if err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	hash, err := api.SendRawRip7560Bundle(context.Background(), data)
+	if err != nil {
+		t.Fatalf("failed to submit forwarded bundle: %v", err)
+	}
+	if hash != bundle.BundleHash {
+		t.Errorf("bundle hash mismatch: have %x, want %x", hash, bundle.BundleHash)
+	}
+	if len(b.bundles) != 1 || b.bundles[0].BundlerId != "sentry" || b.bundles[0].Transactions[0].Hash() != txs[0].Hash() {
+		t.Fatalf("forwarded bundle not submitted: %v", b.bundles)
+	}
+
+	bundle.BundleHash = common.Hash{0x01}
+	if data, err = rlp.EncodeToBytes(bundle); err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	if _, err := api.SendRawRip7560Bundle(context.Background(), data); err == nil {
+		t.Error("expected bundle with mismatching hash to be rejected")
+	}
+	if _, err := api.SendRawRip7560Bundle(context.Background(), []byte{0x01, 0x02}); err == nil {
+		t.Error("expected malformed bundle to be rejected")
+	}
+	if len(b.bundles) != 1 {
+		t.Errorf("rejected bundles were submitted: have %d, want 1", len(b.bundles))
+	}
+}
+
+// rip7560BundleRecorder is a test backend recording the bundles submitted to it. The
+// pool checks of dry runs fail with poolErr.
+type rip7560BundleRecorder struct {
+	*testBackend
+	bundles []*types.ExternallyReceivedBundle
+	poolErr error
+}
+
+func (b *rip7560BundleRecorder) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	b.bundles = append(b.bundles, bundle)
+	return nil
+}
+
+func (b *rip7560BundleRecorder) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	return b.poolErr
+}
+
+func TestRip7560SendRawTransaction(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	var (
+		config  = *params.TestChainConfig
+		valid   = common.Address{0x01}
+		invalid = common.Address{0x02}
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			valid:   {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptAccount)},
+			invalid: {Balance: big.NewInt(params.Ether), Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+		},
+	}
+	b := &rip7560BundleRecorder{testBackend: newTestBackend(t, 0, genesis, ethash.NewFaker(), nil)}
+	api := NewTransactionAPI(b, nil)
+
+	newTx := func(sender common.Address, modify func(aatx *types.Rip7560AccountAbstractionTx)) hexutil.Bytes {
+		aatx := &types.Rip7560AccountAbstractionTx{
+			ChainID:            config.ChainID,
+			Sender:             &sender,
+			NonceKey:           big.NewInt(0),
+			Gas:                100_000,
+			ValidationGasLimit: 100_000,
+			GasTipCap:          big.NewInt(1),
+			GasFeeCap:          big.NewInt(params.GWei * 2),
+			BuilderFee:         new(big.Int),
+		}
+		if modify != nil {
+			modify(aatx)
+		}
+		data, err := types.NewTx(aatx).MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		return data
+	}
+	legacy, err := types.NewTx(&types.DynamicFeeTx{ChainID: config.ChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	for i, input := range []hexutil.Bytes{
+		{0x05, 0x01},
+		legacy,
+		newTx(valid, func(aatx *types.Rip7560AccountAbstractionTx) { aatx.GasTipCap = big.NewInt(params.GWei * 3) }),
+		newTx(valid, func(aatx *types.Rip7560AccountAbstractionTx) { aatx.ChainID = big.NewInt(7560) }),
+		newTx(invalid, nil),
+	} {
+		if _, err := api.SendRawRip7560Transaction(context.Background(), input, nil); err == nil {
+			t.Errorf("test %d: expected transaction to be rejected", i)
+		}
+	}
+	if len(b.bundles) != 0 {
+		t.Fatalf("rejected transactions were submitted: %d bundles", len(b.bundles))
+	}
+
+	input := newTx(valid, nil)
+	hash, err := api.SendRawRip7560Transaction(context.Background(), input, nil)
+	if err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if len(b.bundles) != 1 {
+		t.Fatalf("bundle count mismatch: have %d, want 1", len(b.bundles))
+	}
+	bundle := b.bundles[0]
+	if len(bundle.Transactions) != 1 || bundle.Transactions[0].Hash() != hash {
+		t.Fatalf("submitted bundle does not wrap transaction %x", hash)
+	}
+	if bundle.BundleHash != CalculateBundleHash(bundle.Transactions) {
+		t.Errorf("bundle hash mismatch: have %x, want %x", bundle.BundleHash, CalculateBundleHash(bundle.Transactions))
+	}
+	if bundle.ValidForBlock.Uint64() != 1 || bundle.LastValidBlock().Uint64() != rawRip7560TxValidityBlocks {
+		t.Errorf("bundle window mismatch: have [%v, %v], want [1, %d]", bundle.ValidForBlock, bundle.LastValidBlock(), rawRip7560TxValidityBlocks)
+	}
+}
+
+func TestRip7560SendBundleErrors(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	var (
+		config   = *params.TestChainConfig
+		valid    = common.Address{0x01}
+		invalid  = common.Address{0x02}
+		simulate = true
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			valid:   {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptAccount)},
+			invalid: {Balance: big.NewInt(params.Ether), Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+		},
+	}
+	b := &rip7560BundleRecorder{testBackend: newTestBackend(t, 0, genesis, ethash.NewFaker(), nil)}
+	api := NewTransactionAPI(b, nil)
+
+	newArgs := func(sender common.Address, modify func(args *TransactionArgs)) TransactionArgs {
+		nonce := hexutil.Uint64(0)
+		gas := hexutil.Uint64(100_000)
+		args := TransactionArgs{
+			ChainID:              (*hexutil.Big)(config.ChainID),
+			Sender:               &sender,
+			NonceKey:             new(hexutil.Big),
+			Nonce:                &nonce,
+			Gas:                  &gas,
+			ValidationGas:        &gas,
+			MaxFeePerGas:         (*hexutil.Big)(big.NewInt(params.GWei * 2)),
+			MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(1)),
+			BuilderFee:           new(hexutil.Big),
+			ExecutionData:        new(hexutil.Bytes),
+			AuthorizationData:    new(hexutil.Bytes),
+		}
+		if modify != nil {
+			modify(&args)
+		}
+		return args
+	}
+	tests := []struct {
+		args     []TransactionArgs
+		simulate bool
+		invalid  []hexutil.Uint64 // indices of the transactions reported invalid
+	}{
+		{
+			args: []TransactionArgs{
+				newArgs(valid, nil),
+				newArgs(valid, func(args *TransactionArgs) { args.Nonce = nil }),
+				newArgs(valid, func(args *TransactionArgs) { args.Sender = nil }),
+			},
+			invalid: []hexutil.Uint64{1, 2},
+		},
+		{
+			args: []TransactionArgs{
+				newArgs(valid, func(args *TransactionArgs) { args.ChainID = (*hexutil.Big)(big.NewInt(7560)) }),
+				newArgs(valid, nil),
+			},
+			invalid: []hexutil.Uint64{0},
+		},
+		{
+			args:     []TransactionArgs{newArgs(invalid, nil), newArgs(valid, nil)},
+			simulate: true,
+			invalid:  []hexutil.Uint64{0},
+		},
+		{
+			args: []TransactionArgs{newArgs(invalid, nil), newArgs(valid, nil)},
+		},
+		{
+			args:     []TransactionArgs{newArgs(valid, nil)},
+			simulate: true,
+		},
+	}
+	for i, tt := range tests {
+		b.bundles = nil
+
+		var simulateArg *bool
+		if tt.simulate {
+			simulateArg = &simulate
+		}
+		_, err := api.SendRip7560TransactionsBundle(context.Background(), tt.args, big.NewInt(1), "bundler", nil, nil, simulateArg)
+		if len(tt.invalid) == 0 {
+			if err != nil {
+				t.Errorf("test %d: failed to submit bundle: %v", i, err)
+			}
+			if len(b.bundles) != 1 {
+				t.Errorf("test %d: bundle count mismatch: have %d, want 1", i, len(b.bundles))
+			}
+			continue
+		}
+		var bundleErr *Rip7560BundleError
+		if !errors.As(err, &bundleErr) {
+			t.Errorf("test %d: error mismatch: have %v, want %T", i, err, bundleErr)
+			continue
+		}
+		if bundleErr.ErrorCode() != -32602 {
+			t.Errorf("test %d: error code mismatch: have %d, want %d", i, bundleErr.ErrorCode(), -32602)
+		}
+		var indices []hexutil.Uint64
+		for _, txErr := range bundleErr.ErrorData().([]*Rip7560TransactionError) {
+			indices = append(indices, txErr.Index)
+			if txErr.Message == "" || (tt.simulate && txErr.ValidationError == nil) {
+				t.Errorf("test %d: incomplete error of transaction %d: %+v", i, txErr.Index, txErr)
+			}
+		}
+		if !reflect.DeepEqual(indices, tt.invalid) {
+			t.Errorf("test %d: invalid transactions mismatch: have %v, want %v", i, indices, tt.invalid)
+		}
+		if len(b.bundles) != 0 {
+			t.Errorf("test %d: invalid bundle submitted", i)
+		}
+	}
+}
+
+func TestRip7560SubmissionDryRun(t *testing.T) {
+	acceptAccount, err := core.Rip7560Abi.Pack("acceptAccount", big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack acceptAccount: %v", err)
+	}
+	var (
+		config  = *params.TestChainConfig
+		valid   = common.Address{0x01}
+		invalid = common.Address{0x02}
+		dryRun  = true
+	)
+	config.RIP7560Block = big.NewInt(0)
+	config.Optimism = &params.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50}
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			valid:   {Balance: big.NewInt(params.Ether), Code: entryPointCallbackCode(acceptAccount)},
+			invalid: {Balance: big.NewInt(params.Ether), Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+		},
+	}
+	b := &rip7560BundleRecorder{testBackend: newTestBackend(t, 0, genesis, ethash.NewFaker(), nil)}
+	api := NewTransactionAPI(b, nil)
+
+	newTx := func(sender common.Address, chainID *big.Int) hexutil.Bytes {
+		data, err := types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            chainID,
+			Sender:             &sender,
+			NonceKey:           big.NewInt(0),
+			Gas:                100_000,
+			ValidationGasLimit: 100_000,
+			GasTipCap:          big.NewInt(1),
+			GasFeeCap:          big.NewInt(params.GWei * 2),
+			BuilderFee:         new(big.Int),
+		}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		return data
+	}
+	diagnose := func(input hexutil.Bytes) *Rip7560SubmissionDiagnostics {
+		res, err := api.SendRawRip7560Transaction(context.Background(), input, &dryRun)
+		if err != nil {
+			t.Fatalf("dry run failed: %v", err)
+		}
+		return res.(*Rip7560SubmissionDiagnostics)
+	}
+
+	// An admissible transaction is accepted and simulated.
+	d := diagnose(newTx(valid, config.ChainID))
+	if !d.Accepted || len(d.Errors) != 0 {
+		t.Errorf("valid transaction not accepted: %v", d.Errors)
+	}
+	if d.Simulation == nil || len(d.Simulation.Transactions) != 1 || d.Simulation.Transactions[0].ValidationError != nil {
+		t.Errorf("valid transaction not simulated: %+v", d.Simulation)
+	}
+	// A transaction failing its validation phase is simulated, but rejected.
+	d = diagnose(newTx(invalid, config.ChainID))
+	if d.Accepted || d.Simulation == nil || d.Simulation.Transactions[0].ValidationError == nil {
+		t.Errorf("failing validation not reported: %+v", d)
+	}
+	// A malformed transaction is rejected without simulation.
+	d = diagnose(newTx(valid, big.NewInt(7560)))
+	if d.Accepted || len(d.Errors) != 1 || d.Simulation != nil {
+		t.Errorf("malformed transaction not reported: %+v", d)
+	}
+	// The pool policy is reported alongside the simulation.
+	b.poolErr = errors.New("banned entity")
+	d = diagnose(newTx(valid, config.ChainID))
+	if d.Accepted || len(d.Errors) != 1 || d.Simulation == nil {
+		t.Errorf("pool rejection not reported: %+v", d)
+	}
+	if len(b.bundles) != 0 {
+		t.Fatalf("dry runs were submitted: %d bundles", len(b.bundles))
+	}
+}
@@ -90,6 +90,9 @@ type TransactionArgs struct {
 
 	// Introduced by RIP-7712 Transaction
 	NonceKey *hexutil.Big `json:"nonceKey,omitempty"`
+
+	// EIP-7702 authorizations of RIP-7560 transactions
+	AuthorizationList []types.SetCodeAuthorization `json:"authorizationList,omitempty"`
 }
 
 // from retrieves the transaction sender address.
@@ -113,15 +116,18 @@ func (args *TransactionArgs) data() []byte {
 
 // setDefaults fills in default values for unspecified tx fields.
 func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend, skipGasEstimation bool) error {
+	if args.Sender != nil {
+		return args.setRip7560Defaults(ctx, b, skipGasEstimation)
+	}
+	if args.isRip7560() {
+		return errors.New(`RIP-7560 transaction fields specified without "sender"`)
+	}
 	if err := args.setBlobTxSidecar(ctx); err != nil {
 		return err
 	}
 	if err := args.setFeeDefaults(ctx, b); err != nil {
 		return err
 	}
-	if err := args.set7560Defaults(ctx, b); err != nil {
-		return err
-	}
 
 	if args.Value == nil {
 		args.Value = new(hexutil.Big)
@@ -188,9 +194,12 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend, skipGas
 		}
 	}
 
-	// If chain id is provided, ensure it matches the local chain id. Otherwise, set the local
-	// chain id as the default.
-	want := b.ChainConfig().ChainID
+	return args.setChainID(b.ChainConfig().ChainID)
+}
+
+// setChainID ensures the chain id, if provided, matches the local chain id. Otherwise, it
+// sets the local chain id as the default.
+func (args *TransactionArgs) setChainID(want *big.Int) error {
 	if args.ChainID != nil {
 		if have := (*big.Int)(args.ChainID); have.Cmp(want) != 0 {
 			return fmt.Errorf("chainId does not match node's (have=%v, want=%v)", have, want)
@@ -201,23 +210,136 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend, skipGas
 	return nil
 }
 
-func (args *TransactionArgs) set7560Defaults(ctx context.Context, b Backend) error {
-	// Not 7560 tx
-	if args.Sender == nil {
-		return nil
+// isRip7560 reports whether any of the fields only RIP-7560 transactions have is set.
+func (args *TransactionArgs) isRip7560() bool {
+	return args.Sender != nil || args.AuthorizationData != nil || args.ExecutionData != nil ||
+		args.Paymaster != nil || args.PaymasterData != nil || args.Deployer != nil || args.DeployerData != nil ||
+		args.BuilderFee != nil || args.ValidationGas != nil || args.PaymasterGas != nil || args.PostOpGas != nil ||
+		args.NonceKey != nil
+}
+
+// hasPaymaster reports whether the RIP-7560 transaction is sponsored by a paymaster.
+func (args *TransactionArgs) hasPaymaster() bool {
+	return args.Paymaster != nil && *args.Paymaster != (common.Address{})
+}
+
+// checkRip7560Fields rejects the fields RIP-7560 transactions do not have, and the
+// paymaster and deployer fields specified without the paymaster or deployer.
+func (args *TransactionArgs) checkRip7560Fields() error {
+	switch {
+	case args.To != nil:
+		return errors.New(`"to" not allowed in RIP-7560 transaction, use "sender"`)
+	case args.Value != nil && args.Value.ToInt().Sign() != 0:
+		return errors.New(`"value" not allowed in RIP-7560 transaction`)
+	case args.Data != nil || args.Input != nil:
+		return errors.New(`"input" not allowed in RIP-7560 transaction, use "executionData"`)
+	case args.GasPrice != nil:
+		return errors.New(`"gasPrice" not allowed in RIP-7560 transaction, use maxFeePerGas/maxPriorityFeePerGas`)
+	case args.BlobHashes != nil || args.Blobs != nil:
+		return errors.New(`blobs not allowed in RIP-7560 transaction`)
+	}
+	if !args.hasPaymaster() {
+		if args.PaymasterData != nil && len(*args.PaymasterData) > 0 {
+			return errors.New(`"paymasterData" specified without "paymaster"`)
+		}
+		if toUint64(args.PaymasterGas) != 0 || toUint64(args.PostOpGas) != 0 {
+			return errors.New(`paymaster gas limits specified without "paymaster"`)
+		}
+	}
+	if (args.Deployer == nil || *args.Deployer == (common.Address{})) && args.DeployerData != nil && len(*args.DeployerData) > 0 {
+		return errors.New(`"deployerData" specified without "deployer"`)
+	}
+	return nil
+}
+
+// setRip7560FieldDefaults fills in the zero values of the unspecified RIP-7560 fields
+// not depending on the chain.
+func (args *TransactionArgs) setRip7560FieldDefaults() {
+	if args.AuthorizationData == nil {
+		args.AuthorizationData = new(hexutil.Bytes)
+	}
+	if args.ExecutionData == nil {
+		args.ExecutionData = new(hexutil.Bytes)
+	}
+	if args.BuilderFee == nil {
+		args.BuilderFee = new(hexutil.Big)
+	}
+	if args.NonceKey == nil {
+		args.NonceKey = new(hexutil.Big)
 	}
 	if args.Paymaster == nil {
-		log.Error("set7560Defaults setting default paymaster fields")
 		args.Paymaster = &common.Address{}
-		args.PaymasterData = &hexutil.Bytes{}
+	}
+	if args.PaymasterData == nil {
+		args.PaymasterData = new(hexutil.Bytes)
+	}
+	if !args.hasPaymaster() {
+		args.PaymasterGas = new(hexutil.Uint64)
+		args.PostOpGas = new(hexutil.Uint64)
 	}
 	if args.Deployer == nil {
-		log.Error("set7560Defaults setting default deployer fields")
 		args.Deployer = &common.Address{}
-		args.DeployerData = &hexutil.Bytes{}
 	}
-	return nil
+	if args.DeployerData == nil {
+		args.DeployerData = new(hexutil.Bytes)
+	}
+}
+
+// setRip7560Defaults fills in default values for unspecified RIP-7560 tx fields. The nonce
+// defaults to the next one of the sender for the nonce key at the latest block, and the
+// validation and execution gas limits to their estimates, unless skipGasEstimation is set.
+// The paymaster gas limits are not estimated and have to be specified with a paymaster.
+func (args *TransactionArgs) setRip7560Defaults(ctx context.Context, b Backend, skipGasEstimation bool) error {
+	if err := args.checkRip7560Fields(); err != nil {
+		return err
+	}
+	if head := b.CurrentHeader(); !b.ChainConfig().IsRIP7560(head.Number, head.Time) {
+		return fmt.Errorf("RIP-7560 transactions not activated at block %v", head.Number)
+	}
+	if err := args.setFeeDefaults(ctx, b); err != nil {
+		return err
+	}
+	if args.hasPaymaster() && (args.PaymasterGas == nil || args.PostOpGas == nil) {
+		return errors.New(`missing "paymasterVerificationGasLimit" or "paymasterPostOpGasLimit" in transaction with paymaster`)
+	}
+	args.setRip7560FieldDefaults()
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if args.Nonce == nil {
+		nonce, err := rip7560Nonce(ctx, b, *args.Sender, args.NonceKey.ToInt(), latest)
+		if err != nil {
+			return err
+		}
+		args.Nonce = &nonce
+	}
+	if args.ValidationGas == nil || args.Gas == nil {
+		if skipGasEstimation { // Skip gas usage estimation if a precise gas limit is not critical, e.g., in non-transaction calls.
+			gas := hexutil.Uint64(b.RPCGasCap())
+			if gas == 0 {
+				gas = hexutil.Uint64(math.MaxUint64 / 4) // Leave room for the validation and execution limits to add up
 
+			}
+			if args.ValidationGas == nil {
+				args.ValidationGas = &gas
+			}
+			if args.Gas == nil {
+				args.Gas = &gas
+			}
+		} else { // Estimate the gas usage otherwise.
+			estimated, err := DoEstimateRip7560TransactionGas(ctx, b, *args, latest, nil, b.RPCGasCap())
+			if err != nil {
+				return err
+			}
+			if args.ValidationGas == nil {
+				args.ValidationGas = &estimated.ValidationGas
+			}
+			if args.Gas == nil {
+				args.Gas = &estimated.ExecutionGas
+			}
+			log.Trace("Estimate RIP-7560 gas usage automatically", "validation", args.ValidationGas, "execution", args.Gas)
+		}
+	}
+	return args.setChainID(b.ChainConfig().ChainID)
 }
 
 // setFeeDefaults fills in default fee values for unspecified tx fields.
@@ -455,26 +577,16 @@ func (args *TransactionArgs) CallDefaults(globalGasCap uint64, baseFee *big.Int,
 	return nil
 }
 
+// Call7560Defaults sanitizes the RIP-7560 transaction arguments, often filling in zero
+// values, for the purpose of the RIP-7560 call, validation and estimation RPC methods.
 func (args *TransactionArgs) Call7560Defaults(globalGasCap uint64, baseFee *big.Int, chainID *big.Int) error {
 	if args.Sender == nil {
-		return errors.New(`missing "Sender" in transaction`)
+		return errors.New(`missing "sender" in transaction`)
 	}
-	if args.BuilderFee == nil {
-		args.BuilderFee = new(hexutil.Big)
-	}
-	if args.Paymaster == nil {
-		args.Paymaster = &common.Address{}
-		args.PaymasterData = &hexutil.Bytes{}
-		args.PaymasterGas = new(hexutil.Uint64)
-		args.PostOpGas = new(hexutil.Uint64)
-	}
-	if args.Deployer == nil {
-		args.Deployer = &common.Address{}
-		args.DeployerData = &hexutil.Bytes{}
-	}
-	if args.NonceKey == nil {
-		args.NonceKey = new(hexutil.Big)
+	if err := args.checkRip7560Fields(); err != nil {
+		return err
 	}
+	args.setRip7560FieldDefaults()
 	if args.ValidationGas == nil || *args.ValidationGas == hexutil.Uint64(0) {
 		gas := globalGasCap
 		if gas == 0 {
@@ -576,11 +688,12 @@ func (args *TransactionArgs) ToTransaction() *types.Transaction {
 			GasFeeCap: (*big.Int)(args.MaxFeePerGas),
 			GasTipCap: (*big.Int)(args.MaxPriorityFeePerGas),
 			//Value:         (*big.Int)(args.Value),
-			ExecutionData: *args.ExecutionData,
+			ExecutionData: toByte(args.ExecutionData),
 			AccessList:    al,
 			// RIP-7560 parameters
 			Sender:                      args.Sender,
-			AuthorizationData:           *args.AuthorizationData,
+			AuthorizationData:           toByte(args.AuthorizationData),
+			AuthorizationList:           args.AuthorizationList,
 			Paymaster:                   args.Paymaster,
 			PaymasterData:               toByte(args.PaymasterData),
 			Deployer:                    args.Deployer,
@@ -600,8 +713,6 @@ func (args *TransactionArgs) ToTransaction() *types.Transaction {
 		}
 
 		data = &aatx
-		hash := types.NewTx(data).Hash()
-		log.Error("RIP-7560 transaction created", "sender", aatx.Sender.Hex(), "hash", hash)
 	case args.BlobHashes != nil:
 		al := types.AccessList{}
 		if args.AccessList != nil {
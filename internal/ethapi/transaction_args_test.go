@@ -257,6 +257,10 @@ func TestSetFeeDefaults(t *testing.T) {
 type backendMock struct {
 	current *types.Header
 	config  *params.ChainConfig
+
+	inclusionStats *types.Rip7560InclusionStats
+	paymasterStats map[common.Address][]*types.Rip7560PaymasterStats
+	bundles        []*types.ExternallyReceivedBundle
 }
 
 func newBackendMock() *backendMock {
@@ -405,3 +409,42 @@ func (b *backendMock) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent)
 func (b *backendMock) Engine() consensus.Engine          { return nil }
 func (b *backendMock) HistoricalRPCService() *rpc.Client { return nil }
 func (b *backendMock) Genesis() *types.Block             { return nil }
+
+func (b *backendMock) SubmitRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	b.bundles = append(b.bundles, bundle)
+	return nil
+}
+func (b *backendMock) CheckRip7560Bundle(bundle *types.ExternallyReceivedBundle) error {
+	return nil
+}
+func (b *backendMock) ExtendRip7560Bundle(ctx context.Context, hash common.Hash, validUntil *big.Int) error {
+	return nil
+}
+func (b *backendMock) CancelRip7560Bundle(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	return nil, nil
+}
+func (b *backendMock) GetRip7560BundleStatus(ctx context.Context, hash common.Hash) (*types.BundleReceipt, error) {
+	return nil, nil
+}
+func (b *backendMock) SubscribeRip7560TxStatusEvent(ch chan<- core.Rip7560TxStatusEvent) event.Subscription {
+	return nil
+}
+func (b *backendMock) Rip7560FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, [][]*big.Int, error) {
+	return nil, nil, nil, nil
+}
+func (b *backendMock) Rip7560InclusionStats() *types.Rip7560InclusionStats {
+	return b.inclusionStats
+}
+func (b *backendMock) Rip7560PaymasterStats(ctx context.Context, paymaster common.Address, fromBlock, toBlock uint64) ([]*types.Rip7560PaymasterStats, error) {
+	var result []*types.Rip7560PaymasterStats
+	for _, stats := range b.paymasterStats[paymaster] {
+		if stats.ToBlock >= fromBlock && stats.FromBlock <= toBlock {
+			result = append(result, stats)
+		}
+	}
+	return result, nil
+}
+func (b *backendMock) GetRip7560TransactionDebugInfo(hash common.Hash) (map[string]interface{}, error) {
+	return nil, nil
+}
+func (b *backendMock) SetRip7560TransactionDebugInfo(infos []*types.Rip7560TransactionDebugInfo) {}
@@ -57,6 +57,29 @@ type Config struct {
 
 	RollupComputePendingBlock bool   // Compute the pending block from tx-pool, instead of copying the latest-block
 	EffectiveGasCeil          uint64 // if non-zero, a gas ceiling to apply independent of the header's gaslimit value
+
+	Rip7560RelayOnly         bool              // Relay RIP-7560 bundles without ever including them in locally built payloads
+	Rip7560ValidationTimeout time.Duration     // Wall-clock limit of the validation of each RIP-7560 transaction in a payload (0 = unlimited)
+	Rip7560Bundles           Rip7560BundleMode // Inclusion mode of the RIP-7560 bundles with invalid transactions
+}
+
+// Rip7560BundleMode is how the block builder includes the RIP-7560 bundles with invalid
+// or conflicting transactions.
+type Rip7560BundleMode string
+
+const (
+	Rip7560BundlesSkip    Rip7560BundleMode = "skip"    // Skip the invalid transactions of bundles, including the rest
+	Rip7560BundlesAtomic  Rip7560BundleMode = "atomic"  // Include bundles entirely or not at all
+	Rip7560BundlesPartial Rip7560BundleMode = "partial" // Include the valid prefix of bundles, dropping the rest from their first invalid transaction
+)
+
+// IsValid returns whether the mode is a known one. The empty mode skips invalid transactions.
+func (mode Rip7560BundleMode) IsValid() bool {
+	switch mode {
+	case "", Rip7560BundlesSkip, Rip7560BundlesAtomic, Rip7560BundlesPartial:
+		return true
+	}
+	return false
 }
 
 // DefaultConfig contains default settings for miner.
@@ -69,6 +92,8 @@ var DefaultConfig = Config{
 	// for payload generation. It should be enough for Geth to
 	// run 3 rounds.
 	Recommit: 2 * time.Second,
+
+	Rip7560Bundles: Rip7560BundlesSkip,
 }
 
 // Miner is the main object which takes care of submitting new work to consensus
@@ -89,6 +89,10 @@ func (bc *testBlockChain) HasState(root common.Hash) bool {
 	return bc.root == root
 }
 
+func (bc *testBlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	return nil
+}
+
 func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return bc.chainHeadFeed.Subscribe(ch)
 }
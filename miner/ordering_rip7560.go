@@ -0,0 +1,79 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"math"
+	"math/big"
+	"slices"
+)
+
+// scoredRip7560Bundle is a bundle along with the priority fee it is expected to pay.
+type scoredRip7560Bundle struct {
+	bundle *types.ExternallyReceivedBundle
+	score  *big.Int
+	gas    uint64 // Aggregate gas limit of the transactions of the bundle
+}
+
+// rip7560BundleScore returns the aggregate effective priority fee the transactions of the
+// bundle pay on their gas limits, and their aggregate gas limit. On chains paying the
+// unused gas penalty separately from the fees, the share of the gas limits the penalty rule
+// of the block may take pays no priority fee and is left out of the score.
+// Malformed transactions, or transactions not paying the base fee, score nothing, and
+// bundles whose gas limits overflow never fit in a block.
+func rip7560BundleScore(bundle *types.ExternallyReceivedBundle, header *types.Header, config *params.ChainConfig) (*big.Int, uint64) {
+	var (
+		score   = new(big.Int)
+		gas     uint64
+		penalty = config.RIP7560GasPenalty(header.Number)
+	)
+	for _, tx := range bundle.Transactions {
+		if tx.Type() != types.Rip7560Type {
+			continue
+		}
+		aatx := tx.Rip7560TransactionData()
+		limit, err := aatx.TotalGasLimit()
+		if err == nil {
+			gas, err = types.SumGas(gas, limit)
+		}
+		if err != nil {
+			return new(big.Int), math.MaxUint64
+		}
+		if aatx.GasFeeCap == nil || aatx.GasTipCap == nil {
+			continue
+		}
+		tip, err := tx.EffectiveGasTip(header.BaseFee)
+		if err != nil {
+			continue
+		}
+		if config.RIP7560Penalty(header.Number) != nil {
+			var penalized uint64
+			if penalty.Validation {
+				penalized += aatx.ValidationGasLimit + aatx.PaymasterValidationGasLimit
+			}
+			if penalty.Execution {
+				penalized += aatx.Gas
+			}
+			if penalty.PostOp {
+				penalized += aatx.PostOpGas
+			}
+			limit -= penalized / 100 * penalty.Percent
+		}
+		score.Add(score, tip.Mul(tip, new(big.Int).SetUint64(limit)))
+	}
+	return score, gas
+}
+
+// sortRip7560Bundles scores the bundles and orders them by decreasing score, the bundles
+// scoring the same keeping their original order.
+func sortRip7560Bundles(bundles []*types.ExternallyReceivedBundle, header *types.Header, config *params.ChainConfig) []*scoredRip7560Bundle {
+	scored := make([]*scoredRip7560Bundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		score, gas := rip7560BundleScore(bundle, header, config)
+		scored = append(scored, &scoredRip7560Bundle{bundle: bundle, score: score, gas: gas})
+	}
+	slices.SortStableFunc(scored, func(a, b *scoredRip7560Bundle) int {
+		return b.score.Cmp(a.score)
+	})
+	return scored
+}
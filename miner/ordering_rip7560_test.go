@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func newScoredRip7560Bundle(hash byte, txs ...*types.Rip7560AccountAbstractionTx) *types.ExternallyReceivedBundle {
+	bundle := &types.ExternallyReceivedBundle{BundleHash: common.Hash{hash}}
+	for _, aatx := range txs {
+		bundle.Transactions = append(bundle.Transactions, types.NewTx(aatx))
+	}
+	return bundle
+}
+
+func newScoredRip7560Tx(gas uint64, tip, feeCap int64) *types.Rip7560AccountAbstractionTx {
+	return &types.Rip7560AccountAbstractionTx{
+		Sender:             &common.Address{0x01},
+		Gas:                gas,
+		ValidationGasLimit: 100_000,
+		GasTipCap:          big.NewInt(tip),
+		GasFeeCap:          big.NewInt(feeCap),
+	}
+}
+
+func TestRip7560BundleScore(t *testing.T) {
+	var (
+		header     = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(10)}
+		penalty    = *params.TestChainConfig
+		validation = *params.TestChainConfig
+	)
+	penalty.RIP7560Penalties = []*params.RIP7560PenaltyRule{{Block: big.NewInt(1), Beneficiary: params.RIP7560PenaltyBurn}}
+	validation.RIP7560Penalties = penalty.RIP7560Penalties
+	validation.RIP7560GasPenalties = []*params.RIP7560GasPenaltyRule{{Block: big.NewInt(1), Percent: 20, Validation: true}}
+
+	tests := []struct {
+		name   string
+		bundle *types.ExternallyReceivedBundle
+		config *params.ChainConfig
+		score  uint64
+		gas    uint64
+	}{
+		{
+			name:   "tip capped by fee cap",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 5, 12)),
+			config: params.TestChainConfig,
+			score:  2 * (params.Rip7560TxGas + 1_000_000),
+			gas:    params.Rip7560TxGas + 1_000_000,
+		},
+		{
+			name:   "aggregated over transactions",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 1, 20), newScoredRip7560Tx(900_000, 2, 20)),
+			config: params.TestChainConfig,
+			score:  3 * (params.Rip7560TxGas + 1_000_000),
+			gas:    2 * (params.Rip7560TxGas + 1_000_000),
+		},
+		{
+			name:   "penalty left out",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 1, 20)),
+			config: &penalty,
+			score:  params.Rip7560TxGas + 1_000_000 - 90_000,
+			gas:    params.Rip7560TxGas + 1_000_000,
+		},
+		{
+			name:   "configured penalty left out",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 1, 20)),
+			config: &validation,
+			score:  params.Rip7560TxGas + 1_000_000 - 20_000,
+			gas:    params.Rip7560TxGas + 1_000_000,
+		},
+		{
+			name:   "base fee not paid",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 1, 5)),
+			config: params.TestChainConfig,
+			score:  0,
+			gas:    params.Rip7560TxGas + 1_000_000,
+		},
+		{
+			name:   "missing fees",
+			bundle: newScoredRip7560Bundle(1, &types.Rip7560AccountAbstractionTx{Sender: &common.Address{0x01}, Gas: 900_000, ValidationGasLimit: 100_000}),
+			config: params.TestChainConfig,
+			score:  0,
+			gas:    params.Rip7560TxGas + 1_000_000,
+		},
+		{
+			name:   "overflowing gas",
+			bundle: newScoredRip7560Bundle(1, newScoredRip7560Tx(math.MaxUint64, 1, 20)),
+			config: params.TestChainConfig,
+			score:  0,
+			gas:    math.MaxUint64,
+		},
+	}
+	for _, tt := range tests {
+		score, gas := rip7560BundleScore(tt.bundle, header, tt.config)
+		if score.Cmp(new(big.Int).SetUint64(tt.score)) != 0 || gas != tt.gas {
+			t.Errorf("%s: score mismatch: have %v/%d, want %d/%d", tt.name, score, gas, tt.score, tt.gas)
+		}
+	}
+}
+
+func TestSortRip7560Bundles(t *testing.T) {
+	var (
+		low    = newScoredRip7560Bundle(1, newScoredRip7560Tx(900_000, 1, 20))
+		high   = newScoredRip7560Bundle(2, newScoredRip7560Tx(900_000, 3, 20))
+		tied   = newScoredRip7560Bundle(3, newScoredRip7560Tx(900_000, 1, 20))
+		bigger = newScoredRip7560Bundle(4, newScoredRip7560Tx(900_000, 1, 20), newScoredRip7560Tx(900_000, 1, 20))
+	)
+	sorted := sortRip7560Bundles([]*types.ExternallyReceivedBundle{low, high, tied, bigger}, &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(10)}, params.TestChainConfig)
+	want := []*types.ExternallyReceivedBundle{high, bigger, low, tied}
+	for i, scored := range sorted {
+		if scored.bundle != want[i] {
+			t.Errorf("bundle %d mismatch: have %x, want %x", i, scored.bundle.BundleHash, want[i].BundleHash)
+		}
+	}
+}
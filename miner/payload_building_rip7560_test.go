@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"testing"
+	"time"
+)
+
+func TestBuildPayloadRip7560RelayOnly(t *testing.T) {
+	engine := ethash.NewFaker()
+	chainConfig := *params.TestChainConfig
+	chainConfig.RIP7560Block = common.Big0
+	b := newTestWorkerBackend(t, &chainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, b.chain, []txpool.SubPool{
+		legacypool.New(testTxPoolConfig, b.chain),
+		rip7560pool.New(rip7560pool.Config{}, b.chain, common.Address{}),
+	})
+	if err != nil {
+		t.Fatalf("failed to create tx pool: %v", err)
+	}
+	defer pool.Close()
+	b.txPool = pool
+	b.txPool.Add(pendingTxs, true, true)
+
+	statusCh := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(statusCh)
+	defer sub.Unsubscribe()
+
+	sender := common.Address{0x01}
+	bundle := &types.ExternallyReceivedBundle{
+		BundleHash:    common.Hash{0x01},
+		ValidForBlock: common.Big1,
+		Transactions:  types.Transactions{types.NewTx(&types.Rip7560AccountAbstractionTx{ChainID: params.TestChainConfig.ChainID, Sender: &sender})},
+	}
+	if err := pool.SubmitRip7560Bundle(bundle); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	if ev := <-statusCh; ev.Status != core.Rip7560TxAccepted {
+		t.Fatalf("unexpected status: have %v, want %v", ev.Status, core.Rip7560TxAccepted)
+	}
+
+	config := testConfig
+	config.Rip7560RelayOnly = true
+	w := New(b, config, engine)
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:    b.chain.CurrentBlock().Hash(),
+		Timestamp: uint64(time.Now().Unix()),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	payload.WaitFull()
+	full := payload.ResolveFull()
+	if have, want := len(full.ExecutionPayload.Transactions), len(pendingTxs); have != want {
+		t.Errorf("unexpected transaction count: have %d, want %d", have, want)
+	}
+	select {
+	case ev := <-statusCh:
+		t.Errorf("unexpected status event for relayed bundle: %v", ev.Status)
+	default:
+	}
+}
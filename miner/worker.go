@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -436,6 +437,86 @@ func (miner *Miner) commitTransactions(env *environment, plainTxs, blobTxs *tran
 	return nil
 }
 
+// rip7560NonceSlot is a nonce of a nonce lane of an RIP-7560 sender.
+type rip7560NonceSlot struct {
+	sender   common.Address
+	nonceKey common.Hash
+	nonce    uint64
+}
+
+// rip7560NonceSlotOf returns the nonce slot of the transaction, or false for malformed
+// transactions without a sender, left to their validation to reject.
+func rip7560NonceSlotOf(tx *types.Transaction) (rip7560NonceSlot, bool) {
+	aatx := tx.Rip7560TransactionData()
+	if aatx.Sender == nil {
+		return rip7560NonceSlot{}, false
+	}
+	slot := rip7560NonceSlot{sender: *aatx.Sender, nonce: aatx.Nonce}
+	if aatx.NonceKey != nil {
+		slot.nonceKey = common.BigToHash(aatx.NonceKey)
+	}
+	return slot, true
+}
+
+// commitRip7560Bundles merges the bundles targeting the block into a non-conflicting
+// subset, the highest paying first. Bundles not fitting in the gas left in the block are
+// skipped, leaving it to the lower paying ones. Bundles reusing the nonce of a transaction
+// already included conflict with it: they only lose the conflicting transactions, are
+// cut before the first one in partial mode, or are skipped in atomic mode. Storage
+// conflicts surface as validation failures of the later bundle on top of the state left
+// by the earlier ones, resolved the same way.
+func (miner *Miner) commitRip7560Bundles(env *environment, bundles []*types.ExternallyReceivedBundle, interrupt *atomic.Int32) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	used := make(map[rip7560NonceSlot]struct{})
+	for _, scored := range sortRip7560Bundles(bundles, env.header, miner.chainConfig) {
+		bundle := scored.bundle
+		if scored.gas > env.gasPool.Gas() {
+			log.Debug("Skipping RIP-7560 bundle not fitting in the block", "hash", bundle.BundleHash, "gas", scored.gas, "left", env.gasPool.Gas())
+			continue
+		}
+		var conflicts int
+		txs := make([]*types.Transaction, 0, len(bundle.Transactions))
+		for _, tx := range bundle.Transactions {
+			if tx.Type() == types.Rip7560Type {
+				if slot, ok := rip7560NonceSlotOf(tx); ok {
+					if _, conflict := used[slot]; conflict {
+						conflicts++
+						if miner.config.Rip7560Bundles == Rip7560BundlesPartial {
+							break
+						}
+						continue
+					}
+				}
+			}
+			txs = append(txs, tx)
+		}
+		if conflicts > 0 {
+			if miner.config.Rip7560Bundles == Rip7560BundlesAtomic || len(txs) == 0 {
+				log.Debug("Skipping RIP-7560 bundle with nonce conflicts", "hash", bundle.BundleHash, "conflicts", conflicts)
+				continue
+			}
+			merged := *bundle
+			merged.Transactions = txs
+			bundle = &merged
+		}
+		included := len(env.txs)
+		if err := miner.commitRip7560TransactionsBundle(env, bundle, interrupt); err != nil {
+			return err
+		}
+		for _, tx := range env.txs[included:] {
+			if tx.Type() != types.Rip7560Type {
+				continue
+			}
+			if slot, ok := rip7560NonceSlotOf(tx); ok {
+				used[slot] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
 func (miner *Miner) commitRip7560TransactionsBundle(env *environment, txs *types.ExternallyReceivedBundle, _ *atomic.Int32) error {
 
 	// todo: copied over to fix crash, probably should do it once
@@ -444,17 +525,99 @@ func (miner *Miner) commitRip7560TransactionsBundle(env *environment, txs *types
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
 	}
 
-	validatedTxs, receipts, validationFailureInfos, _, err := core.HandleRip7560Transactions(txs.Transactions, 0, env.state, &env.coinbase, env.header, env.gasPool, miner.chainConfig, miner.chain, vm.Config{}, true, &env.header.GasUsed)
+	vmConfig := vm.Config{
+		Rip7560GasInvariants:     miner.chain.GetVMConfig().Rip7560GasInvariants,
+		Rip7560ValidationTimeout: miner.config.Rip7560ValidationTimeout,
+	}
+	switch miner.config.Rip7560Bundles {
+	case Rip7560BundlesAtomic:
+		return miner.commitRip7560BundleAtomically(env, txs, vmConfig)
+	case Rip7560BundlesPartial:
+		return miner.commitRip7560BundlePrefix(env, txs, vmConfig)
+	}
+	// The bundle is placed at its index in the block, so that the transaction context
+	// of the logs matches its position when merged after other bundles
+	batch := make([]*types.Transaction, env.tcount+len(txs.Transactions))
+	copy(batch[env.tcount:], txs.Transactions)
+
+	// A transaction failing its execution phase leaves the state partially applied, so the
+	// bundle is applied to a copy of the state and dropped in that case
+	var (
+		state   = env.state.Copy()
+		gasPool = *env.gasPool
+		gasUsed = env.header.GasUsed
+	)
+	validatedTxs, receipts, validationFailureInfos, _, err := core.HandleRip7560Transactions(batch, env.tcount, state, &env.coinbase, env.header, &gasPool, miner.chainConfig, miner.chain, vmConfig, true, &gasUsed)
 	miner.chain.SetRip7560TransactionDebugInfo(validationFailureInfos)
+	miner.txpool.ReportRip7560TxsDropped(validationFailureInfos)
+	if errors.Is(err, core.ErrRip7560GasLimitExceeded) {
+		log.Error("Skipping RIP-7560 bundle failing its execution phase", "hash", txs.BundleHash, "err", err)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	env.state, *env.gasPool, env.header.GasUsed = state, gasPool, gasUsed
 	env.txs = append(env.txs, validatedTxs...)
 	env.receipts = append(env.receipts, receipts...)
 	env.tcount += len(validatedTxs)
 	return nil
 }
 
+// commitRip7560BundleAtomically includes all transactions of the bundle in their original
+// order, or none of them if any fails validation.
+func (miner *Miner) commitRip7560BundleAtomically(env *environment, bundle *types.ExternallyReceivedBundle, vmConfig vm.Config) error {
+	state, receipts, validationFailureInfos, err := core.HandleRip7560Bundle(bundle, env.tcount, env.state, &env.coinbase, env.header, env.gasPool, miner.chainConfig, miner.chain, vmConfig, &env.header.GasUsed)
+	miner.chain.SetRip7560TransactionDebugInfo(validationFailureInfos)
+	miner.txpool.ReportRip7560TxsDropped(validationFailureInfos)
+	if err != nil && !errors.Is(err, core.ErrRip7560GasLimitExceeded) {
+		return err
+	}
+	if state == nil {
+		log.Debug("Skipping RIP-7560 bundle with invalid transactions", "hash", bundle.BundleHash, "invalid", len(validationFailureInfos))
+		return nil
+	}
+	env.state = state
+	env.txs = append(env.txs, bundle.Transactions...)
+	env.receipts = append(env.receipts, receipts...)
+	env.tcount += len(bundle.Transactions)
+	return nil
+}
+
+// commitRip7560BundlePrefix includes the transactions of the bundle preceding the first
+// one failing validation, in their original order, and drops the rest of the bundle.
+func (miner *Miner) commitRip7560BundlePrefix(env *environment, bundle *types.ExternallyReceivedBundle, vmConfig vm.Config) error {
+	for len(bundle.Transactions) > 0 {
+		state, receipts, validationFailureInfos, err := core.HandleRip7560Bundle(bundle, env.tcount, env.state, &env.coinbase, env.header, env.gasPool, miner.chainConfig, miner.chain, vmConfig, &env.header.GasUsed)
+		miner.chain.SetRip7560TransactionDebugInfo(validationFailureInfos)
+		miner.txpool.ReportRip7560TxsDropped(validationFailureInfos)
+		// The transaction failing its execution phase is dropped like an invalid one
+		if err != nil && !errors.Is(err, core.ErrRip7560GasLimitExceeded) {
+			return err
+		}
+		if state != nil {
+			env.state = state
+			env.txs = append(env.txs, bundle.Transactions...)
+			env.receipts = append(env.receipts, receipts...)
+			env.tcount += len(bundle.Transactions)
+			return nil
+		}
+		// Retry with the transactions preceding the first invalid one, which validate
+		// exactly as before
+		invalid := len(bundle.Transactions) - 1
+		for _, info := range validationFailureInfos {
+			if i := slices.IndexFunc(bundle.Transactions, func(tx *types.Transaction) bool { return tx.Hash() == info.TxHash }); i >= 0 && i < invalid {
+				invalid = i
+			}
+		}
+		log.Debug("Dropping RIP-7560 bundle suffix from invalid transaction", "hash", bundle.BundleHash, "index", invalid, "dropped", len(bundle.Transactions)-invalid)
+		prefix := *bundle
+		prefix.Transactions = bundle.Transactions[:invalid]
+		bundle = &prefix
+	}
+	return nil
+}
+
 // fillTransactions retrieves the pending transactions from the txpool and fills them
 // into the given sealing block. The transaction selection and ordering strategy can
 // be customized with the plugin in the future.
@@ -494,11 +657,18 @@ func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment)
 		}
 	}
 
-	pendingBundle, err := miner.txpool.PendingRip7560Bundle()
-	if pendingBundle != nil {
-		if err = miner.commitRip7560TransactionsBundle(env, pendingBundle, interrupt); err != nil {
-			log.Error(err.Error())
-			return err
+	// Relay-only nodes accept, gossip and forward RIP-7560 bundles, but leave their
+	// inclusion to the block producer
+	if !miner.config.Rip7560RelayOnly {
+		pendingBundles, err := miner.txpool.PendingRip7560Bundles()
+		if err != nil {
+			log.Warn("Failed to retrieve pending RIP-7560 bundles", "err", err)
+		}
+		if len(pendingBundles) > 0 {
+			if err = miner.commitRip7560Bundles(env, pendingBundles, interrupt); err != nil {
+				log.Error(err.Error())
+				return err
+			}
 		}
 	}
 
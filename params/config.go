@@ -17,8 +17,10 @@
 package params
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params/forks"
@@ -201,6 +203,14 @@ var (
 		EIP158Block:                   big.NewInt(0),
 		RIP7560Block:                  big.NewInt(0),
 		RIP7712Block:                  big.NewInt(0),
+		RIP7560ActualGasCostBlock:     big.NewInt(0),
+		RIP7560EmptyExecutionBlock:    big.NewInt(0),
+		RIP7560SigningDomainBlock:     big.NewInt(0),
+		RIP7560WarmExecutionBlock:     big.NewInt(0),
+		RIP7711Block:                  big.NewInt(0),
+		RIP7560BuilderFeeBlock:        big.NewInt(0),
+		RIP7560EIP7702Block:           big.NewInt(0),
+		RIP7560ReceiptRootBlock:       big.NewInt(0),
 		ByzantiumBlock:                big.NewInt(0),
 		ConstantinopleBlock:           big.NewInt(0),
 		PetersburgBlock:               big.NewInt(0),
@@ -376,6 +386,19 @@ type ChainConfig struct {
 
 	RIP7560Block *big.Int `json:"rip7560block,omitempty"` // RIP7560 HF block
 	RIP7712Block *big.Int `json:"rip7712block,omitempty"` // RIP7712 HF block
+	RIP7560Time  *uint64  `json:"rip7560Time,omitempty"`  // RIP7560 switch time (nil = scheduled by block only)
+	RIP7712Time  *uint64  `json:"rip7712Time,omitempty"`  // RIP7712 switch time (nil = scheduled by block only)
+
+	RIP7560ActualGasCostBlock  *big.Int `json:"rip7560ActualGasCostBlock,omitempty"`  // RIP7560 postOp actualGasCost-in-wei switch block (nil = pass gas units)
+	RIP7560SystemEventGasBlock *big.Int `json:"rip7560SystemEventGasBlock,omitempty"` // RIP7560 system event gas charging switch block (nil = events are free)
+	RIP7560EmptyExecutionBlock *big.Int `json:"rip7560EmptyExecutionBlock,omitempty"` // RIP7560 empty execution frame skipping switch block (nil = always called)
+	RIP7560SigningDomainBlock  *big.Int `json:"rip7560SigningDomainBlock,omitempty"`  // RIP7560 EntryPoint signing domain switch block (nil = chain ID only)
+	RIP7560WarmExecutionBlock  *big.Int `json:"rip7560WarmExecutionBlock,omitempty"`  // RIP7560 validation warm state carried into execution switch block (nil = state of the last validation)
+	RIP7711Block               *big.Int `json:"rip7711block,omitempty"`               // RIP7711 two-phase block processing switch block (nil = each transaction validated and executed in turn)
+	RIP7711Time                *uint64  `json:"rip7711Time,omitempty"`                // RIP7711 two-phase block processing switch time (nil = scheduled by block only)
+	RIP7560BuilderFeeBlock     *big.Int `json:"rip7560BuilderFeeBlock,omitempty"`     // RIP7560 builder fee charging switch block (nil = not charged)
+	RIP7560EIP7702Block        *big.Int `json:"rip7560Eip7702Block,omitempty"`        // RIP7560 EIP-7702 authorization processing switch block (nil = authorizations rejected)
+	RIP7560ReceiptRootBlock    *big.Int `json:"rip7560ReceiptRootBlock,omitempty"`    // RIP7560 receipt root commitment switch block (nil = only the receipt type committed)
 
 	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`      // Byzantium switch block (nil = no fork, 0 = already on byzantium)
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
@@ -423,6 +446,21 @@ type ChainConfig struct {
 
 	// Optimism config, nil if not active
 	Optimism *OptimismConfig `json:"optimism,omitempty"`
+
+	// RIP7560 unused gas penalty destinations by activation block, nil if folded into the used gas
+	RIP7560Penalties []*RIP7560PenaltyRule `json:"rip7560Penalties,omitempty"`
+
+	// RIP7560 unused gas penalty rules by activation block, nil if the default penalty applies
+	RIP7560GasPenalties []*RIP7560GasPenaltyRule `json:"rip7560GasPenalties,omitempty"`
+
+	// RIP7560 EntryPoint ABI version switches by activation block, nil if version 0 applies
+	RIP7560AbiVersions []*RIP7560AbiVersionRule `json:"rip7560AbiVersions,omitempty"`
+
+	// RIP7560 builder fee recipient from the builder fee fork on, nil if paid to the block coinbase
+	RIP7560BuilderFeeRecipient *common.Address `json:"rip7560BuilderFeeRecipient,omitempty"`
+
+	// RIP7712 NonceManager address from the RIP7712 fork on, nil if deployed at the default address
+	RIP7712NonceManager *common.Address `json:"rip7712NonceManager,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -456,6 +494,219 @@ func (o *OptimismConfig) String() string {
 	return "optimism"
 }
 
+// Destinations of the penalty charged on the unused gas of RIP-7560 transactions.
+const (
+	RIP7560PenaltyBurn     = "burn"     // The penalty is paid to no one
+	RIP7560PenaltyCoinbase = "coinbase" // The whole penalty is paid to the block coinbase
+	RIP7560PenaltyVault    = "vault"    // The whole penalty is paid to a fee vault
+)
+
+// RIP7560PenaltyRule is the destination of the penalty charged on the unused gas of
+// RIP-7560 transactions from its activation block on, until the next rule activates.
+// Before the first rule, the penalty is priced like the rest of the used gas: its
+// priority fee goes to the coinbase and its base fee is burnt.
+type RIP7560PenaltyRule struct {
+	Block       *big.Int        `json:"block"`           // Activation block of the rule
+	Beneficiary string          `json:"beneficiary"`     // One of "burn", "coinbase" or "vault"
+	Vault       *common.Address `json:"vault,omitempty"` // Recipient of the penalty, required by the "vault" beneficiary
+}
+
+// String implements the stringer interface, returning the penalty destination.
+func (p *RIP7560PenaltyRule) String() string {
+	if p.Beneficiary == RIP7560PenaltyVault && p.Vault != nil {
+		return fmt.Sprintf("%s(%v)", p.Beneficiary, *p.Vault)
+	}
+	return p.Beneficiary
+}
+
+func (p *RIP7560PenaltyRule) activation() *big.Int { return p.Block }
+
+// sameAs returns whether the rule pays the penalty to the same destination as the other
+// one, regardless of their activation blocks.
+func (p *RIP7560PenaltyRule) sameAs(other *RIP7560PenaltyRule) bool {
+	return p.Beneficiary == other.Beneficiary && sameAddress(p.Vault, other.Vault)
+}
+
+// check returns an error if the penalty destination is unknown or incomplete.
+func (p *RIP7560PenaltyRule) check() error {
+	switch p.Beneficiary {
+	case RIP7560PenaltyBurn, RIP7560PenaltyCoinbase:
+		if p.Vault != nil {
+			return fmt.Errorf("rip7560 penalty vault set for beneficiary %q", p.Beneficiary)
+		}
+	case RIP7560PenaltyVault:
+		if p.Vault == nil {
+			return errors.New("rip7560 penalty vault not set")
+		}
+	default:
+		return fmt.Errorf("unknown rip7560 penalty beneficiary %q", p.Beneficiary)
+	}
+	return nil
+}
+
+// RIP7560Penalty returns the destination of the penalty charged on the unused gas of
+// RIP-7560 transactions at the given block, or nil if it is folded into the used gas.
+func (c *ChainConfig) RIP7560Penalty(num *big.Int) *RIP7560PenaltyRule {
+	var rule *RIP7560PenaltyRule
+	for _, r := range c.RIP7560Penalties {
+		if isBlockForked(r.Block, num) {
+			rule = r
+		}
+	}
+	return rule
+}
+
+// checkRIP7560Penalties returns an error if the penalty destinations are not ordered by
+// activation block, or are unknown or incomplete.
+func (c *ChainConfig) checkRIP7560Penalties() error {
+	var last *big.Int
+	for i, rule := range c.RIP7560Penalties {
+		if rule == nil || rule.Block == nil {
+			return fmt.Errorf("rip7560 penalty rule %d has no activation block", i)
+		}
+		if last != nil && rule.Block.Cmp(last) <= 0 {
+			return fmt.Errorf("rip7560 penalty rule %d activates at block %v, not after block %v", i, rule.Block, last)
+		}
+		if err := rule.check(); err != nil {
+			return err
+		}
+		last = rule.Block
+	}
+	return nil
+}
+
+// sameAddress returns whether both addresses are unset or equal.
+func sameAddress(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// RIP7560DefaultGasPenaltyPercent is the share of the unused execution and postOp gas
+// limits charged to RIP-7560 transactions before the activation of any penalty rule.
+const RIP7560DefaultGasPenaltyPercent = 10
+
+// RIP7560GasPenaltyRule is the penalty charged on the unused gas limits of the frames of
+// RIP-7560 transactions from its activation block on, until the next rule activates.
+type RIP7560GasPenaltyRule struct {
+	Block      *big.Int `json:"block"`                // Activation block of the rule
+	Percent    uint64   `json:"percent"`              // Share of the unused gas limits charged, in percent
+	Validation bool     `json:"validation,omitempty"` // Whether the unused account and paymaster validation gas limits are penalized
+	Execution  bool     `json:"execution,omitempty"`  // Whether the unused execution gas limit is penalized
+	PostOp     bool     `json:"postOp,omitempty"`     // Whether the unused postOp gas limit is penalized
+}
+
+// String implements the stringer interface, returning the penalty and its frames.
+func (r *RIP7560GasPenaltyRule) String() string {
+	var frames []string
+	if r.Validation {
+		frames = append(frames, "validation")
+	}
+	if r.Execution {
+		frames = append(frames, "execution")
+	}
+	if r.PostOp {
+		frames = append(frames, "postOp")
+	}
+	return fmt.Sprintf("%d%%(%s)", r.Percent, strings.Join(frames, ","))
+}
+
+func (r *RIP7560GasPenaltyRule) activation() *big.Int { return r.Block }
+
+// sameAs returns whether the rule charges the same penalty as the other one, regardless of
+// their activation blocks.
+func (r *RIP7560GasPenaltyRule) sameAs(other *RIP7560GasPenaltyRule) bool {
+	return r.Percent == other.Percent && r.Validation == other.Validation && r.Execution == other.Execution && r.PostOp == other.PostOp
+}
+
+// Penalty returns the penalty charged on the given unused gas limit.
+func (r *RIP7560GasPenaltyRule) Penalty(unusedGas uint64) uint64 {
+	return unusedGas * r.Percent / 100
+}
+
+// RIP7560GasPenalty returns the penalty rule charged on the unused gas limits of RIP-7560
+// transactions at the given block.
+func (c *ChainConfig) RIP7560GasPenalty(num *big.Int) *RIP7560GasPenaltyRule {
+	rule := &RIP7560GasPenaltyRule{Percent: RIP7560DefaultGasPenaltyPercent, Execution: true, PostOp: true}
+	for _, r := range c.RIP7560GasPenalties {
+		if isBlockForked(r.Block, num) {
+			rule = r
+		}
+	}
+	return rule
+}
+
+// checkRIP7560GasPenalties returns an error if the penalty rules are not ordered by
+// activation block, or charge more than the unused gas.
+func (c *ChainConfig) checkRIP7560GasPenalties() error {
+	var last *big.Int
+	for i, rule := range c.RIP7560GasPenalties {
+		if rule == nil || rule.Block == nil {
+			return fmt.Errorf("rip7560 gas penalty rule %d has no activation block", i)
+		}
+		if last != nil && rule.Block.Cmp(last) <= 0 {
+			return fmt.Errorf("rip7560 gas penalty rule %d activates at block %v, not after block %v", i, rule.Block, last)
+		}
+		if rule.Percent > 100 {
+			return fmt.Errorf("rip7560 gas penalty rule %d charges %d%% of the unused gas", i, rule.Percent)
+		}
+		last = rule.Block
+	}
+	return nil
+}
+
+// RIP7560AbiVersionRule switches RIP-7560 transactions to a version of the EntryPoint ABI
+// from its activation block on, until the next rule activates.
+type RIP7560AbiVersionRule struct {
+	Block   *big.Int `json:"block"`   // Activation block of the version
+	Version uint64   `json:"version"` // Version of the EntryPoint ABI
+}
+
+func (r *RIP7560AbiVersionRule) activation() *big.Int { return r.Block }
+
+// sameAs returns whether the rule switches to the same version as the other one, regardless
+// of their activation blocks.
+func (r *RIP7560AbiVersionRule) sameAs(other *RIP7560AbiVersionRule) bool {
+	return r.Version == other.Version
+}
+
+// RIP7560SupportedAbiVersions are the versions of the RIP-7560 EntryPoint ABI implemented
+// by the client. A version registered with the EntryPoint ABIs of core is added here, so
+// that a config switching to a version the client lacks is rejected on startup.
+var RIP7560SupportedAbiVersions = map[uint64]bool{0: true}
+
+// RIP7560AbiVersion returns the version of the EntryPoint ABI of RIP-7560 transactions at
+// the given block.
+func (c *ChainConfig) RIP7560AbiVersion(num *big.Int) uint64 {
+	var version uint64
+	for _, rule := range c.RIP7560AbiVersions {
+		if isBlockForked(rule.Block, num) {
+			version = rule.Version
+		}
+	}
+	return version
+}
+
+// checkRIP7560AbiVersions returns an error if the EntryPoint ABI version switches are not
+// ordered by activation block, or switch to a version the client does not support.
+func (c *ChainConfig) checkRIP7560AbiVersions() error {
+	var last *big.Int
+	for i, rule := range c.RIP7560AbiVersions {
+		if rule == nil || rule.Block == nil {
+			return fmt.Errorf("rip7560 abi version rule %d has no activation block", i)
+		}
+		if last != nil && rule.Block.Cmp(last) <= 0 {
+			return fmt.Errorf("rip7560 abi version rule %d activates at block %v, not after block %v", i, rule.Block, last)
+		}
+		if !RIP7560SupportedAbiVersions[rule.Version] {
+			return fmt.Errorf("rip7560 abi version rule %d switches to unsupported version %d", i, rule.Version)
+		}
+		last = rule.Block
+	}
+	return nil
+}
+
 // Description returns a human-readable description of ChainConfig.
 func (c *ChainConfig) Description() string {
 	var banner string
@@ -568,9 +819,54 @@ func (c *ChainConfig) Description() string {
 	if c.InteropTime != nil {
 		banner += fmt.Sprintf(" - Interop:                     @%-10v\n", *c.InteropTime)
 	}
+	banner += c.rip7560Description()
 	return banner
 }
 
+// rip7560Description returns a human-readable description of the RIP-7560 forks and rules
+// of ChainConfig, or an empty string if none is scheduled.
+func (c *ChainConfig) rip7560Description() string {
+	forks := []struct {
+		name  string
+		block *big.Int
+		time  *uint64
+	}{
+		{"RIP-7560", c.RIP7560Block, c.RIP7560Time},
+		{"RIP-7712", c.RIP7712Block, c.RIP7712Time},
+		{"RIP-7560 actual gas cost", c.RIP7560ActualGasCostBlock, nil},
+		{"RIP-7560 system event gas", c.RIP7560SystemEventGasBlock, nil},
+		{"RIP-7560 empty execution", c.RIP7560EmptyExecutionBlock, nil},
+		{"RIP-7560 signing domain", c.RIP7560SigningDomainBlock, nil},
+		{"RIP-7560 warm execution", c.RIP7560WarmExecutionBlock, nil},
+		{"RIP-7711", c.RIP7711Block, c.RIP7711Time},
+		{"RIP-7560 builder fee", c.RIP7560BuilderFeeBlock, nil},
+		{"RIP-7560 EIP-7702", c.RIP7560EIP7702Block, nil},
+		{"RIP-7560 receipt root", c.RIP7560ReceiptRootBlock, nil},
+	}
+	var banner string
+	for _, fork := range forks {
+		if fork.block != nil {
+			banner += fmt.Sprintf(" - %-28s #%-8v\n", fork.name+":", fork.block)
+		}
+		if fork.time != nil {
+			banner += fmt.Sprintf(" - %-28s @%-10v\n", fork.name+":", *fork.time)
+		}
+	}
+	for _, rule := range c.RIP7560Penalties {
+		banner += fmt.Sprintf(" - %-28s #%-8v %v\n", "RIP-7560 penalty:", rule.Block, rule)
+	}
+	for _, rule := range c.RIP7560GasPenalties {
+		banner += fmt.Sprintf(" - %-28s #%-8v %v\n", "RIP-7560 gas penalty:", rule.Block, rule)
+	}
+	for _, rule := range c.RIP7560AbiVersions {
+		banner += fmt.Sprintf(" - %-28s #%-8v v%d\n", "RIP-7560 EntryPoint ABI:", rule.Block, rule.Version)
+	}
+	if banner == "" {
+		return ""
+	}
+	return "\nRIP-7560 account abstraction forks:\n" + banner
+}
+
 // IsHomestead returns whether num is either equal to the homestead block or greater.
 func (c *ChainConfig) IsHomestead(num *big.Int) bool {
 	return isBlockForked(c.HomesteadBlock, num)
@@ -748,14 +1044,68 @@ func (c *ChainConfig) IsOptimismPreBedrock(num *big.Int) bool {
 	return c.IsOptimism() && !c.IsBedrock(num)
 }
 
-// IsRIP7560 returns whether num is either equal to the RIP7560 fork block or greater.
-func (c *ChainConfig) IsRIP7560(num *big.Int) bool {
-	return isBlockForked(c.RIP7560Block, num)
+// IsRIP7560 returns whether RIP7560 has been activated at the given block number or time.
+func (c *ChainConfig) IsRIP7560(num *big.Int, time uint64) bool {
+	return isBlockForked(c.RIP7560Block, num) || isTimestampForked(c.RIP7560Time, time)
+}
+
+// IsRIP7712 returns whether RIP7712 has been activated at the given block number or time.
+func (c *ChainConfig) IsRIP7712(num *big.Int, time uint64) bool {
+	return isBlockForked(c.RIP7712Block, num) || isTimestampForked(c.RIP7712Time, time)
+}
+
+// IsRIP7560ActualGasCost returns whether the paymaster 'postPaymasterTransaction' frame
+// receives the actual gas cost in wei, rather than the amount of gas used, at given block.
+func (c *ChainConfig) IsRIP7560ActualGasCost(num *big.Int) bool {
+	return isBlockForked(c.RIP7560ActualGasCostBlock, num)
+}
+
+// IsRIP7560SystemEventGas returns whether the EntryPoint events injected by RIP-7560
+// transactions are charged for as the equivalent LOG opcodes at given block.
+func (c *ChainConfig) IsRIP7560SystemEventGas(num *big.Int) bool {
+	return isBlockForked(c.RIP7560SystemEventGasBlock, num)
+}
+
+// IsRIP7560EmptyExecution returns whether the execution frame of RIP-7560 transactions
+// with an empty 'executionData' is skipped, rather than called and penalized, at given block.
+func (c *ChainConfig) IsRIP7560EmptyExecution(num *big.Int) bool {
+	return isBlockForked(c.RIP7560EmptyExecutionBlock, num)
+}
+
+// IsRIP7560SigningDomain returns whether the EntryPoint address and ABI version are bound
+// into the signing hash of RIP-7560 transactions, along with the chain ID, at given block.
+func (c *ChainConfig) IsRIP7560SigningDomain(num *big.Int) bool {
+	return isBlockForked(c.RIP7560SigningDomainBlock, num)
+}
+
+// IsRIP7560WarmExecution returns whether the addresses and storage slots warmed by the
+// validation phase of an RIP-7560 transaction remain warm in its execution phase.
+func (c *ChainConfig) IsRIP7560WarmExecution(num *big.Int) bool {
+	return isBlockForked(c.RIP7560WarmExecutionBlock, num)
+}
+
+// IsRIP7711 returns whether the validation phases of all consecutive RIP-7560 transactions
+// of a block run before their execution phases, at the given block number or time.
+func (c *ChainConfig) IsRIP7711(num *big.Int, time uint64) bool {
+	return isBlockForked(c.RIP7711Block, num) || isTimestampForked(c.RIP7711Time, time)
+}
+
+// IsRIP7560BuilderFee returns whether the builder fee of RIP-7560 transactions is charged
+// from their gas payer and paid to the block builder at given block.
+func (c *ChainConfig) IsRIP7560BuilderFee(num *big.Int) bool {
+	return isBlockForked(c.RIP7560BuilderFeeBlock, num)
+}
+
+// IsRIP7560EIP7702 returns whether RIP-7560 transactions may carry EIP-7702 authorizations,
+// and calls follow the delegation designators they set, at given block.
+func (c *ChainConfig) IsRIP7560EIP7702(num *big.Int) bool {
+	return isBlockForked(c.RIP7560EIP7702Block, num)
 }
 
-// IsRIP7712 returns whether RIP7712 has been activated at given block.
-func (c *ChainConfig) IsRIP7712(num *big.Int) bool {
-	return isBlockForked(c.RIP7712Block, num)
+// IsRIP7560ReceiptRoot returns whether the consensus fields of RIP-7560 receipts, their
+// logs included, are committed to the receipt root at given block.
+func (c *ChainConfig) IsRIP7560ReceiptRoot(num *big.Int) bool {
+	return isBlockForked(c.RIP7560ReceiptRootBlock, num)
 }
 
 // CheckCompatible checks whether scheduled fork transitions have been imported
@@ -848,6 +1198,15 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	if err := c.checkRIP7560Penalties(); err != nil {
+		return err
+	}
+	if err := c.checkRIP7560GasPenalties(); err != nil {
+		return err
+	}
+	if err := c.checkRIP7560AbiVersions(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -943,6 +1302,103 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.InteropTime, newcfg.InteropTime, headTimestamp, genesisTimestamp) {
 		return newTimestampCompatError("Interop fork timestamp", c.InteropTime, newcfg.InteropTime)
 	}
+	if isForkBlockIncompatible(c.RIP7560Block, newcfg.RIP7560Block, headNumber) {
+		return newBlockCompatError("RIP7560 fork block", c.RIP7560Block, newcfg.RIP7560Block)
+	}
+	if isForkBlockIncompatible(c.RIP7712Block, newcfg.RIP7712Block, headNumber) {
+		return newBlockCompatError("RIP7712 fork block", c.RIP7712Block, newcfg.RIP7712Block)
+	}
+	if isForkBlockIncompatible(c.RIP7560ActualGasCostBlock, newcfg.RIP7560ActualGasCostBlock, headNumber) {
+		return newBlockCompatError("RIP7560 actual gas cost fork block", c.RIP7560ActualGasCostBlock, newcfg.RIP7560ActualGasCostBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7560SystemEventGasBlock, newcfg.RIP7560SystemEventGasBlock, headNumber) {
+		return newBlockCompatError("RIP7560 system event gas fork block", c.RIP7560SystemEventGasBlock, newcfg.RIP7560SystemEventGasBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7560EmptyExecutionBlock, newcfg.RIP7560EmptyExecutionBlock, headNumber) {
+		return newBlockCompatError("RIP7560 empty execution fork block", c.RIP7560EmptyExecutionBlock, newcfg.RIP7560EmptyExecutionBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7560SigningDomainBlock, newcfg.RIP7560SigningDomainBlock, headNumber) {
+		return newBlockCompatError("RIP7560 signing domain fork block", c.RIP7560SigningDomainBlock, newcfg.RIP7560SigningDomainBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7560WarmExecutionBlock, newcfg.RIP7560WarmExecutionBlock, headNumber) {
+		return newBlockCompatError("RIP7560 warm execution fork block", c.RIP7560WarmExecutionBlock, newcfg.RIP7560WarmExecutionBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7711Block, newcfg.RIP7711Block, headNumber) {
+		return newBlockCompatError("RIP7711 fork block", c.RIP7711Block, newcfg.RIP7711Block)
+	}
+	if isForkBlockIncompatible(c.RIP7560BuilderFeeBlock, newcfg.RIP7560BuilderFeeBlock, headNumber) {
+		return newBlockCompatError("RIP7560 builder fee fork block", c.RIP7560BuilderFeeBlock, newcfg.RIP7560BuilderFeeBlock)
+	}
+	// The recipient was credited the builder fees since the fork
+	if isBlockForked(c.RIP7560BuilderFeeBlock, headNumber) && !sameAddress(c.RIP7560BuilderFeeRecipient, newcfg.RIP7560BuilderFeeRecipient) {
+		return newBlockCompatError("RIP7560 builder fee recipient", c.RIP7560BuilderFeeBlock, newcfg.RIP7560BuilderFeeBlock)
+	}
+	if isForkBlockIncompatible(c.RIP7560EIP7702Block, newcfg.RIP7560EIP7702Block, headNumber) {
+		return newBlockCompatError("RIP7560 EIP-7702 fork block", c.RIP7560EIP7702Block, newcfg.RIP7560EIP7702Block)
+	}
+	if isForkBlockIncompatible(c.RIP7560ReceiptRootBlock, newcfg.RIP7560ReceiptRootBlock, headNumber) {
+		return newBlockCompatError("RIP7560 receipt root fork block", c.RIP7560ReceiptRootBlock, newcfg.RIP7560ReceiptRootBlock)
+	}
+	if isForkTimestampIncompatible(c.RIP7560Time, newcfg.RIP7560Time, headTimestamp, genesisTimestamp) {
+		return newTimestampCompatError("RIP7560 fork timestamp", c.RIP7560Time, newcfg.RIP7560Time)
+	}
+	if isForkTimestampIncompatible(c.RIP7712Time, newcfg.RIP7712Time, headTimestamp, genesisTimestamp) {
+		return newTimestampCompatError("RIP7712 fork timestamp", c.RIP7712Time, newcfg.RIP7712Time)
+	}
+	// The NonceManager validated the RIP-7712 nonces since the fork
+	if !sameAddress(c.RIP7712NonceManager, newcfg.RIP7712NonceManager) {
+		if isBlockForked(c.RIP7712Block, headNumber) {
+			return newBlockCompatError("RIP7712 NonceManager address", c.RIP7712Block, newcfg.RIP7712Block)
+		}
+		if isTimestampForked(c.RIP7712Time, headTimestamp) {
+			return newTimestampCompatError("RIP7712 NonceManager address", c.RIP7712Time, newcfg.RIP7712Time)
+		}
+	}
+	if isForkTimestampIncompatible(c.RIP7711Time, newcfg.RIP7711Time, headTimestamp, genesisTimestamp) {
+		return newTimestampCompatError("RIP7711 fork timestamp", c.RIP7711Time, newcfg.RIP7711Time)
+	}
+	if err := rip7560RulesCompatError("RIP7560 penalty rule", c.RIP7560Penalties, newcfg.RIP7560Penalties, headNumber); err != nil {
+		return err
+	}
+	if err := rip7560RulesCompatError("RIP7560 gas penalty rule", c.RIP7560GasPenalties, newcfg.RIP7560GasPenalties, headNumber); err != nil {
+		return err
+	}
+	if err := rip7560RulesCompatError("RIP7560 EntryPoint ABI version rule", c.RIP7560AbiVersions, newcfg.RIP7560AbiVersions, headNumber); err != nil {
+		return err
+	}
+	return nil
+}
+
+// rip7560Rule is a rule of a RIP-7560 rule list, applying from its activation block on
+// until the next rule of the list activates.
+type rip7560Rule[T any] interface {
+	activation() *big.Int
+	sameAs(other T) bool
+}
+
+// rip7560RulesCompatError returns an error if the stored and new rule lists, ordered by
+// activation block, differ in a rule already activated at the head. The chain is rewound
+// to the first differing rule.
+func rip7560RulesCompatError[T rip7560Rule[T]](what string, stored, new []T, head *big.Int) *ConfigCompatError {
+	for i := 0; i < len(stored) || i < len(new); i++ {
+		var s1, s2 *big.Int
+		if i < len(stored) {
+			s1 = stored[i].activation()
+		}
+		if i < len(new) {
+			s2 = new[i].activation()
+		}
+		if isForkBlockIncompatible(s1, s2, head) {
+			return newBlockCompatError(what, s1, s2)
+		}
+		// The following rules activate after the head too
+		if !isBlockForked(s1, head) {
+			return nil
+		}
+		if !stored[i].sameAs(new[i]) {
+			return newBlockCompatError(what, s1, s2)
+		}
+	}
 	return nil
 }
 
@@ -1138,6 +1594,7 @@ type Rules struct {
 	IsOptimismBedrock, IsOptimismRegolith                   bool
 	IsOptimismCanyon, IsOptimismFjord                       bool
 	IsOptimismGranite, IsOptimismHolocene                   bool
+	IsRIP7560EIP7702                                        bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -1175,5 +1632,7 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsOptimismFjord:    isMerge && c.IsOptimismFjord(timestamp),
 		IsOptimismGranite:  isMerge && c.IsOptimismGranite(timestamp),
 		IsOptimismHolocene: isMerge && c.IsOptimismHolocene(timestamp),
+		// RIP-7560
+		IsRIP7560EIP7702: c.IsRIP7560EIP7702(num),
 	}
 }
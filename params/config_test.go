@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/stretchr/testify/require"
 )
@@ -144,6 +145,118 @@ func TestCheckCompatible(t *testing.T) {
 			genesisTimestamp: newUint64(24),
 			wantErr:          nil,
 		},
+		{
+			stored:    &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(10)},
+			new:       &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(20)},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7560 builder fee fork block",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(20),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(10)},
+			new:       &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(10), RIP7560BuilderFeeRecipient: &common.Address{0xb0}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7560 builder fee recipient",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(30)},
+			new:       &ChainConfig{RIP7560BuilderFeeBlock: big.NewInt(30), RIP7560BuilderFeeRecipient: &common.Address{0xb0}},
+			headBlock: 25,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{RIP7712Block: big.NewInt(10)},
+			new:       &ChainConfig{RIP7712Block: big.NewInt(10), RIP7712NonceManager: &common.Address{0x77}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7712 NonceManager address",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:        &ChainConfig{RIP7712Time: newUint64(10), RIP7712NonceManager: &common.Address{0x77}},
+			new:           &ChainConfig{RIP7712Time: newUint64(10)},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "RIP7712 NonceManager address",
+				StoredTime:   newUint64(10),
+				NewTime:      newUint64(10),
+				RewindToTime: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7712Block: big.NewInt(30)},
+			new:       &ChainConfig{RIP7712Block: big.NewInt(30), RIP7712NonceManager: &common.Address{0x77}},
+			headBlock: 25,
+			wantErr:   nil,
+		},
+		{
+			stored:        &ChainConfig{RIP7711Time: newUint64(10)},
+			new:           &ChainConfig{RIP7711Time: newUint64(20)},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "RIP7711 fork timestamp",
+				StoredTime:   newUint64(10),
+				NewTime:      newUint64(20),
+				RewindToTime: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7560Penalties: []*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyBurn}}},
+			new:       &ChainConfig{RIP7560Penalties: []*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyCoinbase}}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7560 penalty rule",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7560Penalties: []*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyBurn}}},
+			new:       &ChainConfig{RIP7560Penalties: []*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyBurn}, {Block: big.NewInt(30), Beneficiary: RIP7560PenaltyCoinbase}}},
+			headBlock: 25,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{RIP7560GasPenalties: []*RIP7560GasPenaltyRule{{Block: big.NewInt(10), Percent: 10}}},
+			new:       &ChainConfig{RIP7560GasPenalties: []*RIP7560GasPenaltyRule{{Block: big.NewInt(10), Percent: 20}}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7560 gas penalty rule",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{RIP7560GasPenalties: []*RIP7560GasPenaltyRule{{Block: big.NewInt(10), Percent: 10}}},
+			new:       &ChainConfig{RIP7560GasPenalties: []*RIP7560GasPenaltyRule{{Block: big.NewInt(10), Percent: 10}, {Block: big.NewInt(30), Percent: 20}}},
+			headBlock: 25,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{RIP7560AbiVersions: []*RIP7560AbiVersionRule{{Block: big.NewInt(10), Version: 0}}},
+			new:       &ChainConfig{},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "RIP7560 EntryPoint ABI version rule",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      nil,
+				RewindToBlock: 9,
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -211,3 +324,95 @@ func TestConfigRulesRegolith(t *testing.T) {
 		t.Errorf("expected %v to be regolith", stamp)
 	}
 }
+
+func TestCheckRIP7560Penalties(t *testing.T) {
+	vault := common.Address{0xfe, 0xe5}
+	tests := []struct {
+		rules []*RIP7560PenaltyRule
+		valid bool
+	}{
+		{nil, true},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: RIP7560PenaltyBurn}}, true},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: RIP7560PenaltyCoinbase}}, true},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: RIP7560PenaltyBurn}, {Block: big.NewInt(10), Beneficiary: RIP7560PenaltyVault, Vault: &vault}}, true},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: RIP7560PenaltyVault}}, false},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: RIP7560PenaltyBurn, Vault: &vault}}, false},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(0), Beneficiary: "sequencer"}}, false},
+		{[]*RIP7560PenaltyRule{{Beneficiary: RIP7560PenaltyBurn}}, false},
+		{[]*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyBurn}, {Block: big.NewInt(10), Beneficiary: RIP7560PenaltyCoinbase}}, false},
+	}
+	for i, tt := range tests {
+		config := *TestChainConfig
+		config.RIP7560Penalties = tt.rules
+		if err := config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: penalty rules %v validity mismatch: have %v, want valid %v", i, tt.rules, err, tt.valid)
+		}
+	}
+}
+
+func TestRIP7560Penalty(t *testing.T) {
+	config := *TestChainConfig
+	config.RIP7560Penalties = []*RIP7560PenaltyRule{{Block: big.NewInt(10), Beneficiary: RIP7560PenaltyBurn}, {Block: big.NewInt(20), Beneficiary: RIP7560PenaltyCoinbase}}
+	for num, want := range map[int64]string{9: "", 10: RIP7560PenaltyBurn, 19: RIP7560PenaltyBurn, 20: RIP7560PenaltyCoinbase} {
+		var have string
+		if rule := config.RIP7560Penalty(big.NewInt(num)); rule != nil {
+			have = rule.Beneficiary
+		}
+		if have != want {
+			t.Errorf("block %d: beneficiary mismatch: have %q, want %q", num, have, want)
+		}
+	}
+}
+
+func TestCheckRIP7560GasPenalties(t *testing.T) {
+	tests := []struct {
+		rules []*RIP7560GasPenaltyRule
+		valid bool
+	}{
+		{nil, true},
+		{[]*RIP7560GasPenaltyRule{{Block: big.NewInt(0), Percent: 10, Execution: true}, {Block: big.NewInt(10), Percent: 100, Validation: true}}, true},
+		{[]*RIP7560GasPenaltyRule{{Percent: 10}}, false},
+		{[]*RIP7560GasPenaltyRule{{Block: big.NewInt(10), Percent: 10}, {Block: big.NewInt(10), Percent: 20}}, false},
+		{[]*RIP7560GasPenaltyRule{{Block: big.NewInt(0), Percent: 101}}, false},
+	}
+	for i, tt := range tests {
+		config := *TestChainConfig
+		config.RIP7560GasPenalties = tt.rules
+		if err := config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: penalty rules %v validity mismatch: have %v, want valid %v", i, tt.rules, err, tt.valid)
+		}
+	}
+}
+
+func TestRIP7560AbiVersion(t *testing.T) {
+	config := *TestChainConfig
+	if have := config.RIP7560AbiVersion(big.NewInt(100)); have != 0 {
+		t.Errorf("default version mismatch: have %d, want 0", have)
+	}
+	config.RIP7560AbiVersions = []*RIP7560AbiVersionRule{{Block: big.NewInt(10), Version: 1}, {Block: big.NewInt(20), Version: 2}}
+	for num, want := range map[int64]uint64{9: 0, 10: 1, 19: 1, 20: 2} {
+		if have := config.RIP7560AbiVersion(big.NewInt(num)); have != want {
+			t.Errorf("block %d: version mismatch: have %d, want %d", num, have, want)
+		}
+	}
+}
+
+func TestCheckRIP7560AbiVersions(t *testing.T) {
+	tests := []struct {
+		rules []*RIP7560AbiVersionRule
+		valid bool
+	}{
+		{nil, true},
+		{[]*RIP7560AbiVersionRule{{Block: big.NewInt(0), Version: 0}, {Block: big.NewInt(10), Version: 0}}, true},
+		{[]*RIP7560AbiVersionRule{{Version: 0}}, false},
+		{[]*RIP7560AbiVersionRule{{Block: big.NewInt(10), Version: 0}, {Block: big.NewInt(10), Version: 0}}, false},
+		{[]*RIP7560AbiVersionRule{{Block: big.NewInt(10), Version: 1}}, false},
+	}
+	for i, tt := range tests {
+		config := *TestChainConfig
+		config.RIP7560AbiVersions = tt.rules
+		if err := config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: abi version rules validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
@@ -335,6 +335,36 @@ var Forks = map[string]*params.ChainConfig{
 		ShanghaiTime:            u64(0),
 		CancunTime:              u64(0),
 	},
+	"CancunRIP7560": {
+		ChainID:                   big.NewInt(1),
+		HomesteadBlock:            big.NewInt(0),
+		EIP150Block:               big.NewInt(0),
+		EIP155Block:               big.NewInt(0),
+		EIP158Block:               big.NewInt(0),
+		ByzantiumBlock:            big.NewInt(0),
+		ConstantinopleBlock:       big.NewInt(0),
+		PetersburgBlock:           big.NewInt(0),
+		IstanbulBlock:             big.NewInt(0),
+		MuirGlacierBlock:          big.NewInt(0),
+		BerlinBlock:               big.NewInt(0),
+		LondonBlock:               big.NewInt(0),
+		ArrowGlacierBlock:         big.NewInt(0),
+		MergeNetsplitBlock:        big.NewInt(0),
+		TerminalTotalDifficulty:   big.NewInt(0),
+		ShanghaiTime:              u64(0),
+		CancunTime:                u64(0),
+		BedrockBlock:              big.NewInt(0),
+		RegolithTime:              u64(0),
+		CanyonTime:                u64(0),
+		RIP7560Block:              big.NewInt(0),
+		RIP7560ActualGasCostBlock: big.NewInt(0),
+		RIP7560ReceiptRootBlock:   big.NewInt(0),
+		Optimism: &params.OptimismConfig{
+			EIP1559Elasticity:        6,
+			EIP1559Denominator:       50,
+			EIP1559DenominatorCanyon: u64(250),
+		},
+	},
 	"ShanghaiToCancunAtTime15k": {
 		ChainID:                 big.NewInt(1),
 		HomesteadBlock:          big.NewInt(0),
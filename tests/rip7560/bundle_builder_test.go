@@ -0,0 +1,239 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+// senderTx returns a well-formed transaction of the sender, with distinct execution data.
+func (f *bundleFuzzer) senderTx(sender common.Address, nonce uint64, data byte) *types.Transaction {
+	return types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:            f.config.ChainID,
+		Sender:             &sender,
+		NonceKey:           new(big.Int),
+		Nonce:              nonce,
+		ValidationGasLimit: 1_000_000,
+		Gas:                100_000,
+		GasTipCap:          big.NewInt(1),
+		GasFeeCap:          big.NewInt(params.GWei * 10),
+		BuilderFee:         new(big.Int),
+		ExecutionData:      []byte{data},
+	})
+}
+
+// Tests that the block builder merges the bundles targeting the same block, skipping
+// the ones conflicting with the bundles included before them.
+func TestMergeRip7560Bundles(t *testing.T) {
+	var (
+		f         = newBundleFuzzer(t)
+		next      = new(big.Int).Add(f.chain.CurrentBlock().Number, common.Big1)
+		first     = f.senderTx(fuzzSender, 0, 1)
+		conflict  = f.senderTx(fuzzSender, 0, 2)
+		following = f.senderTx(fuzzSender, 1, 3)
+	)
+	for i, txs := range []types.Transactions{{first}, {conflict}, {following}} {
+		bundle := &types.ExternallyReceivedBundle{
+			BundleHash:    common.BigToHash(big.NewInt(int64(i + 1))),
+			ValidForBlock: next,
+			Transactions:  txs,
+		}
+		if err := f.pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle %d: %v", i, err)
+		}
+	}
+	block := f.buildBlock()
+
+	var included []common.Hash
+	for _, tx := range block.Transactions() {
+		if tx.Type() == types.Rip7560Type {
+			included = append(included, tx.Hash())
+		}
+	}
+	want := []common.Hash{first.Hash(), following.Hash()}
+	if len(included) != len(want) {
+		t.Fatalf("included transactions mismatch: have %x, want %x", included, want)
+	}
+	for i := range want {
+		if included[i] != want[i] {
+			t.Fatalf("included transaction %d mismatch: have %x, want %x", i, included[i], want[i])
+		}
+	}
+}
+
+// Tests that the block builder resolves the nonce conflicts of a bundle with the bundles
+// included before it according to the inclusion mode.
+func TestRip7560BundleNonceConflicts(t *testing.T) {
+	tests := []struct {
+		mode     miner.Rip7560BundleMode
+		included int // Number of transactions of the conflicting bundle included
+	}{
+		{miner.Rip7560BundlesSkip, 1},
+		{miner.Rip7560BundlesAtomic, 0},
+		{miner.Rip7560BundlesPartial, 0},
+	}
+	for _, tt := range tests {
+		f := newBundleFuzzer(t)
+		config := miner.DefaultConfig
+		config.GasCeil = fuzzGasLimit
+		config.Rip7560Bundles = tt.mode
+		f.miner = miner.New(f, config, f.chain.Engine())
+
+		// The first bundle tips more, so that it is included before the conflicting one
+		data := *f.senderTx(fuzzSender, 0, 1).Rip7560TransactionData()
+		data.GasTipCap = big.NewInt(100)
+		var (
+			next      = new(big.Int).Add(f.chain.CurrentBlock().Number, common.Big1)
+			first     = types.NewTx(&data)
+			conflict  = f.senderTx(fuzzSender, 0, 2)
+			following = f.senderTx(fuzzSender, 1, 3)
+		)
+		for i, txs := range []types.Transactions{{first}, {conflict, following}} {
+			bundle := &types.ExternallyReceivedBundle{
+				BundleHash:    common.BigToHash(big.NewInt(int64(i + 1))),
+				ValidForBlock: next,
+				Transactions:  txs,
+			}
+			if err := f.pool.SubmitRip7560Bundle(bundle); err != nil {
+				t.Fatalf("%s: failed to submit bundle %d: %v", tt.mode, i, err)
+			}
+		}
+		want := []common.Hash{first.Hash()}
+		if tt.included > 0 {
+			want = append(want, following.Hash())
+		}
+		var included []common.Hash
+		for _, tx := range f.buildBlock().Transactions() {
+			if tx.Type() == types.Rip7560Type {
+				included = append(included, tx.Hash())
+			}
+		}
+		if !slices.Equal(included, want) {
+			t.Errorf("%s: included transactions mismatch: have %x, want %x", tt.mode, included, want)
+		}
+	}
+}
+
+// Tests that the block builder includes the valid prefix of a bundle in partial inclusion
+// mode, and that the bundle receipt records the dropped suffix.
+func TestPartialRip7560Bundle(t *testing.T) {
+	f := newBundleFuzzer(t)
+	config := miner.DefaultConfig
+	config.GasCeil = fuzzGasLimit
+	config.Rip7560Bundles = miner.Rip7560BundlesPartial
+	f.miner = miner.New(f, config, f.chain.Engine())
+
+	var (
+		valid   = f.senderTx(fuzzSender, 0, 1)
+		invalid = f.senderTx(fuzzRejecting, 0, 2)
+		after   = f.senderTx(fuzzSender, 1, 3)
+		bundle  = &types.ExternallyReceivedBundle{
+			BundleHash:    common.HexToHash("0x01"),
+			ValidForBlock: new(big.Int).Add(f.chain.CurrentBlock().Number, common.Big1),
+			Transactions:  types.Transactions{valid, invalid, after},
+		}
+	)
+	if err := f.pool.SubmitRip7560Bundle(bundle); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	block := f.buildBlock()
+
+	var included []common.Hash
+	for _, tx := range block.Transactions() {
+		if tx.Type() == types.Rip7560Type {
+			included = append(included, tx.Hash())
+		}
+	}
+	if len(included) != 1 || included[0] != valid.Hash() {
+		t.Fatalf("included transactions mismatch: have %x, want %x", included, []common.Hash{valid.Hash()})
+	}
+	receipt, err := f.pool.GetRip7560BundleStatus(bundle.BundleHash)
+	if err != nil {
+		t.Fatalf("failed to get bundle status: %v", err)
+	}
+	if receipt == nil || receipt.Status != types.BundleStatusIncluded || receipt.Count != 1 {
+		t.Fatalf("bundle receipt mismatch: have %+v, want included with 1 transaction", receipt)
+	}
+	if want := []common.Hash{invalid.Hash(), after.Hash()}; !slices.Equal(receipt.DroppedTransactions, want) {
+		t.Fatalf("dropped transactions mismatch: have %x, want %x", receipt.DroppedTransactions, want)
+	}
+	deadline := time.Now().Add(fuzzTimeout)
+	for !f.terminal(after.Hash()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no final status reported for the dropped transaction %x", after.Hash())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that the block builder includes the highest paying bundles first when the block
+// gas cannot fit all the bundles targeting it, instead of the first submitted ones.
+func TestRip7560BundlePriority(t *testing.T) {
+	var (
+		f    = newBundleFuzzer(t)
+		head = f.chain.CurrentBlock().Number
+	)
+	tx := func(sender common.Address, tip int64) *types.Transaction {
+		aatx := &types.Rip7560AccountAbstractionTx{
+			ChainID:            f.config.ChainID,
+			Sender:             &sender,
+			NonceKey:           new(big.Int),
+			ValidationGasLimit: 1_000_000,
+			Gas:                27_000_000,
+			GasTipCap:          big.NewInt(tip),
+			GasFeeCap:          big.NewInt(params.GWei * 10),
+			BuilderFee:         new(big.Int),
+		}
+		if sender == fuzzUnfunded {
+			aatx.Paymaster = &fuzzPaymaster
+			aatx.PaymasterValidationGasLimit = 100_000
+			aatx.PostOpGas = 100_000
+		}
+		return types.NewTx(aatx)
+	}
+	// The bundles are sent by distinct senders not to conflict, both only fit in the
+	// block alone, and target distinct blocks so that their gas is not reserved in the
+	// same one
+	var (
+		cheap = &types.ExternallyReceivedBundle{
+			BundleHash:      common.HexToHash("0x01"),
+			ValidForBlock:   new(big.Int).Add(head, big.NewInt(1)),
+			ValidUntilBlock: new(big.Int).Add(head, big.NewInt(2)),
+			Transactions:    types.Transactions{tx(fuzzSender, 1)},
+		}
+		expensive = &types.ExternallyReceivedBundle{
+			BundleHash:    common.HexToHash("0x02"),
+			ValidForBlock: new(big.Int).Add(head, big.NewInt(2)),
+			Transactions:  types.Transactions{tx(fuzzUnfunded, 2)},
+		}
+	)
+	for _, bundle := range []*types.ExternallyReceivedBundle{cheap, expensive} {
+		if err := f.pool.SubmitRip7560Bundle(bundle); err != nil {
+			t.Fatalf("failed to submit bundle %x: %v", bundle.BundleHash, err)
+		}
+	}
+	// Leave the cheap bundle pending in the first block, for both to compete for the second
+	builder := f.miner
+	config := miner.DefaultConfig
+	config.GasCeil = fuzzGasLimit
+	config.Rip7560RelayOnly = true
+	f.miner = miner.New(f, config, f.chain.Engine())
+	f.buildBlock()
+	f.miner = builder
+
+	block := f.buildBlock()
+	var included []common.Hash
+	for _, tx := range block.Transactions() {
+		if tx.Type() == types.Rip7560Type {
+			included = append(included, tx.Hash())
+		}
+	}
+	if want := expensive.Transactions[0].Hash(); len(included) != 1 || included[0] != want {
+		t.Fatalf("included transactions mismatch: have %x, want %x", included, []common.Hash{want})
+	}
+}
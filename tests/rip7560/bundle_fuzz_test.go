@@ -0,0 +1,420 @@
+package rip7560
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"math"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The accounts predeployed in the genesis of the bundle fuzzer.
+var (
+	fuzzSender    = common.HexToAddress("0x7560000000000000000000000000000000000f01") // accepts any transaction
+	fuzzRejecting = common.HexToAddress("0x7560000000000000000000000000000000000f02") // reverts its validation
+	fuzzSilent    = common.HexToAddress("0x7560000000000000000000000000000000000f03") // returns without calling the EntryPoint
+	fuzzSigFail   = common.HexToAddress("0x7560000000000000000000000000000000000f04") // rejects the signature of any transaction
+	fuzzExpired   = common.HexToAddress("0x7560000000000000000000000000000000000f05") // accepts transactions until timestamp 1
+	fuzzUnfunded  = common.HexToAddress("0x7560000000000000000000000000000000000f06") // accepts any transaction, has no funds
+	fuzzNoCode    = common.HexToAddress("0x7560000000000000000000000000000000000f07") // funded, has no code
+
+	fuzzPaymaster          = common.HexToAddress("0x7560000000000000000000000000000000000f11") // sponsors any transaction, with a postOp frame
+	fuzzPaymasterRejecting = common.HexToAddress("0x7560000000000000000000000000000000000f12") // reverts its validation
+	fuzzPaymasterPostOp    = common.HexToAddress("0x7560000000000000000000000000000000000f13") // sponsors any transaction, reverts its postOp frame
+	fuzzPaymasterUnfunded  = common.HexToAddress("0x7560000000000000000000000000000000000f14") // sponsors any transaction, has no funds
+
+	fuzzDeployed = create2_addr(DEPLOYER, createAccountCode())
+
+	fuzzSenders    = []common.Address{fuzzSender, fuzzRejecting, fuzzSilent, fuzzSigFail, fuzzExpired, fuzzUnfunded, fuzzNoCode, fuzzDeployed}
+	fuzzPaymasters = []common.Address{fuzzPaymaster, fuzzPaymasterRejecting, fuzzPaymasterPostOp, fuzzPaymasterUnfunded, fuzzNoCode}
+)
+
+const (
+	fuzzGasLimit    = 30_000_000
+	fuzzMaxBundles  = 4
+	fuzzMaxBundleTx = 5
+	fuzzTimeout     = 10 * time.Second
+)
+
+// fuzzInput draws the fields of the generated bundles from the fuzzer input, reading
+// zeroes once it is exhausted.
+type fuzzInput struct {
+	data []byte
+}
+
+func (in *fuzzInput) byte() byte {
+	if len(in.data) == 0 {
+		return 0
+	}
+	b := in.data[0]
+	in.data = in.data[1:]
+	return b
+}
+
+func (in *fuzzInput) uint64() uint64 {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = in.byte()
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (in *fuzzInput) bytes() []byte {
+	buf := make([]byte, in.byte()%64)
+	for i := range buf {
+		buf[i] = in.byte()
+	}
+	return buf
+}
+
+// choose returns a value in [0, n).
+func (in *fuzzInput) choose(n int) int {
+	return int(in.byte()) % n
+}
+
+// gas returns a gas limit which is valid most of the time, and borderline otherwise.
+func (in *fuzzInput) gas(valid uint64) uint64 {
+	switch in.choose(8) {
+	case 0:
+		return 0
+	case 1:
+		return 1
+	case 2:
+		return fuzzGasLimit
+	case 3:
+		return math.MaxUint64
+	case 4:
+		return in.uint64()
+	default:
+		return valid
+	}
+}
+
+// fee returns a fee which is valid most of the time, and borderline or missing otherwise.
+func (in *fuzzInput) fee(valid int64) *big.Int {
+	switch in.choose(8) {
+	case 0:
+		return nil
+	case 1:
+		return new(big.Int)
+	case 2:
+		return new(big.Int).Lsh(common.Big1, 256)
+	case 3:
+		return new(big.Int).SetUint64(in.uint64())
+	default:
+		return big.NewInt(valid)
+	}
+}
+
+// address returns one of the given addresses most of the time, and a missing or
+// unknown one otherwise.
+func (in *fuzzInput) address(addrs []common.Address) *common.Address {
+	switch in.choose(16) {
+	case 0:
+		return nil
+	case 1:
+		return &common.Address{}
+	default:
+		addr := addrs[in.choose(len(addrs))]
+		return &addr
+	}
+}
+
+// bundleFuzzer feeds randomly generated bundles to the block builder through the
+// bundler pool, and checks the blocks it produces and the statuses reported for
+// the transactions of the bundles.
+type bundleFuzzer struct {
+	t      *testing.T
+	config *params.ChainConfig
+	chain  *core.BlockChain
+	pool   *txpool.TxPool
+	miner  *miner.Miner
+
+	mu       sync.Mutex
+	statuses map[common.Hash][]core.Rip7560TxStatus
+	nonces   map[common.Address]uint64
+}
+
+func (f *bundleFuzzer) BlockChain() *core.BlockChain { return f.chain }
+func (f *bundleFuzzer) TxPool() *txpool.TxPool       { return f.pool }
+
+func newBundleFuzzer(t *testing.T) *bundleFuzzer {
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	balance := new(big.Int).Lsh(common.Big1, 100)
+	genesis := &core.Genesis{
+		Config:     config,
+		GasLimit:   fuzzGasLimit,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: new(big.Int),
+		Alloc: types.GenesisAlloc{
+			fuzzSender:    {Code: createAccountCode(), Balance: balance},
+			fuzzRejecting: {Code: revertWithData([]byte{1, 2, 3}), Balance: balance},
+			fuzzSilent:    {Code: returnWithData([]byte{}), Balance: balance},
+			fuzzSigFail:   {Code: sigFailAccountCode(), Balance: balance},
+			fuzzExpired:   {Code: acceptAccountCode(0, 1), Balance: balance},
+			fuzzUnfunded:  {Code: createAccountCode(), Balance: new(big.Int)},
+			fuzzNoCode:    {Balance: balance},
+			fuzzDeployed:  {Balance: balance},
+			DEPLOYER:      {Code: createCode(create2(createAccountCode()), returnWithData([]byte{})), Balance: new(big.Int)},
+
+			fuzzPaymaster:          {Code: paymasterCode(0, 0, []byte{1}, createCode(vm.STOP)), Balance: balance},
+			fuzzPaymasterRejecting: {Code: revertWithData([]byte{}), Balance: balance},
+			fuzzPaymasterPostOp:    {Code: paymasterCode(0, 0, []byte{1}, revertWithData([]byte{})), Balance: balance},
+			fuzzPaymasterUnfunded:  {Code: paymasterCode(0, 0, []byte{}, nil), Balance: new(big.Int)},
+		},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	poolConfig := legacypool.DefaultConfig
+	poolConfig.Journal = ""
+	pool, err := txpool.New(poolConfig.PriceLimit, chain, []txpool.SubPool{
+		legacypool.New(poolConfig, chain),
+		rip7560pool.New(rip7560pool.Config{}, chain, common.Address{}),
+	})
+	if err != nil {
+		t.Fatalf("failed to create tx pool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	f := &bundleFuzzer{
+		t:        t,
+		config:   config,
+		chain:    chain,
+		pool:     pool,
+		statuses: make(map[common.Hash][]core.Rip7560TxStatus),
+		nonces:   make(map[common.Address]uint64),
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 256)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	go func() {
+		for ev := range events {
+			f.mu.Lock()
+			f.statuses[ev.TxHash] = append(f.statuses[ev.TxHash], ev.Status)
+			f.mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		sub.Unsubscribe()
+		close(events)
+	})
+
+	minerConfig := miner.DefaultConfig
+	minerConfig.GasCeil = fuzzGasLimit
+	f.miner = miner.New(f, minerConfig, chain.Engine())
+	return f
+}
+
+// tx generates a transaction, well-formed and valid most of the time.
+func (f *bundleFuzzer) tx(in *fuzzInput) *types.Transaction {
+	aatx := &types.Rip7560AccountAbstractionTx{
+		ChainID:            f.config.ChainID,
+		Sender:             in.address(fuzzSenders),
+		NonceKey:           new(big.Int),
+		ValidationGasLimit: in.gas(1_000_000),
+		Gas:                in.gas(100_000),
+		GasTipCap:          in.fee(1),
+		GasFeeCap:          in.fee(params.GWei * 10),
+		BuilderFee:         in.fee(0),
+		ExecutionData:      in.bytes(),
+		AuthorizationData:  in.bytes(),
+	}
+	if aatx.Sender != nil {
+		aatx.Nonce = f.nonces[*aatx.Sender]
+		switch in.choose(8) {
+		case 0:
+			aatx.Nonce++
+		case 1:
+			aatx.Nonce--
+		default:
+			f.nonces[*aatx.Sender]++
+		}
+	}
+	switch in.choose(8) {
+	case 0:
+		aatx.ChainID = nil
+	case 1:
+		aatx.ChainID = big.NewInt(int64(in.byte()))
+	}
+	switch in.choose(8) {
+	case 0:
+		aatx.NonceKey = nil
+	case 1:
+		aatx.NonceKey = new(big.Int).SetUint64(in.uint64())
+	}
+	if in.choose(2) == 0 {
+		aatx.Paymaster = in.address(fuzzPaymasters)
+		aatx.PaymasterData = in.bytes()
+		aatx.PaymasterValidationGasLimit = in.gas(1_000_000)
+		aatx.PostOpGas = in.gas(100_000)
+	}
+	if (aatx.Sender != nil && *aatx.Sender == fuzzDeployed) || in.choose(8) == 0 {
+		aatx.Deployer = in.address([]common.Address{DEPLOYER, fuzzSender})
+		aatx.DeployerData = in.bytes()
+	}
+	return types.NewTx(aatx)
+}
+
+// submitBundle generates a bundle valid for one of the next blocks and submits it to
+// the pool. Bundles rejected for exceeding the gas left unreserved in their target
+// block, or for invalid gas values, are not reported.
+func (f *bundleFuzzer) submitBundle(in *fuzzInput, index int) *types.ExternallyReceivedBundle {
+	head := f.chain.CurrentBlock().Number
+	bundle := &types.ExternallyReceivedBundle{
+		BundlerId:     fmt.Sprintf("fuzzer-%d", index),
+		BundleHash:    common.BigToHash(big.NewInt(int64(index + 1))),
+		ValidForBlock: new(big.Int).Add(head, big.NewInt(int64(1+in.choose(2)))),
+	}
+	if in.choose(2) == 0 {
+		bundle.ValidUntilBlock = new(big.Int).Add(bundle.ValidForBlock, big.NewInt(int64(in.choose(3))))
+	}
+	for i := 0; i < 1+in.choose(fuzzMaxBundleTx); i++ {
+		bundle.Transactions = append(bundle.Transactions, f.tx(in))
+	}
+	if err := f.pool.SubmitRip7560Bundle(bundle); err != nil {
+		if errors.Is(err, rip7560pool.ErrBlockGasReserved) || errors.Is(err, rip7560pool.ErrInvalidBundleGas) || errors.Is(err, types.ErrInvalidChainId) {
+			return nil
+		}
+		f.t.Fatalf("failed to submit bundle: %v", err)
+	}
+	return bundle
+}
+
+// buildBlock makes the miner build a block on top of the head, checks that it is valid
+// by importing it, and returns it.
+func (f *bundleFuzzer) buildBlock() *types.Block {
+	head := f.chain.CurrentBlock()
+	deposit := types.NewTx(&types.DepositTx{
+		SourceHash: common.BigToHash(new(big.Int).Add(head.Number, common.Big1)),
+		From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+		To:         &types.L1BlockAddr,
+		Gas:        1_000_000,
+		Data:       make([]byte, 4+32*8),
+	})
+	var (
+		gasLimit   = uint64(fuzzGasLimit)
+		beaconRoot = common.Hash{}
+	)
+	payload, err := f.miner.BuildPayload(&miner.BuildPayloadArgs{
+		Parent:       head.Hash(),
+		Timestamp:    head.Time + 1,
+		Withdrawals:  types.Withdrawals{},
+		BeaconRoot:   &beaconRoot,
+		Transactions: types.Transactions{deposit},
+		GasLimit:     &gasLimit,
+	})
+	if err != nil {
+		f.t.Fatalf("failed to start building the payload: %v", err)
+	}
+	envelope := payload.ResolveFull()
+	if envelope == nil {
+		f.t.Fatalf("no full payload built on block %d", head.Number)
+	}
+	block, err := engine.ExecutableDataToBlock(*envelope.ExecutionPayload, []common.Hash{}, &beaconRoot)
+	if err != nil {
+		f.t.Fatalf("failed to decode the payload: %v", err)
+	}
+	if _, err := f.chain.InsertChain(types.Blocks{block}); err != nil {
+		f.t.Fatalf("built an invalid block %d: %v", block.NumberU64(), err)
+	}
+	if err := f.pool.Sync(); err != nil {
+		f.t.Fatalf("failed to sync the pool: %v", err)
+	}
+	return block
+}
+
+// terminal reports whether a final status was reported for the transaction.
+func (f *bundleFuzzer) terminal(hash common.Hash) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, status := range f.statuses[hash] {
+		if status == core.Rip7560TxIncluded || status == core.Rip7560TxDropped || status == core.Rip7560TxExpired {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *bundleFuzzer) run(data []byte) {
+	var (
+		in      = &fuzzInput{data: data}
+		bundled = make(map[common.Hash]*types.ExternallyReceivedBundle)
+		txs     types.Transactions
+	)
+	for i := 0; i < 1+in.choose(fuzzMaxBundles); i++ {
+		if bundle := f.submitBundle(in, i); bundle != nil {
+			for _, tx := range bundle.Transactions {
+				bundled[tx.Hash()] = bundle
+				txs = append(txs, tx)
+			}
+		}
+	}
+	// Every bundle is valid for at most the next 4 blocks, so that all of them have been
+	// either included or dropped after building 5
+	for i := 0; i < 5; i++ {
+		block := f.buildBlock()
+		for j, tx := range block.Transactions() {
+			if tx.Type() != types.Rip7560Type {
+				continue
+			}
+			bundle := bundled[tx.Hash()]
+			if bundle == nil {
+				f.t.Fatalf("block %d: transaction %d (%x) is not part of any bundle", block.NumberU64(), j, tx.Hash())
+			}
+			if !bundle.IsValidFor(block.Number()) {
+				f.t.Fatalf("block %d: transaction %d (%x) included outside of its bundle window [%v, %v]", block.NumberU64(), j, tx.Hash(), bundle.ValidForBlock, bundle.LastValidBlock())
+			}
+		}
+	}
+	deadline := time.Now().Add(fuzzTimeout)
+	for _, tx := range txs {
+		for !f.terminal(tx.Hash()) {
+			if time.Now().After(deadline) {
+				f.mu.Lock()
+				statuses := f.statuses[tx.Hash()]
+				f.mu.Unlock()
+				f.t.Fatalf("no final status reported for transaction %x, have %v", tx.Hash(), statuses)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func FuzzRip7560Bundles(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff})
+	rng := rand.New(rand.NewSource(7560))
+	for i := 0; i < 16; i++ {
+		data := make([]byte, 32+rng.Intn(512))
+		rng.Read(data)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		newBundleFuzzer(t).run(data)
+	})
+}
@@ -1,88 +1,135 @@
 package rip7560
 
 import (
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
 )
 
-var DEPLOYER = common.HexToAddress("0xddddddddddeeeeeeeeeeddddddddddeeeeeeeeee")
-
 func TestValidationFailure_deployerRevert(t *testing.T) {
+	sender := create2_addr(DEPLOYER, createAccountCode())
 	handleTransaction(newTestContextBuilder(t).
-		withCode(DEFAULT_SENDER, []byte{}, DEFAULT_BALANCE).
+		withCode(sender.Hex(), []byte{}, DEFAULT_BALANCE).
 		withCode(DEPLOYER.Hex(), revertWithData([]byte{}), 0),
 		types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1000000000,
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "account deployment failed: execution reverted")
+		}, "validation phase failed in contract deployer with exception: execution reverted")
 }
 
 func TestValidationFailure_deployerOOG(t *testing.T) {
+	accountCode := createAccountCode()
+	sender := create2_addr(DEPLOYER, accountCode)
+	handleTransaction(newTestContextBuilder(t).withDeployer(accountCode, DEFAULT_BALANCE),
+		types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
+			Deployer:           &DEPLOYER,
+			ValidationGasLimit: 40000,
+			GasFeeCap:          big.NewInt(1000000000),
+		}, "validation phase failed in contract deployer with exception: out of gas")
+}
+
+func TestValidationFailure_deployerNoCode(t *testing.T) {
+	sender := create2_addr(DEPLOYER, createAccountCode())
 	handleTransaction(newTestContextBuilder(t).
-		withCode(DEFAULT_SENDER, []byte{}, DEFAULT_BALANCE).
-		withCode(DEPLOYER.Hex(), revertWithData([]byte{}), 0),
+		withCode(sender.Hex(), []byte{}, DEFAULT_BALANCE),
 		types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1,
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "account deployment failed: out of gas")
+		}, "validation phase failed with exception: deployer address "+DEPLOYER.String()+" is provided but contract has no code deployed")
 }
 
 func TestValidationFailure_senderNotDeployed(t *testing.T) {
+	sender := create2_addr(DEPLOYER, createAccountCode())
 	handleTransaction(newTestContextBuilder(t).
-		withCode(DEFAULT_SENDER, []byte{}, DEFAULT_BALANCE).
+		withCode(sender.Hex(), []byte{}, DEFAULT_BALANCE).
 		withCode(DEPLOYER.Hex(), returnWithData([]byte{}), 0),
 		types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1000000000,
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "account deployment failed: sender not deployed")
+		}, "validation phase failed with exception: sender not deployed by the deployer, sender:"+sender.String()+" deployer:"+DEPLOYER.String())
 }
 
 func TestValidationFailure_senderAlreadyDeployed(t *testing.T) {
-	accountCode := revertWithData([]byte{})
-	deployerCode := create2(accountCode)
+	accountCode := createAccountCode()
 	sender := create2_addr(DEPLOYER, accountCode)
 	handleTransaction(newTestContextBuilder(t).
-		withCode(sender.Hex(), accountCode, DEFAULT_BALANCE).
-		withCode(DEPLOYER.Hex(), deployerCode, 0),
+		withDeployer(accountCode, 0).
+		withCode(sender.Hex(), accountCode, DEFAULT_BALANCE),
 		types.Rip7560AccountAbstractionTx{
 			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1000000000,
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "account deployment failed: sender already deployed")
+		}, "validation phase failed with exception: sender address "+sender.String()+" and deployer address "+DEPLOYER.String()+" are provided but sender is already deployed")
 }
 
 func TestValidationFailure_senderReverts(t *testing.T) {
 	accountCode := revertWithData([]byte{})
-	deployerCode := createCode(create2(accountCode), returnWithData([]byte{}))
 	sender := create2_addr(DEPLOYER, accountCode)
-	handleTransaction(newTestContextBuilder(t).
-		withCode(sender.Hex(), []byte{}, DEFAULT_BALANCE).
-		withCode(DEPLOYER.Hex(), deployerCode, 0),
+	handleTransaction(newTestContextBuilder(t).withDeployer(accountCode, DEFAULT_BALANCE),
 		types.Rip7560AccountAbstractionTx{
 			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1000000000,
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "execution reverted")
+		}, "validation phase failed in contract account with exception: execution reverted")
 }
 
 func TestValidation_deployer_ok(t *testing.T) {
 	accountCode := createAccountCode()
-	deployerCode := createCode(create2(accountCode), returnWithData([]byte{}))
 	sender := create2_addr(DEPLOYER, accountCode)
-	handleTransaction(newTestContextBuilder(t).
-		withCode(sender.Hex(), []byte{}, DEFAULT_BALANCE).
-		withCode(DEPLOYER.Hex(), deployerCode, 0),
+	_, tracer, err := runTransactions(newTestContextBuilder(t).withDeployer(accountCode, DEFAULT_BALANCE),
+		types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
+			Deployer:           &DEPLOYER,
+			ValidationGasLimit: 1000000,
+			GasFeeCap:          big.NewInt(1000000000),
+		})
+	assert.NoError(t, err)
+	tracer.AssertPhases(t, PhaseDeployer, PhaseAccount, PhaseExecution)
+}
+
+func TestValidation_deployer_with_paymaster(t *testing.T) {
+	// the deployed account does not pay for its own deployment
+	accountCode := createAccountCode()
+	sender := create2_addr(DEPLOYER, accountCode)
+	_, tracer, err := runTransactions(newTestContextBuilder(t).
+		withDeployer(accountCode, 0).
+		withPaymaster(paymasterCode(0, 0, []byte{1}, nil), DEFAULT_BALANCE),
+		types.Rip7560AccountAbstractionTx{
+			Sender:                      &sender,
+			Deployer:                    &DEPLOYER,
+			ValidationGasLimit:          1000000,
+			Paymaster:                   &DEFAULT_PAYMASTER,
+			PaymasterValidationGasLimit: 1000000,
+			PostOpGas:                   100000,
+			GasFeeCap:                   big.NewInt(1000000000),
+		})
+	assert.NoError(t, err)
+	tracer.AssertPhases(t, PhaseDeployer, PhaseAccount, PhasePaymaster, PhaseExecution, PhasePostOp)
+}
+
+func TestValidation_deployer_2d_nonce(t *testing.T) {
+	accountCode := createAccountCode()
+	sender := create2_addr(DEPLOYER, accountCode)
+	_, tracer, err := runTransactions(newTestContextBuilder(t).
+		withDeployer(accountCode, DEFAULT_BALANCE).
+		withNonceManager(),
 		types.Rip7560AccountAbstractionTx{
 			Sender:             &sender,
 			Deployer:           &DEPLOYER,
-			ValidationGasLimit: 1000000000,
+			NonceKey:           big.NewInt(1),
+			ValidationGasLimit: 1000000,
 			GasFeeCap:          big.NewInt(1000000000),
-		}, "ok")
+		})
+	assert.NoError(t, err)
+	tracer.AssertPhases(t, PhaseNonce, PhaseDeployer, PhaseAccount, PhaseExecution)
 }
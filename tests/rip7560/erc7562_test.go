@@ -0,0 +1,269 @@
+package rip7560
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var (
+	// erc7562Storage reads its own storage slot 0
+	erc7562Storage = common.HexToAddress("0x7562000000000000000000000000000000000001")
+	// erc7562Sink is a contract that does nothing
+	erc7562Sink = common.HexToAddress("0x7562000000000000000000000000000000000002")
+	// erc7562NoCode is an address without code
+	erc7562NoCode = common.HexToAddress("0x7562000000000000000000000000000000000003")
+	// erc7562Mapping reads the entry of its caller in a solidity mapping at slot 0
+	erc7562Mapping = common.HexToAddress("0x7562000000000000000000000000000000000004")
+)
+
+// erc7562Trace is the part of the rip7560Validation tracer result the rules are checked against.
+type erc7562Trace struct {
+	CallsFromEntryPoint []struct {
+		TopLevelTargetAddress common.Address `json:"topLevelTargetAddress"`
+		Access                map[common.Address]struct {
+			Reads           map[string]string `json:"reads"`
+			Writes          map[string]uint64 `json:"writes"`
+			TransientReads  map[string]uint64 `json:"transientReads"`
+			TransientWrites map[string]uint64 `json:"transientWrites"`
+		} `json:"access"`
+		Opcodes           map[string]uint64         `json:"opcodes"`
+		ExtCodeAccessInfo map[common.Address]string `json:"extCodeAccessInfo"`
+		ContractSize      map[common.Address]struct {
+			ContractSize int    `json:"contractSize"`
+			Opcode       string `json:"opcode"`
+		} `json:"contractSize"`
+		OOG     bool            `json:"oog"`
+		Keccak  []hexutil.Bytes `json:"keccak"`
+		Create2 []struct {
+			From         common.Address `json:"from"`
+			Salt         common.Hash    `json:"salt"`
+			InitCodeHash common.Hash    `json:"initCodeHash"`
+			Address      common.Address `json:"address"`
+		} `json:"create2"`
+	} `json:"callsFromEntryPoint"`
+	Calls []struct {
+		Type  string         `json:"type"`
+		From  common.Address `json:"from"`
+		To    common.Address `json:"to"`
+		Value *hexutil.Big   `json:"value"`
+	} `json:"calls"`
+}
+
+// erc7562Violations submits the transaction to a native pool on top of the genesis
+// block, returning the ids of the ERC-7562 rules the pool rejects it for.
+func erc7562Violations(tb *testContextBuilder, aatx *types.Rip7560AccountAbstractionTx) ([]string, error) {
+	t := tb.build()
+	aatx.ChainID = t.genesis.Config.ChainID
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, t.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.t.Fatalf("failed to create the chain: %v", err)
+	}
+	defer chain.Stop()
+
+	pool := rip7560pool.NewNative(rip7560pool.DefaultNativeConfig, chain, common.Address{})
+	if err := pool.Init(0, chain.CurrentBlock(), nil); err != nil {
+		t.t.Fatalf("failed to init the pool: %v", err)
+	}
+	err = pool.Add([]*types.Transaction{types.NewTx(aatx)}, false, false)[0]
+
+	var (
+		rulesErr   *rip7560pool.Erc7562Error
+		violations []string
+	)
+	if !errors.As(err, &rulesErr) {
+		return nil, err
+	}
+	for _, v := range rulesErr.Violations {
+		if !strings.Contains(err.Error(), v.String()) {
+			t.t.Errorf("violation %v missing from the rejection error %q", v, err)
+		}
+		if !slices.Contains(violations, v.Rule) {
+			violations = append(violations, v.Rule)
+		}
+	}
+	return violations, nil
+}
+
+// traceValidation runs the validation phases of the transaction with the rip7560Validation tracer.
+func traceValidation(tb *testContextBuilder, aatx *types.Rip7560AccountAbstractionTx) (*erc7562Trace, error) {
+	t := tb.build()
+	if aatx.Sender == nil {
+		sender := common.HexToAddress(DEFAULT_SENDER)
+		aatx.Sender = &sender
+	}
+	aatx.ChainID = t.genesis.Config.ChainID
+
+	state := tests.MakePreState(rawdb.NewMemoryDatabase(), t.genesisAlloc, false, rawdb.HashScheme)
+	defer state.Close()
+
+	tracer, err := tracers.DefaultDirectory.New("rip7560Validation", &tracers.Context{}, nil)
+	if err != nil {
+		t.t.Fatalf("failed to create the tracer: %v", err)
+	}
+	header := t.genesisBlock.Header()
+	_, validationErr := core.ApplyRip7560ValidationPhases(t.genesis.Config, nil, &header.Coinbase, t.gaspool, state.StateDB, header, types.NewTx(aatx), vm.Config{Tracer: tracer.Hooks})
+
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.t.Fatalf("failed to get the tracer result: %v", err)
+	}
+	trace := new(erc7562Trace)
+	if err := json.Unmarshal(result, trace); err != nil {
+		t.t.Fatalf("failed to decode the tracer result: %v", err)
+	}
+	return trace, validationErr
+}
+
+// erc7562Call returns the code calling the target with the given gas and value, discarding the result.
+func erc7562Call(op vm.OpCode, target common.Address, gas int, value int) []byte {
+	code := createCode(vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH0)
+	if op == vm.CALL {
+		code = createCode(code, push(value))
+	}
+	return createCode(code, vm.PUSH20, target, push(gas), op, vm.POP)
+}
+
+func TestERC7562Rules(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string // the rule the case violates, if any
+		validation []byte // code run by the account before accepting the transaction
+		deployer   bool   // the account is deployed by the transaction
+	}{
+		{name: "OP-011 allowed opcodes", validation: createCode(vm.PUSH1, byte(1), vm.PUSH1, byte(2), vm.ADD, vm.POP)},
+		{name: "OP-011 GASPRICE", rule: "OP-011", validation: createCode(vm.GASPRICE, vm.POP)},
+		{name: "OP-011 GASLIMIT", rule: "OP-011", validation: createCode(vm.GASLIMIT, vm.POP)},
+		{name: "OP-011 PREVRANDAO", rule: "OP-011", validation: createCode(vm.DIFFICULTY, vm.POP)},
+		{name: "OP-011 TIMESTAMP", rule: "OP-011", validation: createCode(vm.TIMESTAMP, vm.POP)},
+		{name: "OP-011 BASEFEE", rule: "OP-011", validation: createCode(vm.BASEFEE, vm.POP)},
+		{name: "OP-011 BLOCKHASH", rule: "OP-011", validation: createCode(vm.PUSH0, vm.BLOCKHASH, vm.POP)},
+		{name: "OP-011 NUMBER", rule: "OP-011", validation: createCode(vm.NUMBER, vm.POP)},
+		{name: "OP-011 ORIGIN", rule: "OP-011", validation: createCode(vm.ORIGIN, vm.POP)},
+		{name: "OP-011 COINBASE", rule: "OP-011", validation: createCode(vm.COINBASE, vm.POP)},
+		{name: "OP-011 BLOBHASH", rule: "OP-011", validation: createCode(vm.PUSH0, vm.BLOBHASH, vm.POP)},
+		{name: "OP-011 BLOBBASEFEE", rule: "OP-011", validation: createCode(vm.BLOBBASEFEE, vm.POP)},
+		{name: "OP-011 SELFDESTRUCT", rule: "OP-011", validation: createCode(vm.PUSH20, erc7562Sink, vm.SELFDESTRUCT)},
+		{name: "OP-012 GAS followed by a call", validation: createCode(vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH20, erc7562Sink, vm.GAS, vm.STATICCALL, vm.POP)},
+		{name: "OP-012 GAS not followed by a call", rule: "OP-012", validation: createCode(vm.GAS, vm.POP)},
+		{name: "OP-020 call within its gas", validation: erc7562Call(vm.STATICCALL, erc7562Sink, 10_000, 0)},
+		{name: "OP-020 out of gas in a call", rule: "OP-020", validation: erc7562Call(vm.STATICCALL, erc7562Storage, 1, 0)},
+		{name: "OP-031 CREATE2 by the deployer", deployer: true},
+		{name: "OP-031 CREATE2 by the account", rule: "OP-031", validation: createCode(vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.CREATE2, vm.POP)},
+		{name: "OP-031 CREATE", rule: "OP-031", validation: createCode(vm.PUSH0, vm.PUSH0, vm.PUSH0, vm.CREATE, vm.POP)},
+		{name: "OP-041 EXTCODEHASH of a contract", validation: createCode(vm.PUSH20, erc7562Sink, vm.EXTCODEHASH, vm.POP)},
+		{name: "OP-041 EXTCODEHASH of an address without code", rule: "OP-041", validation: createCode(vm.PUSH20, erc7562NoCode, vm.EXTCODEHASH, vm.POP)},
+		{name: "OP-041 call to an address without code", rule: "OP-041", validation: erc7562Call(vm.STATICCALL, erc7562NoCode, 10_000, 0)},
+		{name: "OP-051 EXTCODESIZE ISZERO of an address without code", validation: createCode(vm.PUSH20, erc7562NoCode, vm.EXTCODESIZE, vm.ISZERO, vm.POP)},
+		{name: "OP-051 EXTCODESIZE of an address without code", rule: "OP-041", validation: createCode(vm.PUSH20, erc7562NoCode, vm.EXTCODESIZE, vm.POP)},
+		{name: "OP-061 call without value", validation: erc7562Call(vm.CALL, erc7562Sink, 10_000, 0)},
+		{name: "OP-061 call with value", rule: "OP-061", validation: erc7562Call(vm.CALL, erc7562Sink, 10_000, 1)},
+		{name: "OP-062 allowed precompile", validation: erc7562Call(vm.STATICCALL, common.BytesToAddress([]byte{0x01}), 10_000, 0)},
+		{name: "OP-062 disallowed precompile", rule: "OP-062", validation: erc7562Call(vm.STATICCALL, common.BytesToAddress([]byte{0x0a}), 10_000, 0)},
+		{name: "OP-070 own transient storage", validation: createCode(vm.PUSH1, byte(1), vm.PUSH0, vm.TSTORE, vm.PUSH0, vm.TLOAD, vm.POP)},
+		{name: "OP-080 BALANCE", rule: "OP-080", validation: createCode(vm.PUSH20, erc7562Sink, vm.BALANCE, vm.POP)},
+		{name: "OP-080 SELFBALANCE", rule: "OP-080", validation: createCode(vm.SELFBALANCE, vm.POP)},
+		{name: "STO-010 own storage", validation: createCode(vm.PUSH1, byte(1), vm.PUSH0, vm.SSTORE, vm.PUSH0, vm.SLOAD, vm.POP)},
+		{name: "STO-021 storage of another contract", rule: "STO-021", validation: erc7562Call(vm.STATICCALL, erc7562Storage, 10_000, 0)},
+		{name: "STO-021 storage associated with the sender", validation: erc7562Call(vm.STATICCALL, erc7562Mapping, 10_000, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				accountCode = createCode(tt.validation, createAccountCode())
+				tb          = newTestContextBuilder(t).
+						withCode(erc7562Storage.Hex(), createCode(vm.PUSH0, vm.SLOAD, vm.POP, vm.STOP), 0).
+						withCode(erc7562Sink.Hex(), createCode(vm.STOP), 0).
+						withCode(erc7562Mapping.Hex(), createCode(vm.CALLER, vm.PUSH0, vm.MSTORE, vm.PUSH1, byte(64), vm.PUSH0, vm.KECCAK256, vm.SLOAD, vm.POP, vm.STOP), 0)
+				aatx = &types.Rip7560AccountAbstractionTx{
+					ValidationGasLimit: 1_000_000,
+					GasFeeCap:          big.NewInt(1_000_000_000),
+				}
+			)
+			if tt.deployer {
+				tb.withDeployer(accountCode, DEFAULT_BALANCE)
+				sender := create2_addr(DEPLOYER, accountCode)
+				aatx.Sender, aatx.Deployer = &sender, &DEPLOYER
+			} else {
+				tb.withCode(DEFAULT_SENDER, accountCode, DEFAULT_BALANCE)
+			}
+			if aatx.Sender == nil {
+				sender := common.HexToAddress(DEFAULT_SENDER)
+				aatx.Sender = &sender
+			}
+			violations, err := erc7562Violations(tb, aatx)
+			if tt.rule == "" && err != nil {
+				t.Fatalf("validation of a compliant transaction failed: %v", err)
+			}
+			if tt.rule == "" {
+				if len(violations) > 0 {
+					t.Errorf("compliant transaction reported as violating %v", violations)
+				}
+			} else if !slices.Contains(violations, tt.rule) {
+				t.Errorf("violation of %s not reported, have %v", tt.rule, violations)
+			}
+		})
+	}
+}
+
+func TestValidationTracerKeccakAndCreate2(t *testing.T) {
+	var (
+		preimage = append(asBytes32(0x42), asBytes32(1)...)
+		// hash a mapping key, as solidity does to address associated storage
+		accountCode = createCode(copyToMemory(preimage, 0), vm.PUSH1, byte(64), vm.PUSH0, vm.KECCAK256, vm.POP, createAccountCode())
+		sender      = create2_addr(DEPLOYER, accountCode)
+		tb          = newTestContextBuilder(t).withDeployer(accountCode, DEFAULT_BALANCE)
+		aatx        = &types.Rip7560AccountAbstractionTx{
+			Sender:             &sender,
+			Deployer:           &DEPLOYER,
+			ValidationGasLimit: 1_000_000,
+			GasFeeCap:          big.NewInt(1_000_000_000),
+		}
+	)
+	trace, err := traceValidation(tb, aatx)
+	if err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
+	if len(trace.CallsFromEntryPoint) != 2 {
+		t.Fatalf("unexpected number of frames: have %d, want 2", len(trace.CallsFromEntryPoint))
+	}
+	deployer, account := trace.CallsFromEntryPoint[0], trace.CallsFromEntryPoint[1]
+
+	if len(deployer.Create2) != 1 {
+		t.Fatalf("unexpected number of CREATE2 derivations: have %d, want 1", len(deployer.Create2))
+	}
+	create2 := deployer.Create2[0]
+	if create2.From != DEPLOYER || create2.Salt != (common.Hash{}) || create2.Address != sender {
+		t.Errorf("unexpected CREATE2 derivation: have %+v, want from %v to %v", create2, DEPLOYER, sender)
+	}
+	if want := crypto.Keccak256Hash(create2_contract(accountCode)); create2.InitCodeHash != want {
+		t.Errorf("unexpected init code hash: have %v, want %v", create2.InitCodeHash, want)
+	}
+	if len(deployer.Keccak) != 0 {
+		t.Errorf("keccak of the account recorded in the deployer frame: %v", deployer.Keccak)
+	}
+	if len(account.Keccak) != 1 || !bytes.Equal(account.Keccak[0], preimage) {
+		t.Errorf("unexpected keccak preimages: have %v, want [%x]", account.Keccak, preimage)
+	}
+	if len(account.Create2) != 0 {
+		t.Errorf("CREATE2 of the deployer recorded in the account frame: %v", account.Create2)
+	}
+}
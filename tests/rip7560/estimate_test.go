@@ -0,0 +1,257 @@
+package rip7560
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"testing"
+)
+
+// estimateOvershoot bounds how much gas an estimate may exceed the gas actually used
+// by the frames it covers: the estimator probes with the 63/64 rule and a call stipend
+// of headroom, then stops bisecting within its error ratio.
+func estimateOvershoot(used uint64) uint64 {
+	return (used+params.CallStipend)*64/63 - used + used*3/200
+}
+
+// estimateAccountCode returns the code of an account accepting any transaction. Its
+// execution frame writes to the storage slot given by the first word of the execution
+// data; its validation frame writes to storage first if validationWrites is set.
+func estimateAccountCode(validationWrites bool) []byte {
+	validation := createAccountCode()
+	if validationWrites {
+		validation = createCode(vm.PUSH1, byte(1), vm.PUSH1, byte(1), vm.SSTORE, validation)
+	}
+	dispatch := createCode(
+		vm.PUSH0, vm.CALLDATALOAD, vm.PUSH1, byte(224), vm.SHR,
+		vm.PUSH4, core.Rip7560Abi.Methods["validateTransaction"].ID, vm.EQ,
+	)
+	execution := createCode(vm.PUSH1, byte(1), vm.PUSH0, vm.CALLDATALOAD, vm.SSTORE, vm.STOP)
+	validationDest := len(dispatch) + 4 + len(execution)
+	return createCode(dispatch, vm.PUSH2, uint16(validationDest), vm.JUMPI, execution, vm.JUMPDEST, validation)
+}
+
+// estimateShape is an account, paymaster and deployer combination of the estimator suite.
+type estimateShape struct {
+	name             string
+	validationWrites bool   // the account writes to storage during validation
+	paymaster        []byte // code of the paymaster, if any
+	deployer         bool   // the account is deployed by the transaction
+	executionData    []byte
+}
+
+// estimateShapes returns the matrix of shapes the estimator is checked against.
+func estimateShapes() []*estimateShape {
+	var (
+		paymaster       = paymasterCode(0, 0, []byte{}, nil)
+		postOpPaymaster = paymasterCode(0, 0, []byte{1}, createCode(vm.PUSH1, byte(1), vm.PUSH1, byte(2), vm.SSTORE, vm.STOP))
+		slot            = common.LeftPadBytes([]byte{0x42}, 32)
+	)
+	var shapes []*estimateShape
+	for _, account := range []struct {
+		name             string
+		validationWrites bool
+	}{{"account", false}, {"account_storage", true}} {
+		for _, pm := range []struct {
+			name string
+			code []byte
+		}{{"", nil}, {"_paymaster", paymaster}, {"_paymaster_postop", postOpPaymaster}} {
+			for _, deployer := range []bool{false, true} {
+				for _, data := range [][]byte{{}, slot} {
+					name := account.name + pm.name
+					if deployer {
+						name += "_deployer"
+					}
+					if len(data) > 0 {
+						name += "_execution_data"
+					}
+					shapes = append(shapes, &estimateShape{
+						name:             name,
+						validationWrites: account.validationWrites,
+						paymaster:        pm.code,
+						deployer:         deployer,
+						executionData:    data,
+					})
+				}
+			}
+		}
+	}
+	return shapes
+}
+
+// estimateTest is a chain with one sender, and possibly a paymaster and deployer, per shape.
+type estimateTest struct {
+	t          *testing.T
+	backend    *eth.Ethereum
+	senders    []common.Address
+	paymasters []*common.Address
+	deployers  []*common.Address
+}
+
+func newEstimateTest(t *testing.T, shapes []*estimateShape) *estimateTest {
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	var (
+		tt      = &estimateTest{t: t}
+		balance = new(big.Int).Lsh(common.Big1, 100)
+		alloc   = types.GenesisAlloc{}
+	)
+	for i, shape := range shapes {
+		var (
+			// every shape has its own contracts, distinguished by the top bytes of their addresses
+			prefix      = []byte{0x75, 0x60, byte(i)}
+			accountCode = append(estimateAccountCode(shape.validationWrites), byte(i)) // unreachable suffix making deployments unique
+			sender      = common.BytesToAddress(append(common.CopyBytes(prefix), 0x01))
+			paymaster   *common.Address
+			deployer    *common.Address
+		)
+		if shape.deployer {
+			deployer = new(common.Address)
+			*deployer = common.BytesToAddress(append(common.CopyBytes(prefix), 0x02))
+			alloc[*deployer] = types.Account{Code: createCode(create2(accountCode), returnWithData([]byte{})), Balance: new(big.Int)}
+			sender = create2_addr(*deployer, accountCode)
+			alloc[sender] = types.Account{Balance: balance}
+		} else {
+			alloc[sender] = types.Account{Code: accountCode, Balance: balance}
+		}
+		if shape.paymaster != nil {
+			paymaster = new(common.Address)
+			*paymaster = common.BytesToAddress(append(common.CopyBytes(prefix), 0x03))
+			alloc[*paymaster] = types.Account{Code: shape.paymaster, Balance: balance}
+		}
+		tt.senders = append(tt.senders, sender)
+		tt.paymasters = append(tt.paymasters, paymaster)
+		tt.deployers = append(tt.deployers, deployer)
+	}
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(func() { stack.Close() })
+
+	ethConfig := ethconfig.Defaults
+	ethConfig.Genesis = &core.Genesis{
+		Config:     config,
+		GasLimit:   30_000_000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: new(big.Int),
+		Alloc:      alloc,
+	}
+	if tt.backend, err = eth.New(stack, &ethConfig); err != nil {
+		t.Fatalf("failed to create eth service: %v", err)
+	}
+	return tt
+}
+
+// args returns the arguments of the transaction of the i-th shape, leaving the gas
+// limits covered by the estimate unset.
+func (tt *estimateTest) args(i int, shape *estimateShape) ethapi.TransactionArgs {
+	args := ethapi.TransactionArgs{
+		ChainID:              (*hexutil.Big)(tt.backend.BlockChain().Config().ChainID),
+		Sender:               &tt.senders[i],
+		Nonce:                new(hexutil.Uint64),
+		MaxFeePerGas:         (*hexutil.Big)(big.NewInt(params.GWei * 10)),
+		MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(params.GWei)),
+		ExecutionData:        (*hexutil.Bytes)(&shape.executionData),
+		AuthorizationData:    new(hexutil.Bytes),
+	}
+	if paymaster := tt.paymasters[i]; paymaster != nil {
+		args.Paymaster = paymaster
+		args.PaymasterData = new(hexutil.Bytes)
+		args.PaymasterGas = (*hexutil.Uint64)(newUint64(1_000_000))
+		args.PostOpGas = (*hexutil.Uint64)(newUint64(100_000))
+	}
+	if deployer := tt.deployers[i]; deployer != nil {
+		args.Deployer = deployer
+		args.DeployerData = new(hexutil.Bytes)
+	}
+	return args
+}
+
+// include processes the transaction on top of the head as block processing does,
+// returning the results of its validation, execution and postOp frames.
+func (tt *estimateTest) include(tx *types.Transaction) (*core.ValidationPhaseResult, *types.Receipt, *core.ExecutionResult, *core.ExecutionResult) {
+	chain := tt.backend.BlockChain()
+	head := chain.CurrentBlock()
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		tt.t.Fatalf("failed to get the head state: %v", err)
+	}
+	header := types.CopyHeader(head)
+	header.ParentHash = head.Hash()
+	header.Number = new(big.Int).Add(head.Number, common.Big1)
+	header.Time = head.Time + 1
+
+	var (
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	vpr, err := core.ApplyRip7560ValidationPhases(chain.Config(), chain, &header.Coinbase, gp, statedb, header, tx, vm.Config{})
+	if err != nil {
+		tt.t.Fatalf("validation failed with the estimated gas limits: %v", err)
+	}
+	receipt, exr, ppr, err := core.ApplyRip7560ExecutionPhase(chain.Config(), vpr, chain, &header.Coinbase, gp, statedb, header, vm.Config{}, &usedGas)
+	if err != nil {
+		tt.t.Fatalf("execution failed with the estimated gas limits: %v", err)
+	}
+	return vpr, receipt, exr, ppr
+}
+
+func TestEstimateRip7560Accuracy(t *testing.T) {
+	var (
+		shapes = estimateShapes()
+		tt     = newEstimateTest(t, shapes)
+		api    = ethapi.NewBlockChainAPI(tt.backend.APIBackend)
+	)
+	for i, shape := range shapes {
+		t.Run(shape.name, func(t *testing.T) {
+			tt.t = t
+			args := tt.args(i, shape)
+			estimate, err := api.EstimateRip7560TransactionGas(context.Background(), args, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to estimate gas: %v", err)
+			}
+			args = tt.args(i, shape)
+			args.ValidationGas = &estimate.ValidationGas
+			args.Gas = &estimate.ExecutionGas
+			args.BuilderFee = new(hexutil.Big)
+			args.NonceKey = new(hexutil.Big)
+			if args.Paymaster == nil {
+				args.PaymasterGas, args.PostOpGas = new(hexutil.Uint64), new(hexutil.Uint64)
+			}
+
+			vpr, receipt, exr, ppr := tt.include(args.ToTransaction())
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				t.Fatalf("transaction failed with the estimated gas limits: execution %v", exr.Err)
+			}
+			if ppr != nil && ppr.Failed() {
+				t.Fatalf("postOp failed with the estimated gas limits: %v", ppr.Err)
+			}
+
+			// the validation gas limit covers every validation frame but the paymaster's
+			validationUsed, err := vpr.ValidationPhaseUsedGas()
+			if err != nil {
+				t.Fatalf("failed to sum the validation gas: %v", err)
+			}
+			validationUsed -= vpr.PmValidationUsedGas
+			if have, max := uint64(estimate.ValidationGas), validationUsed+estimateOvershoot(validationUsed); have > max {
+				t.Errorf("validation gas overestimated: have %d, used %d, max %d", have, validationUsed, max)
+			}
+			if have, max := uint64(estimate.ExecutionGas), exr.UsedGas+estimateOvershoot(exr.UsedGas); have > max {
+				t.Errorf("execution gas overestimated: have %d, used %d, max %d", have, exr.UsedGas, max)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package rip7560
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"testing"
+)
+
+// FramePhase is the phase of an RIP-7560 transaction a top-level frame belongs to.
+type FramePhase string
+
+const (
+	PhaseNonce     FramePhase = "nonce"
+	PhaseDeployer  FramePhase = "deployer"
+	PhaseAccount   FramePhase = "account"
+	PhasePaymaster FramePhase = "paymaster"
+	PhaseExecution FramePhase = "execution"
+	PhasePostOp    FramePhase = "postOp"
+)
+
+// Frame is a top-level frame entered while processing RIP-7560 transactions.
+type Frame struct {
+	Phase    FramePhase
+	From     common.Address
+	To       common.Address
+	GasLimit uint64
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s(%x -> %x, gas %d)", f.Phase, f.From, f.To, f.GasLimit)
+}
+
+// FrameTracer records the exact sequence of top-level frames entered while processing
+// RIP-7560 transactions, so tests can pin the order of the frames and their gas limits.
+type FrameTracer struct {
+	frames []Frame
+}
+
+// NewFrameTracer creates a tracer recording no frames yet.
+func NewFrameTracer() *FrameTracer {
+	return &FrameTracer{}
+}
+
+// Hooks returns the tracing hooks to run the transactions with.
+func (ft *FrameTracer) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{OnEnter: ft.onEnter}
+}
+
+func (ft *FrameTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if depth != 0 {
+		return
+	}
+	ft.frames = append(ft.frames, Frame{Phase: framePhase(from, to, input), From: from, To: to, GasLimit: gas})
+}
+
+// framePhase classifies a top-level frame by its endpoints and the EntryPoint method
+// it calls. Execution frames are the ones calling the sender with any other data, so
+// the execution data of traced transactions must not start with a validation selector.
+func framePhase(from common.Address, to common.Address, input []byte) FramePhase {
+	switch {
+	case to == core.AA_NONCE_MANAGER:
+		return PhaseNonce
+	case from == core.AA_SENDER_CREATOR:
+		return PhaseDeployer
+	case len(input) < 4:
+		return PhaseExecution
+	}
+	for name, phase := range map[string]FramePhase{
+		"validateTransaction":          PhaseAccount,
+		"validatePaymasterTransaction": PhasePaymaster,
+		"postPaymasterTransaction":     PhasePostOp,
+	} {
+		if bytes.Equal(input[:4], core.Rip7560Abi.Methods[name].ID) {
+			return phase
+		}
+	}
+	return PhaseExecution
+}
+
+// Frames returns the frames recorded so far.
+func (ft *FrameTracer) Frames() []Frame {
+	return ft.frames
+}
+
+// AssertFrames fails the test unless exactly the expected frames were recorded, in order.
+func (ft *FrameTracer) AssertFrames(t testing.TB, want ...Frame) {
+	t.Helper()
+	if len(ft.frames) != len(want) {
+		t.Fatalf("frame count mismatch: have %d %v, want %d %v", len(ft.frames), ft.frames, len(want), want)
+	}
+	for i := range want {
+		if ft.frames[i] != want[i] {
+			t.Errorf("frame %d mismatch: have %v, want %v", i, ft.frames[i], want[i])
+		}
+	}
+}
+
+// AssertPhases fails the test unless the recorded frames belong to the expected phases,
+// in order, regardless of their endpoints and gas limits.
+func (ft *FrameTracer) AssertPhases(t testing.TB, want ...FramePhase) {
+	t.Helper()
+	have := make([]FramePhase, len(ft.frames))
+	for i, frame := range ft.frames {
+		have[i] = frame.Phase
+	}
+	if fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("frame phases mismatch: have %v, want %v", have, want)
+	}
+}
+
+// TraceScenario processes the transactions of a scenario in a block, skipping invalid
+// ones as block building does, and returns the frames they entered.
+func TraceScenario(scenario *Scenario) (*FrameTracer, error) {
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		return nil, err
+	}
+	state := tests.MakePreState(rawdb.NewMemoryDatabase(), scenario.Pre, false, rawdb.HashScheme)
+	defer state.Close()
+
+	var (
+		coinbase = common.HexToAddress("0xc014ba5e")
+		header   = &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: new(big.Int),
+			GasLimit:   30_000_000,
+			Time:       1000,
+			BaseFee:    big.NewInt(7),
+		}
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		tracer  = NewFrameTracer()
+		txs     = make([]*types.Transaction, len(scenario.Txs))
+		gasUsed uint64
+	)
+	for i, aatx := range scenario.Txs {
+		inner := types.NewTx(aatx).Rip7560TransactionData()
+		inner.ChainID = config.ChainID
+		txs[i] = types.NewTx(inner)
+	}
+	_, _, _, _, err = core.HandleRip7560Transactions(txs, 0, state.StateDB, &coinbase, header, gp, config, nil, vm.Config{Tracer: tracer.Hooks()}, true, &gasUsed)
+	if err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
@@ -0,0 +1,68 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"testing"
+)
+
+func traceScenario(t *testing.T, name string) *FrameTracer {
+	t.Helper()
+	for _, scenario := range Scenarios() {
+		if scenario.Name != name {
+			continue
+		}
+		tracer, err := TraceScenario(scenario)
+		if err != nil {
+			t.Fatalf("scenario %s: failed to process: %v", name, err)
+		}
+		return tracer
+	}
+	t.Fatalf("unknown scenario %s", name)
+	return nil
+}
+
+func TestFrameOrder(t *testing.T) {
+	tests := []struct {
+		scenario string
+		phases   []FramePhase
+	}{
+		{"validation_ok", []FramePhase{PhaseAccount, PhaseExecution}},
+		{"validation_account_revert", []FramePhase{PhaseAccount}},
+		{"validation_nonce_too_high", nil},
+		{"deployer_ok", []FramePhase{PhaseDeployer, PhaseAccount, PhaseExecution}},
+		{"deployer_revert", []FramePhase{PhaseDeployer}},
+		{"paymaster_ok", []FramePhase{PhaseAccount, PhasePaymaster, PhaseExecution}},
+		{"paymaster_revert", []FramePhase{PhaseAccount, PhasePaymaster}},
+		{"multiple_transactions", []FramePhase{
+			PhaseAccount, PhaseExecution,
+			PhaseAccount, PhaseExecution,
+			PhaseAccount, PhaseExecution,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			traceScenario(t, tt.scenario).AssertPhases(t, tt.phases...)
+		})
+	}
+}
+
+func TestFrameGasLimits(t *testing.T) {
+	var scenario *Scenario
+	for _, s := range Scenarios() {
+		if s.Name == "validation_ok" {
+			scenario = s
+		}
+	}
+	aatx := scenario.Txs[0]
+	preTransactionGasCost, err := aatx.PreTransactionGasCost(false)
+	if err != nil {
+		t.Fatalf("failed to compute the pre-transaction gas cost: %v", err)
+	}
+	sender := common.HexToAddress(DEFAULT_SENDER)
+
+	traceScenario(t, scenario.Name).AssertFrames(t,
+		Frame{Phase: PhaseAccount, From: core.AA_ENTRY_POINT, To: sender, GasLimit: aatx.ValidationGasLimit - preTransactionGasCost},
+		Frame{Phase: PhaseExecution, From: core.AA_ENTRY_POINT, To: sender, GasLimit: aatx.Gas},
+	)
+}
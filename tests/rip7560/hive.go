@@ -0,0 +1,375 @@
+package rip7560
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"time"
+)
+
+// The accounts predeployed in the genesis of the hive suite.
+var (
+	hiveSender    = common.HexToAddress("0x7560000000000000000000000000000000000001") // accepts any transaction
+	hiveRejecting = common.HexToAddress("0x7560000000000000000000000000000000000002") // reverts its validation
+	hiveSponsored = common.HexToAddress("0x7560000000000000000000000000000000000003") // accepts any transaction, has no funds
+	hivePaymaster = common.HexToAddress("0x7560000000000000000000000000000000000004") // sponsors any transaction, with a postOp frame
+)
+
+const (
+	hiveChainID      = 7560
+	hiveGasLimit     = 30_000_000
+	hiveRPCTimeout   = 10 * time.Second
+	hivePayloadDelay = 500 * time.Millisecond // time given to the node to fill a payload from its pool
+)
+
+// HiveGenesis returns the genesis of the chain the RIP-7560 hive suite runs on. The node
+// under test must be started from it, accepting pushed RIP-7560 bundles.
+func HiveGenesis() *core.Genesis {
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		panic(err)
+	}
+	chainConfig := *config
+	chainConfig.ChainID = big.NewInt(hiveChainID)
+
+	balance := new(big.Int).Lsh(common.Big1, 100)
+	return &core.Genesis{
+		Config:     &chainConfig,
+		GasLimit:   hiveGasLimit,
+		Difficulty: new(big.Int),
+		Alloc: types.GenesisAlloc{
+			hiveSender:    {Code: acceptAccountCode(0, 0), Balance: balance},
+			hiveRejecting: {Code: revertWithData([]byte{}), Balance: balance},
+			hiveSponsored: {Code: acceptAccountCode(0, 0), Balance: new(big.Int)},
+			hivePaymaster: {Code: paymasterCode(0, 0, []byte{1}, createCode(vm.STOP)), Balance: balance},
+		},
+	}
+}
+
+// HiveSuite submits RIP-7560 transactions and bundles to a running node over RPC,
+// drives its block production through the engine API, and validates the resulting
+// blocks, receipts and tracing output.
+type HiveSuite struct {
+	client *rpc.Client // public RPC endpoint of the node
+	engine *rpc.Client // authenticated engine API endpoint of the node
+}
+
+// NewHiveSuite creates a suite against the node serving the given RPC and engine API endpoints.
+func NewHiveSuite(rpcURL string, engineURL string, jwtSecret [32]byte) (*HiveSuite, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hiveRPCTimeout)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial the RPC endpoint: %w", err)
+	}
+	engineClient, err := rpc.DialOptions(ctx, engineURL, rpc.WithHTTPAuth(node.NewJWTAuth(jwtSecret)))
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to dial the engine API endpoint: %w", err)
+	}
+	return &HiveSuite{client: client, engine: engineClient}, nil
+}
+
+// Close closes the connections to the node.
+func (s *HiveSuite) Close() {
+	s.client.Close()
+	s.engine.Close()
+}
+
+// AllTests returns all the tests of the suite.
+func (s *HiveSuite) AllTests() []utesting.Test {
+	return []utesting.Test{
+		{Name: "BundleInclusion", Fn: s.TestBundleInclusion},
+		{Name: "InvalidTransactionDropped", Fn: s.TestInvalidTransactionDropped},
+		{Name: "PaymasterSponsorship", Fn: s.TestPaymasterSponsorship},
+		{Name: "TraceValidation", Fn: s.TestTraceValidation},
+	}
+}
+
+// TestBundleInclusion submits a bundle of transactions from the same sender and checks
+// that they are included in order, with consistent receipts.
+func (s *HiveSuite) TestBundleInclusion(t *utesting.T) {
+	nonce := s.nonce(t, hiveSender)
+	bundle := []ethapi.TransactionArgs{s.txArgs(hiveSender, nonce), s.txArgs(hiveSender, nonce+1)}
+	bundleHash := s.sendBundle(t, bundle)
+	block := s.buildBlock(t)
+
+	status := s.waitBundleStatus(t, bundleHash)
+	if status.Status != 0 || status.BlockHash != block.Hash() {
+		t.Fatalf("bundle not included in block %v: status %d, block %v", block.Hash(), status.Status, status.BlockHash)
+	}
+	s.checkBlock(t, block, bundle)
+	if have, want := s.nonce(t, hiveSender), nonce+2; have != want {
+		t.Errorf("sender nonce mismatch: have %d, want %d", have, want)
+	}
+}
+
+// TestInvalidTransactionDropped submits a bundle with a transaction failing validation
+// and checks that only the valid transactions of the bundle are included.
+func (s *HiveSuite) TestInvalidTransactionDropped(t *utesting.T) {
+	invalid := s.txArgs(hiveRejecting, s.nonce(t, hiveRejecting))
+	valid := s.txArgs(hiveSender, s.nonce(t, hiveSender))
+	s.sendBundle(t, []ethapi.TransactionArgs{invalid, valid})
+	block := s.buildBlock(t)
+
+	s.checkBlock(t, block, []ethapi.TransactionArgs{valid})
+	var receipt *types.Receipt
+	if err := s.call(&receipt, "eth_getTransactionReceipt", invalid.ToTransaction().Hash()); err != nil {
+		t.Fatalf("failed to get the receipt of the invalid transaction: %v", err)
+	}
+	if receipt != nil {
+		t.Fatalf("invalid transaction included in block %v", receipt.BlockHash)
+	}
+	var info map[string]interface{}
+	if err := s.call(&info, "eth_getRip7560TransactionDebugInfo", invalid.ToTransaction().Hash()); err != nil {
+		t.Fatalf("failed to get the debug info of the invalid transaction: %v", err)
+	}
+	if len(info) == 0 {
+		t.Errorf("no debug info for the invalid transaction")
+	}
+}
+
+// TestPaymasterSponsorship submits a transaction sponsored by a paymaster and checks
+// that the paymaster, and not the sender, pays for it.
+func (s *HiveSuite) TestPaymasterSponsorship(t *utesting.T) {
+	args := s.txArgs(hiveSponsored, s.nonce(t, hiveSponsored))
+	args.Paymaster = &hivePaymaster
+	args.PaymasterGas = (*hexutil.Uint64)(newUint64(1_000_000))
+	args.PostOpGas = (*hexutil.Uint64)(newUint64(100_000))
+
+	paymasterBalance := s.balance(t, hivePaymaster)
+	s.sendBundle(t, []ethapi.TransactionArgs{args})
+	block := s.buildBlock(t)
+
+	receipts := s.checkBlock(t, block, []ethapi.TransactionArgs{args})
+	if have := s.balance(t, hiveSponsored); have.Sign() != 0 {
+		t.Errorf("sponsored sender charged: balance %v", have)
+	}
+	paid := new(big.Int).Sub(paymasterBalance, s.balance(t, hivePaymaster))
+	if want := new(big.Int).Mul(receipts[0].EffectiveGasPrice, new(big.Int).SetUint64(receipts[0].GasUsed)); paid.Cmp(want) != 0 {
+		t.Errorf("paymaster payment mismatch: have %v, want %v", paid, want)
+	}
+}
+
+// TestTraceValidation traces the validation of a transaction and checks that the
+// validation frame of the sender is part of the trace.
+func (s *HiveSuite) TestTraceValidation(t *utesting.T) {
+	args := s.txArgs(hiveSender, s.nonce(t, hiveSender))
+	var trace struct {
+		CallsFromEntryPoint []struct {
+			TopLevelTargetAddress common.Address `json:"topLevelTargetAddress"`
+			OOG                   bool           `json:"oog"`
+		} `json:"callsFromEntryPoint"`
+	}
+	if err := s.call(&trace, "eth_traceRip7560Validation", args, rpc.LatestBlockNumber); err != nil {
+		t.Fatalf("failed to trace the validation: %v", err)
+	}
+	for _, frame := range trace.CallsFromEntryPoint {
+		if frame.TopLevelTargetAddress == hiveSender {
+			if frame.OOG {
+				t.Errorf("traced validation frame ran out of gas")
+			}
+			return
+		}
+	}
+	t.Errorf("no validation frame of %v in the trace: %+v", hiveSender, trace.CallsFromEntryPoint)
+}
+
+// checkBlock checks that the block contains the L1 attributes deposit followed by the
+// given transactions, and that their receipts are consistent with the block.
+func (s *HiveSuite) checkBlock(t *utesting.T, block *types.Block, want []ethapi.TransactionArgs) []*types.Receipt {
+	txs := block.Transactions()
+	if len(txs) != len(want)+1 {
+		t.Fatalf("block transaction count mismatch: have %d, want %d", len(txs), len(want)+1)
+	}
+	for i, args := range want {
+		if have, want := txs[i+1].Hash(), args.ToTransaction().Hash(); have != want {
+			t.Fatalf("transaction %d mismatch: have %v, want %v", i+1, have, want)
+		}
+	}
+	var receipts []*types.Receipt
+	if err := s.call(&receipts, "eth_getBlockReceipts", rpc.BlockNumberOrHashWithHash(block.Hash(), true)); err != nil {
+		t.Fatalf("failed to get the block receipts: %v", err)
+	}
+	if len(receipts) != len(txs) {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(receipts), len(txs))
+	}
+	var cumulative uint64
+	for i, receipt := range receipts {
+		cumulative += receipt.GasUsed
+		if receipt.TxHash != txs[i].Hash() || receipt.TransactionIndex != uint(i) || receipt.BlockHash != block.Hash() {
+			t.Errorf("receipt %d does not match its transaction", i)
+		}
+		if receipt.CumulativeGasUsed != cumulative {
+			t.Errorf("receipt %d cumulative gas mismatch: have %d, want %d", i, receipt.CumulativeGasUsed, cumulative)
+		}
+		if i > 0 && (receipt.Type != types.Rip7560Type || receipt.Status != types.ReceiptStatusSuccessful) {
+			t.Errorf("receipt %d: unexpected type %d or status %d", i, receipt.Type, receipt.Status)
+		}
+	}
+	if cumulative != block.GasUsed() {
+		t.Errorf("block gas used mismatch: have %d, receipts sum %d", block.GasUsed(), cumulative)
+	}
+	return receipts[1:]
+}
+
+// buildBlock makes the node build a block on top of its head, including the L1
+// attributes deposit and the transactions of its pool, and makes it the new head.
+func (s *HiveSuite) buildBlock(t *utesting.T) *types.Block {
+	var head *types.Header
+	if err := s.call(&head, "eth_getBlockByNumber", rpc.LatestBlockNumber, false); err != nil {
+		t.Fatalf("failed to get the head block: %v", err)
+	}
+	deposit, err := types.NewTx(&types.DepositTx{
+		SourceHash: common.BigToHash(new(big.Int).Add(head.Number, common.Big1)),
+		From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+		To:         &types.L1BlockAddr,
+		Gas:        1_000_000,
+		Data:       make([]byte, 4+32*8),
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode the L1 attributes deposit: %v", err)
+	}
+	var (
+		gasLimit   = uint64(hiveGasLimit)
+		beaconRoot = common.Hash{}
+		state      = engine.ForkchoiceStateV1{HeadBlockHash: head.Hash(), SafeBlockHash: head.Hash(), FinalizedBlockHash: head.Hash()}
+		attributes = &engine.PayloadAttributes{
+			Timestamp:             head.Time + 1,
+			SuggestedFeeRecipient: common.Address{}, // the pool only tracks bundles in blocks of its own coinbase or the zero address
+			Withdrawals:           []*types.Withdrawal{},
+			BeaconRoot:            &beaconRoot,
+			Transactions:          [][]byte{deposit},
+			GasLimit:              &gasLimit,
+		}
+		fcu engine.ForkChoiceResponse
+	)
+	if err := s.engineCall(&fcu, "engine_forkchoiceUpdatedV3", state, attributes); err != nil {
+		t.Fatalf("failed to start building the payload: %v", err)
+	}
+	if fcu.PayloadID == nil {
+		t.Fatalf("no payload started, forkchoice status %v", fcu.PayloadStatus.Status)
+	}
+	time.Sleep(hivePayloadDelay)
+
+	var envelope engine.ExecutionPayloadEnvelope
+	if err := s.engineCall(&envelope, "engine_getPayloadV3", fcu.PayloadID); err != nil {
+		t.Fatalf("failed to get the payload: %v", err)
+	}
+	var status engine.PayloadStatusV1
+	if err := s.engineCall(&status, "engine_newPayloadV3", envelope.ExecutionPayload, []common.Hash{}, beaconRoot); err != nil {
+		t.Fatalf("failed to submit the payload: %v", err)
+	}
+	if status.Status != engine.VALID {
+		t.Fatalf("payload not valid: %v", status.Status)
+	}
+	hash := envelope.ExecutionPayload.BlockHash
+	state = engine.ForkchoiceStateV1{HeadBlockHash: hash, SafeBlockHash: hash, FinalizedBlockHash: hash}
+	if err := s.engineCall(&fcu, "engine_forkchoiceUpdatedV3", state, nil); err != nil {
+		t.Fatalf("failed to update the head: %v", err)
+	}
+	block, err := engine.ExecutableDataToBlock(*envelope.ExecutionPayload, []common.Hash{}, &beaconRoot)
+	if err != nil {
+		t.Fatalf("failed to decode the payload: %v", err)
+	}
+	return block
+}
+
+// txArgs returns the arguments of a transaction from the given sender, without a paymaster.
+func (s *HiveSuite) txArgs(sender common.Address, nonce uint64) ethapi.TransactionArgs {
+	return ethapi.TransactionArgs{
+		ChainID:              (*hexutil.Big)(big.NewInt(hiveChainID)),
+		Sender:               &sender,
+		NonceKey:             new(hexutil.Big),
+		Nonce:                (*hexutil.Uint64)(&nonce),
+		Gas:                  (*hexutil.Uint64)(newUint64(100_000)),
+		ValidationGas:        (*hexutil.Uint64)(newUint64(1_000_000)),
+		MaxFeePerGas:         (*hexutil.Big)(big.NewInt(params.GWei * 10)),
+		MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(params.GWei)),
+		ExecutionData:        &hexutil.Bytes{},
+		AuthorizationData:    &hexutil.Bytes{},
+	}
+}
+
+// sendBundle submits a bundle valid for the next block and returns its hash.
+func (s *HiveSuite) sendBundle(t *utesting.T, bundle []ethapi.TransactionArgs) common.Hash {
+	var number hexutil.Big
+	if err := s.call(&number, "eth_blockNumber"); err != nil {
+		t.Fatalf("failed to get the head number: %v", err)
+	}
+	var hash common.Hash
+	nextBlock := new(big.Int).Add(number.ToInt(), common.Big1)
+	if err := s.call(&hash, "eth_sendRip7560TransactionsBundle", bundle, nextBlock, "hive"); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+	return hash
+}
+
+// waitBundleStatus waits for the pool of the node to report the status of the bundle,
+// which it learns about asynchronously after the head changed.
+func (s *HiveSuite) waitBundleStatus(t *utesting.T, hash common.Hash) *types.BundleReceipt {
+	for deadline := time.Now().Add(hiveRPCTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		var status *types.BundleReceipt
+		if err := s.call(&status, "eth_getRip7560BundleStatus", hash); err != nil {
+			t.Fatalf("failed to get the bundle status: %v", err)
+		}
+		if status != nil {
+			return status
+		}
+	}
+	t.Fatalf("unknown bundle %v", hash)
+	return nil
+}
+
+func (s *HiveSuite) nonce(t *utesting.T, addr common.Address) uint64 {
+	var nonce hexutil.Uint64
+	if err := s.call(&nonce, "eth_getTransactionCount", addr, rpc.LatestBlockNumber); err != nil {
+		t.Fatalf("failed to get the nonce of %v: %v", addr, err)
+	}
+	return uint64(nonce)
+}
+
+func (s *HiveSuite) balance(t *utesting.T, addr common.Address) *big.Int {
+	var balance hexutil.Big
+	if err := s.call(&balance, "eth_getBalance", addr, rpc.LatestBlockNumber); err != nil {
+		t.Fatalf("failed to get the balance of %v: %v", addr, err)
+	}
+	return balance.ToInt()
+}
+
+func (s *HiveSuite) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hiveRPCTimeout)
+	defer cancel()
+	return s.client.CallContext(ctx, result, method, args...)
+}
+
+func (s *HiveSuite) engineCall(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hiveRPCTimeout)
+	defer cancel()
+	if err := s.engine.CallContext(ctx, result, method, args...); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			return fmt.Errorf("%s: %w (code %d)", method, err, rpcErr.ErrorCode())
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+func newUint64(v uint64) *uint64 {
+	return &v
+}
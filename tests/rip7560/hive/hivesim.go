@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// simulation is a client of the hive simulation API. It implements the few calls the
+// suite needs, so that the simulator does not depend on the hivesim module.
+type simulation struct {
+	url    string
+	client *http.Client
+}
+
+// clientDefinition describes a client type available in the hive run.
+type clientDefinition struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Meta    struct {
+		Roles []string `json:"roles"`
+	} `json:"meta"`
+}
+
+// hasRole reports whether the client has the given role.
+func (c *clientDefinition) hasRole(role string) bool {
+	for _, r := range c.Meta.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func newSimulation(url string) *simulation {
+	return &simulation{url: url, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// clients returns the client types available in the hive run.
+func (s *simulation) clients() ([]*clientDefinition, error) {
+	var clients []*clientDefinition
+	err := s.do(http.MethodGet, "/clients", nil, "", &clients)
+	return clients, err
+}
+
+// startSuite starts a test suite and returns its identifier.
+func (s *simulation) startSuite(name, description string) (int, error) {
+	var suite int
+	err := s.doJSON(http.MethodPost, "/testsuite", map[string]string{"name": name, "description": description}, &suite)
+	return suite, err
+}
+
+// endSuite ends a test suite, stopping all its clients.
+func (s *simulation) endSuite(suite int) error {
+	return s.do(http.MethodDelete, fmt.Sprintf("/testsuite/%d", suite), nil, "", nil)
+}
+
+// startTest starts a test of a suite and returns its identifier.
+func (s *simulation) startTest(suite int, name, description string) (int, error) {
+	var test int
+	err := s.doJSON(http.MethodPost, fmt.Sprintf("/testsuite/%d/test", suite), map[string]string{"name": name, "description": description}, &test)
+	return test, err
+}
+
+// endTest reports the result of a test, stopping the clients started by it.
+func (s *simulation) endTest(suite, test int, pass bool, details string) error {
+	result := struct {
+		Pass    bool   `json:"pass"`
+		Details string `json:"details"`
+	}{pass, details}
+	return s.doJSON(http.MethodPost, fmt.Sprintf("/testsuite/%d/test/%d", suite, test), result, nil)
+}
+
+// startClient starts a client of the given type within a test, with the given environment
+// and files placed at their paths in the container, and returns its IP address.
+func (s *simulation) startClient(suite, test int, client string, env map[string]string, files map[string][]byte) (string, error) {
+	var (
+		body   bytes.Buffer
+		writer = multipart.NewWriter(&body)
+	)
+	config, err := json.Marshal(map[string]interface{}{"client": client, "networks": []string{}, "environment": env})
+	if err != nil {
+		return "", err
+	}
+	if err := writer.WriteField("config", string(config)); err != nil {
+		return "", err
+	}
+	for path, content := range files {
+		part, err := writer.CreateFormFile(path, path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := part.Write(content); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	var node struct {
+		ID string `json:"id"`
+		IP string `json:"ip"`
+	}
+	err = s.do(http.MethodPost, fmt.Sprintf("/testsuite/%d/test/%d/node", suite, test), &body, writer.FormDataContentType(), &node)
+	return node.IP, err
+}
+
+func (s *simulation) doJSON(method, path string, request interface{}, result interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return s.do(method, path, bytes.NewReader(body), "application/json", result)
+}
+
+func (s *simulation) do(method, path string, body io.Reader, contentType string, result interface{}) error {
+	req, err := http.NewRequest(method, s.url+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("content-type", contentType)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("hive API %s %s failed: %s: %s", method, path, resp.Status, msg)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSimulationAPI(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/clients":
+			io.WriteString(w, `[{"name":"go-ethereum","meta":{"roles":["eth1"]}}]`)
+		case "/testsuite", "/testsuite/1/test":
+			io.WriteString(w, "1")
+		case "/testsuite/1/test/1/node":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("failed to parse the client request: %v", err)
+			}
+			var config struct {
+				Client      string            `json:"client"`
+				Environment map[string]string `json:"environment"`
+			}
+			if err := json.Unmarshal([]byte(r.FormValue("config")), &config); err != nil {
+				t.Errorf("failed to decode the client config: %v", err)
+			}
+			if config.Client != "go-ethereum" || config.Environment["HIVE_CHAIN_ID"] != "7560" {
+				t.Errorf("unexpected client config: %+v", config)
+			}
+			if _, ok := r.MultipartForm.File["/genesis.json"]; !ok {
+				t.Errorf("missing genesis file")
+			}
+			io.WriteString(w, `{"id":"abc","ip":"192.0.2.1"}`)
+		}
+	}))
+	defer server.Close()
+
+	sim := newSimulation(server.URL)
+	clients, err := sim.clients()
+	if err != nil {
+		t.Fatalf("failed to list clients: %v", err)
+	}
+	if len(clients) != 1 || !clients[0].hasRole("eth1") {
+		t.Fatalf("unexpected clients: %+v", clients)
+	}
+	suite, err := sim.startSuite("rip7560", "")
+	if err != nil {
+		t.Fatalf("failed to start suite: %v", err)
+	}
+	test, err := sim.startTest(suite, "test", "")
+	if err != nil {
+		t.Fatalf("failed to start test: %v", err)
+	}
+	ip, err := sim.startClient(suite, test, "go-ethereum", map[string]string{"HIVE_CHAIN_ID": "7560"}, map[string][]byte{"/genesis.json": []byte("{}")})
+	if err != nil {
+		t.Fatalf("failed to start client: %v", err)
+	}
+	if ip != "192.0.2.1" {
+		t.Errorf("unexpected client IP: %s", ip)
+	}
+	if err := sim.endTest(suite, test, true, ""); err != nil {
+		t.Fatalf("failed to end test: %v", err)
+	}
+	if err := sim.endSuite(suite); err != nil {
+		t.Fatalf("failed to end suite: %v", err)
+	}
+	want := []string{
+		"GET /clients",
+		"POST /testsuite",
+		"POST /testsuite/1/test",
+		"POST /testsuite/1/test/1/node",
+		"POST /testsuite/1/test/1",
+		"DELETE /testsuite/1",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("unexpected requests: have %v, want %v", requests, want)
+	}
+}
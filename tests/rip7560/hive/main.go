@@ -0,0 +1,99 @@
+// rip7560-hive is a hive simulator running the RIP-7560 suite against the clients of
+// a hive run. See the README for how to add it to hive.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/tests/rip7560"
+	"os"
+	"strings"
+)
+
+// jwtSecret is the engine API secret hive configures in all clients.
+var jwtSecret = common.HexToHash("0x7365637265747365637265747365637265747365637265747365637265747365")
+
+// rip7560Config is the node configuration enabling pushed RIP-7560 bundles. RIP-7560
+// options have no command line flags, so the client definition passes it to --config.
+const rip7560Config = "[Eth]\nRip7560AcceptPush = true\n"
+
+func main() {
+	sim := newSimulation(os.Getenv("HIVE_SIMULATOR"))
+	if err := run(sim); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(sim *simulation) error {
+	genesis, err := json.Marshal(rip7560.HiveGenesis())
+	if err != nil {
+		return err
+	}
+	clients, err := sim.clients()
+	if err != nil {
+		return fmt.Errorf("failed to list the clients: %w", err)
+	}
+	suite, err := sim.startSuite("rip7560", "Submits RIP-7560 transactions and bundles to a node, and validates its blocks, receipts and tracing output.")
+	if err != nil {
+		return fmt.Errorf("failed to start the suite: %w", err)
+	}
+	defer sim.endSuite(suite)
+
+	files := map[string][]byte{"/genesis.json": genesis, "/rip7560.toml": []byte(rip7560Config)}
+	env := map[string]string{
+		"HIVE_CHAIN_ID":       rip7560.HiveGenesis().Config.ChainID.String(),
+		"HIVE_NETWORK_ID":     rip7560.HiveGenesis().Config.ChainID.String(),
+		"HIVE_RIP7560_CONFIG": "/rip7560.toml",
+	}
+	for _, client := range clients {
+		if !client.hasRole("eth1") {
+			continue
+		}
+		if err := runClient(sim, suite, client.Name, env, files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runClient starts a node of the given client type, and runs each test of the suite
+// against it, reported as a hive test of its own.
+func runClient(sim *simulation, suite int, client string, env map[string]string, files map[string][]byte) error {
+	launch, err := sim.startTest(suite, fmt.Sprintf("%s: client launch", client), "Starts the node the RIP-7560 tests run against.")
+	if err != nil {
+		return err
+	}
+	ip, err := sim.startClient(suite, launch, client, env, files)
+	if err != nil {
+		return sim.endTest(suite, launch, false, fmt.Sprintf("failed to start the client: %v", err))
+	}
+	hiveSuite, err := rip7560.NewHiveSuite(fmt.Sprintf("http://%s:8545", ip), fmt.Sprintf("http://%s:8551", ip), jwtSecret)
+	if err != nil {
+		return sim.endTest(suite, launch, false, err.Error())
+	}
+	defer hiveSuite.Close()
+
+	var failed []string
+	for _, test := range hiveSuite.AllTests() {
+		id, err := sim.startTest(suite, fmt.Sprintf("%s: %s", client, test.Name), "")
+		if err != nil {
+			return err
+		}
+		var output bytes.Buffer
+		results := utesting.RunTests([]utesting.Test{test}, &output)
+		if results[0].Failed {
+			failed = append(failed, test.Name)
+		}
+		if err := sim.endTest(suite, id, !results[0].Failed, output.String()); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return sim.endTest(suite, launch, false, "failed tests: "+strings.Join(failed, ", "))
+	}
+	return sim.endTest(suite, launch, true, "")
+}
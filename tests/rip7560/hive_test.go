@@ -0,0 +1,77 @@
+package rip7560
+
+import (
+	crand "crypto/rand"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/node"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// runHiveNode starts a node from the genesis of the hive suite, serving the public RPC
+// and the engine API on random local ports.
+func runHiveNode(t *testing.T) (*node.Node, [32]byte) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to write jwt secret: %v", err)
+	}
+	stack, err := node.New(&node.Config{
+		DataDir:     t.TempDir(),
+		HTTPHost:    "127.0.0.1",
+		HTTPModules: []string{"eth"},
+		AuthAddr:    "127.0.0.1",
+		JWTSecret:   jwtPath,
+	})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	config := ethconfig.Defaults
+	config.Genesis = HiveGenesis()
+	config.Rip7560AcceptPush = true
+	backend, err := eth.New(stack, &config)
+	if err != nil {
+		stack.Close()
+		t.Fatalf("failed to create eth service: %v", err)
+	}
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	if err := catalyst.Register(stack, backend); err != nil {
+		stack.Close()
+		t.Fatalf("failed to register catalyst service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		t.Fatalf("failed to start node: %v", err)
+	}
+	return stack, secret
+}
+
+func TestHiveSuite(t *testing.T) {
+	stack, secret := runHiveNode(t)
+	defer stack.Close()
+
+	suite, err := NewHiveSuite(stack.HTTPEndpoint(), stack.HTTPAuthEndpoint(), secret)
+	if err != nil {
+		t.Fatalf("could not create the hive suite: %v", err)
+	}
+	defer suite.Close()
+
+	for _, test := range suite.AllTests() {
+		t.Run(test.Name, func(t *testing.T) {
+			result := utesting.RunTests([]utesting.Test{test}, os.Stdout)
+			if result[0].Failed {
+				t.Fatal()
+			}
+		})
+	}
+}
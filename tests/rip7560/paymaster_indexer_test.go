@@ -0,0 +1,121 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPaymasterIndexer(t *testing.T) {
+	var (
+		failingPaymaster = common.HexToAddress("0x7560000000000000000000000000000000000002")
+		senderA          = common.HexToAddress("0x7560000000000000000000000000000000000003")
+		senderB          = common.HexToAddress("0x7560000000000000000000000000000000000004")
+		ctx              = newTestContextBuilder(t).
+					withCode(senderA.Hex(), createAccountCode(), 0).
+					withCode(senderB.Hex(), createAccountCode(), 0).
+					withPaymaster(paymasterCode(0, 0, []byte{1}, createCode(vm.STOP)), DEFAULT_BALANCE).
+					withCode(failingPaymaster.Hex(), paymasterCode(0, 0, []byte{1}, createCode(vm.PUSH0, vm.PUSH0, vm.REVERT)), DEFAULT_BALANCE).
+					build()
+		sponsored = func(sender common.Address, nonce uint64, paymaster common.Address) *types.Transaction {
+			return types.NewTx(&types.Rip7560AccountAbstractionTx{
+				ChainID:                     ctx.genesis.Config.ChainID,
+				Sender:                      &sender,
+				Nonce:                       nonce,
+				Paymaster:                   &paymaster,
+				ValidationGasLimit:          1_000_000,
+				PaymasterValidationGasLimit: 1_000_000,
+				PostOpGas:                   100_000,
+				Gas:                         100_000,
+				GasFeeCap:                   big.NewInt(1_000_000_000),
+				GasTipCap:                   big.NewInt(1),
+			})
+		}
+		// sections of two blocks: the first one holds the genesis and block 1
+		blocks = [][]*types.Transaction{
+			{sponsored(senderA, 0, DEFAULT_PAYMASTER)},
+			{sponsored(senderB, 0, failingPaymaster)},
+			{sponsored(senderA, 1, failingPaymaster)},
+			{},
+		}
+	)
+	_, chain, _ := core.GenerateChainWithGenesis(ctx.genesis, beacon.New(ethash.NewFaker()), len(blocks), func(i int, b *core.BlockGen) {
+		b.AddTx(types.NewTx(&types.DepositTx{
+			SourceHash: common.BigToHash(b.Number()),
+			From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+			To:         &types.L1BlockAddr,
+			Gas:        1_000_000,
+			Data:       make([]byte, 4+32*8),
+		}))
+		for _, tx := range blocks[i] {
+			b.AddRip7560Tx(tx)
+		}
+	})
+	db := rawdb.NewMemoryDatabase()
+	blockchain, err := core.NewBlockChain(db, nil, ctx.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer blockchain.Stop()
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+
+	indexer := core.NewRip7560PaymasterIndexer(db, ctx.genesis.Config, 2, 0)
+	defer indexer.Close()
+	indexer.Start(blockchain)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if sections, _, _ := indexer.Sections(); sections == 2 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timed out waiting for the paymaster index")
+		}
+	}
+
+	// the expected stats are derived from the receipts of the sponsored transactions
+	expect := func(section uint64, blocks ...int) *types.Rip7560PaymasterStats {
+		stats := &types.Rip7560PaymasterStats{FromBlock: section * 2, ToBlock: section*2 + 1, GasPaid: new(big.Int)}
+		for _, i := range blocks {
+			receipt := blockchain.GetReceiptsByHash(chain[i].Hash())[1]
+			stats.Transactions++
+			stats.GasUsed += receipt.GasUsed
+			stats.GasPaid.Add(stats.GasPaid, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice))
+			if receipt.L1Fee != nil {
+				stats.GasPaid.Add(stats.GasPaid, receipt.L1Fee)
+			}
+		}
+		return stats
+	}
+	check := func(section uint64, paymaster common.Address, want *types.Rip7560PaymasterStats) {
+		t.Helper()
+		have := rawdb.ReadRip7560PaymasterStats(db, section, paymaster)
+		if want == nil {
+			if have != nil {
+				t.Errorf("section %d: unexpected stats of paymaster %v: %+v", section, paymaster, have)
+			}
+			return
+		}
+		if have == nil {
+			t.Fatalf("section %d: missing stats of paymaster %v", section, paymaster)
+		}
+		if have.FromBlock != want.FromBlock || have.ToBlock != want.ToBlock || have.Transactions != want.Transactions ||
+			have.GasUsed != want.GasUsed || have.GasPaid.Cmp(want.GasPaid) != 0 || have.PostOpFailures != want.PostOpFailures {
+			t.Errorf("section %d: stats mismatch of paymaster %v: have %+v, want %+v", section, paymaster, have, want)
+		}
+	}
+	// blocks are 1-based in the chain, but 0-based in the generated slice
+	failed := expect(1, 1, 2)
+	failed.PostOpFailures = 2
+	check(0, DEFAULT_PAYMASTER, expect(0, 0))
+	check(0, failingPaymaster, nil)
+	check(1, DEFAULT_PAYMASTER, nil)
+	check(1, failingPaymaster, failed)
+}
@@ -5,111 +5,192 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
 )
 
-var DEFAULT_PAYMASTER = common.HexToAddress("0xaaaaaaaaaabbbbbbbbbbccccccccccdddddddddd")
-
 func TestPaymasterValidationFailure_nobalance(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), createCode(vm.PUSH0, vm.DUP1, vm.REVERT), 1), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: 1000000000,
+		withPaymaster(paymasterCode(0, 0, nil, nil), 1), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	}, "validation phase failed with exception: insufficient funds for gas * price + value: RIP-7560 address 0xaaAaaAAAAAbBbbbbBbBBCCCCcCCCcCdddDDDdddd have 1 want 2015000000000000")
+}
+
+func TestPaymasterValidationFailure_no_gas_limit(t *testing.T) {
+
+	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(paymasterCode(0, 0, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: 1000000,
 		GasFeeCap:          big.NewInt(1000000000),
 		Paymaster:          &DEFAULT_PAYMASTER,
-	}, "insufficient funds for gas * price + value: address 0xaaAaaAAAAAbBbbbbBbBBCCCCcCCCcCdddDDDdddd have 1 want 1000000000000000000")
+	}, "validation phase failed with exception: paymaster address  0xaaAaaAAAAAbBbbbbBbBBCCCCcCCCcCdddDDDdddd is provided but 'paymasterVerificationGasLimit' is zero")
 }
 
 func TestPaymasterValidationFailure_oog(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), createCode(vm.PUSH0, vm.DUP1, vm.REVERT), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: 1000000000,
-		GasFeeCap:          big.NewInt(1000000000),
-		Paymaster:          &DEFAULT_PAYMASTER,
-	}, "out of gas")
+		withPaymaster(paymasterCode(0, 0, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 10,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	}, "validation phase failed in contract paymaster with exception: out of gas")
 }
+
 func TestPaymasterValidationFailure_revert(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), createCode(vm.PUSH0, vm.DUP1, vm.REVERT), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          uint64(1000000000),
+		withPaymaster(createCode(vm.PUSH0, vm.DUP1, vm.REVERT), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          uint64(1000000),
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-		PaymasterValidationGasLimit: 1000000000,
-	}, "execution reverted")
+		PaymasterValidationGasLimit: 1000000,
+	}, "validation phase failed in contract paymaster with exception: execution reverted")
 }
 
-func TestPaymasterValidationFailure_unparseable_return_value(t *testing.T) {
+func TestPaymasterValidationFailure_no_callback(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), createAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(returnWithData([]byte{}), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-	}, "paymaster return data: too short")
+	}, "validation phase failed with exception: paymaster validation did not call the EntryPoint 'acceptPaymaster' callback")
 }
 
-func TestPaymasterValidationFailure_wrong_magic(t *testing.T) {
+func TestPaymasterValidationFailure_wrong_callback(t *testing.T) {
+	// the paymaster accepts the transaction as an account would
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), returnWithData(paymasterReturnValue(1, 2, 3, []byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(createAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-	}, "paymaster did not return correct MAGIC_VALUE")
+	}, "validation phase failed with exception: unable to decode acceptPaymaster: got wrong method acceptAccount")
 }
 
 func TestPaymasterValidationFailure_contextTooLarge(t *testing.T) {
-	//paymaster returning huge context.
-	// first word is magic return value
-	// 2nd word is offset (fixed 64)
-	// 3rd word is length of context (max+1)
-	// then we return the total length of above (context itself is uninitialized string of max+1 zeroes)
+	//paymaster accepting with a huge context.
+	// only the head of the calldata (selector, validity range, context offset and length)
+	// is copied to memory: the context itself is the uninitialized memory following it.
+	calldata, _ := core.Rip7560Abi.Pack("acceptPaymaster", common.Big0, common.Big0, make([]byte, core.PaymasterMaxContextSize+1))
 	pmCode := createCode(
-		//vm.PUSH1, 1, vm.PUSH0, vm.RETURN,
-		copyToMemory(core.PackValidationData(core.AcceptPaymasterMethodSig, 0, 0), 0),
-		copyToMemory(asBytes32(64), 32),
-		copyToMemory(asBytes32(core.PaymasterMaxContextSize+1), 64),
-		push(core.PaymasterMaxContextSize+96+1), vm.PUSH0, vm.RETURN)
+		copyToMemory(calldata[:4+4*32], 0),
+		vm.PUSH0, vm.PUSH0, push(len(calldata)), vm.PUSH0, vm.PUSH0,
+		vm.PUSH20, core.AA_ENTRY_POINT, vm.GAS, vm.CALL, vm.POP, vm.STOP)
+
+	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(pmCode, DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		PostOpGas:                   1000000,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	}, "validation phase failed with exception: paymaster return data: context too large")
+}
 
+func TestPaymasterValidationFailure_context_without_postOp_gas(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), pmCode, DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(paymasterCode(0, 0, []byte{1, 2, 3}, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-	}, "paymaster return data: context too large")
+	}, "validation phase failed with exception: paymaster returned a context of size 3 but the paymasterPostOpGasLimit is 0")
 }
 
 func TestPaymasterValidationFailure_validAfter(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), returnWithData(paymasterReturnValue(core.AcceptPaymasterMethodSig, 300, 200, []byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(paymasterCode(300, 400, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-	}, "RIP-7560 transaction validity not reached yet")
+	}, "validation phase failed with exception: RIP-7560 transaction validity not reached yet")
 }
 
 func TestPaymasterValidationFailure_validUntil(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), returnWithData(paymasterReturnValue(core.AcceptPaymasterMethodSig, 1, 0, []byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(paymasterCode(0, 1, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
-	}, "RIP-7560 transaction validity expired")
+	}, "validation phase failed with exception: RIP-7560 transaction validity expired")
 }
 
 func TestPaymasterValidation_ok(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
-		withCode(DEFAULT_PAYMASTER.String(), returnWithData(paymasterReturnValue(core.AcceptPaymasterMethodSig, 0, 0, []byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit:          1000000000,
-		PaymasterValidationGasLimit: 1000000000,
+		withPaymaster(paymasterCode(0, 0, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
 		GasFeeCap:                   big.NewInt(1000000000),
 		Paymaster:                   &DEFAULT_PAYMASTER,
 	}, "ok")
 }
+
+func TestPaymasterPostOp_ok(t *testing.T) {
+	receipts, tracer, err := runTransactions(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(paymasterCode(0, 0, []byte{1, 2, 3}, createCode(vm.STOP)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		Gas:                         100000,
+		PostOpGas:                   100000,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipts[0].Status)
+	tracer.AssertPhases(t, PhaseAccount, PhasePaymaster, PhaseExecution, PhasePostOp)
+}
+
+func TestPaymasterPostOp_no_context(t *testing.T) {
+	// a paymaster returning no context is not called after the execution
+	_, tracer, err := runTransactions(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(paymasterCode(0, 0, nil, revertWithData([]byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		Gas:                         100000,
+		PostOpGas:                   100000,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	})
+	assert.NoError(t, err)
+	tracer.AssertPhases(t, PhaseAccount, PhasePaymaster, PhaseExecution)
+}
+
+func TestPaymasterPostOp_revert(t *testing.T) {
+	// a reverting postOp frame fails the transaction, but keeps it in the block
+	receipts, tracer, err := runTransactions(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(paymasterCode(0, 0, []byte{1, 2, 3}, revertWithData([]byte{})), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		Gas:                         100000,
+		PostOpGas:                   100000,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipts[0].Status)
+	tracer.AssertPhases(t, PhaseAccount, PhasePaymaster, PhaseExecution, PhasePostOp)
+}
+
+func TestPaymasterPostOp_oog(t *testing.T) {
+	receipts, _, err := runTransactions(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 0).
+		withPaymaster(paymasterCode(0, 0, []byte{1, 2, 3}, createCode(vm.PUSH1, byte(1), vm.PUSH0, vm.SSTORE)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit:          1000000,
+		PaymasterValidationGasLimit: 1000000,
+		Gas:                         100000,
+		PostOpGas:                   100,
+		GasFeeCap:                   big.NewInt(1000000000),
+		Paymaster:                   &DEFAULT_PAYMASTER,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipts[0].Status)
+}
@@ -43,7 +43,7 @@ func TestProcess1(t *testing.T) {
 		build(), []*types.Rip7560AccountAbstractionTx{
 		{
 			Sender:             &Sender,
-			ValidationGasLimit: uint64(1000000000),
+			ValidationGasLimit: uint64(1000000),
 			GasFeeCap:          big.NewInt(1000000000),
 			ExecutionData:      []byte{1, 2, 3},
 		},
@@ -95,6 +95,9 @@ func runProcess(t *testContext, aatxs []*types.Rip7560AccountAbstractionTx) erro
 
 	txs := []*types.Transaction{tx1}
 	for _, aatx := range aatxs {
+		if aatx.ChainID == nil {
+			aatx.ChainID = t.genesis.Config.ChainID
+		}
 		txs = append(txs, types.NewTx(aatx))
 	}
 	txs = append(txs, tx3)
@@ -112,3 +115,174 @@ func runProcess(t *testContext, aatxs []*types.Rip7560AccountAbstractionTx) erro
 func shouldPreserve(*types.Header) bool {
 	return false
 }
+
+// consecutive RIP-7560 transactions of a block are processed together: their logs are
+// attributed to their position in the block, and starting with RIP-7711 all of them are
+// validated before any of them is executed, as during block building
+func TestProcessRip7560TxContext(t *testing.T) {
+	const other = "0x2222222222333333333344444444445555555555"
+	// an account emitting a log in each of its frames
+	logging := createCode(vm.PUSH0, vm.PUSH0, vm.LOG0, createAccountCode())
+	for _, twoPhase := range []bool{false, true} {
+		ctx := newTestContextBuilder(t).
+			withCode(DEFAULT_SENDER, logging, DEFAULT_BALANCE).
+			withCode(other, logging, DEFAULT_BALANCE).
+			build()
+		if twoPhase {
+			ctx.genesis.Config.RIP7711Block = big.NewInt(0)
+		}
+		var txs []*types.Transaction
+		for _, sender := range []string{DEFAULT_SENDER, other} {
+			addr := common.HexToAddress(sender)
+			txs = append(txs, types.NewTx(&types.Rip7560AccountAbstractionTx{
+				ChainID:            ctx.genesis.Config.ChainID,
+				Sender:             &addr,
+				NonceKey:           big.NewInt(0),
+				ValidationGasLimit: 1_000_000,
+				Gas:                100_000,
+				GasFeeCap:          big.NewInt(1_000_000_000),
+				ExecutionData:      []byte{1, 2, 3},
+			}))
+		}
+		db := rawdb.NewMemoryDatabase()
+		state := tests.MakePreState(db, ctx.genesisAlloc, false, rawdb.HashScheme)
+		defer state.Close()
+		blockchain, err := core.NewBlockChain(db, &core.CacheConfig{}, ctx.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, shouldPreserve, nil)
+		if err != nil {
+			t.Fatalf("NewBlockChain failed: %v", err)
+		}
+		// without a beacon root, only the frames of the transactions are traced
+		header := types.CopyHeader(blockchain.CurrentBlock())
+		header.ParentBeaconRoot = nil
+		block := types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+		tracer := NewFrameTracer()
+		receipts, _, _, err := blockchain.Processor().Process(block, state.StateDB, vm.Config{Tracer: tracer.Hooks()})
+		blockchain.Stop()
+		if err != nil {
+			t.Fatalf("two phases %v: failed to process block: %v", twoPhase, err)
+		}
+		if twoPhase {
+			tracer.AssertPhases(t, PhaseAccount, PhaseAccount, PhaseExecution, PhaseExecution)
+		} else {
+			tracer.AssertPhases(t, PhaseAccount, PhaseExecution, PhaseAccount, PhaseExecution)
+		}
+		var logIndex uint
+		for i, receipt := range receipts {
+			if receipt.TransactionIndex != uint(i) || receipt.BlockHash != block.Hash() || len(receipt.Logs) == 0 {
+				t.Fatalf("two phases %v: receipt %d mismatch: transaction index %d, block %x, %d logs", twoPhase, i, receipt.TransactionIndex, receipt.BlockHash, len(receipt.Logs))
+			}
+			// including the events injected on behalf of the EntryPoint
+			for _, log := range receipt.Logs {
+				if log.TxHash != txs[i].Hash() || log.TxIndex != uint(i) || log.Index != logIndex {
+					t.Errorf("two phases %v: receipt %d log mismatch: tx %x index %d, log index %d, want %d", twoPhase, i, log.TxHash, log.TxIndex, log.Index, logIndex)
+				}
+				if log.BlockHash != block.Hash() || log.BlockNumber != block.NumberU64() {
+					t.Errorf("two phases %v: receipt %d log block mismatch: have %d %x, want %d %x", twoPhase, i, log.BlockNumber, log.BlockHash, block.NumberU64(), block.Hash())
+				}
+				logIndex++
+			}
+		}
+	}
+}
+
+// generateRip7560DivergentEvents generates a block with an RIP-7560 transaction, along with
+// its receipts in which the RIP7560TransactionEvent reports another execution status,
+// leaving the bloom unchanged.
+func generateRip7560DivergentEvents(ctx *testContext) (types.Blocks, types.Receipts) {
+	sender := common.HexToAddress(DEFAULT_SENDER)
+	_, blocks, receipts := core.GenerateChainWithGenesis(ctx.genesis, beacon.New(ethash.NewFaker()), 1, func(i int, b *core.BlockGen) {
+		b.AddTx(types.NewTx(&types.DepositTx{
+			SourceHash: common.BigToHash(b.Number()),
+			From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+			To:         &types.L1BlockAddr,
+			Gas:        1_000_000,
+			Data:       make([]byte, 4+32*8),
+		}))
+		b.AddRip7560Tx(types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            ctx.genesis.Config.ChainID,
+			Sender:             &sender,
+			ValidationGasLimit: 1_000_000,
+			Gas:                100_000,
+			GasFeeCap:          big.NewInt(1_000_000_000),
+			GasTipCap:          big.NewInt(1),
+		}))
+	})
+	divergent := make(types.Receipts, len(receipts[0]))
+	for i, receipt := range receipts[0] {
+		cpy := *receipt
+		cpy.Logs = make([]*types.Log, len(receipt.Logs))
+		for j, log := range receipt.Logs {
+			event := *log
+			if log.Topics[0] == core.Rip7560Abi.Events["RIP7560TransactionEvent"].ID {
+				event.Data = common.CopyBytes(log.Data)
+				event.Data[len(event.Data)-1] ^= 1
+			}
+			cpy.Logs[j] = &event
+		}
+		divergent[i] = &cpy
+	}
+	return blocks, divergent
+}
+
+// TestImportRip7560DivergentEvents imports a block whose receipt root commits to an
+// RIP7560TransactionEvent other than the one produced by re-executing the block.
+func TestImportRip7560DivergentEvents(t *testing.T) {
+	ctx := newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).build()
+	blocks, divergent := generateRip7560DivergentEvents(ctx)
+
+	header := types.CopyHeader(blocks[0].Header())
+	header.ReceiptHash = types.DeriveSha(divergent, trie.NewStackTrie(nil))
+	if header.ReceiptHash == blocks[0].ReceiptHash() {
+		t.Fatal("receipt root does not commit to the RIP-7560 events")
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, ctx.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(types.Blocks{blocks[0].WithSeal(header)}); err == nil {
+		t.Fatal("block with divergent RIP-7560 events imported")
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import the block: %v", err)
+	}
+}
+
+// TestImportRip7560PreForkReceiptRoot imports a block from before the RIP7560ReceiptRoot
+// fork, whose receipt root only commits to the type of the RIP-7560 receipts.
+func TestImportRip7560PreForkReceiptRoot(t *testing.T) {
+	ctx := newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).build()
+	ctx.genesis.Config.RIP7560ReceiptRootBlock = big.NewInt(2)
+	blocks, divergent := generateRip7560DivergentEvents(ctx)
+
+	if root := types.DeriveSha(divergent, trie.NewStackTrie(nil)); root != blocks[0].ReceiptHash() {
+		t.Fatalf("pre-fork receipt root commits to the RIP-7560 events: have %x, want %x", blocks[0].ReceiptHash(), root)
+	}
+	// the root a node committing the RIP-7560 receipts before the fork would compute
+	version := types.Rip7560ReceiptRootVersion
+	for _, receipt := range divergent {
+		if receipt.Type == types.Rip7560Type {
+			receipt.Rip7560ReceiptVersion = &version
+		}
+	}
+	header := types.CopyHeader(blocks[0].Header())
+	header.ReceiptHash = types.DeriveSha(divergent, trie.NewStackTrie(nil))
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, ctx.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(types.Blocks{blocks[0].WithSeal(header)}); err == nil {
+		t.Fatal("pre-fork block committing the RIP-7560 receipts imported")
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import the pre-fork block: %v", err)
+	}
+	receipts := chain.GetReceiptsByHash(blocks[0].Hash())
+	if len(receipts) != 2 || receipts[1].Rip7560ReceiptVersion != nil {
+		t.Errorf("pre-fork RIP-7560 receipt versioned: %v", receipts)
+	}
+}
@@ -0,0 +1,206 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"testing"
+)
+
+// the embedding API applies a bundle the same way, whether as a whole or one phase at a time
+func TestRip7560Processor(t *testing.T) {
+	const rejecting = "0x2222222222333333333344444444445555555555"
+	ctx := newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withCode(rejecting, revertWithData([]byte{}), DEFAULT_BALANCE).
+		build()
+	newTx := func(sender string) *types.Transaction {
+		addr := common.HexToAddress(sender)
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            ctx.genesis.Config.ChainID,
+			Sender:             &addr,
+			NonceKey:           big.NewInt(0),
+			ValidationGasLimit: 1_000_000,
+			Gas:                100_000,
+			GasFeeCap:          big.NewInt(1_000_000_000),
+			ExecutionData:      []byte{1, 2, 3},
+		})
+	}
+	var (
+		valid    = newTx(DEFAULT_SENDER)
+		invalid  = newTx(rejecting)
+		header   = ctx.genesisBlock.Header()
+		proc     = core.NewRip7560Processor(ctx.genesis.Config, nil, vm.Config{})
+		bundleDb = tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+		phaseDb  = tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+	)
+	defer bundleDb.Close()
+	defer phaseDb.Close()
+
+	var bundleGas uint64
+	res, err := proc.ProcessBundle(bundleDb.StateDB, header, new(core.GasPool).AddGas(header.GasLimit), 2, []*types.Transaction{invalid, valid}, &bundleGas)
+	if err != nil {
+		t.Fatalf("failed to process bundle: %v", err)
+	}
+	if len(res.Included) != 1 || res.Included[0] != valid || len(res.Receipts) != 1 {
+		t.Fatalf("included transactions mismatch: have %d, want the valid one", len(res.Included))
+	}
+	if len(res.Failures) != 1 || res.Failures[0].TxHash != invalid.Hash() {
+		t.Fatalf("validation failures mismatch: have %d, want the invalid one", len(res.Failures))
+	}
+
+	var phaseGas uint64
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	snapshot := phaseDb.StateDB.Snapshot()
+	if _, err := proc.ValidateTransaction(phaseDb.StateDB, header, gp, 2, invalid); err == nil {
+		t.Fatal("expected validation of the rejecting account to fail")
+	}
+	phaseDb.StateDB.RevertToSnapshot(snapshot)
+	gp = new(core.GasPool).AddGas(header.GasLimit)
+	vpr, err := proc.ValidateTransaction(phaseDb.StateDB, header, gp, 3, valid)
+	if err != nil {
+		t.Fatalf("failed to validate transaction: %v", err)
+	}
+	receipt, err := proc.ExecuteTransaction(phaseDb.StateDB, header, gp, vpr, &phaseGas)
+	if err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	if receipt.GasUsed != res.Receipts[0].GasUsed || phaseGas != bundleGas {
+		t.Errorf("gas used mismatch: have %d (total %d), want %d (total %d)", receipt.GasUsed, phaseGas, res.Receipts[0].GasUsed, bundleGas)
+	}
+	if have, want := phaseDb.StateDB.IntermediateRoot(true), bundleDb.StateDB.IntermediateRoot(true); have != want {
+		t.Errorf("state root mismatch: have %x, want %x", have, want)
+	}
+}
+
+// a bundle is applied atomically: with any transaction failing validation, none is included
+func TestHandleRip7560Bundle(t *testing.T) {
+	const rejecting = "0x2222222222333333333344444444445555555555"
+	ctx := newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withCode(rejecting, revertWithData([]byte{}), DEFAULT_BALANCE).
+		build()
+	newTx := func(sender string) *types.Transaction {
+		addr := common.HexToAddress(sender)
+		return types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            ctx.genesis.Config.ChainID,
+			Sender:             &addr,
+			NonceKey:           big.NewInt(0),
+			ValidationGasLimit: 1_000_000,
+			Gas:                100_000,
+			GasFeeCap:          big.NewInt(1_000_000_000),
+			ExecutionData:      []byte{1, 2, 3},
+		})
+	}
+	var (
+		valid   = newTx(DEFAULT_SENDER)
+		invalid = newTx(rejecting)
+		header  = ctx.genesisBlock.Header()
+		db      = tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+	)
+	defer db.Close()
+
+	var (
+		root    = db.StateDB.IntermediateRoot(true)
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	bundle := &types.ExternallyReceivedBundle{Transactions: []*types.Transaction{valid, invalid}}
+	state, receipts, failures, err := core.HandleRip7560Bundle(bundle, 0, db.StateDB, &header.Coinbase, header, gp, ctx.genesis.Config, nil, vm.Config{}, &usedGas)
+	if err != nil {
+		t.Fatalf("failed to handle bundle: %v", err)
+	}
+	if state != nil || len(receipts) != 0 {
+		t.Fatalf("bundle with an invalid transaction partially included: %d receipts", len(receipts))
+	}
+	if len(failures) != 1 || failures[0].TxHash != invalid.Hash() {
+		t.Fatalf("validation failures mismatch: have %d, want the invalid one", len(failures))
+	}
+	if gp.Gas() != header.GasLimit || usedGas != 0 {
+		t.Errorf("gas consumed by a dropped bundle: pool %d of %d, used %d", gp.Gas(), header.GasLimit, usedGas)
+	}
+	if have := db.StateDB.IntermediateRoot(true); have != root {
+		t.Errorf("state modified by a dropped bundle: have %x, want %x", have, root)
+	}
+
+	bundle = &types.ExternallyReceivedBundle{Transactions: []*types.Transaction{valid}}
+	state, receipts, failures, err = core.HandleRip7560Bundle(bundle, 0, db.StateDB, &header.Coinbase, header, gp, ctx.genesis.Config, nil, vm.Config{}, &usedGas)
+	if err != nil {
+		t.Fatalf("failed to handle bundle: %v", err)
+	}
+	if state == nil || len(receipts) != 1 || len(failures) != 0 {
+		t.Fatalf("valid bundle not included: %d receipts, %d failures", len(receipts), len(failures))
+	}
+	if usedGas != receipts[0].GasUsed || gp.Gas() != header.GasLimit-usedGas {
+		t.Errorf("gas accounting mismatch: pool %d of %d, used %d, receipt %d", gp.Gas(), header.GasLimit, usedGas, receipts[0].GasUsed)
+	}
+	if state.IntermediateRoot(true) == root {
+		t.Error("bundle state not modified")
+	}
+}
+
+// starting with RIP-7711, all transactions are validated before any of them is executed
+func TestRip7711TwoPhaseProcessing(t *testing.T) {
+	const other = "0x2222222222333333333344444444445555555555"
+	// an account emitting a log in each of its frames
+	logging := createCode(vm.PUSH0, vm.PUSH0, vm.LOG0, createAccountCode())
+	for _, twoPhase := range []bool{false, true} {
+		ctx := newTestContextBuilder(t).
+			withCode(DEFAULT_SENDER, logging, DEFAULT_BALANCE).
+			withCode(other, logging, DEFAULT_BALANCE).
+			build()
+		if twoPhase {
+			ctx.genesis.Config.RIP7711Block = big.NewInt(0)
+		}
+		var txs []*types.Transaction
+		for _, sender := range []string{DEFAULT_SENDER, other} {
+			addr := common.HexToAddress(sender)
+			txs = append(txs, types.NewTx(&types.Rip7560AccountAbstractionTx{
+				ChainID:            ctx.genesis.Config.ChainID,
+				Sender:             &addr,
+				NonceKey:           big.NewInt(0),
+				ValidationGasLimit: 1_000_000,
+				Gas:                100_000,
+				GasFeeCap:          big.NewInt(1_000_000_000),
+				ExecutionData:      []byte{1, 2, 3},
+			}))
+		}
+		db := tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+		defer db.Close()
+
+		var (
+			header  = ctx.genesisBlock.Header()
+			tracer  = NewFrameTracer()
+			usedGas uint64
+		)
+		_, receipts, _, _, err := core.HandleRip7560Transactions(txs, 0, db.StateDB, &header.Coinbase, header, ctx.gaspool, ctx.genesis.Config, nil, vm.Config{Tracer: tracer.Hooks(), Rip7560GasInvariants: true}, false, &usedGas)
+		if err != nil {
+			t.Fatalf("two phases %v: failed to handle transactions: %v", twoPhase, err)
+		}
+		if twoPhase {
+			tracer.AssertPhases(t, PhaseAccount, PhaseAccount, PhaseExecution, PhaseExecution)
+		} else {
+			tracer.AssertPhases(t, PhaseAccount, PhaseExecution, PhaseAccount, PhaseExecution)
+		}
+		// the logs are attributed to their transaction and numbered in the order of the receipts
+		var logIndex uint
+		for i, receipt := range receipts {
+			if receipt.TransactionIndex != uint(i) {
+				t.Errorf("two phases %v: receipt %d transaction index mismatch: have %d", twoPhase, i, receipt.TransactionIndex)
+			}
+			if len(receipt.Logs) == 0 {
+				t.Fatalf("two phases %v: receipt %d has no logs", twoPhase, i)
+			}
+			for _, log := range receipt.Logs {
+				if log.TxHash != txs[i].Hash() || log.TxIndex != uint(i) || log.Index != logIndex {
+					t.Errorf("two phases %v: receipt %d log mismatch: tx %x index %d, log index %d, want %d", twoPhase, i, log.TxHash, log.TxIndex, log.Index, logIndex)
+				}
+				logIndex++
+			}
+		}
+	}
+}
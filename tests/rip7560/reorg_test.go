@@ -0,0 +1,341 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool/rip7560pool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// reorgTest is a chain of RIP-7560 accounts served by a bundler pool, along with
+// the events the pool emits for the transactions it tracks.
+type reorgTest struct {
+	t       *testing.T
+	genesis *core.Genesis
+	db      ethdb.Database
+	chain   *core.BlockChain
+	pool    *rip7560pool.Rip7560BundlerPool
+	events  chan core.Rip7560TxStatusEvent
+	head    *types.Header
+}
+
+func newReorgTest(t *testing.T, accounts ...common.Address) *reorgTest {
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	// The forks are shared between tests, enable RIP-7712 nonces on a copy
+	chainConfig := *config
+	chainConfig.RIP7712Block = big.NewInt(0)
+
+	genesis := &core.Genesis{
+		Config:   &chainConfig,
+		Alloc:    types.GenesisAlloc{},
+		BaseFee:  big.NewInt(params.InitialBaseFee),
+		GasLimit: 30_000_000,
+	}
+	for _, account := range accounts {
+		genesis.Alloc[account] = types.Account{Code: acceptAccountCode(0, 0), Balance: big.NewInt(DEFAULT_BALANCE)}
+	}
+	genesis.Alloc[core.AA_NONCE_MANAGER] = types.Account{Code: nonceManagerCode(), Balance: new(big.Int)}
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	pool := rip7560pool.New(rip7560pool.Config{}, chain, common.Address{})
+	head := chain.CurrentBlock()
+	if err := pool.Init(0, head, nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	events := make(chan core.Rip7560TxStatusEvent, 16)
+	sub := pool.SubscribeRip7560TxStatus(events)
+	t.Cleanup(sub.Unsubscribe)
+
+	return &reorgTest{t: t, genesis: genesis, db: db, chain: chain, pool: pool, events: events, head: head}
+}
+
+// aaTx creates an RIP-7560 transaction of the given sender, distinguished by its
+// execution data from the other ones with the same nonce.
+func (tt *reorgTest) aaTx(sender common.Address, nonce uint64, data byte) *types.Transaction {
+	return tt.aaKeyTx(sender, 0, nonce, data)
+}
+
+// aaKeyTx creates an RIP-7560 transaction of the given sender using an RIP-7712 nonce
+// key, or the account nonce if the key is zero.
+func (tt *reorgTest) aaKeyTx(sender common.Address, nonceKey int64, nonce uint64, data byte) *types.Transaction {
+	return types.NewTx(&types.Rip7560AccountAbstractionTx{
+		ChainID:            tt.genesis.Config.ChainID,
+		Sender:             &sender,
+		NonceKey:           big.NewInt(nonceKey),
+		Nonce:              nonce,
+		ValidationGasLimit: 1_000_000,
+		Gas:                100_000,
+		GasTipCap:          big.NewInt(1),
+		GasFeeCap:          big.NewInt(1_000_000_000),
+		BuilderFee:         new(big.Int),
+		ExecutionData:      []byte{data},
+	})
+}
+
+// makeChain generates a chain on top of the genesis, with blocks containing the
+// given RIP-7560 transactions after the L1 attributes deposit of the rollup.
+func (tt *reorgTest) makeChain(blocks ...[]*types.Transaction) []*types.Block {
+	_, chain, _ := core.GenerateChainWithGenesis(tt.genesis, beacon.New(ethash.NewFaker()), len(blocks), func(i int, b *core.BlockGen) {
+		b.AddTx(types.NewTx(&types.DepositTx{
+			SourceHash: common.BigToHash(b.Number()),
+			From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+			To:         &types.L1BlockAddr,
+			Gas:        1_000_000,
+			Data:       make([]byte, 4+32*8),
+		}))
+		for _, tx := range blocks[i] {
+			b.AddRip7560Tx(tx)
+		}
+	})
+	return chain
+}
+
+// insert imports the blocks into the chain and resets the pool to the new head.
+func (tt *reorgTest) insert(blocks ...*types.Block) {
+	tt.t.Helper()
+	if _, err := tt.chain.InsertChain(blocks); err != nil {
+		tt.t.Fatalf("failed to insert blocks: %v", err)
+	}
+	head := tt.chain.CurrentBlock()
+	if head.Hash() != blocks[len(blocks)-1].Hash() {
+		tt.t.Fatalf("chain head mismatch: have %v, want %v", head.Hash(), blocks[len(blocks)-1].Hash())
+	}
+	tt.pool.Reset(tt.head, head)
+	tt.head = head
+}
+
+func (tt *reorgTest) submit(validForBlock int64, txs ...*types.Transaction) *types.ExternallyReceivedBundle {
+	tt.t.Helper()
+	bundle := &types.ExternallyReceivedBundle{
+		BundlerId:     "bundler",
+		BundleHash:    common.Hash{byte(validForBlock), byte(len(txs))},
+		ValidForBlock: big.NewInt(validForBlock),
+		Transactions:  txs,
+	}
+	if err := tt.pool.SubmitRip7560Bundle(bundle); err != nil {
+		tt.t.Fatalf("failed to submit bundle: %v", err)
+	}
+	tt.expect(core.Rip7560TxAccepted, txs...)
+	return bundle
+}
+
+// expect asserts the pool emitted the given status for the transactions, in order.
+func (tt *reorgTest) expect(status core.Rip7560TxStatus, txs ...*types.Transaction) []core.Rip7560TxStatusEvent {
+	tt.t.Helper()
+	received := make([]core.Rip7560TxStatusEvent, 0, len(txs))
+	for _, tx := range txs {
+		select {
+		case ev := <-tt.events:
+			if ev.TxHash != tx.Hash() || ev.Status != status {
+				tt.t.Fatalf("unexpected event: have %v %s, want %v %s", ev.TxHash, ev.Status, tx.Hash(), status)
+			}
+			received = append(received, ev)
+		default:
+			tt.t.Fatalf("missing %s event for %v", status, tx.Hash())
+		}
+	}
+	return received
+}
+
+func (tt *reorgTest) expectNoEvents() {
+	tt.t.Helper()
+	select {
+	case ev := <-tt.events:
+		tt.t.Fatalf("unexpected event: %v %s", ev.TxHash, ev.Status)
+	default:
+	}
+}
+
+// expectReceipt asserts the canonical receipt of a transaction is available in the
+// given block, or unavailable if the block is nil.
+func (tt *reorgTest) expectReceipt(tx *types.Transaction, block *types.Block) {
+	tt.t.Helper()
+	receipt, blockHash, _, _ := rawdb.ReadReceipt(tt.db, tx.Hash(), tt.chain.Config())
+	switch {
+	case block == nil && receipt != nil:
+		tt.t.Errorf("receipt of %v still available in block %v", tx.Hash(), blockHash)
+	case block != nil && receipt == nil:
+		tt.t.Errorf("receipt of %v not available", tx.Hash())
+	case block != nil && (blockHash != block.Hash() || receipt.TxHash != tx.Hash()):
+		tt.t.Errorf("receipt of %v mismatch: have block %v, want %v", tx.Hash(), blockHash, block.Hash())
+	}
+}
+
+func (tt *reorgTest) expectBundleIncluded(bundle *types.ExternallyReceivedBundle, block *types.Block) {
+	tt.t.Helper()
+	receipt, err := tt.pool.GetRip7560BundleStatus(bundle.BundleHash)
+	if err != nil {
+		tt.t.Fatalf("failed to get bundle status: %v", err)
+	}
+	switch {
+	case block == nil && receipt != nil:
+		tt.t.Errorf("bundle %v still reported as included in block %v", bundle.BundleHash, receipt.BlockHash)
+	case block != nil && receipt == nil:
+		tt.t.Errorf("bundle %v not reported as included", bundle.BundleHash)
+	case block != nil && receipt.BlockHash != block.Hash():
+		tt.t.Errorf("bundle %v included in wrong block: have %v, want %v", bundle.BundleHash, receipt.BlockHash, block.Hash())
+	}
+}
+
+// Tests that a bundle included in a block abandoned by a reorg has its still valid
+// transactions re-injected and included again in the new chain, while the ones
+// invalidated by a competing transaction are dropped.
+func TestReorgBundleReinjection(t *testing.T) {
+	var (
+		sender = common.HexToAddress(DEFAULT_SENDER)
+		second = common.HexToAddress("0x5555555555666666666677777777778888888888")
+		tt     = newReorgTest(t, sender, second)
+
+		stale     = tt.aaTx(sender, 0, 1)
+		competing = tt.aaTx(sender, 0, 2)
+		reorged   = tt.aaTx(second, 0, 1)
+	)
+	bundle := tt.submit(1, stale, reorged)
+	if pending, err := tt.pool.PendingRip7560Bundle(); err != nil || pending != bundle {
+		t.Fatalf("bundle not selected: %v", err)
+	}
+	tt.expect(core.Rip7560TxSelected, stale, reorged)
+
+	// Include the bundle in the first chain
+	chainA := tt.makeChain([]*types.Transaction{stale, reorged})
+	tt.insert(chainA...)
+	for _, ev := range tt.expect(core.Rip7560TxIncluded, stale, reorged) {
+		if ev.Receipt == nil || ev.Receipt.BlockHash != chainA[0].Hash() || ev.Receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("unexpected receipt for included transaction %v: %+v", ev.TxHash, ev.Receipt)
+		}
+	}
+	tt.expectNoEvents()
+	tt.expectBundleIncluded(bundle, chainA[0])
+	tt.expectReceipt(stale, chainA[0])
+	tt.expectReceipt(reorged, chainA[0])
+
+	// Reorg to a longer chain in which the sender spent its nonce on another transaction
+	chainB := tt.makeChain([]*types.Transaction{competing}, nil, []*types.Transaction{reorged})
+	tt.insert(chainB[:2]...)
+
+	dropped := tt.expect(core.Rip7560TxDropped, stale)[0]
+	if !strings.Contains(dropped.Reason, "chain reorganization") {
+		t.Errorf("unexpected drop reason: %q", dropped.Reason)
+	}
+	tt.expect(core.Rip7560TxRevalidated, reorged)
+	tt.expectNoEvents()
+
+	tt.expectBundleIncluded(bundle, nil)
+	tt.expectReceipt(stale, nil)
+	tt.expectReceipt(reorged, nil)
+	tt.expectReceipt(competing, chainB[0])
+	if tt.pool.Has(stale.Hash()) || !tt.pool.Has(reorged.Hash()) {
+		t.Errorf("unexpected pool content after reorg")
+	}
+	pending, err := tt.pool.PendingRip7560Bundle()
+	if err != nil {
+		t.Fatalf("failed to get pending bundle: %v", err)
+	}
+	if pending.BundleHash != bundle.BundleHash || pending.ValidForBlock.Uint64() != 3 || len(pending.Transactions) != 1 || pending.Transactions[0].Hash() != reorged.Hash() {
+		t.Fatalf("unexpected re-injected bundle: hash %v, valid for %v, %d txs", pending.BundleHash, pending.ValidForBlock, len(pending.Transactions))
+	}
+	tt.expect(core.Rip7560TxSelected, reorged)
+
+	// Include the re-injected bundle in the new chain
+	tt.insert(chainB[2])
+	tt.expect(core.Rip7560TxIncluded, reorged)
+	tt.expectNoEvents()
+	tt.expectBundleIncluded(bundle, chainB[2])
+	tt.expectReceipt(reorged, chainB[2])
+}
+
+// Tests that building a block on top of the new chain reports the transactions
+// invalidated by a reorg through the debug info of the chain.
+func TestReorgDebugInfo(t *testing.T) {
+	var (
+		sender = common.HexToAddress(DEFAULT_SENDER)
+		tt     = newReorgTest(t, sender)
+
+		stale     = tt.aaTx(sender, 0, 1)
+		competing = tt.aaTx(sender, 0, 2)
+	)
+	tt.insert(tt.makeChain([]*types.Transaction{stale})...)
+	chainB := tt.makeChain([]*types.Transaction{competing}, nil)
+	tt.insert(chainB...)
+
+	// Build on top of the new head with the transaction of the abandoned block
+	statedb, err := tt.chain.State()
+	if err != nil {
+		t.Fatalf("failed to get head state: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: tt.head.Hash(),
+		Number:     new(big.Int).Add(tt.head.Number, common.Big1),
+		GasLimit:   tt.head.GasLimit,
+		Time:       tt.head.Time + 1,
+		BaseFee:    tt.head.BaseFee,
+		Difficulty: new(big.Int),
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	included, _, infos, _, err := core.HandleRip7560Transactions([]*types.Transaction{stale}, 0, statedb, &header.Coinbase, header, gp, tt.chain.Config(), tt.chain, vm.Config{}, true, new(uint64))
+	if err != nil {
+		t.Fatalf("failed to build block: %v", err)
+	}
+	if len(included) != 0 || len(infos) != 1 {
+		t.Fatalf("stale transaction not skipped: %d included, %d debug infos", len(included), len(infos))
+	}
+	tt.chain.SetRip7560TransactionDebugInfo(infos)
+
+	info := tt.chain.GetRip7560TransactionDebugInfo(stale.Hash())
+	if info == nil {
+		t.Fatalf("missing debug info for stale transaction")
+	}
+	if info.TxHash != stale.Hash() || !strings.Contains(info.RevertData, "nonce too low") || info.FrameReverted {
+		t.Errorf("unexpected debug info: %+v", info)
+	}
+	if tt.chain.GetRip7560TransactionDebugInfo(competing.Hash()) != nil {
+		t.Errorf("unexpected debug info for included transaction")
+	}
+}
+
+// Tests that the RIP-7712 nonce keys of the blocks reorged out of the chain are no
+// longer listed, while the ones also used by the new chain are kept.
+func TestReorgRip7712NonceKeys(t *testing.T) {
+	var (
+		sender = common.HexToAddress(DEFAULT_SENDER)
+		tt     = newReorgTest(t, sender)
+	)
+	expectKeys := func(want ...int64) {
+		t.Helper()
+		keys := rawdb.ReadRip7712NonceKeys(tt.db, sender)
+		if len(keys) != len(want) {
+			t.Fatalf("nonce key count mismatch: have %v, want %v", keys, want)
+		}
+		for i, key := range keys {
+			if key.Int64() != want[i] {
+				t.Fatalf("nonce key %d mismatch: have %v, want %d", i, key, want[i])
+			}
+		}
+	}
+	chainA := tt.makeChain([]*types.Transaction{tt.aaKeyTx(sender, 5, 0, 1), tt.aaKeyTx(sender, 7, 0, 1)})
+	tt.insert(chainA...)
+	expectKeys(5, 7)
+
+	// Reorg to a longer chain using one of the keys at the same number, and another one
+	chainB := tt.makeChain([]*types.Transaction{tt.aaKeyTx(sender, 5, 0, 2)}, []*types.Transaction{tt.aaKeyTx(sender, 6, 0, 2)})
+	tt.insert(chainB...)
+	expectKeys(5, 6)
+}
@@ -2,14 +2,13 @@ package rip7560
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/tests"
 	"github.com/status-im/keycard-go/hexutils"
 	"math/big"
 	"testing"
@@ -18,10 +17,12 @@ import (
 const DEFAULT_SENDER = "0x1111111111222222222233333333334444444444"
 const DEFAULT_BALANCE = 1 << 62
 
+var DEFAULT_PAYMASTER = common.HexToAddress("0xaaaaaaaaaabbbbbbbbbbccccccccccdddddddddd")
+var DEPLOYER = common.HexToAddress("0xddddddddddeeeeeeeeeeddddddddddeeeeeeeeee")
+
 type testContext struct {
 	genesisAlloc types.GenesisAlloc
 	t            *testing.T
-	chainContext *ethapi.ChainContext
 	gaspool      *core.GasPool
 	genesis      *core.Genesis
 	genesisBlock *types.Block
@@ -34,6 +35,7 @@ func newTestContext(t *testing.T) *testContext {
 type testContextBuilder struct {
 	t            *testing.T
 	genesisAlloc types.GenesisAlloc
+	nonceManager *common.Address
 }
 
 func newTestContextBuilder(t *testing.T) *testContextBuilder {
@@ -71,18 +73,28 @@ func create2(deployedCode []byte) []byte {
 }
 
 func (tb *testContextBuilder) build() *testContext {
-	genesis := core.DeveloperGenesisBlock(10_000_000, &common.Address{})
-	genesis.Timestamp = 100
+	config, _, err := tests.GetChainConfig("CancunRIP7560")
+	if err != nil {
+		tb.t.Fatalf("failed to load the chain config: %v", err)
+	}
+	// The forks are shared between tests, enable RIP-7712 nonces on a copy
+	chainConfig := *config
+	chainConfig.RIP7712Block = big.NewInt(0)
+	chainConfig.RIP7712NonceManager = tb.nonceManager
+
+	genesis := &core.Genesis{
+		Config:     &chainConfig,
+		GasLimit:   30_000_000,
+		Timestamp:  100,
+		Difficulty: new(big.Int),
+		Alloc:      tb.genesisAlloc,
+	}
 	genesisBlock := genesis.ToBlock()
 	gaspool := new(core.GasPool).AddGas(genesisBlock.GasLimit())
 
-	//TODO: fill some mock backend...
-	var backend ethapi.Backend
-
 	return &testContext{
 		t:            tb.t,
 		genesisAlloc: tb.genesisAlloc,
-		chainContext: ethapi.NewChainContext(context.TODO(), backend),
 		genesis:      genesis,
 		genesisBlock: genesisBlock,
 		gaspool:      gaspool,
@@ -108,6 +120,30 @@ func (tt *testContextBuilder) withCode(addr string, code []byte, balance int64)
 	return tt
 }
 
+// add a paymaster with the given code at DEFAULT_PAYMASTER
+func (tt *testContextBuilder) withPaymaster(code []byte, balance int64) *testContextBuilder {
+	return tt.withCode(DEFAULT_PAYMASTER.Hex(), code, balance)
+}
+
+// add a deployer at DEPLOYER, deploying an account with the given code using CREATE2.
+// the counterfactual account (see create2_addr) is funded with the given balance.
+func (tt *testContextBuilder) withDeployer(accountCode []byte, balance int64) *testContextBuilder {
+	sender := create2_addr(DEPLOYER, accountCode)
+	return tt.withCode(DEPLOYER.Hex(), createCode(create2(accountCode), returnWithData([]byte{})), 0).
+		withCode(sender.Hex(), []byte{}, balance)
+}
+
+// add the RIP-7712 NonceManager at its predeployed address
+func (tt *testContextBuilder) withNonceManager() *testContextBuilder {
+	return tt.withCode(core.AA_NONCE_MANAGER.Hex(), nonceManagerCode(), 0)
+}
+
+// add the RIP-7712 NonceManager at the given address, configured as the chain's one
+func (tt *testContextBuilder) withNonceManagerAt(addr common.Address) *testContextBuilder {
+	tt.nonceManager = &addr
+	return tt.withCode(addr.Hex(), nonceManagerCode(), 0)
+}
+
 // generate a push opcode and its following constant value
 func push(n int) []byte {
 	if n < 0 {
@@ -156,8 +192,54 @@ func returnWithData(data []byte) []byte {
 	return ret
 }
 
+// create the code of an account accepting any transaction
 func createAccountCode() []byte {
-	return nil
+	return acceptAccountCode(0, 0)
+}
+
+// create the code of an account rejecting the signature of any transaction
+func sigFailAccountCode() []byte {
+	calldata, err := core.Rip7560Abi.Pack("sigFailAccount", common.Big0, common.Big0)
+	if err != nil {
+		panic(err)
+	}
+	return createCode(callEntryPoint(calldata), vm.STOP)
+}
+
+// create the code of a paymaster sponsoring any transaction within the given validity range.
+// a non-empty context makes the paymaster ask for a postOp frame, running postOpCode.
+func paymasterCode(validAfter, validUntil uint64, context []byte, postOpCode []byte) []byte {
+	calldata, err := core.Rip7560Abi.Pack("acceptPaymaster", new(big.Int).SetUint64(validAfter), new(big.Int).SetUint64(validUntil), context)
+	if err != nil {
+		panic(err)
+	}
+	validation := createCode(callEntryPoint(calldata), vm.STOP)
+	// dispatch on the method selector: postPaymasterTransaction runs the postOp code
+	dispatch := createCode(
+		vm.PUSH0, vm.CALLDATALOAD, vm.PUSH1, byte(224), vm.SHR,
+		vm.PUSH4, core.Rip7560Abi.Methods["postPaymasterTransaction"].ID, vm.EQ,
+	)
+	postOpDest := len(dispatch) + 4 + len(validation)
+	return createCode(dispatch, vm.PUSH2, uint16(postOpDest), vm.JUMPI, validation, vm.JUMPDEST, postOpCode)
+}
+
+// create the code of a minimal RIP-7712 NonceManager.
+// it keeps a sequence number per (sender, nonceKey), and reverts unless the validated
+// nonce matches it, incrementing it otherwise. reading the current nonce is not supported.
+func nonceManagerCode() []byte {
+	// calldata: sender (20 bytes) | nonceKey (24 bytes) | nonce (8 bytes)
+	check := createCode(
+		vm.PUSH1, byte(44), vm.PUSH0, vm.PUSH0, vm.CALLDATACOPY,
+		vm.PUSH1, byte(44), vm.PUSH0, vm.KECCAK256,
+		vm.DUP1, vm.SLOAD,
+		vm.PUSH1, byte(44), vm.CALLDATALOAD, vm.PUSH1, byte(192), vm.SHR,
+		vm.EQ,
+	)
+	revert := createCode(vm.PUSH0, vm.PUSH0, vm.REVERT)
+	return createCode(
+		check, vm.PUSH1, byte(len(check)+3+len(revert)), vm.JUMPI, revert,
+		vm.JUMPDEST, vm.DUP1, vm.SLOAD, vm.PUSH1, byte(1), vm.ADD, vm.SWAP1, vm.SSTORE, vm.STOP,
+	)
 }
 
 // create EVM code from OpCode, byte and []bytes
@@ -0,0 +1,194 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"math/big"
+)
+
+// Scenario is a block of RIP-7560 transactions executed on top of a pre-state. The
+// scenarios are exported so that consensus fixtures can be generated from them for
+// other execution clients implementing RIP-7560.
+type Scenario struct {
+	Name string
+	Pre  types.GenesisAlloc
+	Txs  []*types.Rip7560AccountAbstractionTx
+}
+
+// callEntryPoint returns the code calling the EntryPoint with the given calldata.
+func callEntryPoint(calldata []byte) []byte {
+	return createCode(
+		copyToMemory(calldata, 0),
+		vm.PUSH0, vm.PUSH0, vm.PUSH2, uint16(len(calldata)), vm.PUSH0, vm.PUSH0,
+		vm.PUSH20, core.AA_ENTRY_POINT, vm.GAS, vm.CALL, vm.POP,
+	)
+}
+
+// acceptAccountCode returns the code of an account accepting any transaction within
+// the given validity range.
+func acceptAccountCode(validAfter, validUntil uint64) []byte {
+	calldata, err := core.Rip7560Abi.Pack("acceptAccount", new(big.Int).SetUint64(validAfter), new(big.Int).SetUint64(validUntil))
+	if err != nil {
+		panic(err)
+	}
+	return createCode(callEntryPoint(calldata), vm.STOP)
+}
+
+// revertingExecutionAccountCode returns the code of an account accepting any
+// transaction, but reverting its execution when called without execution data.
+func revertingExecutionAccountCode() []byte {
+	return createCode(
+		vm.CALLDATASIZE, vm.PUSH1, byte(7), vm.JUMPI,
+		vm.PUSH0, vm.PUSH0, vm.REVERT,
+		vm.JUMPDEST, acceptAccountCode(0, 0),
+	)
+}
+
+// acceptPaymasterCode returns the code of a paymaster sponsoring any transaction.
+func acceptPaymasterCode() []byte {
+	calldata, err := core.Rip7560Abi.Pack("acceptPaymaster", common.Big0, common.Big0, []byte{})
+	if err != nil {
+		panic(err)
+	}
+	return createCode(callEntryPoint(calldata), vm.STOP)
+}
+
+// Scenarios returns the RIP-7560 consensus scenarios.
+func Scenarios() []*Scenario {
+	var (
+		sender    = common.HexToAddress(DEFAULT_SENDER)
+		second    = common.HexToAddress("0x5555555555666666666677777777778888888888")
+		paymaster = common.HexToAddress("0xaaaaaaaaaabbbbbbbbbbccccccccccdddddddddd")
+		deployer  = common.HexToAddress("0xddddddddddeeeeeeeeeeddddddddddeeeeeeeeee")
+		balance   = big.NewInt(DEFAULT_BALANCE)
+
+		accountCode  = acceptAccountCode(0, 0)
+		deployedCode = acceptAccountCode(0, 0)
+		deployed     = create2_addr(deployer, deployedCode)
+	)
+	tx := func(from common.Address, nonce uint64, modify ...func(*types.Rip7560AccountAbstractionTx)) *types.Rip7560AccountAbstractionTx {
+		tx := &types.Rip7560AccountAbstractionTx{
+			Sender:             &from,
+			NonceKey:           new(big.Int),
+			Nonce:              nonce,
+			ValidationGasLimit: 1_000_000,
+			Gas:                100_000,
+			GasTipCap:          big.NewInt(1),
+			GasFeeCap:          big.NewInt(1_000_000_000),
+			ExecutionData:      []byte{1, 2, 3},
+		}
+		for _, fn := range modify {
+			fn(tx)
+		}
+		return tx
+	}
+	return []*Scenario{
+		{
+			Name: "validation_ok",
+			Pre:  types.GenesisAlloc{sender: {Code: accountCode, Balance: balance}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 0)},
+		},
+		{
+			Name: "validation_ok_no_tip",
+			Pre:  types.GenesisAlloc{sender: {Code: accountCode, Balance: balance}},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(sender, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.GasTipCap = new(big.Int)
+			})},
+		},
+		{
+			Name: "validation_out_of_gas",
+			Pre:  types.GenesisAlloc{sender: {Code: accountCode, Balance: balance}},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(sender, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.ValidationGasLimit = 1
+			})},
+		},
+		{
+			Name: "validation_no_balance",
+			Pre:  types.GenesisAlloc{sender: {Code: accountCode, Balance: new(big.Int)}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 0)},
+		},
+		{
+			Name: "validation_nonce_too_high",
+			Pre:  types.GenesisAlloc{sender: {Code: accountCode, Balance: balance}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 1)},
+		},
+		{
+			Name: "validation_account_revert",
+			Pre:  types.GenesisAlloc{sender: {Code: revertWithData([]byte{1, 2, 3}), Balance: balance}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 0)},
+		},
+		{
+			Name: "validation_no_callback",
+			Pre:  types.GenesisAlloc{sender: {Code: returnWithData([]byte{}), Balance: balance}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 0)},
+		},
+		{
+			Name: "validation_expired",
+			Pre:  types.GenesisAlloc{sender: {Code: acceptAccountCode(0, 1), Balance: balance}},
+			Txs:  []*types.Rip7560AccountAbstractionTx{tx(sender, 0)},
+		},
+		{
+			Name: "execution_revert",
+			Pre:  types.GenesisAlloc{sender: {Code: revertingExecutionAccountCode(), Balance: balance}},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(sender, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.ExecutionData = nil
+			})},
+		},
+		{
+			Name: "deployer_ok",
+			Pre: types.GenesisAlloc{
+				deployed: {Balance: balance},
+				deployer: {Code: createCode(create2(deployedCode), returnWithData([]byte{})), Balance: new(big.Int)},
+			},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(deployed, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.Deployer = &deployer
+			})},
+		},
+		{
+			Name: "deployer_revert",
+			Pre: types.GenesisAlloc{
+				deployed: {Balance: balance},
+				deployer: {Code: revertWithData([]byte{}), Balance: new(big.Int)},
+			},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(deployed, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.Deployer = &deployer
+			})},
+		},
+		{
+			Name: "paymaster_ok",
+			Pre: types.GenesisAlloc{
+				sender:    {Code: accountCode, Balance: new(big.Int)},
+				paymaster: {Code: acceptPaymasterCode(), Balance: balance},
+			},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(sender, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.Paymaster = &paymaster
+				tx.PaymasterValidationGasLimit = 1_000_000
+			})},
+		},
+		{
+			Name: "paymaster_revert",
+			Pre: types.GenesisAlloc{
+				sender:    {Code: accountCode, Balance: new(big.Int)},
+				paymaster: {Code: revertWithData([]byte{}), Balance: balance},
+			},
+			Txs: []*types.Rip7560AccountAbstractionTx{tx(sender, 0, func(tx *types.Rip7560AccountAbstractionTx) {
+				tx.Paymaster = &paymaster
+				tx.PaymasterValidationGasLimit = 1_000_000
+			})},
+		},
+		{
+			Name: "multiple_transactions",
+			Pre: types.GenesisAlloc{
+				sender: {Code: accountCode, Balance: balance},
+				second: {Code: revertingExecutionAccountCode(), Balance: balance},
+			},
+			Txs: []*types.Rip7560AccountAbstractionTx{
+				tx(sender, 0),
+				tx(second, 0, func(tx *types.Rip7560AccountAbstractionTx) { tx.ExecutionData = nil }),
+				tx(sender, 1),
+			},
+		},
+	}
+}
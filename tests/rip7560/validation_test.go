@@ -9,69 +9,58 @@ import (
 	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
-func TestPackValidationData(t *testing.T) {
-	// --------------- after 6bytes     before 6 bytes   magic 20 bytes
-	validationData := "000000000002" + "000000000001" + "0000000000000000000000000000000000001234"
-	packed, _ := new(big.Int).SetString(validationData, 16)
-	assert.Equal(t, packed.Text(16), new(big.Int).SetBytes(core.PackValidationData(0x1234, 1, 2)).Text(16))
-}
-
-// func TestUnpackValidationData(t *testing.T) {
-// 	packed := core.PackValidationData(0xdead, 0xcafe, 0xface)
-// 	magic, until, after := core.UnpackValidationData(packed)
-// 	assert.Equal(t, []uint64{0xdead, 0xcafe, 0xface}, []uint64{magic, until, after})
-// }
-
 func TestValidationFailure_OOG(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
 		ValidationGasLimit: uint64(1),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "out of gas")
+	}, "validation phase failed with exception: insufficient ValidationGasLimit(1) to cover PreTransactionGasCost(15000)")
 }
 
 func TestValidationFailure_no_balance(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), 1), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "insufficient funds for gas * price + value: address 0x1111111111222222222233333333334444444444 have 1 want 1000000000")
+	}, "validation phase failed with exception: insufficient funds for gas * price + value: RIP-7560 address 0x1111111111222222222233333333334444444444 have 1 want 1015000000000000")
 }
 
 func TestValidationFailure_sigerror(t *testing.T) {
-	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, returnWithData(core.PackValidationData(core.SigFailAccountMethodSig, 0, 0)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+	// sigFailAccount is only accepted when estimating gas
+	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, sigFailAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "account signature error")
+	}, "validation phase failed with exception: unable to decode acceptAccount: got wrong method sigFailAccount")
 }
 
 func TestValidationFailure_validAfter(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
-		returnWithData(core.PackValidationData(core.AcceptAccountMethodSig, 300, 200)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		acceptAccountCode(300, 400), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "RIP-7560 transaction validity not reached yet")
+	}, "validation phase failed with exception: RIP-7560 transaction validity not reached yet")
 }
 
 func TestValidationFailure_validUntil(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
-		returnWithData(core.PackValidationData(core.AcceptAccountMethodSig, 1, 0)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		acceptAccountCode(0, 1), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "RIP-7560 transaction validity expired")
+	}, "validation phase failed with exception: RIP-7560 transaction validity expired")
 }
 
 func TestValidation_ok(t *testing.T) {
 
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
 	}, "ok")
 }
@@ -79,7 +68,7 @@ func TestValidation_ok(t *testing.T) {
 func TestValidation_ok_paid(t *testing.T) {
 
 	aatx := types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
 	}
 	tb := newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE)
@@ -92,7 +81,7 @@ func TestValidation_ok_paid(t *testing.T) {
 func TestValidationFailure_account_nonce(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
 		Nonce:              1234,
-		ValidationGasLimit: uint64(1000000000),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
 	}, "nonce too high: address 0x1111111111222222222233333333334444444444, tx: 1234 state: 0")
 }
@@ -100,9 +89,9 @@ func TestValidationFailure_account_nonce(t *testing.T) {
 func TestValidationFailure_account_revert(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
 		createCode(vm.PUSH0, vm.DUP1, vm.REVERT), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "execution reverted")
+	}, "validation phase failed in contract account with exception: execution reverted")
 }
 
 func TestValidationFailure_account_revert_with_reason(t *testing.T) {
@@ -110,61 +99,239 @@ func TestValidationFailure_account_revert_with_reason(t *testing.T) {
 	reason := hexutils.HexToBytes("0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000568656c6c6f000000000000000000000000000000000000000000000000000000")
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
 		revertWithData(reason), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "execution reverted")
+	}, "validation phase failed in contract account with exception: execution reverted")
 }
 
-func TestValidationFailure_account_wrong_return_length(t *testing.T) {
+func TestValidationFailure_account_no_callback(t *testing.T) {
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
-		returnWithData([]byte{1, 2, 3}), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		returnWithData([]byte{}), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "invalid account return data length")
+	}, "validation phase failed with exception: account validation did not call the EntryPoint 'acceptAccount' callback")
 }
 
-func TestValidationFailure_account_no_return_value(t *testing.T) {
+func TestValidationFailure_account_wrong_callback(t *testing.T) {
+	// the account accepts the transaction as a paymaster would
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
-		returnWithData([]byte{}), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		paymasterCode(0, 0, nil, nil), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "invalid account return data length")
+	}, "validation phase failed with exception: unable to decode acceptAccount: got wrong method acceptPaymaster")
 }
 
-func TestValidationFailure_account_wrong_return_value(t *testing.T) {
-	// create buffer of 32 byte array
+func TestValidationFailure_account_repeated_callback(t *testing.T) {
+	calldata, _ := core.Rip7560Abi.Pack("acceptAccount", common.Big0, common.Big0)
 	handleTransaction(newTestContextBuilder(t).withCode(DEFAULT_SENDER,
-		returnWithData(make([]byte, 32)),
-		DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
-		ValidationGasLimit: uint64(1000000000),
+		createCode(callEntryPoint(calldata), callEntryPoint(calldata)), DEFAULT_BALANCE), types.Rip7560AccountAbstractionTx{
+		ValidationGasLimit: uint64(1000000),
 		GasFeeCap:          big.NewInt(1000000000),
-	}, "account did not return correct MAGIC_VALUE")
+	}, "validation phase failed with exception: illegal repeated call to the EntryPoint callback")
 }
 
-func handleTransaction(tb *testContextBuilder, aatx types.Rip7560AccountAbstractionTx, expectedErr string) {
+func TestValidation_2d_nonce(t *testing.T) {
+	aatx := types.Rip7560AccountAbstractionTx{
+		NonceKey:           big.NewInt(1),
+		ValidationGasLimit: uint64(1000000),
+		GasFeeCap:          big.NewInt(1000000000),
+	}
+	second := aatx
+	second.Nonce = 1
+	// the same sequence number under another key is independent
+	otherKey := aatx
+	otherKey.NonceKey = big.NewInt(2)
+
+	receipts, tracer, err := runTransactions(newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withNonceManager(), aatx, second, otherKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(receipts))
+	tracer.AssertPhases(t,
+		PhaseNonce, PhaseAccount, PhaseExecution,
+		PhaseNonce, PhaseAccount, PhaseExecution,
+		PhaseNonce, PhaseAccount, PhaseExecution,
+	)
+}
+
+func TestValidation_2d_nonce_configured_manager(t *testing.T) {
+	aatx := types.Rip7560AccountAbstractionTx{
+		NonceKey:           big.NewInt(1),
+		ValidationGasLimit: uint64(1000000),
+		GasFeeCap:          big.NewInt(1000000000),
+	}
+	second := aatx
+	second.Nonce = 1
+
+	// nothing is deployed at the default NonceManager address
+	tb := newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withNonceManagerAt(common.HexToAddress("0x7712000000000000000000000000000000007712"))
+	receipts, _, err := runTransactions(tb, aatx, second)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(receipts))
+
+	_, _, err = runTransactions(tb, aatx, aatx)
+	assert.EqualError(t, err, "validation phase failed in contract NonceManager with exception: RIP-7712 nonce validation failed: execution reverted")
+}
+
+func TestValidationFailure_2d_nonce_replay(t *testing.T) {
+	aatx := types.Rip7560AccountAbstractionTx{
+		NonceKey:           big.NewInt(1),
+		ValidationGasLimit: uint64(1000000),
+		GasFeeCap:          big.NewInt(1000000000),
+	}
+	_, _, err := runTransactions(newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withNonceManager(), aatx, aatx)
+	assert.EqualError(t, err, "validation phase failed in contract NonceManager with exception: RIP-7712 nonce validation failed: execution reverted")
+}
+
+func TestValidationFailure_2d_nonce_too_high(t *testing.T) {
+	handleTransaction(newTestContextBuilder(t).
+		withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+		withNonceManager(), types.Rip7560AccountAbstractionTx{
+		NonceKey:           big.NewInt(1),
+		Nonce:              1,
+		ValidationGasLimit: uint64(1000000),
+		GasFeeCap:          big.NewInt(1000000000),
+	}, "validation phase failed in contract NonceManager with exception: RIP-7712 nonce validation failed: execution reverted")
+}
+
+// run the given transactions in a single block on top of the test context's genesis
+func runTransactions(tb *testContextBuilder, aatxs ...types.Rip7560AccountAbstractionTx) (types.Receipts, *FrameTracer, error) {
 	t := tb.build()
-	if aatx.Sender == nil {
-		//pre-deployed sender account
-		Sender := common.HexToAddress(DEFAULT_SENDER)
-		aatx.Sender = &Sender
+	txs := make([]*types.Transaction, len(aatxs))
+	for i, aatx := range aatxs {
+		if aatx.Sender == nil {
+			//pre-deployed sender account
+			Sender := common.HexToAddress(DEFAULT_SENDER)
+			aatx.Sender = &Sender
+		}
+		if aatx.ChainID == nil {
+			aatx.ChainID = t.genesis.Config.ChainID
+		}
+		txs[i] = types.NewTx(&aatx)
 	}
-	tx := types.NewTx(&aatx)
 
 	var state = tests.MakePreState(rawdb.NewMemoryDatabase(), t.genesisAlloc, false, rawdb.HashScheme)
 	defer state.Close()
 
-	state.StateDB.SetTxContext(tx.Hash(), 0)
-	_, _, _, err := core.HandleRip7560Transactions([]*types.Transaction{tx}, 0, state.StateDB, &common.Address{}, t.genesisBlock.Header(), t.gaspool, t.genesis.Config, t.chainContext, vm.Config{})
+	var (
+		tracer  = NewFrameTracer()
+		gasUsed uint64
+	)
+	_, receipts, _, _, err := core.HandleRip7560Transactions(txs, 0, state.StateDB, &common.Address{}, t.genesisBlock.Header(), t.gaspool, t.genesis.Config, nil, vm.Config{Tracer: tracer.Hooks()}, false, &gasUsed)
+	return receipts, tracer, err
+}
+
+func handleTransaction(tb *testContextBuilder, aatx types.Rip7560AccountAbstractionTx, expectedErr string) {
+	_, _, err := runTransactions(tb, aatx)
 
 	errStr := "ok"
 	if err != nil {
 		errStr = err.Error()
 	}
-	assert.Equal(t.t, expectedErr, errStr)
+	assert.Equal(tb.t, expectedErr, errStr)
 }
 
-//test failure on non-rip7560
+func TestValidationFailure_frames_debug_info(t *testing.T) {
+	revertingAccount := revertWithData([]byte{0xde, 0xad})
+	deployed := create2_addr(DEPLOYER, revertingAccount)
+	cases := []struct {
+		name     string
+		tb       *testContextBuilder
+		aatx     types.Rip7560AccountAbstractionTx
+		entities []string
+		revert   []byte // return data of the last, reverted, frame
+	}{
+		{
+			name: "account reverted after deployment",
+			tb:   newTestContextBuilder(t).withDeployer(revertingAccount, DEFAULT_BALANCE),
+			aatx: types.Rip7560AccountAbstractionTx{
+				Sender:             &deployed,
+				Deployer:           &DEPLOYER,
+				ValidationGasLimit: 1000000,
+				GasFeeCap:          big.NewInt(1000000000),
+			},
+			entities: []string{"deployer", "account"},
+			revert:   []byte{0xde, 0xad},
+		},
+		{
+			name: "paymaster reverted after 2d nonce",
+			tb: newTestContextBuilder(t).
+				withCode(DEFAULT_SENDER, createAccountCode(), DEFAULT_BALANCE).
+				withPaymaster(revertWithData([]byte{0xbe, 0xef}), DEFAULT_BALANCE).
+				withNonceManager(),
+			aatx: types.Rip7560AccountAbstractionTx{
+				NonceKey:                    big.NewInt(1),
+				ValidationGasLimit:          1000000,
+				Paymaster:                   &DEFAULT_PAYMASTER,
+				PaymasterValidationGasLimit: 1000000,
+				GasFeeCap:                   big.NewInt(1000000000),
+			},
+			entities: []string{"nonce", "account", "paymaster"},
+			revert:   []byte{0xbe, 0xef},
+		},
+	}
+	for _, tt := range cases {
+		ctx := tt.tb.build()
+		if tt.aatx.Sender == nil {
+			sender := common.HexToAddress(DEFAULT_SENDER)
+			tt.aatx.Sender = &sender
+		}
+		tt.aatx.ChainID = ctx.genesis.Config.ChainID
+		tx := types.NewTx(&tt.aatx)
 
-//IntrinsicGas: for validation frame, should return the max possible gas.
-// - execution should be "free" (and refund the excess)
-// geth increment nonce before "call" our validation frame. (in ApplyMessage)
+		state := tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+		var gasUsed uint64
+		_, _, infos, _, err := core.HandleRip7560Transactions([]*types.Transaction{tx}, 0, state.StateDB, &common.Address{}, ctx.genesisBlock.Header(), ctx.gaspool, ctx.genesis.Config, nil, vm.Config{}, true, &gasUsed)
+		state.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to handle transaction: %v", tt.name, err)
+		}
+		if len(infos) != 1 {
+			t.Fatalf("%s: debug info count mismatch: have %d, want 1", tt.name, len(infos))
+		}
+		frames := infos[0].Frames
+		if len(frames) != len(tt.entities) {
+			t.Fatalf("%s: frame count mismatch: have %d, want %d", tt.name, len(frames), len(tt.entities))
+		}
+		for i, frame := range frames {
+			last := i == len(frames)-1
+			if frame.Entity != tt.entities[i] || frame.Reverted != last {
+				t.Errorf("%s: frame %d mismatch: have %s (reverted %v), want %s (reverted %v)", tt.name, i, frame.Entity, frame.Reverted, tt.entities[i], last)
+			}
+		}
+		assert.Equal(t, tt.revert, []byte(frames[len(frames)-1].ReturnData), tt.name)
+	}
+}
+
+func TestValidationFailure_timeout(t *testing.T) {
+	// an account looping until it runs out of gas, which takes far longer than the timeout
+	looping := createCode(vm.JUMPDEST, vm.PUSH0, vm.JUMP)
+	for _, timeout := range []time.Duration{0, time.Millisecond} {
+		ctx := newTestContextBuilder(t).withCode(DEFAULT_SENDER, looping, DEFAULT_BALANCE).build()
+		sender := common.HexToAddress(DEFAULT_SENDER)
+		tx := types.NewTx(&types.Rip7560AccountAbstractionTx{
+			ChainID:            ctx.genesis.Config.ChainID,
+			Sender:             &sender,
+			ValidationGasLimit: 10_000_000,
+			GasFeeCap:          big.NewInt(1000000000),
+		})
+		state := tests.MakePreState(rawdb.NewMemoryDatabase(), ctx.genesisAlloc, false, rawdb.HashScheme)
+		var gasUsed uint64
+		_, _, infos, _, err := core.HandleRip7560Transactions([]*types.Transaction{tx}, 0, state.StateDB, &common.Address{}, ctx.genesisBlock.Header(), ctx.gaspool, ctx.genesis.Config, nil, vm.Config{Rip7560ValidationTimeout: timeout}, true, &gasUsed)
+		state.Close()
+		if err != nil {
+			t.Fatalf("timeout %v: failed to handle transaction: %v", timeout, err)
+		}
+		if len(infos) != 1 {
+			t.Fatalf("timeout %v: debug info count mismatch: have %d, want 1", timeout, len(infos))
+		}
+		if have, want := infos[0].TimedOut, timeout != 0; have != want {
+			t.Errorf("timeout %v: timed out mismatch: have %v, want %v", timeout, have, want)
+		}
+		assert.Equal(t, "account", infos[0].RevertEntityName, "timeout %v", timeout)
+	}
+}
@@ -0,0 +1,80 @@
+package rip7560
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"math/big"
+	"testing"
+)
+
+// TestWitnessCrossValidation imports a block running every RIP-7560 frame with witness
+// collection enabled, so the block is re-executed statelessly from its witness alone.
+func TestWitnessCrossValidation(t *testing.T) {
+	var (
+		// the execution frames and the postOp frame write to storage
+		accountCode = createCode(vm.PUSH1, byte(1), vm.PUSH0, vm.CALLDATALOAD, vm.SSTORE, createAccountCode())
+		deployed    = create2_addr(DEPLOYER, acceptAccountCode(0, 0))
+		sponsored   = common.HexToAddress("0x7560000000000000000000000000000000000001")
+		ctx         = newTestContextBuilder(t).
+				withCode(DEFAULT_SENDER, accountCode, DEFAULT_BALANCE).
+				withCode(sponsored.Hex(), createAccountCode(), 0).
+				withPaymaster(paymasterCode(0, 0, []byte{1}, createCode(vm.PUSH1, byte(1), vm.PUSH1, byte(1), vm.SSTORE, vm.STOP)), DEFAULT_BALANCE).
+				withDeployer(acceptAccountCode(0, 0), DEFAULT_BALANCE).
+				withNonceManager().
+				build()
+		sender = common.HexToAddress(DEFAULT_SENDER)
+		aatxs  = []*types.Rip7560AccountAbstractionTx{{
+			Sender:        &sender,
+			ExecutionData: common.LeftPadBytes([]byte{0x42}, 32),
+		}, {
+			Sender:                      &sponsored,
+			Paymaster:                   &DEFAULT_PAYMASTER,
+			PaymasterValidationGasLimit: 1_000_000,
+			PostOpGas:                   100_000,
+		}, {
+			Sender:   &deployed,
+			Deployer: &DEPLOYER,
+			NonceKey: big.NewInt(1),
+		}}
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(ctx.genesis, beacon.New(ethash.NewFaker()), 1, func(i int, b *core.BlockGen) {
+		b.AddTx(types.NewTx(&types.DepositTx{
+			SourceHash: common.BigToHash(b.Number()),
+			From:       common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"),
+			To:         &types.L1BlockAddr,
+			Gas:        1_000_000,
+			Data:       make([]byte, 4+32*8),
+		}))
+		for _, aatx := range aatxs {
+			aatx.ChainID = ctx.genesis.Config.ChainID
+			aatx.ValidationGasLimit = 1_000_000
+			aatx.Gas = 100_000
+			aatx.GasFeeCap = big.NewInt(1_000_000_000)
+			aatx.GasTipCap = big.NewInt(1)
+			b.AddRip7560Tx(types.NewTx(aatx))
+		}
+	})
+	if have := len(blocks[0].Transactions()); have != len(aatxs)+1 {
+		t.Fatalf("unexpected number of transactions in the block: have %d, want %d", have, len(aatxs)+1)
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, ctx.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{EnableWitnessCollection: true}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// the import fails if the stateless execution diverges from the stateful one
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import the block: %v", err)
+	}
+	for i, receipt := range chain.GetReceiptsByHash(blocks[0].Hash())[1:] {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("transaction %d failed", i)
+		}
+	}
+}